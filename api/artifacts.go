@@ -0,0 +1,657 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+// Harbor 2.x replaced the tags of v1 API by artifacts, which carry tags,
+// labels and accessories (signatures, SBOMs, ...) of their own.
+
+func init() {
+	utils.Parser.AddCommand("artifacts_list",
+		"List artifacts of a repository. (v2.0 API)",
+		"This endpoint lists the artifacts under the repository specified by project and repository name.",
+		&ArtifactsList{})
+	utils.Parser.AddCommand("artifact_get",
+		"Get an artifact by tag or digest. (v2.0 API)",
+		"This endpoint gets the artifact specified by the reference (tag or digest) under the project and repository.",
+		&ArtifactGet{})
+	utils.Parser.AddCommand("artifact_del",
+		"Delete an artifact by tag or digest. (v2.0 API)",
+		"This endpoint deletes the artifact specified by the reference (tag or digest), with all its tags.",
+		&ArtifactDel{})
+	utils.Parser.AddCommand("artifact_copy",
+		"Copy an artifact into another repository. (v2.0 API)",
+		"This endpoint copies the artifact --from, 'project/repo:tag' or 'project/repo@digest', into the repository specified by project and repository name, server-side and with the tag it is referenced by, e.g. to promote an image from dev/app to prod/app without pulling and pushing it. Use tag_retag to copy it under another tag.",
+		&ArtifactCopy{})
+	utils.Parser.AddCommand("artifact_tags_list",
+		"List tags of an artifact. (v2.0 API)",
+		"This endpoint lists the tags of the artifact specified by the reference.",
+		&ArtifactTagsList{})
+	utils.Parser.AddCommand("artifact_tag_create",
+		"Create a tag for an artifact. (v2.0 API)",
+		"This endpoint creates a tag for the artifact specified by the reference.",
+		&ArtifactTagCreate{})
+	utils.Parser.AddCommand("artifact_tag_del",
+		"Delete a tag of an artifact. (v2.0 API)",
+		"This endpoint deletes the tag of the artifact specified by the reference, the artifact itself is kept.",
+		&ArtifactTagDel{})
+	utils.Parser.AddCommand("artifact_label_add",
+		"Add a label to an artifact. (v2.0 API)",
+		"This endpoint adds an already existing label (global or project specific) to the artifact.",
+		&ArtifactLabelAdd{})
+	utils.Parser.AddCommand("artifact_label_del",
+		"Delete a label from an artifact. (v2.0 API)",
+		"This endpoint deletes the label from the artifact specified by the reference.",
+		&ArtifactLabelDel{})
+	utils.Parser.AddCommand("artifact_accessories_list",
+		"List accessories (signatures, SBOMs, ...) of an artifact. (v2.0 API, Harbor v2.8+)",
+		"This endpoint lists the accessories attached to the artifact specified by the reference, of one type only with --type, e.g. signature.cosign for cosign signatures or harbor.sbom for SBOMs.",
+		&ArtifactAccessoriesList{})
+	utils.Parser.AddCommand("artifact_sbom_get",
+		"Download the SBOM of an artifact. (v2.0 API, Harbor v2.11+)",
+		"This endpoint downloads the content of the SBOM attached to the artifact specified by the reference, the latest one if several are, to stdout or to --file.",
+		&ArtifactSBOMGet{})
+
+	utils.ResponseModel("artifacts_list", model.Artifact{})
+	utils.ResponseModel("artifact_get", model.Artifact{})
+	utils.ResponseModel("artifact_tags_list", model.ArtifactTag{})
+	utils.ResponseModel("artifact_accessories_list", model.Accessory{})
+}
+
+// ArtifactRef identifies an artifact of v2.0 API.
+type ArtifactRef struct {
+	Project   string `short:"p" long:"project" description:"(REQUIRED) The name of the project." required:"yes"`
+	RepoName  string `short:"r" long:"repo_name" description:"(REQUIRED) The name of the repository, without the project part." required:"yes"`
+	Reference string `short:"a" long:"reference" description:"(REQUIRED) The tag or the digest of the artifact." required:"yes"`
+}
+
+// repoURL returns the URL of the repository of the artifact.
+func (x *ArtifactRef) repoURL(c *harbor.Client) string {
+	return c.URL("/api/v2.0/projects") + "/" + url.PathEscape(x.Project) +
+		"/repositories/" + utils.RepoPathV2(x.RepoName)
+}
+
+// artifactURL returns the URL of the artifact.
+func (x *ArtifactRef) artifactURL(c *harbor.Client) string {
+	return x.repoURL(c) + "/artifacts/" + utils.TagPath(x.Reference)
+}
+
+// ArtifactWith selects the extra attributes returned with artifacts.
+type ArtifactWith struct {
+	WithTag          bool `long:"with_tag" description:"Return the tags of the artifacts."`
+	WithLabel        bool `long:"with_label" description:"Return the labels of the artifacts."`
+	WithScanOverview bool `long:"with_scan_overview" description:"Return the scan overview of the artifacts."`
+	WithAccessory    bool `long:"with_accessory" description:"Return the accessories of the artifacts. (Harbor v2.8+)"`
+}
+
+// query returns the with_* query parameters.
+func (x *ArtifactWith) query() string {
+	return "with_tag=" + strconv.FormatBool(x.WithTag) +
+		"&with_label=" + strconv.FormatBool(x.WithLabel) +
+		"&with_scan_overview=" + strconv.FormatBool(x.WithScanOverview) +
+		"&with_accessory=" + strconv.FormatBool(x.WithAccessory)
+}
+
+// TimeWindow selects the artifacts, or the tags, listed by push and pull
+// time.
+type TimeWindow struct {
+	PushedSince  string `long:"pushed-since" description:"List only those pushed since this time: a date (2006-01-02), an RFC 3339 time, or an age like 7d, 2w or 36h."`
+	PushedBefore string `long:"pushed-before" description:"List only those pushed before this time, see --pushed-since."`
+	PulledSince  string `long:"pulled-since" description:"List only those pulled since this time, see --pushed-since."`
+}
+
+// timeWindow is a parsed TimeWindow, zero times are unset bounds.
+type timeWindow struct {
+	pushedSince, pushedBefore, pulledSince time.Time
+}
+
+// window parses the flags, it returns nil if none is set.
+func (x *TimeWindow) window(now time.Time) (*timeWindow, error) {
+	if x.PushedSince == "" && x.PushedBefore == "" && x.PulledSince == "" {
+		return nil, nil
+	}
+	w := &timeWindow{}
+	for _, f := range []struct {
+		name, value string
+		t           *time.Time
+	}{
+		{"--pushed-since", x.PushedSince, &w.pushedSince},
+		{"--pushed-before", x.PushedBefore, &w.pushedBefore},
+		{"--pulled-since", x.PulledSince, &w.pulledSince},
+	} {
+		if f.value == "" {
+			continue
+		}
+		t, err := parseWindowTime(f.value, now)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", f.name, err)
+		}
+		*f.t = t
+	}
+	if !w.pushedSince.IsZero() && !w.pushedBefore.IsZero() && !w.pushedSince.Before(w.pushedBefore) {
+		return nil, errors.New("--pushed-since is not before --pushed-before")
+	}
+	return w, nil
+}
+
+// parseWindowTime parses a date, an RFC 3339 time, or an age of days
+// ("7d"), weeks ("2w") or of time.ParseDuration ("36h") before now.
+func parseWindowTime(s string, now time.Time) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	bad := fmt.Errorf("bad time %q, expected e.g. 2027-01-15, 2027-01-15T08:00:00Z, 7d, 2w or 36h", s)
+
+	// Exactly one unit: "7dw" or "7dd" is no age.
+	num, days := s, 0
+	switch {
+	case strings.HasSuffix(s, "d"):
+		num, days = strings.TrimSuffix(s, "d"), 1
+	case strings.HasSuffix(s, "w"):
+		num, days = strings.TrimSuffix(s, "w"), 7
+	}
+	if days > 0 {
+		n, err := strconv.Atoi(num)
+		if err != nil || n <= 0 {
+			return time.Time{}, bad
+		}
+		return now.AddDate(0, 0, -days*n), nil
+	}
+	if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, bad
+}
+
+// query returns the window as range queries of the q parameter, e.g.
+// push_time=[2027-01-08 00:00:00~].
+func (w *timeWindow) query() string {
+	const layout = "2006-01-02 15:04:05"
+	bound := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format(layout)
+	}
+
+	var q []string
+	if !w.pushedSince.IsZero() || !w.pushedBefore.IsZero() {
+		q = append(q, "push_time=["+bound(w.pushedSince)+"~"+bound(w.pushedBefore)+"]")
+	}
+	if !w.pulledSince.IsZero() {
+		q = append(q, "pull_time=["+bound(w.pulledSince)+"~]")
+	}
+	return strings.Join(q, ",")
+}
+
+// keep reports whether the listed item, an artifact or a tag, is in the
+// window.
+func (w *timeWindow) keep(item json.RawMessage) (bool, error) {
+	var t struct {
+		PushTime model.Time `json:"push_time"`
+		PullTime model.Time `json:"pull_time"`
+	}
+	if err := json.Unmarshal(item, &t); err != nil {
+		return false, err
+	}
+	switch {
+	case !w.pushedSince.IsZero() && t.PushTime.Before(w.pushedSince),
+		!w.pushedBefore.IsZero() && !t.PushTime.Before(w.pushedBefore),
+		!w.pulledSince.IsZero() && (t.PullTime.IsZero() || t.PullTime.Before(w.pulledSince)):
+		return false, nil
+	}
+	return true, nil
+}
+
+// listInWindow lists the items of a list endpoint in the window w, nil for
+// no window, which keep accepts, nil for all, as listItems does: listURL
+// returns the URL of the endpoint with q as its q parameter, query being
+// the one of the user. The window is sent as range queries, or applied
+// client-side to the items listed by the Harbor versions rejecting them,
+// in which case the pages are those of the unfiltered list, as reported to
+// the progress callback.
+func listInWindow(c *harbor.Client, w *timeWindow, query string, listURL func(q string) string, keep func(json.RawMessage) (bool, error), count, all bool) (*harbor.Result, error) {
+	q := query
+	if w != nil {
+		if q != "" {
+			q += ","
+		}
+		q += w.query()
+	}
+	targetURL := listURL(q)
+
+	if !count {
+		c.Trace("==> GET", targetURL)
+	}
+	if w == nil {
+		return listItems(c, targetURL, keep, count, all)
+	}
+	res, err := c.DoStream(c.Get(targetURL))
+	if !harbor.IsBadRequest(err) {
+		if err != nil || (keep == nil && !all) {
+			return res, err
+		}
+		res.Close()
+		return listItems(c, targetURL, keep, count, all)
+	}
+
+	c.Status("warning: range queries rejected, filtering the list client-side")
+	targetURL = listURL(query)
+	if !count {
+		c.Trace("==> GET", targetURL)
+	}
+	return listItems(c, targetURL, keepAll(w.keep, keep), count, all)
+}
+
+// ArtifactsList holds the parameters of GetArtifacts.
+type ArtifactsList struct {
+	Project  string `short:"p" long:"project" description:"(REQUIRED) The name of the project." required:"yes"`
+	RepoName string `short:"r" long:"repo_name" description:"(REQUIRED) The name of the repository, without the project part." required:"yes"`
+	Query    string `short:"q" long:"query" description:"Query string to filter the artifacts, e.g. 'tags=v1' or 'type=IMAGE'." default:""`
+	ArtifactWith
+	TimeWindow
+	RegexFilter
+	Page     int  `long:"page" description:"The page nubmer, default is 1." default:"1"`
+	PageSize int  `long:"page_size" description:"The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs." default:"10" validate:"pagesize"`
+	Count    bool `long:"count" description:"Print the total number of matched items only."`
+	All      bool `long:"all" description:"Fetch all pages, --page and --page_size are ignored."`
+}
+
+func (x *ArtifactsList) Execute(args []string) error {
+	return utils.ListPrinter(x.Count)(GetArtifacts(utils.NewClient(), x))
+}
+
+// GetArtifacts lists the artifacts of a repository.
+//
+// params:
+//  project   - (REQUIRED) The name of the project.
+//  repo_name - (REQUIRED) The name of the repository.
+//  q         - Query string to filter the artifacts.
+//  pushed-since, pushed-before, pulled-since - The time window of the artifacts, sent as range queries of q.
+//  filter-regex - The regular expression the digest or a tag of the artifacts matches, applied client-side.
+//  page      - The page nubmer, default is 1.
+//  page_size - The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs.
+//
+// format:
+//  GET /projects/{project_name}/repositories/{repository_name}/artifacts
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/projects/library/repositories/photon/artifacts?with_tag=true&page=1&page_size=10'
+func GetArtifacts(c *harbor.Client, opt *ArtifactsList) (*harbor.Result, error) {
+	if opt.Count {
+		opt.Page, opt.PageSize = 1, 1
+	}
+	w, err := opt.window(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	keep, err := opt.keep(artifactNames)
+	if err != nil {
+		return nil, err
+	}
+	if keep != nil {
+		// Artifacts are filtered by their tags too.
+		opt.WithTag = true
+	}
+
+	ref := ArtifactRef{Project: opt.Project, RepoName: opt.RepoName}
+	return listInWindow(c, w, opt.Query, func(q string) string {
+		return ref.repoURL(c) + "/artifacts?q=" + url.QueryEscape(q) +
+			"&" + opt.query() +
+			"&page=" + strconv.Itoa(opt.Page) +
+			"&page_size=" + strconv.Itoa(opt.PageSize)
+	}, keep, opt.Count, opt.All)
+}
+
+// ArtifactGet holds the parameters of GetArtifact.
+type ArtifactGet struct {
+	ArtifactRef
+	ArtifactWith
+}
+
+func (x *ArtifactGet) Execute(args []string) error {
+	return utils.PrintResult(GetArtifact(utils.NewClient(), x))
+}
+
+// GetArtifact gets an artifact by tag or digest.
+//
+// format:
+//  GET /projects/{project_name}/repositories/{repository_name}/artifacts/{reference}
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/projects/library/repositories/photon/artifacts/v3?with_tag=true'
+func GetArtifact(c *harbor.Client, opt *ArtifactGet) (*harbor.Result, error) {
+	targetURL := opt.artifactURL(c) + "?" + opt.query()
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
+}
+
+// ArtifactDel holds the parameters of DeleteArtifact.
+type ArtifactDel struct {
+	ArtifactRef
+}
+
+func (x *ArtifactDel) Execute(args []string) error {
+	return utils.PrintResult(DeleteArtifact(utils.NewClient(), x))
+}
+
+// DeleteArtifact deletes an artifact by tag or digest, all tags of the
+// artifact are gone with it.
+//
+// format:
+//  DELETE /projects/{project_name}/repositories/{repository_name}/artifacts/{reference}
+//
+// e.g. curl -X DELETE 'https://localhost/api/v2.0/projects/library/repositories/photon/artifacts/sha256:5a1e...'
+func DeleteArtifact(c *harbor.Client, opt *ArtifactDel) (*harbor.Result, error) {
+	targetURL := opt.artifactURL(c)
+	c.Trace("==> DELETE", targetURL)
+
+	return c.Do(c.Delete(targetURL))
+}
+
+// ArtifactCopy holds the parameters of PostArtifactCopy.
+type ArtifactCopy struct {
+	Project  string `short:"p" long:"project" description:"(REQUIRED) The name of the project to copy into." required:"yes"`
+	RepoName string `short:"r" long:"repo_name" description:"The name of the repository to copy into, without the project part, the one of --from by default."`
+	From     string `long:"from" description:"(REQUIRED) The artifact to copy, 'project/repo:tag' or 'project/repo@digest'." required:"yes"`
+}
+
+func (x *ArtifactCopy) Execute(args []string) error {
+	return utils.PrintResult(PostArtifactCopy(utils.NewClient(), x))
+}
+
+// PostArtifactCopy copies an artifact, with the tag it is referenced by,
+// into a repository of another project, or another repository.
+//
+// params:
+//   project - (REQUIRED) The name of the project to copy into.
+//   repo_name - The name of the repository to copy into, the one of from by default.
+//   from - (REQUIRED) The artifact to copy, 'project/repo:tag' or 'project/repo@digest'.
+//
+// format:
+//  POST /projects/{project_name}/repositories/{repository_name}/artifacts?from={from}
+//
+// e.g. curl -X POST 'https://localhost/api/v2.0/projects/prod/repositories/app/artifacts?from=dev%2Fapp%3A1.2'
+func PostArtifactCopy(c *harbor.Client, opt *ArtifactCopy) (*harbor.Result, error) {
+	if !c.IsV2() {
+		return nil, errors.New("artifact_copy requires the v2.0 API (Harbor v2.0+), use tag_retag with v1 API")
+	}
+
+	srcRepo, ref := splitImage(opt.From)
+	src := artifactRefOf(srcRepo, ref)
+	if src.Project == "" {
+		return nil, fmt.Errorf("--from must include the project, e.g. 'library/photon:latest'")
+	}
+	dst := &ArtifactRef{Project: opt.Project, RepoName: opt.RepoName}
+	if dst.RepoName == "" {
+		dst.RepoName = src.RepoName
+	}
+	if dst.Project == src.Project && dst.RepoName == src.RepoName {
+		return nil, fmt.Errorf("%s is already in %s/%s", opt.From, dst.Project, dst.RepoName)
+	}
+
+	// Tags cannot have a colon, digests do.
+	from := srcRepo + ":" + ref
+	if strings.Contains(ref, ":") {
+		from = srcRepo + "@" + ref
+	}
+	return copyArtifact(c, dst, from)
+}
+
+// copyArtifact copies the artifact from, a full image name, into the
+// repository of dst.
+func copyArtifact(c *harbor.Client, dst *ArtifactRef, from string) (*harbor.Result, error) {
+	targetURL := dst.repoURL(c) + "/artifacts?from=" + url.QueryEscape(from)
+	c.Trace("==> POST", targetURL)
+
+	return c.Do(c.Post(targetURL))
+}
+
+// ArtifactTagsList holds the parameters of GetArtifactTags.
+type ArtifactTagsList struct {
+	ArtifactRef
+	TimeWindow
+	RegexFilter
+}
+
+func (x *ArtifactTagsList) Execute(args []string) error {
+	return utils.PrintResult(GetArtifactTags(utils.NewClient(), x))
+}
+
+// GetArtifactTags lists the tags of an artifact, in the time window of
+// opt and matching its regular expression if set.
+//
+// format:
+//  GET /projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/tags
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/projects/library/repositories/photon/artifacts/v3/tags'
+func GetArtifactTags(c *harbor.Client, opt *ArtifactTagsList) (*harbor.Result, error) {
+	w, err := opt.window(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	keep, err := opt.keep(itemName)
+	if err != nil {
+		return nil, err
+	}
+
+	return listInWindow(c, w, "", func(q string) string {
+		if q == "" {
+			return opt.artifactURL(c) + "/tags"
+		}
+		return opt.artifactURL(c) + "/tags?q=" + url.QueryEscape(q)
+	}, keep, false, false)
+}
+
+// ArtifactTagCreate holds the parameters of PostArtifactTag.
+type ArtifactTagCreate struct {
+	ArtifactRef
+	Tag string `short:"t" long:"tag" description:"(REQUIRED) The name of the new tag." required:"yes"`
+}
+
+func (x *ArtifactTagCreate) Execute(args []string) error {
+	return utils.PrintResult(PostArtifactTag(utils.NewClient(), x))
+}
+
+// PostArtifactTag creates a tag for an artifact.
+//
+// format:
+//  POST /projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/tags
+//
+// e.g. curl -X POST --header 'Content-Type: application/json' -d '{"name": "latest"}' 'https://localhost/api/v2.0/projects/library/repositories/photon/artifacts/v3/tags'
+func PostArtifactTag(c *harbor.Client, opt *ArtifactTagCreate) (*harbor.Result, error) {
+	targetURL := opt.artifactURL(c) + "/tags"
+	c.Trace("==> POST", targetURL)
+
+	t, err := json.Marshal(map[string]string{"name": opt.Tag})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Do(c.Post(targetURL).
+		Send(string(t)))
+}
+
+// ArtifactTagDel holds the parameters of DeleteArtifactTag.
+type ArtifactTagDel struct {
+	ArtifactRef
+	Tag string `short:"t" long:"tag" description:"(REQUIRED) The name of the tag to delete." required:"yes"`
+}
+
+func (x *ArtifactTagDel) Execute(args []string) error {
+	return utils.PrintResult(DeleteArtifactTag(utils.NewClient(), x))
+}
+
+// DeleteArtifactTag deletes a tag of an artifact, the artifact is kept.
+//
+// format:
+//  DELETE /projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/tags/{tag_name}
+//
+// e.g. curl -X DELETE 'https://localhost/api/v2.0/projects/library/repositories/photon/artifacts/v3/tags/latest'
+func DeleteArtifactTag(c *harbor.Client, opt *ArtifactTagDel) (*harbor.Result, error) {
+	targetURL := opt.artifactURL(c) + "/tags/" + utils.TagPath(opt.Tag)
+	c.Trace("==> DELETE", targetURL)
+
+	return c.Do(c.Delete(targetURL))
+}
+
+// ArtifactLabelAdd holds the parameters of PostArtifactLabel.
+type ArtifactLabelAdd struct {
+	ArtifactRef
+	LabelID int `short:"i" long:"label_id" description:"(REQUIRED) The ID of the label." required:"yes"`
+}
+
+func (x *ArtifactLabelAdd) Execute(args []string) error {
+	return utils.PrintResult(PostArtifactLabel(utils.NewClient(), x))
+}
+
+// PostArtifactLabel adds a label to an artifact.
+//
+// format:
+//  POST /projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/labels
+//
+// e.g. curl -X POST --header 'Content-Type: application/json' -d '{"id": 1}' 'https://localhost/api/v2.0/projects/library/repositories/photon/artifacts/v3/labels'
+func PostArtifactLabel(c *harbor.Client, opt *ArtifactLabelAdd) (*harbor.Result, error) {
+	targetURL := opt.artifactURL(c) + "/labels"
+	c.Trace("==> POST", targetURL)
+
+	t, err := json.Marshal(map[string]int{"id": opt.LabelID})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Do(c.Post(targetURL).
+		Send(string(t)))
+}
+
+// ArtifactLabelDel holds the parameters of DeleteArtifactLabel.
+type ArtifactLabelDel struct {
+	ArtifactRef
+	LabelID int `short:"i" long:"label_id" description:"(REQUIRED) The ID of the label." required:"yes"`
+}
+
+func (x *ArtifactLabelDel) Execute(args []string) error {
+	return utils.PrintResult(DeleteArtifactLabel(utils.NewClient(), x))
+}
+
+// DeleteArtifactLabel deletes a label from an artifact.
+//
+// format:
+//  DELETE /projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/labels/{label_id}
+//
+// e.g. curl -X DELETE 'https://localhost/api/v2.0/projects/library/repositories/photon/artifacts/v3/labels/1'
+func DeleteArtifactLabel(c *harbor.Client, opt *ArtifactLabelDel) (*harbor.Result, error) {
+	targetURL := opt.artifactURL(c) + "/labels/" + strconv.Itoa(opt.LabelID)
+	c.Trace("==> DELETE", targetURL)
+
+	return c.Do(c.Delete(targetURL))
+}
+
+// ArtifactAccessoriesList holds the parameters of GetArtifactAccessories.
+type ArtifactAccessoriesList struct {
+	ArtifactRef
+	Type string `short:"t" long:"type" description:"List the accessories of this type only, e.g. signature.cosign, signature.notation, harbor.sbom."`
+}
+
+func (x *ArtifactAccessoriesList) Execute(args []string) error {
+	return utils.PrintResult(GetArtifactAccessories(utils.NewClient(), x))
+}
+
+// GetArtifactAccessories lists the accessories (cosign/notation signatures,
+// SBOMs, ...) attached to an artifact.
+//
+// format:
+//  GET /projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/accessories
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/projects/library/repositories/photon/artifacts/v3/accessories?q=type%3Dsignature.cosign'
+func GetArtifactAccessories(c *harbor.Client, opt *ArtifactAccessoriesList) (*harbor.Result, error) {
+	targetURL := opt.artifactURL(c) + "/accessories"
+	if opt.Type != "" {
+		targetURL += "?q=" + url.QueryEscape("type="+opt.Type)
+	}
+	c.Trace("==> GET", targetURL)
+
+	return c.DoStream(c.Get(targetURL))
+}
+
+// ArtifactSBOMGet holds the parameters of GetArtifactSBOM.
+type ArtifactSBOMGet struct {
+	ArtifactRef
+	File string `short:"f" long:"file" description:"Write the SBOM to this file instead of stdout."`
+}
+
+func (x *ArtifactSBOMGet) Execute(args []string) error {
+	if x.File == "" {
+		res, err := GetArtifactSBOM(utils.NewDataClient(), x)
+		if err != nil {
+			return utils.PrintResult(res, err)
+		}
+		_, err = os.Stdout.Write(res.Body)
+		return err
+	}
+
+	res, err := GetArtifactSBOM(utils.NewClient(), x)
+	if err != nil {
+		return utils.PrintResult(res, err)
+	}
+	if err := ioutil.WriteFile(x.File, res.Body, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("SBOM of %s written to %s (%d bytes)\n", scanName(&x.ArtifactRef), x.File, len(res.Body))
+	return nil
+}
+
+// sbomAccessoryType is the type of the SBOMs Harbor generates and attaches
+// to artifacts.
+const sbomAccessoryType = "harbor.sbom"
+
+// GetArtifactSBOM downloads the SBOM attached to an artifact, the latest
+// one if several are: the accessories of the artifact are listed, and the
+// SBOM addition of the SBOM accessory is got.
+//
+// format:
+//  GET /projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/accessories
+//  GET /projects/{project_name}/repositories/{repository_name}/artifacts/{sbom_digest}/additions/sbom
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/projects/library/repositories/photon/artifacts/sha256:5a1e.../additions/sbom'
+func GetArtifactSBOM(c *harbor.Client, opt *ArtifactSBOMGet) (*harbor.Result, error) {
+	targetURL := opt.artifactURL(c) + "/accessories?q=" + url.QueryEscape("type="+sbomAccessoryType)
+	c.Trace("==> GET", targetURL)
+
+	var latest *model.Accessory
+	err := c.EachPage(targetURL, func(item json.RawMessage) error {
+		var acc model.Accessory
+		if err := json.Unmarshal(item, &acc); err != nil {
+			return err
+		}
+		if acc.Type == sbomAccessoryType && (latest == nil || acc.CreationTime.After(latest.CreationTime.Time)) {
+			latest = &acc
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no SBOM attached to %s, generate one by an SBOM scan", scanName(&opt.ArtifactRef))
+	}
+
+	targetURL = opt.repoURL(c) + "/artifacts/" + utils.TagPath(latest.Digest) + "/additions/sbom"
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
+}
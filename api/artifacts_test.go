@@ -0,0 +1,58 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWindowTime(t *testing.T) {
+	now := time.Date(2027, 1, 15, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		s    string
+		want time.Time // zero for an error
+	}{
+		{"2027-01-08", time.Date(2027, 1, 8, 0, 0, 0, 0, time.UTC)},
+		{"2027-01-08T10:30:00", time.Date(2027, 1, 8, 10, 30, 0, 0, time.UTC)},
+		{"2027-01-08T10:30:00Z", time.Date(2027, 1, 8, 10, 30, 0, 0, time.UTC)},
+		{"2027-01-08T10:30:00+02:00", time.Date(2027, 1, 8, 8, 30, 0, 0, time.UTC)},
+		{"1d", now.AddDate(0, 0, -1)},
+		{"7d", now.AddDate(0, 0, -7)},
+		{"90d", now.AddDate(0, 0, -90)},
+		{"2w", now.AddDate(0, 0, -14)},
+		{"36h", now.Add(-36 * time.Hour)},
+		{"1h30m", now.Add(-90 * time.Minute)},
+		{"", time.Time{}},
+		{"d", time.Time{}},
+		{"w", time.Time{}},
+		{"7", time.Time{}},
+		{"0d", time.Time{}},
+		{"-7d", time.Time{}},
+		{"7dd", time.Time{}},
+		{"7dw", time.Time{}},
+		{"7wd", time.Time{}},
+		{"7 d", time.Time{}},
+		{"1.5d", time.Time{}},
+		{"7y", time.Time{}},
+		{"0h", time.Time{}},
+		{"-36h", time.Time{}},
+		{"2027-13-01", time.Time{}},
+		{"yesterday", time.Time{}},
+	}
+	for _, tt := range tests {
+		got, err := parseWindowTime(tt.s, now)
+		if tt.want.IsZero() {
+			if err == nil {
+				t.Errorf("parseWindowTime(%q) = %s, want an error", tt.s, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseWindowTime(%q): %v", tt.s, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseWindowTime(%q) = %s, want %s", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,367 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+func init() {
+	utils.Parser.AddCommand("chartrepo_charts_list",
+		"List the Helm charts of a project.",
+		"This endpoint lists the Helm charts of the chart repository of a project, with their number of versions and latest version. (Harbor v1.6+ with ChartMuseum, removed in v2.8)",
+		&ChartsList{})
+	utils.Parser.AddCommand("chartrepo_chart_versions",
+		"List the versions of a Helm chart.",
+		"This endpoint lists the versions of a Helm chart of a project, with their metadata, download URLs and labels. (Harbor v1.6+ with ChartMuseum, removed in v2.8)",
+		&ChartVersionsList{})
+	utils.Parser.AddCommand("chartrepo_chart_get",
+		"Get a version of a Helm chart.",
+		"This endpoint gets the details of a version of a Helm chart of a project: its metadata, dependencies, default values, files, signature and labels. (Harbor v1.6+ with ChartMuseum, removed in v2.8)",
+		&ChartGet{})
+	utils.Parser.AddCommand("chartrepo_chart_del",
+		"Delete a Helm chart or a version of it.",
+		"This endpoint deletes a version of a Helm chart of a project with --version, all the versions of the chart otherwise. (Harbor v1.6+ with ChartMuseum, removed in v2.8)",
+		&ChartDel{})
+	utils.Parser.AddCommand("chartrepo_chart_upload",
+		"Upload a Helm chart package to a project.",
+		"This endpoint uploads a Helm chart package (.tgz, as made by helm package) to the chart repository of a project, with its provenance file (.prov, as made by helm package --sign) if --prov is given. (Harbor v1.6+ with ChartMuseum, removed in v2.8)",
+		&ChartUpload{})
+	utils.Parser.AddCommand("chartrepo_chart_labels_get",
+		"Get the labels of a version of a Helm chart.",
+		"This endpoint gets the labels of a version of a Helm chart of a project. (Harbor v1.6+ with ChartMuseum, removed in v2.8)",
+		&ChartLabelsGet{})
+	utils.Parser.AddCommand("chartrepo_chart_label_add",
+		"Add a label to a version of a Helm chart.",
+		"This endpoint adds an already existing label (global or of the project) to a version of a Helm chart, as repo_image_label_add does to an image. label_merge relabels chart versions too. (Harbor v1.6+ with ChartMuseum, removed in v2.8)",
+		&ChartLabelAdd{})
+	utils.Parser.AddCommand("chartrepo_chart_label_del",
+		"Delete a label from a version of a Helm chart.",
+		"This endpoint deletes a label from a version of a Helm chart. (Harbor v1.6+ with ChartMuseum, removed in v2.8)",
+		&ChartLabelDel{})
+
+	for _, cmd := range []string{"chartrepo_charts_list", "chartrepo_chart_versions", "chartrepo_chart_get", "chartrepo_chart_del", "chartrepo_chart_upload",
+		"chartrepo_chart_labels_get", "chartrepo_chart_label_add", "chartrepo_chart_label_del"} {
+		utils.RequireComponent(cmd, utils.ComponentChartmuseum)
+	}
+	utils.ResponseModel("chartrepo_charts_list", model.ChartInfo{})
+	utils.ResponseModel("chartrepo_chart_versions", model.ChartVersion{})
+	utils.ResponseModel("chartrepo_chart_labels_get", model.Label{})
+}
+
+// chartsURL returns the URL of the charts of the chart repository of a
+// project, which is not versioned with the rest of the API.
+func chartsURL(c *harbor.Client, project string) string {
+	return c.URL("/api/chartrepo") + "/" + url.PathEscape(project) + "/charts"
+}
+
+// ChartRef is the flags naming a Helm chart.
+type ChartRef struct {
+	Project string `short:"p" long:"project" description:"(REQUIRED) The name of the project, that is of its chart repository." required:"yes"`
+	Name    string `short:"n" long:"name" description:"(REQUIRED) The name of the chart." required:"yes"`
+}
+
+// chartURL returns the URL of the chart, of a version of it if version is
+// not empty.
+func (x *ChartRef) chartURL(c *harbor.Client, version string) string {
+	targetURL := chartsURL(c, x.Project) + "/" + url.PathEscape(x.Name)
+	if version != "" {
+		targetURL += "/" + url.PathEscape(version)
+	}
+	return targetURL
+}
+
+// ChartsList holds the parameters of GetCharts.
+type ChartsList struct {
+	Project string `short:"p" long:"project" description:"(REQUIRED) The name of the project, that is of its chart repository." required:"yes"`
+}
+
+func (x *ChartsList) Execute(args []string) error {
+	return utils.PrintResult(GetCharts(utils.NewClient(), x))
+}
+
+// GetCharts lists the Helm charts of the chart repository of a project.
+//
+// params:
+//   project - (REQUIRED) The name of the project.
+//
+// format:
+//   GET /chartrepo/{repo}/charts
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/chartrepo/library/charts'
+//
+func GetCharts(c *harbor.Client, opt *ChartsList) (*harbor.Result, error) {
+	targetURL := chartsURL(c, opt.Project)
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
+}
+
+// ChartVersionsList holds the parameters of GetChartVersions.
+type ChartVersionsList struct {
+	ChartRef
+}
+
+func (x *ChartVersionsList) Execute(args []string) error {
+	return utils.PrintResult(GetChartVersions(utils.NewClient(), x))
+}
+
+// GetChartVersions lists the versions of a Helm chart.
+//
+// params:
+//   project - (REQUIRED) The name of the project.
+//   name - (REQUIRED) The name of the chart.
+//
+// format:
+//   GET /chartrepo/{repo}/charts/{name}
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/chartrepo/library/charts/nginx'
+//
+func GetChartVersions(c *harbor.Client, opt *ChartVersionsList) (*harbor.Result, error) {
+	targetURL := opt.chartURL(c, "")
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
+}
+
+// ChartGet holds the parameters of GetChart.
+type ChartGet struct {
+	ChartVersionRef
+}
+
+func (x *ChartGet) Execute(args []string) error {
+	return utils.PrintResult(GetChart(utils.NewClient(), x))
+}
+
+// GetChart gets the details of a version of a Helm chart.
+//
+// params:
+//   project - (REQUIRED) The name of the project.
+//   name - (REQUIRED) The name of the chart.
+//   version - (REQUIRED) The version of the chart.
+//
+// format:
+//   GET /chartrepo/{repo}/charts/{name}/{version}
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/chartrepo/library/charts/nginx/1.2.0'
+//
+func GetChart(c *harbor.Client, opt *ChartGet) (*harbor.Result, error) {
+	targetURL := opt.chartURL(c, opt.Version)
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
+}
+
+// ChartDel holds the parameters of DeleteChart.
+type ChartDel struct {
+	ChartRef
+	Version string `short:"v" long:"version" description:"The version of the chart to delete, all of them if not given."`
+}
+
+func (x *ChartDel) Execute(args []string) error {
+	return utils.PrintResult(DeleteChart(utils.NewClient(), x))
+}
+
+// DeleteChart deletes a version of a Helm chart, or all its versions if
+// version is empty.
+//
+// params:
+//   project - (REQUIRED) The name of the project.
+//   name - (REQUIRED) The name of the chart.
+//   version - The version of the chart.
+//
+// format:
+//   DELETE /chartrepo/{repo}/charts/{name}/{version}
+//   DELETE /chartrepo/{repo}/charts/{name}
+//
+// e.g. curl -X DELETE --header 'Accept: application/json' 'https://localhost/api/chartrepo/library/charts/nginx/1.2.0'
+func DeleteChart(c *harbor.Client, opt *ChartDel) (*harbor.Result, error) {
+	targetURL := opt.chartURL(c, opt.Version)
+	c.Trace("==> DELETE", targetURL)
+
+	return c.Do(c.Delete(targetURL))
+}
+
+// ChartUpload holds the parameters of PostChartUpload.
+type ChartUpload struct {
+	Project string `short:"p" long:"project" description:"(REQUIRED) The name of the project, that is of its chart repository." required:"yes"`
+	Chart   string `short:"f" long:"chart" description:"(REQUIRED) The chart package to upload, e.g. nginx-1.2.0.tgz." required:"yes"`
+	Prov    string `long:"prov" description:"The provenance file of the chart package, e.g. nginx-1.2.0.tgz.prov."`
+}
+
+func (x *ChartUpload) Execute(args []string) error {
+	return utils.PrintResult(PostChartUpload(utils.NewClient(), x))
+}
+
+// chartUploadBody returns the multipart form uploading a chart package and
+// its provenance file, if prov is not empty, and its Content-Type.
+func chartUploadBody(chart, prov string) ([]byte, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	files := []struct{ field, path string }{{"chart", chart}, {"prov", prov}}
+	for _, f := range files {
+		if f.path == "" {
+			continue
+		}
+		if err := addFormFile(w, f.field, f.path); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), w.FormDataContentType(), nil
+}
+
+// addFormFile adds the file at path to the form as field.
+func addFormFile(w *multipart.Writer, field, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	part, err := w.CreateFormFile(field, filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, f)
+	return err
+}
+
+// PostChartUpload uploads a Helm chart package to the chart repository of a
+// project, with its provenance file if prov is given, as a multipart form.
+//
+// params:
+//   project - (REQUIRED) The name of the project.
+//   chart - (REQUIRED) The chart package.
+//   prov - The provenance file of the chart package.
+//
+// format:
+//   POST /chartrepo/{repo}/charts
+//
+// e.g. curl -X POST --header 'Accept: application/json' -F 'chart=@nginx-1.2.0.tgz' -F 'prov=@nginx-1.2.0.tgz.prov' 'https://localhost/api/chartrepo/library/charts'
+func PostChartUpload(c *harbor.Client, opt *ChartUpload) (*harbor.Result, error) {
+	if filepath.Ext(opt.Chart) == ".prov" {
+		return nil, errors.New("--chart is the chart package, give its .prov file with --prov")
+	}
+	body, contentType, err := chartUploadBody(opt.Chart, opt.Prov)
+	if err != nil {
+		return nil, err
+	}
+
+	targetURL := chartsURL(c, opt.Project)
+	c.Trace("==> POST", targetURL)
+
+	return c.Do(c.Post(targetURL).
+		Set("Content-Type", contentType).
+		Send(body))
+}
+
+// ChartVersionRef is the flags naming a version of a Helm chart.
+type ChartVersionRef struct {
+	ChartRef
+	Version string `short:"v" long:"version" description:"(REQUIRED) The version of the chart." required:"yes"`
+}
+
+// labelsURL returns the URL of the labels of the version of the chart.
+func (x *ChartVersionRef) labelsURL(c *harbor.Client) string {
+	return x.chartURL(c, x.Version) + "/labels"
+}
+
+// ChartLabelsGet holds the parameters of GetChartLabels.
+type ChartLabelsGet struct {
+	ChartVersionRef
+}
+
+func (x *ChartLabelsGet) Execute(args []string) error {
+	return utils.PrintResult(GetChartLabels(utils.NewClient(), x))
+}
+
+// GetChartLabels gets the labels of a version of a Helm chart.
+//
+// params:
+//   project - (REQUIRED) The name of the project.
+//   name - (REQUIRED) The name of the chart.
+//   version - (REQUIRED) The version of the chart.
+//
+// format:
+//   GET /chartrepo/{repo}/charts/{name}/{version}/labels
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/chartrepo/library/charts/nginx/1.2.0/labels'
+//
+func GetChartLabels(c *harbor.Client, opt *ChartLabelsGet) (*harbor.Result, error) {
+	targetURL := opt.labelsURL(c)
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
+}
+
+// ChartLabelAdd holds the parameters of PostChartLabelAdd.
+type ChartLabelAdd struct {
+	ChartVersionRef
+	LabelID int `short:"i" long:"label_id" description:"(REQUIRED) The ID of the already existing label." required:"yes"`
+}
+
+func (x *ChartLabelAdd) Execute(args []string) error {
+	return utils.PrintResult(PostChartLabelAdd(utils.NewClient(), x))
+}
+
+// PostChartLabelAdd adds an already existing label, global or of the
+// project, to a version of a Helm chart.
+//
+// params:
+//   project - (REQUIRED) The name of the project.
+//   name - (REQUIRED) The name of the chart.
+//   version - (REQUIRED) The version of the chart.
+//   label_id - (REQUIRED) The ID of the label.
+//
+// format:
+//   POST /chartrepo/{repo}/charts/{name}/{version}/labels
+//
+// e.g. curl -X POST --header 'Content-Type: application/json' -d '{"id": 3}' 'https://localhost/api/chartrepo/library/charts/nginx/1.2.0/labels'
+func PostChartLabelAdd(c *harbor.Client, opt *ChartLabelAdd) (*harbor.Result, error) {
+	targetURL := opt.labelsURL(c)
+	c.Trace("==> POST", targetURL)
+
+	return c.Do(c.Post(targetURL).
+		Send(map[string]int{"id": opt.LabelID}))
+}
+
+// ChartLabelDel holds the parameters of DeleteChartLabel.
+type ChartLabelDel struct {
+	ChartVersionRef
+	LabelID int `short:"i" long:"label_id" description:"(REQUIRED) The ID of the label." required:"yes"`
+}
+
+func (x *ChartLabelDel) Execute(args []string) error {
+	return utils.PrintResult(DeleteChartLabel(utils.NewClient(), x))
+}
+
+// DeleteChartLabel deletes a label from a version of a Helm chart.
+//
+// params:
+//   project - (REQUIRED) The name of the project.
+//   name - (REQUIRED) The name of the chart.
+//   version - (REQUIRED) The version of the chart.
+//   label_id - (REQUIRED) The ID of the label.
+//
+// format:
+//   DELETE /chartrepo/{repo}/charts/{name}/{version}/labels/{id}
+//
+// e.g. curl -X DELETE --header 'Accept: application/json' 'https://localhost/api/chartrepo/library/charts/nginx/1.2.0/labels/3'
+func DeleteChartLabel(c *harbor.Client, opt *ChartLabelDel) (*harbor.Result, error) {
+	targetURL := opt.labelsURL(c) + "/" + strconv.Itoa(opt.LabelID)
+	c.Trace("==> DELETE", targetURL)
+
+	return c.Do(c.Delete(targetURL))
+}
@@ -0,0 +1,281 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+func init() {
+	utils.Parser.AddCommand("cleanup_tags",
+		"Delete the tags older than a given age, or all but the last ones.",
+		"This endpoint deletes the tags of a repository, or of all repositories of a project, pushed before --older-than, e.g. 90d, or but the --keep-last latest pushed ones of each repository, for Harbor versions without tag retention; with both, the tags matching both. --match deletes the tags matching a regular expression only, e.g. 'release-.*'. An artifact (an image with v1 API) whose tags are all deleted is deleted with them, its storage is freed by the next garbage collection. With v2.0 API the old tags of an artifact having newer ones are deleted alone, with v1 API they are skipped since deleting a tag deletes its image. Immutable tags are kept. Artifacts are deleted by --workers requests at a time. --dry-run prints what would be deleted only.",
+		&TagsCleanup{})
+}
+
+// TagsCleanup holds the parameters of CleanupTags.
+type TagsCleanup struct {
+	Project   string `short:"p" long:"project" description:"(REQUIRED) The name of the project." required:"yes"`
+	RepoName  string `short:"r" long:"repo_name" description:"Clean up this repository only, without the project part."`
+	OlderThan string `long:"older-than" description:"Delete the tags pushed before this age or time: 90d, 12w, 36h, a date (2006-01-02) or an RFC 3339 time."`
+	KeepLast  int    `long:"keep-last" description:"Keep the latest pushed tags of each repository, this many, and delete the others." validate:"min=0"`
+	Match     string `long:"match" description:"Delete the tags whose whole name matches this regular expression only, --keep-last keeps the latest of them, e.g. 'release-.*'."`
+	Workers   int    `short:"w" long:"workers" description:"The number of artifacts deleted concurrently." default:"4" validate:"min=1"`
+	DryRun    bool   `long:"dry-run" description:"Print the tags to delete only."`
+}
+
+func (x *TagsCleanup) Execute(args []string) error {
+	r, err := CleanupTags(utils.NewClient(), x)
+	if err = utils.PrintValue(r, err, func() { utils.PrintCleanupReport(r) }); err != nil {
+		return err
+	}
+	if len(r.Failed) > 0 {
+		return fmt.Errorf("%d artifacts or repositories not cleaned up", len(r.Failed))
+	}
+	return nil
+}
+
+// cleanupArtifact is an artifact of a repository, an image with v1 API,
+// and its tags.
+type cleanupArtifact struct {
+	digest string
+	size   int64
+	tags   []model.ArtifactTag
+}
+
+// CleanupTags deletes the tags of a project, or of one of its
+// repositories, matching opt.Match, pushed before opt.OlderThan and but the
+// opt.KeepLast latest pushed of their repository, and the artifacts left
+// without tags. The tags are all listed before any is deleted, the
+// artifacts are then deleted by opt.Workers requests at a time.
+//
+// params:
+//   project - (REQUIRED) The name of the project.
+//   repo_name - The name of the repository.
+//   older-than - The age, or the time, the tags to delete were pushed before.
+//   keep-last - The number of latest pushed tags kept in each repository.
+//   match - The regular expression the names of the tags to delete match.
+//
+// format:
+//   GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts?with_tag=true
+//   DELETE /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{digest}
+//   DELETE /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{digest}/tags/{tag}
+//   GET /api/repositories/{repo_name}/tags
+//   DELETE /api/repositories/{repo_name}/tags/{tag}
+func CleanupTags(c *harbor.Client, opt *TagsCleanup) (*utils.CleanupReport, error) {
+	if opt.OlderThan == "" && opt.KeepLast == 0 {
+		return nil, errors.New("--older-than or --keep-last is required")
+	}
+	if opt.Workers < 1 {
+		return nil, errors.New("--workers must be positive")
+	}
+	var before time.Time
+	if opt.OlderThan != "" {
+		var err error
+		if before, err = parseWindowTime(opt.OlderThan, time.Now()); err != nil {
+			return nil, fmt.Errorf("--older-than: %v", err)
+		}
+	}
+	var match *regexp.Regexp
+	if opt.Match != "" {
+		if _, err := regexp.Compile(opt.Match); err != nil {
+			return nil, fmt.Errorf("--match: %v", err)
+		}
+		match = regexp.MustCompile("^(?:" + opt.Match + ")$")
+	}
+
+	prj, err := projectByName(c, opt.Project)
+	if err != nil {
+		return nil, err
+	}
+	var repos []string
+	if opt.RepoName != "" {
+		repos = append(repos, prj.Name+"/"+opt.RepoName)
+	} else if repos, err = projectRepoNames(c, prj); err != nil {
+		return nil, err
+	}
+
+	r := &utils.CleanupReport{
+		Project: prj.Name,
+		Before:  model.Time{Time: before},
+		DryRun:  opt.DryRun,
+		Deleted: []*utils.CleanupItem{},
+	}
+	var items []*utils.CleanupItem
+	for _, repo := range repos {
+		arts, err := cleanupArtifacts(c, repo)
+		if err != nil {
+			r.Failed = append(r.Failed, &utils.CleanupItem{Repository: repo, Reason: err.Error()})
+			continue
+		}
+
+		del := cleanupSelect(arts, match, before, opt.KeepLast)
+		for _, a := range arts {
+			item := &utils.CleanupItem{Repository: repo, Digest: a.digest, Size: a.size}
+			for _, t := range a.tags {
+				if del[a.digest+":"+t.Name] {
+					item.Tags = append(item.Tags, t.Name)
+				}
+			}
+			if len(item.Tags) == 0 {
+				continue
+			}
+			item.Artifact = len(item.Tags) == len(a.tags)
+			if !item.Artifact && !c.IsV2() {
+				item.Reason = "the image has newer tags"
+				r.Skipped = append(r.Skipped, item)
+				continue
+			}
+			items = append(items, item)
+		}
+	}
+
+	var errs []error
+	if !opt.DryRun {
+		errs = cleanupDeleteAll(c, items, opt.Workers)
+	}
+	for i, item := range items {
+		if errs != nil && errs[i] != nil {
+			item.Reason = errs[i].Error()
+			r.Failed = append(r.Failed, item)
+			continue
+		}
+		r.Add(item)
+	}
+	return r, nil
+}
+
+// cleanupSelect returns the tags of arts, the artifacts of a repository,
+// to delete, as digest:tag: the tags matching match, if not nil, pushed
+// before before, if not zero, and but the keepLast latest pushed. Immutable
+// tags are never deleted, while they count in keepLast.
+func cleanupSelect(arts []*cleanupArtifact, match *regexp.Regexp, before time.Time, keepLast int) map[string]bool {
+	type tag struct {
+		digest string
+		model.ArtifactTag
+	}
+	var tags []tag
+	for _, a := range arts {
+		for _, t := range a.tags {
+			if match == nil || match.MatchString(t.Name) {
+				tags = append(tags, tag{a.digest, t})
+			}
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].PushTime.After(tags[j].PushTime.Time)
+	})
+
+	del := map[string]bool{}
+	for i, t := range tags {
+		if i < keepLast || t.Immutable || (!before.IsZero() && !t.PushTime.Before(before)) {
+			continue
+		}
+		del[t.digest+":"+t.Name] = true
+	}
+	return del
+}
+
+// cleanupArtifacts returns the tagged artifacts of repo, a full name.
+func cleanupArtifacts(c *harbor.Client, repo string) ([]*cleanupArtifact, error) {
+	if !c.IsV2() {
+		targetURL := c.URL("/api/repositories") + "/" + utils.RepoPath(repo) + "/tags"
+		c.Trace("==> GET", targetURL)
+
+		var tags []*model.Tag
+		if err := c.GetJSON(targetURL, &tags); err != nil {
+			return nil, err
+		}
+		// Tags of the same image are deleted together.
+		var arts []*cleanupArtifact
+		byDigest := map[string]*cleanupArtifact{}
+		for _, t := range tags {
+			a := byDigest[t.Digest]
+			if a == nil {
+				a = &cleanupArtifact{digest: t.Digest, size: t.Size}
+				byDigest[t.Digest] = a
+				arts = append(arts, a)
+			}
+			pushed := t.PushTime
+			if pushed.IsZero() {
+				// Harbor versions before v1.7 have no push time.
+				pushed = t.Created
+			}
+			a.tags = append(a.tags, model.ArtifactTag{Name: t.Name, PushTime: pushed})
+		}
+		return arts, nil
+	}
+
+	ref := artifactRefOf(repo, "")
+	targetURL := ref.repoURL(c) + "/artifacts?with_tag=true"
+	c.Trace("==> GET", targetURL)
+
+	var arts []*cleanupArtifact
+	err := c.EachPage(targetURL, func(item json.RawMessage) error {
+		var a model.Artifact
+		if err := json.Unmarshal(item, &a); err != nil {
+			return err
+		}
+		if len(a.Tags) > 0 {
+			arts = append(arts, &cleanupArtifact{digest: a.Digest, size: a.Size, tags: a.Tags})
+		}
+		return nil
+	})
+	return arts, err
+}
+
+// cleanupDeleteAll deletes items, as cleanupDelete does, by workers
+// concurrent requests, and returns their errors in the same order.
+func cleanupDeleteAll(c *harbor.Client, items []*utils.CleanupItem, workers int) []error {
+	errs := make([]error, len(items))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				errs[i] = cleanupDelete(c, items[i])
+			}
+		}()
+	}
+	for i := range items {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return errs
+}
+
+// cleanupDelete deletes the tags of item, and its artifact with them if
+// item.Artifact is set.
+func cleanupDelete(c *harbor.Client, item *utils.CleanupItem) error {
+	if !c.IsV2() {
+		// Deleting a tag deletes its image, thus its other tags.
+		targetURL := c.URL("/api/repositories") + "/" + utils.RepoPath(item.Repository) + "/tags/" + utils.TagPath(item.Tags[0])
+		c.Trace("==> DELETE", targetURL)
+		_, err := c.Do(c.Delete(targetURL))
+		return err
+	}
+
+	ref := artifactRefOf(item.Repository, item.Digest)
+	if item.Artifact {
+		_, err := DeleteArtifact(c, &ArtifactDel{ArtifactRef: *ref})
+		return err
+	}
+	for _, t := range item.Tags {
+		if _, err := DeleteArtifactTag(c, &ArtifactTagDel{ArtifactRef: *ref, Tag: t}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,71 +3,208 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
 
+	"github.com/moooofly/harbor-go-client/harbor"
 	"github.com/moooofly/harbor-go-client/utils"
+	yaml "gopkg.in/yaml.v2"
 )
 
 func init() {
 	utils.Parser.AddCommand("configurations_get",
 		"Get system configurations.",
-		"This endpoint is for retrieving system configurations that only provides for admin user.",
-		&scGet)
+		"This endpoint is for retrieving system configurations that only provides for admin user, --key prints the values of some items only.",
+		&SysConfigGet{})
 	utils.Parser.AddCommand("configurations_create",
 		"Modify system configurations. (set configuration in conf/config.yaml)",
 		"This endpoint is for modifying system configurations that only provides for admin user.",
-		&scCreate)
+		&SysConfigCreate{})
 	utils.Parser.AddCommand("configurations_reset",
 		"Reset system configurations.",
 		"Reset system configurations from environment variables. Can only be accessed by admin user.",
-		&scReset)
+		&SysConfigReset{})
+	utils.Parser.AddCommand("configurations_update",
+		"Change some system configurations.",
+		"This endpoint changes the system configuration items given by --set key:value (repeatable) or by a flat YAML file of items with --from-file, e.g. token_expiration, project_creation_restriction, self_registration or the ldap_* items, the others left untouched. Values are typed as the current ones, unknown and read-only items are refused; the changes are printed, --dry-run prints them only.",
+		&SysConfigUpdate{})
+	utils.Parser.AddCommand("configurations_pull_get",
+		"Get pull audit log and pull time update settings.",
+		"This endpoint returns the settings for heavy pull traffic: whether pulls are audited and whether pull time and pull count of artifacts are updated. (Harbor v2.10+, uses v2.0 API)",
+		&SysConfigPullGet{})
+	utils.Parser.AddCommand("configurations_pull_set",
+		"Set pull audit log and pull time update settings.",
+		"This endpoint modifies the settings for heavy pull traffic: skip audit logs of pull operations, and disable updating pull time and pull count of artifacts. Settings not given are left untouched. (Harbor v2.10+, uses v2.0 API)",
+		&SysConfigPullSet{})
+	utils.RequireAdmin(
+		"configurations_get",
+		"configurations_create",
+		"configurations_reset",
+		"configurations_update",
+		"configurations_pull_get",
+		"configurations_pull_set",
+	)
 }
 
-type sysConfigGet struct {
+// SysConfigGet holds the parameters of the configurations_get command.
+type SysConfigGet struct {
+	Keys []string `short:"k" long:"key" description:"Print the value of this configuration item only, repeatable."`
 }
 
-var scGet sysConfigGet
+func (x *SysConfigGet) Execute(args []string) error {
+	c := utils.NewClient()
+	if len(x.Keys) == 0 {
+		return utils.PrintResult(GetSysConfig(c))
+	}
 
-func (x *sysConfigGet) Execute(args []string) error {
-	GetSysConfig(utils.URLGen("/api/configurations"))
-	return nil
+	items, err := sysConfigItems(c)
+	values := map[string]interface{}{}
+	if err == nil {
+		for _, key := range x.Keys {
+			item, ok := items[key]
+			if !ok {
+				return fmt.Errorf("unknown configuration item %q", key)
+			}
+			values[key] = item.Value
+		}
+	}
+	return utils.PrintValue(values, err, func() {
+		for _, key := range x.Keys {
+			fmt.Printf("%s: %v\n", key, values[key])
+		}
+	})
+}
+
+// SysConfigCreate is the configurations_create command.
+type SysConfigCreate struct {
 }
 
-type sysConfigCreate struct {
+func (x *SysConfigCreate) Execute(args []string) error {
+	sc, err := utils.SysConfigLoad()
+	if err != nil {
+		return err
+	}
+	return utils.PrintResult(PutSysConfigCreate(utils.NewClient(), sc))
 }
 
-var scCreate sysConfigCreate
+// SysConfigReset is the configurations_reset command.
+type SysConfigReset struct {
+}
 
-func (x *sysConfigCreate) Execute(args []string) error {
-	PutSysConfigCreate(utils.URLGen("/api/configurations"))
-	return nil
+func (x *SysConfigReset) Execute(args []string) error {
+	return utils.PrintResult(PostSysConfigReset(utils.NewClient()))
 }
 
-type sysConfigReset struct {
+// pullConfigKeys are the configuration items tuning heavy pull traffic.
+var pullConfigKeys = []string{
+	"pull_audit_log_disable",
+	"pull_time_update_disable",
+	"pull_count_update_disable",
 }
 
-var scReset sysConfigReset
+// SysConfigPullGet is the configurations_pull_get command.
+type SysConfigPullGet struct {
+}
 
-func (x *sysConfigReset) Execute(args []string) error {
-	PostSysConfigReset(utils.URLGen("/api/configurations/reset"))
+func (x *SysConfigPullGet) Execute(args []string) error {
+	values, err := GetSysConfigPull(utils.NewClient())
+	if err != nil {
+		return err
+	}
+
+	for _, key := range pullConfigKeys {
+		value, ok := values[key]
+		if !ok {
+			fmt.Printf("%s: (not supported by this Harbor)\n", key)
+			continue
+		}
+		fmt.Printf("%s: %v\n", key, value)
+	}
 	return nil
 }
 
-// GetSysConfig is for retrieving system configurations that only provides for admin user.
+// SysConfigPullSet holds the parameters of PutSysConfigPull.
+type SysConfigPullSet struct {
+	PullAuditLogDisable    string `long:"pull_audit_log_disable" description:"Skip audit logs of pull operations. ('true' or 'false')" validate:"oneof=true|false" json:"pull_audit_log_disable,omitempty"`
+	PullTimeUpdateDisable  string `long:"pull_time_update_disable" description:"Do not update the pull time of artifacts. ('true' or 'false')" validate:"oneof=true|false" json:"pull_time_update_disable,omitempty"`
+	PullCountUpdateDisable string `long:"pull_count_update_disable" description:"Do not update the pull count of artifacts. ('true' or 'false')" validate:"oneof=true|false" json:"pull_count_update_disable,omitempty"`
+}
+
+func (x *SysConfigPullSet) Execute(args []string) error {
+	return utils.PrintResult(PutSysConfigPull(utils.NewClient(), x))
+}
+
+// GetSysConfigPull returns the values of the configuration items tuning
+// heavy pull traffic, items unknown to the server are left out.
 //
-// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/configurations'
-func GetSysConfig(baseURL string) {
-	targetURL := baseURL
-	fmt.Println("==> GET", targetURL)
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/configurations'
+func GetSysConfigPull(c *harbor.Client) (map[string]interface{}, error) {
+	targetURL := c.URL("/api/v2.0/configurations")
+	c.Trace("==> GET", targetURL)
 
-	c, err := utils.CookieLoad()
+	res, err := c.Do(c.Get(targetURL))
 	if err != nil {
-		fmt.Println("error:", err)
-		return
+		return nil, err
+	}
+
+	var items map[string]struct {
+		Value    interface{} `json:"value"`
+		Editable bool        `json:"editable"`
+	}
+	if err := json.Unmarshal(res.Body, &items); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{})
+	for _, key := range pullConfigKeys {
+		if item, ok := items[key]; ok {
+			values[key] = item.Value
+		}
+	}
+	return values, nil
+}
+
+// PutSysConfigPull modifies the configuration items tuning heavy pull
+// traffic.
+//
+// e.g. curl -X PUT --header 'Content-Type: application/json' -d '{"pull_audit_log_disable": true}' 'https://localhost/api/v2.0/configurations'
+func PutSysConfigPull(c *harbor.Client, opt *SysConfigPullSet) (*harbor.Result, error) {
+	cfg := make(map[string]bool)
+	for key, value := range map[string]string{
+		"pull_audit_log_disable":    opt.PullAuditLogDisable,
+		"pull_time_update_disable":  opt.PullTimeUpdateDisable,
+		"pull_count_update_disable": opt.PullCountUpdateDisable,
+	} {
+		if value != "" {
+			cfg[key] = value == "true"
+		}
 	}
+	if len(cfg) == 0 {
+		return nil, fmt.Errorf("nothing to set, give at least one of %v", pullConfigKeys)
+	}
+
+	targetURL := c.URL("/api/v2.0/configurations")
+	c.Trace("==> PUT", targetURL)
+
+	t, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	c.Trace("==> configurations:", string(t))
+
+	return c.Do(c.Put(targetURL).
+		Send(string(t)))
+}
+
+// GetSysConfig is for retrieving system configurations that only provides for admin user.
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/configurations'
+func GetSysConfig(c *harbor.Client) (*harbor.Result, error) {
+	targetURL := c.APIURL("/configurations")
+	c.Trace("==> GET", targetURL)
 
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Get(targetURL))
 }
 
 // PutSysConfigCreate is for modifying system configurations that only provides for admin user.
@@ -101,48 +238,180 @@ func GetSysConfig(baseURL string) {
   }
 }' 'https://localhost/api/configurations'
 */
-func PutSysConfigCreate(baseURL string) {
-	targetURL := baseURL
-	fmt.Println("==> PUT", targetURL)
+func PutSysConfigCreate(c *harbor.Client, sc *utils.SysConfig) (*harbor.Result, error) {
+	targetURL := c.APIURL("/configurations")
+	c.Trace("==> PUT", targetURL)
 
-	c, err := utils.CookieLoad()
+	msc, err := json.Marshal(sc)
 	if err != nil {
-		fmt.Println("error:", err)
-		return
+		return nil, err
 	}
 
-	sc, err := utils.SysConfigLoad()
+	return c.Do(c.Put(targetURL).
+		Send(string(msc)))
+}
+
+// PostSysConfigReset resets system configurations from environment variables. Can only be accessed by admin user.
+//
+// e.g. curl -X POST --header 'Content-Type: application/json' --header 'Accept: text/plain' 'https://localhost/api/configurations/reset'
+func PostSysConfigReset(c *harbor.Client) (*harbor.Result, error) {
+	targetURL := c.URL("/api/configurations/reset")
+	c.Trace("==> POST", targetURL)
+
+	return c.Do(c.Post(targetURL))
+}
+
+// sysConfigItem is a system configuration item as returned by the server.
+type sysConfigItem struct {
+	Value    interface{} `json:"value"`
+	Editable bool        `json:"editable"`
+}
+
+// sysConfigSecrets are the configuration items the server never returns,
+// sent as strings.
+var sysConfigSecrets = map[string]bool{
+	"email_password":       true,
+	"ldap_search_password": true,
+	"uaa_client_secret":    true,
+	"oidc_client_secret":   true,
+}
+
+// sysConfigItems gets and decodes the system configuration items.
+func sysConfigItems(c *harbor.Client) (map[string]sysConfigItem, error) {
+	res, err := GetSysConfig(c)
 	if err != nil {
-		fmt.Println("error:", err)
-		return
+		return nil, err
+	}
+	var items map[string]sysConfigItem
+	if err := json.Unmarshal(res.Body, &items); err != nil {
+		return nil, err
 	}
+	return items, nil
+}
 
-	msc, err := json.Marshal(sc)
-	if err != nil {
-		fmt.Println("error:", err)
-		return
+// sysConfigValue returns the value of a configuration item given as a
+// string, typed as its current value.
+func sysConfigValue(key, s string, cur interface{}) (interface{}, error) {
+	switch cur.(type) {
+	case bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not a boolean", key, s)
+		}
+		return b, nil
+	case float64:
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n, nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not a number", key, s)
+		}
+		return f, nil
 	}
+	return s, nil
+}
 
-	utils.Request.Put(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		Send(string(msc)).
-		End(utils.PrintStatus)
+// SysConfigUpdate holds the parameters of PutSysConfigUpdate.
+type SysConfigUpdate struct {
+	Set      map[string]string `short:"s" long:"set" description:"Set this configuration item, e.g. -s token_expiration:60, repeatable."`
+	FromFile string            `short:"f" long:"from-file" description:"Set the configuration items of this flat YAML file, e.g. 'self_registration: false'."`
+	DryRun   bool              `long:"dry-run" description:"Print the changes only."`
 }
 
-// PostSysConfigReset resets system configurations from environment variables. Can only be accessed by admin user.
+func (x *SysConfigUpdate) Execute(args []string) error {
+	res, err := PutSysConfigUpdate(utils.NewClient(), x)
+	if res == nil && err == nil {
+		return nil
+	}
+	return utils.PrintResult(res, err)
+}
+
+// PutSysConfigUpdate changes some system configuration items, given by
+// --set or by a YAML file, typed as their current values. The changes are
+// printed, nothing is sent with --dry-run or when nothing changes.
 //
-// e.g. curl -X POST --header 'Content-Type: application/json' --header 'Accept: text/plain' 'https://localhost/api/configurations/reset'
-func PostSysConfigReset(baseURL string) {
-	targetURL := baseURL
-	fmt.Println("==> POST", targetURL)
+// params:
+//   set - The configuration items to set, key:value.
+//   from-file - The YAML file of configuration items to set.
+//
+// format:
+//   GET /configurations
+//   PUT /configurations
+//
+// e.g. curl -X PUT --header 'Content-Type: application/json' -d '{"token_expiration": 60, "self_registration": false}' 'https://localhost/api/v2.0/configurations'
+func PutSysConfigUpdate(c *harbor.Client, opt *SysConfigUpdate) (*harbor.Result, error) {
+	given := map[string]interface{}{}
+	if opt.FromFile != "" {
+		data, err := ioutil.ReadFile(opt.FromFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, &given); err != nil {
+			return nil, fmt.Errorf("%s: %v", opt.FromFile, err)
+		}
+	}
+	for key, value := range opt.Set {
+		if _, ok := given[key]; ok {
+			return nil, fmt.Errorf("%s both set and in %s", key, opt.FromFile)
+		}
+		given[key] = value
+	}
+	if len(given) == 0 {
+		return nil, fmt.Errorf("nothing to change, see --set and --from-file")
+	}
 
-	c, err := utils.CookieLoad()
+	items, err := sysConfigItems(c)
 	if err != nil {
-		fmt.Println("error:", err)
-		return
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(given))
+	for key := range given {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
+
+	cfg := map[string]interface{}{}
+	for _, key := range keys {
+		value := given[key]
+		item, ok := items[key]
+		switch {
+		case sysConfigSecrets[key]:
+			cfg[key] = fmt.Sprint(value)
+			fmt.Printf("%s: (secret changed)\n", key)
+			continue
+		case !ok:
+			return nil, fmt.Errorf("unknown configuration item %q", key)
+		case !item.Editable:
+			return nil, fmt.Errorf("configuration item %q is read-only", key)
+		}
+
+		switch value.(type) {
+		case string, bool, int, float64:
+		default:
+			return nil, fmt.Errorf("%s: only scalar values can be set", key)
+		}
+		if value, err = sysConfigValue(key, fmt.Sprint(value), item.Value); err != nil {
+			return nil, err
+		}
+		if fmt.Sprint(value) == fmt.Sprint(item.Value) {
+			continue
+		}
+		cfg[key] = value
+		fmt.Printf("%s: %v -> %v\n", key, item.Value, value)
+	}
+	if len(cfg) == 0 {
+		fmt.Println("nothing changed")
+		return nil, nil
+	}
+	if opt.DryRun {
+		return nil, nil
+	}
+
+	targetURL := c.APIURL("/configurations")
+	c.Trace("==> PUT", targetURL)
 
-	utils.Request.Post(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Put(targetURL).
+		Send(cfg))
 }
@@ -0,0 +1,428 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
+	"github.com/moooofly/harbor-go-client/utils"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func init() {
+	utils.Parser.AddCommand("cve_allowlist_export",
+		"Export the system CVE allowlist as YAML.",
+		"Write the system CVE allowlist, the CVEs ignored when preventing vulnerable images from running, and its expiry as YAML, to keep it under version control and import it elsewhere with cve_allowlist_import. (Harbor v1.9+)",
+		&CVEAllowlistExport{})
+	utils.Parser.AddCommand("cve_allowlist_import",
+		"Replace the system CVE allowlist by a YAML file.",
+		"Replace the system CVE allowlist by the one of a YAML file written by cve_allowlist_export. The expiry is taken from the file, 'expires_at' or 'expires_in' relative to now, unless given by --expires-in, --expires-at or --never-expires. (Harbor v1.9+)",
+		&CVEAllowlistImport{})
+	utils.Parser.AddCommand("cve_allowlist_get",
+		"Get the system CVE allowlist.",
+		"This endpoint gets the system CVE allowlist, the CVEs ignored when preventing vulnerable images from running, and its expiry as a Unix time, none if it never expires. (Harbor v1.9+)",
+		&CVEAllowlistGet{})
+	utils.Parser.AddCommand("cve_allowlist_update",
+		"Add CVEs to or remove CVEs from the system CVE allowlist.",
+		"This endpoint updates the system CVE allowlist: --add and --remove (repeatable, or comma-separated) change the CVEs listed, the other ones kept, and --expires-in, --expires-at or --never-expires change its expiry, kept otherwise. The changes are printed, --dry-run prints them only. (Harbor v1.9+)",
+		&CVEAllowlistUpdate{})
+	utils.RequireAdmin("cve_allowlist_import", "cve_allowlist_update")
+	utils.ResponseModel("cve_allowlist_get", model.CVEAllowlist{})
+}
+
+// cveAllowlistFile is the YAML of cve_allowlist_export and
+// cve_allowlist_import:
+//
+//	expires_at: 2027-01-15T00:00:00Z
+//	items:
+//	- CVE-2021-44228
+//
+// expires_in, e.g. "90d", may replace expires_at, the allowlist never
+// expires without both.
+type cveAllowlistFile struct {
+	ExpiresAt *time.Time `yaml:"expires_at,omitempty"`
+	ExpiresIn string     `yaml:"expires_in,omitempty"`
+	Items     []string   `yaml:"items"`
+}
+
+// CVEAllowlistExport holds the parameters of the cve_allowlist_export
+// command.
+type CVEAllowlistExport struct {
+	File string `short:"f" long:"file" description:"The file to write, stdout if not set."`
+}
+
+func (x *CVEAllowlistExport) Execute(args []string) error {
+	c := utils.NewDataClient()
+	list, err := sysCVEAllowlist(c)
+	if err != nil {
+		return err
+	}
+
+	out := cveAllowlistFile{Items: []string{}}
+	if list.ExpiresAt != nil {
+		t := time.Unix(*list.ExpiresAt, 0).UTC()
+		out.ExpiresAt = &t
+	}
+	for _, item := range list.Items {
+		out.Items = append(out.Items, item.CVEID)
+	}
+	sort.Strings(out.Items)
+
+	b, err := yaml.Marshal(&out)
+	if err != nil {
+		return err
+	}
+	b = append([]byte("# system CVE allowlist of "+c.BaseURL()+"\n"), b...)
+	if x.File == "" {
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+	return ioutil.WriteFile(x.File, b, 0644)
+}
+
+// CVEAllowlistImport holds the parameters of the cve_allowlist_import
+// command.
+type CVEAllowlistImport struct {
+	File   string `short:"f" long:"file" description:"(REQUIRED) The YAML file, as written by cve_allowlist_export." required:"yes"`
+	DryRun bool   `long:"dry-run" description:"Print the changes only."`
+	CVEAllowlistExpiry
+}
+
+func (x *CVEAllowlistImport) Execute(args []string) error {
+	data, err := ioutil.ReadFile(x.File)
+	if err != nil {
+		return err
+	}
+	var in cveAllowlistFile
+	if err := yaml.UnmarshalStrict(data, &in); err != nil {
+		return fmt.Errorf("%s: %v", x.File, err)
+	}
+
+	expiresAt, err := x.expiry(&in, time.Now())
+	if err != nil {
+		return err
+	}
+
+	c := utils.NewClient()
+	cur, err := sysCVEAllowlist(c)
+	if err != nil {
+		return err
+	}
+
+	list := &model.CVEAllowlist{Items: []model.CVEAllowlistItem{}}
+	seen := map[string]bool{}
+	for _, id := range in.Items {
+		id = strings.ToUpper(strings.TrimSpace(id))
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		list.Items = append(list.Items, model.CVEAllowlistItem{CVEID: id})
+	}
+	if expiresAt != nil {
+		sec := expiresAt.Unix()
+		list.ExpiresAt = &sec
+	}
+
+	printCVEAllowlistChanges(cur, list)
+	if x.DryRun {
+		return nil
+	}
+
+	res, err := PutSysCVEAllowlist(c, list)
+	if err != nil {
+		return utils.PrintResult(res, err)
+	}
+	return nil
+}
+
+// CVEAllowlistExpiry holds the flags setting the expiry of a CVE allowlist.
+type CVEAllowlistExpiry struct {
+	ExpiresIn    string `long:"expires-in" description:"Expire the allowlist after this long from now, e.g. 90d, 12w or 36h."`
+	ExpiresAt    string `long:"expires-at" description:"Expire the allowlist at this date, e.g. 2027-01-15 or 2027-01-15T08:00:00Z."`
+	NeverExpires bool   `long:"never-expires" description:"Never expire the allowlist."`
+}
+
+// expiry returns the expiry of the flags, nil for never, and whether one
+// is given.
+func (x *CVEAllowlistExpiry) expiry(now time.Time) (*time.Time, bool, error) {
+	given := 0
+	for _, set := range []bool{x.ExpiresIn != "", x.ExpiresAt != "", x.NeverExpires} {
+		if set {
+			given++
+		}
+	}
+	if given > 1 {
+		return nil, true, fmt.Errorf("--expires-in, --expires-at and --never-expires are exclusive")
+	}
+
+	switch {
+	case x.ExpiresAt != "":
+		t, err := parseExpiryDate(x.ExpiresAt)
+		return t, true, err
+	case x.ExpiresIn != "":
+		t, err := expiryIn(x.ExpiresIn, now)
+		return t, true, err
+	}
+	return nil, x.NeverExpires, nil
+}
+
+// expiry returns the expiry of the imported allowlist, nil for never: the
+// one of the flags, otherwise the one of the file.
+func (x *CVEAllowlistImport) expiry(in *cveAllowlistFile, now time.Time) (*time.Time, error) {
+	if t, given, err := x.CVEAllowlistExpiry.expiry(now); given || err != nil {
+		return t, err
+	}
+
+	switch {
+	case in.ExpiresAt != nil && in.ExpiresIn != "":
+		return nil, fmt.Errorf("%s: expires_at and expires_in are exclusive", x.File)
+	case in.ExpiresIn != "":
+		return expiryIn(in.ExpiresIn, now)
+	}
+	return in.ExpiresAt, nil
+}
+
+// expiryIn returns now plus a duration of days ("90d"), weeks ("12w") or
+// of time.ParseDuration ("36h").
+func expiryIn(s string, now time.Time) (*time.Time, error) {
+	var t time.Time
+	if n, err := strconv.Atoi(strings.TrimRight(s, "dw")); err == nil && n > 0 && len(s) > 1 {
+		switch s[len(s)-1] {
+		case 'd':
+			t = now.AddDate(0, 0, n)
+		case 'w':
+			t = now.AddDate(0, 0, 7*n)
+		}
+	}
+	if t.IsZero() {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("bad expiry %q, expected e.g. 90d, 12w or 36h", s)
+		}
+		t = now.Add(d)
+	}
+	return &t, nil
+}
+
+func parseExpiryDate(s string) (*time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("bad expiry date %q, expected e.g. 2027-01-15 or 2027-01-15T08:00:00Z", s)
+}
+
+// CVEAllowlistGet is the cve_allowlist_get command.
+type CVEAllowlistGet struct {
+}
+
+func (x *CVEAllowlistGet) Execute(args []string) error {
+	return utils.PrintResult(GetSysCVEAllowlist(utils.NewClient()))
+}
+
+// CVEAllowlistUpdate holds the parameters of the cve_allowlist_update
+// command.
+type CVEAllowlistUpdate struct {
+	CVEAllowlistEdit
+}
+
+func (x *CVEAllowlistUpdate) Execute(args []string) error {
+	c := utils.NewClient()
+	cur, err := sysCVEAllowlist(c)
+	if err != nil {
+		return err
+	}
+	list, err := x.edit(cur, time.Now())
+	if err != nil {
+		return err
+	}
+
+	printCVEAllowlistChanges(cur, list)
+	if x.DryRun {
+		return nil
+	}
+
+	res, err := PutSysCVEAllowlist(c, list)
+	if err != nil {
+		return utils.PrintResult(res, err)
+	}
+	return nil
+}
+
+// CVEAllowlistEdit holds the flags changing a CVE allowlist, merged with the
+// current one.
+type CVEAllowlistEdit struct {
+	Add    []string `short:"a" long:"add" description:"Add this CVE, e.g. CVE-2021-44228, repeatable or comma-separated."`
+	Remove []string `short:"r" long:"remove" description:"Remove this CVE, repeatable or comma-separated."`
+	DryRun bool     `long:"dry-run" description:"Print the changes only."`
+	CVEAllowlistExpiry
+}
+
+// edit returns the allowlist cur changed by the flags, the CVEs added after
+// those kept, its expiry kept unless changed.
+func (x *CVEAllowlistEdit) edit(cur *model.CVEAllowlist, now time.Time) (*model.CVEAllowlist, error) {
+	add, remove := cveIDs(x.Add), cveIDs(x.Remove)
+	for _, id := range add {
+		for _, r := range remove {
+			if id == r {
+				return nil, fmt.Errorf("%s both added and removed", id)
+			}
+		}
+	}
+	expiresAt, given, err := x.expiry(now)
+	if err != nil {
+		return nil, err
+	}
+	if len(add) == 0 && len(remove) == 0 && !given {
+		return nil, fmt.Errorf("nothing to change, see --add, --remove and --expires-in")
+	}
+
+	list := &model.CVEAllowlist{Items: []model.CVEAllowlistItem{}, ExpiresAt: cur.ExpiresAt}
+	if given {
+		list.ExpiresAt = nil
+		if expiresAt != nil {
+			sec := expiresAt.Unix()
+			list.ExpiresAt = &sec
+		}
+	}
+
+	drop := map[string]bool{}
+	for _, id := range remove {
+		drop[id] = true
+	}
+	for _, item := range cur.Items {
+		if id := strings.ToUpper(item.CVEID); !drop[id] {
+			drop[id] = true
+			list.Items = append(list.Items, item)
+		}
+	}
+	for _, id := range add {
+		if !drop[id] {
+			drop[id] = true
+			list.Items = append(list.Items, model.CVEAllowlistItem{CVEID: id})
+		}
+	}
+	return list, nil
+}
+
+// cveIDs returns the CVE IDs of repeated or comma-separated flag values,
+// upper-cased.
+func cveIDs(values []string) []string {
+	ids := splitList(values)
+	for i, id := range ids {
+		ids[i] = strings.ToUpper(id)
+	}
+	return ids
+}
+
+// splitList returns the items of repeated or comma-separated flag values,
+// trimmed, the empty ones left out.
+func splitList(values []string) []string {
+	var items []string
+	for _, v := range values {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// printCVEAllowlistChanges prints the CVEs removed from cur and added by
+// list, and the expiry of list.
+func printCVEAllowlistChanges(cur, list *model.CVEAllowlist) {
+	kept := map[string]bool{}
+	for _, item := range list.Items {
+		kept[item.CVEID] = true
+	}
+	old := map[string]bool{}
+	for _, item := range cur.Items {
+		old[item.CVEID] = true
+		if !kept[item.CVEID] {
+			fmt.Println("-", item.CVEID)
+		}
+	}
+	for _, item := range list.Items {
+		if !old[item.CVEID] {
+			fmt.Println("+", item.CVEID)
+		}
+	}
+	fmt.Println(cveAllowlistSummary(list))
+}
+
+// cveAllowlistSummary returns the number of CVEs of a list and its expiry.
+func cveAllowlistSummary(list *model.CVEAllowlist) string {
+	if list.ExpiresAt != nil {
+		return fmt.Sprintf("%d CVEs, expiring at %s", len(list.Items), time.Unix(*list.ExpiresAt, 0).UTC().Format(time.RFC3339))
+	}
+	return fmt.Sprintf("%d CVEs, never expiring", len(list.Items))
+}
+
+// sysCVEAllowlistURL returns the URL of the system CVE allowlist, named
+// whitelist before Harbor v2.1.
+func sysCVEAllowlistURL(c *harbor.Client, allowlist bool) string {
+	if allowlist {
+		return c.APIURL("/system/CVEAllowlist")
+	}
+	return c.APIURL("/system/CVEWhitelist")
+}
+
+// sysCVEAllowlist gets and decodes the system CVE allowlist.
+func sysCVEAllowlist(c *harbor.Client) (*model.CVEAllowlist, error) {
+	// The error of a failed request has the body of its response.
+	res, err := GetSysCVEAllowlist(c)
+	if err != nil {
+		return nil, err
+	}
+	var list model.CVEAllowlist
+	if err := json.Unmarshal(res.Body, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// GetSysCVEAllowlist gets the system CVE allowlist.
+//
+// format:
+//   GET /api/v2.0/system/CVEAllowlist
+//   GET /api/system/CVEWhitelist       (v1 API and Harbor v2.0)
+func GetSysCVEAllowlist(c *harbor.Client) (*harbor.Result, error) {
+	targetURL := sysCVEAllowlistURL(c, c.IsV2())
+	c.Trace("==> GET", targetURL)
+
+	res, err := c.Do(c.Get(targetURL))
+	if c.IsV2() && harbor.IsNotFound(err) {
+		targetURL = sysCVEAllowlistURL(c, false)
+		c.Trace("==> GET", targetURL)
+		return c.Do(c.Get(targetURL))
+	}
+	return res, err
+}
+
+// PutSysCVEAllowlist replaces the system CVE allowlist, its expiry included.
+//
+// format:
+//   PUT /api/v2.0/system/CVEAllowlist
+//   PUT /api/system/CVEWhitelist       (v1 API and Harbor v2.0)
+func PutSysCVEAllowlist(c *harbor.Client, list *model.CVEAllowlist) (*harbor.Result, error) {
+	targetURL := sysCVEAllowlistURL(c, c.IsV2())
+	c.Trace("==> PUT", targetURL)
+
+	res, err := c.Do(c.Put(targetURL).Send(list))
+	if c.IsV2() && harbor.IsNotFound(err) {
+		targetURL = sysCVEAllowlistURL(c, false)
+		c.Trace("==> PUT", targetURL)
+		return c.Do(c.Put(targetURL).Send(list))
+	}
+	return res, err
+}
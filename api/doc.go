@@ -1,2 +1,7 @@
 // Package api contains all Harbor REST API interfaces
+//
+// Every operation is sent by a *harbor.Client and returns the response as
+// *harbor.Result, or an error, a non-2xx status is reported as
+// *harbor.APIError. Nothing is printed, except request traces when the
+// client is created with harbor.WithTrace.
 package api // import "github.com/moooofly/harbor-go-client/api"
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+// RegexFilter selects the listed items by name client-side, for the
+// filters the query parameters of Harbor cannot express.
+type RegexFilter struct {
+	FilterRegex string `long:"filter-regex" description:"List only those whose name matches this regular expression, e.g. '^release-' or 'nginx|redis', applied client-side: to all pages with --all or --count, to the listed page otherwise."`
+}
+
+// keep returns the filter of the listed items, whose names are returned by
+// names, nil if the flag is not set.
+func (x *RegexFilter) keep(names func(json.RawMessage) ([]string, error)) (func(json.RawMessage) (bool, error), error) {
+	if x.FilterRegex == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(x.FilterRegex)
+	if err != nil {
+		return nil, fmt.Errorf("--filter-regex: %v", err)
+	}
+	return func(item json.RawMessage) (bool, error) {
+		names, err := names(item)
+		if err != nil {
+			return false, err
+		}
+		for _, name := range names {
+			if re.MatchString(name) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}, nil
+}
+
+// itemName returns the name of a listed repository or tag.
+func itemName(item json.RawMessage) ([]string, error) {
+	var v struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(item, &v); err != nil {
+		return nil, err
+	}
+	return []string{v.Name}, nil
+}
+
+// artifactNames returns the digest and the tags of a listed artifact.
+func artifactNames(item json.RawMessage) ([]string, error) {
+	var a struct {
+		Digest string `json:"digest"`
+		Tags   []struct {
+			Name string `json:"name"`
+		} `json:"tags"`
+	}
+	if err := json.Unmarshal(item, &a); err != nil {
+		return nil, err
+	}
+	names := []string{a.Digest}
+	for _, t := range a.Tags {
+		names = append(names, t.Name)
+	}
+	return names, nil
+}
+
+// keepAll returns the filter accepting the items all of keeps accept, nil
+// ones being left out, nil if there is none.
+func keepAll(keeps ...func(json.RawMessage) (bool, error)) func(json.RawMessage) (bool, error) {
+	var all []func(json.RawMessage) (bool, error)
+	for _, keep := range keeps {
+		if keep != nil {
+			all = append(all, keep)
+		}
+	}
+	if len(all) == 0 {
+		return nil
+	}
+	return func(item json.RawMessage) (bool, error) {
+		for _, keep := range all {
+			if ok, err := keep(item); !ok || err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+}
+
+// listItems lists the items of targetURL, all pages with all. With keep,
+// the items keep accepts only: of all pages as well with count, whose
+// X-Total-Count is then their number.
+func listItems(c *harbor.Client, targetURL string, keep func(json.RawMessage) (bool, error), count, all bool) (*harbor.Result, error) {
+	if keep == nil {
+		if all && !count {
+			return c.StreamAllPages(targetURL), nil
+		}
+		return c.DoStream(c.Get(targetURL))
+	}
+
+	var res *harbor.Result
+	if count || all {
+		res = c.StreamAllPages(targetURL)
+	} else {
+		var err error
+		if res, err = c.DoStream(c.Get(targetURL)); err != nil {
+			return res, err
+		}
+	}
+
+	n := 0
+	res, err := utils.FilterResult(res, func(item json.RawMessage) (bool, error) {
+		ok, err := keep(item)
+		if ok {
+			n++
+		}
+		return ok, err
+	})
+	if err == nil && count {
+		res.Header = http.Header{"X-Total-Count": {strconv.Itoa(n)}}
+	}
+	return res, err
+}
@@ -0,0 +1,242 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+func init() {
+	utils.Parser.AddCommand("gc_run",
+		"Run the garbage collection now.",
+		"This endpoint triggers a garbage collection of the registry storage, --delete-untagged deletes the untagged artifacts too (v2.0 API). gc_history_list and gc_log follow it. (Harbor v1.5+)",
+		&GCRun{})
+	utils.Parser.AddCommand("gc_schedule_get",
+		"Get the schedule of the garbage collection.",
+		"This endpoint gets the schedule of the garbage collection, and its parameters with v2.0 API. (Harbor v1.5+)",
+		&GCScheduleGet{})
+	utils.Parser.AddCommand("gc_schedule_set",
+		"Set the schedule of the garbage collection.",
+		"This endpoint sets the schedule of the garbage collection: hourly, daily, weekly, by a cron expression, or none. (Harbor v1.5+)",
+		&GCScheduleSet{})
+	utils.Parser.AddCommand("gc_history_list",
+		"List the runs of the garbage collection.",
+		"This endpoint lists the runs of the garbage collection, latest first, with their status. (Harbor v1.5+)",
+		&GCHistoryList{})
+	utils.Parser.AddCommand("gc_get",
+		"Get a run of the garbage collection.",
+		"This endpoint gets a run of the garbage collection by specific ID. (Harbor v1.5+)",
+		&GCGet{})
+	utils.Parser.AddCommand("gc_log",
+		"Get the log of a run of the garbage collection.",
+		"This endpoint gets the log of a run of the garbage collection by specific ID, the blobs and manifests deleted. (Harbor v1.5+)",
+		&GCLog{})
+
+	utils.RequireAdmin("gc_run", "gc_schedule_get", "gc_schedule_set", "gc_history_list", "gc_get", "gc_log")
+	utils.ResponseModel("gc_history_list", model.GCHistory{})
+	utils.ResponseModel("gc_get", model.GCHistory{})
+}
+
+// gcScheduleBody returns the body setting the schedule of the garbage
+// collection, deleteUntagged is a parameter of v2.0 API only.
+func gcScheduleBody(c *harbor.Client, schedule *model.GCSchedule, deleteUntagged bool) (interface{}, error) {
+	if !c.IsV2() {
+		if deleteUntagged {
+			return nil, errors.New("--delete-untagged requires the v2.0 API (Harbor v2.0+)")
+		}
+		return map[string]interface{}{"schedule": schedule}, nil
+	}
+	return map[string]interface{}{
+		"schedule":   schedule,
+		"parameters": map[string]interface{}{"delete_untagged": deleteUntagged},
+	}, nil
+}
+
+// GCRun holds the parameters of PostGCRun.
+type GCRun struct {
+	DeleteUntagged bool `long:"delete-untagged" description:"Delete the untagged artifacts too. (v2.0 API)"`
+}
+
+func (x *GCRun) Execute(args []string) error {
+	return utils.PrintResult(PostGCRun(utils.NewClient(), x))
+}
+
+// PostGCRun triggers a garbage collection, as a schedule of type Manual.
+//
+// params:
+//   delete-untagged - Delete the untagged artifacts too.
+//
+// format:
+//   POST /system/gc/schedule
+//
+// e.g. curl -X POST --header 'Content-Type: application/json' -d '{"schedule": {"type": "Manual"}, "parameters": {"delete_untagged": true}}' 'https://localhost/api/v2.0/system/gc/schedule'
+func PostGCRun(c *harbor.Client, opt *GCRun) (*harbor.Result, error) {
+	body, err := gcScheduleBody(c, &model.GCSchedule{Type: "Manual"}, opt.DeleteUntagged)
+	if err != nil {
+		return nil, err
+	}
+
+	targetURL := c.APIURL("/system/gc/schedule")
+	c.Trace("==> POST", targetURL)
+
+	return c.Do(c.Post(targetURL).
+		Send(body))
+}
+
+// GCScheduleGet is the gc_schedule_get command.
+type GCScheduleGet struct {
+}
+
+func (x *GCScheduleGet) Execute(args []string) error {
+	return utils.PrintResult(GetGCSchedule(utils.NewClient()))
+}
+
+// GetGCSchedule gets the schedule of the garbage collection.
+//
+// format:
+//   GET /system/gc/schedule
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/system/gc/schedule'
+//
+func GetGCSchedule(c *harbor.Client) (*harbor.Result, error) {
+	targetURL := c.APIURL("/system/gc/schedule")
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
+}
+
+// GCScheduleSet holds the parameters of PutGCSchedule.
+type GCScheduleSet struct {
+	Type           string `short:"t" long:"type" description:"(REQUIRED) The type of the schedule, Custom with --cron, None to unschedule." required:"yes" choice:"Hourly" choice:"Daily" choice:"Weekly" choice:"Custom" choice:"None"`
+	Cron           string `short:"c" long:"cron" description:"The cron expression of a Custom schedule, with seconds, e.g. '0 0 2 * * *'."`
+	DeleteUntagged bool   `long:"delete-untagged" description:"Delete the untagged artifacts too. (v2.0 API)"`
+}
+
+func (x *GCScheduleSet) Execute(args []string) error {
+	return utils.PrintResult(PutGCSchedule(utils.NewClient(), x))
+}
+
+// PutGCSchedule sets the schedule of the garbage collection.
+//
+// params:
+//   type - (REQUIRED) The type of the schedule.
+//   cron - The cron expression of a Custom schedule.
+//   delete-untagged - Delete the untagged artifacts too.
+//
+// format:
+//   PUT /system/gc/schedule
+//
+// e.g. curl -X PUT --header 'Content-Type: application/json' -d '{"schedule": {"type": "Custom", "cron": "0 0 2 * * *"}, "parameters": {"delete_untagged": false}}' 'https://localhost/api/v2.0/system/gc/schedule'
+func PutGCSchedule(c *harbor.Client, opt *GCScheduleSet) (*harbor.Result, error) {
+	if (opt.Type == "Custom") != (opt.Cron != "") {
+		return nil, errors.New("--cron is required for, and only for, a Custom schedule")
+	}
+	body, err := gcScheduleBody(c, &model.GCSchedule{Type: opt.Type, Cron: opt.Cron}, opt.DeleteUntagged)
+	if err != nil {
+		return nil, err
+	}
+
+	targetURL := c.APIURL("/system/gc/schedule")
+	c.Trace("==> PUT", targetURL)
+
+	return c.Do(c.Put(targetURL).
+		Send(body))
+}
+
+// GCHistoryList holds the parameters of GetGCHistory.
+type GCHistoryList struct {
+	Page     int  `short:"p" long:"page" description:"The page nubmer, default is 1." default:"1"`
+	PageSize int  `short:"s" long:"page_size" description:"The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs." default:"10" validate:"pagesize"`
+	Count    bool `long:"count" description:"Print the total number of matched items only."`
+	All      bool `long:"all" description:"Fetch all pages, --page and --page_size are ignored."`
+}
+
+func (x *GCHistoryList) Execute(args []string) error {
+	return utils.ListPrinter(x.Count)(GetGCHistory(utils.NewClient(), x))
+}
+
+// GetGCHistory lists the runs of the garbage collection. The v1 API lists
+// them all, whatever the page.
+//
+// params:
+//   page - The page nubmer, default is 1.
+//   page_size - The size of per page, default is 10.
+//
+// format:
+//   GET /system/gc
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/system/gc?page=1&page_size=10'
+//
+func GetGCHistory(c *harbor.Client, opt *GCHistoryList) (*harbor.Result, error) {
+	if opt.Count {
+		opt.Page, opt.PageSize = 1, 1
+	}
+
+	targetURL := c.APIURL("/system/gc") + "?page=" + strconv.Itoa(opt.Page) +
+		"&page_size=" + strconv.Itoa(opt.PageSize)
+
+	if !opt.Count {
+		c.Trace("==> GET", targetURL)
+	}
+
+	if opt.All && !opt.Count {
+		return c.StreamAllPages(targetURL), nil
+	}
+
+	return c.DoStream(c.Get(targetURL))
+}
+
+// GCGet holds the parameters of GetGC.
+type GCGet struct {
+	ID int `short:"i" long:"id" description:"(REQUIRED) The ID of the garbage collection run." required:"yes"`
+}
+
+func (x *GCGet) Execute(args []string) error {
+	return utils.PrintResult(GetGC(utils.NewClient(), x))
+}
+
+// GetGC gets a run of the garbage collection.
+//
+// params:
+//   id - (REQUIRED) The ID of the garbage collection run.
+//
+// format:
+//   GET /system/gc/{id}
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/system/gc/3'
+//
+func GetGC(c *harbor.Client, opt *GCGet) (*harbor.Result, error) {
+	targetURL := c.APIURL("/system/gc") + "/" + strconv.Itoa(opt.ID)
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
+}
+
+// GCLog holds the parameters of GetGCLog.
+type GCLog struct {
+	ID int `short:"i" long:"id" description:"(REQUIRED) The ID of the garbage collection run." required:"yes"`
+}
+
+func (x *GCLog) Execute(args []string) error {
+	return utils.PrintResult(GetGCLog(utils.NewClient(), x))
+}
+
+// GetGCLog gets the log of a run of the garbage collection.
+//
+// params:
+//   id - (REQUIRED) The ID of the garbage collection run.
+//
+// format:
+//   GET /system/gc/{id}/log
+//
+// e.g. curl -X GET --header 'Accept: text/plain' 'https://localhost/api/v2.0/system/gc/3/log'
+//
+func GetGCLog(c *harbor.Client, opt *GCLog) (*harbor.Result, error) {
+	targetURL := c.APIURL("/system/gc") + "/" + strconv.Itoa(opt.ID) + "/log"
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
+}
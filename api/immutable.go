@@ -0,0 +1,263 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+func init() {
+	utils.Parser.AddCommand("immutable_rules_list",
+		"List the immutable tag rules of a project.",
+		"This endpoint lists the immutable tag rules of a project, the tags they protect from deletion and overwriting. (Harbor v1.10+)",
+		&ImmutableRulesList{})
+	utils.Parser.AddCommand("immutable_rule_create",
+		"Create an immutable tag rule of a project.",
+		"This endpoint creates an immutable tag rule of a project: the tags matching --tags (or not matching --exclude-tags), in the repositories matching --repos (or not matching --exclude-repos, all if neither), can be neither deleted nor overwritten, e.g. --tags 'v*' protects the release tags. (Harbor v1.10+)",
+		&ImmutableRuleCreate{})
+	utils.Parser.AddCommand("immutable_rule_update",
+		"Update an immutable tag rule of a project.",
+		"This endpoint updates an immutable tag rule of a project by specific ID: the selectors given are changed, the other ones kept; --enable and --disable switch the rule on and off. (Harbor v1.10+)",
+		&ImmutableRuleUpdate{})
+	utils.Parser.AddCommand("immutable_rule_del",
+		"Delete an immutable tag rule of a project.",
+		"This endpoint deletes an immutable tag rule of a project by specific ID. (Harbor v1.10+)",
+		&ImmutableRuleDel{})
+
+	utils.ResponseModel("immutable_rules_list", model.ImmutableRule{})
+}
+
+// immutableURL returns the URL of the immutable tag rules of the project.
+func immutableURL(c *harbor.Client, projectID int) string {
+	return c.APIURL("/projects") + "/" + strconv.Itoa(projectID) + "/immutabletagrules"
+}
+
+// immutableSelector returns the selector of the flags of key (repos or
+// tags), nil if neither include nor exclude is set.
+func immutableSelector(key, include, exclude string) (*model.RetentionSelector, error) {
+	if include == "" && exclude == "" {
+		return nil, nil
+	}
+	if include != "" && exclude != "" {
+		return nil, fmt.Errorf("--%s and --exclude-%s are exclusive", key, key)
+	}
+	kv := map[string]string{key: include, "exclude-" + key: exclude}
+	if key == "repos" {
+		return retentionSelector(kv, key, "repoMatches", "repoExcludes")
+	}
+	return retentionSelector(kv, key, "matches", "excludes")
+}
+
+// ImmutableRulesList holds the parameters of GetImmutableRules.
+type ImmutableRulesList struct {
+	ProjectID int  `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
+	Page      int  `short:"p" long:"page" description:"The page nubmer, default is 1." default:"1"`
+	PageSize  int  `short:"s" long:"page_size" description:"The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs." default:"10" validate:"pagesize"`
+	Count     bool `long:"count" description:"Print the total number of matched items only."`
+	All       bool `long:"all" description:"Fetch all pages, --page and --page_size are ignored."`
+}
+
+func (x *ImmutableRulesList) Execute(args []string) error {
+	return utils.ListPrinter(x.Count)(GetImmutableRules(utils.NewClient(), x))
+}
+
+// GetImmutableRules lists the immutable tag rules of a project.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   page - The page nubmer, default is 1.
+//   page_size - The size of per page, default is 10.
+//
+// format:
+//   GET /projects/{project_id}/immutabletagrules
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/projects/1/immutabletagrules?page=1&page_size=10'
+//
+func GetImmutableRules(c *harbor.Client, opt *ImmutableRulesList) (*harbor.Result, error) {
+	if opt.Count {
+		opt.Page, opt.PageSize = 1, 1
+	}
+
+	targetURL := immutableURL(c, opt.ProjectID) + "?page=" + strconv.Itoa(opt.Page) +
+		"&page_size=" + strconv.Itoa(opt.PageSize)
+
+	if !opt.Count {
+		c.Trace("==> GET", targetURL)
+	}
+
+	if opt.All && !opt.Count {
+		return c.StreamAllPages(targetURL), nil
+	}
+
+	return c.DoStream(c.Get(targetURL))
+}
+
+// ImmutableRuleCreate holds the parameters of PostImmutableRule.
+type ImmutableRuleCreate struct {
+	ProjectID    int    `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
+	Tags         string `short:"t" long:"tags" description:"Protect the tags matching this doublestar pattern, e.g. 'v*' or '{latest,stable}'. (required unless --exclude-tags)"`
+	ExcludeTags  string `long:"exclude-tags" description:"Protect the tags not matching this doublestar pattern."`
+	Repos        string `short:"r" long:"repos" description:"Of the repositories matching this doublestar pattern, e.g. 'team/**'; all if neither --repos nor --exclude-repos."`
+	ExcludeRepos string `long:"exclude-repos" description:"Of the repositories not matching this doublestar pattern."`
+	Disabled     bool   `long:"disabled" description:"Create the rule disabled."`
+}
+
+func (x *ImmutableRuleCreate) Execute(args []string) error {
+	return utils.PrintResult(PostImmutableRule(utils.NewClient(), x))
+}
+
+// PostImmutableRule creates an immutable tag rule of a project.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   tags - The pattern of the tags protected.
+//   exclude-tags - The pattern of the tags not protected.
+//   repos - The pattern of the repositories.
+//   exclude-repos - The pattern of the repositories excluded.
+//   disabled - Create the rule disabled.
+//
+// format:
+//   POST /projects/{project_id}/immutabletagrules
+//
+// e.g. curl -X POST --header 'Content-Type: application/json' -d '{"project_id": 1, "disabled": false, "action": "immutable", "template": "immutable_template", "tag_selectors": [{"kind": "doublestar", "decoration": "matches", "pattern": "v*"}], "scope_selectors": {"repository": [{"kind": "doublestar", "decoration": "repoMatches", "pattern": "**"}]}}' 'https://localhost/api/v2.0/projects/1/immutabletagrules'
+func PostImmutableRule(c *harbor.Client, opt *ImmutableRuleCreate) (*harbor.Result, error) {
+	tags, err := immutableSelector("tags", opt.Tags, opt.ExcludeTags)
+	if err != nil {
+		return nil, err
+	}
+	if tags == nil {
+		return nil, errors.New("one of --tags and --exclude-tags is required")
+	}
+	repos, err := immutableSelector("repos", opt.Repos, opt.ExcludeRepos)
+	if err != nil {
+		return nil, err
+	}
+	if repos == nil {
+		repos, _ = immutableSelector("repos", "**", "")
+	}
+
+	rule := &model.ImmutableRule{
+		ProjectID:      int64(opt.ProjectID),
+		Disabled:       opt.Disabled,
+		Action:         "immutable",
+		Template:       "immutable_template",
+		TagSelectors:   []*model.RetentionSelector{tags},
+		ScopeSelectors: map[string][]*model.RetentionSelector{"repository": {repos}},
+	}
+
+	targetURL := immutableURL(c, opt.ProjectID)
+	c.Trace("==> POST", targetURL)
+
+	return c.Do(c.Post(targetURL).
+		Send(rule))
+}
+
+// ImmutableRuleUpdate holds the parameters of PutImmutableRule.
+type ImmutableRuleUpdate struct {
+	ProjectID    int    `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
+	ID           int    `short:"i" long:"id" description:"(REQUIRED) The ID of the immutable tag rule." required:"yes"`
+	Tags         string `short:"t" long:"tags" description:"The new pattern of the tags protected, unchanged if neither --tags nor --exclude-tags."`
+	ExcludeTags  string `long:"exclude-tags" description:"The new pattern of the tags not protected."`
+	Repos        string `short:"r" long:"repos" description:"The new pattern of the repositories, unchanged if neither --repos nor --exclude-repos."`
+	ExcludeRepos string `long:"exclude-repos" description:"The new pattern of the repositories excluded."`
+	Enable       bool   `long:"enable" description:"Enable the rule."`
+	Disable      bool   `long:"disable" description:"Disable the rule."`
+}
+
+func (x *ImmutableRuleUpdate) Execute(args []string) error {
+	return utils.PrintResult(PutImmutableRule(utils.NewClient(), x))
+}
+
+// PutImmutableRule updates an immutable tag rule of a project, the rule is
+// found among those listed, there is no endpoint to get one, and updated as
+// is but the settings given.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   id - (REQUIRED) The ID of the immutable tag rule.
+//
+// format:
+//   GET /projects/{project_id}/immutabletagrules
+//   PUT /projects/{project_id}/immutabletagrules/{id}
+//
+// e.g. curl -X PUT --header 'Content-Type: application/json' -d '{"id": 2, "project_id": 1, "disabled": true, ...}' 'https://localhost/api/v2.0/projects/1/immutabletagrules/2'
+func PutImmutableRule(c *harbor.Client, opt *ImmutableRuleUpdate) (*harbor.Result, error) {
+	if opt.Enable && opt.Disable {
+		return nil, errors.New("--enable and --disable are exclusive")
+	}
+	tags, err := immutableSelector("tags", opt.Tags, opt.ExcludeTags)
+	if err != nil {
+		return nil, err
+	}
+	repos, err := immutableSelector("repos", opt.Repos, opt.ExcludeRepos)
+	if err != nil {
+		return nil, err
+	}
+
+	listURL := immutableURL(c, opt.ProjectID)
+	c.Trace("==> GET", listURL)
+
+	var rule map[string]interface{}
+	err = c.EachPage(listURL, func(item json.RawMessage) error {
+		var r map[string]interface{}
+		if err := json.Unmarshal(item, &r); err != nil {
+			return err
+		}
+		if id, _ := r["id"].(float64); int(id) == opt.ID {
+			rule = r
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, fmt.Errorf("no immutable tag rule %d in project %d", opt.ID, opt.ProjectID)
+	}
+
+	if tags != nil {
+		rule["tag_selectors"] = []*model.RetentionSelector{tags}
+	}
+	if repos != nil {
+		rule["scope_selectors"] = map[string][]*model.RetentionSelector{"repository": {repos}}
+	}
+	if opt.Enable || opt.Disable {
+		rule["disabled"] = opt.Disable
+	}
+
+	targetURL := listURL + "/" + strconv.Itoa(opt.ID)
+	c.Trace("==> PUT", targetURL)
+	return c.Do(c.Put(targetURL).Send(rule))
+}
+
+// ImmutableRuleDel holds the parameters of DeleteImmutableRule.
+type ImmutableRuleDel struct {
+	ProjectID int `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
+	ID        int `short:"i" long:"id" description:"(REQUIRED) The ID of the immutable tag rule." required:"yes"`
+}
+
+func (x *ImmutableRuleDel) Execute(args []string) error {
+	return utils.PrintResult(DeleteImmutableRule(utils.NewClient(), x))
+}
+
+// DeleteImmutableRule deletes an immutable tag rule of a project.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   id - (REQUIRED) The ID of the immutable tag rule.
+//
+// format:
+//   DELETE /projects/{project_id}/immutabletagrules/{id}
+//
+// e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/v2.0/projects/1/immutabletagrules/2'
+func DeleteImmutableRule(c *harbor.Client, opt *ImmutableRuleDel) (*harbor.Result, error) {
+	targetURL := immutableURL(c, opt.ProjectID) + "/" + strconv.Itoa(opt.ID)
+	c.Trace("==> DELETE", targetURL)
+
+	return c.Do(c.Delete(targetURL))
+}
@@ -2,11 +2,12 @@ package api
 
 import (
 	"encoding/json"
-	"fmt"
-	"os"
+	"net/url"
 	"strconv"
 	"time"
 
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
 	"github.com/moooofly/harbor-go-client/utils"
 )
 
@@ -14,86 +15,88 @@ func init() {
 	utils.Parser.AddCommand("jobs_repl_list_by_filters",
 		"List jobs filtered by specific policy and repository.",
 		"This endpoint let user list jobs filtered by specific policy and repository. (if start_time and end_time are both null, list jobs of last 10 days)",
-		&rplistbyfilter)
+		&ReplListByFilters{})
 	utils.Parser.AddCommand("jobs_repl_stop_by_policy",
 		"Update status of jobs. Only \"stop\" is supported for now.",
 		"The endpoint is used to stop the replication jobs of a policy.",
-		&replstopbypolicy)
+		&ReplStopByPolicy{})
 	utils.Parser.AddCommand("jobs_repl_job_del_by_jid",
 		"Delete replication job with specific ID.",
 		"This endpoint is aimed to remove job with specific ID from jobservice.",
-		&repljobdelbyid)
+		&ReplJobDelByID{})
 	utils.Parser.AddCommand("jobs_repl_log_get_by_jid",
 		"Get replication job logs by specific job ID.",
 		"This endpoint let user search job replication logs filtered by specific job ID.",
-		&repllogbyid)
+		&ReplLogByID{})
 	utils.Parser.AddCommand("jobs_scan_log_get_by_jid",
 		"Get scan job logs by specific job ID.",
 		"This endpoint let user get scan job logs filtered by specific ID.",
-		&scanlogbyid)
+		&ScanLogByID{})
+
+	utils.RequireComponent("jobs_scan_log_get_by_jid", utils.ComponentScanner)
+	utils.RequireAdmin(
+		"jobs_repl_list_by_filters",
+		"jobs_repl_stop_by_policy",
+		"jobs_repl_job_del_by_jid",
+		"jobs_repl_log_get_by_jid",
+	)
+
+	utils.ResponseModel("jobs_repl_list_by_filters", model.ReplicationJob{})
 }
 
-type replListByFilters struct {
+// ReplListByFilters holds the parameters of GetReplListByFilters.
+type ReplListByFilters struct {
 	PolicyID   int    `short:"i" long:"policy_id" description:"(REQUIRED) The ID of the policy that triggered this job. (by targets_list and targets_policies_by_tid)" required:"yes"`
 	Num        int    `short:"n" long:"num" description:"The length of return list." default:"50"`
 	StartTime  string `short:"s" long:"start_time" description:"The start time of jobs. (format: yyyymmdd)" default:""`
 	EndTime    string `short:"e" long:"end_time" description:"The end time of jobs. (format: yyyymmdd)" default:""`
 	Repository string `short:"r" long:"repository" description:"The repository name to be filtered."`
-	Status     string `short:"t" long:"status" description:"The status to be filtered. ([running|error|pending|retrying|stopped|finished|canceled])" default:""`
+	Status     string `short:"t" long:"status" description:"The status to be filtered. ([running|error|pending|retrying|stopped|finished|canceled])" default:"" validate:"oneof=running|error|pending|retrying|stopped|finished|canceled"`
 	Page       int    `short:"p" long:"page" description:"The page nubmer, default is 1." default:"1"`
-	PageSize   int    `short:"z" long:"page_size" description:"The size of per page, default is 10, maximum is 100." default:"10"`
+	PageSize   int    `short:"z" long:"page_size" description:"The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs." default:"10" validate:"pagesize"`
+	Count      bool   `long:"count" description:"Print the total number of matched items only."`
+	All        bool   `long:"all" description:"Fetch all pages, --page and --page_size are ignored."`
 }
 
-var rplistbyfilter replListByFilters
-
-func (x *replListByFilters) Execute(args []string) error {
-	GetReplListByFilters(utils.URLGen("/api/jobs/replication"))
-	return nil
+func (x *ReplListByFilters) Execute(args []string) error {
+	return utils.ListPrinter(x.Count)(GetReplListByFilters(utils.NewClient(), x))
 }
 
-type replStopByPolicy struct {
+// ReplStopByPolicy holds the parameters of PutReplStopByPolicy.
+type ReplStopByPolicy struct {
 	PolicyID int    `short:"i" long:"policy_id" description:"(REQUIRED) The ID of replication policy." required:"yes" json:"policy_id"`
-	Status   string `short:"s" long:"status" description:"(REQUIRED) The status of jobs to be changed into. The only valid value is \"stop\" for now." required:"yes" json:"status"`
+	Status   string `short:"s" long:"status" description:"(REQUIRED) The status of jobs to be changed into. The only valid value is \"stop\" for now." required:"yes" validate:"oneof=stop" json:"status"`
 }
 
-var replstopbypolicy replStopByPolicy
-
-func (x *replStopByPolicy) Execute(args []string) error {
-	PutReplStopByPolicy(utils.URLGen("/api/jobs/replication"))
-	return nil
+func (x *ReplStopByPolicy) Execute(args []string) error {
+	return utils.PrintResult(PutReplStopByPolicy(utils.NewClient(), x))
 }
 
-type replJobDelByID struct {
+// ReplJobDelByID holds the parameters of DelReplJobByID.
+type ReplJobDelByID struct {
 	ID int `short:"i" long:"id" description:"(REQUIRED) Replication job ID to delete." required:"yes" default:""`
 }
 
-var repljobdelbyid replJobDelByID
-
-func (x *replJobDelByID) Execute(args []string) error {
-	DelReplJobByID(utils.URLGen("/api/jobs/replication"))
-	return nil
+func (x *ReplJobDelByID) Execute(args []string) error {
+	return utils.PrintResult(DelReplJobByID(utils.NewClient(), x))
 }
 
-type replLogByID struct {
+// ReplLogByID holds the parameters of GetReplLogByID.
+type ReplLogByID struct {
 	ID int `short:"i" long:"id" description:"(REQUIRED) Relevant job ID." required:"yes" default:""`
 }
 
-var repllogbyid replLogByID
-
-func (x *replLogByID) Execute(args []string) error {
-	GetReplLogByID(utils.URLGen("/api/jobs/replication"))
-	return nil
+func (x *ReplLogByID) Execute(args []string) error {
+	return utils.PrintResult(GetReplLogByID(utils.NewClient(), x))
 }
 
-type scanLogByID struct {
+// ScanLogByID holds the parameters of GetScanLogByID.
+type ScanLogByID struct {
 	ID int `short:"i" long:"id" description:"(REQUIRED) Relevant job ID." required:"yes" default:""`
 }
 
-var scanlogbyid scanLogByID
-
-func (x *scanLogByID) Execute(args []string) error {
-	GetScanLogByID(utils.URLGen("/api/jobs/scan"))
-	return nil
+func (x *ScanLogByID) Execute(args []string) error {
+	return utils.PrintResult(GetScanLogByID(utils.NewClient(), x))
 }
 
 // GetReplListByFilters list filtered jobs according to the policy and repository
@@ -108,68 +111,57 @@ func (x *scanLogByID) Execute(args []string) error {
 //               status must be one of [running|error|pending|retrying|stopped|finished|canceled].
 //               If not set, means 'all' by default.
 //  page       - The page nubmer, default is 1.
-//  page_size  - The size of per page, default is 10, maximum is 100.
+//  page_size  - The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs.
 //
 // operation format:
 //  GET /jobs/replication/{id}/log
 //
 // e.g. curl -X GET --header 'Accept: text/plain' 'https://localhost/api/jobs/replication?page=1&page_size=15&status=finished&start_time=1529884800&end_time=1530057600&policy_id=6'
 //
-func GetReplListByFilters(baseURL string) {
-	if rplistbyfilter.StartTime == "" || rplistbyfilter.EndTime == "" {
+func GetReplListByFilters(c *harbor.Client, opt *ReplListByFilters) (*harbor.Result, error) {
+	if opt.Count {
+		opt.Page, opt.PageSize = 1, 1
+	}
+
+	if opt.StartTime == "" || opt.EndTime == "" {
 		// if start_time and end_time are both null, list jobs of last 10 days
 		now := time.Now()
-		rplistbyfilter.StartTime = now.AddDate(0, 0, -10).Format("20060102")
-		rplistbyfilter.EndTime = now.Format("20060102")
+		opt.StartTime = now.AddDate(0, 0, -10).Format("20060102")
+		opt.EndTime = now.Format("20060102")
 	}
 
-	//fmt.Println("StartTime:", rplistbyfilter.StartTime)
-	//fmt.Println("EndTime:", rplistbyfilter.EndTime)
+	//fmt.Println("StartTime:", opt.StartTime)
+	//fmt.Println("EndTime:", opt.EndTime)
 
-	st, err := time.Parse("20060102", rplistbyfilter.StartTime)
+	st, err := time.Parse("20060102", opt.StartTime)
 	if err != nil {
-		fmt.Println("error:", err)
-		os.Exit(1)
+		return nil, err
 	}
-	et, err := time.Parse("20060102", rplistbyfilter.EndTime)
+	et, err := time.Parse("20060102", opt.EndTime)
 	if err != nil {
-		fmt.Println("error:", err)
-		os.Exit(1)
+		return nil, err
 	}
 
-	if rplistbyfilter.Status != "" &&
-		rplistbyfilter.Status != "running" &&
-		rplistbyfilter.Status != "error" &&
-		rplistbyfilter.Status != "pending" &&
-		rplistbyfilter.Status != "retrying" &&
-		rplistbyfilter.Status != "stopped" &&
-		rplistbyfilter.Status != "finished" &&
-		rplistbyfilter.Status != "canceled" {
-		fmt.Println("error: status must be one of [running|error|pending|retrying|stopped|finished|canceled].")
-		os.Exit(1)
+	targetURL := c.URL("/api/jobs/replication") + "?" + url.Values{
+		"policy_id":  {strconv.Itoa(opt.PolicyID)},
+		"page":       {strconv.Itoa(opt.Page)},
+		"page_size":  {strconv.Itoa(opt.PageSize)},
+		"status":     {opt.Status},
+		"start_time": {strconv.FormatInt(st.Unix(), 10)},
+		"end_time":   {strconv.FormatInt(et.Unix(), 10)},
+		"repository": {opt.Repository},
+		"num":        {strconv.Itoa(opt.Num)},
+	}.Encode()
+
+	if !opt.Count {
+		c.Trace("==> GET", targetURL)
 	}
 
-	targetURL := baseURL + "?policy_id=" + strconv.Itoa(rplistbyfilter.PolicyID) +
-		"&page=" + strconv.Itoa(rplistbyfilter.Page) +
-		"&page_size=" + strconv.Itoa(rplistbyfilter.PageSize) +
-		"&status=" + rplistbyfilter.Status +
-		"&start_time=" + strconv.FormatInt(st.Unix(), 10) +
-		"&end_time=" + strconv.FormatInt(et.Unix(), 10) +
-		"&repository=" + rplistbyfilter.Repository +
-		"&num=" + strconv.Itoa(rplistbyfilter.Num)
-
-	fmt.Println("==> GET", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
+	if opt.All && !opt.Count {
+		return c.StreamAllPages(targetURL), nil
 	}
 
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.DoStream(c.Get(targetURL))
 }
 
 // PutReplStopByPolicy is used to stop the replication jobs of a policy.
@@ -188,30 +180,20 @@ curl -X PUT --header 'Content-Type: application/json' --header 'Accept: text/pla
    "status": "stop" \
 }' 'https://localhost/api/jobs/replication'
 */
-func PutReplStopByPolicy(baseURL string) {
-	targetURL := baseURL
+func PutReplStopByPolicy(c *harbor.Client, opt *ReplStopByPolicy) (*harbor.Result, error) {
+	targetURL := c.URL("/api/jobs/replication")
 
-	fmt.Println("==> PUT", targetURL)
+	c.Trace("==> PUT", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
+	t, err := json.Marshal(opt)
 	if err != nil {
-		fmt.Println("Error:", err)
-		return
+		return nil, err
 	}
 
-	t, err := json.Marshal(&replstopbypolicy)
-	if err != nil {
-		fmt.Println("error:", err)
-		return
-	}
-
-	fmt.Println("==> policyinfo:", string(t))
+	c.Trace("==> policyinfo:", string(t))
 
-	utils.Request.Put(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		Send(string(t)).
-		End(utils.PrintStatus)
+	return c.Do(c.Put(targetURL).
+		Send(string(t)))
 }
 
 // DelReplJobByID is aimed to remove job with specific ID from jobservice.
@@ -224,21 +206,12 @@ func PutReplStopByPolicy(baseURL string) {
 //
 // e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/jobs/replication/1'
 //
-func DelReplJobByID(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(repljobdelbyid.ID)
+func DelReplJobByID(c *harbor.Client, opt *ReplJobDelByID) (*harbor.Result, error) {
+	targetURL := c.URL("/api/jobs/replication") + "/" + strconv.Itoa(opt.ID)
 
-	fmt.Println("==> DELETE", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
+	c.Trace("==> DELETE", targetURL)
 
-	utils.Request.Delete(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Delete(targetURL))
 }
 
 // GetReplLogByID let user search job logs filtered by specific ID.
@@ -251,21 +224,12 @@ func DelReplJobByID(baseURL string) {
 //
 // e.g. curl -X GET --header 'Accept: text/plain' 'https://localhost/api/jobs/replication/1/log'
 //
-func GetReplLogByID(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(repllogbyid.ID) + "/log"
+func GetReplLogByID(c *harbor.Client, opt *ReplLogByID) (*harbor.Result, error) {
+	targetURL := c.URL("/api/jobs/replication") + "/" + strconv.Itoa(opt.ID) + "/log"
 
-	fmt.Println("==> GET", targetURL)
+	c.Trace("==> GET", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
-
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Get(targetURL))
 }
 
 // GetScanLogByID let user get scan job logs filtered by specific ID.
@@ -278,19 +242,10 @@ func GetReplLogByID(baseURL string) {
 //
 // e.g. curl -X GET --header 'Accept: text/plain' 'https://localhost/api/jobs/scan/1/log'
 //
-func GetScanLogByID(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(scanlogbyid.ID) + "/log"
+func GetScanLogByID(c *harbor.Client, opt *ScanLogByID) (*harbor.Result, error) {
+	targetURL := c.URL("/api/jobs/scan") + "/" + strconv.Itoa(opt.ID) + "/log"
 
-	fmt.Println("==> GET", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
+	c.Trace("==> GET", targetURL)
 
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Get(targetURL))
 }
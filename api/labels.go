@@ -2,10 +2,14 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"strconv"
 	"time"
 
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
 	"github.com/moooofly/harbor-go-client/utils"
 )
 
@@ -13,38 +17,47 @@ func init() {
 	utils.Parser.AddCommand("labels_list",
 		"List labels according to the query strings.",
 		"This endpoint let user list labels by name, scope and project_id",
-		&labelslist)
+		&LabelsList{})
 	utils.Parser.AddCommand("label_create",
 		"Post creates a label",
-		"This endpoint let user creates a label.",
-		&labelcreate)
+		"This endpoint let user creates a label. Apply it with artifact_label_add (v2.0 API), repo_label_add and repo_image_label_add (v1 API) or chartrepo_chart_label_add, and remove it with the matching *_label_del command.",
+		&LabelCreate{})
 	utils.Parser.AddCommand("label_del_by_id",
 		"Delete the label specified by ID.",
 		"Delete the label specified by ID.",
-		&labeldel)
+		&LabelDel{})
 	utils.Parser.AddCommand("label_get_by_id",
 		"Get the label specified by ID.",
 		"This endpoint let user get the label by specific ID.",
-		&labelget)
+		&LabelGet{})
 	utils.Parser.AddCommand("label_update",
 		"Update the label properties.",
 		"This endpoint let user update label properties.",
-		&labelupdate)
+		&LabelUpdate{})
+	utils.Parser.AddCommand("label_restore",
+		"Restore a deleted label.",
+		"This endpoint restores a label marked deleted, on Harbor versions marking labels deleted instead of removing them; the label is got and updated as is but its deleted state.",
+		&LabelRestore{})
+
+	utils.ResponseModel("labels_list", model.Label{})
+	utils.ResponseModel("label_get_by_id", model.Label{})
 }
 
-type labelsList struct {
+// LabelsList holds the parameters of GetLabels.
+type LabelsList struct {
 	Name      string `short:"n" long:"name" description:"The label name as filter." default:""`
-	Scope     string `short:"s" long:"scope" description:"(REQUIRED) The label scope. Valid values are 'g' and 'p'. 'g' for global labels and 'p' for project labels." required:"yes"`
-	ProjectID int    `short:"i" long:"project_id" description:"Relevant project ID, Required when scope is 'p'." default:"0"`
+	Scope     string `short:"s" long:"scope" description:"(REQUIRED) The label scope. Valid values are 'g' and 'p'. 'g' for global labels and 'p' for project labels." required:"yes" validate:"oneof=g|p"`
+	ProjectID int    `short:"i" long:"project_id" description:"Relevant project ID, Required when scope is 'p'." default:"0" validate:"required_if=Scope:p"`
 	Page      int    `short:"p" long:"page" description:"The page nubmer, default is 1." default:"1"`
-	PageSize  int    `short:"z" long:"page_size" description:"The size of per page, default is 10, maximum is 100." default:"10"`
-}
+	PageSize  int    `short:"z" long:"page_size" description:"The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs." default:"10" validate:"pagesize"`
+	Count     bool   `long:"count" description:"Print the total number of matched items only."`
+	All       bool   `long:"all" description:"Fetch all pages, --page and --page_size are ignored."`
 
-var labelslist labelsList
+	IncludeDeleted bool `long:"include-deleted" description:"List the labels marked deleted too, see label_restore. --count counts them anyway."`
+}
 
-func (x *labelsList) Execute(args []string) error {
-	GetLabels(utils.URLGen("/api/labels"))
-	return nil
+func (x *LabelsList) Execute(args []string) error {
+	return utils.ListPrinter(x.Count)(GetLabels(utils.NewClient(), x))
 }
 
 // GetLabels let user list labels by name, scope and project_id
@@ -54,51 +67,77 @@ func (x *labelsList) Execute(args []string) error {
 //  scope      - (REQUIRED) The label scope. Valid values are g and p. g for global labels and p for project labels.
 //  project_id - Relevant project ID, required when scope is p.
 //  page       - The page nubmer, default is 1.
-//  page_size  - The size of per page, default is 10, maximum is 100.
+//  page_size  - The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs.
+//
+// The labels marked deleted are left out unless include-deleted is set.
 //
 // operation format:
 //  GET /labels
 //
 // e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/labels?scope=g&page=1&page_size=10'
 //
-func GetLabels(baseURL string) {
-	targetURL := baseURL + "?scope=" + labelslist.Scope +
-		"&name=" + labelslist.Name +
-		"&project_id=" + strconv.Itoa(labelslist.ProjectID) +
-		"&page=" + strconv.Itoa(labelslist.Page) +
-		"&page_size=" + strconv.Itoa(labelslist.PageSize)
+func GetLabels(c *harbor.Client, opt *LabelsList) (*harbor.Result, error) {
+	if opt.Count {
+		opt.Page, opt.PageSize = 1, 1
+	}
 
-	fmt.Println("==> GET", targetURL)
+	targetURL := c.APIURL("/labels") + "?" + url.Values{
+		"scope":      {opt.Scope},
+		"name":       {opt.Name},
+		"project_id": {strconv.Itoa(opt.ProjectID)},
+		"page":       {strconv.Itoa(opt.Page)},
+		"page_size":  {strconv.Itoa(opt.PageSize)},
+	}.Encode()
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
+	if !opt.Count {
+		c.Trace("==> GET", targetURL)
+	}
+
+	if opt.Count {
+		return c.DoStream(c.Get(targetURL))
 	}
 
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	var res *harbor.Result
+	var err error
+	if opt.All {
+		res = c.StreamAllPages(targetURL)
+	} else if res, err = c.DoStream(c.Get(targetURL)); err != nil {
+		return res, err
+	}
+	if opt.IncludeDeleted {
+		return res, nil
+	}
+	return withoutDeletedLabels(res)
 }
 
-type labelCreate struct {
+// withoutDeletedLabels returns the labels listed in res but those marked
+// deleted.
+func withoutDeletedLabels(res *harbor.Result) (*harbor.Result, error) {
+	return utils.FilterResult(res, func(item json.RawMessage) (bool, error) {
+		var l struct {
+			Deleted bool `json:"deleted"`
+		}
+		err := json.Unmarshal(item, &l)
+		return !l.Deleted, err
+	})
+}
+
+// LabelCreate holds the parameters of PostLabelCreate.
+type LabelCreate struct {
 	ID           int    `short:"i" long:"id" description:"The ID of label. If not set, automatically generated by harbor." default:"0" json:"id"`
-	Name         string `short:"n" long:"name" description:"(REQUIRED) The name of label." required:"yes" json:"name"`
-	Description  string `short:"d" long:"description" description:"(REQUIRED) The description of label." required:"yes" json:"description"`
-	Color        string `short:"c" long:"color" description:"The color code of label. (e.g. Format: #A9B6BE)" default:"#000000" json:"color"`
-	Scope        string `short:"s" long:"scope" description:"The scope of label, 'g' for global labels and 'p' for project labels." default:"g" json:"scope"`
-	ProjectID    int    `short:"p" long:"project_id" description:"The project ID if the label is a project label. Required when scope is 'p'." default:"0" json:"project_id"`
+	Name         string `short:"n" long:"name" description:"(REQUIRED) The name of label." validate:"required" json:"name"`
+	Description  string `short:"d" long:"description" description:"(REQUIRED) The description of label." validate:"required" json:"description"`
+	Color        string `short:"c" long:"color" description:"The color code of label. (e.g. Format: #A9B6BE)" default:"#000000" validate:"color" json:"color"`
+	Scope        string `short:"s" long:"scope" description:"The scope of label, 'g' for global labels and 'p' for project labels." default:"g" validate:"oneof=g|p" json:"scope"`
+	ProjectID    int    `short:"p" long:"project_id" description:"The project ID if the label is a project label. Required when scope is 'p'." default:"0" validate:"required_if=Scope:p" json:"project_id"`
 	CreationTime string `long:"creation_time" description:"The creation time of label. default time.Now()" default:"" json:"creation_time"`
 	UpdateTime   string `long:"update_time" description:"The update time of label. default time.Now()" default:"" json:"update_time"`
-	Deleted      bool   `long:"deleted" description:"The label is deleted or not." json:"deleted"`
+	Deleted      bool   `long:"deleted" description:"Not supported, a label is created not deleted." hidden:"yes" json:"deleted"`
+	File         string `short:"f" long:"file" description:"Read the request from a JSON or YAML spec file (or @file, - for stdin) instead of flags." spec:"file" json:"-"`
 }
 
-var labelcreate labelCreate
-
-func (x *labelCreate) Execute(args []string) error {
-	PostLabelCreate(utils.URLGen("/api/labels"))
-	return nil
+func (x *LabelCreate) Execute(args []string) error {
+	return utils.PrintResult(PostLabelCreate(utils.NewClient(), x))
 }
 
 // PostLabelCreate let user creates a label.
@@ -112,7 +151,7 @@ func (x *labelCreate) Execute(args []string) error {
 //   project_id    - Which project id this label belongs to when created. ('0' indicates global label, others indicate specific project)
 //   creation_time - The creation time of label. default time.Now()
 //   update_time   - The update time of label. default time.Now()
-//   deleted       - not sure
+//   deleted       - Not supported, must be false.
 //
 // format:
 //   POST /labels
@@ -124,49 +163,49 @@ curl -X POST --header 'Content-Type: application/json' --header 'Accept: text/pl
    "color": "#000000", \
    "scope": "g", \
    "project_id": 0, \
-   "deleted": true \
+   "deleted": false \
  }' 'https://localhost/api/labels'
 */
-func PostLabelCreate(baseURL string) {
-	if labelcreate.CreationTime == "" || labelcreate.UpdateTime == "" {
-		now := time.Now().Format("2006-01-02T15:04:05Z")
-		labelcreate.CreationTime = now
-		labelcreate.UpdateTime = now
+func PostLabelCreate(c *harbor.Client, opt *LabelCreate) (*harbor.Result, error) {
+	if opt.File != "" {
+		if err := utils.SpecLoad(opt.File, opt); err != nil {
+			return nil, err
+		}
+	}
+	if err := utils.Validate(opt); err != nil {
+		return nil, err
+	}
+	if opt.Deleted {
+		return nil, errors.New("a label cannot be created deleted, create it then delete it with label_del_by_id")
 	}
 
-	targetURL := baseURL
-	fmt.Println("==> POST", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
+	if opt.CreationTime == "" || opt.UpdateTime == "" {
+		now := time.Now().Format("2006-01-02T15:04:05Z")
+		opt.CreationTime = now
+		opt.UpdateTime = now
 	}
 
-	t, err := json.Marshal(&labelcreate)
+	targetURL := c.APIURL("/labels")
+	c.Trace("==> POST", targetURL)
+
+	t, err := json.Marshal(opt)
 	if err != nil {
-		fmt.Println("error:", err)
-		return
+		return nil, err
 	}
 
-	fmt.Println("==> label add:", string(t))
+	c.Trace("==> label add:", string(t))
 
-	utils.Request.Post(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		Send(string(t)).
-		End(utils.PrintStatus)
+	return c.Do(c.Post(targetURL).
+		Send(string(t)))
 }
 
-type labelDel struct {
+// LabelDel holds the parameters of DeleteLabel.
+type LabelDel struct {
 	ID int `short:"i" long:"id" description:"(REQUIRED) Label ID." required:"yes"`
 }
 
-var labeldel labelDel
-
-func (x *labelDel) Execute(args []string) error {
-	DeleteLabel(utils.URLGen("/api/labels"))
-	return nil
+func (x *LabelDel) Execute(args []string) error {
+	return utils.PrintResult(DeleteLabel(utils.NewClient(), x))
 }
 
 // DeleteLabel deletes the label specified by ID.
@@ -179,32 +218,21 @@ func (x *labelDel) Execute(args []string) error {
 //
 // e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/labels/100'
 //
-func DeleteLabel(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(labeldel.ID)
-
-	fmt.Println("==> DELETE", targetURL)
+func DeleteLabel(c *harbor.Client, opt *LabelDel) (*harbor.Result, error) {
+	targetURL := c.APIURL("/labels") + "/" + strconv.Itoa(opt.ID)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
+	c.Trace("==> DELETE", targetURL)
 
-	utils.Request.Delete(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Delete(targetURL))
 }
 
-type labelGet struct {
+// LabelGet holds the parameters of GetLabel.
+type LabelGet struct {
 	ID int `short:"i" long:"id" description:"(REQUIRED) Label ID." required:"yes"`
 }
 
-var labelget labelGet
-
-func (x *labelGet) Execute(args []string) error {
-	GetLabel(utils.URLGen("/api/labels"))
-	return nil
+func (x *LabelGet) Execute(args []string) error {
+	return utils.PrintResult(GetLabel(utils.NewClient(), x))
 }
 
 // GetLabel gets the label specified by ID.
@@ -217,40 +245,29 @@ func (x *labelGet) Execute(args []string) error {
 //
 // e.g. curl -X GET --header 'Accept: text/plain' 'https://localhost/api/labels/100'
 //
-func GetLabel(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(labelget.ID)
+func GetLabel(c *harbor.Client, opt *LabelGet) (*harbor.Result, error) {
+	targetURL := c.APIURL("/labels") + "/" + strconv.Itoa(opt.ID)
 
-	fmt.Println("==> GET", targetURL)
+	c.Trace("==> GET", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
-
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Get(targetURL))
 }
 
-type labelUpdate struct {
+// LabelUpdate holds the parameters of PutLabelUpdate.
+type LabelUpdate struct {
 	ID          int    `short:"i" long:"id" description:"(REQUIRED) Label ID." required:"yes" json:"id"`
 	Name        string `short:"n" long:"name" description:"(REQUIRED) The name of label." required:"yes" json:"name"`
 	Description string `short:"d" long:"description" description:"(REQUIRED) The description of label." required:"yes" json:"description"`
-	Color       string `short:"c" long:"color" description:"The color code of label. (e.g. Format: #A9B6BE)" default:"#000000" json:"color"`
-	Scope       string `short:"s" long:"scope" description:"The scope of label, 'g' for global labels and 'p' for project labels." default:"g" json:"scope"`
-	ProjectID   int    `short:"p" long:"project_id" description:"The project ID if the label is a project label. Required when scope is 'p'." default:"0" json:"project_id"`
+	Color       string `short:"c" long:"color" description:"The color code of label. (e.g. Format: #A9B6BE)" default:"#000000" validate:"color" json:"color"`
+	Scope       string `short:"s" long:"scope" description:"The scope of label, 'g' for global labels and 'p' for project labels." default:"g" validate:"oneof=g|p" json:"scope"`
+	ProjectID   int    `short:"p" long:"project_id" description:"The project ID if the label is a project label. Required when scope is 'p'." default:"0" validate:"required_if=Scope:p" json:"project_id"`
 	//CreationTime string `long:"creation_time" description:"The creation time of label. default time.Now()" default:"" json:"creation_time"`
 	//UpdateTime   string `long:"update_time" description:"The update time of label. default time.Now()" default:"" json:"update_time"`
-	Deleted bool `long:"deleted" description:"The label is deleted or not." json:"deleted"`
+	Deleted bool `long:"deleted" description:"Mark the label deleted, see label_del_by_id and label_restore instead." hidden:"yes" json:"deleted"`
 }
 
-var labelupdate labelUpdate
-
-func (x *labelUpdate) Execute(args []string) error {
-	PutLabelUpdate(utils.URLGen("/api/labels"))
-	return nil
+func (x *LabelUpdate) Execute(args []string) error {
+	return utils.PrintResult(PutLabelUpdate(utils.NewClient(), x))
 }
 
 // PutLabelUpdate let user update label properties.
@@ -264,7 +281,7 @@ func (x *labelUpdate) Execute(args []string) error {
 //   project_id    - Which project id this label belongs to when created. ('0' indicates global label, others indicate specific project)
 //   creation_time - The creation time of label. default time.Now()
 //   update_time   - The update time of label. default time.Now()
-//   deleted       - not sure
+//   deleted       - Mark the label deleted, see DeleteLabel and PutLabelRestore instead.
 //
 // operation format:
 //   PUT /labels/{id}
@@ -279,37 +296,77 @@ curl -X PUT --header 'Content-Type: application/json' --header 'Accept: text/pla
    "deleted": true \
  }' 'https://localhost/api/labels/100'
 */
-func PutLabelUpdate(baseURL string) {
+func PutLabelUpdate(c *harbor.Client, opt *LabelUpdate) (*harbor.Result, error) {
 	// NOTE:
 	// Though as swagger shows, both creation_time and creation_time can be updated, but actually not
 	/*
-		if labelupdate.UpdateTime == "" {
+		if opt.UpdateTime == "" {
 			now := time.Now().Format("2006-01-02T15:04:05Z")
-			labelupdate.UpdateTime = now
+			opt.UpdateTime = now
 		}
 	*/
 
-	targetURL := baseURL + "/" + strconv.Itoa(labelupdate.ID)
-	fmt.Println("==> PUT", targetURL)
+	targetURL := c.APIURL("/labels") + "/" + strconv.Itoa(opt.ID)
+	c.Trace("==> PUT", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
+	t, err := json.Marshal(opt)
 	if err != nil {
-		fmt.Println("Error:", err)
-		return
+		return nil, err
 	}
 
-	t, err := json.Marshal(&labelupdate)
-	if err != nil {
-		fmt.Println("error:", err)
-		return
+	c.Trace("==> label add:", string(t))
+
+	return c.Do(c.Put(targetURL).
+		Set("Content-Type", "application/json").
+		Send(string(t)))
+}
+
+// LabelRestore holds the parameters of PutLabelRestore.
+type LabelRestore struct {
+	ID int `short:"i" long:"id" description:"(REQUIRED) Label ID." required:"yes"`
+}
+
+func (x *LabelRestore) Execute(args []string) error {
+	return utils.PrintResult(PutLabelRestore(utils.NewClient(), x))
+}
+
+// PutLabelRestore restores a label marked deleted: the label is got, and
+// updated as is but its deleted state. Harbor versions removing labels on
+// deletion, or ignoring the deleted state on update, cannot restore them:
+// an error tells so.
+//
+// params:
+//   id - Label ID.
+//
+// operation format:
+//   GET /labels/{id}
+//   PUT /labels/{id}
+//
+// e.g. curl -X PUT --header 'Content-Type: application/json' --header 'Accept: text/plain' -d '{"id": 100, "name": "label-name-100", "deleted": false}' 'https://localhost/api/labels/100'
+func PutLabelRestore(c *harbor.Client, opt *LabelRestore) (*harbor.Result, error) {
+	targetURL := c.APIURL("/labels") + "/" + strconv.Itoa(opt.ID)
+	c.Trace("==> GET", targetURL)
+
+	var label map[string]interface{}
+	if err := c.GetJSON(targetURL, &label); err != nil {
+		return nil, err
 	}
+	if deleted, _ := label["deleted"].(bool); !deleted {
+		return nil, fmt.Errorf("label %d is not deleted", opt.ID)
+	}
+	label["deleted"] = false
 
-	fmt.Println("==> label add:", string(t))
+	c.Trace("==> PUT", targetURL)
+	res, err := c.Do(c.Put(targetURL).Send(label))
+	if err != nil {
+		return res, err
+	}
 
-	utils.Request.Put(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		Set("Content-Type", "application/json").
-		Send(string(t)).
-		End(utils.PrintStatus)
+	if err := c.GetJSON(targetURL, &label); err != nil {
+		return nil, err
+	}
+	if deleted, _ := label["deleted"].(bool); deleted {
+		return nil, fmt.Errorf("label %d is still deleted, this Harbor does not support restoring labels", opt.ID)
+	}
+	return res, nil
 }
@@ -1 +1,233 @@
 package api
+
+import (
+	"errors"
+	"net/url"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+func init() {
+	utils.Parser.AddCommand("ldap_ping",
+		"Ping the LDAP server.",
+		"This endpoint pings the LDAP server: with the saved settings when no flag is given, otherwise with the settings given, the other ones (the search password included) taken from the saved ones, to validate them before saving them.",
+		&LDAPPing{})
+	utils.Parser.AddCommand("ldap_users_search",
+		"Search the users of LDAP.",
+		"This endpoint searches the users of LDAP by username, with the saved LDAP settings.",
+		&LDAPUsersSearch{})
+	utils.Parser.AddCommand("ldap_groups_search",
+		"Search the groups of LDAP.",
+		"This endpoint searches the groups of LDAP by name or by DN, with the saved LDAP settings, to register them with usergroup_create.",
+		&LDAPGroupsSearch{})
+	utils.Parser.AddCommand("ldap_users_import",
+		"Import users of LDAP.",
+		"This endpoint imports users of LDAP into Harbor by uid before they log in, e.g. to add them to projects; the uids which cannot be imported are returned.",
+		&LDAPUsersImport{})
+	utils.RequireAdmin("ldap_ping", "ldap_users_search", "ldap_groups_search", "ldap_users_import")
+
+	utils.ResponseModel("ldap_users_search", model.LDAPUser{})
+	utils.ResponseModel("ldap_groups_search", model.UserGroup{})
+}
+
+// ldapPingKeys are the configuration items of the LDAP settings pinged.
+var ldapPingKeys = []string{
+	"ldap_url",
+	"ldap_search_dn",
+	"ldap_base_dn",
+	"ldap_filter",
+	"ldap_uid",
+	"ldap_scope",
+	"ldap_timeout",
+	"ldap_connection_timeout",
+	"ldap_verify_cert",
+}
+
+// LDAPPing holds the parameters of PostLDAPPing.
+type LDAPPing struct {
+	URL            string `long:"ldap_url" description:"The URL of LDAP server, e.g. ldaps://ldap.mydomain.com." json:"ldap_url"`
+	SearchDN       string `long:"ldap_search_dn" description:"The DN of the user searching LDAP." json:"ldap_search_dn"`
+	SearchPassword string `long:"ldap_search_password" description:"The password of the user searching LDAP, the saved one if not given." json:"ldap_search_password"`
+	BaseDN         string `long:"ldap_base_dn" description:"The base DN of the users." json:"ldap_base_dn"`
+	Filter         string `long:"ldap_filter" description:"The filter of the users." json:"ldap_filter"`
+	UID            string `long:"ldap_uid" description:"The attribute of the users' uid, e.g. uid or cn." json:"ldap_uid"`
+	Scope          int    `long:"ldap_scope" description:"The search scope: 0 base, 1 one level, 2 subtree." json:"ldap_scope"`
+	Timeout        int    `long:"ldap_connection_timeout" description:"The connection timeout in seconds." json:"ldap_connection_timeout"`
+	VerifyCert     bool   `long:"ldap_verify_cert" description:"Verify the certificate of LDAP server." json:"ldap_verify_cert"`
+
+	// given holds the flags given on the command line, the other settings
+	// are the saved ones. All are given when nil.
+	given map[string]bool
+}
+
+func (x *LDAPPing) Execute(args []string) error {
+	x.given = utils.FlagsGiven()
+	return utils.PrintResult(PostLDAPPing(utils.NewClient(), x))
+}
+
+// PostLDAPPing pings the LDAP server. The server pings with its saved
+// settings when none is sent, and with its saved search password when none
+// is sent; the settings not given on the command line are filled from the
+// saved configuration.
+//
+// params:
+//   ldap_url - The URL of LDAP server.
+//   ldap_search_dn - The DN of the user searching LDAP.
+//   ldap_search_password - The password of the user searching LDAP.
+//   ldap_base_dn - The base DN of the users.
+//   ldap_filter - The filter of the users.
+//   ldap_uid - The attribute of the users' uid.
+//   ldap_scope - The search scope.
+//   ldap_connection_timeout - The connection timeout in seconds.
+//   ldap_verify_cert - Verify the certificate of LDAP server.
+//
+// format:
+//   POST /ldap/ping
+//
+// e.g. curl -X POST --header 'Content-Type: application/json' -d '{"ldap_url": "ldaps://ldap.mydomain.com", "ldap_search_dn": "cn=admin,dc=mydomain,dc=com", "ldap_base_dn": "ou=people,dc=mydomain,dc=com", "ldap_uid": "uid", "ldap_scope": 2}' 'https://localhost/api/v2.0/ldap/ping'
+func PostLDAPPing(c *harbor.Client, opt *LDAPPing) (*harbor.Result, error) {
+	targetURL := c.APIURL("/ldap/ping")
+
+	if opt.given != nil && len(opt.given) == 0 {
+		c.Trace("==> POST", targetURL)
+		return c.Do(c.Post(targetURL))
+	}
+
+	body := map[string]interface{}{}
+	if opt.given != nil {
+		items, err := sysConfigItems(c)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range ldapPingKeys {
+			if item, ok := items[key]; ok {
+				body[key] = item.Value
+			}
+		}
+	}
+	for key, value := range map[string]interface{}{
+		"ldap_url":                opt.URL,
+		"ldap_search_dn":          opt.SearchDN,
+		"ldap_search_password":    opt.SearchPassword,
+		"ldap_base_dn":            opt.BaseDN,
+		"ldap_filter":             opt.Filter,
+		"ldap_uid":                opt.UID,
+		"ldap_scope":              opt.Scope,
+		"ldap_connection_timeout": opt.Timeout,
+		"ldap_verify_cert":        opt.VerifyCert,
+	} {
+		if opt.given == nil || opt.given[key] {
+			body[key] = value
+		}
+	}
+	// ldap_timeout is the name of the saved connection timeout.
+	if t, ok := body["ldap_timeout"]; ok {
+		if _, ok := body["ldap_connection_timeout"]; !ok {
+			body["ldap_connection_timeout"] = t
+		}
+		delete(body, "ldap_timeout")
+	}
+
+	c.Trace("==> POST", targetURL)
+	return c.Do(c.Post(targetURL).
+		Send(body))
+}
+
+// LDAPUsersSearch holds the parameters of GetLDAPUsersSearch.
+type LDAPUsersSearch struct {
+	Username string `short:"u" long:"username" description:"(REQUIRED) The username, or a part of it, to search." required:"yes"`
+}
+
+func (x *LDAPUsersSearch) Execute(args []string) error {
+	return utils.PrintResult(GetLDAPUsersSearch(utils.NewClient(), x))
+}
+
+// GetLDAPUsersSearch searches the users of LDAP by username.
+//
+// params:
+//   username - (REQUIRED) The username to search.
+//
+// format:
+//   GET /ldap/users/search?username={username}
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/ldap/users/search?username=alice'
+//
+func GetLDAPUsersSearch(c *harbor.Client, opt *LDAPUsersSearch) (*harbor.Result, error) {
+	targetURL := c.APIURL("/ldap/users/search") + "?username=" + url.QueryEscape(opt.Username)
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
+}
+
+// LDAPGroupsSearch holds the parameters of GetLDAPGroupsSearch.
+type LDAPGroupsSearch struct {
+	GroupName string `short:"n" long:"groupname" description:"The name of the group to search. (required unless --groupdn)"`
+	GroupDN   string `short:"d" long:"groupdn" description:"The DN of the group to search."`
+}
+
+func (x *LDAPGroupsSearch) Execute(args []string) error {
+	return utils.PrintResult(GetLDAPGroupsSearch(utils.NewClient(), x))
+}
+
+// GetLDAPGroupsSearch searches the groups of LDAP by name or by DN.
+//
+// params:
+//   groupname - The name of the group.
+//   groupdn - The DN of the group.
+//
+// format:
+//   GET /ldap/groups/search?groupname={groupname}&groupdn={groupdn}
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/ldap/groups/search?groupname=developers'
+//
+func GetLDAPGroupsSearch(c *harbor.Client, opt *LDAPGroupsSearch) (*harbor.Result, error) {
+	if (opt.GroupName == "") == (opt.GroupDN == "") {
+		return nil, errors.New("one of --groupname and --groupdn is required")
+	}
+
+	q := url.Values{}
+	if opt.GroupName != "" {
+		q.Set("groupname", opt.GroupName)
+	}
+	if opt.GroupDN != "" {
+		q.Set("groupdn", opt.GroupDN)
+	}
+	targetURL := c.APIURL("/ldap/groups/search") + "?" + q.Encode()
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
+}
+
+// LDAPUsersImport holds the parameters of PostLDAPUsersImport.
+type LDAPUsersImport struct {
+	UIDs []string `short:"u" long:"uid" description:"(REQUIRED) The uid of a user to import, repeatable or comma-separated." required:"yes"`
+}
+
+func (x *LDAPUsersImport) Execute(args []string) error {
+	return utils.PrintResult(PostLDAPUsersImport(utils.NewClient(), x))
+}
+
+// PostLDAPUsersImport imports users of LDAP by uid. The server answers 404
+// with the uids which cannot be imported, the others are.
+//
+// params:
+//   uid - (REQUIRED) The uids of the users to import.
+//
+// format:
+//   POST /ldap/users/import
+//
+// e.g. curl -X POST --header 'Content-Type: application/json' -d '{"ldap_uid_list": ["alice", "bob"]}' 'https://localhost/api/v2.0/ldap/users/import'
+func PostLDAPUsersImport(c *harbor.Client, opt *LDAPUsersImport) (*harbor.Result, error) {
+	uids := splitList(opt.UIDs)
+	if len(uids) == 0 {
+		return nil, errors.New("--uid is required")
+	}
+
+	targetURL := c.APIURL("/ldap/users/import")
+	c.Trace("==> POST", targetURL)
+
+	return c.Do(c.Post(targetURL).
+		Send(map[string][]string{"ldap_uid_list": uids}))
+}
@@ -1,77 +1,101 @@
 package api
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
+	"os"
 
+	"github.com/moooofly/harbor-go-client/harbor"
 	"github.com/moooofly/harbor-go-client/utils"
 )
 
 func init() {
 	utils.Parser.AddCommand("login",
-		"Log in to Harbor.", "Log in to Harbor with username and password.", &li)
+		"Log in to Harbor.", "Log in to Harbor with username and password.", &Login{})
 	utils.Parser.AddCommand("logout",
-		"Log out from Harbor.", "Log out current user from Harbor.", &lo)
+		"Log out from Harbor.", "Log out current user from Harbor.", &Logout{})
 }
 
-type login struct {
+// Login holds the parameters of LoginHarbor.
+type Login struct {
 	Username string `short:"u" long:"username" description:"(REQUIRED) Current login username." required:"yes"`
-	Password string `short:"p" long:"password" description:"Current login password." default:""`
+	Password string `short:"p" long:"password" env:"HARBOR_PASSWORD" description:"Current login password." default:""`
+	// For CI and service accounts, e.g. `cat secret | harbor-go-client login -u robot --password-stdin`
+	PasswordStdin bool `long:"password-stdin" description:"Take the password from stdin."`
 	// FIXME:
 	// 需要设计一种可以覆盖 config.yaml 配置文件中 dstip 的方式
 	//Address  string `short:"a" long:"address" description:"The specified ip address of the harbor service." default:""`
 }
 
-var li login
+func (x *Login) Execute(args []string) error {
+	// HARBOR_PASSWORD sets the password too, only --password counts.
+	given := utils.FlagsGiven()["password"]
+	if x.PasswordStdin {
+		if given {
+			return errors.New("--password and --password-stdin are mutually exclusive")
+		}
 
-func (x *login) Execute(args []string) error {
-	LoginHarbor(utils.URLGen("/login"))
-	return nil
-}
+		passwd, err := utils.ReadPasswordFromStdin()
+		if err != nil {
+			return err
+		}
+		x.Password = passwd
+	} else if x.Password == "" {
+		// 支持密码隐藏功能
+		passwd, err := utils.ReadPasswordFromTerm()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "hint: use --password-stdin or set HARBOR_PASSWORD when no terminal is available.")
+			return err
+		}
+
+		x.Password = passwd
+	} else if given {
+		fmt.Fprintln(os.Stderr, "WARNING! Using --password via the CLI is insecure. Use --password-stdin or HARBOR_PASSWORD.")
+	}
 
-type logout struct {
+	res, err := LoginHarbor(utils.NewClient(), x)
+	if res != nil {
+		fmt.Println("<== Cookies:", (&http.Response{Header: res.Header}).Cookies())
+	}
+	if err == nil {
+		err = utils.SaveSession(res)
+	}
+	return utils.PrintResult(res, err)
 }
 
-var lo logout
+// Logout is the logout command.
+type Logout struct {
+}
 
-func (x *logout) Execute(args []string) error {
-	LogoutHarbor(utils.URLGen("/log_out"))
-	return nil
+func (x *Logout) Execute(args []string) error {
+	res, err := LogoutHarbor(utils.NewClient())
+	if err == nil {
+		err = utils.DropSession()
+	}
+	return utils.PrintResult(res, err)
 }
 
-// LoginHarbor log in to Harbor.
+// LoginHarbor log in to Harbor, the session ID set by the response is got by
+// harbor.SessionID.
 //
 // params:
 // 	username - Current login username.
 //  password - Current login password.
 //
 // e.g. curl -X POST --header 'Content-Type: application/x-www-form-urlencoded;param=value' 'https://localhost/login' -i -k -d "principal=admin&password=Harbor12345"
-func LoginHarbor(baseURL string) {
-
-	if li.Password == "" {
-		// 支持密码隐藏功能
-		passwd, err := utils.ReadPasswordFromTerm()
-		if err != nil {
-			fmt.Println("error:", err)
-			return
-		}
-
-		if passwd == "" {
-			fmt.Println("error: Password Required.")
-			return
-		}
-
-		li.Password = passwd
-	} else {
-		fmt.Println("WARNING! Using --password via the CLI is insecure.")
+func LoginHarbor(c *harbor.Client, opt *Login) (*harbor.Result, error) {
+	if opt.Password == "" {
+		return nil, errors.New("password required")
 	}
 
-	targetURL := baseURL
-	fmt.Println("==> POST", targetURL)
+	targetURL := c.URL("/login")
+	c.Trace("==> POST", targetURL)
 
-	//fmt.Printf("==> username: %s   password: %s   escape: %s\n", li.Username, li.Password, url.QueryEscape(li.Password))
+	//fmt.Printf("==> username: %s   password: %s   escape: %s\n", opt.Username, opt.Password, url.QueryEscape(opt.Password))
 
-	utils.Request.Post(targetURL).
+	return c.Do(c.Post(targetURL).
 		Set("Content-Type", "application/x-www-form-urlencoded;param=value").
 		// NOTE:
 		// After some experiments, conclude that the value of Cookie has two forms:
@@ -83,8 +107,7 @@ func LoginHarbor(baseURL string) {
 		//
 		// Taking the second form just for long-live coding.
 		Set("Cookie", "harbor-lang=zh-cn").
-		Send("principal=" + li.Username + "&password=" + url.QueryEscape(li.Password)).
-		End(utils.LoginProc)
+		Send("principal=" + opt.Username + "&password=" + url.QueryEscape(opt.Password)))
 }
 
 // LogoutHarbor log out from Harbor.
@@ -92,17 +115,9 @@ func LoginHarbor(baseURL string) {
 // params:
 //
 // e.g. curl -X GET 'https://localhost/log_out' -i -k
-func LogoutHarbor(baseURL string) {
-	targetURL := baseURL
-	fmt.Println("==> GET", targetURL)
-
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
+func LogoutHarbor(c *harbor.Client) (*harbor.Result, error) {
+	targetURL := c.URL("/log_out")
+	c.Trace("==> GET", targetURL)
 
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.LogoutProc)
+	return c.Do(c.Get(targetURL))
 }
@@ -1,21 +1,29 @@
 package api
 
 import (
-	"fmt"
-	"os"
+	"errors"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/moooofly/harbor-go-client/harbor"
 	"github.com/moooofly/harbor-go-client/utils"
 )
 
 func init() {
-	utils.Parser.AddCommand("logs",
+	cmd, _ := utils.Parser.AddCommand("logs",
 		"Get recent logs of the projects which the user is a member of.",
-		"This endpoint let user see the recent operation logs of the projects which he is member of.",
-		&logs)
+		"This endpoint let user see the recent operation logs of the projects which he is member of, the audit logs with v2.0 API, filtered by operator, operation, repository, tag and time range; -j only lists those of a project, as prj_logs_get does, and --all streams the whole history page after page.",
+		&RecentLogs{})
+	if cmd != nil {
+		cmd.Aliases = []string{"logs_list"}
+	}
 }
 
-type recentLogs struct {
+// RecentLogs holds the parameters of GetOPLogs.
+type RecentLogs struct {
+	ProjectID      int    `short:"j" long:"project_id" description:"Only the logs of this project."`
 	Username       string `short:"u" long:"username" description:"Username of the operator."`
 	Repository     string `short:"r" long:"repository" description:"The name of repository."`
 	Tag            string `short:"t" long:"tag" description:"The name of tag."`
@@ -23,47 +31,106 @@ type recentLogs struct {
 	BeginTimestamp string `short:"b" long:"begin_timestamp" description:"The begin timestamp. (format: yyyymmdd)"`
 	EndTimestamp   string `short:"e" long:"end_timestamp" description:"The end timestamp. (format: yyyymmdd)"`
 	Page           int    `short:"p" long:"page" description:"The page nubmer, default is 1." default:"1"`
-	PageSize       int    `short:"s" long:"page_size" description:"The size of per page, default is 10, maximum is 100." default:"10"`
+	PageSize       int    `short:"s" long:"page_size" description:"The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs." default:"10" validate:"pagesize"`
+	Count          bool   `long:"count" description:"Print the total number of matched items only."`
+	All            bool   `long:"all" description:"Fetch all pages, --page and --page_size are ignored."`
 }
 
-var logs recentLogs
-
-func (x *recentLogs) Execute(args []string) error {
-	GetOPLogs(utils.URLGen("/api/logs"))
-	return nil
+func (x *RecentLogs) Execute(args []string) error {
+	if x.ProjectID != 0 {
+		return utils.ListPrinter(x.Count)(GetPrjLogs(utils.NewClient(), &ProjectLogsGet{
+			ProjectID:      x.ProjectID,
+			Username:       x.Username,
+			Repository:     x.Repository,
+			Tag:            x.Tag,
+			Operation:      x.Operation,
+			BeginTimestamp: x.BeginTimestamp,
+			EndTimestamp:   x.EndTimestamp,
+			Page:           x.Page,
+			PageSize:       x.PageSize,
+			Count:          x.Count,
+			All:            x.All,
+		}))
+	}
+	return utils.ListPrinter(x.Count)(GetOPLogs(utils.NewClient(), x))
 }
 
 // GetOPLogs ...
 //
 // e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/logs?username=admin&repository=prj2%2Fphoton&tag=v3&operation=push&begin_timestamp=20171102&page=1&page_size=10'
-func GetOPLogs(baseURL string) {
-	if logs.Operation != "" &&
-		logs.Operation != "create" &&
-		logs.Operation != "delete" &&
-		logs.Operation != "push" &&
-		logs.Operation != "pull" {
-		fmt.Println("error: operation must be one of [create|delete|push|pull]")
-		os.Exit(1)
+func GetOPLogs(c *harbor.Client, opt *RecentLogs) (*harbor.Result, error) {
+	if opt.Count {
+		opt.Page, opt.PageSize = 1, 1
 	}
 
-	targetURL := baseURL + "?username=" + logs.Username +
-		"&repository=" + logs.Repository +
-		"&tag=" + logs.Tag +
-		"&operation=" + logs.Operation +
-		"&begin_timestamp=" + logs.BeginTimestamp +
-		"&begin_timestamp=" + logs.EndTimestamp +
-		"&page=" + strconv.Itoa(logs.Page) +
-		"&page_size=" + strconv.Itoa(logs.PageSize)
+	if opt.Operation != "" &&
+		opt.Operation != "create" &&
+		opt.Operation != "delete" &&
+		opt.Operation != "push" &&
+		opt.Operation != "pull" {
+		return nil, errors.New("operation must be one of [create|delete|push|pull]")
+	}
 
-	fmt.Println("==> GET", targetURL)
+	var targetURL string
+	if c.IsV2() {
+		targetURL = c.URL("/api/v2.0/audit-logs") + "?" + url.Values{
+			"q":         {auditLogQuery(opt.Username, opt.Operation, opt.Repository, opt.Tag, opt.BeginTimestamp, opt.EndTimestamp)},
+			"page":      {strconv.Itoa(opt.Page)},
+			"page_size": {strconv.Itoa(opt.PageSize)},
+		}.Encode()
+	} else {
+		targetURL = c.URL("/api/logs") + "?" + url.Values{
+			"username":        {opt.Username},
+			"repository":      {opt.Repository},
+			"tag":             {opt.Tag},
+			"operation":       {opt.Operation},
+			"begin_timestamp": {opt.BeginTimestamp},
+			"end_timestamp":   {opt.EndTimestamp},
+			"page":            {strconv.Itoa(opt.Page)},
+			"page_size":       {strconv.Itoa(opt.PageSize)},
+		}.Encode()
+	}
 
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("error:", err)
-		return
+	if !opt.Count {
+		c.Trace("==> GET", targetURL)
+	}
+
+	if opt.All && !opt.Count {
+		return c.StreamAllPages(targetURL), nil
+	}
+
+	return c.DoStream(c.Get(targetURL))
+}
+
+// auditLogQuery builds the q parameter of the audit logs of v2.0 API from
+// the filters of v1 API, timestamps (yyyymmdd) become an op_time range.
+func auditLogQuery(username, operation, repository, tag, begin, end string) string {
+	var q []string
+	if username != "" {
+		q = append(q, "username="+username)
+	}
+	if operation != "" {
+		q = append(q, "operation="+operation)
+	}
+	if repository != "" {
+		resource := repository
+		if tag != "" {
+			resource += ":" + tag
+		}
+		q = append(q, "resource=~"+resource)
 	}
+	if begin != "" || end != "" {
+		q = append(q, "op_time=["+logTime(begin, "00:00:00")+"~"+logTime(end, "23:59:59")+"]")
+	}
+	return strings.Join(q, ",")
+}
 
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+// logTime converts a yyyymmdd timestamp to the time format of v2.0 API,
+// other values are returned as is.
+func logTime(ts, clock string) string {
+	t, err := time.Parse("20060102", ts)
+	if err != nil {
+		return ts
+	}
+	return t.Format("2006-01-02") + " " + clock
 }
@@ -0,0 +1,261 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+func init() {
+	utils.Parser.AddCommand("manifest_inspect",
+		"Inspect the manifest of an image.",
+		"This endpoint gets the manifest of an image (a tag with v1 API, an artifact of the registry API with v2.0 API) and prints its digest, its layers and their sizes, and its config: platform, creation time, entrypoint, environment, labels... For a manifest list (multi-arch image) it prints the platforms, --platform inspects the image of one. --raw prints the manifest as is.",
+		&ManifestInspect{})
+	utils.Parser.AddCommand("tag_history",
+		"Get the build history of an image.",
+		"This endpoint gets the build history of an image (a tag with v1 API, an artifact with v2.0 API) from its config, first step first: when and by which command each layer was made, and its size, as docker history does but without pulling the image. For a manifest list (multi-arch image), --platform selects the image.",
+		&TagHistory{})
+}
+
+// ManifestInspect holds the parameters of InspectManifest.
+type ManifestInspect struct {
+	ArtifactRef
+	Platform string `long:"platform" description:"The platform of the image to inspect in a manifest list, as os/arch[/variant], e.g. linux/arm64/v8."`
+	Raw      bool   `long:"raw" description:"Print the manifest as is instead of its summary."`
+}
+
+func (x *ManifestInspect) Execute(args []string) error {
+	c := utils.NewClient()
+	if x.Raw {
+		c = utils.NewDataClient()
+	}
+	info, err := InspectManifest(c, x)
+	if x.Raw && err == nil {
+		_, err = fmt.Println(string(info.Raw))
+		return err
+	}
+	return utils.PrintValue(info, err, func() { utils.PrintManifest(info) })
+}
+
+// InspectManifest gets the manifest of an image and its config, of the
+// image of opt.Platform for a manifest list. With v2.0 API the manifest is
+// got from the registry API of Harbor, and the config from the artifact.
+//
+// params:
+//   project - (REQUIRED) The name of the project.
+//   repo_name - (REQUIRED) The name of the repository.
+//   reference - (REQUIRED) The tag or the digest of the image.
+//   platform - The platform of the image in a manifest list.
+//
+// format:
+//   GET /v2/{project_name}/{repository_name}/manifests/{reference}
+//   GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}
+//   GET /api/repositories/{repo_name}/tags/{tag}/manifest
+//
+// e.g. curl -X GET --header 'Accept: application/vnd.docker.distribution.manifest.v2+json' 'https://localhost/v2/library/nginx/manifests/1.25'
+func InspectManifest(c *harbor.Client, opt *ManifestInspect) (*utils.ManifestInfo, error) {
+	name := scanName(&opt.ArtifactRef)
+	m, raw, digest, config, err := resolveManifest(c, &opt.ArtifactRef, opt.Platform)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Manifests) > 0 {
+		return utils.NewManifestInfo(name, digest, m, nil, raw), nil
+	}
+
+	cfg, err := getImageConfig(c, &opt.ArtifactRef, digest, config)
+	if err != nil {
+		return nil, err
+	}
+	if opt.Platform != "" && !platformMatches(&model.Platform{OS: cfg.OS, Architecture: cfg.Architecture, Variant: cfg.Variant}, opt.Platform) {
+		return nil, fmt.Errorf("%s is a %s/%s image only", name, cfg.OS, cfg.Architecture)
+	}
+	return utils.NewManifestInfo(name, digest, m, cfg, raw), nil
+}
+
+// resolveManifest gets the manifest of the artifact, as getManifest does,
+// or of the image of platform if it is a manifest list and platform is not
+// empty.
+func resolveManifest(c *harbor.Client, ref *ArtifactRef, platform string) (*model.Manifest, []byte, string, []byte, error) {
+	m, raw, digest, config, err := getManifest(c, ref, ref.Reference)
+	if err != nil || len(m.Manifests) == 0 || platform == "" {
+		return m, raw, digest, config, err
+	}
+	d, err := manifestForPlatform(m, platform)
+	if err != nil {
+		return nil, nil, "", nil, fmt.Errorf("%s: %v", scanName(ref), err)
+	}
+	return getManifest(c, ref, d.Digest)
+}
+
+// getManifest gets the manifest of reference, a tag or a digest, in the
+// repository of ref, its raw content and its digest, and with v1 API the
+// config of the image.
+func getManifest(c *harbor.Client, ref *ArtifactRef, reference string) (*model.Manifest, []byte, string, []byte, error) {
+	var raw, config []byte
+	digest := ""
+	if c.IsV2() {
+		targetURL := c.URL("/v2/") + utils.RepoPath(ref.Project+"/"+ref.RepoName) + "/manifests/" + utils.TagPath(reference)
+		c.Trace("==> GET", targetURL)
+
+		res, err := c.Do(c.Get(targetURL))
+		if err != nil {
+			return nil, nil, "", nil, err
+		}
+		raw = res.Body
+		digest = res.Header.Get("Docker-Content-Digest")
+	} else {
+		tag := *ref
+		tag.Reference = reference
+		targetURL := scanTagURL(c, &tag) + "/manifest?version=v2"
+		c.Trace("==> GET", targetURL)
+
+		var v1 struct {
+			Manifest json.RawMessage `json:"manifest"`
+			Config   string          `json:"config"`
+		}
+		if err := c.GetJSON(targetURL, &v1); err != nil {
+			return nil, nil, "", nil, err
+		}
+		raw, config = v1.Manifest, []byte(v1.Config)
+
+		// The manifest is marshaled again by Harbor, its digest is the
+		// one of the tag.
+		if !strings.HasPrefix(reference, "sha256:") {
+			var t model.Tag
+			if err := c.GetJSON(scanTagURL(c, &tag), &t); err != nil {
+				return nil, nil, "", nil, err
+			}
+			digest = t.Digest
+		}
+	}
+	if digest == "" && strings.HasPrefix(reference, "sha256:") {
+		digest = reference
+	}
+	if digest == "" {
+		digest = fmt.Sprintf("sha256:%x", sha256.Sum256(raw))
+	}
+
+	var m model.Manifest
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, nil, "", nil, fmt.Errorf("decoding the manifest of %s: %v", reference, err)
+	}
+	if m.SchemaVersion == 1 {
+		return nil, nil, "", nil, fmt.Errorf("%s has a schema 1 manifest, which has no layer sizes, see --raw", reference)
+	}
+	return &m, raw, digest, config, nil
+}
+
+// getImageConfig returns the config of the image of digest: config with v1
+// API, the extra attributes of the artifact with v2.0 API.
+func getImageConfig(c *harbor.Client, ref *ArtifactRef, digest string, config []byte) (*model.ImageConfig, error) {
+	var cfg model.ImageConfig
+	if !c.IsV2() {
+		if err := json.Unmarshal(config, &cfg); err != nil {
+			return nil, fmt.Errorf("decoding the config of %s: %v", digest, err)
+		}
+		return &cfg, nil
+	}
+
+	targetURL := ref.repoURL(c) + "/artifacts/" + utils.TagPath(digest)
+	c.Trace("==> GET", targetURL)
+
+	var artifact struct {
+		ExtraAttrs json.RawMessage `json:"extra_attrs"`
+	}
+	if err := c.GetJSON(targetURL, &artifact); err != nil {
+		return nil, err
+	}
+	if len(artifact.ExtraAttrs) > 0 && string(artifact.ExtraAttrs) != "null" {
+		if err := json.Unmarshal(artifact.ExtraAttrs, &cfg); err != nil {
+			return nil, fmt.Errorf("decoding the config of %s: %v", digest, err)
+		}
+	}
+	return &cfg, nil
+}
+
+// platformMatches reports whether p is the platform os/arch[/variant], any
+// variant if it is not given.
+func platformMatches(p *model.Platform, platform string) bool {
+	parts := strings.SplitN(platform, "/", 3)
+	if len(parts) < 2 || p.OS != parts[0] || p.Architecture != parts[1] {
+		return false
+	}
+	return len(parts) < 3 || p.Variant == parts[2]
+}
+
+// manifestForPlatform returns the image of the manifest list m which runs
+// on platform.
+func manifestForPlatform(m *model.Manifest, platform string) (*model.Descriptor, error) {
+	var platforms []string
+	for i, d := range m.Manifests {
+		if d.Platform == nil {
+			continue
+		}
+		if platformMatches(d.Platform, platform) {
+			return &m.Manifests[i], nil
+		}
+		platforms = append(platforms, d.Platform.String())
+	}
+	return nil, fmt.Errorf("no image for platform %s, the manifest list has %s", platform, strings.Join(platforms, ", "))
+}
+
+// TagHistory holds the parameters of GetTagHistory.
+type TagHistory struct {
+	ArtifactRef
+	Platform string `long:"platform" description:"The platform of the image in a manifest list, as os/arch[/variant], e.g. linux/arm64/v8."`
+	NoTrunc  bool   `long:"no-trunc" description:"Print the commands in full."`
+}
+
+func (x *TagHistory) Execute(args []string) error {
+	steps, err := GetTagHistory(utils.NewClient(), x)
+	return utils.PrintValue(steps, err, func() { utils.PrintImageHistory(steps, x.NoTrunc) })
+}
+
+// GetTagHistory gets the build history of an image from its config, of
+// the image of opt.Platform for a manifest list, with the layers of its
+// manifest.
+//
+// params:
+//   project - (REQUIRED) The name of the project.
+//   repo_name - (REQUIRED) The name of the repository.
+//   reference - (REQUIRED) The tag or the digest of the image.
+//   platform - The platform of the image in a manifest list.
+//
+// format:
+//   GET /v2/{project_name}/{repository_name}/manifests/{reference}
+//   GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/additions/build_history
+//   GET /api/repositories/{repo_name}/tags/{tag}/manifest
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/projects/library/repositories/nginx/artifacts/sha256:5a1e.../additions/build_history'
+func GetTagHistory(c *harbor.Client, opt *TagHistory) ([]*utils.HistoryStep, error) {
+	m, _, digest, config, err := resolveManifest(c, &opt.ArtifactRef, opt.Platform)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Manifests) > 0 {
+		return nil, fmt.Errorf("%s is a manifest list, select one of its images with --platform", scanName(&opt.ArtifactRef))
+	}
+
+	var history []model.ImageHistory
+	if c.IsV2() {
+		targetURL := opt.repoURL(c) + "/artifacts/" + utils.TagPath(digest) + "/additions/build_history"
+		c.Trace("==> GET", targetURL)
+
+		if err := c.GetJSON(targetURL, &history); err != nil {
+			return nil, err
+		}
+	} else {
+		var cfg model.ImageConfig
+		if err := json.Unmarshal(config, &cfg); err != nil {
+			return nil, fmt.Errorf("decoding the config of %s: %v", digest, err)
+		}
+		history = cfg.History
+	}
+	return utils.NewImageHistory(history, m.Layers), nil
+}
@@ -2,8 +2,9 @@ package api
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 
+	"github.com/moooofly/harbor-go-client/harbor"
 	"github.com/moooofly/harbor-go-client/utils"
 )
 
@@ -11,21 +12,20 @@ func init() {
 	utils.Parser.AddCommand("syncregistry",
 		"Sync repositories from registry to DB.",
 		"This endpoint is for syncing all repositories of registry with database.",
-		&syncregistry)
+		&SyncRegistry{})
 	utils.Parser.AddCommand("email_ping",
 		"Test connection and authentication with email server.",
-		"Test connection and authentication with email server.",
-		&emailping)
+		"Test connection and authentication with email server: with the saved settings when no flag is given, otherwise with the settings given, the other ones (the password included) taken from the saved ones, to validate them before saving them.",
+		&EmailPing{})
+	utils.RequireAdmin("syncregistry", "email_ping")
 }
 
-type syncRegistry struct {
+// SyncRegistry is the syncregistry command.
+type SyncRegistry struct {
 }
 
-var syncregistry syncRegistry
-
-func (x *syncRegistry) Execute(args []string) error {
-	PostSyncRegistry(utils.URLGen("/api/internal/syncregistry"))
-	return nil
+func (x *SyncRegistry) Execute(args []string) error {
+	return utils.PrintResult(PostSyncRegistry(utils.NewClient()))
 }
 
 // PostSyncRegistry is for syncing all repositories of registry with database.
@@ -36,39 +36,38 @@ func (x *syncRegistry) Execute(args []string) error {
 //   POST /internal/syncregistry
 //
 // e.g. curl -X POST --header 'Content-Type: application/json' --header 'Accept: text/plain' 'https://localhost/api/internal/syncregistry'
-func PostSyncRegistry(baseURL string) {
-	targetURL := baseURL
-	fmt.Println("==> POST", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
+func PostSyncRegistry(c *harbor.Client) (*harbor.Result, error) {
+	targetURL := c.URL("/api/internal/syncregistry")
+	c.Trace("==> POST", targetURL)
 
-	utils.Request.Post(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Post(targetURL))
 }
 
-type emailPing struct {
-	EmailHost     string `short:"h" long:"email_host" description:"The host of email server." default:"smtp.mydomain.com" json:"email_host"`
-	EmailPort     int    `short:"t" long:"email_port" description:"The port of email server." default:"25" json:"email_port"`
-	EmailUsername string `short:"u" long:"email_username" description:"The username of email server." default:"sample_admin@mydomain.com" json:"email_username"`
-	EmailPassword string `short:"p" long:"email_password" description:"The password of email server." default:"" json:"email_password"`
+// EmailPing holds the parameters of PostEmailPing.
+type EmailPing struct {
+	EmailHost     string `short:"h" long:"email_host" description:"The host of email server." json:"email_host"`
+	EmailPort     int    `short:"t" long:"email_port" description:"The port of email server." json:"email_port"`
+	EmailUsername string `short:"u" long:"email_username" description:"The username of email server." json:"email_username"`
+	EmailPassword string `short:"p" long:"email_password" description:"The password of email server, the saved one if not given." json:"email_password,omitempty"`
 	EmailSsl      bool   `short:"s" long:"email_ssl" description:"Use ssl/tls or not." json:"email_ssl"`
-	EmailIdentity string `short:"i" long:"email_identity" description:"The identity of email server." default:"" json:"email_identity"`
-}
+	NoEmailSsl    bool   `long:"no_email_ssl" description:"Do not use ssl/tls, even if the saved settings do." json:"-"`
+	EmailIdentity string `short:"i" long:"email_identity" description:"The identity of email server." json:"email_identity"`
+	EmailInsecure bool   `long:"email_insecure" description:"Do not verify the certificate of email server." json:"email_insecure"`
 
-var emailping emailPing
+	// given holds the flags given on the command line, the other settings
+	// are the saved ones. All are given when nil.
+	given map[string]bool
+}
 
-func (x *emailPing) Execute(args []string) error {
-	PostEmailPing(utils.URLGen("/api/email/ping"))
-	return nil
+func (x *EmailPing) Execute(args []string) error {
+	x.given = utils.FlagsGiven()
+	return utils.PrintResult(PostEmailPing(utils.NewClient(), x))
 }
 
-// PostEmailPing tests connection and authentication with email server.
+// PostEmailPing tests connection and authentication with email server. The
+// server tests its saved settings when none is sent, and its saved password
+// when none is sent; the settings not given on the command line are filled
+// from the saved configuration.
 //
 // params:
 //  email_host     - The host of email server.
@@ -77,6 +76,7 @@ func (x *emailPing) Execute(args []string) error {
 //  email_password - The password of email server.
 //  email_ssl      - Use ssl/tls or not.
 //  email_identity - The dentity of email server.
+//  email_insecure - Do not verify the certificate of email server.
 //
 // format:
 //   POST /email/ping
@@ -92,27 +92,62 @@ curl -X POST --header 'Content-Type: application/json' --header 'Accept: text/pl
    "email_identity": "string" \
  }' 'https://localhost/api/email/ping'
 )*/
-func PostEmailPing(baseURL string) {
-	targetURL := baseURL
-	fmt.Println("==> POST", targetURL)
+func PostEmailPing(c *harbor.Client, opt *EmailPing) (*harbor.Result, error) {
+	if opt.EmailSsl && opt.NoEmailSsl {
+		return nil, errors.New("--email_ssl and --no_email_ssl are exclusive")
+	}
+	targetURL := c.APIURL("/email/ping")
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
+	if opt.given != nil && len(opt.given) == 0 {
+		c.Trace("==> POST", targetURL)
+		return c.Do(c.Post(targetURL))
+	}
+	if opt.given != nil {
+		if err := opt.fillSaved(c); err != nil {
+			return nil, err
+		}
 	}
+	c.Trace("==> POST", targetURL)
 
-	t, err := json.Marshal(&emailping)
+	t, err := json.Marshal(opt)
 	if err != nil {
-		fmt.Println("error:", err)
-		return
+		return nil, err
 	}
 
-	fmt.Println("==> email ping:", string(t))
+	c.Trace("==> email ping:", string(t))
 
-	utils.Request.Post(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		Send(string(t)).
-		End(utils.PrintStatus)
+	return c.Do(c.Post(targetURL).
+		Send(string(t)))
+}
+
+// fillSaved sets the settings not given on the command line to the saved
+// ones, but the password the server fills itself.
+func (x *EmailPing) fillSaved(c *harbor.Client) error {
+	items, err := sysConfigItems(c)
+	if err != nil {
+		return err
+	}
+	str := func(key string) string { s, _ := items[key].Value.(string); return s }
+	flag := func(key string) bool { b, _ := items[key].Value.(bool); return b }
+
+	if !x.given["email_host"] {
+		x.EmailHost = str("email_host")
+	}
+	if !x.given["email_port"] {
+		port, _ := items["email_port"].Value.(float64)
+		x.EmailPort = int(port)
+	}
+	if !x.given["email_username"] {
+		x.EmailUsername = str("email_username")
+	}
+	if !x.given["email_ssl"] {
+		x.EmailSsl = flag("email_ssl") && !x.NoEmailSsl
+	}
+	if !x.given["email_identity"] {
+		x.EmailIdentity = str("email_identity")
+	}
+	if !x.given["email_insecure"] {
+		x.EmailInsecure = flag("email_insecure")
+	}
+	return nil
 }
@@ -1,9 +1,15 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"strconv"
+	"strings"
 
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
 	"github.com/moooofly/harbor-go-client/utils"
 )
 
@@ -11,51 +17,393 @@ func init() {
 	utils.Parser.AddCommand("policy_update_by_id",
 		"Modify name, description, target and enablement of a policy.",
 		"This endpoint let user update policy's name, description, target and enablement.",
-		&poUpdateByID)
+		&PolicyUpdateByID{})
 	utils.Parser.AddCommand("policy_get_by_id",
 		"Get a policy.",
 		"This endpoint let user search a policy by specific ID.",
-		&poGetByID)
+		&PolicyGetByID{})
 	utils.Parser.AddCommand("policy_create",
 		"Create a policy.",
 		"This endpoint let user creates a policy, and if it is enabled, the replication will be triggered right now.",
-		&poCreate)
+		&PolicyCreate{})
 	utils.Parser.AddCommand("policies_list",
 		"Filter policies by name and project_id.",
 		"This endpoint let user filter policies by name and project_id, if name and project_id are nil, list returns all policies.",
-		&poList)
+		&PoliciesList{})
+	utils.Parser.AddCommand("policy_del",
+		"Delete a policy.",
+		"This endpoint let user delete a replication policy by specific ID.",
+		&PolicyDel{})
+	utils.Parser.AddCommand("policy_enable",
+		"Enable a policy.",
+		"This endpoint enables a replication policy by specific ID, its other settings are kept.",
+		&PolicyEnable{})
+	utils.Parser.AddCommand("policy_disable",
+		"Disable a policy.",
+		"This endpoint disables a replication policy by specific ID, its other settings are kept.",
+		&PolicyDisable{})
+	utils.RequireAdmin(
+		"policy_update_by_id",
+		"policy_get_by_id",
+		"policy_create",
+		"policies_list",
+		"policy_del",
+		"policy_enable",
+		"policy_disable",
+	)
+
+	utils.ResponseModel("policy_get_by_id", model.ReplicationPolicy{})
+	utils.ResponseModel("policies_list", model.ReplicationPolicy{})
 }
 
-type policyUpdateByID struct {
-	ID int `short:"i" long:"id" description:"(REQUIRED) policy ID" required:"yes"`
-	// add more
+// replPolicyProject is a project referenced by replication policy.
+type replPolicyProject struct {
+	ProjectID int    `json:"project_id"`
+	Name      string `json:"name,omitempty"`
+}
+
+// replPolicyTarget is a target referenced by replication policy.
+type replPolicyTarget struct {
+	ID   int    `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// replPolicyFilter filters the resources to be replicated.
+type replPolicyFilter struct {
+	Kind    string      `json:"kind"`
+	Pattern string      `json:"pattern,omitempty"`
+	Value   interface{} `json:"value,omitempty"`
+}
+
+// replPolicyTrigger describes when the replication is triggered.
+type replPolicyTrigger struct {
+	Kind          string `json:"kind"`
+	ScheduleParam *struct {
+		Type    string `json:"type"`
+		Weekday int    `json:"weekday"`
+		Offtime int    `json:"offtime"`
+	} `json:"schedule_param,omitempty"`
 }
 
-var poUpdateByID policyUpdateByID
+// replPolicy is the request body of creating/updating replication policy.
+//
+// Simple properties and filters are exposed as flags, scheduled triggers of
+// v1 API are given by spec file. The body of v2.0 API is built by v2.
+type replPolicy struct {
+	ID                        int                  `short:"i" long:"id" description:"(REQUIRED when update) policy ID" json:"id,omitempty"`
+	Name                      string               `short:"n" long:"name" description:"(REQUIRED) The policy name." validate:"required" json:"name"`
+	Description               string               `short:"d" long:"description" description:"The description of the policy." default:"" json:"description"`
+	ProjectID                 int                  `short:"j" long:"project_id" description:"(REQUIRED with v1 API) The ID of project to be replicated, a name filter 'project/**' with v2.0 API." json:"-"`
+	TargetID                  int                  `short:"t" long:"target_id" description:"(REQUIRED) The ID of replication target, the destination registry with v2.0 API." json:"-"`
+	SrcRegistryID             int                  `long:"src_registry_id" description:"The ID of the source registry, to pull from it instead of pushing to --target_id. (v2.0 API)" json:"-"`
+	TriggerKind               string               `short:"k" long:"trigger" description:"The trigger kind, valid values are 'Manual', 'Immediate' (or 'event_based') and 'Scheduled'." default:"Manual" json:"-"`
+	Cron                      string               `long:"cron" description:"The cron of the 'Scheduled' trigger, e.g. '0 0 2 * * *'. (v2.0 API)" json:"-"`
+	FilterName                string               `long:"filter_name" description:"Replicate only the repositories matching this pattern, e.g. 'library/**'." json:"-"`
+	FilterTag                 string               `long:"filter_tag" description:"Replicate only the tags matching this pattern, e.g. 'v*'." json:"-"`
+	FilterLabels              []string             `long:"filter_label" description:"Replicate only the resources with this label, may be repeated: the label ID with v1 API, its name with v2.0 API." json:"-"`
+	FilterResource            string               `long:"filter_resource" description:"Replicate only this type of resources. (v2.0 API)" choice:"image" choice:"chart" choice:"artifact" json:"-"`
+	ReplicateDeletion         bool                 `long:"replicate_deletion" description:"Whether to replicate the deletion operation." json:"replicate_deletion"`
+	ReplicateExistingImageNow bool                 `long:"replicate_existing_image_now" description:"Whether to replicate the existing images now." json:"replicate_existing_image_now"`
+	Override                  bool                 `long:"override" description:"Overwrite the resources existing at the destination. (v2.0 API)" json:"-"`
+	Disabled                  bool                 `long:"disabled" description:"Leave the policy disabled. (v2.0 API)" json:"-"`
+	DestNamespace             string               `long:"dest_namespace" description:"The namespace (project) to replicate the resources into at the destination, their own by default. (v2.0 API)" json:"-"`
+	DestNamespaceReplaceCount int                  `long:"dest_namespace_replace_count" description:"Flattening: how many leading path components of the repositories are replaced by --dest_namespace, -1 for all but the last one, 0 for none (Harbor v2.3+). (v2.0 API)" default:"-1" json:"-"`
+	Speed                     int                  `long:"speed" description:"The bandwidth limit of each task in KB/s, 0 for no limit (Harbor v2.3+). (v2.0 API)" json:"-"`
+	CopyByChunk               bool                 `long:"copy_by_chunk" description:"Copy the blobs by chunk, for registries limiting the size of requests (Harbor v2.6+). (v2.0 API)" json:"-"`
+	Projects                  []*replPolicyProject `json:"projects"`
+	Targets                   []*replPolicyTarget  `json:"targets"`
+	Trigger                   *replPolicyTrigger   `json:"trigger"`
+	Filters                   []*replPolicyFilter  `json:"filters"`
+	File                      string               `short:"f" long:"file" description:"Read the policy from a JSON or YAML spec file (or @file, - for stdin) instead of flags." spec:"file" json:"-"`
+	MapFrom                   string               `long:"map_from" description:"Translate project, target and label IDs from the Harbor of this base URL to the current target, by the mapping table of idmap_sync." json:"-"`
+}
+
+// replPolicyV2 is the request body of creating/updating replication policy
+// with v2.0 API.
+type replPolicyV2 struct {
+	ID                        int                        `json:"id,omitempty"`
+	Name                      string                     `json:"name"`
+	Description               string                     `json:"description"`
+	SrcRegistry               *replPolicyTarget          `json:"src_registry,omitempty"`
+	DestRegistry              *replPolicyTarget          `json:"dest_registry,omitempty"`
+	Trigger                   *model.ReplicationTrigger  `json:"trigger"`
+	Filters                   []*model.ReplicationFilter `json:"filters"`
+	ReplicateDeletion         bool                       `json:"replicate_deletion"`
+	Override                  bool                       `json:"override"`
+	Enabled                   bool                       `json:"enabled"`
+	DestNamespace             string                     `json:"dest_namespace,omitempty"`
+	DestNamespaceReplaceCount int                        `json:"dest_namespace_replace_count"`
+	Speed                     int                        `json:"speed,omitempty"`
+	CopyByChunk               bool                       `json:"copy_by_chunk,omitempty"`
+}
+
+// replTriggerKinds maps the trigger kinds, lowercased, to the ones of v1
+// and v2.0 API.
+var replTriggerKinds = map[string][2]string{
+	"manual":      {"Manual", "manual"},
+	"immediate":   {"Immediate", "event_based"},
+	"event_based": {"Immediate", "event_based"},
+	"scheduled":   {"Scheduled", "scheduled"},
+}
+
+// build completes the policy from the flags and the spec file, for v2.0 API
+// if v2.
+func (p *replPolicy) build(v2 bool) error {
+	if p.ProjectID != 0 {
+		p.Projects = []*replPolicyProject{{ProjectID: p.ProjectID}}
+	}
+	if p.TargetID != 0 {
+		p.Targets = []*replPolicyTarget{{ID: p.TargetID}}
+	}
+	if p.TriggerKind != "" {
+		p.Trigger = &replPolicyTrigger{Kind: p.TriggerKind}
+	}
+	if p.FilterName != "" {
+		p.Filters = append(p.Filters, &replPolicyFilter{Kind: "repository", Pattern: p.FilterName})
+	}
+	if p.FilterTag != "" {
+		p.Filters = append(p.Filters, &replPolicyFilter{Kind: "tag", Pattern: p.FilterTag})
+	}
+	if len(p.FilterLabels) > 0 && v2 {
+		p.Filters = append(p.Filters, &replPolicyFilter{Kind: "label", Value: p.FilterLabels})
+	}
+	for _, l := range p.FilterLabels {
+		if v2 {
+			break
+		}
+		id, err := strconv.Atoi(l)
+		if err != nil {
+			return fmt.Errorf("--filter_label: a label ID is expected with v1 API, got %q", l)
+		}
+		p.Filters = append(p.Filters, &replPolicyFilter{Kind: "label", Value: id})
+	}
+	if p.FilterResource != "" {
+		if !v2 {
+			return errors.New("--filter_resource is not supported by v1 API")
+		}
+		p.Filters = append(p.Filters, &replPolicyFilter{Kind: "resource", Value: p.FilterResource})
+	}
+	if !v2 && (p.DestNamespace != "" || p.DestNamespaceReplaceCount > 0 || p.Speed != 0 || p.CopyByChunk) {
+		return errors.New("--dest_namespace, --dest_namespace_replace_count, --speed and --copy_by_chunk are not supported by v1 API")
+	}
+	if p.DestNamespaceReplaceCount < -1 {
+		return fmt.Errorf("--dest_namespace_replace_count: -1 or more expected, got %d", p.DestNamespaceReplaceCount)
+	}
+	if p.Speed < 0 {
+		return fmt.Errorf("--speed: 0 or more expected, got %d", p.Speed)
+	}
+
+	// Values in spec file take precedence over flags.
+	if p.File != "" {
+		if err := utils.SpecLoad(p.File, p); err != nil {
+			return err
+		}
+	}
+
+	if p.MapFrom != "" {
+		if err := p.mapIDs(); err != nil {
+			return err
+		}
+	}
 
-func (x *policyUpdateByID) Execute(args []string) error {
-	PutPolicyUpdateByID(utils.URLGen("/api/policies"))
+	if err := utils.Validate(p); err != nil {
+		return err
+	}
+	var missing []string
+	if len(p.Projects) == 0 && !v2 {
+		missing = append(missing, "--project_id")
+	}
+	if len(p.Targets) == 0 && (!v2 || p.SrcRegistryID == 0) {
+		missing = append(missing, "--target_id")
+	}
+	if len(missing) > 0 {
+		return errors.New("missing required field(s): " + strings.Join(missing, ", "))
+	}
+
+	if p.Trigger != nil {
+		kinds, ok := replTriggerKinds[strings.ToLower(p.Trigger.Kind)]
+		if !ok {
+			return fmt.Errorf("trigger: kind must be one of [Manual|Immediate|Scheduled]")
+		}
+		p.Trigger.Kind = kinds[0]
+		switch {
+		case p.Trigger.Kind != "Scheduled":
+		case v2 && p.Cron == "":
+			return fmt.Errorf("trigger: --cron is required for 'Scheduled' trigger")
+		case !v2 && p.Trigger.ScheduleParam == nil:
+			return fmt.Errorf("trigger: schedule_param is required for 'Scheduled' trigger (in spec file)")
+		}
+	}
 	return nil
 }
 
+// v2 converts the policy, built, for v2.0 API. The project of --project_id
+// becomes a name filter.
+func (p *replPolicy) v2(c *harbor.Client) (*replPolicyV2, error) {
+	body := &replPolicyV2{
+		ID:                        p.ID,
+		Name:                      p.Name,
+		Description:               p.Description,
+		ReplicateDeletion:         p.ReplicateDeletion,
+		Override:                  p.Override,
+		Enabled:                   !p.Disabled,
+		Filters:                   []*model.ReplicationFilter{},
+		DestNamespace:             p.DestNamespace,
+		DestNamespaceReplaceCount: p.DestNamespaceReplaceCount,
+		Speed:                     p.Speed,
+		CopyByChunk:               p.CopyByChunk,
+	}
+	if p.SrcRegistryID != 0 {
+		body.SrcRegistry = &replPolicyTarget{ID: p.SrcRegistryID}
+	} else {
+		body.DestRegistry = &replPolicyTarget{ID: p.Targets[0].ID}
+	}
+	if p.Trigger != nil {
+		body.Trigger = &model.ReplicationTrigger{Type: replTriggerKinds[strings.ToLower(p.Trigger.Kind)][1]}
+		if p.Cron != "" {
+			body.Trigger.TriggerSettings = map[string]interface{}{"cron": p.Cron}
+		}
+	}
+
+	hasName := false
+	for _, f := range p.Filters {
+		filter := &model.ReplicationFilter{Type: f.Kind, Value: f.Value}
+		if f.Kind == "repository" {
+			filter.Type = "name"
+			hasName = true
+		}
+		if f.Pattern != "" {
+			filter.Value = f.Pattern
+		}
+		if f.Kind == "label" {
+			filter.Decoration = "matches"
+		}
+		body.Filters = append(body.Filters, filter)
+	}
+	if len(p.Projects) > 0 && !hasName {
+		var prj model.Project
+		if err := c.GetJSON(c.URL("/api/v2.0/projects")+"/"+strconv.Itoa(p.Projects[0].ProjectID), &prj); err != nil {
+			return nil, err
+		}
+		body.Filters = append(body.Filters, &model.ReplicationFilter{Type: "name", Value: prj.Name + "/**"})
+	}
+	return body, nil
+}
+
+// send sends the policy with method to targetURL, for the API version of
+// the Harbor.
+func (p *replPolicy) send(c *harbor.Client, method, targetURL, name string) (*harbor.Result, error) {
+	var body interface{} = p
+	if c.IsV2() {
+		v2, err := p.v2(c)
+		if err != nil {
+			return nil, err
+		}
+		body = v2
+	}
+
+	c.Trace("==> "+method, targetURL)
+
+	t, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Trace("==> policy "+name+":", string(t))
+
+	return c.Do(c.Request(method, targetURL).
+		Send(string(t)))
+}
+
+// mapIDs translates the IDs the policy refers to, which are given as IDs
+// of Harbor p.MapFrom, to the IDs of the current target.
+func (p *replPolicy) mapIDs() error {
+	var err error
+	for _, prj := range p.Projects {
+		if prj.ProjectID, err = utils.IDMapTranslate(utils.IDKindProject, prj.ProjectID, p.MapFrom); err != nil {
+			return err
+		}
+		prj.Name = ""
+	}
+	for _, t := range p.Targets {
+		if t.ID, err = utils.IDMapTranslate(utils.IDKindTarget, t.ID, p.MapFrom); err != nil {
+			return err
+		}
+		t.Name = ""
+	}
+	for _, f := range p.Filters {
+		if f.Kind != "label" {
+			continue
+		}
+		// JSON numbers are decoded as float64 into interface{}.
+		id, ok := f.Value.(float64)
+		if !ok {
+			return fmt.Errorf("filters: label value must be a label ID, got %v", f.Value)
+		}
+		mapped, err := utils.IDMapTranslate(utils.IDKindLabel, int(id), p.MapFrom)
+		if err != nil {
+			return err
+		}
+		f.Value = mapped
+	}
+	return nil
+}
+
+// PolicyUpdateByID holds the parameters of PutPolicyUpdateByID.
+type PolicyUpdateByID struct {
+	replPolicy
+}
+
+func (x *PolicyUpdateByID) Execute(args []string) error {
+	return utils.PrintResult(PutPolicyUpdateByID(utils.NewClient(), x))
+}
+
 // PutPolicyUpdateByID let user update policy name, description, target and enablement.
 //
-//  params:
+// params:
+//   id                           - (REQUIRED) policy ID
+//   name                         - (REQUIRED) The policy name.
+//   description                  - The description of the policy.
+//   project_id                   - (REQUIRED) The ID of project to be replicated.
+//   target_id                    - (REQUIRED) The ID of replication target.
+//   trigger                      - The trigger kind.
+//   replicate_deletion           - Whether to replicate the deletion operation.
+//   replicate_existing_image_now - Whether to replicate the existing images now.
+//   file                         - The spec file of policy.
 //
-//  format:
+// format:
+//   PUT /policies/replication/{id}
 //
 // e.g.
-func PutPolicyUpdateByID(baseURL string) {}
+/*
+curl -X PUT --header 'Content-Type: application/json' --header 'Accept: text/plain' -d '{ \
+   "id": 1, \
+   "name": "repl_policy_name", \
+   "projects": [{"project_id": 86}], \
+   "targets": [{"id": 1}], \
+   "trigger": {"kind": "Manual"}, \
+   "filters": [{"kind": "repository", "pattern": "temp_*"}], \
+   "replicate_deletion": false \
+ }' 'https://localhost/api/policies/replication/1'
+*/
+func PutPolicyUpdateByID(c *harbor.Client, opt *PolicyUpdateByID) (*harbor.Result, error) {
+	if err := opt.build(c.IsV2()); err != nil {
+		return nil, err
+	}
+	if opt.ID == 0 {
+		return nil, errors.New("missing required field(s): --id")
+	}
 
-type policyGetByID struct {
-	ID int `short:"i" long:"id" description:"(REQUIRED) policy ID" required:"yes"`
+	return opt.send(c, "PUT", replPoliciesURL(c)+"/"+strconv.Itoa(opt.ID), "update")
 }
 
-var poGetByID policyGetByID
+// PolicyGetByID holds the parameters of GetPolicyByID.
+type PolicyGetByID struct {
+	ID int `short:"i" long:"id" description:"(REQUIRED) policy ID" required:"yes"`
+}
 
-func (x *policyGetByID) Execute(args []string) error {
-	GetPolicyByID(utils.URLGen("/api/policies"))
-	return nil
+func (x *PolicyGetByID) Execute(args []string) error {
+	return utils.PrintResult(GetPolicyByID(utils.NewClient(), x))
 }
 
 // GetPolicyByID let user search replication policy by specific ID.
@@ -67,53 +415,133 @@ func (x *policyGetByID) Execute(args []string) error {
 //   GET /policies/replication/{id}
 //
 // e.g. curl -X GET --header 'Accept: text/plain' 'https://localhost/api/policies/replication/1'
-func GetPolicyByID(baseURL string) {
-	targetURL := baseURL + "/replication/" + strconv.Itoa(poGetByID.ID)
-	fmt.Println("==> GET", targetURL)
+func GetPolicyByID(c *harbor.Client, opt *PolicyGetByID) (*harbor.Result, error) {
+	targetURL := replPoliciesURL(c) + "/" + strconv.Itoa(opt.ID)
+	c.Trace("==> GET", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
-
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Get(targetURL))
 }
 
-type policyCreate struct {
+// PolicyCreate holds the parameters of PostPolicyCreate.
+type PolicyCreate struct {
+	replPolicy
 }
 
-var poCreate policyCreate
-
-func (x *policyCreate) Execute(args []string) error {
-	PostPolicyCreate(utils.URLGen("/api/policies"))
-	return nil
+func (x *PolicyCreate) Execute(args []string) error {
+	return utils.PrintResult(PostPolicyCreate(utils.NewClient(), x))
 }
 
 // PostPolicyCreate let user creates a policy, and if it is enabled, the replication will be triggered right now.
 //
-//  params:
+// params:
+//   name                         - (REQUIRED) The policy name.
+//   description                  - The description of the policy.
+//   project_id                   - (REQUIRED) The ID of project to be replicated.
+//   target_id                    - (REQUIRED) The ID of replication target.
+//   trigger                      - The trigger kind.
+//   replicate_deletion           - Whether to replicate the deletion operation.
+//   replicate_existing_image_now - Whether to replicate the existing images now.
+//   file                         - The spec file of policy.
 //
-//  format:
+// format:
+//   POST /policies/replication
 //
 // e.g.
-func PostPolicyCreate(baseURL string) {}
+/*
+curl -X POST --header 'Content-Type: application/json' --header 'Accept: text/plain' -d '{ \
+   "name": "repl_policy_name", \
+   "projects": [{"project_id": 86}], \
+   "targets": [{"id": 1}], \
+   "trigger": {"kind": "Scheduled", "schedule_param": {"type": "Daily", "offtime": 3600}}, \
+   "filters": [{"kind": "tag", "pattern": "v*"}], \
+   "replicate_existing_image_now": true \
+ }' 'https://localhost/api/policies/replication'
+*/
+func PostPolicyCreate(c *harbor.Client, opt *PolicyCreate) (*harbor.Result, error) {
+	if err := opt.build(c.IsV2()); err != nil {
+		return nil, err
+	}
+
+	return opt.send(c, "POST", replPoliciesURL(c), "create")
+}
+
+// PolicyDel holds the parameters of DeletePolicy.
+type PolicyDel struct {
+	ID int `short:"i" long:"id" description:"(REQUIRED) policy ID" required:"yes"`
+}
+
+func (x *PolicyDel) Execute(args []string) error {
+	return utils.PrintResult(DeletePolicy(utils.NewClient(), x))
+}
+
+// DeletePolicy let user delete a replication policy by specific ID.
+//
+// params:
+//   id - (REQUIRED) policy ID
+//
+// format:
+//   DELETE /policies/replication/{id}
+//
+// e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/policies/replication/1'
+func DeletePolicy(c *harbor.Client, opt *PolicyDel) (*harbor.Result, error) {
+	targetURL := replPoliciesURL(c) + "/" + strconv.Itoa(opt.ID)
+	c.Trace("==> DELETE", targetURL)
+
+	return c.Do(c.Delete(targetURL))
+}
+
+// PolicyEnable holds the parameters of the policy_enable command.
+type PolicyEnable struct {
+	ID int `short:"i" long:"id" description:"(REQUIRED) policy ID" required:"yes"`
+}
+
+func (x *PolicyEnable) Execute(args []string) error {
+	return utils.PrintResult(PutPolicyEnabled(utils.NewClient(), x.ID, true))
+}
+
+// PolicyDisable holds the parameters of the policy_disable command.
+type PolicyDisable struct {
+	ID int `short:"i" long:"id" description:"(REQUIRED) policy ID" required:"yes"`
+}
+
+func (x *PolicyDisable) Execute(args []string) error {
+	return utils.PrintResult(PutPolicyEnabled(utils.NewClient(), x.ID, false))
+}
+
+// PutPolicyEnabled enables or disables a replication policy, the policy is
+// got and updated as is but its enablement.
+//
+// format:
+//   GET /policies/replication/{id}
+//   PUT /policies/replication/{id}
+//
+// e.g. curl -X PUT --header 'Content-Type: application/json' -d '{"id": 1, "name": "repl_policy_name", ..., "enabled": false}' 'https://localhost/api/v2.0/replication/policies/1'
+func PutPolicyEnabled(c *harbor.Client, id int, enabled bool) (*harbor.Result, error) {
+	targetURL := replPoliciesURL(c) + "/" + strconv.Itoa(id)
+	c.Trace("==> GET", targetURL)
 
-type policiesList struct {
+	var policy map[string]interface{}
+	if err := c.GetJSON(targetURL, &policy); err != nil {
+		return nil, err
+	}
+	policy["enabled"] = enabled
+
+	c.Trace("==> PUT", targetURL)
+	return c.Do(c.Put(targetURL).Send(policy))
+}
+
+// PoliciesList holds the parameters of GetPoliciesList.
+type PoliciesList struct {
 	Name      string `short:"n" long:"name" description:"The replication's policy name." default:""`
 	ProjectID int    `short:"j" long:"project_id" description:"The ID of project." default:"0"`
 	Page      int    `short:"p" long:"page" description:"The page nubmer, default is 1." default:"1"`
-	PageSize  int    `short:"s" long:"page_size" description:"The size of per page, default is 10, maximum is 100." default:"10"`
+	PageSize  int    `short:"s" long:"page_size" description:"The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs." default:"10" validate:"pagesize"`
+	Count     bool   `long:"count" description:"Print the total number of matched items only."`
+	All       bool   `long:"all" description:"Fetch all pages, --page and --page_size are ignored."`
 }
 
-var poList policiesList
-
-func (x *policiesList) Execute(args []string) error {
-	GetPoliciesList(utils.URLGen("/api/policies"))
-	return nil
+func (x *PoliciesList) Execute(args []string) error {
+	return utils.ListPrinter(x.Count)(GetPoliciesList(utils.NewClient(), x))
 }
 
 // GetPoliciesList let user list filters policies by name and project_id, if name and project_id are nil, list returns all policies.
@@ -122,27 +550,43 @@ func (x *policiesList) Execute(args []string) error {
 //   name       - The replication's policy name.
 //   project_id - The ID of project.
 //   page       - The page nubmer, default is 1.
-//   page_size  - The size of per page, default is 10, maximum is 100.
+//   page_size  - The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs.
 //
 // format:
 //   GET /policies/replication
 //
 // e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/policies/replication?name=repl_policy_name&project_id=86&page=1&page_size=10'
-func GetPoliciesList(baseURL string) {
-	targetURL := baseURL + "/replication?name=" + poList.Name +
-		"&project_id=" + strconv.Itoa(poList.ProjectID) +
-		"&page=" + strconv.Itoa(poList.Page) +
-		"&page_size=" + strconv.Itoa(poList.PageSize)
-	fmt.Println("==> GET", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
+func GetPoliciesList(c *harbor.Client, opt *PoliciesList) (*harbor.Result, error) {
+	if opt.Count {
+		opt.Page, opt.PageSize = 1, 1
 	}
 
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	targetURL := replPoliciesURL(c) + "?name=" + url.QueryEscape(opt.Name)
+	if c.IsV2() {
+		if opt.ProjectID != 0 {
+			return nil, errors.New("filtering policies by project_id is not supported by v2.0 API")
+		}
+	} else {
+		targetURL += "&project_id=" + strconv.Itoa(opt.ProjectID)
+	}
+	targetURL += "&page=" + strconv.Itoa(opt.Page) +
+		"&page_size=" + strconv.Itoa(opt.PageSize)
+	if !opt.Count {
+		c.Trace("==> GET", targetURL)
+	}
+
+	if opt.All && !opt.Count {
+		return c.StreamAllPages(targetURL), nil
+	}
+
+	return c.DoStream(c.Get(targetURL))
+}
+
+// replPoliciesURL returns the URL of replication policies for the API
+// version of the Harbor.
+func replPoliciesURL(c *harbor.Client) string {
+	if c.IsV2() {
+		return c.URL("/api/v2.0/replication/policies")
+	}
+	return c.URL("/api/policies") + "/replication"
 }
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+func init() {
+	utils.Parser.AddCommand("prj_cve_allowlist_get",
+		"Get the CVE allowlist of a project.",
+		"This endpoint gets the CVE allowlist of a project, the CVEs ignored when preventing its vulnerable images from running, and whether the system CVE allowlist is used instead. (Harbor v1.9+)",
+		&ProjectCVEAllowlistGet{})
+	utils.Parser.AddCommand("prj_cve_allowlist_update",
+		"Add CVEs to or remove CVEs from the CVE allowlist of a project.",
+		"This endpoint updates the CVE allowlist of a project as cve_allowlist_update does the system one, and makes the project use it instead of the system one; --reuse-system makes it use the system one again, its own kept. (Harbor v1.9+)",
+		&ProjectCVEAllowlistUpdate{})
+}
+
+// prjCVEAllowlistKeys returns the names of the CVE allowlist of a project
+// and of its reuse_sys_cve_allowlist metadata, named whitelist before
+// Harbor v2.1.
+func prjCVEAllowlistKeys(legacy bool) (string, string) {
+	if legacy {
+		return "cve_whitelist", "reuse_sys_cve_whitelist"
+	}
+	return "cve_allowlist", "reuse_sys_cve_allowlist"
+}
+
+// getPrjCVEAllowlist gets the CVE allowlist of a project from the project,
+// and whether it is named whitelist.
+func getPrjCVEAllowlist(c *harbor.Client, projectID int) (*model.ProjectCVEAllowlist, bool, error) {
+	targetURL := c.APIURL("/projects") + "/" + strconv.Itoa(projectID)
+	c.Trace("==> GET", targetURL)
+
+	var p map[string]json.RawMessage
+	if err := c.GetJSON(targetURL, &p); err != nil {
+		return nil, false, err
+	}
+
+	_, allowlist := p["cve_allowlist"]
+	_, whitelist := p["cve_whitelist"]
+	legacy := whitelist && !allowlist
+	listKey, reuseKey := prjCVEAllowlistKeys(legacy)
+
+	a := &model.ProjectCVEAllowlist{
+		CVEAllowlist: &model.CVEAllowlist{Items: []model.CVEAllowlistItem{}},
+	}
+	if raw, ok := p[listKey]; ok && string(raw) != "null" {
+		if err := json.Unmarshal(raw, a.CVEAllowlist); err != nil {
+			return nil, false, err
+		}
+	}
+	var metadata map[string]string
+	if raw, ok := p["metadata"]; ok {
+		if err := json.Unmarshal(raw, &metadata); err != nil {
+			return nil, false, err
+		}
+	}
+	a.ReuseSysCVEAllowlist = metadata[reuseKey] == "true"
+	return a, legacy, nil
+}
+
+// ProjectCVEAllowlistGet holds the parameters of GetPrjCVEAllowlist.
+type ProjectCVEAllowlistGet struct {
+	ProjectID int `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
+}
+
+func (x *ProjectCVEAllowlistGet) Execute(args []string) error {
+	a, err := GetPrjCVEAllowlist(utils.NewClient(), x)
+	return utils.PrintValue(a, err, func() {
+		if a.ReuseSysCVEAllowlist {
+			fmt.Println("The system CVE allowlist is used, see cve_allowlist_get. The project's own:")
+		}
+		for _, item := range a.CVEAllowlist.Items {
+			fmt.Println(item.CVEID)
+		}
+		fmt.Println(cveAllowlistSummary(a.CVEAllowlist))
+	})
+}
+
+// GetPrjCVEAllowlist gets the CVE allowlist of a project, its
+// cve_allowlist (cve_whitelist before Harbor v2.1), and whether the
+// system one is used instead, by the reuse_sys_cve_allowlist metadata.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//
+// format:
+//   GET /projects/{project_id}
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/projects/1'
+//
+func GetPrjCVEAllowlist(c *harbor.Client, opt *ProjectCVEAllowlistGet) (*model.ProjectCVEAllowlist, error) {
+	a, _, err := getPrjCVEAllowlist(c, opt.ProjectID)
+	return a, err
+}
+
+// ProjectCVEAllowlistUpdate holds the parameters of PutPrjCVEAllowlist.
+type ProjectCVEAllowlistUpdate struct {
+	ProjectID   int  `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
+	ReuseSystem bool `long:"reuse-system" description:"Use the system CVE allowlist again instead of the project's own."`
+	CVEAllowlistEdit
+}
+
+func (x *ProjectCVEAllowlistUpdate) Execute(args []string) error {
+	res, err := PutPrjCVEAllowlist(utils.NewClient(), x)
+	if x.DryRun && err == nil {
+		return nil
+	}
+	return utils.PrintResult(res, err)
+}
+
+// PutPrjCVEAllowlist updates the CVE allowlist of a project, merging the
+// changes with the current one, and sets reuse_sys_cve_allowlist to false
+// so the project uses it, or to true with --reuse-system. Nothing is sent
+// with --dry-run.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   add - The CVEs to add.
+//   remove - The CVEs to remove.
+//   reuse-system - Use the system CVE allowlist again.
+//
+// format:
+//   GET /projects/{project_id}
+//   PUT /projects/{project_id}
+//
+// e.g. curl -X PUT --header 'Content-Type: application/json' -d '{"cve_allowlist": {"project_id": 1, "items": [{"cve_id": "CVE-2021-44228"}]}, "metadata": {"reuse_sys_cve_allowlist": "false"}}' 'https://localhost/api/v2.0/projects/1'
+func PutPrjCVEAllowlist(c *harbor.Client, opt *ProjectCVEAllowlistUpdate) (*harbor.Result, error) {
+	edits := len(opt.Add) > 0 || len(opt.Remove) > 0 || opt.ExpiresIn != "" || opt.ExpiresAt != "" || opt.NeverExpires
+	if opt.ReuseSystem && edits {
+		return nil, fmt.Errorf("--reuse-system cannot be given with changes to the project's CVE allowlist")
+	}
+
+	cur, legacy, err := getPrjCVEAllowlist(c, opt.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	listKey, reuseKey := prjCVEAllowlistKeys(legacy)
+
+	body := map[string]interface{}{}
+	if opt.ReuseSystem {
+		body["metadata"] = map[string]string{reuseKey: "true"}
+	} else {
+		list, err := opt.edit(cur.CVEAllowlist, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		list.ProjectID = int64(opt.ProjectID)
+		printCVEAllowlistChanges(cur.CVEAllowlist, list)
+		if opt.DryRun {
+			return nil, nil
+		}
+		body[listKey] = list
+		body["metadata"] = map[string]string{reuseKey: "false"}
+	}
+
+	targetURL := c.APIURL("/projects") + "/" + strconv.Itoa(opt.ProjectID)
+	c.Trace("==> PUT", targetURL)
+
+	return c.Do(c.Put(targetURL).
+		Send(body))
+}
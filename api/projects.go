@@ -3,8 +3,11 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"strconv"
 
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
 	"github.com/moooofly/harbor-go-client/utils"
 )
 
@@ -12,80 +15,98 @@ func init() {
 	utils.Parser.AddCommand("prj_member_update",
 		"Update a member of a project.",
 		"Update a member of a project.",
-		&prjMemberUpdate)
+		&ProjectMemberUpdate{})
 	utils.Parser.AddCommand("prj_member_get",
 		"Get a member of a project.",
 		"Get a member of a project.",
-		&prjMemberGet)
+		&ProjectMemberGet{})
 	utils.Parser.AddCommand("prj_member_del",
 		"Delete a member of a project.",
 		"Delete a member of a project.",
-		&prjMemberDel)
+		&ProjectMemberDel{})
 	utils.Parser.AddCommand("prj_member_create",
 		"Create a member of a project.",
 		"Create project member relationship, the member can be one of the user_member and group_member, The user_member need to specify user_id or username. If the user already exist in harbor DB, specify the user_id, If does not exist in harbor DB, it will SearchAndOnBoard the user. The group_member need to specify id or ldap_group_dn. If the group already exist in harbor DB. specify the user group's id, If does not exist, it will SearchAndOnBoard the group.",
-		&prjMemberCreate)
+		&ProjectMemberCreate{})
 	utils.Parser.AddCommand("prj_members_get",
 		"Get all members information of a project.",
 		"Get all members information of a project.",
-		&prjMembersGet)
+		&ProjectMembersGet{})
 	utils.Parser.AddCommand("prj_metadata_update_by_name",
 		"Update metadata of a project by meta_name.",
 		"This endpoint is aimed to update the metadata of a project by meta_name.",
-		&prjMetadataUpdateByName)
+		&ProjectMetadataUpdateByName{})
 	utils.Parser.AddCommand("prj_metadata_get_by_name",
 		"Get metadata of a project by meta_name.",
 		"This endpoint returns specified metadata of a project by meta_name.",
-		&prjMetadataGetByName)
+		&ProjectMetadataGetByName{})
 	utils.Parser.AddCommand("prj_metadata_del_by_name",
 		"Delete metadata of a project by meta_name.",
 		"This endpoint is aimed to delete metadata of a project by meta_name.",
-		&prjMetadataDelByName)
+		&ProjectMetadataDelByName{})
 	utils.Parser.AddCommand("prj_metadata_add",
 		"Add metadata for a project.",
 		"This endpoint is aimed to add metadata of a project.",
-		&prjMetadataAdd)
+		&ProjectMetadataAdd{})
 	utils.Parser.AddCommand("prj_metadata_get",
 		"Get metadata of a project.",
 		"This endpoint returns metadata of the project specified by project ID.",
-		&prjMetadataGet)
+		&ProjectMetadataGet{})
 	utils.Parser.AddCommand("prj_logs_get",
 		"Get access logs accompany with a relevant project.",
 		"This endpoint let user search access logs filtered by operations and date time ranges.",
-		&prjLogsGet)
+		&ProjectLogsGet{})
 	utils.Parser.AddCommand("prj_update",
 		"Update properties for a selected project.",
 		"This endpoint is aimed to update the properties of a project.",
-		&prjUpdate)
+		&ProjectUpdate{})
 	utils.Parser.AddCommand("prj_create",
 		"Create a new project.",
 		"This endpoint is for user to create a new project.",
-		&prjCreate)
+		&ProjectCreate{})
 	utils.Parser.AddCommand("prj_get",
 		"Return specific project detail information.",
 		"This endpoint returns specific project information by project ID.",
-		&prjGet)
+		&ProjectGet{})
 	utils.Parser.AddCommand("prj_del",
 		"Delete a project by project_id.",
 		"This endpoint is aimed to delete a project by project_id.",
-		&prjDel)
+		&ProjectDel{})
 	utils.Parser.AddCommand("prjs_list",
 		"List projects.",
 		"This endpoint returns all projects created by Harbor, and can be filtered by project name.",
-		&prjsList)
+		&ProjectsList{})
+	utils.Parser.AddCommand("prj_summary_get",
+		"Get summary of a project, with the upstream registry of a proxy cache project.",
+		"This endpoint returns the summary of a project (quota, member and repository counts, and the upstream registry if it is a proxy cache project). (Harbor v2.1+, uses v2.0 API)",
+		&ProjectSummaryGet{})
+	utils.Parser.AddCommand("prj_proxy_speed_get",
+		"Get the bandwidth limit of a proxy cache project.",
+		"This endpoint returns the proxy_speed_kb metadata of a proxy cache project, the bandwidth limit in KB/s of pulling from the upstream registry. (Harbor v2.9+, uses v2.0 API)",
+		&ProjectProxySpeedGet{})
+	utils.Parser.AddCommand("prj_proxy_speed_set",
+		"Set the bandwidth limit of a proxy cache project.",
+		"This endpoint sets the proxy_speed_kb metadata of a proxy cache project, the bandwidth limit in KB/s of pulling from the upstream registry, -1 for unlimited. (Harbor v2.9+, uses v2.0 API)",
+		&ProjectProxySpeedSet{})
+
+	utils.ResponseModel("prj_member_get", model.ProjectMember{})
+	utils.ResponseModel("prj_members_get", model.ProjectMember{})
+	utils.ResponseModel("prj_metadata_get", model.ProjectMetadata{})
+	utils.ResponseModel("prj_get", model.Project{})
+	utils.ResponseModel("prjs_list", model.Project{})
+	utils.ResponseModel("prj_summary_get", model.ProjectSummary{})
 }
 
-type projectMemberUpdate struct {
-	ProjectID int `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes" json:"-"`
-	MID       int `short:"m" long:"mid" description:"(REQUIRED) Member ID." required:"yes" json:"-"`
-	RoleID    int `short:"r" long:"role_id" description:"(REQUIRED) Role ID. Only 1 (projectAdmin),2 (developer), 3 (guest) are valid." required:"yes" json:"role_id"`
+// ProjectMemberUpdate holds the parameters of PutPrjMemberUpdate.
+type ProjectMemberUpdate struct {
+	ProjectID int    `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes" json:"-"`
+	MID       int    `short:"m" long:"mid" description:"(REQUIRED) Member ID." required:"yes" json:"-"`
+	RoleID    int    `short:"r" long:"role_id" description:"Role ID: 1 (projectAdmin), 2 (developer), 3 (guest), 4 (maintainer) or 5 (limitedGuest, v2.0 API). Required unless --role is given." json:"role_id"`
+	Role      string `long:"role" description:"Role name, instead of --role_id: admin, developer, guest, maintainer or limited-guest." validate:"oneof=admin|developer|guest|maintainer|limited-guest" json:"-"`
 }
 
-var prjMemberUpdate projectMemberUpdate
-
-func (x *projectMemberUpdate) Execute(args []string) error {
-	PutPrjMemberUpdate(utils.URLGen("/api/projects"))
-	return nil
+func (x *ProjectMemberUpdate) Execute(args []string) error {
+	return utils.PrintResult(PutPrjMemberUpdate(utils.NewClient(), x))
 }
 
 // PutPrjMemberUpdate updates a member of the project.
@@ -93,7 +114,7 @@ func (x *projectMemberUpdate) Execute(args []string) error {
 // params:
 //   project_id - (REQUIRED) The ID of project.
 //   mid        - (REQUIRED) Member ID.
-//   role_id    - (REQUIRED) Role ID.
+//   role_id    - (REQUIRED) Role ID, or role by name.
 //
 // format:
 //   PUT /projects/{project_id}/members/{mid}
@@ -104,41 +125,35 @@ curl -X PUT --header 'Content-Type: application/json' --header 'Accept: text/pla
    "role_id": 1 \
  }' 'https://localhost/api/projects/86/members/86'
 */
-func PutPrjMemberUpdate(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(prjMemberUpdate.ProjectID) +
-		"/members/" + strconv.Itoa(prjMemberUpdate.MID)
-	fmt.Println("==> PUT", targetURL)
+func PutPrjMemberUpdate(c *harbor.Client, opt *ProjectMemberUpdate) (*harbor.Result, error) {
+	targetURL := c.APIURL("/projects") + "/" + strconv.Itoa(opt.ProjectID) +
+		"/members/" + strconv.Itoa(opt.MID)
+	c.Trace("==> PUT", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
+	role, err := memberRoleID(opt.RoleID, opt.Role)
 	if err != nil {
-		fmt.Println("Error:", err)
-		return
+		return nil, err
 	}
+	opt.RoleID = role
 
-	p, err := json.Marshal(&prjMemberUpdate)
+	p, err := json.Marshal(opt)
 	if err != nil {
-		fmt.Println("error:", err)
-		return
+		return nil, err
 	}
-	fmt.Println("==> member update:", string(p))
+	c.Trace("==> member update:", string(p))
 
-	utils.Request.Put(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		Send(string(p)).
-		End(utils.PrintStatus)
+	return c.Do(c.Put(targetURL).
+		Send(string(p)))
 }
 
-type projectMemberGet struct {
+// ProjectMemberGet holds the parameters of GetPrjMember.
+type ProjectMemberGet struct {
 	ProjectID int `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
 	MID       int `short:"m" long:"mid" description:"(REQUIRED) Member ID." required:"yes"`
 }
 
-var prjMemberGet projectMemberGet
-
-func (x *projectMemberGet) Execute(args []string) error {
-	GetPrjMember(utils.URLGen("/api/projects"))
-	return nil
+func (x *ProjectMemberGet) Execute(args []string) error {
+	return utils.PrintResult(GetPrjMember(utils.NewClient(), x))
 }
 
 // GetPrjMember gets a member of the project.
@@ -151,33 +166,22 @@ func (x *projectMemberGet) Execute(args []string) error {
 //   GET /projects/{project_id}/members/{mid}
 //
 // e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/projects/86/members/86'
-func GetPrjMember(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(prjMemberGet.ProjectID) +
-		"/members/" + strconv.Itoa(prjMemberGet.MID)
-	fmt.Println("==> GET", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
+func GetPrjMember(c *harbor.Client, opt *ProjectMemberGet) (*harbor.Result, error) {
+	targetURL := c.APIURL("/projects") + "/" + strconv.Itoa(opt.ProjectID) +
+		"/members/" + strconv.Itoa(opt.MID)
+	c.Trace("==> GET", targetURL)
 
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Get(targetURL))
 }
 
-type projectMemberDel struct {
+// ProjectMemberDel holds the parameters of DeletePrjMemberDel.
+type ProjectMemberDel struct {
 	ProjectID int `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
 	MID       int `short:"m" long:"mid" description:"(REQUIRED) Member ID." required:"yes"`
 }
 
-var prjMemberDel projectMemberDel
-
-func (x *projectMemberDel) Execute(args []string) error {
-	DeletePrjMemberDel(utils.URLGen("/api/projects"))
-	return nil
+func (x *ProjectMemberDel) Execute(args []string) error {
+	return utils.PrintResult(DeletePrjMemberDel(utils.NewClient(), x))
 }
 
 // DeletePrjMemberDel deletes a member of the project.
@@ -190,21 +194,12 @@ func (x *projectMemberDel) Execute(args []string) error {
 //   DELETE /projects/{project_id}/members/{mid}
 //
 // e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/projects/86/members/86'
-func DeletePrjMemberDel(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(prjMemberDel.ProjectID) +
-		"/members/" + strconv.Itoa(prjMemberDel.MID)
-	fmt.Println("==> DELETE", targetURL)
+func DeletePrjMemberDel(c *harbor.Client, opt *ProjectMemberDel) (*harbor.Result, error) {
+	targetURL := c.APIURL("/projects") + "/" + strconv.Itoa(opt.ProjectID) +
+		"/members/" + strconv.Itoa(opt.MID)
+	c.Trace("==> DELETE", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
-
-	utils.Request.Delete(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Delete(targetURL))
 }
 
 /*
@@ -217,41 +212,77 @@ func DeletePrjMemberDel(baseURL string) {
 
 */
 
-// ProjectMember defines the member settings of a project.
+// ProjectMember defines the member settings of a project, a user or a
+// user group.
 type ProjectMember struct {
-	RoleID     int `json:"role_id,omitempty"`
-	MemberUser struct {
-		UserID   int    `json:"user_id,omitempty,omitempty"`
-		Username string `json:"username,omitempty"`
-	} `json:"member_user,omitempty"`
-	MemberGroup struct {
-		ID          int    `json:"id,omitempty"`
-		GroupName   string `json:"group_name,omitempty"`
-		GroupType   int    `json:"group_type,omitempty"`
-		LdapGroupDn string `json:"ldap_group_dn,omitempty"`
-	} `json:"member_group,omitempty"`
-}
-
-var prjMember ProjectMember
-
-type projectMemberCreate struct {
-	ProjectID int    `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
-	RoleID    int    `short:"r" long:"role_id" description:"(REQUIRED) Role ID. Only 1 (projectAdmin),2 (developer), 3 (guest) are valid." required:"yes"`
-	Username  string `short:"n" long:"username" description:"(REQUIRED) Username." required:"yes"`
+	RoleID      int                 `json:"role_id,omitempty"`
+	MemberUser  *ProjectMemberUser  `json:"member_user,omitempty"`
+	MemberGroup *ProjectMemberGroup `json:"member_group,omitempty"`
+}
+
+// ProjectMemberUser is the user of a ProjectMember.
+type ProjectMemberUser struct {
+	UserID   int    `json:"user_id,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+// ProjectMemberGroup is the user group of a ProjectMember.
+type ProjectMemberGroup struct {
+	ID          int    `json:"id,omitempty"`
+	GroupName   string `json:"group_name,omitempty"`
+	GroupType   int    `json:"group_type,omitempty"`
+	LdapGroupDn string `json:"ldap_group_dn,omitempty"`
+}
+
+// memberRoles are the project roles by name.
+var memberRoles = map[string]int{
+	"admin":         1,
+	"developer":     2,
+	"guest":         3,
+	"maintainer":    4,
+	"limited-guest": 5,
+}
+
+// memberRoleID returns the role of a member, given by ID or by name.
+func memberRoleID(id int, name string) (int, error) {
+	switch {
+	case id != 0 && name != "":
+		return 0, fmt.Errorf("--role_id and --role are exclusive")
+	case name != "":
+		return memberRoles[name], nil
+	case id < 1 || id > 5:
+		return 0, fmt.Errorf("--role_id or --role is required, a role ID is 1 to 5")
+	}
+	return id, nil
 }
 
-var prjMemberCreate projectMemberCreate
+// ProjectMemberCreate holds the parameters of PostPrjMemberCreate.
+type ProjectMemberCreate struct {
+	ProjectID   int    `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
+	RoleID      int    `short:"r" long:"role_id" description:"Role ID: 1 (projectAdmin), 2 (developer), 3 (guest), 4 (maintainer) or 5 (limitedGuest, v2.0 API). Required unless --role is given."`
+	Role        string `long:"role" description:"Role name, instead of --role_id: admin, developer, guest, maintainer or limited-guest." validate:"oneof=admin|developer|guest|maintainer|limited-guest"`
+	Username    string `short:"n" long:"username" description:"The username of a user member."`
+	GroupID     int    `short:"g" long:"group_id" description:"The ID of a user group member."`
+	GroupName   string `long:"group_name" description:"The name of a user group member, onboarded if unknown to Harbor (HTTP and OIDC groups)."`
+	GroupType   int    `long:"group_type" description:"The type of the user group of --group_name: 1 (LDAP), 2 (HTTP) or 3 (OIDC)." validate:"min=1,max=3"`
+	LdapGroupDn string `long:"ldap_group_dn" description:"The DN of an LDAP group member, onboarded if unknown to Harbor."`
+}
 
-func (x *projectMemberCreate) Execute(args []string) error {
-	PostPrjMemberCreate(utils.URLGen("/api/projects"))
-	return nil
+func (x *ProjectMemberCreate) Execute(args []string) error {
+	return utils.PrintResult(PostPrjMemberCreate(utils.NewClient(), x))
 }
 
 // PostPrjMemberCreate creates project member relationship, the member can be one of the user_member and group_member, The user_member need to specify user_id or username. If the user already exist in harbor DB, specify the user_id, If does not exist in harbor DB, it will SearchAndOnBoard the user. The group_member need to specify id or ldap_group_dn. If the group already exist in harbor DB. specify the user group's id, If does not exist, it will SearchAndOnBoard the group.
 //
 // params:
-//   project_id - (REQUIRED) The ID of project.
-//   mid        - (REQUIRED) Member ID.
+//   project_id    - (REQUIRED) The ID of project.
+//   role_id       - (REQUIRED) Role ID, or role by name.
+//   username      - The username of a user member.
+//   group_id      - The ID of a user group member.
+//   group_name    - The name of a user group member, with group_type.
+//   ldap_group_dn - The DN of an LDAP group member.
+//
+// Exactly one of username, group_id, group_name and ldap_group_dn is given.
 //
 // format:
 //   POST /projects/{project_id}/members
@@ -265,43 +296,62 @@ curl -X POST --header 'Content-Type: application/json' --header 'Accept: text/pl
    } \
  }' 'https://localhost/api/projects/86/members'
 */
-func PostPrjMemberCreate(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(prjMemberCreate.ProjectID) + "/members"
-	fmt.Println("==> POST", targetURL)
+func PostPrjMemberCreate(c *harbor.Client, opt *ProjectMemberCreate) (*harbor.Result, error) {
+	targetURL := c.APIURL("/projects") + "/" + strconv.Itoa(opt.ProjectID) + "/members"
+	c.Trace("==> POST", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
+	role, err := memberRoleID(opt.RoleID, opt.Role)
 	if err != nil {
-		fmt.Println("Error:", err)
-		return
+		return nil, err
 	}
 
-	prjMember.RoleID = prjMemberCreate.RoleID
-	prjMember.MemberUser.Username = prjMemberCreate.Username
+	var prjMember ProjectMember
+	prjMember.RoleID = role
+	members := 0
+	if opt.Username != "" {
+		prjMember.MemberUser = &ProjectMemberUser{Username: opt.Username}
+		members++
+	}
+	if opt.GroupID != 0 {
+		prjMember.MemberGroup = &ProjectMemberGroup{ID: opt.GroupID}
+		members++
+	}
+	if opt.GroupName != "" {
+		if opt.GroupType == 0 {
+			return nil, fmt.Errorf("--group_type is required with --group_name")
+		}
+		prjMember.MemberGroup = &ProjectMemberGroup{GroupName: opt.GroupName, GroupType: opt.GroupType}
+		members++
+	}
+	if opt.LdapGroupDn != "" {
+		prjMember.MemberGroup = &ProjectMemberGroup{LdapGroupDn: opt.LdapGroupDn, GroupType: 1}
+		members++
+	}
+	if members != 1 {
+		return nil, fmt.Errorf("exactly one of --username, --group_id, --group_name and --ldap_group_dn is required")
+	}
 
 	p, err := json.Marshal(&prjMember)
 	if err != nil {
-		fmt.Println("error:", err)
-		return
+		return nil, err
 	}
-	fmt.Println("==> member create:", string(p))
+	c.Trace("==> member create:", string(p))
 
-	utils.Request.Post(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		Send(string(p)).
-		End(utils.PrintStatus)
+	return c.Do(c.Post(targetURL).
+		Send(string(p)))
 }
 
-type projectMembersGet struct {
+// ProjectMembersGet holds the parameters of GetPrjAllMembers.
+type ProjectMembersGet struct {
 	ProjectID  int    `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
 	EntityName string `short:"n" long:"entityname" description:"The entity name to search (filter)." default:""`
+	Page       int    `short:"p" long:"page" description:"The page nubmer, default is 1. (v2.0 API, v1 API lists all members)" default:"1"`
+	PageSize   int    `short:"s" long:"page_size" description:"The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs." default:"10" validate:"pagesize"`
+	All        bool   `long:"all" description:"Fetch all pages, --page and --page_size are ignored."`
 }
 
-var prjMembersGet projectMembersGet
-
-func (x *projectMembersGet) Execute(args []string) error {
-	GetPrjAllMembers(utils.URLGen("/api/projects"))
-	return nil
+func (x *ProjectMembersGet) Execute(args []string) error {
+	return utils.PrintResult(GetPrjAllMembers(utils.NewClient(), x))
 }
 
 // GetPrjAllMembers gets all members information of the project.
@@ -309,38 +359,41 @@ func (x *projectMembersGet) Execute(args []string) error {
 // params:
 //   project_id - (REQUIRED) The ID of project.
 //   entityname - The entity name to search (filter).
+//   page       - The page nubmer, v2.0 API.
+//   page_size  - The size of per page, v2.0 API.
 //
 // format:
 //   GET /projects/{project_id}/members
 //
 // e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/projects/86/members?entityname=admin'
-func GetPrjAllMembers(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(prjMembersGet.ProjectID) +
-		"/members?entityname=" + prjMembersGet.EntityName
-	fmt.Println("==> GET", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
+func GetPrjAllMembers(c *harbor.Client, opt *ProjectMembersGet) (*harbor.Result, error) {
+	targetURL := c.APIURL("/projects") + "/" + strconv.Itoa(opt.ProjectID) +
+		"/members?entityname=" + url.QueryEscape(opt.EntityName)
+
+	// v1 API has no pages, every member is in the response.
+	if !c.IsV2() {
+		c.Trace("==> GET", targetURL)
+		return c.Do(c.Get(targetURL))
 	}
 
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	targetURL += "&page=" + strconv.Itoa(opt.Page) +
+		"&page_size=" + strconv.Itoa(opt.PageSize)
+	c.Trace("==> GET", targetURL)
+
+	if opt.All {
+		return c.StreamAllPages(targetURL), nil
+	}
+	return c.DoStream(c.Get(targetURL))
 }
 
-type projectMetadataUpdateByName struct {
+// ProjectMetadataUpdateByName holds the parameters of PutPrjMetadataUpdateByName.
+type ProjectMetadataUpdateByName struct {
 	ProjectID int    `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
 	MetaName  string `short:"m" long:"meta_name" description:"(REQUIRED) The name of metadata." required:"yes"`
 }
 
-var prjMetadataUpdateByName projectMetadataUpdateByName
-
-func (x *projectMetadataUpdateByName) Execute(args []string) error {
-	PutPrjMetadataUpdateByName(utils.URLGen("/api/projects"))
-	return nil
+func (x *ProjectMetadataUpdateByName) Execute(args []string) error {
+	return utils.PrintResult(PutPrjMetadataUpdateByName(utils.NewClient(), x))
 }
 
 // PutPrjMetadataUpdateByName is aimed to update the metadata of a project.
@@ -353,33 +406,22 @@ func (x *projectMetadataUpdateByName) Execute(args []string) error {
 //   PUT /projects/{project_id}/metadatas/{meta_name}
 //
 // e.g. curl -X PUT --header 'Content-Type: application/json' --header 'Accept: text/plain' 'https://localhost/api/projects/86/metadatas/metaname_new'
-func PutPrjMetadataUpdateByName(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(prjMetadataUpdateByName.ProjectID) +
-		"/metadatas" + prjMetadataUpdateByName.MetaName
-	fmt.Println("==> PUT", targetURL)
+func PutPrjMetadataUpdateByName(c *harbor.Client, opt *ProjectMetadataUpdateByName) (*harbor.Result, error) {
+	targetURL := c.APIURL("/projects") + "/" + strconv.Itoa(opt.ProjectID) +
+		"/metadatas" + opt.MetaName
+	c.Trace("==> PUT", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
-
-	utils.Request.Put(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Put(targetURL))
 }
 
-type projectMetadataGetByName struct {
+// ProjectMetadataGetByName holds the parameters of GetPrjMetadataGetByName.
+type ProjectMetadataGetByName struct {
 	ProjectID int    `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
 	MetaName  string `short:"m" long:"meta_name" description:"(REQUIRED) The name of metadata." required:"yes"`
 }
 
-var prjMetadataGetByName projectMetadataGetByName
-
-func (x *projectMetadataGetByName) Execute(args []string) error {
-	GetPrjMetadataGetByName(utils.URLGen("/api/projects"))
-	return nil
+func (x *ProjectMetadataGetByName) Execute(args []string) error {
+	return utils.PrintResult(GetPrjMetadataGetByName(utils.NewClient(), x))
 }
 
 // GetPrjMetadataGetByName returns specified metadata of a project.
@@ -392,33 +434,22 @@ func (x *projectMetadataGetByName) Execute(args []string) error {
 //   GET /projects/{project_id}/metadatas/{meta_name}
 //
 // e.g. curl -X GET --header 'Accept: text/plain' 'https://localhost/api/projects/86/metadatas/metaname_new'
-func GetPrjMetadataGetByName(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(prjMetadataGetByName.ProjectID) +
-		"/metadatas" + prjMetadataGetByName.MetaName
-	fmt.Println("==> GET", targetURL)
+func GetPrjMetadataGetByName(c *harbor.Client, opt *ProjectMetadataGetByName) (*harbor.Result, error) {
+	targetURL := c.APIURL("/projects") + "/" + strconv.Itoa(opt.ProjectID) +
+		"/metadatas" + opt.MetaName
+	c.Trace("==> GET", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
-
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Get(targetURL))
 }
 
-type projectMetadataDelByName struct {
+// ProjectMetadataDelByName holds the parameters of DeletePrjMetadataDelByName.
+type ProjectMetadataDelByName struct {
 	ProjectID int    `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
 	MetaName  string `short:"m" long:"meta_name" description:"(REQUIRED) The name of metadata." required:"yes"`
 }
 
-var prjMetadataDelByName projectMetadataDelByName
-
-func (x *projectMetadataDelByName) Execute(args []string) error {
-	DeletePrjMetadataDelByName(utils.URLGen("/api/projects"))
-	return nil
+func (x *ProjectMetadataDelByName) Execute(args []string) error {
+	return utils.PrintResult(DeletePrjMetadataDelByName(utils.NewClient(), x))
 }
 
 // DeletePrjMetadataDelByName is aimed to delete metadata of a project.
@@ -431,37 +462,28 @@ func (x *projectMetadataDelByName) Execute(args []string) error {
 //   DELETE /projects/{project_id}/metadatas/{meta_name}
 //
 // e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/projects/86/metadatas/metaname-new'
-func DeletePrjMetadataDelByName(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(prjMetadataDelByName.ProjectID) +
-		"/metadatas" + prjMetadataDelByName.MetaName
-	fmt.Println("==> DELETE", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
+func DeletePrjMetadataDelByName(c *harbor.Client, opt *ProjectMetadataDelByName) (*harbor.Result, error) {
+	targetURL := c.APIURL("/projects") + "/" + strconv.Itoa(opt.ProjectID) +
+		"/metadatas" + opt.MetaName
+	c.Trace("==> DELETE", targetURL)
 
-	utils.Request.Delete(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Delete(targetURL))
 }
 
-type projectMetadataAdd struct {
+// ProjectMetadataAdd holds the parameters of PostPrjMetadataAdd.
+type ProjectMetadataAdd struct {
 	ProjectID                                  int    `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes" json:"-"`
 	Public                                     int    `short:"k" long:"public" description:"The public status of the project, public(1) or private(0)." json:"public"`
 	EnablelontentTrust                         bool   `short:"t" long:"enable_content_trust" description:"Whether content trust is enabled or not. If it is enabled, user cann't pull unsigned images from this project." json:"enable_content_trust"`
 	PreventVulnerableImagesFromRunning         bool   `short:"r" long:"prevent_vulnerable_images_from_running" description:"Whether prevent the vulnerable images from running." json:"prevent_vulnerable_images_from_running"`
-	PreventVulnerableImagesFromRunningSeverity string `short:"s" long:"prevent_vulnerable_images_from_running_severity" description:"If the vulnerability is high than severity defined here, the images cann't be pulled." default:"" json:"prevent_vulnerable_images_from_running_severity"`
+	PreventVulnerableImagesFromRunningSeverity string `short:"s" long:"prevent_vulnerable_images_from_running_severity" description:"If the vulnerability is high than severity defined here, the images cann't be pulled: negligible, unknown, low, medium, high, or critical and none with v2.0 API." default:"" validate:"oneof=negligible|unknown|low|medium|high|critical|none" json:"prevent_vulnerable_images_from_running_severity"`
 	AutomaticallyScanImagesOnPush              bool   `short:"a" long:"automatically_scan_images_on_push" description:"Whether scan images automatically when pushing." json:"automatically_scan_images_on_push"`
+	RegistryID                                 int    `long:"registry_id" description:"Create a proxy cache project of this registry endpoint. (Harbor v2.1+, uses v2.0 API)" json:"registry_id,omitempty"`
+	ProxySpeedKB                               int    `long:"proxy_speed_kb" description:"Bandwidth limit of the proxy cache project pulling from upstream, in KB/s, -1 for unlimited. (Harbor v2.9+)" json:"proxy_speed_kb,omitempty"`
 }
 
-var prjMetadataAdd projectMetadataAdd
-
-func (x *projectMetadataAdd) Execute(args []string) error {
-	PostPrjMetadataAdd(utils.URLGen("/api/projects"))
-	return nil
+func (x *ProjectMetadataAdd) Execute(args []string) error {
+	return utils.PrintResult(PostPrjMetadataAdd(utils.NewClient(), x))
 }
 
 // PostPrjMetadataAdd is aimed to add metadata of a project.
@@ -483,39 +505,27 @@ curl -X POST --header 'Content-Type: application/json' --header 'Accept: text/pl
    "public": "false" \
  }' 'https://localhost/api/projects/86/metadatas'
 */
-func PostPrjMetadataAdd(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(prjMetadataAdd.ProjectID) + "/metadatas"
-	fmt.Println("==> POST", targetURL)
+func PostPrjMetadataAdd(c *harbor.Client, opt *ProjectMetadataAdd) (*harbor.Result, error) {
+	targetURL := c.APIURL("/projects") + "/" + strconv.Itoa(opt.ProjectID) + "/metadatas"
+	c.Trace("==> POST", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
+	p, err := json.Marshal(opt)
 	if err != nil {
-		fmt.Println("Error:", err)
-		return
+		return nil, err
 	}
+	c.Trace("==> metadata add:", string(p))
 
-	p, err := json.Marshal(&prjMetadataAdd)
-	if err != nil {
-		fmt.Println("error:", err)
-		return
-	}
-	fmt.Println("==> metadata add:", string(p))
-
-	utils.Request.Post(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		Send(string(p)).
-		End(utils.PrintStatus)
+	return c.Do(c.Post(targetURL).
+		Send(string(p)))
 }
 
-type projectMetadataGet struct {
+// ProjectMetadataGet holds the parameters of GetPrjMetadata.
+type ProjectMetadataGet struct {
 	ProjectID int `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
 }
 
-var prjMetadataGet projectMetadataGet
-
-func (x *projectMetadataGet) Execute(args []string) error {
-	GetPrjMetadata(utils.URLGen("/api/projects"))
-	return nil
+func (x *ProjectMetadataGet) Execute(args []string) error {
+	return utils.PrintResult(GetPrjMetadata(utils.NewClient(), x))
 }
 
 // GetPrjMetadata returns metadata of the project specified by project ID.
@@ -527,23 +537,15 @@ func (x *projectMetadataGet) Execute(args []string) error {
 //   GET /projects/{project_id}/metadatas
 //
 // e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/projects/86/metadatas'
-func GetPrjMetadata(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(prjMetadataGet.ProjectID) + "/metadatas"
-	fmt.Println("==> GET", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
+func GetPrjMetadata(c *harbor.Client, opt *ProjectMetadataGet) (*harbor.Result, error) {
+	targetURL := c.APIURL("/projects") + "/" + strconv.Itoa(opt.ProjectID) + "/metadatas"
+	c.Trace("==> GET", targetURL)
 
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Get(targetURL))
 }
 
-type projectLogsGet struct {
+// ProjectLogsGet holds the parameters of GetPrjLogs.
+type ProjectLogsGet struct {
 	ProjectID      int    `short:"j" long:"project_id" description:"(REQUIRED) Relevant project ID" required:"yes"`
 	Username       string `short:"u" long:"username" description:"Username of the operator" default:""`
 	Repository     string `short:"r" long:"repository" description:"The name of repository" default:""`
@@ -552,14 +554,13 @@ type projectLogsGet struct {
 	BeginTimestamp string `short:"b" long:"begin_timestamp" description:"The begin timestamp, time format is unknown." default:""`
 	EndTimestamp   string `short:"e" long:"end_timestamp" description:"The end timestamp, time format is unknown." default:""`
 	Page           int    `short:"p" long:"page" description:"The page nubmer, default is 1." default:"1"`
-	PageSize       int    `short:"s" long:"page_size" description:"The size of per page, default is 10, maximum is 100." default:"10"`
+	PageSize       int    `short:"s" long:"page_size" description:"The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs." default:"10" validate:"pagesize"`
+	Count          bool   `long:"count" description:"Print the total number of matched items only."`
+	All            bool   `long:"all" description:"Fetch all pages, --page and --page_size are ignored."`
 }
 
-var prjLogsGet projectLogsGet
-
-func (x *projectLogsGet) Execute(args []string) error {
-	GetPrjLogs(utils.URLGen("/api/projects"))
-	return nil
+func (x *ProjectLogsGet) Execute(args []string) error {
+	return utils.ListPrinter(x.Count)(GetPrjLogs(utils.NewClient(), x))
 }
 
 // GetPrjLogs lets user search access logs filtered by operations and date time ranges.
@@ -573,51 +574,145 @@ func (x *projectLogsGet) Execute(args []string) error {
 //   begin_timestamp - The begin timestamp, time format is unknown.
 //   end_timestamp   - The end timestamp, time format is unknown.
 //   page            - The page nubmer, default is 1.
-//   page_size       - The size of per page, default is 10, maximum is 100.
+//   page_size       - The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs.
 //
 // format:
 //   GET /projects/{project_id}/logs
 //
 // e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/projects/86/logs?username=admin&repository=temp_5&tag=v6&operation=pull&page=1&page_size=10'
-func GetPrjLogs(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(prjLogsGet.ProjectID) +
-		"/logs" + "?username=" + prjLogsGet.Username +
-		"&repository=" + prjLogsGet.Repository +
-		"&tag=" + prjLogsGet.Tag +
-		"&operation=" + prjLogsGet.Operation +
-		"&begin_timestamp=" + prjLogsGet.BeginTimestamp +
-		"&end_timestamp=" + prjLogsGet.EndTimestamp +
-		"&page=" + strconv.Itoa(prjLogsGet.Page) +
-		"&page_size=" + strconv.Itoa(prjLogsGet.PageSize)
-	fmt.Println("==> GET", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
+func GetPrjLogs(c *harbor.Client, opt *ProjectLogsGet) (*harbor.Result, error) {
+	if opt.Count {
+		opt.Page, opt.PageSize = 1, 1
+	}
+
+	if c.IsV2() {
+		return getPrjLogsV2(c, opt)
+	}
+
+	targetURL := c.URL("/api/projects") + "/" + strconv.Itoa(opt.ProjectID) +
+		"/logs?" + url.Values{
+		"username":        {opt.Username},
+		"repository":      {opt.Repository},
+		"tag":             {opt.Tag},
+		"operation":       {opt.Operation},
+		"begin_timestamp": {opt.BeginTimestamp},
+		"end_timestamp":   {opt.EndTimestamp},
+		"page":            {strconv.Itoa(opt.Page)},
+		"page_size":       {strconv.Itoa(opt.PageSize)},
+	}.Encode()
+	if !opt.Count {
+		c.Trace("==> GET", targetURL)
+	}
+
+	if opt.All && !opt.Count {
+		return c.StreamAllPages(targetURL), nil
+	}
+
+	return c.DoStream(c.Get(targetURL))
+}
+
+// getPrjLogsV2 is GetPrjLogs for v2.0 API, where the logs of a project are
+// found by its name and filtered by a query.
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/projects/prj1/logs?q=username%3Dadmin%2Coperation%3Dpull&page=1&page_size=10'
+func getPrjLogsV2(c *harbor.Client, opt *ProjectLogsGet) (*harbor.Result, error) {
+	name, err := projectName(c, opt.ProjectID)
 	if err != nil {
-		fmt.Println("Error:", err)
-		return
+		return nil, err
+	}
+
+	targetURL := c.URL("/api/v2.0/projects") + "/" + url.PathEscape(name) + "/logs" +
+		"?q=" + url.QueryEscape(auditLogQuery(opt.Username, opt.Operation, opt.Repository, opt.Tag, opt.BeginTimestamp, opt.EndTimestamp)) +
+		"&page=" + strconv.Itoa(opt.Page) +
+		"&page_size=" + strconv.Itoa(opt.PageSize)
+	if !opt.Count {
+		c.Trace("==> GET", targetURL)
+	}
+
+	if opt.All && !opt.Count {
+		return c.StreamAllPages(targetURL), nil
 	}
 
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.DoStream(c.Get(targetURL))
 }
 
-type projectUpdate struct {
+// projectName returns the name of the project of ID id, v2.0 API refers to
+// projects by name in most paths.
+func projectName(c *harbor.Client, id int) (string, error) {
+	var prj struct {
+		Name string `json:"name"`
+	}
+	if err := c.GetJSON(c.APIURL("/projects")+"/"+strconv.Itoa(id), &prj); err != nil {
+		return "", err
+	}
+	return prj.Name, nil
+}
+
+// projectByName returns the project named name.
+func projectByName(c *harbor.Client, name string) (*model.Project, error) {
+	targetURL := c.APIURL("/projects") + "?name=" + url.QueryEscape(name)
+	c.Trace("==> GET", targetURL)
+
+	var prjs []*model.Project
+	if err := c.GetJSON(targetURL, &prjs); err != nil {
+		return nil, err
+	}
+	for _, prj := range prjs {
+		if prj.Name == name {
+			return prj, nil
+		}
+	}
+	return nil, fmt.Errorf("project %q not found", name)
+}
+
+// projectRepoNames returns the full names of the repositories of prj.
+func projectRepoNames(c *harbor.Client, prj *model.Project) ([]string, error) {
+	targetURL := c.URL("/api/v2.0/projects") + "/" + url.PathEscape(prj.Name) + "/repositories"
+	if !c.IsV2() {
+		targetURL = c.APIURL("/repositories") + "?project_id=" + strconv.FormatInt(prj.ProjectID, 10)
+	}
+	c.Trace("==> GET", targetURL)
+
+	var repos []string
+	err := c.EachPage(targetURL, func(item json.RawMessage) error {
+		var repo model.Repository
+		if err := json.Unmarshal(item, &repo); err != nil {
+			return err
+		}
+		repos = append(repos, repo.Name)
+		return nil
+	})
+	return repos, err
+}
+
+// ProjectUpdate holds the parameters of PutPrjUpdate.
+type ProjectUpdate struct {
 	ProjectID                                  int    `short:"j" long:"project_id" description:"(REQUIRED) Project ID of project which will be get." required:"yes" json:"-"`
 	ProjectName                                string `short:"n" long:"project_name" description:"The name of the project." json:"project_name"`
 	Public                                     int    `short:"k" long:"public" description:"The public status of the project, public(1) or private(0)." json:"public"`
 	EnablelontentTrust                         bool   `short:"t" long:"enable_content_trust" description:"Whether content trust is enabled or not. If it is enabled, user cann't pull unsigned images from this project." json:"enable_content_trust"`
 	PreventVulnerableImagesFromRunning         bool   `short:"r" long:"prevent_vulnerable_images_from_running" description:"Whether prevent the vulnerable images from running." json:"prevent_vulnerable_images_from_running"`
-	PreventVulnerableImagesFromRunningSeverity string `short:"s" long:"prevent_vulnerable_images_from_running_severity" description:"If the vulnerability is high than severity defined here, the images cann't be pulled." default:"" json:"prevent_vulnerable_images_from_running_severity"`
+	PreventVulnerableImagesFromRunningSeverity string `short:"s" long:"prevent_vulnerable_images_from_running_severity" description:"If the vulnerability is high than severity defined here, the images cann't be pulled: negligible, unknown, low, medium, high, or critical and none with v2.0 API." default:"" validate:"oneof=negligible|unknown|low|medium|high|critical|none" json:"prevent_vulnerable_images_from_running_severity"`
 	AutomaticallyScanImagesOnPush              bool   `short:"a" long:"automatically_scan_images_on_push" description:"Whether scan images automatically when pushing." json:"automatically_scan_images_on_push"`
+	RegistryID                                 int    `long:"registry_id" description:"Create a proxy cache project of this registry endpoint. (Harbor v2.1+, uses v2.0 API)" json:"registry_id,omitempty"`
+	ProxySpeedKB                               int    `long:"proxy_speed_kb" description:"Bandwidth limit of the proxy cache project pulling from upstream, in KB/s, -1 for unlimited. (Harbor v2.9+)" json:"proxy_speed_kb,omitempty"`
+
+	Metadata map[string]string `short:"m" long:"metadata" description:"Set another metadata of the project, e.g. -m reuse_sys_cve_allowlist:true, may be repeated. (v2.0 API)" json:"metadata,omitempty"`
+
+	// given holds the flags given on the command line, only their metadata
+	// are updated with v2.0 API. All are when nil.
+	given map[string]bool
 }
 
-var prjUpdate projectUpdate
+// projectUpdateV2 is the project update request of v2.0 API, only the
+// metadata sent are updated.
+type projectUpdateV2 struct {
+	Metadata map[string]string `json:"metadata"`
+}
 
-func (x *projectUpdate) Execute(args []string) error {
-	PutPrjUpdate(utils.URLGen("/api/projects"))
-	return nil
+func (x *ProjectUpdate) Execute(args []string) error {
+	x.given = utils.FlagsGiven()
+	return utils.PrintResult(PutPrjUpdate(utils.NewClient(), x))
 }
 
 // PutPrjUpdate is aimed to update the properties of a project.
@@ -630,9 +725,14 @@ func (x *projectUpdate) Execute(args []string) error {
 //   prevent_vulnerable_images_from_running          - Whether prevent the vulnerable images from running.
 //   prevent_vulnerable_images_from_running_severity - If the vulnerability is high than severity defined here, the images cann't be pulled.
 //   automatically_scan_images_on_push               - Whether scan images automatically when pushing.
+//   metadata             - Other metadata of the project (v2.0 API).
+//
+// With v2.0 API, the properties are sent as metadata, those given on the
+// command line only.
 //
 // format:
 //    PUT /projects/{project_id}
+//    PUT /api/v2.0/projects/{project_id}  {"metadata": {"auto_scan": "true"}}
 //
 // e.g.
 /*
@@ -645,69 +745,137 @@ curl -X PUT --header 'Content-Type: application/json' --header 'Accept: text/pla
      "automatically_scan_images_on_push": false \
  }' 'https://localhost/api/projects/92'
 */
-func PutPrjUpdate(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(prjUpdate.ProjectID)
-	fmt.Println("==> PUT", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
+func PutPrjUpdate(c *harbor.Client, opt *ProjectUpdate) (*harbor.Result, error) {
+	targetURL := c.APIURL("/projects") + "/" + strconv.Itoa(opt.ProjectID)
+	c.Trace("==> PUT", targetURL)
+
+	// v2.0 API updates the metadata sent only, and cannot rename a project.
+	var body interface{} = opt
+	if c.IsV2() {
+		if opt.ProjectName != "" {
+			return nil, fmt.Errorf("--project_name: a project cannot be renamed with v2.0 API")
+		}
+		v2 := opt.v2()
+		if len(v2.Metadata) == 0 {
+			return nil, fmt.Errorf("nothing to update, give the properties or --metadata to change")
+		}
+		body = v2
 	}
 
-	p, err := json.Marshal(&prjUpdate)
+	p, err := json.Marshal(body)
 	if err != nil {
-		fmt.Println("error:", err)
-		return
+		return nil, err
 	}
-	fmt.Println("==> project update:", string(p))
+	c.Trace("==> project update:", string(p))
 
-	utils.Request.Put(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		Send(string(p)).
-		End(utils.PrintStatus)
+	return c.Do(c.Put(targetURL).
+		Send(string(p)))
 }
 
-type projectCreate struct {
-	ProjectName                                string `short:"n" long:"project_name" description:"(REQUIRED) The name of the project." required:"yes" json:"project_name"`
-	Public                                     int    `short:"k" long:"public" description:"(REQUIRED) The public status of the project, public(1) or private(0)." required:"yes" json:"public"`
+// ProjectCreate holds the parameters of PostPrjCreate.
+type ProjectCreate struct {
+	ProjectName                                string `short:"n" long:"project_name" description:"(REQUIRED) The name of the project." validate:"required" json:"project_name"`
+	Public                                     int    `short:"k" long:"public" description:"The public status of the project, public(1) or private(0)." default:"0" json:"public"`
 	EnablelontentTrust                         bool   `short:"t" long:"enable_content_trust" description:"Whether content trust is enabled or not. If it is enabled, user cann't pull unsigned images from this project." json:"enable_content_trust"`
 	PreventVulnerableImagesFromRunning         bool   `short:"r" long:"prevent_vulnerable_images_from_running" description:"Whether prevent the vulnerable images from running." json:"prevent_vulnerable_images_from_running"`
-	PreventVulnerableImagesFromRunningSeverity string `short:"s" long:"prevent_vulnerable_images_from_running_severity" description:"If the vulnerability is high than severity defined here, the images cann't be pulled." default:"" json:"prevent_vulnerable_images_from_running_severity"`
+	PreventVulnerableImagesFromRunningSeverity string `short:"s" long:"prevent_vulnerable_images_from_running_severity" description:"If the vulnerability is high than severity defined here, the images cann't be pulled: negligible, unknown, low, medium, high, or critical and none with v2.0 API." default:"" validate:"oneof=negligible|unknown|low|medium|high|critical|none" json:"prevent_vulnerable_images_from_running_severity"`
 	AutomaticallyScanImagesOnPush              bool   `short:"a" long:"automatically_scan_images_on_push" description:"Whether scan images automatically when pushing." json:"automatically_scan_images_on_push"`
+	RegistryID                                 int    `long:"registry_id" description:"Create a proxy cache project of this registry endpoint. (Harbor v2.1+, uses v2.0 API)" json:"registry_id,omitempty"`
+	ProxySpeedKB                               int    `long:"proxy_speed_kb" description:"Bandwidth limit of the proxy cache project pulling from upstream, in KB/s, -1 for unlimited. (Harbor v2.9+)" json:"proxy_speed_kb,omitempty"`
+	File                                       string `short:"f" long:"file" description:"Read the request from a JSON or YAML spec file (or @file, - for stdin) instead of flags." spec:"file" json:"-"`
+
+	Metadata map[string]string `short:"m" long:"metadata" description:"Set another metadata of the project, e.g. -m reuse_sys_cve_allowlist:true, may be repeated. (v2.0 API)" json:"metadata,omitempty"`
 }
 
-var prjCreate projectCreate
+// projectCreateV2 is the project creation request of v2.0 API, where the
+// project properties are given as metadata strings.
+type projectCreateV2 struct {
+	ProjectName string            `json:"project_name"`
+	RegistryID  int               `json:"registry_id,omitempty"`
+	Metadata    map[string]string `json:"metadata"`
+}
 
-func (x *projectCreate) Execute(args []string) error {
-	PostPrjCreate(utils.URLGen("/api/projects"))
-	return nil
+// v2 converts the request for v2.0 API.
+func (x *ProjectCreate) v2() *projectCreateV2 {
+	return &projectCreateV2{
+		ProjectName: x.ProjectName,
+		RegistryID:  x.RegistryID,
+		Metadata: projectMetadata(x.Public, x.EnablelontentTrust, x.PreventVulnerableImagesFromRunning,
+			x.PreventVulnerableImagesFromRunningSeverity, x.AutomaticallyScanImagesOnPush, x.ProxySpeedKB, x.Metadata, nil),
+	}
 }
 
-type projectGet struct {
-	ProjectID int `short:"j" long:"project_id" description:"(REQUIRED) Project ID of project which will be get." required:"yes"`
+// v2 converts the request for v2.0 API.
+func (x *ProjectUpdate) v2() *projectUpdateV2 {
+	return &projectUpdateV2{
+		Metadata: projectMetadata(x.Public, x.EnablelontentTrust, x.PreventVulnerableImagesFromRunning,
+			x.PreventVulnerableImagesFromRunningSeverity, x.AutomaticallyScanImagesOnPush, x.ProxySpeedKB, x.Metadata, x.given),
+	}
 }
 
-var prjGet projectGet
+// projectMetadata returns the project properties as metadata strings of
+// v2.0 API, merged with extra. Only the properties whose flag is in given
+// are returned, or all of them when given is nil, but an empty severity and
+// a zero bandwidth limit.
+func projectMetadata(public int, contentTrust, preventVul bool, severity string, autoScan bool, proxySpeedKB int, extra map[string]string, given map[string]bool) map[string]string {
+	props := []struct {
+		flag, key, value string
+		unset            bool
+	}{
+		{"public", "public", strconv.FormatBool(public == 1), false},
+		{"enable_content_trust", "enable_content_trust", strconv.FormatBool(contentTrust), false},
+		{"prevent_vulnerable_images_from_running", "prevent_vul", strconv.FormatBool(preventVul), false},
+		{"prevent_vulnerable_images_from_running_severity", "severity", severity, severity == ""},
+		{"automatically_scan_images_on_push", "auto_scan", strconv.FormatBool(autoScan), false},
+		{"proxy_speed_kb", "proxy_speed_kb", strconv.Itoa(proxySpeedKB), proxySpeedKB == 0},
+	}
 
-func (x *projectGet) Execute(args []string) error {
-	GetPrjByPrjID(utils.URLGen("/api/projects"))
-	return nil
+	md := map[string]string{}
+	for _, p := range props {
+		if given != nil && !given[p.flag] || given == nil && p.unset {
+			continue
+		}
+		md[p.key] = p.value
+	}
+	for k, v := range extra {
+		md[k] = v
+	}
+	return md
+}
+
+func (x *ProjectCreate) Execute(args []string) error {
+	return utils.PrintResult(PostPrjCreate(utils.NewClient(), x))
 }
 
-type projectDel struct {
+// ProjectGet holds the parameters of GetPrjByPrjID.
+type ProjectGet struct {
+	ProjectID int `short:"j" long:"project_id" description:"(REQUIRED) Project ID of project which will be get." required:"yes"`
+}
+
+func (x *ProjectGet) Execute(args []string) error {
+	return utils.PrintResult(GetPrjByPrjID(utils.NewClient(), x))
+}
+
+// ProjectDel holds the parameters of DelPrjByPrjID.
+type ProjectDel struct {
 	ProjectID int `short:"j" long:"project_id" description:"(REQUIRED) Project ID of project which will be deleted." required:"yes"`
 }
 
-var prjDel projectDel
+// ProjectSummaryGet holds the parameters of GetPrjSummary.
+type ProjectSummaryGet struct {
+	Project string `short:"n" long:"project" description:"(REQUIRED) Name or ID of the project." required:"yes"`
+}
+
+func (x *ProjectSummaryGet) Execute(args []string) error {
+	return utils.PrintResult(GetPrjSummary(utils.NewClient(), x))
+}
 
-func (x *projectDel) Execute(args []string) error {
-	DelPrjByPrjID(utils.URLGen("/api/projects"))
-	return nil
+func (x *ProjectDel) Execute(args []string) error {
+	return utils.PrintResult(DelPrjByPrjID(utils.NewClient(), x))
 }
 
-type projectsList struct {
+// ProjectsList holds the parameters of GetPrjsList.
+type ProjectsList struct {
 	Name string `short:"n" long:"name" description:"The name of the project (for filtering)." default:""`
 	// NOTE:
 	// 这里将 public 的类型从 bool 变更为 string ，因为bool 类型只有 true 和 false 二值语义，而实际使用中需要第三种语义
@@ -719,14 +887,13 @@ type projectsList struct {
 	// harbor 中基于 owner 过滤的功能似乎存在问题；
 	Owner    string `short:"o" long:"owner" description:"The name of project owner." default:""`
 	Page     int    `short:"p" long:"page" description:"The page nubmer, default is 1." default:"1"`
-	PageSize int    `short:"s" long:"page_size" description:"The size of per page, default is 10, maximum is 100." default:"10"`
+	PageSize int    `short:"s" long:"page_size" description:"The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs." default:"10" validate:"pagesize"`
+	Count    bool   `long:"count" description:"Print the total number of matched items only."`
+	All      bool   `long:"all" description:"Fetch all pages, --page and --page_size are ignored."`
 }
 
-var prjsList projectsList
-
-func (x *projectsList) Execute(args []string) error {
-	GetPrjsList(utils.URLGen("/api/projects"))
-	return nil
+func (x *ProjectsList) Execute(args []string) error {
+	return utils.ListPrinter(x.Count)(GetPrjsList(utils.NewClient(), x))
 }
 
 // PostPrjCreate is for user to create a new project.
@@ -738,6 +905,9 @@ func (x *projectsList) Execute(args []string) error {
 //  prevent_vulnerable_images_from_running - Whether prevent the vulnerable images from running.
 //  prevent_vulnerable_images_from_running_severity - If the vulnerability is high than severity defined here, the images cann't be pulled.
 //  automatically_scan_images_on_push - Whether scan images automatically when pushing.
+//  registry_id - The registry endpoint of a proxy cache project, v2.0 API is used when set.
+//  proxy_speed_kb - Bandwidth limit of a proxy cache project in KB/s.
+//  metadata - Other metadata of the project, e.g. reuse_sys_cve_allowlist (v2.0 API).
 //
 // e.g.
 /*
@@ -750,28 +920,33 @@ curl -X POST --header 'Content-Type: text/plain' --header 'Accept: text/plain' -
   "automatically_scan_images_on_push": false
 }' 'https://localhost/api/projects'
 */
-func PostPrjCreate(baseURL string) {
-	targetURL := baseURL
-	fmt.Println("==> POST", targetURL)
+func PostPrjCreate(c *harbor.Client, opt *ProjectCreate) (*harbor.Result, error) {
+	if opt.File != "" {
+		if err := utils.SpecLoad(opt.File, opt); err != nil {
+			return nil, err
+		}
+	}
+	if err := utils.Validate(opt); err != nil {
+		return nil, err
+	}
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
+	// Proxy cache projects are only known to v2.0 API.
+	targetURL := c.URL("/api/projects")
+	var body interface{} = opt
+	if opt.RegistryID != 0 || c.IsV2() {
+		targetURL = c.URL("/api/v2.0/projects")
+		body = opt.v2()
 	}
+	c.Trace("==> POST", targetURL)
 
-	p, err := json.Marshal(&prjCreate)
+	p, err := json.Marshal(body)
 	if err != nil {
-		fmt.Println("error:", err)
-		return
+		return nil, err
 	}
-	fmt.Println("==> prject create:", string(p))
+	c.Trace("==> prject create:", string(p))
 
-	utils.Request.Post(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		Send(string(p)).
-		End(utils.PrintStatus)
+	return c.Do(c.Post(targetURL).
+		Send(string(p)))
 }
 
 // GetPrjByPrjID returns specific project information by project ID.
@@ -780,20 +955,92 @@ func PostPrjCreate(baseURL string) {
 //  project_id - (REQUIRED) Project ID of project which will be get.
 //
 // e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/projects/100'
-func GetPrjByPrjID(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(prjGet.ProjectID)
-	fmt.Println("==> GET", targetURL)
+func GetPrjByPrjID(c *harbor.Client, opt *ProjectGet) (*harbor.Result, error) {
+	targetURL := c.APIURL("/projects") + "/" + strconv.Itoa(opt.ProjectID)
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
+}
+
+// GetPrjSummary returns the summary of a project, including the upstream
+// registry of a proxy cache project.
+//
+// params:
+//  project - (REQUIRED) Name or ID of the project.
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/projects/dockerhub-proxy/summary'
+func GetPrjSummary(c *harbor.Client, opt *ProjectSummaryGet) (*harbor.Result, error) {
+	targetURL := c.URL("/api/v2.0/projects") + "/" + url.PathEscape(opt.Project) + "/summary"
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL).
+		Set("X-Is-Resource-Name", strconv.FormatBool(!isNumeric(opt.Project))))
+}
+
+// ProjectProxySpeedGet holds the parameters of GetPrjProxySpeed.
+type ProjectProxySpeedGet struct {
+	Project string `short:"n" long:"project" description:"(REQUIRED) Name or ID of the proxy cache project." required:"yes"`
+}
+
+func (x *ProjectProxySpeedGet) Execute(args []string) error {
+	return utils.PrintResult(GetPrjProxySpeed(utils.NewClient(), x))
+}
+
+// GetPrjProxySpeed returns the bandwidth limit of a proxy cache project.
+//
+// params:
+//  project - (REQUIRED) Name or ID of the proxy cache project.
+//
+// format:
+//  GET /projects/{project_name_or_id}/metadatas/proxy_speed_kb
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/projects/dockerhub-proxy/metadatas/proxy_speed_kb'
+func GetPrjProxySpeed(c *harbor.Client, opt *ProjectProxySpeedGet) (*harbor.Result, error) {
+	targetURL := c.URL("/api/v2.0/projects") + "/" + url.PathEscape(opt.Project) + "/metadatas/proxy_speed_kb"
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL).
+		Set("X-Is-Resource-Name", strconv.FormatBool(!isNumeric(opt.Project))))
+}
+
+// ProjectProxySpeedSet holds the parameters of PutPrjProxySpeed.
+type ProjectProxySpeedSet struct {
+	Project string `short:"n" long:"project" description:"(REQUIRED) Name or ID of the proxy cache project." required:"yes"`
+	SpeedKB int    `short:"k" long:"speed_kb" description:"(REQUIRED) The bandwidth limit in KB/s, -1 for unlimited." required:"yes" validate:"min=-1"`
+}
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
+func (x *ProjectProxySpeedSet) Execute(args []string) error {
+	return utils.PrintResult(PutPrjProxySpeed(utils.NewClient(), x))
+}
+
+// PutPrjProxySpeed sets the bandwidth limit of a proxy cache project.
+//
+// params:
+//  project  - (REQUIRED) Name or ID of the proxy cache project.
+//  speed_kb - (REQUIRED) The bandwidth limit in KB/s, -1 for unlimited.
+//
+// format:
+//  PUT /projects/{project_name_or_id}/metadatas/proxy_speed_kb
+//
+// e.g. curl -X PUT --header 'Content-Type: application/json' -d '{"proxy_speed_kb": "1024"}' 'https://localhost/api/v2.0/projects/dockerhub-proxy/metadatas/proxy_speed_kb'
+func PutPrjProxySpeed(c *harbor.Client, opt *ProjectProxySpeedSet) (*harbor.Result, error) {
+	targetURL := c.URL("/api/v2.0/projects") + "/" + url.PathEscape(opt.Project) + "/metadatas/proxy_speed_kb"
+	c.Trace("==> PUT", targetURL)
+
+	p, err := json.Marshal(map[string]string{"proxy_speed_kb": strconv.Itoa(opt.SpeedKB)})
 	if err != nil {
-		fmt.Println("Error:", err)
-		return
+		return nil, err
 	}
 
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Put(targetURL).
+		Set("X-Is-Resource-Name", strconv.FormatBool(!isNumeric(opt.Project))).
+		Send(string(p)))
+}
+
+// isNumeric tells whether s is an ID rather than a name.
+func isNumeric(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
 }
 
 // DelPrjByPrjID is aimed to delete project by project ID
@@ -802,20 +1049,11 @@ func GetPrjByPrjID(baseURL string) {
 //  project_id - (REQUIRED) Project ID of project which will be deleted.
 //
 // e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/projects/100'
-func DelPrjByPrjID(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(prjDel.ProjectID)
-	fmt.Println("==> DELETE", targetURL)
+func DelPrjByPrjID(c *harbor.Client, opt *ProjectDel) (*harbor.Result, error) {
+	targetURL := c.APIURL("/projects") + "/" + strconv.Itoa(opt.ProjectID)
+	c.Trace("==> DELETE", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
-
-	utils.Request.Delete(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Delete(targetURL))
 }
 
 // GetPrjsList returns all projects created by Harbor, and can be filtered by project name.
@@ -825,27 +1063,30 @@ func DelPrjByPrjID(baseURL string) {
 //  public - The project is public or private. default is "", return both public and private prjs.
 //  owner - The name of project owner.
 //  page - The page nubmer, default is 1.
-//  page_size - The size of per page, default is 10, maximum is 100.
+//  page_size - The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs.
 //
 // e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/projects?name=prj&public=true&owner=moooofly&page=1&page_size=10'
-func GetPrjsList(baseURL string) {
-	targetURL := baseURL + "?name=" + prjsList.Name +
-		"&public=" + prjsList.Public +
-		"&owner=" + prjsList.Owner +
-		"&page=" + strconv.Itoa(prjsList.Page) +
-		"&page_size=" + strconv.Itoa(prjsList.PageSize)
-	fmt.Println("==> GET", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
+func GetPrjsList(c *harbor.Client, opt *ProjectsList) (*harbor.Result, error) {
+	if opt.Count {
+		opt.Page, opt.PageSize = 1, 1
+	}
+
+	targetURL := c.APIURL("/projects") + "?" + url.Values{
+		"name":      {opt.Name},
+		"public":    {opt.Public},
+		"owner":     {opt.Owner},
+		"page":      {strconv.Itoa(opt.Page)},
+		"page_size": {strconv.Itoa(opt.PageSize)},
+	}.Encode()
+	if !opt.Count {
+		c.Trace("==> GET", targetURL)
+	}
+
+	if opt.All && !opt.Count {
+		return c.StreamAllPages(targetURL), nil
 	}
 
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		// TODO:
-		// 可以通过解析 Rsp Heaer 中的 X-Total-Count 直接得到返回的 projects 数量
-		End(utils.PrintStatus)
+	// TODO:
+	// 可以通过解析 Rsp Heaer 中的 X-Total-Count 直接得到返回的 projects 数量
+	return c.DoStream(c.Get(targetURL))
 }
@@ -1,9 +1,18 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
 	"github.com/moooofly/harbor-go-client/utils"
 )
 
@@ -11,18 +20,46 @@ func init() {
 	utils.Parser.AddCommand("replication_trigger_by_id",
 		"Trigger the replication according to the specified policy.",
 		"This endpoint is used to trigger a replication.",
-		&replTriByID)
+		&ReplicationTriByID{})
+	utils.Parser.AddCommand("replication_topology",
+		"Render replication targets and policies as a graph.",
+		"Render the configured replication targets and policies as a Graphviz (dot) or mermaid graph, so multi-registry flows can be reviewed in docs and PRs.",
+		&ReplicationTopology{})
+	utils.Parser.AddCommand("replication_start",
+		"Start a replication execution of a policy.",
+		"This endpoint starts an execution of a replication policy, --wait waits for it to end and exits with non-zero code unless it succeeded. (Harbor v1.8+)",
+		&ReplicationStart{})
+	utils.Parser.AddCommand("replication_executions_list",
+		"List replication executions.",
+		"This endpoint lists the replication executions, filtered by policy, status and trigger. (Harbor v1.8+)",
+		&ReplicationExecutionsList{})
+	utils.Parser.AddCommand("replication_execution_get",
+		"Get a replication execution.",
+		"This endpoint gets a replication execution by specific ID, --wait waits for it to end and exits with non-zero code unless it succeeded. (Harbor v1.8+)",
+		&ReplicationExecutionGet{})
+	utils.Parser.AddCommand("replication_tasks_list",
+		"List the tasks of a replication execution.",
+		"This endpoint lists the tasks of a replication execution, one per replicated resource, filtered by status and resource type. (Harbor v1.8+)",
+		&ReplicationTasksList{})
+	utils.Parser.AddCommand("replication_adapters",
+		"List the registry types supported for replication.",
+		"This endpoint lists the types of the remote registries the Harbor can replicate with, to be used as the type of a registry endpoint; --info gets the endpoint and credential patterns of every type too (Harbor v2.x). (Harbor v1.8+)",
+		&ReplicationAdapters{})
+	utils.RequireAdmin("replication_trigger_by_id", "replication_topology",
+		"replication_start", "replication_executions_list", "replication_execution_get", "replication_tasks_list",
+		"replication_adapters")
+	utils.ResponseModel("replication_executions_list", model.ReplicationExecution{})
+	utils.ResponseModel("replication_execution_get", model.ReplicationExecution{})
+	utils.ResponseModel("replication_tasks_list", model.ReplicationTask{})
 }
 
-type replicationTriByID struct {
+// ReplicationTriByID holds the parameters of PostReplTriByID.
+type ReplicationTriByID struct {
 	PolicyID int `short:"i" long:"policy_id" description:"(REQUIRED) The ID of replication policy" required:"yes" json:"policy_id"`
 }
 
-var replTriByID replicationTriByID
-
-func (x *replicationTriByID) Execute(args []string) error {
-	PostReplTriByID(utils.URLGen("/api/replications"))
-	return nil
+func (x *ReplicationTriByID) Execute(args []string) error {
+	return utils.PrintResult(PostReplTriByID(utils.NewClient(), x))
 }
 
 // PostReplTriByID is used to trigger a replication.
@@ -39,25 +76,485 @@ func (x *replicationTriByID) Execute(args []string) error {
      "policy_id": 1 \
    }' 'https://localhost/api/replications'
 */
-func PostReplTriByID(baseURL string) {
-	targetURL := baseURL
-	fmt.Println("==> POST", targetURL)
+func PostReplTriByID(c *harbor.Client, opt *ReplicationTriByID) (*harbor.Result, error) {
+	targetURL := c.URL("/api/replications")
+	c.Trace("==> POST", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
+	t, err := json.Marshal(opt)
 	if err != nil {
-		fmt.Println("Error:", err)
-		return
+		return nil, err
+	}
+
+	return c.Do(c.Post(targetURL).
+		Send(string(t)))
+}
+
+// ReplicationStart holds the parameters of PostReplicationExecution.
+type ReplicationStart struct {
+	PolicyID int64 `short:"i" long:"policy_id" description:"(REQUIRED) The ID of the replication policy." required:"yes"`
+	Wait     bool  `short:"w" long:"wait" description:"Wait for the execution to end, the exit code is non-zero unless it succeeded."`
+	Interval int   `long:"interval" description:"Polling interval in seconds with --wait." default:"5" validate:"min=1"`
+	Timeout  int   `long:"timeout" description:"Seconds to wait with --wait, 0 for no limit." default:"0"`
+}
+
+func (x *ReplicationStart) Execute(args []string) error {
+	if x.Wait && x.Interval <= 0 {
+		// Checked before the execution is started.
+		return fmt.Errorf("--interval must be positive")
+	}
+	c := utils.NewClient()
+	res, err := PostReplicationExecution(c, x)
+	if err != nil || !x.Wait {
+		return utils.PrintResult(res, err)
 	}
 
-	t, err := json.Marshal(&replTriByID)
+	id, err := replicationExecutionID(res)
 	if err != nil {
-		fmt.Println("error:", err)
-		return
+		return err
+	}
+	c.Status("replication execution %d started", id)
+	return utils.PrintResult(WaitReplicationExecution(c, id,
+		time.Duration(x.Interval)*time.Second, time.Duration(x.Timeout)*time.Second))
+}
+
+// PostReplicationExecution starts an execution of a replication policy, the
+// URL of the execution is in the Location header of the response.
+//
+// params:
+//   policy_id - (REQUIRED) The ID of the replication policy.
+//
+// format:
+//   POST /replication/executions
+//
+// e.g.
+/*
+  curl -X POST --header 'Content-Type: application/json' --header 'Accept: application/json' -d '{ \
+     "policy_id": 1 \
+   }' 'https://localhost/api/v2.0/replication/executions'
+*/
+func PostReplicationExecution(c *harbor.Client, opt *ReplicationStart) (*harbor.Result, error) {
+	targetURL := c.APIURL("/replication/executions")
+	c.Trace("==> POST", targetURL)
+
+	return c.Do(c.Post(targetURL).
+		Send(map[string]int64{"policy_id": opt.PolicyID}))
+}
+
+// replicationExecutionID returns the ID of the execution started, from the
+// Location header of the response of PostReplicationExecution.
+func replicationExecutionID(res *harbor.Result) (int64, error) {
+	loc := res.Header.Get("Location")
+	id, err := strconv.ParseInt(path.Base(loc), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("no execution ID in the Location header %q of the response", loc)
+	}
+	return id, nil
+}
+
+// ReplicationExecutionsList holds the parameters of GetReplicationExecutions.
+type ReplicationExecutionsList struct {
+	PolicyID int64  `short:"i" long:"policy_id" description:"The ID of the replication policy of the executions."`
+	Status   string `long:"status" description:"The status of the executions, e.g. InProgress, Succeed, Failed, Stopped."`
+	Trigger  string `long:"trigger" description:"The trigger of the executions, e.g. manual, scheduled, event_based."`
+	Page     int    `short:"p" long:"page" description:"The page nubmer, default is 1." default:"1"`
+	PageSize int    `short:"s" long:"page_size" description:"The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs." default:"10" validate:"pagesize"`
+	Count    bool   `long:"count" description:"Print the total number of matched items only."`
+	All      bool   `long:"all" description:"Fetch all pages, --page and --page_size are ignored."`
+}
+
+func (x *ReplicationExecutionsList) Execute(args []string) error {
+	return utils.ListPrinter(x.Count)(GetReplicationExecutions(utils.NewClient(), x))
+}
+
+// GetReplicationExecutions lists the replication executions.
+//
+// params:
+//   policy_id - The ID of the replication policy of the executions.
+//   status - The status of the executions.
+//   trigger - The trigger of the executions.
+//   page - The page nubmer, default is 1.
+//   page_size - The size of per page, default is 10.
+//
+// format:
+//   GET /replication/executions
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/replication/executions?policy_id=1&status=Failed&page=1&page_size=10'
+//
+func GetReplicationExecutions(c *harbor.Client, opt *ReplicationExecutionsList) (*harbor.Result, error) {
+	if opt.Count {
+		opt.Page, opt.PageSize = 1, 1
+	}
+
+	q := url.Values{}
+	if opt.PolicyID != 0 {
+		q.Set("policy_id", strconv.FormatInt(opt.PolicyID, 10))
+	}
+	if opt.Status != "" {
+		q.Set("status", opt.Status)
+	}
+	if opt.Trigger != "" {
+		q.Set("trigger", opt.Trigger)
+	}
+	q.Set("page", strconv.Itoa(opt.Page))
+	q.Set("page_size", strconv.Itoa(opt.PageSize))
+	targetURL := c.APIURL("/replication/executions") + "?" + q.Encode()
+
+	if !opt.Count {
+		c.Trace("==> GET", targetURL)
+	}
+
+	if opt.All && !opt.Count {
+		return c.StreamAllPages(targetURL), nil
+	}
+
+	return c.DoStream(c.Get(targetURL))
+}
+
+// ReplicationExecutionGet holds the parameters of GetReplicationExecution.
+type ReplicationExecutionGet struct {
+	ID       int64 `short:"e" long:"execution_id" description:"(REQUIRED) The ID of the replication execution." required:"yes"`
+	Wait     bool  `short:"w" long:"wait" description:"Wait for the execution to end, the exit code is non-zero unless it succeeded."`
+	Interval int   `long:"interval" description:"Polling interval in seconds with --wait." default:"5" validate:"min=1"`
+	Timeout  int   `long:"timeout" description:"Seconds to wait with --wait, 0 for no limit." default:"0"`
+}
+
+func (x *ReplicationExecutionGet) Execute(args []string) error {
+	c := utils.NewClient()
+	if x.Wait {
+		return utils.PrintResult(WaitReplicationExecution(c, x.ID,
+			time.Duration(x.Interval)*time.Second, time.Duration(x.Timeout)*time.Second))
+	}
+	return utils.PrintResult(GetReplicationExecution(c, x.ID))
+}
+
+// GetReplicationExecution gets a replication execution.
+//
+// params:
+//   id - (REQUIRED) The ID of the replication execution.
+//
+// format:
+//   GET /replication/executions/{id}
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/replication/executions/1'
+//
+func GetReplicationExecution(c *harbor.Client, id int64) (*harbor.Result, error) {
+	targetURL := c.APIURL("/replication/executions") + "/" + strconv.FormatInt(id, 10)
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
+}
+
+// replicationEnded maps the terminal statuses of replication executions to
+// whether the execution succeeded. Harbor v2.2+ reports the statuses of its
+// task manager, older versions their own.
+var replicationEnded = map[string]bool{
+	"Succeed": true,
+	"Success": true,
+	"Failed":  false,
+	"Error":   false,
+	"Stopped": false,
+}
+
+// WaitReplicationExecution polls the replication execution id every
+// interval until it ends, or until timeout if not 0, reporting its status
+// changes to the progress callback. It returns the last response, with an error unless the
+// execution succeeded.
+func WaitReplicationExecution(c *harbor.Client, id int64, interval, timeout time.Duration) (*harbor.Result, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("the polling interval must be positive")
+	}
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		deadline = time.After(timeout)
+	}
+
+	last := ""
+	for {
+		res, err := GetReplicationExecution(c, id)
+		if err != nil {
+			return res, err
+		}
+		var e model.ReplicationExecution
+		if err := json.Unmarshal(res.Body, &e); err != nil {
+			return res, err
+		}
+
+		progress := fmt.Sprintf("%s (%d/%d succeeded, %d failed, %d stopped)", e.Status, e.Succeed, e.Total, e.Failed, e.Stopped)
+		if progress != last {
+			c.Status("replication execution %d: %s", id, progress)
+			last = progress
+		}
+		if ok, ended := replicationEnded[e.Status]; ended {
+			if !ok {
+				return res, fmt.Errorf("replication execution %d ended with status %s", id, e.Status)
+			}
+			return res, nil
+		}
+
+		select {
+		case <-c.Context().Done():
+			return res, c.Context().Err()
+		case <-deadline:
+			return res, fmt.Errorf("replication execution %d not ended in %s", id, timeout)
+		case <-time.After(interval):
+		}
+	}
+}
+
+// ReplicationTasksList holds the parameters of GetReplicationTasks.
+type ReplicationTasksList struct {
+	ID           int64  `short:"e" long:"execution_id" description:"(REQUIRED) The ID of the replication execution." required:"yes"`
+	Status       string `long:"status" description:"The status of the tasks, e.g. InProgress, Succeed, Failed, Stopped."`
+	ResourceType string `long:"resource_type" description:"The type of the replicated resources, e.g. image, chart, artifact."`
+	Page         int    `short:"p" long:"page" description:"The page nubmer, default is 1." default:"1"`
+	PageSize     int    `short:"s" long:"page_size" description:"The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs." default:"10" validate:"pagesize"`
+	Count        bool   `long:"count" description:"Print the total number of matched items only."`
+	All          bool   `long:"all" description:"Fetch all pages, --page and --page_size are ignored."`
+}
+
+func (x *ReplicationTasksList) Execute(args []string) error {
+	return utils.ListPrinter(x.Count)(GetReplicationTasks(utils.NewClient(), x))
+}
+
+// GetReplicationTasks lists the tasks of a replication execution.
+//
+// params:
+//   id - (REQUIRED) The ID of the replication execution.
+//   status - The status of the tasks.
+//   resource_type - The type of the replicated resources.
+//   page - The page nubmer, default is 1.
+//   page_size - The size of per page, default is 10.
+//
+// format:
+//   GET /replication/executions/{id}/tasks
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/replication/executions/1/tasks?status=Failed&page=1&page_size=10'
+//
+func GetReplicationTasks(c *harbor.Client, opt *ReplicationTasksList) (*harbor.Result, error) {
+	if opt.Count {
+		opt.Page, opt.PageSize = 1, 1
+	}
+
+	q := url.Values{}
+	if opt.Status != "" {
+		q.Set("status", opt.Status)
+	}
+	if opt.ResourceType != "" {
+		q.Set("resource_type", opt.ResourceType)
+	}
+	q.Set("page", strconv.Itoa(opt.Page))
+	q.Set("page_size", strconv.Itoa(opt.PageSize))
+	targetURL := c.APIURL("/replication/executions") + "/" + strconv.FormatInt(opt.ID, 10) + "/tasks?" + q.Encode()
+
+	if !opt.Count {
+		c.Trace("==> GET", targetURL)
+	}
+
+	if opt.All && !opt.Count {
+		return c.StreamAllPages(targetURL), nil
+	}
+
+	return c.DoStream(c.Get(targetURL))
+}
+
+// ReplicationAdapters holds the parameters of GetReplicationAdapters.
+type ReplicationAdapters struct {
+	Info bool `long:"info" description:"Get the endpoint and credential patterns of every registry type. (v2.0 API)"`
+}
+
+func (x *ReplicationAdapters) Execute(args []string) error {
+	return utils.PrintResult(GetReplicationAdapters(utils.NewClient(), x))
+}
+
+// GetReplicationAdapters lists the registry types supported for
+// replication, or their details with info.
+//
+// params:
+//   info - Get the endpoint and credential patterns of every registry type.
+//
+// format:
+//   GET /replication/adapters
+//   GET /replication/adapterinfos
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/replication/adapterinfos'
+//
+func GetReplicationAdapters(c *harbor.Client, opt *ReplicationAdapters) (*harbor.Result, error) {
+	targetURL := c.APIURL("/replication/adapters")
+	if opt.Info {
+		if !c.IsV2() {
+			return nil, errors.New("--info is not supported by v1 API")
+		}
+		targetURL = c.APIURL("/replication/adapterinfos")
+	}
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
+}
+
+// ReplicationTopology holds the parameters of GetReplTopology.
+type ReplicationTopology struct {
+	Output string `short:"o" long:"output" description:"The graph format, valid values are 'dot' and 'mermaid'." default:"dot"`
+}
+
+func (x *ReplicationTopology) Execute(args []string) error {
+	graph, err := GetReplTopology(utils.NewClient(), x)
+	if err != nil {
+		return err
+	}
+	fmt.Print(graph)
+	return nil
+}
+
+type topoProject struct {
+	ProjectID int    `json:"project_id"`
+	Name      string `json:"name"`
+}
+
+type topoTarget struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Endpoint string `json:"endpoint"`
+}
+
+type topoPolicy struct {
+	ID       int            `json:"id"`
+	Name     string         `json:"name"`
+	Enabled  bool           `json:"enabled"`
+	Projects []*topoProject `json:"projects"`
+	Targets  []*topoTarget  `json:"targets"`
+	Trigger  *struct {
+		Kind string `json:"kind"`
+	} `json:"trigger"`
+}
+
+// GetReplTopology renders replication targets and policies as a graph.
+//
+// Every project is a node of the local Harbor, every target is a node of
+// a remote registry, and every policy is an edge from project to target.
+//
+// format:
+//   GET /targets
+//   GET /policies/replication
+func GetReplTopology(c *harbor.Client, opt *ReplicationTopology) (string, error) {
+	if opt.Output != "dot" && opt.Output != "mermaid" {
+		return "", errors.New("output must be one of [dot|mermaid]")
+	}
+
+	var targets []*topoTarget
+	var policies []*topoPolicy
+
+	for _, q := range []struct {
+		uri string
+		v   interface{}
+	}{
+		{"/targets", &targets},
+		{"/policies/replication?page=1&page_size=100", &policies},
+	} {
+		res, err := c.Do(c.Get("/api" + q.uri))
+		if err != nil {
+			return "", err
+		}
+		if err := json.Unmarshal(res.Body, q.v); err != nil {
+			return "", err
+		}
+	}
+
+	if opt.Output == "dot" {
+		return topologyDot(c.BaseURL(), targets, policies), nil
+	}
+	return topologyMermaid(c.BaseURL(), targets, policies), nil
+}
+
+// topologyLabel returns the edge label of a policy.
+func topologyLabel(p *topoPolicy) string {
+	label := p.Name
+	if p.Trigger != nil && p.Trigger.Kind != "" {
+		label += " (" + p.Trigger.Kind + ")"
+	}
+	if !p.Enabled {
+		label += " [disabled]"
+	}
+	return label
+}
+
+func topologyDot(harborURL string, targets []*topoTarget, policies []*topoPolicy) string {
+	var b bytes.Buffer
+
+	b.WriteString("digraph replication {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  subgraph cluster_harbor {\n")
+	b.WriteString("    label=" + strconv.Quote(harborURL) + ";\n")
+	projects := make(map[int]bool)
+	for _, p := range policies {
+		for _, prj := range p.Projects {
+			if projects[prj.ProjectID] {
+				continue
+			}
+			projects[prj.ProjectID] = true
+			fmt.Fprintf(&b, "    project_%d [shape=folder, label=%s];\n", prj.ProjectID, strconv.Quote(prj.Name))
+		}
+	}
+	b.WriteString("  }\n")
+
+	for _, t := range targets {
+		fmt.Fprintf(&b, "  target_%d [shape=box3d, label=%s];\n", t.ID, strconv.Quote(t.Name+"\n"+t.Endpoint))
+	}
+
+	for _, p := range policies {
+		style := "solid"
+		if !p.Enabled {
+			style = "dashed"
+		}
+		for _, prj := range p.Projects {
+			for _, t := range p.Targets {
+				fmt.Fprintf(&b, "  project_%d -> target_%d [label=%s, style=%s];\n",
+					prj.ProjectID, t.ID, strconv.Quote(topologyLabel(p)), style)
+			}
+		}
+	}
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// mermaidEscape makes a string safe to be used in a quoted mermaid label.
+func mermaidEscape(s string) string {
+	return strings.Replace(s, "\"", "#quot;", -1)
+}
+
+func topologyMermaid(harborURL string, targets []*topoTarget, policies []*topoPolicy) string {
+	var b bytes.Buffer
+
+	b.WriteString("graph LR\n")
+	b.WriteString("  subgraph harbor[\"" + mermaidEscape(harborURL) + "\"]\n")
+	projects := make(map[int]bool)
+	for _, p := range policies {
+		for _, prj := range p.Projects {
+			if projects[prj.ProjectID] {
+				continue
+			}
+			projects[prj.ProjectID] = true
+			fmt.Fprintf(&b, "    project_%d[\"%s\"]\n", prj.ProjectID, mermaidEscape(prj.Name))
+		}
+	}
+	b.WriteString("  end\n")
+
+	for _, t := range targets {
+		fmt.Fprintf(&b, "  target_%d[(\"%s<br/>%s\")]\n", t.ID, mermaidEscape(t.Name), mermaidEscape(t.Endpoint))
+	}
+
+	for _, p := range policies {
+		arrow := "-->"
+		if !p.Enabled {
+			arrow = "-.->"
+		}
+		for _, prj := range p.Projects {
+			for _, t := range p.Targets {
+				fmt.Fprintf(&b, "  project_%d %s|\"%s\"| target_%d\n",
+					prj.ProjectID, arrow, mermaidEscape(topologyLabel(p)), t.ID)
+			}
+		}
 	}
 
-	utils.Request.Post(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		Send(string(t)).
-		End(utils.PrintStatus)
+	return b.String()
 }
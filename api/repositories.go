@@ -2,10 +2,17 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"path"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
 	"github.com/moooofly/harbor-go-client/utils"
 )
 
@@ -13,70 +20,81 @@ func init() {
 	utils.Parser.AddCommand("repo_signature_get",
 		"Get signature information of a repository from notary instance.",
 		"This endpoint aims to retrieve signature information of a repository, the data is from the nested notary instance of Harbor. If the repository does not have any signature information in notary, this API will return an empty list with response code 200, instead of 404",
-		&repoSignatureGet)
+		&RepositorySignatureGet{})
 	utils.Parser.AddCommand("repo_image_vul_details_get",
-		"Get vulnerability details of the image. (not support yet)",
-		"Call Clair API to get the vulnerability based on the previous successful scan.",
-		&repoImageVulDetailsGet)
+		"Get vulnerability details of the image.",
+		"Call Clair API to get the vulnerability based on the previous successful scan. Reports are cached locally by digest and scan, so repeated calls do not download them again.",
+		&RepositoryImageVulDetailsGet{})
 	utils.Parser.AddCommand("repo_image_scan",
 		"Scan the image. (not support yet)",
 		"Trigger jobservice to call Clair API to scan the image identified by the repo_name and tag. Only project admins have permission to scan images under the project.",
-		&repoImageScan)
+		&repositoryImageScan{})
 	utils.Parser.AddCommand("repo_image_manifests_get",
 		"Get manifests of a relevant repository.",
 		"This endpoint aims to retrieve manifests from a relevant repository.",
-		&repoImageManifestsGet)
+		&RepositoryImageManifestsGet{})
 	utils.Parser.AddCommand("repo_image_label_del",
 		"Delete label from the image under specific repository.",
 		"This endpoint deletes the label from the image specified by the repo_name and tag.",
-		&repoImageLabelDel)
+		&RepositoryImageLabelDel{})
 	utils.Parser.AddCommand("repo_image_label_add",
 		"Add a label to the image under specific repository.",
 		"This endpoint adds a label to the image under specific repository.",
-		&repoImageLabelAdd)
+		&RepositoryImageLabelAdd{})
 	utils.Parser.AddCommand("repo_image_labels_get",
 		"Get labels of an image under specific repository.",
 		"This endpoint gets labels of an image under specific repository specified by the repo_name and tag.",
-		&repoImageLabelsGet)
+		&RepositoryImageLabelsGet{})
 	utils.Parser.AddCommand("repo_label_del",
 		"Delete a label from the repository.",
 		"This endpoint deletes the label from the repository specified by the repo_name.",
-		&repoLabelDel)
+		&RepositoryLabelDel{})
 	utils.Parser.AddCommand("repo_label_add",
 		"Add a label to the repository.",
 		"This endpoint adds an already existing label (global or project specific) to the repository.",
-		&repoLabelAdd)
+		&RepositoryLabelAdd{})
 	utils.Parser.AddCommand("repo_labels_get",
 		"Get labels of a repository.",
 		"This endpoint gets labels of a repository specified by the repo_name. NOTE: This API gets '401 Unauthorized' all the time, even when logging in as admin user.",
-		&repoLabelsGet)
+		&RepositoryLabelsGet{})
 	utils.Parser.AddCommand("repo_desp_update",
 		"Update description of the repository.",
 		"This endpoint is used to update description of the repository.",
-		&repoUpdate)
+		&RepoDescriptionUpdate{})
+	utils.Parser.AddCommand("repo_describe",
+		"Set the description of a repository in a project.",
+		"This endpoint is used to update description of the repository, given as project and repository name.",
+		&RepoDescribe{})
 	utils.Parser.AddCommand("repo_del",
 		"Delete a repository by repo_name.",
-		"This endpoint let user delete a repository by repo_name.",
-		&repoDel)
+		"This endpoint let user delete a repository by repo_name, or the repositories of a project matching a name pattern and a label with --project_id.",
+		&RepositoryDel{})
 	utils.Parser.AddCommand("repos_list",
 		"Get repositories accompany with relevant project and repo name.",
 		"This endpoint let user search repositories accompanying with relevant project ID and repo name.",
-		&reposList)
+		&RepositoriesList{})
 	utils.Parser.AddCommand("repos_top",
 		"Get public repositories which are accessed most.",
 		"This endpoint aims to let users see the most popular public repositories",
-		&reposTop)
+		&RepositoriesTop{})
+
+	utils.RequireComponent("repo_signature_get", utils.ComponentNotary)
+	utils.RequireComponent("repo_image_vul_details_get", utils.ComponentScanner)
+	utils.RequireComponent("repo_image_scan", utils.ComponentScanner)
+
+	utils.ResponseModel("repo_signature_get", model.RepoSignature{})
+	utils.ResponseModel("repo_image_labels_get", model.Label{})
+	utils.ResponseModel("repo_labels_get", model.Label{})
+	utils.ResponseModel("repos_list", model.Repository{})
 }
 
-type repositorySignatureGet struct {
+// RepositorySignatureGet holds the parameters of GetRepoSignature.
+type RepositorySignatureGet struct {
 	RepoName string `short:"n" long:"repo_name" description:"(REQUIRED) The name of repository." required:"yes"`
 }
 
-var repoSignatureGet repositorySignatureGet
-
-func (x *repositorySignatureGet) Execute(args []string) error {
-	GetRepoSignature(utils.URLGen("/api/repositories"))
-	return nil
+func (x *RepositorySignatureGet) Execute(args []string) error {
+	return utils.PrintResult(GetRepoSignature(utils.NewClient(), x))
 }
 
 // GetRepoSignature aims to retrieve signature information of a repository, the data is from the nested notary instance of Harbor.
@@ -88,34 +106,76 @@ func (x *repositorySignatureGet) Execute(args []string) error {
 //   GET /repositories/{repo_name}/signatures
 //
 // e.g. curl -X GET --header 'Accept: text/plain' 'https://localhost/api/repositories/temp_3%2Fhello-world/signatures'
-func GetRepoSignature(baseURL string) {
-	targetURL := baseURL + "/" + repoSignatureGet.RepoName + "/signatures"
-	fmt.Println("==> GET", targetURL)
+func GetRepoSignature(c *harbor.Client, opt *RepositorySignatureGet) (*harbor.Result, error) {
+	targetURL := c.URL("/api/repositories") + "/" + utils.RepoPath(opt.RepoName) + "/signatures"
+	c.Trace("==> GET", targetURL)
 
-	utils.Request.Get(targetURL).End(utils.PrintStatus)
+	return c.Do(c.Get(targetURL))
 }
 
-type repositoryImageVulDetailsGet struct {
+// RepositoryImageVulDetailsGet holds the parameters of GetRepoImageVulDetails.
+type RepositoryImageVulDetailsGet struct {
+	RepoName string `short:"n" long:"repo_name" description:"(REQUIRED) The name of repository." required:"yes"`
+	Tag      string `short:"t" long:"tag" description:"(REQUIRED) The tag of the image." required:"yes"`
+	NoCache  bool   `long:"no_cache" description:"Download the report even if it is cached."`
+
+	FixableOnly bool `long:"fixable-only" description:"Print only the vulnerabilities which have a fixed version."`
+	FixSummary  bool `long:"fix-summary" description:"Print the packages which have fixed versions instead, with the version to upgrade to and the CVEs it fixes."`
 }
 
-var repoImageVulDetailsGet repositoryImageVulDetailsGet
+func (x *RepositoryImageVulDetailsGet) Execute(args []string) error {
+	report, err := GetRepoImageVulDetails(utils.NewClient(), x)
+	if err != nil {
+		return err
+	}
+	if !x.FixableOnly && !x.FixSummary {
+		fmt.Printf("%s\n", report)
+		return nil
+	}
 
-type repositoryImageScan struct {
+	var vulns []model.VulnerabilityItem
+	if err := json.Unmarshal(report, &vulns); err != nil {
+		return err
+	}
+	if x.FixSummary {
+		utils.PrintVulFixSummary(vulns)
+		return nil
+	}
+	b, err := json.MarshalIndent(utils.VulFixable(vulns), "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", b)
+	return nil
+}
+
+// GetRepoImageVulDetails gets the vulnerability details of an image, from
+// local cache if the latest report is there.
+//
+// params:
+//   repo_name - (REQUIRED) The name of repository.
+//   tag       - (REQUIRED) The tag of the image.
+//
+// format:
+//   GET /repositories/{repo_name}/tags/{tag}/vulnerability/details
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/repositories/prj2%2Fphoton/tags/v3/vulnerability/details'
+func GetRepoImageVulDetails(c *harbor.Client, opt *RepositoryImageVulDetailsGet) ([]byte, error) {
+	return utils.VulDetailsFetch(c, opt.RepoName, opt.Tag, opt.NoCache)
 }
 
-var repoImageScan repositoryImageScan
+type repositoryImageScan struct {
+}
 
-type repositoryImageManifestsGet struct {
+// RepositoryImageManifestsGet holds the parameters of GetRepoImageManifest.
+type RepositoryImageManifestsGet struct {
 	RepoName string `short:"n" long:"repo_name" description:"(REQUIRED) The name of repository." required:"yes"`
 	Tag      string `short:"t" long:"tag" description:"(REQUIRED) The tag of the image." required:"yes"`
 	Version  string `short:"v" long:"version" description:"The version of manifest, valid value are \"v1\" and \"v2\", default is \"v2\"" default:"v2"`
 }
 
-var repoImageManifestsGet repositoryImageManifestsGet
-
-func (x *repositoryImageManifestsGet) Execute(args []string) error {
-	GetRepoImageManifest(utils.URLGen("/api/repositories"))
-	return nil
+func (x *RepositoryImageManifestsGet) Execute(args []string) error {
+	return utils.PrintResult(GetRepoImageManifest(utils.NewClient(), x))
 }
 
 // GetRepoImageManifest aims to retrieve manifests from a relevant repository.
@@ -129,26 +189,24 @@ func (x *repositoryImageManifestsGet) Execute(args []string) error {
 //   GET /repositories/{repo_name}/tags/{tag}/manifest
 //
 // e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/repositories/temp_3%2Fhello-world/tags/v1/manifest?version=v2'
-func GetRepoImageManifest(baseURL string) {
-	targetURL := baseURL + "/" + repoImageManifestsGet.RepoName +
-		"/tags/" + repoImageManifestsGet.Tag +
-		"/manifest?version=" + repoImageManifestsGet.Version
-	fmt.Println("==> GET", targetURL)
+func GetRepoImageManifest(c *harbor.Client, opt *RepositoryImageManifestsGet) (*harbor.Result, error) {
+	targetURL := c.URL("/api/repositories") + "/" + utils.RepoPath(opt.RepoName) +
+		"/tags/" + utils.TagPath(opt.Tag) +
+		"/manifest?version=" + opt.Version
+	c.Trace("==> GET", targetURL)
 
-	utils.Request.Get(targetURL).End(utils.PrintStatus)
+	return c.Do(c.Get(targetURL))
 }
 
-type repositoryImageLabelDel struct {
+// RepositoryImageLabelDel holds the parameters of DeleteRepoImageLabel.
+type RepositoryImageLabelDel struct {
 	RepoName string `short:"n" long:"repo_name" description:"(REQUIRED) The name of repository." required:"yes"`
 	Tag      string `short:"t" long:"tag" description:"(REQUIRED) The tag of the image." required:"yes"`
 	LabelID  int    `short:"i" long:"label_id" description:"(REQUIRED) The ID of label." required:"yes"`
 }
 
-var repoImageLabelDel repositoryImageLabelDel
-
-func (x *repositoryImageLabelDel) Execute(args []string) error {
-	DeleteRepoImageLabel(utils.URLGen("/api/repositories"))
-	return nil
+func (x *RepositoryImageLabelDel) Execute(args []string) error {
+	return utils.PrintResult(DeleteRepoImageLabel(utils.NewClient(), x))
 }
 
 // DeleteRepoImageLabel deletes the label from the image specified by the repo_name and tag.
@@ -159,43 +217,32 @@ func (x *repositoryImageLabelDel) Execute(args []string) error {
 //   id        - (REQUIRED) The ID of label.
 //
 // e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/repositories/temp_3%2Fhello-world/tags/v1/labels/2'
-func DeleteRepoImageLabel(baseURL string) {
-	targetURL := baseURL + "/" + repoImageLabelDel.RepoName +
-		"/tags/" + repoImageLabelDel.Tag +
-		"/labels/" + strconv.Itoa(repoImageLabelDel.LabelID)
-	fmt.Println("==> DELETE", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
+func DeleteRepoImageLabel(c *harbor.Client, opt *RepositoryImageLabelDel) (*harbor.Result, error) {
+	targetURL := c.URL("/api/repositories") + "/" + utils.RepoPath(opt.RepoName) +
+		"/tags/" + utils.TagPath(opt.Tag) +
+		"/labels/" + strconv.Itoa(opt.LabelID)
+	c.Trace("==> DELETE", targetURL)
 
-	utils.Request.Delete(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Delete(targetURL))
 }
 
-type repositoryImageLabelAdd struct {
+// RepositoryImageLabelAdd holds the parameters of PostRepoImageLabelAdd.
+type RepositoryImageLabelAdd struct {
 	RepoName     string `short:"n" long:"repo_name" description:"(REQUIRED) The name of repository that you want to add a label." required:"yes"`
 	Tag          string `short:"t" long:"tag" description:"(REQUIRED) The tag of the image." required:"yes"`
 	ID           int    `short:"i" long:"id" description:"(REQUIRED) The ID of the already existing label." required:"yes" json:"id"`
 	Name         string `long:"name" description:"The name of this label." default:"" json:"name"`
 	Description  string `long:"description" description:"The description of this label." default:"" json:"description"`
-	Color        string `long:"color" description:"The color code of this label. (e.g. Format: #A9B6BE)" default:"" json:"color"`
-	Scope        string `long:"scope" description:"The scope of this label. ('p' indicates project scope, 'g' indicates global scope)" default:"" json:"scope"`
+	Color        string `long:"color" description:"The color code of this label. (e.g. Format: #A9B6BE)" default:"" validate:"color" json:"color"`
+	Scope        string `long:"scope" description:"The scope of this label. ('p' indicates project scope, 'g' indicates global scope)" default:"" validate:"oneof=g|p" json:"scope"`
 	ProjectID    int    `long:"project_id" description:"Which project (id) this label belongs to when created. ('0' indicates global label, others indicate specific project)" default:"" json:"project_id"`
 	CreationTime string `long:"creation_time" description:"The creation time of this label. default time.Now()" default:"" json:"creation_time"`
 	UpdateTime   string `long:"update_time" description:"The update time of this label. default time.Now()" default:"" json:"update_time"`
 	Deleted      bool   `long:"deleted" description:"not sure" json:"deleted"`
 }
 
-var repoImageLabelAdd repositoryImageLabelAdd
-
-func (x *repositoryImageLabelAdd) Execute(args []string) error {
-	PostRepoImageLabelAdd(utils.URLGen("/api/repositories"))
-	return nil
+func (x *RepositoryImageLabelAdd) Execute(args []string) error {
+	return utils.PrintResult(PostRepoImageLabelAdd(utils.NewClient(), x))
 }
 
 // PostRepoImageLabelAdd adds a label to the image under specific repository.
@@ -226,48 +273,36 @@ curl -X POST --header 'Content-Type: application/json' --header 'Accept: text/pl
    "deleted": true \
  }' 'https://localhost/api/repositories/temp_3%2Fhello-world/tags/v1/labels'
 */
-func PostRepoImageLabelAdd(baseURL string) {
-	if repoImageLabelAdd.CreationTime == "" || repoImageLabelAdd.UpdateTime == "" {
+func PostRepoImageLabelAdd(c *harbor.Client, opt *RepositoryImageLabelAdd) (*harbor.Result, error) {
+	if opt.CreationTime == "" || opt.UpdateTime == "" {
 		now := time.Now().Format("2006-01-02T15:04:05Z")
-		repoImageLabelAdd.CreationTime = now
-		repoImageLabelAdd.UpdateTime = now
+		opt.CreationTime = now
+		opt.UpdateTime = now
 	}
 
-	targetURL := baseURL + "/" + repoImageLabelAdd.RepoName +
-		"/tags/" + repoImageLabelAdd.Tag + "/labels"
-	fmt.Println("==> POST", targetURL)
+	targetURL := c.URL("/api/repositories") + "/" + utils.RepoPath(opt.RepoName) +
+		"/tags/" + utils.TagPath(opt.Tag) + "/labels"
+	c.Trace("==> POST", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
+	t, err := json.Marshal(opt)
 	if err != nil {
-		fmt.Println("Error:", err)
-		return
+		return nil, err
 	}
 
-	t, err := json.Marshal(&repoImageLabelAdd)
-	if err != nil {
-		fmt.Println("error:", err)
-		return
-	}
+	c.Trace("==> label add:", string(t))
 
-	fmt.Println("==> label add:", string(t))
-
-	utils.Request.Post(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		Send(string(t)).
-		End(utils.PrintStatus)
+	return c.Do(c.Post(targetURL).
+		Send(string(t)))
 }
 
-type repositoryImageLabelsGet struct {
+// RepositoryImageLabelsGet holds the parameters of GetRepoImageLabel.
+type RepositoryImageLabelsGet struct {
 	RepoName string `short:"n" long:"repo_name" description:"(REQUIRED) The name of repository." required:"yes"`
 	Tag      string `short:"t" long:"tag" description:"(REQUIRED) The tag of the image." required:"yes"`
 }
 
-var repoImageLabelsGet repositoryImageLabelsGet
-
-func (x *repositoryImageLabelsGet) Execute(args []string) error {
-	GetRepoImageLabel(utils.URLGen("/api/repositories"))
-	return nil
+func (x *RepositoryImageLabelsGet) Execute(args []string) error {
+	return utils.PrintResult(GetRepoImageLabel(utils.NewClient(), x))
 }
 
 // GetRepoImageLabel gets labels of an image specified by the repo_name and tag.
@@ -280,24 +315,22 @@ func (x *repositoryImageLabelsGet) Execute(args []string) error {
 //   GET /repositories/{repo_name}/tags/{tag}/labels
 //
 // e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/repositories/temp_3%2Fhello-world/tags/v1/labels'
-func GetRepoImageLabel(baseURL string) {
-	targetURL := baseURL + "/" + repoImageLabelsGet.RepoName +
-		"/tags/" + repoImageLabelsGet.Tag + "/labels"
-	fmt.Println("==> GET", targetURL)
+func GetRepoImageLabel(c *harbor.Client, opt *RepositoryImageLabelsGet) (*harbor.Result, error) {
+	targetURL := c.URL("/api/repositories") + "/" + utils.RepoPath(opt.RepoName) +
+		"/tags/" + utils.TagPath(opt.Tag) + "/labels"
+	c.Trace("==> GET", targetURL)
 
-	utils.Request.Get(targetURL).End(utils.PrintStatus)
+	return c.Do(c.Get(targetURL))
 }
 
-type repositoryLabelDel struct {
+// RepositoryLabelDel holds the parameters of DeleteRepoLabel.
+type RepositoryLabelDel struct {
 	RepoName string `short:"n" long:"repo_name" description:"(REQUIRED) The name of repository that you want to delete a label from." required:"yes"`
 	ID       int    `short:"i" long:"id" description:"(REQUIRED) The ID of label." required:"yes"`
 }
 
-var repoLabelDel repositoryLabelDel
-
-func (x *repositoryLabelDel) Execute(args []string) error {
-	DeleteRepoLabel(utils.URLGen("/api/repositories"))
-	return nil
+func (x *RepositoryLabelDel) Execute(args []string) error {
+	return utils.PrintResult(DeleteRepoLabel(utils.NewClient(), x))
 }
 
 // DeleteRepoLabel deletes the label from the repository specified by the repo_name.
@@ -307,41 +340,30 @@ func (x *repositoryLabelDel) Execute(args []string) error {
 //   id        - (REQUIRED) The ID of label.
 //
 // e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/repositories/temp_3%2Fhello-world/labels/2'
-func DeleteRepoLabel(baseURL string) {
-	targetURL := baseURL + "/" + repoLabelDel.RepoName +
-		"/labels/" + strconv.Itoa(repoLabelDel.ID)
-	fmt.Println("==> DELETE", targetURL)
+func DeleteRepoLabel(c *harbor.Client, opt *RepositoryLabelDel) (*harbor.Result, error) {
+	targetURL := c.URL("/api/repositories") + "/" + utils.RepoPath(opt.RepoName) +
+		"/labels/" + strconv.Itoa(opt.ID)
+	c.Trace("==> DELETE", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
-
-	utils.Request.Delete(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Delete(targetURL))
 }
 
-type repositoryLabelAdd struct {
+// RepositoryLabelAdd holds the parameters of PostRepoLabelAdd.
+type RepositoryLabelAdd struct {
 	RepoName     string `short:"n" long:"repo_name" description:"(REQUIRED) The name of repository that you want to add a label." required:"yes"`
 	ID           int    `short:"i" long:"id" description:"(REQUIRED) The ID of the already existing label." required:"yes" json:"id"`
 	Name         string `long:"name" description:"The name of this label." default:"" json:"name"`
 	Description  string `long:"description" description:"The description of this label." default:"" json:"description"`
-	Color        string `long:"color" description:"The color code of this label. (e.g. Format: #A9B6BE)" default:"" json:"color"`
-	Scope        string `long:"scope" description:"The scope of this label. ('p' indicates project scope, 'g' indicates global scope)" default:"" json:"scope"`
+	Color        string `long:"color" description:"The color code of this label. (e.g. Format: #A9B6BE)" default:"" validate:"color" json:"color"`
+	Scope        string `long:"scope" description:"The scope of this label. ('p' indicates project scope, 'g' indicates global scope)" default:"" validate:"oneof=g|p" json:"scope"`
 	ProjectID    int    `long:"project_id" description:"Which project (id) this label belongs to when created. ('0' indicates global label, others indicate specific project)" default:"" json:"project_id"`
 	CreationTime string `long:"creation_time" description:"The creation time of this label. default time.Now()" default:"" json:"creation_time"`
 	UpdateTime   string `long:"update_time" description:"The update time of this label. default time.Now()" default:"" json:"update_time"`
 	Deleted      bool   `long:"deleted" description:"not sure" json:"deleted"`
 }
 
-var repoLabelAdd repositoryLabelAdd
-
-func (x *repositoryLabelAdd) Execute(args []string) error {
-	PostRepoLabelAdd(utils.URLGen("/api/repositories"))
-	return nil
+func (x *RepositoryLabelAdd) Execute(args []string) error {
+	return utils.PrintResult(PostRepoLabelAdd(utils.NewClient(), x))
 }
 
 // PostRepoLabelAdd add a label to the repository.
@@ -371,46 +393,34 @@ curl -X POST --header 'Content-Type: application/json' --header 'Accept: text/pl
    "deleted": true \
  }' 'https://localhost/api/repositories/temp_5%2Fhello-world/labels'
 */
-func PostRepoLabelAdd(baseURL string) {
-	if repoLabelAdd.CreationTime == "" || repoLabelAdd.UpdateTime == "" {
+func PostRepoLabelAdd(c *harbor.Client, opt *RepositoryLabelAdd) (*harbor.Result, error) {
+	if opt.CreationTime == "" || opt.UpdateTime == "" {
 		now := time.Now().Format("2006-01-02T15:04:05Z")
-		repoLabelAdd.CreationTime = now
-		repoLabelAdd.UpdateTime = now
+		opt.CreationTime = now
+		opt.UpdateTime = now
 	}
 
-	targetURL := baseURL + "/" + repoLabelAdd.RepoName + "/labels"
-	fmt.Println("==> POST", targetURL)
+	targetURL := c.URL("/api/repositories") + "/" + utils.RepoPath(opt.RepoName) + "/labels"
+	c.Trace("==> POST", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
+	t, err := json.Marshal(opt)
 	if err != nil {
-		fmt.Println("Error:", err)
-		return
+		return nil, err
 	}
 
-	t, err := json.Marshal(&repoLabelAdd)
-	if err != nil {
-		fmt.Println("error:", err)
-		return
-	}
+	c.Trace("==> label add:", string(t))
 
-	fmt.Println("==> label add:", string(t))
-
-	utils.Request.Post(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		Send(string(t)).
-		End(utils.PrintStatus)
+	return c.Do(c.Post(targetURL).
+		Send(string(t)))
 }
 
-type repositoryLabelsGet struct {
+// RepositoryLabelsGet holds the parameters of GetRepoLabels.
+type RepositoryLabelsGet struct {
 	RepoName string `short:"n" long:"repo_name" description:"(REQUIRED) The name of repository." required:"yes"`
 }
 
-var repoLabelsGet repositoryLabelsGet
-
-func (x *repositoryLabelsGet) Execute(args []string) error {
-	GetRepoLabels(utils.URLGen("/api/repositories"))
-	return nil
+func (x *RepositoryLabelsGet) Execute(args []string) error {
+	return utils.PrintResult(GetRepoLabels(utils.NewClient(), x))
 }
 
 // GetRepoLabels get labels of a repository specified by the repo_name.
@@ -422,23 +432,21 @@ func (x *repositoryLabelsGet) Execute(args []string) error {
 //   GET /repositories/{repo_name}/labels
 //
 // e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/repositories/temp_5%2Fhello-world/labels'
-func GetRepoLabels(baseURL string) {
-	targetURL := baseURL + "/" + repoLabelsGet.RepoName + "/labels"
-	fmt.Println("==> GET", targetURL)
+func GetRepoLabels(c *harbor.Client, opt *RepositoryLabelsGet) (*harbor.Result, error) {
+	targetURL := c.URL("/api/repositories") + "/" + utils.RepoPath(opt.RepoName) + "/labels"
+	c.Trace("==> GET", targetURL)
 
-	utils.Request.Get(targetURL).End(utils.PrintStatus)
+	return c.Do(c.Get(targetURL))
 }
 
-type repoDescriptionUpdate struct {
+// RepoDescriptionUpdate holds the parameters of PutRepoDescriptionUpdate.
+type RepoDescriptionUpdate struct {
 	RepoName    string `short:"n" long:"repo_name" description:"(REQUIRED) Repo name for filtering results." required:"yes" json:"-"`
 	Description string `short:"d" long:"description" description:"(REQUIRED) The description of the repository." required:"yes" json:"description"`
 }
 
-var repoUpdate repoDescriptionUpdate
-
-func (x *repoDescriptionUpdate) Execute(args []string) error {
-	PutRepoDescriptionUpdate(utils.URLGen("/api/repositories"))
-	return nil
+func (x *RepoDescriptionUpdate) Execute(args []string) error {
+	return utils.PrintResult(PutRepoDescriptionUpdate(utils.NewClient(), x))
 }
 
 // PutRepoDescriptionUpdate is used to update description of the repository.
@@ -456,65 +464,164 @@ curl -X PUT --header 'Content-Type: application/json' --header 'Accept: text/pla
    "description": "change" \
  }' 'https://localhost/api/repositories/temp_5%2Fhello-world'
 */
-func PutRepoDescriptionUpdate(baseURL string) {
-	targetURL := baseURL + "/" + repoUpdate.RepoName
-	fmt.Println("==> PUT", targetURL)
+func PutRepoDescriptionUpdate(c *harbor.Client, opt *RepoDescriptionUpdate) (*harbor.Result, error) {
+	targetURL := c.URL("/api/repositories") + "/" + utils.RepoPath(opt.RepoName)
+	c.Trace("==> PUT", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
+	t, err := json.Marshal(opt)
 	if err != nil {
-		fmt.Println("Error:", err)
-		return
+		return nil, err
 	}
 
-	t, err := json.Marshal(&repoUpdate)
-	if err != nil {
-		fmt.Println("error:", err)
-		return
-	}
+	c.Trace("==> description:", string(t))
+
+	return c.Do(c.Put(targetURL).
+		Send(string(t)))
+}
+
+// RepoDescribe holds the parameters of PutRepoDescribe.
+type RepoDescribe struct {
+	Project     string `short:"p" long:"project" description:"(REQUIRED) The name of the project." required:"yes"`
+	RepoName    string `short:"r" long:"repo_name" description:"(REQUIRED) The name of the repository, with or without the project part." required:"yes"`
+	Description string `short:"d" long:"description" description:"(REQUIRED) The description of the repository, '' to clear it." required:"yes"`
+}
+
+func (x *RepoDescribe) Execute(args []string) error {
+	return utils.PrintResult(PutRepoDescribe(utils.NewClient(), x))
+}
 
-	fmt.Println("==> description:", string(t))
+// PutRepoDescribe sets the description of a repository given by project and
+// repository name, see PutRepoDescriptionUpdate.
+//
+// params:
+//   project     - (REQUIRED) The name of the project.
+//   repo_name   - (REQUIRED) The name of the repository.
+//   description - (REQUIRED) The description of the repository.
+//
+// format:
+//   PUT /repositories/{project}/{repo_name}
+func PutRepoDescribe(c *harbor.Client, opt *RepoDescribe) (*harbor.Result, error) {
+	name := opt.RepoName
+	if !strings.HasPrefix(name, opt.Project+"/") {
+		name = opt.Project + "/" + name
+	}
 
-	utils.Request.Put(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		Send(string(t)).
-		End(utils.PrintStatus)
+	return PutRepoDescriptionUpdate(c, &RepoDescriptionUpdate{
+		RepoName:    name,
+		Description: opt.Description,
+	})
 }
 
-type repositoriesList struct {
+// RepositoriesList holds the parameters of GetReposByPrjID.
+type RepositoriesList struct {
 	ProjectID int    `short:"j" long:"project_id" description:"(REQUIRED) Relevant project ID." required:"yes"`
 	RepoName  string `short:"n" long:"repo_name" description:"Repo name for filtering results." default:""`
-	LabelID   int    `short:"l" long:"label_id" description:"The ID of label used to filter the result." default:"0"`
+	LabelID   int    `short:"l" long:"label_id" description:"The ID of label used to filter the result. With v2.0 API, the repositories having an artifact with the label, all pages are listed." default:"0"`
 	Page      int    `short:"p" long:"page" description:"The page nubmer, default is 1." default:"1"`
-	PageSize  int    `short:"s" long:"page_size" description:"The size of per page, default is 10, maximum is 100." default:"10"`
+	PageSize  int    `short:"s" long:"page_size" description:"The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs." default:"10" validate:"pagesize"`
+	Count     bool   `long:"count" description:"Print the total number of matched items only."`
+	All       bool   `long:"all" description:"Fetch all pages, --page and --page_size are ignored."`
+	Table     bool   `long:"table" description:"Print name, tags, pulls, update time and description as a table instead of the raw response."`
+	RegexFilter
 }
 
-var reposList repositoriesList
+func (x *RepositoriesList) Execute(args []string) error {
+	res, err := GetReposByPrjID(utils.NewClient(), x)
+	if err != nil || x.Count || !x.Table {
+		return utils.ListPrinter(x.Count)(res, err)
+	}
 
-func (x *repositoriesList) Execute(args []string) error {
-	GetReposByPrjID(utils.URLGen("/api/repositories"))
+	var repos []*utils.RepoInfo
+	if err := res.Decode(&repos); err != nil {
+		return err
+	}
+	utils.PrintRepoTable(repos)
+	if hint := utils.PageHint(res.Pagination(), len(repos)); hint != "" {
+		fmt.Println(hint)
+	}
 	return nil
 }
 
-type repositoriesTop struct {
+// RepositoriesTop holds the parameters of GetTopRepos.
+type RepositoriesTop struct {
 	Count int `short:"c" long:"count" description:"The number of the requested public repositories, default is 10 if not provided." default:"10"`
 }
 
-var reposTop repositoriesTop
-
-func (x *repositoriesTop) Execute(args []string) error {
-	GetTopRepos(utils.URLGen("/api/repositories/top"))
-	return nil
+func (x *RepositoriesTop) Execute(args []string) error {
+	return utils.PrintResult(GetTopRepos(utils.NewClient(), x))
 }
 
-type repositoryDel struct {
-	RepoName string `short:"n" long:"repo_name" description:"(REQUIRED) The name of repository which will be deleted." required:"yes"`
+// RepositoryDel holds the parameters of DelRepoByRepoName.
+type RepositoryDel struct {
+	RepoName  string `short:"n" long:"repo_name" description:"The name of repository which will be deleted. Required unless --project_id is given."`
+	ProjectID int    `short:"j" long:"project_id" description:"Delete the repositories of this project matching --match and --label_id instead."`
+	Match     string `short:"m" long:"match" description:"Shell pattern of the names of the repositories to delete, with or without project, e.g. 'ci-*'. (with --project_id)"`
+	LabelID   int    `short:"l" long:"label_id" description:"Delete the repositories with this label only. (with --project_id)"`
+	DryRun    bool   `long:"dry-run" description:"Print the repositories to delete only. (with --project_id)"`
 }
 
-var repoDel repositoryDel
+func (x *RepositoryDel) Execute(args []string) error {
+	switch {
+	case x.RepoName != "" && x.ProjectID != 0:
+		return errors.New("--repo_name and --project_id are exclusive")
+	case x.RepoName != "":
+		return utils.PrintResult(DelRepoByRepoName(utils.NewClient(), x))
+	case x.ProjectID == 0:
+		return errors.New("--repo_name or --project_id is required")
+	}
 
-func (x *repositoryDel) Execute(args []string) error {
-	DelRepoByRepoName(utils.URLGen("/api/repositories"))
+	// The repositories are all decoded, thus listed, before any is
+	// deleted: deleting while paging would shift the pages and skip some.
+	c := utils.NewClient()
+	res, err := GetReposByPrjID(c, &RepositoriesList{
+		ProjectID: x.ProjectID,
+		LabelID:   x.LabelID,
+		Page:      1,
+		PageSize:  c.MaxPageSize(),
+		All:       true,
+	})
+	if err != nil {
+		return utils.PrintResult(res, err)
+	}
+	var repos []*model.Repository
+	if err := res.Decode(&repos); err != nil {
+		return err
+	}
+
+	var failed []string
+	deleted := 0
+	for _, r := range repos {
+		if x.Match != "" {
+			full, _ := path.Match(x.Match, r.Name)
+			short, _ := path.Match(x.Match, r.Name[strings.IndexByte(r.Name, '/')+1:])
+			if !full && !short {
+				continue
+			}
+		}
+
+		fmt.Println("delete", r.Name)
+		if x.DryRun {
+			deleted++
+			continue
+		}
+		if _, err := DelRepoByRepoName(c, &RepositoryDel{RepoName: r.Name}); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", r.Name, err))
+			continue
+		}
+		deleted++
+	}
+
+	if x.DryRun {
+		fmt.Printf("%d repositories to delete\n", deleted)
+		return nil
+	}
+	fmt.Printf("%d repositories deleted\n", deleted)
+	if len(failed) > 0 {
+		for _, f := range failed {
+			fmt.Println("failed:", f)
+		}
+		return fmt.Errorf("%d repositories not deleted", len(failed))
+	}
 	return nil
 }
 
@@ -524,28 +631,121 @@ func (x *repositoryDel) Execute(args []string) error {
 //   project_id - (REQUIRED) Relevant project ID.
 //   q          - Repo name for filtering results.
 //   label_id   - The ID of label used to filter the result.
+//   filter-regex - The regular expression the names of the repositories match, applied client-side.
 //   page       - The page nubmer, default is 1.
-//   pageSize   - The size of per page, default is 10, maximum is 100.
+//   pageSize   - The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs.
 //
 // e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/repositories?project_id=1&q=prj&label_id=100&page=1&page_size=10'
-func GetReposByPrjID(baseURL string) {
-	targetURL := baseURL + "?project_id=" + strconv.Itoa(reposList.ProjectID) +
-		"&q=" + reposList.RepoName +
-		"&label_id=" + strconv.Itoa(reposList.LabelID) +
-		"&page=" + strconv.Itoa(reposList.Page) +
-		"&page_size=" + strconv.Itoa(reposList.PageSize)
-	fmt.Println("==> GET", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
+func GetReposByPrjID(c *harbor.Client, opt *RepositoriesList) (*harbor.Result, error) {
+	if opt.Count {
+		opt.Page, opt.PageSize = 1, 1
+	}
+	keep, err := opt.keep(itemName)
 	if err != nil {
-		fmt.Println("Error:", err)
-		return
+		return nil, err
+	}
+
+	if c.IsV2() {
+		return getReposV2(c, opt, keep)
 	}
 
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	targetURL := c.URL("/api/repositories") + "?project_id=" + strconv.Itoa(opt.ProjectID) +
+		"&q=" + url.QueryEscape(opt.RepoName) +
+		"&label_id=" + strconv.Itoa(opt.LabelID) +
+		"&page=" + strconv.Itoa(opt.Page) +
+		"&page_size=" + strconv.Itoa(opt.PageSize)
+	if !opt.Count {
+		c.Trace("==> GET", targetURL)
+	}
+
+	return listItems(c, targetURL, keep, opt.Count, opt.All)
+}
+
+// getReposV2 is GetReposByPrjID for v2.0 API, where the repositories are
+// listed under the name of their project.
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/projects/prj1/repositories?q=name%3D~app&page=1&page_size=10'
+func getReposV2(c *harbor.Client, opt *RepositoriesList, keep func(json.RawMessage) (bool, error)) (*harbor.Result, error) {
+	name, err := projectName(c, opt.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	targetURL := c.URL("/api/v2.0/projects") + "/" + url.PathEscape(name) + "/repositories?q="
+	if opt.RepoName != "" {
+		targetURL += url.QueryEscape("name=~" + opt.RepoName)
+	}
+	if opt.LabelID != 0 {
+		if !opt.Count {
+			c.Trace("==> GET", targetURL)
+		}
+		return reposWithLabelV2(c, targetURL, name, opt.LabelID, keep)
+	}
+	targetURL += "&page=" + strconv.Itoa(opt.Page) +
+		"&page_size=" + strconv.Itoa(opt.PageSize)
+	if !opt.Count {
+		c.Trace("==> GET", targetURL)
+	}
+
+	return listItems(c, targetURL, keep, opt.Count, opt.All)
+}
+
+// reposWithLabelV2 lists the repositories of reposURL having an artifact
+// with the label, as v2.0 API labels artifacts only, which keep accepts if
+// not nil. All pages are listed,
+// the Result is built as the response of a single page.
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/projects/prj1/repositories/app/artifacts?q=labels%3D%283%29&page_size=1'
+func reposWithLabelV2(c *harbor.Client, reposURL, project string, labelID int, keep func(json.RawMessage) (bool, error)) (*harbor.Result, error) {
+	repos := []json.RawMessage{}
+	err := c.EachPage(reposURL, func(item json.RawMessage) error {
+		if keep != nil {
+			if ok, err := keep(item); !ok || err != nil {
+				return err
+			}
+		}
+		var r model.Repository
+		if err := json.Unmarshal(item, &r); err != nil {
+			return err
+		}
+		artifactsURL := c.URL("/api/v2.0/projects") + "/" + url.PathEscape(project) +
+			"/repositories/" + utils.RepoPathV2(strings.TrimPrefix(r.Name, project+"/")) +
+			"/artifacts?page_size=1&q=" + url.QueryEscape("labels=("+strconv.Itoa(labelID)+")")
+		var arts []json.RawMessage
+		if err := c.GetJSON(artifactsURL, &arts); err != nil {
+			return err
+		}
+		if len(arts) > 0 {
+			repos = append(repos, item)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(repos)
+	if err != nil {
+		return nil, err
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("X-Total-Count", strconv.Itoa(len(repos)))
+	return &harbor.Result{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Header:     header,
+		Body:       body,
+	}, nil
+}
+
+// splitRepoName splits a full repository name, "prj1/team/app", into the
+// project and the repository name within it.
+func splitRepoName(name string) (project, repo string) {
+	if i := strings.IndexByte(name, '/'); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
 }
 
 // GetTopRepos aims to let users see the most popular public repositories
@@ -554,31 +754,32 @@ func GetReposByPrjID(baseURL string) {
 //   count - The number of the requested public repositories, default is 10 if not provided.
 //
 // e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/repositories/top?count=3'
-func GetTopRepos(baseURL string) {
-	targetURL := baseURL + "?count=" + strconv.Itoa(reposTop.Count)
-	fmt.Println("==> GET", targetURL)
+func GetTopRepos(c *harbor.Client, opt *RepositoriesTop) (*harbor.Result, error) {
+	targetURL := c.URL("/api/repositories/top") + "?count=" + strconv.Itoa(opt.Count)
+	c.Trace("==> GET", targetURL)
 
-	utils.Request.Get(targetURL).End(utils.PrintStatus)
+	return c.Do(c.Get(targetURL))
 }
 
-// DelRepoByRepoName let user delete a repository with name.
+// DelRepoByRepoName let user delete a repository with name. The filters of
+// RepositoryDel are for the repo_del command, see its Execute.
 //
 // params:
 //   repo_name - (REQUIRED) The name of repository which will be deleted.
 //
 // e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/repositories/prj1%2Fhello-world'
-func DelRepoByRepoName(baseURL string) {
-	targetURL := baseURL + "/" + repoDel.RepoName
-	fmt.Println("==> DELETE", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
+func DelRepoByRepoName(c *harbor.Client, opt *RepositoryDel) (*harbor.Result, error) {
+	if c.IsV2() {
+		project, repo := splitRepoName(opt.RepoName)
+		targetURL := c.URL("/api/v2.0/projects") + "/" + url.PathEscape(project) +
+			"/repositories/" + utils.RepoPathV2(repo)
+		c.Trace("==> DELETE", targetURL)
+
+		return c.Do(c.Delete(targetURL))
 	}
 
-	utils.Request.Delete(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	targetURL := c.URL("/api/repositories") + "/" + utils.RepoPath(opt.RepoName)
+	c.Trace("==> DELETE", targetURL)
+
+	return c.Do(c.Delete(targetURL))
 }
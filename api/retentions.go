@@ -0,0 +1,731 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+func init() {
+	utils.Parser.AddCommand("retention_metadatas",
+		"Get the metadatas of tag retention.",
+		"This endpoint gets the rule templates and the selectors of tag retention policies, with their parameters. (Harbor v1.9+)",
+		&RetentionMetadatas{})
+	utils.Parser.AddCommand("retention_get",
+		"Get a tag retention policy.",
+		"This endpoint gets a tag retention policy by specific ID, or the one of the project given by --project_id. (Harbor v1.9+)",
+		&RetentionGet{})
+	utils.Parser.AddCommand("retention_create",
+		"Create the tag retention policy of a project.",
+		"This endpoint creates the tag retention policy of a project, from the rules of --rule and of the policy file if any, e.g. --rule 'keep=last-pushed:10,repos=**' keeps the 10 artifacts pushed last of every repository. (Harbor v1.9+)",
+		&RetentionCreate{})
+	utils.Parser.AddCommand("retention_update",
+		"Update a tag retention policy.",
+		"This endpoint updates a tag retention policy by specific ID: the rules of --rule and of the policy file replace those of the policy if any are given, --cron or --no-schedule changes its schedule. (Harbor v1.9+)",
+		&RetentionUpdate{})
+	utils.Parser.AddCommand("retention_run",
+		"Run a tag retention policy.",
+		"This endpoint triggers an execution of a tag retention policy, --dry-run tells the artifacts it would delete without deleting them. retention_executions_list and retention_tasks_list follow it. (Harbor v1.9+)",
+		&RetentionRun{})
+	utils.Parser.AddCommand("retention_simulate",
+		"Simulate a tag retention policy.",
+		"This endpoint runs a tag retention policy as a dry run, waits for it to end and prints the tags it would keep and delete, by repository, to check the rules before scheduling the policy. Nothing is deleted. (Harbor v1.9+)",
+		&RetentionSimulate{})
+	utils.Parser.AddCommand("retention_executions_list",
+		"List the executions of a tag retention policy.",
+		"This endpoint lists the executions of a tag retention policy, latest first, with their status. (Harbor v1.9+)",
+		&RetentionExecutionsList{})
+	utils.Parser.AddCommand("retention_tasks_list",
+		"List the tasks of an execution of a tag retention policy.",
+		"This endpoint lists the tasks of an execution of a tag retention policy, one per repository, with the number of artifacts retained. (Harbor v1.9+)",
+		&RetentionTasksList{})
+	utils.Parser.AddCommand("retention_task_log",
+		"Get the log of a task of a tag retention policy.",
+		"This endpoint gets the log of a task of an execution of a tag retention policy, the artifacts retained and deleted. (Harbor v1.9+)",
+		&RetentionTaskLog{})
+
+	utils.ResponseModel("retention_get", model.RetentionPolicy{})
+	utils.ResponseModel("retention_executions_list", model.RetentionExecution{})
+	utils.ResponseModel("retention_tasks_list", model.RetentionTask{})
+}
+
+// retentionTemplates maps the keep= values of --rule to the rule templates
+// of Harbor, whose parameter is named after them.
+var retentionTemplates = map[string]string{
+	"last-pushed":   "latestPushedK",
+	"last-pulled":   "latestPulledN",
+	"pushed-within": "nDaysSinceLastPush",
+	"pulled-within": "nDaysSinceLastPull",
+	"always":        "always",
+}
+
+// retentionRule returns the rule of a --rule flag, a comma separated list
+// of:
+//
+//  keep=last-pushed:N     - the N artifacts pushed last
+//  keep=last-pulled:N     - the N artifacts pulled last
+//  keep=pushed-within:N   - the artifacts pushed in the last N days
+//  keep=pulled-within:N   - the artifacts pulled in the last N days
+//  keep=always            - all the artifacts
+//  repos=PATTERN          - of the repositories matching PATTERN, ** if unset
+//  exclude-repos=PATTERN  - of the repositories not matching PATTERN
+//  tags=PATTERN           - with a tag matching PATTERN, ** if unset
+//  exclude-tags=PATTERN   - with no tag matching PATTERN
+//  untagged=true|false    - the untagged artifacts too (Harbor v2.1+)
+//
+// Patterns are doublestar patterns, e.g. {dev,test}/** or v1.*.
+func retentionRule(f string) (*model.RetentionRule, error) {
+	kv := map[string]string{}
+	for _, field := range strings.Split(f, ",") {
+		i := strings.IndexByte(field, '=')
+		if i <= 0 {
+			return nil, fmt.Errorf("--rule: key=value expected, got %q in %q", field, f)
+		}
+		k := strings.TrimSpace(field[:i])
+		switch k {
+		case "keep", "repos", "exclude-repos", "tags", "exclude-tags", "untagged":
+		default:
+			return nil, fmt.Errorf("--rule: unknown key %q in %q, keep, repos, exclude-repos, tags, exclude-tags or untagged expected", k, f)
+		}
+		kv[k] = strings.TrimSpace(field[i+1:])
+	}
+
+	keep := strings.SplitN(kv["keep"], ":", 2)
+	template, ok := retentionTemplates[keep[0]]
+	if !ok {
+		return nil, fmt.Errorf("--rule: keep=last-pushed:N, last-pulled:N, pushed-within:DAYS, pulled-within:DAYS or always expected, got %q", f)
+	}
+	params := map[string]interface{}{}
+	if template != "always" {
+		if len(keep) != 2 {
+			return nil, fmt.Errorf("--rule: keep=%s:N expected, got %q", keep[0], f)
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(keep[1], "d"))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("--rule: a positive number expected in keep=%s, got %q", kv["keep"], f)
+		}
+		params[template] = n
+	} else if len(keep) == 2 {
+		return nil, fmt.Errorf("--rule: keep=always takes no number, got %q", f)
+	}
+
+	repos, err := retentionSelector(kv, "repos", "repoMatches", "repoExcludes")
+	if err != nil {
+		return nil, fmt.Errorf("--rule: %v in %q", err, f)
+	}
+	tags, err := retentionSelector(kv, "tags", "matches", "excludes")
+	if err != nil {
+		return nil, fmt.Errorf("--rule: %v in %q", err, f)
+	}
+	switch kv["untagged"] {
+	case "":
+	case "true", "false":
+		tags.Extras = `{"untagged":` + kv["untagged"] + `}`
+	default:
+		return nil, fmt.Errorf("--rule: untagged=true or untagged=false expected, got %q", f)
+	}
+
+	return &model.RetentionRule{
+		Action:         "retain",
+		Template:       template,
+		Params:         params,
+		TagSelectors:   []*model.RetentionSelector{tags},
+		ScopeSelectors: map[string][]*model.RetentionSelector{"repository": {repos}},
+	}, nil
+}
+
+// retentionSelector returns the selector of the key, or of exclude-key,
+// ** if neither is set.
+func retentionSelector(kv map[string]string, key, matches, excludes string) (*model.RetentionSelector, error) {
+	include, exclude := kv[key], kv["exclude-"+key]
+	if include != "" && exclude != "" {
+		return nil, fmt.Errorf("%s and exclude-%s are exclusive", key, key)
+	}
+	if exclude != "" {
+		return &model.RetentionSelector{Kind: "doublestar", Decoration: excludes, Pattern: exclude}, nil
+	}
+	if include == "" {
+		include = "**"
+	}
+	return &model.RetentionSelector{Kind: "doublestar", Decoration: matches, Pattern: include}, nil
+}
+
+// retentionRules returns the rules of the policy file if any, then those of
+// the --rule flags.
+func retentionRules(flags []string, policy *model.RetentionPolicy) ([]*model.RetentionRule, error) {
+	var rules []*model.RetentionRule
+	if policy != nil {
+		rules = append(rules, policy.Rules...)
+	}
+	for _, f := range flags {
+		rule, err := retentionRule(f)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// retentionSchedule returns the trigger of a policy run on cron, manually
+// only if cron is empty.
+func retentionSchedule(cron string) *model.RetentionTrigger {
+	return &model.RetentionTrigger{
+		Kind:     "Schedule",
+		Settings: map[string]interface{}{"cron": cron},
+	}
+}
+
+// retentionURL returns the URL of the retention policy id.
+func retentionURL(c *harbor.Client, id int) string {
+	return c.APIURL("/retentions") + "/" + strconv.Itoa(id)
+}
+
+// RetentionMetadatas is the retention_metadatas command.
+type RetentionMetadatas struct {
+}
+
+func (x *RetentionMetadatas) Execute(args []string) error {
+	return utils.PrintResult(GetRetentionMetadatas(utils.NewClient()))
+}
+
+// GetRetentionMetadatas gets the rule templates and selectors of tag
+// retention.
+//
+// format:
+//   GET /retentions/metadatas
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/retentions/metadatas'
+//
+func GetRetentionMetadatas(c *harbor.Client) (*harbor.Result, error) {
+	targetURL := c.APIURL("/retentions/metadatas")
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
+}
+
+// RetentionGet holds the parameters of GetRetention.
+type RetentionGet struct {
+	ID        int `short:"i" long:"id" description:"The ID of the tag retention policy. (required unless --project_id)"`
+	ProjectID int `short:"j" long:"project_id" description:"Get the tag retention policy of this project."`
+}
+
+func (x *RetentionGet) Execute(args []string) error {
+	return utils.PrintResult(GetRetention(utils.NewClient(), x))
+}
+
+// GetRetention gets a tag retention policy, the ID of the policy of a
+// project is its retention_id metadata.
+//
+// params:
+//   id - The ID of the tag retention policy.
+//   project_id - The ID of the project, instead of id.
+//
+// format:
+//   GET /retentions/{id}
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/retentions/1'
+//
+func GetRetention(c *harbor.Client, opt *RetentionGet) (*harbor.Result, error) {
+	if (opt.ID == 0) == (opt.ProjectID == 0) {
+		return nil, errors.New("one of --id and --project_id is required")
+	}
+
+	id := opt.ID
+	if opt.ProjectID != 0 {
+		targetURL := c.APIURL("/projects") + "/" + strconv.Itoa(opt.ProjectID)
+		c.Trace("==> GET", targetURL)
+
+		var prj model.Project
+		if err := c.GetJSON(targetURL, &prj); err != nil {
+			return nil, err
+		}
+		if prj.Metadata == nil || prj.Metadata.RetentionID == "" {
+			return nil, fmt.Errorf("project %d has no tag retention policy", opt.ProjectID)
+		}
+		n, err := strconv.Atoi(prj.Metadata.RetentionID)
+		if err != nil {
+			return nil, fmt.Errorf("bad retention_id %q of project %d", prj.Metadata.RetentionID, opt.ProjectID)
+		}
+		id = n
+	}
+
+	targetURL := retentionURL(c, id)
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
+}
+
+// RetentionCreate holds the parameters of PostRetention.
+type RetentionCreate struct {
+	ProjectID int      `short:"j" long:"project_id" description:"(REQUIRED) The ID of the project." required:"yes"`
+	Rule      []string `short:"r" long:"rule" description:"A rule as keep=...,repos=PATTERN,tags=PATTERN, may be repeated, e.g. 'keep=last-pushed:10', 'keep=pulled-within:30,repos=team/**'; see README for the keys."`
+	File      string   `short:"f" long:"file" description:"A JSON or YAML policy file (or @file, - for stdin): algorithm, rules and trigger, as in the output of retention_get."`
+	Cron      string   `short:"c" long:"cron" description:"The cron expression of the schedule, with seconds, e.g. '0 0 0 * * *'; manual runs only if unset."`
+}
+
+func (x *RetentionCreate) Execute(args []string) error {
+	return utils.PrintResult(PostRetention(utils.NewClient(), x))
+}
+
+// PostRetention creates the tag retention policy of a project, Harbor links
+// it to the project as its retention_id metadata. A project has one policy
+// at most.
+//
+// params:
+//   project_id - (REQUIRED) The ID of the project.
+//   rule - The rules of the policy.
+//   file - The policy file.
+//   cron - The cron expression of the schedule.
+//
+// format:
+//   POST /retentions
+//
+// e.g. curl -X POST --header 'Content-Type: application/json' -d '{"algorithm": "or", "rules": [{"action": "retain", "template": "latestPushedK", "params": {"latestPushedK": 10}, "tag_selectors": [{"kind": "doublestar", "decoration": "matches", "pattern": "**"}], "scope_selectors": {"repository": [{"kind": "doublestar", "decoration": "repoMatches", "pattern": "**"}]}}], "trigger": {"kind": "Schedule", "settings": {"cron": "0 0 0 * * *"}}, "scope": {"level": "project", "ref": 1}}' 'https://localhost/api/v2.0/retentions'
+func PostRetention(c *harbor.Client, opt *RetentionCreate) (*harbor.Result, error) {
+	policy := &model.RetentionPolicy{}
+	if opt.File != "" {
+		if err := utils.SpecLoad(opt.File, policy); err != nil {
+			return nil, err
+		}
+	}
+	rules, err := retentionRules(opt.Rule, policy)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, errors.New("no rule, set --rule or --file")
+	}
+
+	policy.ID = 0
+	policy.Rules = rules
+	if policy.Algorithm == "" {
+		policy.Algorithm = "or"
+	}
+	if opt.Cron != "" || policy.Trigger == nil {
+		policy.Trigger = retentionSchedule(opt.Cron)
+	}
+	policy.Scope = &model.RetentionScope{Level: "project", Ref: int64(opt.ProjectID)}
+
+	targetURL := c.APIURL("/retentions")
+	c.Trace("==> POST", targetURL)
+
+	return c.Do(c.Post(targetURL).
+		Send(policy))
+}
+
+// RetentionUpdate holds the parameters of PutRetention.
+type RetentionUpdate struct {
+	ID         int      `short:"i" long:"id" description:"(REQUIRED) The ID of the tag retention policy." required:"yes"`
+	Rule       []string `short:"r" long:"rule" description:"A rule as keep=...,repos=PATTERN,tags=PATTERN, may be repeated; the rules given replace those of the policy, unchanged if none."`
+	File       string   `short:"f" long:"file" description:"A JSON or YAML policy file (or @file, - for stdin) whose rules replace those of the policy, along with --rule."`
+	Cron       string   `short:"c" long:"cron" description:"The new cron expression of the schedule, with seconds, unchanged if not set."`
+	NoSchedule bool     `long:"no-schedule" description:"Unschedule the policy, to run it manually only."`
+}
+
+func (x *RetentionUpdate) Execute(args []string) error {
+	return utils.PrintResult(PutRetention(utils.NewClient(), x))
+}
+
+// PutRetention updates a tag retention policy, the policy is got and
+// updated as is but the rules and schedule given.
+//
+// params:
+//   id - (REQUIRED) The ID of the tag retention policy.
+//   rule - The new rules of the policy.
+//   file - The policy file of the new rules.
+//   cron - The new cron expression of the schedule.
+//   no-schedule - Unschedule the policy.
+//
+// format:
+//   GET /retentions/{id}
+//   PUT /retentions/{id}
+//
+// e.g. curl -X PUT --header 'Content-Type: application/json' -d '{"id": 1, "algorithm": "or", "rules": [...], "trigger": {"kind": "Schedule", "settings": {"cron": ""}}, "scope": {"level": "project", "ref": 1}}' 'https://localhost/api/v2.0/retentions/1'
+func PutRetention(c *harbor.Client, opt *RetentionUpdate) (*harbor.Result, error) {
+	if opt.Cron != "" && opt.NoSchedule {
+		return nil, errors.New("--cron and --no-schedule are exclusive")
+	}
+	var file *model.RetentionPolicy
+	if opt.File != "" {
+		file = &model.RetentionPolicy{}
+		if err := utils.SpecLoad(opt.File, file); err != nil {
+			return nil, err
+		}
+	}
+	rules, err := retentionRules(opt.Rule, file)
+	if err != nil {
+		return nil, err
+	}
+	if (opt.File != "" || len(opt.Rule) > 0) && len(rules) == 0 {
+		return nil, errors.New("no rule in --file, a policy without rules retains nothing")
+	}
+
+	targetURL := retentionURL(c, opt.ID)
+	c.Trace("==> GET", targetURL)
+
+	var policy map[string]interface{}
+	if err := c.GetJSON(targetURL, &policy); err != nil {
+		return nil, err
+	}
+	if len(rules) > 0 {
+		policy["rules"] = rules
+	}
+	if opt.Cron != "" || opt.NoSchedule {
+		policy["trigger"] = retentionSchedule(opt.Cron)
+	}
+
+	c.Trace("==> PUT", targetURL)
+	return c.Do(c.Put(targetURL).Send(policy))
+}
+
+// RetentionRun holds the parameters of PostRetentionExecution.
+type RetentionRun struct {
+	ID     int  `short:"i" long:"id" description:"(REQUIRED) The ID of the tag retention policy." required:"yes"`
+	DryRun bool `long:"dry-run" description:"Tell the artifacts which would be deleted, in the logs of the tasks, without deleting them."`
+}
+
+func (x *RetentionRun) Execute(args []string) error {
+	return utils.PrintResult(PostRetentionExecution(utils.NewClient(), x))
+}
+
+// PostRetentionExecution triggers an execution of a tag retention policy.
+//
+// params:
+//   id - (REQUIRED) The ID of the tag retention policy.
+//   dry-run - Delete nothing.
+//
+// format:
+//   POST /retentions/{id}/executions
+//
+// e.g. curl -X POST --header 'Content-Type: application/json' -d '{"dry_run": true}' 'https://localhost/api/v2.0/retentions/1/executions'
+func PostRetentionExecution(c *harbor.Client, opt *RetentionRun) (*harbor.Result, error) {
+	targetURL := retentionURL(c, opt.ID) + "/executions"
+	c.Trace("==> POST", targetURL)
+
+	return c.Do(c.Post(targetURL).
+		Send(map[string]interface{}{"dry_run": opt.DryRun}))
+}
+
+// RetentionSimulate holds the parameters of SimulateRetention.
+type RetentionSimulate struct {
+	ID       int `short:"i" long:"id" description:"(REQUIRED) The ID of the tag retention policy." required:"yes"`
+	Interval int `long:"interval" description:"Polling interval in seconds while the dry run goes on." default:"5" validate:"min=1"`
+	Timeout  int `long:"timeout" description:"Seconds to wait for the dry run, 0 for no limit." default:"0"`
+}
+
+func (x *RetentionSimulate) Execute(args []string) error {
+	s, err := SimulateRetention(utils.NewClient(), x)
+	return utils.PrintValue(s, err, func() { utils.PrintRetentionSimulation(s) })
+}
+
+// retentionEnded maps the status of the executions and tasks which ended
+// to whether they succeeded.
+var retentionEnded = map[string]bool{
+	"Succeed": true,
+	"Success": true,
+	"Failed":  false,
+	"Error":   false,
+	"Stopped": false,
+}
+
+// SimulateRetention runs a tag retention policy as a dry run, waits for it
+// to end, and returns what it would keep and delete, from the logs of its
+// tasks.
+//
+// format:
+//   POST /retentions/{id}/executions
+//   GET /retentions/{id}/executions
+//   GET /retentions/{id}/executions/{execution_id}/tasks
+//   GET /retentions/{id}/executions/{execution_id}/tasks/{task_id}
+func SimulateRetention(c *harbor.Client, opt *RetentionSimulate) (*utils.RetentionSimulation, error) {
+	if opt.Interval <= 0 {
+		return nil, fmt.Errorf("--interval must be positive")
+	}
+	res, err := PostRetentionExecution(c, &RetentionRun{ID: opt.ID, DryRun: true})
+	if err != nil {
+		return nil, err
+	}
+	executionsURL := retentionURL(c, opt.ID) + "/executions"
+
+	// Harbor tells the execution started in the Location header, but for
+	// some versions; it is the latest one otherwise.
+	eid, err := strconv.ParseInt(path.Base(res.Header.Get("Location")), 10, 64)
+	if err != nil {
+		var latest []*model.RetentionExecution
+		if err := c.GetJSON(executionsURL+"?page=1&page_size=1", &latest); err != nil {
+			return nil, err
+		}
+		if len(latest) == 0 {
+			return nil, fmt.Errorf("no execution of retention policy %d found", opt.ID)
+		}
+		eid = latest[0].ID
+	}
+	c.Status("retention dry run %d started", eid)
+
+	if err := waitRetentionExecution(c, executionsURL, eid,
+		time.Duration(opt.Interval)*time.Second, time.Duration(opt.Timeout)*time.Second); err != nil {
+		return nil, err
+	}
+
+	s := &utils.RetentionSimulation{PolicyID: int64(opt.ID), ExecutionID: eid}
+	tasksURL := executionsURL + "/" + strconv.FormatInt(eid, 10) + "/tasks"
+	c.Trace("==> GET", tasksURL)
+	err = c.EachPage(tasksURL, func(item json.RawMessage) error {
+		var t model.RetentionTask
+		if err := json.Unmarshal(item, &t); err != nil {
+			return err
+		}
+		logURL := tasksURL + "/" + strconv.FormatInt(t.ID, 10)
+		c.Trace("==> GET", logURL)
+		log, err := c.Do(c.Get(logURL))
+		if err != nil {
+			return err
+		}
+		s.Repositories = append(s.Repositories, &utils.RetentionSimulationRepository{
+			Name:      t.Repository,
+			Status:    t.Status,
+			Artifacts: retentionLogTags(log.Body),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(s.Repositories, func(i, j int) bool { return s.Repositories[i].Name < s.Repositories[j].Name })
+	return s, nil
+}
+
+// waitRetentionExecution waits for the execution eid, among those listed
+// by executionsURL, to end. There is no endpoint for a single execution.
+func waitRetentionExecution(c *harbor.Client, executionsURL string, eid int64, interval, timeout time.Duration) error {
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		deadline = time.After(timeout)
+	}
+
+	last := ""
+	for {
+		var executions []*model.RetentionExecution
+		if err := c.GetJSON(executionsURL+"?page=1&page_size=20", &executions); err != nil {
+			return err
+		}
+		var e *model.RetentionExecution
+		for _, x := range executions {
+			if x.ID == eid {
+				e = x
+				break
+			}
+		}
+		if e == nil {
+			return fmt.Errorf("retention execution %d not found", eid)
+		}
+
+		if e.Status != last {
+			c.Status("retention dry run %d: %s", eid, e.Status)
+			last = e.Status
+		}
+		if ok, ended := retentionEnded[e.Status]; ended {
+			if !ok {
+				return fmt.Errorf("retention dry run %d ended with status %s, see retention_tasks_list -e %d", eid, e.Status, eid)
+			}
+			return nil
+		}
+
+		select {
+		case <-c.Context().Done():
+			return c.Context().Err()
+		case <-deadline:
+			return fmt.Errorf("retention dry run %d not ended in %s", eid, timeout)
+		case <-time.After(interval):
+		}
+	}
+}
+
+// retentionActions maps the marks of the retention column of a task log to
+// the actions of a simulation.
+var retentionActions = map[string]string{
+	"RETAIN":    "keep",
+	"DEL":       "delete",
+	"IMMUTABLE": "immutable",
+	"ERR":       "error",
+}
+
+// retentionLogTags returns the tags of the table logged by a retention
+// task, whose columns are, e.g.:
+//
+//  |  DIGEST  |  TAG  |  KIND  |  LABELS  |  PUSHEDTIME  |  PULLEDTIME  |  CREATEDTIME  |  RETENTION  |
+//
+// Columns are found by name, lines of other shapes are skipped.
+func retentionLogTags(log []byte) []*utils.RetentionSimulationTag {
+	var tags []*utils.RetentionSimulationTag
+	columns := map[string]int{}
+	for _, line := range strings.Split(string(log), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "|") || strings.Trim(line, "|-+ ") == "" {
+			continue
+		}
+		cells := strings.Split(strings.Trim(line, "|"), "|")
+		for i := range cells {
+			cells[i] = strings.TrimSpace(cells[i])
+		}
+
+		if _, ok := columns["RETENTION"]; !ok {
+			for i, name := range cells {
+				columns[strings.ToUpper(name)] = i
+			}
+			if _, ok := columns["TAG"]; !ok {
+				columns = map[string]int{}
+			}
+			continue
+		}
+		if len(cells) <= columns["RETENTION"] || len(cells) <= columns["TAG"] {
+			continue
+		}
+
+		t := &utils.RetentionSimulationTag{Tag: cells[columns["TAG"]]}
+		if t.Tag == "" {
+			t.Tag = "<none>"
+		}
+		if i, ok := columns["DIGEST"]; ok && i < len(cells) {
+			t.Digest = cells[i]
+		}
+		mark := strings.ToUpper(cells[columns["RETENTION"]])
+		if t.Action = retentionActions[mark]; t.Action == "" {
+			t.Action = strings.ToLower(mark)
+		}
+		tags = append(tags, t)
+	}
+	return tags
+}
+
+// RetentionExecutionsList holds the parameters of GetRetentionExecutions.
+type RetentionExecutionsList struct {
+	ID       int  `short:"i" long:"id" description:"(REQUIRED) The ID of the tag retention policy." required:"yes"`
+	Page     int  `short:"p" long:"page" description:"The page nubmer, default is 1." default:"1"`
+	PageSize int  `short:"s" long:"page_size" description:"The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs." default:"10" validate:"pagesize"`
+	Count    bool `long:"count" description:"Print the total number of matched items only."`
+	All      bool `long:"all" description:"Fetch all pages, --page and --page_size are ignored."`
+}
+
+func (x *RetentionExecutionsList) Execute(args []string) error {
+	return utils.ListPrinter(x.Count)(GetRetentionExecutions(utils.NewClient(), x))
+}
+
+// GetRetentionExecutions lists the executions of a tag retention policy.
+//
+// params:
+//   id - (REQUIRED) The ID of the tag retention policy.
+//   page - The page nubmer, default is 1.
+//   page_size - The size of per page, default is 10.
+//
+// format:
+//   GET /retentions/{id}/executions
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/retentions/1/executions?page=1&page_size=10'
+//
+func GetRetentionExecutions(c *harbor.Client, opt *RetentionExecutionsList) (*harbor.Result, error) {
+	if opt.Count {
+		opt.Page, opt.PageSize = 1, 1
+	}
+
+	targetURL := retentionURL(c, opt.ID) + "/executions?page=" + strconv.Itoa(opt.Page) +
+		"&page_size=" + strconv.Itoa(opt.PageSize)
+
+	if !opt.Count {
+		c.Trace("==> GET", targetURL)
+	}
+
+	if opt.All && !opt.Count {
+		return c.StreamAllPages(targetURL), nil
+	}
+
+	return c.DoStream(c.Get(targetURL))
+}
+
+// RetentionTasksList holds the parameters of GetRetentionTasks.
+type RetentionTasksList struct {
+	ID          int  `short:"i" long:"id" description:"(REQUIRED) The ID of the tag retention policy." required:"yes"`
+	ExecutionID int  `short:"e" long:"execution_id" description:"(REQUIRED) The ID of the execution." required:"yes"`
+	Page        int  `short:"p" long:"page" description:"The page nubmer, default is 1." default:"1"`
+	PageSize    int  `short:"s" long:"page_size" description:"The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs." default:"10" validate:"pagesize"`
+	Count       bool `long:"count" description:"Print the total number of matched items only."`
+	All         bool `long:"all" description:"Fetch all pages, --page and --page_size are ignored."`
+}
+
+func (x *RetentionTasksList) Execute(args []string) error {
+	return utils.ListPrinter(x.Count)(GetRetentionTasks(utils.NewClient(), x))
+}
+
+// GetRetentionTasks lists the tasks of an execution of a tag retention
+// policy.
+//
+// params:
+//   id - (REQUIRED) The ID of the tag retention policy.
+//   execution_id - (REQUIRED) The ID of the execution.
+//   page - The page nubmer, default is 1.
+//   page_size - The size of per page, default is 10.
+//
+// format:
+//   GET /retentions/{id}/executions/{execution_id}/tasks
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/retentions/1/executions/5/tasks?page=1&page_size=10'
+//
+func GetRetentionTasks(c *harbor.Client, opt *RetentionTasksList) (*harbor.Result, error) {
+	if opt.Count {
+		opt.Page, opt.PageSize = 1, 1
+	}
+
+	targetURL := retentionURL(c, opt.ID) + "/executions/" + strconv.Itoa(opt.ExecutionID) +
+		"/tasks?page=" + strconv.Itoa(opt.Page) + "&page_size=" + strconv.Itoa(opt.PageSize)
+
+	if !opt.Count {
+		c.Trace("==> GET", targetURL)
+	}
+
+	if opt.All && !opt.Count {
+		return c.StreamAllPages(targetURL), nil
+	}
+
+	return c.DoStream(c.Get(targetURL))
+}
+
+// RetentionTaskLog holds the parameters of GetRetentionTaskLog.
+type RetentionTaskLog struct {
+	ID          int `short:"i" long:"id" description:"(REQUIRED) The ID of the tag retention policy." required:"yes"`
+	ExecutionID int `short:"e" long:"execution_id" description:"(REQUIRED) The ID of the execution." required:"yes"`
+	TaskID      int `short:"t" long:"task_id" description:"(REQUIRED) The ID of the task." required:"yes"`
+}
+
+func (x *RetentionTaskLog) Execute(args []string) error {
+	return utils.PrintResult(GetRetentionTaskLog(utils.NewClient(), x))
+}
+
+// GetRetentionTaskLog gets the log of a task of a tag retention policy.
+//
+// params:
+//   id - (REQUIRED) The ID of the tag retention policy.
+//   execution_id - (REQUIRED) The ID of the execution.
+//   task_id - (REQUIRED) The ID of the task.
+//
+// format:
+//   GET /retentions/{id}/executions/{execution_id}/tasks/{task_id}
+//
+// e.g. curl -X GET --header 'Accept: text/plain' 'https://localhost/api/v2.0/retentions/1/executions/5/tasks/8'
+//
+func GetRetentionTaskLog(c *harbor.Client, opt *RetentionTaskLog) (*harbor.Result, error) {
+	targetURL := retentionURL(c, opt.ID) + "/executions/" + strconv.Itoa(opt.ExecutionID) +
+		"/tasks/" + strconv.Itoa(opt.TaskID)
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+func TestRetentionLogTags(t *testing.T) {
+	tests := []struct {
+		name string
+		log  string
+		want []*utils.RetentionSimulationTag
+	}{
+		{
+			name: "harbor table",
+			log: `
++--------------------------------------------------------------------------------------------------------------+
+|   Digest   |  Tag   | Kind  | Labels |     PushedTime      |     PulledTime      |     CreatedTime     | Retention |
++--------------------------------------------------------------------------------------------------------------+
+| sha256:aaa | latest | image |        | 2020/01/02 03:04:05 | 2020/01/02 03:04:05 | 2020/01/02 03:04:05 |  RETAIN   |
+| sha256:bbb | 1.0    | image | prod   | 2020/01/01 03:04:05 |                     | 2020/01/01 03:04:05 |    DEL    |
+| sha256:ccc | 0.9    | image |        | 2019/01/01 03:04:05 |                     | 2019/01/01 03:04:05 | IMMUTABLE |
+| sha256:ddd |        | image |        | 2019/01/01 03:04:05 |                     | 2019/01/01 03:04:05 |    ERR    |
++--------------------------------------------------------------------------------------------------------------+
+`,
+			want: []*utils.RetentionSimulationTag{
+				{Tag: "latest", Digest: "sha256:aaa", Action: "keep"},
+				{Tag: "1.0", Digest: "sha256:bbb", Action: "delete"},
+				{Tag: "0.9", Digest: "sha256:ccc", Action: "immutable"},
+				{Tag: "<none>", Digest: "sha256:ddd", Action: "error"},
+			},
+		},
+		{
+			name: "columns found by name",
+			log: "2020-01-02T03:04:05Z [INFO] run retention\n" +
+				"| RETENTION | TAG |\n" +
+				"|-----------|-----|\n" +
+				"| retain | v1 |\n" +
+				"| Skip | v2 |\n",
+			want: []*utils.RetentionSimulationTag{
+				{Tag: "v1", Action: "keep"},
+				{Tag: "v2", Action: "skip"},
+			},
+		},
+		{
+			name: "short lines skipped",
+			log: "| Digest | Tag | Retention |\n" +
+				"| sha256:aaa | v1 |\n" +
+				"| sha256:bbb | v2 | DEL |\n",
+			want: []*utils.RetentionSimulationTag{
+				{Tag: "v2", Digest: "sha256:bbb", Action: "delete"},
+			},
+		},
+		{
+			name: "no table",
+			log:  "2020-01-02T03:04:05Z [INFO] no candidates\n| a | b |\n",
+		},
+		{
+			name: "empty",
+		},
+	}
+	for _, tt := range tests {
+		if got := retentionLogTags([]byte(tt.log)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
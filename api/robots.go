@@ -0,0 +1,685 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+func init() {
+	utils.Parser.AddCommand("robot_create",
+		"Create a robot account of a project.",
+		"This endpoint creates a robot account of a project, with the permissions given by --access. Its secret is returned only once: --print secret prints it alone, --print env prints HARBOR_USERNAME and HARBOR_PASSWORD for the shell, for CI pipelines. (Harbor v1.8+)",
+		&RobotCreate{})
+	utils.Parser.AddCommand("robots_list",
+		"List the robot accounts of a project.",
+		"This endpoint lists the robot accounts of a project. (Harbor v1.8+)",
+		&RobotsList{})
+	utils.Parser.AddCommand("robot_get",
+		"Get a robot account of a project.",
+		"This endpoint gets a robot account of a project by specific ID. (Harbor v1.8+)",
+		&RobotGet{})
+	utils.Parser.AddCommand("robot_enable",
+		"Enable a robot account of a project.",
+		"This endpoint enables a robot account of a project by specific ID, its other settings are kept. (Harbor v1.8+)",
+		&RobotEnable{})
+	utils.Parser.AddCommand("robot_disable",
+		"Disable a robot account of a project.",
+		"This endpoint disables a robot account of a project by specific ID, its other settings are kept. (Harbor v1.8+)",
+		&RobotDisable{})
+	utils.Parser.AddCommand("robot_del",
+		"Delete a robot account of a project.",
+		"This endpoint deletes a robot account of a project by specific ID. (Harbor v1.8+)",
+		&RobotDel{})
+	utils.Parser.AddCommand("robot_refresh_secret",
+		"Regenerate the secret of a robot account.",
+		"This endpoint regenerates the secret of a robot account, or sets it to --secret, and returns it once, see --print of robot_create. (Harbor v2.2+, uses v2.0 API)",
+		&RobotRefreshSecret{})
+	utils.Parser.AddCommand("system_robot_create",
+		"Create a system robot account.",
+		"This endpoint creates a system robot account, with permissions on projects given by --permission or a permissions file. Its secret is returned only once, see --print of robot_create. (Harbor v2.2+, uses v2.0 API)",
+		&SystemRobotCreate{})
+	utils.Parser.AddCommand("system_robots_list",
+		"List the system robot accounts.",
+		"This endpoint lists the system robot accounts. (Harbor v2.2+, uses v2.0 API)",
+		&SystemRobotsList{})
+	utils.Parser.AddCommand("system_robot_get",
+		"Get a system robot account.",
+		"This endpoint gets a system robot account by specific ID, with its permissions. (Harbor v2.2+, uses v2.0 API)",
+		&SystemRobotGet{})
+	utils.Parser.AddCommand("system_robot_update",
+		"Update a system robot account.",
+		"This endpoint updates a system robot account by specific ID: its description, expiration, state, or its permissions, replaced by those given by --permission or a permissions file. Its other settings are kept. (Harbor v2.2+, uses v2.0 API)",
+		&SystemRobotUpdate{})
+	utils.Parser.AddCommand("system_robot_del",
+		"Delete a system robot account.",
+		"This endpoint deletes a system robot account by specific ID. (Harbor v2.2+, uses v2.0 API)",
+		&SystemRobotDel{})
+	utils.RequireAdmin("system_robot_create", "system_robots_list", "system_robot_get", "system_robot_update", "system_robot_del")
+	utils.ResponseModel("robots_list", model.Robot{})
+	utils.ResponseModel("robot_get", model.Robot{})
+	utils.ResponseModel("system_robots_list", model.Robot{})
+	utils.ResponseModel("system_robot_get", model.Robot{})
+}
+
+// RobotCreate holds the parameters of PostRobot.
+type RobotCreate struct {
+	ProjectID   int      `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
+	Name        string   `short:"n" long:"name" description:"(REQUIRED) The name of the robot account, Harbor prefixes it with 'robot$'." required:"yes"`
+	Description string   `short:"d" long:"description" description:"The description of the robot account."`
+	Access      []string `short:"a" long:"access" description:"(REQUIRED) A permission as resource:action, may be repeated, e.g. 'repository:pull', 'repository:push', 'helm-chart:read', 'helm-chart-version:create'." required:"yes"`
+	ExpiresDays int      `short:"e" long:"expires_days" description:"Days before the robot account expires, 0 for the system default, -1 for never (Harbor v2.2+)." default:"0"`
+	Print       string   `long:"print" description:"Print the secret only, alone or as HARBOR_USERNAME and HARBOR_PASSWORD shell variables, instead of the response." choice:"secret" choice:"env"`
+}
+
+func (x *RobotCreate) Execute(args []string) error {
+	if x.Print == "" {
+		return utils.PrintResult(PostRobot(utils.NewClient(), x))
+	}
+	res, err := PostRobot(utils.NewDataClient(), x)
+	if err != nil {
+		return utils.PrintResult(res, err)
+	}
+	return printRobotSecret(res, x.Print)
+}
+
+// robotCreated is the response of the creation of a robot account, or of
+// the regeneration of its secret: the v1 API returns the secret as token.
+type robotCreated struct {
+	Name   string `json:"name"`
+	Token  string `json:"token"`
+	Secret string `json:"secret"`
+}
+
+// printRobotSecret prints the secret of a robot account from res, alone
+// or, with format env, as shell variables with its name, for the
+// HARBOR_USERNAME and HARBOR_PASSWORD environment variables of the clients.
+func printRobotSecret(res *harbor.Result, format string) error {
+	var r robotCreated
+	if err := res.Decode(&r); err != nil {
+		return err
+	}
+	secret := r.Secret
+	if secret == "" {
+		secret = r.Token
+	}
+	if secret == "" {
+		return errors.New("no secret in the response")
+	}
+
+	if format == "secret" {
+		fmt.Println(secret)
+		return nil
+	}
+	if r.Name != "" {
+		fmt.Printf("HARBOR_USERNAME=%s\n", shellQuote(r.Name))
+	}
+	fmt.Printf("HARBOR_PASSWORD=%s\n", shellQuote(secret))
+	return nil
+}
+
+// shellQuote quotes s for POSIX shells, robot names contain '$'.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// PostRobot creates a robot account of a project.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   name - (REQUIRED) The name of the robot account.
+//   description - The description of the robot account.
+//   access - (REQUIRED) The permissions, as resource:action.
+//   expires_days - Days before the robot account expires.
+//
+// format:
+//   POST /projects/{project_id}/robots
+//
+// e.g.
+/*
+  curl -X POST --header 'Content-Type: application/json' --header 'Accept: application/json' -d '{ \
+     "name": "ci", \
+     "description": "", \
+     "expires_at": 1735689600, \
+     "access": [{"resource": "/project/1/repository", "action": "push"}] \
+   }' 'https://localhost/api/projects/1/robots'
+*/
+func PostRobot(c *harbor.Client, opt *RobotCreate) (*harbor.Result, error) {
+	if opt.ExpiresDays < -1 {
+		return nil, fmt.Errorf("--expires_days: -1 or more expected, got %d", opt.ExpiresDays)
+	}
+	body := struct {
+		Name        string             `json:"name"`
+		Description string             `json:"description"`
+		ExpiresAt   int64              `json:"expires_at,omitempty"`
+		Access      []model.Permission `json:"access"`
+	}{Name: opt.Name, Description: opt.Description}
+	switch {
+	case opt.ExpiresDays > 0:
+		body.ExpiresAt = time.Now().AddDate(0, 0, opt.ExpiresDays).Unix()
+	case opt.ExpiresDays < 0:
+		body.ExpiresAt = -1
+	}
+	for _, a := range opt.Access {
+		i := strings.LastIndexByte(a, ':')
+		if i <= 0 || i == len(a)-1 {
+			return nil, fmt.Errorf("--access: resource:action expected, got %q", a)
+		}
+		body.Access = append(body.Access, model.Permission{
+			Resource: "/project/" + strconv.Itoa(opt.ProjectID) + "/" + strings.TrimPrefix(a[:i], "/"),
+			Action:   a[i+1:],
+		})
+	}
+
+	targetURL := robotsURL(c, opt.ProjectID)
+	c.Trace("==> POST", targetURL)
+
+	return c.Do(c.Post(targetURL).
+		Send(body))
+}
+
+// robotsURL returns the URL of the robot accounts of the project.
+func robotsURL(c *harbor.Client, projectID int) string {
+	return c.APIURL("/projects") + "/" + strconv.Itoa(projectID) + "/robots"
+}
+
+// RobotsList holds the parameters of GetRobots.
+type RobotsList struct {
+	ProjectID int  `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
+	Page      int  `short:"p" long:"page" description:"The page nubmer, default is 1." default:"1"`
+	PageSize  int  `short:"s" long:"page_size" description:"The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs." default:"10" validate:"pagesize"`
+	Count     bool `long:"count" description:"Print the total number of matched items only."`
+	All       bool `long:"all" description:"Fetch all pages, --page and --page_size are ignored."`
+}
+
+func (x *RobotsList) Execute(args []string) error {
+	return utils.ListPrinter(x.Count)(GetRobots(utils.NewClient(), x))
+}
+
+// GetRobots lists the robot accounts of a project.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   page - The page nubmer, default is 1.
+//   page_size - The size of per page, default is 10.
+//
+// format:
+//   GET /projects/{project_id}/robots
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/projects/1/robots?page=1&page_size=10'
+//
+func GetRobots(c *harbor.Client, opt *RobotsList) (*harbor.Result, error) {
+	if opt.Count {
+		opt.Page, opt.PageSize = 1, 1
+	}
+
+	targetURL := robotsURL(c, opt.ProjectID) + "?page=" + strconv.Itoa(opt.Page) +
+		"&page_size=" + strconv.Itoa(opt.PageSize)
+
+	if !opt.Count {
+		c.Trace("==> GET", targetURL)
+	}
+
+	if opt.All && !opt.Count {
+		return c.StreamAllPages(targetURL), nil
+	}
+
+	return c.DoStream(c.Get(targetURL))
+}
+
+// RobotGet holds the parameters of GetRobot.
+type RobotGet struct {
+	ProjectID int `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
+	ID        int `short:"i" long:"robot_id" description:"(REQUIRED) The ID of the robot account." required:"yes"`
+}
+
+func (x *RobotGet) Execute(args []string) error {
+	return utils.PrintResult(GetRobot(utils.NewClient(), x))
+}
+
+// GetRobot gets a robot account of a project.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   robot_id - (REQUIRED) The ID of the robot account.
+//
+// format:
+//   GET /projects/{project_id}/robots/{robot_id}
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/projects/1/robots/2'
+//
+func GetRobot(c *harbor.Client, opt *RobotGet) (*harbor.Result, error) {
+	targetURL := robotsURL(c, opt.ProjectID) + "/" + strconv.Itoa(opt.ID)
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
+}
+
+// RobotEnable holds the parameters of the robot_enable command.
+type RobotEnable struct {
+	ProjectID int `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
+	ID        int `short:"i" long:"robot_id" description:"(REQUIRED) The ID of the robot account." required:"yes"`
+}
+
+func (x *RobotEnable) Execute(args []string) error {
+	return utils.PrintResult(PutRobotDisabled(utils.NewClient(), x.ProjectID, x.ID, false))
+}
+
+// RobotDisable holds the parameters of the robot_disable command.
+type RobotDisable struct {
+	ProjectID int `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
+	ID        int `short:"i" long:"robot_id" description:"(REQUIRED) The ID of the robot account." required:"yes"`
+}
+
+func (x *RobotDisable) Execute(args []string) error {
+	return utils.PrintResult(PutRobotDisabled(utils.NewClient(), x.ProjectID, x.ID, true))
+}
+
+// PutRobotDisabled disables or enables a robot account of a project, the
+// robot account is got and updated as is but its state: "disabled" with
+// v1 API, "disable" for Harbor v2.2+.
+//
+// format:
+//   GET /projects/{project_id}/robots/{robot_id}
+//   PUT /projects/{project_id}/robots/{robot_id}
+//
+// e.g. curl -X PUT --header 'Content-Type: application/json' -d '{"disabled": true}' 'https://localhost/api/projects/1/robots/2'
+func PutRobotDisabled(c *harbor.Client, projectID, id int, disabled bool) (*harbor.Result, error) {
+	targetURL := robotsURL(c, projectID) + "/" + strconv.Itoa(id)
+	c.Trace("==> GET", targetURL)
+
+	var robot map[string]interface{}
+	if err := c.GetJSON(targetURL, &robot); err != nil {
+		return nil, err
+	}
+	if _, ok := robot["disable"]; ok {
+		robot["disable"] = disabled
+	} else {
+		robot["disabled"] = disabled
+	}
+
+	c.Trace("==> PUT", targetURL)
+	return c.Do(c.Put(targetURL).Send(robot))
+}
+
+// RobotDel holds the parameters of DeleteRobot.
+type RobotDel struct {
+	ProjectID int `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
+	ID        int `short:"i" long:"robot_id" description:"(REQUIRED) The ID of the robot account." required:"yes"`
+}
+
+func (x *RobotDel) Execute(args []string) error {
+	return utils.PrintResult(DeleteRobot(utils.NewClient(), x))
+}
+
+// DeleteRobot deletes a robot account of a project.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   robot_id - (REQUIRED) The ID of the robot account.
+//
+// format:
+//   DELETE /projects/{project_id}/robots/{robot_id}
+//
+// e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/projects/1/robots/2'
+func DeleteRobot(c *harbor.Client, opt *RobotDel) (*harbor.Result, error) {
+	targetURL := robotsURL(c, opt.ProjectID) + "/" + strconv.Itoa(opt.ID)
+	c.Trace("==> DELETE", targetURL)
+
+	return c.Do(c.Delete(targetURL))
+}
+
+// RobotRefreshSecret holds the parameters of PatchRobotSecret.
+type RobotRefreshSecret struct {
+	ID     int    `short:"i" long:"robot_id" description:"(REQUIRED) The ID of the robot account." required:"yes"`
+	Secret string `long:"secret" description:"The new secret, generated by Harbor if not set."`
+	Print  string `long:"print" description:"Print the secret only, alone or as HARBOR_PASSWORD shell variable, instead of the response." choice:"secret" choice:"env"`
+}
+
+func (x *RobotRefreshSecret) Execute(args []string) error {
+	if x.Print == "" {
+		return utils.PrintResult(PatchRobotSecret(utils.NewClient(), x))
+	}
+	res, err := PatchRobotSecret(utils.NewDataClient(), x)
+	if err != nil {
+		return utils.PrintResult(res, err)
+	}
+	return printRobotSecret(res, x.Print)
+}
+
+// PatchRobotSecret regenerates the secret of a robot account, or sets it.
+//
+// params:
+//   robot_id - (REQUIRED) The ID of the robot account.
+//   secret - The new secret, generated by Harbor if empty.
+//
+// format:
+//   PATCH /robots/{robot_id}
+//
+// e.g. curl -X PATCH --header 'Content-Type: application/json' --header 'Accept: application/json' -d '{"secret": ""}' 'https://localhost/api/v2.0/robots/2'
+func PatchRobotSecret(c *harbor.Client, opt *RobotRefreshSecret) (*harbor.Result, error) {
+	if !c.IsV2() {
+		return nil, errors.New("robot_refresh_secret requires the v2.0 API (Harbor v2.2+)")
+	}
+	targetURL := c.URL("/api/v2.0/robots") + "/" + strconv.Itoa(opt.ID)
+	c.Trace("==> PATCH", targetURL)
+
+	return c.Do(c.Request(http.MethodPatch, targetURL).
+		Send(map[string]string{"secret": opt.Secret}))
+}
+
+// SystemRobotCreate holds the parameters of PostSystemRobot.
+type SystemRobotCreate struct {
+	Name        string   `short:"n" long:"name" description:"(REQUIRED) The name of the robot account, Harbor prefixes it with 'robot$'." required:"yes"`
+	Description string   `short:"d" long:"description" description:"The description of the robot account."`
+	Permission  []string `long:"permission" description:"A permission as project=NAME,resource=RESOURCE,action=ACTION, may be repeated, e.g. 'project=library,resource=repository,action=pull'; project=* is all the projects."`
+	File        string   `short:"f" long:"file" description:"A JSON or YAML permissions file (or @file, - for stdin): a list of {kind: project, namespace: NAME, access: [{resource, action}]}, as in the output of system_robot_get."`
+	ExpiresDays int      `short:"e" long:"expires_days" description:"Days before the robot account expires, 0 for the system default, -1 for never." default:"0"`
+	Print       string   `long:"print" description:"Print the secret only, alone or as HARBOR_USERNAME and HARBOR_PASSWORD shell variables, instead of the response." choice:"secret" choice:"env"`
+}
+
+func (x *SystemRobotCreate) Execute(args []string) error {
+	if x.Print == "" {
+		return utils.PrintResult(PostSystemRobot(utils.NewClient(), x))
+	}
+	res, err := PostSystemRobot(utils.NewDataClient(), x)
+	if err != nil {
+		return utils.PrintResult(res, err)
+	}
+	return printRobotSecret(res, x.Print)
+}
+
+// PostSystemRobot creates a system robot account.
+//
+// params:
+//   name - (REQUIRED) The name of the robot account.
+//   description - The description of the robot account.
+//   permission - The permissions, as project=NAME,resource=RESOURCE,action=ACTION.
+//   file - The permissions file.
+//   expires_days - Days before the robot account expires.
+//
+// format:
+//   POST /robots
+//
+// e.g.
+/*
+  curl -X POST --header 'Content-Type: application/json' --header 'Accept: application/json' -d '{ \
+     "name": "ci", \
+     "description": "", \
+     "level": "system", \
+     "duration": -1, \
+     "disable": false, \
+     "permissions": [{"kind": "project", "namespace": "library", "access": [{"resource": "repository", "action": "pull"}]}] \
+   }' 'https://localhost/api/v2.0/robots'
+*/
+func PostSystemRobot(c *harbor.Client, opt *SystemRobotCreate) (*harbor.Result, error) {
+	if !c.IsV2() {
+		return nil, errors.New("system_robot_create requires the v2.0 API (Harbor v2.2+)")
+	}
+	if opt.ExpiresDays < -1 {
+		return nil, fmt.Errorf("--expires_days: -1 or more expected, got %d", opt.ExpiresDays)
+	}
+	permissions, err := robotPermissions(opt.Permission, opt.File)
+	if err != nil {
+		return nil, err
+	}
+	if len(permissions) == 0 {
+		return nil, errors.New("no permission, set --permission or --file")
+	}
+
+	body := struct {
+		Name        string                  `json:"name"`
+		Description string                  `json:"description"`
+		Level       string                  `json:"level"`
+		Duration    int                     `json:"duration"`
+		Disable     bool                    `json:"disable"`
+		Permissions []model.RobotPermission `json:"permissions"`
+	}{
+		Name:        opt.Name,
+		Description: opt.Description,
+		Level:       "system",
+		Duration:    opt.ExpiresDays,
+		Permissions: permissions,
+	}
+
+	targetURL := c.URL("/api/v2.0/robots")
+	c.Trace("==> POST", targetURL)
+
+	return c.Do(c.Post(targetURL).
+		Send(body))
+}
+
+// robotPermissions returns the permissions of a system robot account from
+// the --permission flags, grouped by project in the order given, after
+// those of the permissions file if any.
+func robotPermissions(flags []string, file string) ([]model.RobotPermission, error) {
+	var permissions []model.RobotPermission
+	if file != "" {
+		if err := utils.SpecLoad(file, &permissions); err != nil {
+			return nil, err
+		}
+		for i := range permissions {
+			if permissions[i].Kind == "" {
+				permissions[i].Kind = "project"
+			}
+		}
+	}
+
+	for _, f := range flags {
+		kv := map[string]string{}
+		for _, field := range strings.Split(f, ",") {
+			i := strings.IndexByte(field, '=')
+			if i <= 0 {
+				return nil, fmt.Errorf("--permission: key=value expected, got %q in %q", field, f)
+			}
+			k := strings.TrimSpace(field[:i])
+			if k != "project" && k != "resource" && k != "action" {
+				return nil, fmt.Errorf("--permission: unknown key %q in %q, project, resource or action expected", k, f)
+			}
+			kv[k] = strings.TrimSpace(field[i+1:])
+		}
+		if kv["project"] == "" || kv["resource"] == "" || kv["action"] == "" {
+			return nil, fmt.Errorf("--permission: project=NAME,resource=RESOURCE,action=ACTION expected, got %q", f)
+		}
+
+		access := model.Permission{Resource: kv["resource"], Action: kv["action"]}
+		found := false
+		for i := range permissions {
+			if permissions[i].Kind == "project" && permissions[i].Namespace == kv["project"] {
+				permissions[i].Access = append(permissions[i].Access, access)
+				found = true
+				break
+			}
+		}
+		if !found {
+			permissions = append(permissions, model.RobotPermission{
+				Kind:      "project",
+				Namespace: kv["project"],
+				Access:    []model.Permission{access},
+			})
+		}
+	}
+	return permissions, nil
+}
+
+// SystemRobotsList holds the parameters of GetSystemRobots.
+type SystemRobotsList struct {
+	Page     int  `short:"p" long:"page" description:"The page nubmer, default is 1." default:"1"`
+	PageSize int  `short:"s" long:"page_size" description:"The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs." default:"10" validate:"pagesize"`
+	Count    bool `long:"count" description:"Print the total number of matched items only."`
+	All      bool `long:"all" description:"Fetch all pages, --page and --page_size are ignored."`
+}
+
+func (x *SystemRobotsList) Execute(args []string) error {
+	return utils.ListPrinter(x.Count)(GetSystemRobots(utils.NewClient(), x))
+}
+
+// GetSystemRobots lists the system robot accounts.
+//
+// params:
+//   page - The page nubmer, default is 1.
+//   page_size - The size of per page, default is 10.
+//
+// format:
+//   GET /robots?q=Level=system
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/robots?q=Level%3Dsystem&page=1&page_size=10'
+//
+func GetSystemRobots(c *harbor.Client, opt *SystemRobotsList) (*harbor.Result, error) {
+	if !c.IsV2() {
+		return nil, errors.New("system_robots_list requires the v2.0 API (Harbor v2.2+)")
+	}
+	if opt.Count {
+		opt.Page, opt.PageSize = 1, 1
+	}
+
+	targetURL := c.URL("/api/v2.0/robots") + "?q=" + url.QueryEscape("Level=system") +
+		"&page=" + strconv.Itoa(opt.Page) + "&page_size=" + strconv.Itoa(opt.PageSize)
+
+	if !opt.Count {
+		c.Trace("==> GET", targetURL)
+	}
+
+	if opt.All && !opt.Count {
+		return c.StreamAllPages(targetURL), nil
+	}
+
+	return c.DoStream(c.Get(targetURL))
+}
+
+// SystemRobotGet holds the parameters of GetSystemRobot.
+type SystemRobotGet struct {
+	ID int `short:"i" long:"robot_id" description:"(REQUIRED) The ID of the robot account." required:"yes"`
+}
+
+func (x *SystemRobotGet) Execute(args []string) error {
+	return utils.PrintResult(GetSystemRobot(utils.NewClient(), x))
+}
+
+// GetSystemRobot gets a system robot account.
+//
+// params:
+//   robot_id - (REQUIRED) The ID of the robot account.
+//
+// format:
+//   GET /robots/{robot_id}
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/robots/2'
+//
+func GetSystemRobot(c *harbor.Client, opt *SystemRobotGet) (*harbor.Result, error) {
+	if !c.IsV2() {
+		return nil, errors.New("system_robot_get requires the v2.0 API (Harbor v2.2+)")
+	}
+	targetURL := c.URL("/api/v2.0/robots") + "/" + strconv.Itoa(opt.ID)
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
+}
+
+// SystemRobotUpdate holds the parameters of PutSystemRobot.
+type SystemRobotUpdate struct {
+	ID          int      `short:"i" long:"robot_id" description:"(REQUIRED) The ID of the robot account." required:"yes"`
+	Description string   `short:"d" long:"description" description:"The new description of the robot account, unchanged if not set."`
+	Permission  []string `long:"permission" description:"A permission as project=NAME,resource=RESOURCE,action=ACTION, may be repeated; the permissions given replace all those of the robot account, unchanged if none."`
+	File        string   `short:"f" long:"file" description:"A JSON or YAML permissions file (or @file, - for stdin), see system_robot_create."`
+	ExpiresDays int      `short:"e" long:"expires_days" description:"Days before the robot account expires, from its creation, -1 for never, unchanged if not set." default:"0"`
+	Enable      bool     `long:"enable" description:"Enable the robot account."`
+	Disable     bool     `long:"disable" description:"Disable the robot account."`
+}
+
+func (x *SystemRobotUpdate) Execute(args []string) error {
+	return utils.PrintResult(PutSystemRobot(utils.NewClient(), x))
+}
+
+// PutSystemRobot updates a system robot account, got and updated as is
+// but the settings given.
+//
+// params:
+//   robot_id - (REQUIRED) The ID of the robot account.
+//   description - The new description of the robot account.
+//   permission - The permissions replacing those of the robot account.
+//   file - The permissions file.
+//   expires_days - Days before the robot account expires.
+//
+// format:
+//   GET /robots/{robot_id}
+//   PUT /robots/{robot_id}
+//
+// e.g. curl -X PUT --header 'Content-Type: application/json' -d '{"id": 2, "name": "robot$ci", "level": "system", "duration": 30, "disable": false, "permissions": [...]}' 'https://localhost/api/v2.0/robots/2'
+func PutSystemRobot(c *harbor.Client, opt *SystemRobotUpdate) (*harbor.Result, error) {
+	if !c.IsV2() {
+		return nil, errors.New("system_robot_update requires the v2.0 API (Harbor v2.2+)")
+	}
+	if opt.Enable && opt.Disable {
+		return nil, errors.New("--enable and --disable are exclusive")
+	}
+	if opt.ExpiresDays < -1 {
+		return nil, fmt.Errorf("--expires_days: -1 or more expected, got %d", opt.ExpiresDays)
+	}
+	permissions, err := robotPermissions(opt.Permission, opt.File)
+	if err != nil {
+		return nil, err
+	}
+
+	targetURL := c.URL("/api/v2.0/robots") + "/" + strconv.Itoa(opt.ID)
+	c.Trace("==> GET", targetURL)
+
+	var robot map[string]interface{}
+	if err := c.GetJSON(targetURL, &robot); err != nil {
+		return nil, err
+	}
+	if level, _ := robot["level"].(string); level != "system" {
+		return nil, fmt.Errorf("robot account %d is not a system robot account", opt.ID)
+	}
+	if opt.Description != "" {
+		robot["description"] = opt.Description
+	}
+	if len(permissions) > 0 {
+		robot["permissions"] = permissions
+	}
+	if opt.ExpiresDays != 0 {
+		robot["duration"] = opt.ExpiresDays
+	}
+	if opt.Enable || opt.Disable {
+		robot["disable"] = opt.Disable
+	}
+
+	c.Trace("==> PUT", targetURL)
+	return c.Do(c.Put(targetURL).Send(robot))
+}
+
+// SystemRobotDel holds the parameters of DeleteSystemRobot.
+type SystemRobotDel struct {
+	ID int `short:"i" long:"robot_id" description:"(REQUIRED) The ID of the robot account." required:"yes"`
+}
+
+func (x *SystemRobotDel) Execute(args []string) error {
+	return utils.PrintResult(DeleteSystemRobot(utils.NewClient(), x))
+}
+
+// DeleteSystemRobot deletes a system robot account.
+//
+// params:
+//   robot_id - (REQUIRED) The ID of the robot account.
+//
+// format:
+//   DELETE /robots/{robot_id}
+//
+// e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/v2.0/robots/2'
+func DeleteSystemRobot(c *harbor.Client, opt *SystemRobotDel) (*harbor.Result, error) {
+	if !c.IsV2() {
+		return nil, errors.New("system_robot_del requires the v2.0 API (Harbor v2.2+)")
+	}
+	targetURL := c.URL("/api/v2.0/robots") + "/" + strconv.Itoa(opt.ID)
+	c.Trace("==> DELETE", targetURL)
+
+	return c.Do(c.Delete(targetURL))
+}
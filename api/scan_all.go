@@ -0,0 +1,200 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+func init() {
+	utils.Parser.AddCommand("scan_all_run",
+		"Scan all the artifacts now.",
+		"This endpoint triggers a vulnerability scan of all the artifacts (images with v1 API), scan_all_metrics follows it. (Harbor v1.7+)",
+		&ScanAllRun{})
+	utils.Parser.AddCommand("scan_all_schedule_get",
+		"Get the schedule of the scan of all the artifacts.",
+		"This endpoint gets the schedule of the vulnerability scan of all the artifacts. (Harbor v1.7+)",
+		&ScanAllScheduleGet{})
+	utils.Parser.AddCommand("scan_all_schedule_set",
+		"Set the schedule of the scan of all the artifacts.",
+		"This endpoint sets the schedule of the vulnerability scan of all the artifacts: hourly, daily, weekly, by a cron expression, or none. (Harbor v1.7+)",
+		&ScanAllScheduleSet{})
+	utils.Parser.AddCommand("scan_all_metrics",
+		"Get the progress of the scan of all the artifacts.",
+		"This endpoint gets the progress of the last manual vulnerability scan of all the artifacts, or of the last scheduled one with --schedule: the number of artifacts scanned, by status. --wait follows it until it ends. (Harbor v1.10+)",
+		&ScanAllMetrics{})
+
+	utils.RequireAdmin("scan_all_run", "scan_all_schedule_get", "scan_all_schedule_set", "scan_all_metrics")
+	utils.RequireComponent("scan_all_run", utils.ComponentScanner)
+	utils.RequireComponent("scan_all_metrics", utils.ComponentScanner)
+	utils.ResponseModel("scan_all_metrics", model.ScanAllMetrics{})
+}
+
+// ScanAllRun is the scan_all_run command.
+type ScanAllRun struct {
+}
+
+func (x *ScanAllRun) Execute(args []string) error {
+	return utils.PrintResult(PostScanAllRun(utils.NewClient()))
+}
+
+// PostScanAllRun triggers a scan of all the artifacts, as a schedule of
+// type Manual.
+//
+// format:
+//   POST /system/scanAll/schedule
+//
+// e.g. curl -X POST --header 'Content-Type: application/json' -d '{"schedule": {"type": "Manual"}}' 'https://localhost/api/v2.0/system/scanAll/schedule'
+func PostScanAllRun(c *harbor.Client) (*harbor.Result, error) {
+	targetURL := c.APIURL("/system/scanAll/schedule")
+	c.Trace("==> POST", targetURL)
+
+	return c.Do(c.Post(targetURL).
+		Send(map[string]interface{}{"schedule": &model.GCSchedule{Type: "Manual"}}))
+}
+
+// ScanAllScheduleGet is the scan_all_schedule_get command.
+type ScanAllScheduleGet struct {
+}
+
+func (x *ScanAllScheduleGet) Execute(args []string) error {
+	return utils.PrintResult(GetScanAllSchedule(utils.NewClient()))
+}
+
+// GetScanAllSchedule gets the schedule of the scan of all the artifacts.
+//
+// format:
+//   GET /system/scanAll/schedule
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/system/scanAll/schedule'
+//
+func GetScanAllSchedule(c *harbor.Client) (*harbor.Result, error) {
+	targetURL := c.APIURL("/system/scanAll/schedule")
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
+}
+
+// ScanAllScheduleSet holds the parameters of PutScanAllSchedule.
+type ScanAllScheduleSet struct {
+	Type string `short:"t" long:"type" description:"(REQUIRED) The type of the schedule, Custom with --cron, None to unschedule." required:"yes" choice:"Hourly" choice:"Daily" choice:"Weekly" choice:"Custom" choice:"None"`
+	Cron string `short:"c" long:"cron" description:"The cron expression of a Custom schedule, with seconds, e.g. '0 0 2 * * *'."`
+}
+
+func (x *ScanAllScheduleSet) Execute(args []string) error {
+	return utils.PrintResult(PutScanAllSchedule(utils.NewClient(), x))
+}
+
+// PutScanAllSchedule sets the schedule of the scan of all the artifacts.
+//
+// params:
+//   type - (REQUIRED) The type of the schedule.
+//   cron - The cron expression of a Custom schedule.
+//
+// format:
+//   PUT /system/scanAll/schedule
+//
+// e.g. curl -X PUT --header 'Content-Type: application/json' -d '{"schedule": {"type": "Custom", "cron": "0 0 3 * * *"}}' 'https://localhost/api/v2.0/system/scanAll/schedule'
+func PutScanAllSchedule(c *harbor.Client, opt *ScanAllScheduleSet) (*harbor.Result, error) {
+	if (opt.Type == "Custom") != (opt.Cron != "") {
+		return nil, errors.New("--cron is required for, and only for, a Custom schedule")
+	}
+
+	targetURL := c.APIURL("/system/scanAll/schedule")
+	c.Trace("==> PUT", targetURL)
+
+	return c.Do(c.Put(targetURL).
+		Send(map[string]interface{}{"schedule": &model.GCSchedule{Type: opt.Type, Cron: opt.Cron}}))
+}
+
+// ScanAllMetrics holds the parameters of GetScanAllMetrics.
+type ScanAllMetrics struct {
+	Schedule bool `long:"schedule" description:"Get the progress of the last scheduled scan instead of the last manual one."`
+	Wait     bool `short:"w" long:"wait" description:"Print the progress until the scan ends."`
+	Interval int  `long:"interval" description:"Polling interval in seconds with --wait." default:"5" validate:"min=1"`
+	Timeout  int  `long:"timeout" description:"Seconds to wait with --wait, 0 for no limit." default:"0"`
+}
+
+func (x *ScanAllMetrics) Execute(args []string) error {
+	get := GetScanAllMetrics
+	if x.Wait {
+		get = WaitScanAllMetrics
+	}
+	m, err := get(utils.NewClient(), x)
+	return utils.PrintValue(m, err, func() { utils.PrintScanAllMetrics(m) })
+}
+
+// scanAllMetricsURL returns the URL of the metrics of the manual or the
+// scheduled scan of all the artifacts.
+func scanAllMetricsURL(c *harbor.Client, schedule bool) string {
+	if schedule {
+		return c.APIURL("/scans/schedule/metrics")
+	}
+	return c.APIURL("/scans/all/metrics")
+}
+
+// GetScanAllMetrics gets the progress of the last manual scan of all the
+// artifacts, or of the last scheduled one.
+//
+// params:
+//   schedule - Get the progress of the last scheduled scan.
+//
+// format:
+//   GET /scans/all/metrics
+//   GET /scans/schedule/metrics
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/scans/all/metrics'
+//
+func GetScanAllMetrics(c *harbor.Client, opt *ScanAllMetrics) (*model.ScanAllMetrics, error) {
+	targetURL := scanAllMetricsURL(c, opt.Schedule)
+	c.Trace("==> GET", targetURL)
+
+	var m model.ScanAllMetrics
+	if err := c.GetJSON(targetURL, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// WaitScanAllMetrics polls the progress of the scan of all the artifacts,
+// reporting it to the progress callback as it changes, until the scan ends,
+// and returns the last progress.
+func WaitScanAllMetrics(c *harbor.Client, opt *ScanAllMetrics) (*model.ScanAllMetrics, error) {
+	if opt.Interval <= 0 {
+		return nil, fmt.Errorf("--interval must be positive")
+	}
+	var deadline <-chan time.Time
+	if opt.Timeout > 0 {
+		deadline = time.After(time.Duration(opt.Timeout) * time.Second)
+	}
+
+	targetURL := scanAllMetricsURL(c, opt.Schedule)
+	c.Trace("==> GET", targetURL)
+
+	last := ""
+	for {
+		var m model.ScanAllMetrics
+		if err := c.GetJSON(targetURL, &m); err != nil {
+			return nil, err
+		}
+		if !m.Ongoing {
+			return &m, nil
+		}
+		if line := utils.ScanAllProgress(&m); line != last {
+			c.Status("%s", line)
+			last = line
+		}
+
+		select {
+		case <-c.Context().Done():
+			return nil, c.Context().Err()
+		case <-deadline:
+			return nil, fmt.Errorf("scan of all the artifacts not ended in %ds", opt.Timeout)
+		case <-time.After(time.Duration(opt.Interval) * time.Second):
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+func init() {
+	utils.Parser.AddCommand("scanners_list",
+		"List the scanners.",
+		"This endpoint lists the scanner adapters registered, the default one marked by is_default. (Harbor v1.10+)",
+		&ScannersList{})
+	utils.Parser.AddCommand("scanner_create",
+		"Register a scanner.",
+		"This endpoint registers a scanner adapter by the URL of its API, e.g. an external Trivy or Clair adapter; scanner_ping checks it can be reached before. (Harbor v1.10+)",
+		&ScannerCreate{})
+	utils.Parser.AddCommand("scanner_get",
+		"Get a scanner.",
+		"This endpoint gets a scanner adapter registered by specific registration ID. (Harbor v1.10+)",
+		&ScannerGet{})
+	utils.Parser.AddCommand("scanner_ping",
+		"Ping a scanner.",
+		"This endpoint checks that a scanner adapter can be reached, the one registered with --uuid, whose metadata (vendor, version, capabilities) is got, or the one at --url, before registering it. (Harbor v1.10+)",
+		&ScannerPing{})
+	utils.Parser.AddCommand("scanner_set_default",
+		"Set the default scanner.",
+		"This endpoint sets the system default scanner adapter, which scans the projects having no scanner of their own. (Harbor v1.10+)",
+		&ScannerSetDefault{})
+	utils.Parser.AddCommand("project_scanner_get",
+		"Get the scanner of a project.",
+		"This endpoint gets the scanner adapter of a project, the system default one if the project has none of its own. (Harbor v1.10+)",
+		&ProjectScannerGet{})
+	utils.Parser.AddCommand("project_scanner_set",
+		"Set the scanner of a project.",
+		"This endpoint sets the scanner adapter of a project, overriding the system default one. (Harbor v1.10+)",
+		&ProjectScannerSet{})
+
+	utils.RequireAdmin("scanners_list", "scanner_create", "scanner_get", "scanner_ping", "scanner_set_default")
+	utils.ResponseModel("scanners_list", model.ScannerRegistration{})
+	utils.ResponseModel("scanner_get", model.ScannerRegistration{})
+	utils.ResponseModel("project_scanner_get", model.ScannerRegistration{})
+}
+
+// ScannerEndpoint holds the flags of the API of a scanner adapter, for
+// scanner_create and scanner_ping.
+type ScannerEndpoint struct {
+	Auth           string `long:"auth" description:"The authentication of the adapter API, none if not set; the credential is given by --credential." choice:"Basic" choice:"Bearer" choice:"X-ScannerAdapter-API-Key"`
+	Credential     string `long:"credential" description:"The credential of --auth: 'user:password' for Basic, the token or the API key otherwise."`
+	SkipCertVerify bool   `long:"skip_cert_verify" description:"Do not verify the certificate of the adapter API."`
+}
+
+// scannerBody returns the registration sent for the scanner at address.
+func scannerBody(name, description, address string, e *ScannerEndpoint) (map[string]interface{}, error) {
+	if e.Credential != "" && e.Auth == "" {
+		return nil, errors.New("--credential requires --auth")
+	}
+	if u, err := url.Parse(address); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("--url: an http or https URL expected, got " + strconv.Quote(address))
+	}
+	return map[string]interface{}{
+		"name":              name,
+		"description":       description,
+		"url":               address,
+		"auth":              e.Auth,
+		"access_credential": e.Credential,
+		"skip_certVerify":   e.SkipCertVerify,
+	}, nil
+}
+
+// ScannersList holds the parameters of GetScanners.
+type ScannersList struct {
+	Page     int  `short:"p" long:"page" description:"The page nubmer, default is 1." default:"1"`
+	PageSize int  `short:"s" long:"page_size" description:"The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs." default:"10" validate:"pagesize"`
+	Count    bool `long:"count" description:"Print the total number of matched items only."`
+	All      bool `long:"all" description:"Fetch all pages, --page and --page_size are ignored."`
+}
+
+func (x *ScannersList) Execute(args []string) error {
+	return utils.ListPrinter(x.Count)(GetScanners(utils.NewClient(), x))
+}
+
+// GetScanners lists the scanner adapters registered.
+//
+// params:
+//   page - The page nubmer, default is 1.
+//   page_size - The size of per page, default is 10.
+//
+// format:
+//   GET /scanners
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/scanners?page=1&page_size=10'
+//
+func GetScanners(c *harbor.Client, opt *ScannersList) (*harbor.Result, error) {
+	if opt.Count {
+		opt.Page, opt.PageSize = 1, 1
+	}
+
+	targetURL := c.APIURL("/scanners") + "?page=" + strconv.Itoa(opt.Page) +
+		"&page_size=" + strconv.Itoa(opt.PageSize)
+
+	if !opt.Count {
+		c.Trace("==> GET", targetURL)
+	}
+
+	if opt.All && !opt.Count {
+		return c.StreamAllPages(targetURL), nil
+	}
+
+	return c.DoStream(c.Get(targetURL))
+}
+
+// ScannerCreate holds the parameters of PostScanner.
+type ScannerCreate struct {
+	Name            string `short:"n" long:"name" description:"(REQUIRED) The name of the scanner." required:"yes"`
+	Description     string `short:"d" long:"description" description:"The description of the scanner."`
+	URL             string `short:"u" long:"url" description:"(REQUIRED) The URL of the adapter API, e.g. 'http://trivy-adapter:8080'." required:"yes"`
+	UseInternalAddr bool   `long:"use_internal_addr" description:"Let the adapter pull the artifacts from the internal address of the registry."`
+	Disabled        bool   `long:"disabled" description:"Register the scanner disabled."`
+	ScannerEndpoint
+}
+
+func (x *ScannerCreate) Execute(args []string) error {
+	return utils.PrintResult(PostScanner(utils.NewClient(), x))
+}
+
+// PostScanner registers a scanner adapter, the URL of the registration is
+// in the Location header of the response.
+//
+// params:
+//   name - (REQUIRED) The name of the scanner.
+//   description - The description of the scanner.
+//   url - (REQUIRED) The URL of the adapter API.
+//   auth - The authentication of the adapter API.
+//   credential - The credential of auth.
+//
+// format:
+//   POST /scanners
+//
+// e.g. curl -X POST --header 'Content-Type: application/json' -d '{"name": "trivy-ext", "url": "http://trivy-adapter:8080", "auth": "Bearer", "access_credential": "TOKEN", "skip_certVerify": false, "use_internal_addr": true, "disabled": false}' 'https://localhost/api/v2.0/scanners'
+func PostScanner(c *harbor.Client, opt *ScannerCreate) (*harbor.Result, error) {
+	body, err := scannerBody(opt.Name, opt.Description, opt.URL, &opt.ScannerEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	body["use_internal_addr"] = opt.UseInternalAddr
+	body["disabled"] = opt.Disabled
+
+	targetURL := c.APIURL("/scanners")
+	c.Trace("==> POST", targetURL)
+
+	return c.Do(c.Post(targetURL).
+		Send(body))
+}
+
+// ScannerGet holds the parameters of GetScanner.
+type ScannerGet struct {
+	ID string `short:"i" long:"uuid" description:"(REQUIRED) The registration ID (uuid) of the scanner." required:"yes"`
+}
+
+func (x *ScannerGet) Execute(args []string) error {
+	return utils.PrintResult(GetScanner(utils.NewClient(), x))
+}
+
+// GetScanner gets a scanner adapter registered.
+//
+// params:
+//   uuid - (REQUIRED) The registration ID of the scanner.
+//
+// format:
+//   GET /scanners/{uuid}
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/scanners/8a2b1c5e-...'
+//
+func GetScanner(c *harbor.Client, opt *ScannerGet) (*harbor.Result, error) {
+	targetURL := c.APIURL("/scanners") + "/" + url.PathEscape(opt.ID)
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
+}
+
+// ScannerPing holds the parameters of PingScanner.
+type ScannerPing struct {
+	ID  string `short:"i" long:"uuid" description:"The registration ID (uuid) of the scanner. (required unless --url)"`
+	URL string `short:"u" long:"url" description:"The URL of the API of an adapter not registered."`
+	ScannerEndpoint
+}
+
+func (x *ScannerPing) Execute(args []string) error {
+	return utils.PrintResult(PingScanner(utils.NewClient(), x))
+}
+
+// PingScanner checks that a scanner adapter can be reached: a registered
+// one by getting its metadata, another one by the ping endpoint.
+//
+// params:
+//   uuid - The registration ID of the scanner.
+//   url - The URL of the adapter API, instead of uuid.
+//
+// format:
+//   GET /scanners/{uuid}/metadata
+//   POST /scanners/ping
+//
+// e.g. curl -X POST --header 'Content-Type: application/json' -d '{"name": "ping", "url": "http://trivy-adapter:8080", "auth": "", "access_credential": "", "skip_certVerify": false}' 'https://localhost/api/v2.0/scanners/ping'
+func PingScanner(c *harbor.Client, opt *ScannerPing) (*harbor.Result, error) {
+	if (opt.ID == "") == (opt.URL == "") {
+		return nil, errors.New("one of --uuid and --url is required")
+	}
+
+	if opt.ID != "" {
+		targetURL := c.APIURL("/scanners") + "/" + url.PathEscape(opt.ID) + "/metadata"
+		c.Trace("==> GET", targetURL)
+
+		return c.Do(c.Get(targetURL))
+	}
+
+	// The name is required by Harbor, though not used by the ping.
+	body, err := scannerBody("ping", "", opt.URL, &opt.ScannerEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	targetURL := c.APIURL("/scanners/ping")
+	c.Trace("==> POST", targetURL)
+
+	return c.Do(c.Post(targetURL).
+		Send(body))
+}
+
+// ScannerSetDefault holds the parameters of PatchScannerDefault.
+type ScannerSetDefault struct {
+	ID string `short:"i" long:"uuid" description:"(REQUIRED) The registration ID (uuid) of the scanner." required:"yes"`
+}
+
+func (x *ScannerSetDefault) Execute(args []string) error {
+	return utils.PrintResult(PatchScannerDefault(utils.NewClient(), x))
+}
+
+// PatchScannerDefault sets the system default scanner adapter.
+//
+// params:
+//   uuid - (REQUIRED) The registration ID of the scanner.
+//
+// format:
+//   PATCH /scanners/{uuid}
+//
+// e.g. curl -X PATCH --header 'Content-Type: application/json' -d '{"is_default": true}' 'https://localhost/api/v2.0/scanners/8a2b1c5e-...'
+func PatchScannerDefault(c *harbor.Client, opt *ScannerSetDefault) (*harbor.Result, error) {
+	targetURL := c.APIURL("/scanners") + "/" + url.PathEscape(opt.ID)
+	c.Trace("==> PATCH", targetURL)
+
+	return c.Do(c.Request(http.MethodPatch, targetURL).
+		Send(map[string]bool{"is_default": true}))
+}
+
+// ProjectScannerGet holds the parameters of GetProjectScanner.
+type ProjectScannerGet struct {
+	ProjectID int `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
+}
+
+func (x *ProjectScannerGet) Execute(args []string) error {
+	return utils.PrintResult(GetProjectScanner(utils.NewClient(), x))
+}
+
+// GetProjectScanner gets the scanner adapter of a project.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//
+// format:
+//   GET /projects/{project_id}/scanner
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/projects/1/scanner'
+//
+func GetProjectScanner(c *harbor.Client, opt *ProjectScannerGet) (*harbor.Result, error) {
+	targetURL := c.APIURL("/projects") + "/" + strconv.Itoa(opt.ProjectID) + "/scanner"
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
+}
+
+// ProjectScannerSet holds the parameters of PutProjectScanner.
+type ProjectScannerSet struct {
+	ProjectID int    `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
+	ID        string `short:"i" long:"uuid" description:"(REQUIRED) The registration ID (uuid) of the scanner." required:"yes"`
+}
+
+func (x *ProjectScannerSet) Execute(args []string) error {
+	return utils.PrintResult(PutProjectScanner(utils.NewClient(), x))
+}
+
+// PutProjectScanner sets the scanner adapter of a project.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   uuid - (REQUIRED) The registration ID of the scanner.
+//
+// format:
+//   PUT /projects/{project_id}/scanner
+//
+// e.g. curl -X PUT --header 'Content-Type: application/json' -d '{"uuid": "8a2b1c5e-..."}' 'https://localhost/api/v2.0/projects/1/scanner'
+func PutProjectScanner(c *harbor.Client, opt *ProjectScannerSet) (*harbor.Result, error) {
+	targetURL := c.APIURL("/projects") + "/" + strconv.Itoa(opt.ProjectID) + "/scanner"
+	c.Trace("==> PUT", targetURL)
+
+	return c.Do(c.Put(targetURL).
+		Send(map[string]string{"uuid": opt.ID}))
+}
@@ -0,0 +1,346 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+func init() {
+	utils.Parser.AddCommand("scan_start",
+		"Scan an artifact for vulnerabilities.",
+		"This endpoint triggers a vulnerability scan of an artifact (a tag with v1 API). --wait waits for it to end and prints the report, --severity-threshold (implying --wait) exits with non-zero code when vulnerabilities of that severity or higher are found, e.g. as a CI gate, --format (implying --wait too) exports the report as SARIF, CycloneDX or CSV. (Harbor v1.x with Clair, v2.0+ with any scanner)",
+		&ScanStart{})
+	utils.Parser.AddCommand("scan_report",
+		"Get the vulnerability report of an artifact.",
+		"This endpoint gets the vulnerability report of the last scan of an artifact (a tag with v1 API), most severe first. --wait waits for a scan still going to end, --severity-threshold exits with non-zero code when vulnerabilities of that severity or higher are found, --format exports it as SARIF for code scanning, CycloneDX for SBOM tooling or CSV. (Harbor v1.x with Clair, v2.0+ with any scanner)",
+		&ScanReportGet{})
+
+	utils.RequireComponent("scan_start", utils.ComponentScanner)
+	utils.RequireComponent("scan_report", utils.ComponentScanner)
+}
+
+// ScanWait holds the flags waiting for a scan, and exporting and gating on
+// its report.
+type ScanWait struct {
+	Wait              bool   `short:"w" long:"wait" description:"Wait for the scan to end."`
+	Interval          int    `long:"interval" description:"Polling interval in seconds with --wait." default:"5" validate:"min=1"`
+	Timeout           int    `long:"timeout" description:"Seconds to wait with --wait, 0 for no limit." default:"0"`
+	SeverityThreshold string `long:"severity-threshold" description:"Exit with non-zero code if vulnerabilities of this severity or higher are found." choice:"Low" choice:"Medium" choice:"High" choice:"Critical"`
+	Format            string `long:"format" description:"Export the report as SARIF (code scanning), CycloneDX (SBOM) or CSV instead of the usual output." choice:"sarif" choice:"cyclonedx" choice:"csv"`
+}
+
+// client returns the client of the command, tracing to stderr when the
+// report is exported.
+func (x *ScanWait) client() *harbor.Client {
+	if x.Format != "" {
+		return utils.NewDataClient()
+	}
+	return utils.NewClient()
+}
+
+// scanState is the state of the last scan of an artifact: ID changes with
+// every scan, Status is empty if it was never scanned. Scanner is the
+// scanner of the v2.0 API, its name and version, or the report media type
+// before Harbor v2.1.
+type scanState struct {
+	ID      string
+	Status  string
+	Digest  string
+	Scanner string
+}
+
+// scanEnded maps the status of the scans which ended, of both API
+// versions, to whether they succeeded.
+var scanEnded = map[string]bool{
+	"success":  true,
+	"finished": true,
+	"error":    false,
+	"stopped":  false,
+}
+
+// scanName returns the artifact as project/repo:tag or project/repo@digest.
+func scanName(ref *ArtifactRef) string {
+	if strings.HasPrefix(ref.Reference, "sha256:") {
+		return ref.Project + "/" + ref.RepoName + "@" + ref.Reference
+	}
+	return ref.Project + "/" + ref.RepoName + ":" + ref.Reference
+}
+
+// scanTagURL returns the URL of the tag of the v1 API.
+func scanTagURL(c *harbor.Client, ref *ArtifactRef) string {
+	return c.URL("/api/repositories") + "/" + utils.RepoPath(ref.Project+"/"+ref.RepoName) +
+		"/tags/" + utils.TagPath(ref.Reference)
+}
+
+// getScanState gets the state of the last scan of the artifact.
+func getScanState(c *harbor.Client, ref *ArtifactRef) (*scanState, error) {
+	if !c.IsV2() {
+		var tag struct {
+			Digest       string `json:"digest"`
+			ScanOverview *struct {
+				JobID      int    `json:"job_id"`
+				ScanStatus string `json:"scan_status"`
+			} `json:"scan_overview"`
+		}
+		if err := c.GetJSON(scanTagURL(c, ref), &tag); err != nil {
+			return nil, err
+		}
+		s := &scanState{Digest: tag.Digest}
+		if so := tag.ScanOverview; so != nil {
+			s.ID, s.Status = strconv.Itoa(so.JobID), so.ScanStatus
+		}
+		return s, nil
+	}
+
+	var artifact struct {
+		Digest       string                                `json:"digest"`
+		ScanOverview map[string]*model.NativeReportSummary `json:"scan_overview"`
+	}
+	if err := c.GetJSON(ref.artifactURL(c)+"?with_scan_overview=true", &artifact); err != nil {
+		return nil, err
+	}
+	s := &scanState{Digest: artifact.Digest}
+	for mime, so := range artifact.ScanOverview {
+		if so != nil {
+			s.ID, s.Status, s.Scanner = so.ReportID, so.ScanStatus, mime
+			if so.Scanner != nil {
+				s.Scanner = so.Scanner.Name + " " + so.Scanner.Version
+			}
+			break
+		}
+	}
+	return s, nil
+}
+
+// waitScan waits for a scan of the artifact other than the one of prev, if
+// not nil, to end, reporting its status changes to the progress callback.
+func waitScan(c *harbor.Client, ref *ArtifactRef, prev *scanState, interval, timeout time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("the polling interval must be positive")
+	}
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		deadline = time.After(timeout)
+	}
+
+	name := scanName(ref)
+	last := ""
+	for {
+		s, err := getScanState(c, ref)
+		if err != nil {
+			return err
+		}
+		if s.Status == "" && prev == nil {
+			return fmt.Errorf("%s is not scanned, see scan_start", name)
+		}
+
+		if prev == nil || (s.ID != "" && s.ID != prev.ID) {
+			if s.Status != last {
+				c.Status("scan of %s: %s", name, s.Status)
+				last = s.Status
+			}
+			if ok, ended := scanEnded[strings.ToLower(s.Status)]; ended {
+				if !ok {
+					return fmt.Errorf("scan of %s ended with status %s", name, s.Status)
+				}
+				return nil
+			}
+		}
+
+		select {
+		case <-c.Context().Done():
+			return c.Context().Err()
+		case <-deadline:
+			return fmt.Errorf("scan of %s not ended in %s", name, timeout)
+		case <-time.After(interval):
+		}
+	}
+}
+
+// printScanReport prints the report, exported to format if not empty, and
+// fails if it has vulnerabilities of the severity threshold or higher.
+func printScanReport(r *utils.ScanReport, err error, format, threshold string) error {
+	if err == nil && format != "" {
+		err = utils.WriteScanReport(os.Stdout, r, format)
+	} else {
+		err = utils.PrintValue(r, err, func() { utils.PrintScanReport(r) })
+	}
+	if err != nil {
+		return err
+	}
+	if threshold == "" {
+		return nil
+	}
+	if n := r.AtLeast(threshold); n > 0 {
+		return fmt.Errorf("%d vulnerabilities of severity %s or higher found in %s", n, threshold, r.Artifact)
+	}
+	return nil
+}
+
+// ScanStart holds the parameters of PostScan.
+type ScanStart struct {
+	ArtifactRef
+	ScanWait
+}
+
+func (x *ScanStart) Execute(args []string) error {
+	c := x.client()
+	if !x.Wait && x.SeverityThreshold == "" && x.Format == "" {
+		return utils.PrintResult(PostScan(c, x))
+	}
+	if x.Interval <= 0 {
+		// Checked before the scan is started.
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	prev, err := getScanState(c, &x.ArtifactRef)
+	if err != nil {
+		return err
+	}
+	if _, err := PostScan(c, x); err != nil {
+		return err
+	}
+	c.Status("scan of %s started", scanName(&x.ArtifactRef))
+
+	r, err := waitScanReport(c, &x.ArtifactRef, prev,
+		time.Duration(x.Interval)*time.Second, time.Duration(x.Timeout)*time.Second)
+	return printScanReport(r, err, x.Format, x.SeverityThreshold)
+}
+
+// PostScan triggers a vulnerability scan of an artifact, or of a tag with
+// v1 API, whose repository is then project/repo_name.
+//
+// params:
+//   project - (REQUIRED) The name of the project.
+//   repo_name - (REQUIRED) The name of the repository.
+//   reference - (REQUIRED) The tag or the digest of the artifact.
+//
+// format:
+//   POST /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/scan
+//   POST /api/repositories/{repo_name}/tags/{tag}/scan
+//
+// e.g. curl -X POST --header 'Accept: application/json' 'https://localhost/api/v2.0/projects/library/repositories/nginx/artifacts/1.25/scan'
+func PostScan(c *harbor.Client, opt *ScanStart) (*harbor.Result, error) {
+	targetURL := scanTagURL(c, &opt.ArtifactRef) + "/scan"
+	if c.IsV2() {
+		targetURL = opt.artifactURL(c) + "/scan"
+	}
+	c.Trace("==> POST", targetURL)
+
+	return c.Do(c.Post(targetURL))
+}
+
+// ScanReportGet holds the parameters of GetScanReport.
+type ScanReportGet struct {
+	ArtifactRef
+	ScanWait
+}
+
+func (x *ScanReportGet) Execute(args []string) error {
+	r, err := GetScanReport(x.client(), x)
+	return printScanReport(r, err, x.Format, x.SeverityThreshold)
+}
+
+// GetScanReport gets the vulnerability report of the last scan of an
+// artifact, or of a tag with v1 API, waiting for the scan to end with
+// opt.Wait. The report is the same for both API versions.
+//
+// params:
+//   project - (REQUIRED) The name of the project.
+//   repo_name - (REQUIRED) The name of the repository.
+//   reference - (REQUIRED) The tag or the digest of the artifact.
+//
+// format:
+//   GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/additions/vulnerabilities
+//   GET /api/repositories/{repo_name}/tags/{tag}/vulnerability/details
+//
+// e.g. curl -X GET --header 'Accept: application/vnd.security.vulnerability.report; version=1.1' 'https://localhost/api/v2.0/projects/library/repositories/nginx/artifacts/1.25/additions/vulnerabilities'
+func GetScanReport(c *harbor.Client, opt *ScanReportGet) (*utils.ScanReport, error) {
+	if opt.Wait {
+		if opt.Interval <= 0 {
+			return nil, fmt.Errorf("--interval must be positive")
+		}
+		return waitScanReport(c, &opt.ArtifactRef, nil,
+			time.Duration(opt.Interval)*time.Second, time.Duration(opt.Timeout)*time.Second)
+	}
+	return scanReport(c, &opt.ArtifactRef)
+}
+
+// waitScanReport waits for a scan of the artifact other than prev to end,
+// and gets its report.
+func waitScanReport(c *harbor.Client, ref *ArtifactRef, prev *scanState, interval, timeout time.Duration) (*utils.ScanReport, error) {
+	if err := waitScan(c, ref, prev, interval, timeout); err != nil {
+		return nil, err
+	}
+	return scanReport(c, ref)
+}
+
+// scanReport gets the report of the last scan of the artifact, which must
+// have succeeded.
+func scanReport(c *harbor.Client, ref *ArtifactRef) (*utils.ScanReport, error) {
+	s, err := getScanState(c, ref)
+	if err != nil {
+		return nil, err
+	}
+	name := scanName(ref)
+	switch ok, ended := scanEnded[strings.ToLower(s.Status)]; {
+	case s.Status == "":
+		return nil, fmt.Errorf("%s is not scanned, see scan_start", name)
+	case !ended:
+		return nil, fmt.Errorf("the scan of %s is %s, see --wait", name, s.Status)
+	case !ok:
+		return nil, fmt.Errorf("the scan of %s ended with status %s", name, s.Status)
+	}
+
+	if !c.IsV2() {
+		details, err := utils.VulDetailsFetch(c, ref.Project+"/"+ref.RepoName, ref.Reference, false)
+		if err != nil {
+			return nil, err
+		}
+		var items []model.VulnerabilityItem
+		if err := json.Unmarshal(details, &items); err != nil {
+			return nil, err
+		}
+		return utils.ScanReportFromV1(name, s.Digest, items), nil
+	}
+
+	// Reports are cached as those of v1 API, by the report ID.
+	body, ok := utils.ScanReportCacheGet(s.Digest, s.Scanner, s.ID)
+	if !ok {
+		targetURL := ref.artifactURL(c) + "/additions/vulnerabilities"
+		c.Trace("==> GET", targetURL)
+		res, err := c.Do(c.Get(targetURL))
+		if err != nil {
+			return nil, err
+		}
+		body = res.Body
+		if err := utils.ScanReportCachePut(s.Digest, s.Scanner, s.ID, body); err != nil {
+			c.Status("warning: report not cached: %v", err)
+		}
+	}
+
+	var reports map[string]*model.VulnerabilityReport
+	if err := json.Unmarshal(body, &reports); err != nil {
+		return nil, err
+	}
+	for _, report := range reports {
+		if report == nil {
+			continue
+		}
+		scanner := ""
+		if report.Scanner != nil {
+			scanner = strings.TrimSpace(report.Scanner.Name + " " + report.Scanner.Version)
+		}
+		return utils.NewScanReport(name, s.Digest, scanner, report.Vulnerabilities), nil
+	}
+	return nil, errors.New("no vulnerability report of " + name)
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+func init() {
+	utils.Parser.AddCommand("tag_signature_get",
+		"Tell whether a tag is signed.",
+		"This endpoint tells whether a tag is signed: by Notary (the signature of the tag with v1 API, its signed flag with v2.0 API), or with v2.0 API by a signature attached to its artifact (cosign, notation). --require-signed exits with non-zero code if it is not, e.g. as a CI gate.",
+		&TagSignatureGet{})
+	utils.Parser.AddCommand("prj_unsigned_tags",
+		"List the unsigned tags of a project.",
+		"This endpoint lists the tags of a project, or of one of its repositories, which are not signed, and tells whether the project enforces content trust, in which case they cannot be pulled: Notary with enable_content_trust, cosign with enable_content_trust_cosign (Harbor v2.5+).",
+		&ProjectUnsignedTags{})
+}
+
+// tagSignatures returns the tags of the artifacts of a repository with
+// v2.0 API, and how they are signed.
+func tagSignatures(c *harbor.Client, project, repo string) ([]*utils.TagSignature, error) {
+	targetURL := c.URL("/api/v2.0/projects") + "/" + url.PathEscape(project) +
+		"/repositories/" + utils.RepoPathV2(repo) +
+		"/artifacts?with_tag=true&with_signature=true&with_accessory=true"
+	c.Trace("==> GET", targetURL)
+
+	var sigs []*utils.TagSignature
+	err := c.EachPage(targetURL, func(item json.RawMessage) error {
+		var a model.Artifact
+		if err := json.Unmarshal(item, &a); err != nil {
+			return err
+		}
+		for _, t := range a.Tags {
+			sigs = append(sigs, utils.NewTagSignature(project+"/"+repo+":"+t.Name, &a, t.Name))
+		}
+		return nil
+	})
+	return sigs, err
+}
+
+// tagSignaturesV1 returns the tags of a repository, full name, with v1
+// API, and whether Notary signed them.
+func tagSignaturesV1(c *harbor.Client, repo string) ([]*utils.TagSignature, error) {
+	targetURL := c.URL("/api/repositories") + "/" + utils.RepoPath(repo) + "/tags"
+	c.Trace("==> GET", targetURL)
+
+	var tags []*model.Tag
+	if err := c.GetJSON(targetURL, &tags); err != nil {
+		return nil, err
+	}
+	sigs := make([]*utils.TagSignature, 0, len(tags))
+	for _, t := range tags {
+		sigs = append(sigs, utils.NewTagSignatureV1(repo+":"+t.Name, t))
+	}
+	return sigs, nil
+}
+
+// TagSignatureGet holds the parameters of GetTagSignature.
+type TagSignatureGet struct {
+	ArtifactRef
+	RequireSigned bool `long:"require-signed" description:"Exit with non-zero code if the tag is not signed."`
+}
+
+func (x *TagSignatureGet) Execute(args []string) error {
+	sig, err := GetTagSignature(utils.NewClient(), x)
+	if err = utils.PrintValue(sig, err, func() { utils.PrintTagSignatures([]*utils.TagSignature{sig}) }); err != nil {
+		return err
+	}
+	if x.RequireSigned && !sig.Signed {
+		return fmt.Errorf("%s is not signed", sig.Tag)
+	}
+	return nil
+}
+
+// GetTagSignature tells whether a tag is signed, by Notary or, with v2.0
+// API, by a signature attached to its artifact.
+//
+// params:
+//   project - (REQUIRED) The name of the project.
+//   repo_name - (REQUIRED) The name of the repository.
+//   reference - (REQUIRED) The tag.
+//
+// format:
+//   GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}?with_tag=true&with_signature=true&with_accessory=true
+//   GET /api/repositories/{repo_name}/tags/{tag}
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/projects/library/repositories/nginx/artifacts/1.25?with_tag=true&with_signature=true&with_accessory=true'
+func GetTagSignature(c *harbor.Client, opt *TagSignatureGet) (*utils.TagSignature, error) {
+	name := scanName(&opt.ArtifactRef)
+	if !c.IsV2() {
+		targetURL := scanTagURL(c, &opt.ArtifactRef)
+		c.Trace("==> GET", targetURL)
+
+		var t model.Tag
+		if err := c.GetJSON(targetURL, &t); err != nil {
+			return nil, err
+		}
+		return utils.NewTagSignatureV1(name, &t), nil
+	}
+
+	targetURL := opt.artifactURL(c) + "?with_tag=true&with_signature=true&with_accessory=true"
+	c.Trace("==> GET", targetURL)
+
+	var a model.Artifact
+	if err := c.GetJSON(targetURL, &a); err != nil {
+		return nil, err
+	}
+	return utils.NewTagSignature(name, &a, opt.Reference), nil
+}
+
+// ProjectUnsignedTags holds the parameters of GetProjectUnsignedTags.
+type ProjectUnsignedTags struct {
+	Project  string `short:"p" long:"project" description:"(REQUIRED) The name of the project." required:"yes"`
+	RepoName string `short:"r" long:"repo_name" description:"List the unsigned tags of this repository only, without the project part."`
+}
+
+func (x *ProjectUnsignedTags) Execute(args []string) error {
+	r, err := GetProjectUnsignedTags(utils.NewClient(), x)
+	return utils.PrintValue(r, err, func() { utils.PrintUnsignedTags(r) })
+}
+
+// GetProjectUnsignedTags lists the unsigned tags of a project, or of one
+// of its repositories, and the content trust policies the project
+// enforces.
+//
+// params:
+//   project - (REQUIRED) The name of the project.
+//   repo_name - The name of the repository.
+//
+// format:
+//   GET /projects?name={project_name}
+//   GET /api/v2.0/projects/{project_name}/repositories
+//   GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts?with_tag=true&with_signature=true&with_accessory=true
+//   GET /api/repositories?project_id={project_id}
+//   GET /api/repositories/{repo_name}/tags
+func GetProjectUnsignedTags(c *harbor.Client, opt *ProjectUnsignedTags) (*utils.UnsignedTags, error) {
+	prj, err := projectByName(c, opt.Project)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &utils.UnsignedTags{Project: prj.Name, Unsigned: []*utils.TagSignature{}}
+	if md := prj.Metadata; md != nil {
+		if md.EnableContentTrust == "true" {
+			r.Enforced = append(r.Enforced, "notary")
+		}
+		if md.EnableContentTrustCosign == "true" {
+			r.Enforced = append(r.Enforced, "cosign")
+		}
+	}
+
+	var repos []string
+	if opt.RepoName != "" {
+		repos = append(repos, prj.Name+"/"+opt.RepoName)
+	} else if repos, err = projectRepoNames(c, prj); err != nil {
+		return nil, err
+	}
+
+	for _, repo := range repos {
+		var sigs []*utils.TagSignature
+		if c.IsV2() {
+			sigs, err = tagSignatures(c, prj.Name, strings.TrimPrefix(repo, prj.Name+"/"))
+		} else {
+			sigs, err = tagSignaturesV1(c, repo)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, sig := range sigs {
+			r.Tags++
+			if !sig.Signed {
+				r.Unsigned = append(r.Unsigned, sig)
+			}
+		}
+	}
+	return r, nil
+}
@@ -1,8 +1,9 @@
 package api
 
 import (
-	"fmt"
 
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
 	"github.com/moooofly/harbor-go-client/utils"
 )
 
@@ -10,33 +11,25 @@ func init() {
 	utils.Parser.AddCommand("statistics",
 		"Get projects number and repositories number relevant to the user.",
 		"This endpoint is aimed to statistic all of the projects number and repositories number relevant to the logined user, also the public projects number and repositories number. If the user is admin, he can also get total projects number and total repositories number.",
-		&stats)
-}
+		&Statistics{})
 
-type statistics struct {
+	utils.ResponseModel("statistics", model.Statistic{})
 }
 
-var stats statistics
+// Statistics is the statistics command.
+type Statistics struct {
+}
 
-func (x *statistics) Execute(args []string) error {
-	GetStats(utils.URLGen("/api/statistics"))
-	return nil
+func (x *Statistics) Execute(args []string) error {
+	return utils.PrintResult(GetStats(utils.NewClient()))
 }
 
 // GetStats is aimed to statistic all of the projects number and repositories number relevant to the logined user, also the public projects number and repositories number. If the user is admin, he can also get total projects number and total repositories number.
 //
 // e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/statistics'
-func GetStats(baseURL string) {
-	targetURL := baseURL
-	fmt.Println("==> GET", targetURL)
-
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("error:", err)
-		return
-	}
-
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+func GetStats(c *harbor.Client) (*harbor.Result, error) {
+	targetURL := c.APIURL("/statistics")
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
 }
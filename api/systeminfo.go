@@ -2,99 +2,217 @@ package api
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
 	"github.com/moooofly/harbor-go-client/utils"
 )
 
 func init() {
-	utils.Parser.AddCommand("sysinfo_general",
+	cmd, _ := utils.Parser.AddCommand("sysinfo_general",
 		"Get general system info.",
 		"This API is for retrieving general system info, this can be called by anonymous request.",
-		&sysGeneral)
-	utils.Parser.AddCommand("sysinfo_volumes",
+		&SysInfoGeneral{})
+	if cmd != nil {
+		cmd.Aliases = []string{"systeminfo"}
+	}
+	cmd, _ = utils.Parser.AddCommand("sysinfo_volumes",
 		"Get system volume info (total/free size).",
 		"This endpoint is for retrieving system volume info that only provides for admin user.",
-		&sysVolumes)
+		&SysInfoVolumes{})
+	if cmd != nil {
+		cmd.Aliases = []string{"systeminfo_volumes"}
+	}
 	utils.Parser.AddCommand("sysinfo_rootcert",
 		"Get default root certificate under OVA deployment.",
 		"This endpoint is for downloading a default root certificate that only provides for admin user under OVA deployment.",
-		&sysRootCert)
+		&SysInfoRootCert{})
+	utils.Parser.AddCommand("health",
+		"Check the health of Harbor and its components.",
+		"This endpoint checks the health of Harbor and of each of its components (core, database, redis, registry, jobservice, ...), and exits with non-zero code if any is unhealthy, e.g. in deployment pipelines. It can be called by anonymous request. (Harbor v1.9+)",
+		&Health{})
+	utils.Parser.AddCommand("wait_healthy",
+		"Wait for Harbor and all its components to be healthy.",
+		"This command polls the health of Harbor until all its components are healthy, printing the unhealthy ones as they change, and exits with non-zero code if they are not within --timeout; Harbor not answering yet counts as unhealthy. Deployment scripts can block on it. (Harbor v1.9+)",
+		&WaitHealthy{})
+	utils.RequireAdmin("sysinfo_volumes")
+
+	utils.ResponseModel("sysinfo_general", model.SystemInfo{})
+	utils.ResponseModel("health", model.OverallHealth{})
 }
 
-type sysInfoGeneral struct {
+// SysInfoGeneral is the sysinfo_general command.
+type SysInfoGeneral struct {
 }
 
-var sysGeneral sysInfoGeneral
-
-func (x *sysInfoGeneral) Execute(args []string) error {
-	GetSysGeneral(utils.URLGen("/api/systeminfo"))
-	return nil
+func (x *SysInfoGeneral) Execute(args []string) error {
+	return utils.PrintResult(GetSysGeneral(utils.NewClient()))
 }
 
-type sysInfoVolumes struct {
+// SysInfoVolumes is the sysinfo_volumes command.
+type SysInfoVolumes struct {
 }
 
-var sysVolumes sysInfoVolumes
-
-func (x *sysInfoVolumes) Execute(args []string) error {
-	GetSysVolumes(utils.URLGen("/api/systeminfo/volumes"))
-	return nil
+func (x *SysInfoVolumes) Execute(args []string) error {
+	return utils.PrintResult(GetSysVolumes(utils.NewClient()))
 }
 
-type sysInfoRootCert struct {
+// SysInfoRootCert is the sysinfo_rootcert command.
+type SysInfoRootCert struct {
 }
 
-var sysRootCert sysInfoRootCert
-
-func (x *sysInfoRootCert) Execute(args []string) error {
-	GetSysRootCert(utils.URLGen("/api/systeminfo/getcert"))
-	return nil
+func (x *SysInfoRootCert) Execute(args []string) error {
+	return utils.PrintResult(GetSysRootCert(utils.NewClient()))
 }
 
 // GetSysGeneral is for retrieving general system info, this can be called by anonymous request.
 //
 // e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/systeminfo'
-func GetSysGeneral(baseURL string) {
-	targetURL := baseURL
-	fmt.Println("==> GET", targetURL)
+func GetSysGeneral(c *harbor.Client) (*harbor.Result, error) {
+	targetURL := c.APIURL("/systeminfo")
+	c.Trace("==> GET", targetURL)
 
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn").
-		End(utils.PrintStatus)
+	return c.Do(c.Get(targetURL).
+		Set("Cookie", "harbor-lang=zh-cn"))
 }
 
 // GetSysVolumes is for retrieving system volume info that only provides for admin user.
 //
 // e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/systeminfo/volumes'
-func GetSysVolumes(baseURL string) {
-	targetURL := baseURL
-	fmt.Println("==> GET", targetURL)
-
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("error:", err)
-		return
-	}
+func GetSysVolumes(c *harbor.Client) (*harbor.Result, error) {
+	targetURL := c.APIURL("/systeminfo/volumes")
+	c.Trace("==> GET", targetURL)
 
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Get(targetURL))
 }
 
 // GetSysRootCert is for downloading a default root certificate that only provides for admin user under OVA deployment.
 //
 // e.g. curl -X GET --header 'Accept: text/plain' 'https://localhost/api/systeminfo/getcert'
-func GetSysRootCert(baseURL string) {
-	targetURL := baseURL
-	fmt.Println("==> GET", targetURL)
-
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("error:", err)
-		return
+func GetSysRootCert(c *harbor.Client) (*harbor.Result, error) {
+	targetURL := c.APIURL("/systeminfo/getcert")
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
+}
+
+// Health is the health command.
+type Health struct {
+}
+
+func (x *Health) Execute(args []string) error {
+	h, err := GetHealth(utils.NewClient())
+	if err := utils.PrintValue(h, err, func() {
+		for _, comp := range h.Components {
+			fmt.Printf("%-20s %s", comp.Name, comp.Status)
+			if comp.Error != "" {
+				fmt.Printf(": %s", comp.Error)
+			}
+			fmt.Println()
+		}
+		fmt.Println("Harbor is", h.Status)
+	}); err != nil {
+		return err
+	}
+
+	return healthError(h)
+}
+
+// healthError returns the error naming the unhealthy components, nil if
+// Harbor is healthy.
+func healthError(h *model.OverallHealth) error {
+	var unhealthy []string
+	for _, comp := range h.Components {
+		if comp.Status != "healthy" {
+			unhealthy = append(unhealthy, comp.Name)
+		}
+	}
+	if len(unhealthy) > 0 {
+		return fmt.Errorf("unhealthy: %s", strings.Join(unhealthy, ", "))
 	}
+	if h.Status != "healthy" {
+		return fmt.Errorf("overall status %s", h.Status)
+	}
+	return nil
+}
+
+// GetHealth checks the health of Harbor and of each of its components, this
+// can be called by anonymous request.
+//
+// format:
+//   GET /health
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/health'
+func GetHealth(c *harbor.Client) (*model.OverallHealth, error) {
+	targetURL := c.APIURL("/health")
+	c.Trace("==> GET", targetURL)
+
+	var h model.OverallHealth
+	if err := c.GetJSON(targetURL, &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// WaitHealthy holds the parameters of WaitForHealthy.
+type WaitHealthy struct {
+	Timeout  time.Duration `long:"timeout" description:"Give up after this long, e.g. 5m, 0 for no limit." default:"5m"`
+	Interval time.Duration `long:"interval" description:"Polling interval, e.g. 10s." default:"10s"`
+}
 
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+func (x *WaitHealthy) Execute(args []string) error {
+	h, err := WaitForHealthy(utils.NewClient(), x)
+	return utils.PrintValue(h, err, func() { fmt.Println("Harbor is", h.Status) })
+}
+
+// WaitForHealthy polls the health of Harbor until it and all its components
+// are healthy, reporting why it is not to the progress callback as it
+// changes, and returns the last health. Errors, e.g. Harbor not answering yet, are retried
+// until the timeout.
+//
+// params:
+//   timeout - Give up after this long, 0 for no limit.
+//   interval - Polling interval.
+//
+// format:
+//   GET /health
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/health'
+func WaitForHealthy(c *harbor.Client, opt *WaitHealthy) (*model.OverallHealth, error) {
+	if opt.Interval <= 0 {
+		return nil, fmt.Errorf("--interval must be positive")
+	}
+	var deadline <-chan time.Time
+	if opt.Timeout > 0 {
+		deadline = time.After(opt.Timeout)
+	}
+
+	targetURL := c.APIURL("/health")
+	c.Trace("==> GET", targetURL)
+
+	last := ""
+	for {
+		var h model.OverallHealth
+		err := c.GetJSON(targetURL, &h)
+		if err == nil {
+			err = healthError(&h)
+			if err == nil {
+				return &h, nil
+			}
+		}
+		if err.Error() != last {
+			c.Status("waiting: %v", err)
+			last = err.Error()
+		}
+
+		select {
+		case <-c.Context().Done():
+			return nil, c.Context().Err()
+		case <-deadline:
+			return nil, fmt.Errorf("not healthy after %s, %s", opt.Timeout, last)
+		case <-time.After(opt.Interval):
+		}
+	}
 }
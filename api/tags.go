@@ -2,7 +2,12 @@ package api
 
 import (
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
 	"github.com/moooofly/harbor-go-client/utils"
 )
 
@@ -10,49 +15,117 @@ func init() {
 	utils.Parser.AddCommand("tag_get",
 		"Get the tag of the repository.",
 		"This endpoint aims to retrieve the tag of the repository. If deployed with Notary, the signature property of response represents whether the image is singed or not. If the property is null, the image is unsigned.",
-		&tagget)
+		&TagGet{})
 	utils.Parser.AddCommand("tag_del",
 		"Delete a tag in a repository.",
 		"This endpoint let user delete tags with repo name and tag.",
-		&tagdel)
+		&TagDel{})
 	utils.Parser.AddCommand("tags_list",
 		"Get tags of a relevant repository.",
 		"This endpoint aims to retrieve tags from a relevant repository. If deployed with Notary, the signature property of response represents whether the image is singed or not. If the property is null, the image is unsigned.",
-		&tagslist)
+		&TagsList{})
+	utils.Parser.AddCommand("tag_retag",
+		"Tag an image into a repository.",
+		"This endpoint tags an existing image with a new tag, in the same repository or in another one, e.g. to promote ':staging' to ':prod'. With v2.0 API, the artifact is tagged, copied first into the repository if it is another one.",
+		&TagRetag{})
+
+	utils.ResponseModel("tag_get", model.Tag{})
+	utils.ResponseModel("tags_list", model.Tag{})
 }
 
-type tagGet struct {
-	RepoName string `short:"n" long:"repo_name" description:"(REQUIRED) Relevant repository name." required:"yes"`
-	Tag      string `short:"t" long:"tag" description:"(REQUIRED) Tag of the repository." required:"yes"`
+// TagGet holds the parameters of GetTaginfoOfRepo.
+type TagGet struct {
+	RepoName        string `short:"n" long:"repo_name" description:"(REQUIRED) Relevant repository name." required:"yes"`
+	Tag             string `short:"t" long:"tag" description:"(REQUIRED) Tag of the repository." required:"yes"`
+	Table           bool   `long:"table" description:"Print os/arch, size, author and created time as a table instead of the raw response."`
+	ShowAnnotations bool   `long:"show-annotations" description:"Print the annotations (image config labels) in the table too. (implies --table)"`
 }
 
-var tagget tagGet
+func (x *TagGet) Execute(args []string) error {
+	res, err := GetTaginfoOfRepo(utils.NewClient(), x)
+	if err != nil || !(x.Table || x.ShowAnnotations) {
+		return utils.PrintResult(res, err)
+	}
 
-func (x *tagGet) Execute(args []string) error {
-	GetTaginfoOfRepo(utils.URLGen("/api/repositories"))
+	var t model.Tag
+	if err := res.Decode(&t); err != nil {
+		return err
+	}
+	printTagTable(x.ShowAnnotations, &t)
 	return nil
 }
 
-type tagDel struct {
+// TagDel holds the parameters of DelTaginfoOfRepo.
+type TagDel struct {
 	RepoName string `short:"n" long:"repo_name" description:"(REQUIRED) The name of repository which will be deleted." required:"yes"`
 	Tag      string `short:"t" long:"tag" description:"(REQUIRED) Tag of a repository." required:"yes"`
 }
 
-var tagdel tagDel
+func (x *TagDel) Execute(args []string) error {
+	return utils.PrintResult(DelTaginfoOfRepo(utils.NewClient(), x))
+}
 
-func (x *tagDel) Execute(args []string) error {
-	DelTaginfoOfRepo(utils.URLGen("/api/repositories"))
-	return nil
+// TagsList holds the parameters of GetTagsByRepoName.
+type TagsList struct {
+	RepoName        string `short:"n" long:"repo_name" description:"(REQUIRED) Relevant repository name." required:"yes"`
+	Table           bool   `long:"table" description:"Print os/arch, size, author and created time as a table instead of the raw response."`
+	ShowAnnotations bool   `long:"show-annotations" description:"Print the annotations (image config labels) in the table too. (implies --table)"`
+	TimeWindow
+	RegexFilter
+}
+
+// TagRetag holds the parameters of PostTagRetag.
+type TagRetag struct {
+	RepoName string `short:"n" long:"repo_name" description:"(REQUIRED) The repository of the new tag, with its project." required:"yes"`
+	Tag      string `short:"t" long:"tag" description:"(REQUIRED) The new tag." required:"yes"`
+	SrcImage string `short:"s" long:"src_image" description:"(REQUIRED) The image to tag, 'project/repo:tag' or 'project/repo@sha256:...', or only ':tag' or '@sha256:...' for an image of --repo_name." required:"yes"`
+	Override bool   `long:"override" description:"Move the tag if it already exists in the repository."`
+}
+
+func (x *TagRetag) Execute(args []string) error {
+	return utils.PrintResult(PostTagRetag(utils.NewClient(), x))
+}
+
+// tagArtifactInfo returns the columns of a tag of v1 API in the artifact
+// table.
+func tagArtifactInfo(t *model.Tag) *utils.ArtifactInfo {
+	a := &utils.ArtifactInfo{
+		Tag:    t.Name,
+		Digest: t.Digest,
+		Size:   t.Size,
+		OS:     t.OS,
+		Arch:   t.Architecture,
+		Author: t.Author,
+	}
+	if !t.Created.IsZero() {
+		a.Created = t.Created.UTC().Format(time.RFC3339)
+	}
+	if t.Config != nil {
+		a.Annotations = t.Config.Labels
+	}
+	return a
 }
 
-type tagsList struct {
-	RepoName string `short:"n" long:"repo_name" description:"(REQUIRED) Relevant repository name." required:"yes"`
+// printTagTable prints tag details as a table.
+func printTagTable(showAnnotations bool, details ...*model.Tag) {
+	var arts []*utils.ArtifactInfo
+	for _, t := range details {
+		arts = append(arts, tagArtifactInfo(t))
+	}
+	utils.PrintArtifactTable(arts, showAnnotations)
 }
 
-var tagslist tagsList
+func (x *TagsList) Execute(args []string) error {
+	res, err := GetTagsByRepoName(utils.NewClient(), x)
+	if err != nil || !(x.Table || x.ShowAnnotations) {
+		return utils.PrintResult(res, err)
+	}
 
-func (x *tagsList) Execute(args []string) error {
-	GetTagsByRepoName(utils.URLGen("/api/repositories"))
+	var tags []*model.Tag
+	if err := res.Decode(&tags); err != nil {
+		return err
+	}
+	printTagTable(x.ShowAnnotations, tags...)
 	return nil
 }
 
@@ -60,67 +133,153 @@ func (x *tagsList) Execute(args []string) error {
 // response represents whether the image is singed or not. If the property is null, the image is unsigned.
 //
 // params:
-//  repo_name - (REQUIRED) Relevant repository name.
-//  tag       - (REQUIRED) Tag of the repository.
+//
+//	repo_name - (REQUIRED) Relevant repository name.
+//	tag       - (REQUIRED) Tag of the repository.
 //
 // e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/repositories/prj2%2Fphoton/tags/v2'
-func GetTaginfoOfRepo(baseURL string) {
-	targetURL := baseURL + "/" + tagget.RepoName + "/tags/" + tagget.Tag
-	fmt.Println("==> GET", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
+func GetTaginfoOfRepo(c *harbor.Client, opt *TagGet) (*harbor.Result, error) {
+	targetURL := c.URL("/api/repositories") + "/" + utils.RepoPath(opt.RepoName) + "/tags/" + utils.TagPath(opt.Tag)
+	c.Trace("==> GET", targetURL)
 
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Get(targetURL))
 }
 
 // DelTaginfoOfRepo let user delete tags with repo name and tag.
 //
 // params:
-//  repo_name - (REQUIRED) The name of repository which will be deleted.
-//  tag       - (REQUIRED) Tag of a repository.
+//
+//	repo_name - (REQUIRED) The name of repository which will be deleted.
+//	tag       - (REQUIRED) Tag of a repository.
 //
 // e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/repositories/prj2%2Fphoton/tags/v2'
-func DelTaginfoOfRepo(baseURL string) {
-	targetURL := baseURL + "/" + tagdel.RepoName + "/tags/" + tagdel.Tag
-	fmt.Println("==> DELETE", targetURL)
+func DelTaginfoOfRepo(c *harbor.Client, opt *TagDel) (*harbor.Result, error) {
+	targetURL := c.URL("/api/repositories") + "/" + utils.RepoPath(opt.RepoName) + "/tags/" + utils.TagPath(opt.Tag)
+	c.Trace("==> DELETE", targetURL)
+
+	return c.Do(c.Delete(targetURL))
+}
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
+// GetTagsByRepoName aims to retrieve tags from a relevant repository. If deployed with Notary, the signature property of response represents whether the image is singed or not. If the property is null, the image is unsigned.
+//
+// params:
+//
+//	repo_name - (REQUIRED) Relevant repository name.
+//	pushed-since, pushed-before, pulled-since - The time window of the tags, applied client-side.
+//	filter-regex - The regular expression the names of the tags match, applied client-side.
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/repositories/prj2%2Fphoton/tags'
+func GetTagsByRepoName(c *harbor.Client, opt *TagsList) (*harbor.Result, error) {
+	w, err := opt.window(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	keep, err := opt.keep(itemName)
 	if err != nil {
-		fmt.Println("Error:", err)
-		return
+		return nil, err
 	}
+	if w != nil {
+		keep = keepAll(w.keep, keep)
+	}
+
+	targetURL := c.URL("/api/repositories") + "/" + utils.RepoPath(opt.RepoName) + "/tags"
+	c.Trace("==> GET", targetURL)
 
-	utils.Request.Delete(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	res, err := c.DoStream(c.Get(targetURL))
+	if err != nil || keep == nil {
+		return res, err
+	}
+	return utils.FilterResult(res, keep)
 }
 
-// GetTagsByRepoName aims to retrieve tags from a relevant repository. If deployed with Notary, the signature property of response represents whether the image is singed or not. If the property is null, the image is unsigned.
+// PostTagRetag tags an existing image with a new tag, possibly in another
+// repository. With v2.0 API, which has no retag endpoint, the source
+// artifact is copied into the repository if needed, then tagged.
 //
 // params:
-//  repo_name - (REQUIRED) Relevant repository name.
 //
-// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/repositories/prj2%2Fphoton/tags'
-func GetTagsByRepoName(baseURL string) {
-	targetURL := baseURL + "/" + tagslist.RepoName + "/tags"
-	fmt.Println("==> GET", targetURL)
+//	repo_name - (REQUIRED) The repository of the new tag, with its project.
+//	tag       - (REQUIRED) The new tag.
+//	src_image - (REQUIRED) The image to tag, 'project/repo:tag' or 'project/repo@digest'.
+//	override  - Move the tag if it already exists in the repository.
+//
+// e.g. curl -X POST --header 'Content-Type: application/json' -d '{"tag": "prod", "src_image": "prj2/photon:staging", "override": true}' 'https://localhost/api/repositories/prj2%2Fphoton/tags'
+func PostTagRetag(c *harbor.Client, opt *TagRetag) (*harbor.Result, error) {
+	src := opt.SrcImage
+	if strings.HasPrefix(src, ":") || strings.HasPrefix(src, "@") {
+		src = opt.RepoName + src
+	}
+	if c.IsV2() {
+		return retagV2(c, opt, src)
+	}
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
+	targetURL := c.URL("/api/repositories") + "/" + utils.RepoPath(opt.RepoName) + "/tags"
+	c.Trace("==> POST", targetURL)
+
+	return c.Do(c.Post(targetURL).
+		Send(map[string]interface{}{
+			"tag":       opt.Tag,
+			"src_image": src,
+			"override":  opt.Override,
+		}))
+}
+
+// retagV2 tags the image src with v2.0 API.
+func retagV2(c *harbor.Client, opt *TagRetag, src string) (*harbor.Result, error) {
+	srcRepo, ref := splitImage(src)
+	from := artifactRefOf(srcRepo, ref)
+	dst := artifactRefOf(opt.RepoName, opt.Tag)
+	if from.Project == "" || dst.Project == "" {
+		return nil, fmt.Errorf("repository names must include the project, e.g. 'library/photon'")
+	}
+
+	var a model.Artifact
+	if err := c.GetJSON(from.artifactURL(c), &a); err != nil {
+		return nil, err
+	}
+
+	var cur model.Artifact
+	err := c.GetJSON(dst.artifactURL(c), &cur)
+	switch {
+	case err == nil && a.Digest != "" && cur.Digest == a.Digest:
+		return &harbor.Result{StatusCode: http.StatusOK, Status: "200 OK"}, nil
+	case err == nil && !opt.Override:
+		return nil, fmt.Errorf("tag %s:%s already exists, pass --override to move it", opt.RepoName, opt.Tag)
+	case err == nil:
+		targetURL := dst.artifactURL(c) + "/tags/" + utils.TagPath(opt.Tag)
+		c.Trace("==> DELETE", targetURL)
+		if _, err := c.Do(c.Delete(targetURL)); err != nil {
+			return nil, err
+		}
+	case !harbor.IsNotFound(err):
+		return nil, err
+	}
+
+	if srcRepo != opt.RepoName {
+		if _, err := copyArtifact(c, dst, srcRepo+"@"+a.Digest); err != nil {
+			return nil, err
+		}
 	}
 
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	dst.Reference = a.Digest
+	return PostArtifactTag(c, &ArtifactTagCreate{ArtifactRef: *dst, Tag: opt.Tag})
+}
+
+// splitImage splits an image name, 'repo:tag' or 'repo@digest', into its
+// repository and its reference, latest if it has none.
+func splitImage(image string) (repo, ref string) {
+	if i := strings.LastIndexByte(image, '@'); i >= 0 {
+		return image[:i], image[i+1:]
+	}
+	if i := strings.LastIndexByte(image, ':'); i > strings.LastIndexByte(image, '/') {
+		return image[:i], image[i+1:]
+	}
+	return image, "latest"
+}
+
+// artifactRefOf returns the reference ref in the repository name, a full
+// name.
+func artifactRefOf(name, ref string) *ArtifactRef {
+	project, repo := splitRepoName(name)
+	return &ArtifactRef{Project: project, RepoName: repo, Reference: ref}
 }
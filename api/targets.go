@@ -2,9 +2,11 @@ package api
 
 import (
 	"encoding/json"
-	"fmt"
+	"net/url"
 	"strconv"
 
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
 	"github.com/moooofly/harbor-go-client/utils"
 )
 
@@ -12,111 +14,113 @@ func init() {
 	utils.Parser.AddCommand("targets_list",
 		"List targets filtered by name.",
 		"This endpoint let user list targets filtered by name, if name is nil, list returns all targets.",
-		&tl)
+		&TargetsList{})
 	utils.Parser.AddCommand("targets_create",
 		"Create a new replication target.",
 		"This endpoint is for user to create a new replication target.",
-		&tc)
+		&TargetsCreate{})
 	utils.Parser.AddCommand("targets_ping",
 		"Ping validates target.",
 		"This endpoint is for ping validates whether the target is reachable and whether the credential is valid.",
-		&tping)
+		&TargetsPing{})
 	utils.Parser.AddCommand("targets_ping_by_tid",
 		"Ping target.",
 		"This endpoint is for ping target.",
-		&tpingByID)
+		&TargetsPingByID{})
 	utils.Parser.AddCommand("targets_delete_by_tid",
 		"Delete specific replication's target.",
 		"This endpoint is for to delete specific replication's target.",
-		&tdByID)
+		&TargetsDeleteByID{})
 	utils.Parser.AddCommand("targets_get_by_tid",
 		"Get replication's target.",
 		"This endpoint is for get specific replication's target.",
-		&tgByID)
+		&TargetsGetByID{})
 	utils.Parser.AddCommand("targets_update_by_tid",
 		"Update replication's target.",
 		"This endpoint is for update specific replication's target.",
-		&tuByID)
+		&TargetsUpdateByID{})
 	utils.Parser.AddCommand("targets_policies_by_tid",
 		"List the target relevant policies.",
 		"This endpoint list policies filter with specific replication's target ID.",
-		&tpoliciesByID)
+		&TargetsPoliciesByID{})
+	utils.RequireAdmin(
+		"targets_list",
+		"targets_create",
+		"targets_ping",
+		"targets_ping_by_tid",
+		"targets_delete_by_tid",
+		"targets_get_by_tid",
+		"targets_update_by_tid",
+		"targets_policies_by_tid",
+	)
+
+	utils.ResponseModel("targets_policies_by_tid", model.ReplicationPolicy{})
 }
 
-type targetsList struct {
+// TargetsList holds the parameters of GetTargetsList.
+type TargetsList struct {
 	Name string `short:"n" long:"name" description:"The replication's target name (for filter)." default:""`
 }
 
-var tl targetsList
-
-func (x *targetsList) Execute(args []string) error {
-	GetTargetsList(utils.URLGen("/api/targets"))
-	return nil
+func (x *TargetsList) Execute(args []string) error {
+	return utils.PrintResult(GetTargetsList(utils.NewClient(), x))
 }
 
-type targetsCreate struct {
-	EndpointURL  string `short:"e" long:"endpoint" description:"(REQUIRED) The target address URL string. (Should be globally unique)" required:"yes" json:"endpoint"`
-	EndpointName string `short:"n" long:"name" description:"(REQUIRED) The target name. (Should be globally unique)" required:"yes" json:"name"`
-	Username     string `short:"u" long:"username" description:"(REQUIRED) The target server username." required:"yes" json:"username"`
-	Password     string `short:"p" long:"password" description:"(REQUIRED) The target server password." required:"yes" json:"password"`
-	Insecure     bool   `short:"x" long:"insecure" description:"(REQUIRED) Whether or not the certificate will be verified when Harbor tries to access the server." required:"yes" json:"insecure"`
+// TargetsCreate holds the parameters of PostTargetsCreate.
+type TargetsCreate struct {
+	EndpointURL  string `short:"e" long:"endpoint" description:"(REQUIRED) The target address URL string. (Should be globally unique)" validate:"required" json:"endpoint"`
+	EndpointName string `short:"n" long:"name" description:"(REQUIRED) The target name. (Should be globally unique)" validate:"required" json:"name"`
+	Username     string `short:"u" long:"username" description:"(REQUIRED) The target server username." validate:"required" json:"username"`
+	Password     string `short:"p" long:"password" description:"(REQUIRED) The target server password." validate:"required" json:"password"`
+	Insecure     bool   `short:"x" long:"insecure" description:"Whether or not the certificate will be verified when Harbor tries to access the server." json:"insecure"`
+	File         string `short:"f" long:"file" description:"Read the request from a JSON or YAML spec file (or @file, - for stdin) instead of flags." spec:"file" json:"-"`
 }
 
-var tc targetsCreate
-
-func (x *targetsCreate) Execute(args []string) error {
-	PostTargetsCreate(utils.URLGen("/api/targets"))
-	return nil
+func (x *TargetsCreate) Execute(args []string) error {
+	return utils.PrintResult(PostTargetsCreate(utils.NewClient(), x))
 }
 
-type targetsPing struct {
+// TargetsPing holds the parameters of PostTargetsPing.
+type TargetsPing struct {
 	EndpointURL string `short:"e" long:"endpoint" description:"(REQUIRED) The target address URL string." required:"yes" json:"endpoint"`
 	Username    string `short:"u" long:"username" description:"(REQUIRED) The target server username." required:"yes" json:"username"`
 	Password    string `short:"p" long:"password" description:"(REQUIRED) The target server password." required:"yes" json:"password"`
 	Insecure    bool   `short:"x" long:"insecure" description:"(REQUIRED) Whether or not the certificate will be verified when Harbor tries to access the server." required:"yes" json:"insecure"`
 }
 
-var tping targetsPing
-
-func (x *targetsPing) Execute(args []string) error {
-	PostTargetsPing(utils.URLGen("/api/targets/ping"))
-	return nil
+func (x *TargetsPing) Execute(args []string) error {
+	return utils.PrintResult(PostTargetsPing(utils.NewClient(), x))
 }
 
-type targetsPingByID struct {
+// TargetsPingByID holds the parameters of PostTargetsPingByID.
+type TargetsPingByID struct {
 	ID int `short:"i" long:"id" description:"(REQUIRED) The replication's target ID." required:"yes"`
 }
 
-var tpingByID targetsPingByID
-
-func (x *targetsPingByID) Execute(args []string) error {
-	PostTargetsPingByID(utils.URLGen("/api/targets"))
-	return nil
+func (x *TargetsPingByID) Execute(args []string) error {
+	return utils.PrintResult(PostTargetsPingByID(utils.NewClient(), x))
 }
 
-type targetsDeleteByID struct {
+// TargetsDeleteByID holds the parameters of DeleteTargetsByID.
+type TargetsDeleteByID struct {
 	ID int `short:"i" long:"id" description:"(REQUIRED) The replication's target ID." required:"yes"`
 }
 
-var tdByID targetsDeleteByID
-
-func (x *targetsDeleteByID) Execute(args []string) error {
-	DeleteTargetsByID(utils.URLGen("/api/targets"))
-	return nil
+func (x *TargetsDeleteByID) Execute(args []string) error {
+	return utils.PrintResult(DeleteTargetsByID(utils.NewClient(), x))
 }
 
-type targetsGetByID struct {
+// TargetsGetByID holds the parameters of GetTargetsByID.
+type TargetsGetByID struct {
 	ID int `short:"i" long:"id" description:"(REQUIRED) The replication's target ID." required:"yes"`
 }
 
-var tgByID targetsGetByID
-
-func (x *targetsGetByID) Execute(args []string) error {
-	GetTargetsByID(utils.URLGen("/api/targets"))
-	return nil
+func (x *TargetsGetByID) Execute(args []string) error {
+	return utils.PrintResult(GetTargetsByID(utils.NewClient(), x))
 }
 
-type targetsUpdateByID struct {
+// TargetsUpdateByID holds the parameters of UpdateTargetsByID.
+type TargetsUpdateByID struct {
 	ID           int    `short:"i" long:"id" description:"(REQUIRED) The replication's target ID." required:"yes" json:"-"`
 	EndpointURL  string `short:"e" long:"endpoint" description:"(REQUIRED) The target address URL string." required:"yes" json:"endpoint"`
 	EndpointName string `short:"n" long:"name" description:"(REQUIRED) The target name." required:"yes" json:"name"`
@@ -125,22 +129,17 @@ type targetsUpdateByID struct {
 	Insecure     bool   `short:"x" long:"insecure" description:"(REQUIRED) Whether or not the certificate will be verified when Harbor tries to access the server." required:"yes" json:"insecure"`
 }
 
-var tuByID targetsUpdateByID
-
-func (x *targetsUpdateByID) Execute(args []string) error {
-	UpdateTargetsByID(utils.URLGen("/api/targets"))
-	return nil
+func (x *TargetsUpdateByID) Execute(args []string) error {
+	return utils.PrintResult(UpdateTargetsByID(utils.NewClient(), x))
 }
 
-type targetsPoliciesByID struct {
+// TargetsPoliciesByID holds the parameters of GetPoliciesByID.
+type TargetsPoliciesByID struct {
 	ID int `short:"i" long:"id" description:"(REQUIRED) The replication's target ID." required:"yes"`
 }
 
-var tpoliciesByID targetsPoliciesByID
-
-func (x *targetsPoliciesByID) Execute(args []string) error {
-	GetPoliciesByID(utils.URLGen("/api/targets"))
-	return nil
+func (x *TargetsPoliciesByID) Execute(args []string) error {
+	return utils.PrintResult(GetPoliciesByID(utils.NewClient(), x))
 }
 
 // GetTargetsList let user list filters targets by name, if name is nil, list returns all targets.
@@ -149,20 +148,11 @@ func (x *targetsPoliciesByID) Execute(args []string) error {
 //  name - The replication's target name (for filter).
 //
 // e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/targets?name=remote'
-func GetTargetsList(baseURL string) {
-	targetURL := baseURL + "?name=" + tl.Name
-	fmt.Println("==> GET", targetURL)
+func GetTargetsList(c *harbor.Client, opt *TargetsList) (*harbor.Result, error) {
+	targetURL := targetsURL(c) + "?name=" + url.QueryEscape(opt.Name)
+	c.Trace("==> GET", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
-
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Get(targetURL))
 }
 
 // PostTargetsCreate is for user to create a new replication target.
@@ -184,27 +174,26 @@ curl -X POST --header 'Content-Type: text/plain' --header 'Accept: text/plain' -
   "insecure": true
 }' 'https://localhost/api/targets'
 */
-func PostTargetsCreate(baseURL string) {
-	targetURL := baseURL
-	fmt.Println("==> POST", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
+func PostTargetsCreate(c *harbor.Client, opt *TargetsCreate) (*harbor.Result, error) {
+	if opt.File != "" {
+		if err := utils.SpecLoad(opt.File, opt); err != nil {
+			return nil, err
+		}
+	}
+	if err := utils.Validate(opt); err != nil {
+		return nil, err
 	}
 
-	t, err := json.Marshal(&tc)
+	targetURL := c.URL("/api/targets")
+	c.Trace("==> POST", targetURL)
+
+	t, err := json.Marshal(opt)
 	if err != nil {
-		fmt.Println("error:", err)
-		return
+		return nil, err
 	}
 
-	utils.Request.Post(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		Send(string(t)).
-		End(utils.PrintStatus)
+	return c.Do(c.Post(targetURL).
+		Send(string(t)))
 }
 
 // PostTargetsPing is for ping validates whether the target is reachable and whether the credential is valid.
@@ -224,27 +213,17 @@ curl -X POST --header 'Content-Type: text/plain' --header 'Accept: text/plain' -
   "insecure": true
 }' 'https://localhost/api/targets/ping'
 */
-func PostTargetsPing(baseURL string) {
-	targetURL := baseURL
-	fmt.Println("==> POST", targetURL)
+func PostTargetsPing(c *harbor.Client, opt *TargetsPing) (*harbor.Result, error) {
+	targetURL := c.URL("/api/targets/ping")
+	c.Trace("==> POST", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
+	p, err := json.Marshal(opt)
 	if err != nil {
-		fmt.Println("Error:", err)
-		return
+		return nil, err
 	}
 
-	p, err := json.Marshal(&tping)
-	if err != nil {
-		fmt.Println("error:", err)
-		return
-	}
-
-	utils.Request.Post(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		Send(string(p)).
-		End(utils.PrintStatus)
+	return c.Do(c.Post(targetURL).
+		Send(string(p)))
 }
 
 // PostTargetsPingByID is for ping target.
@@ -253,20 +232,11 @@ func PostTargetsPing(baseURL string) {
 //  id - (REQUIRED) The replication's target ID.
 //
 // e.g. curl -X POST --header 'Content-Type: application/json' --header 'Accept: text/plain' 'https://localhost/api/targets/1/ping'
-func PostTargetsPingByID(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(tpingByID.ID) + "/ping"
-	fmt.Println("==> POST", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
+func PostTargetsPingByID(c *harbor.Client, opt *TargetsPingByID) (*harbor.Result, error) {
+	targetURL := c.URL("/api/targets") + "/" + strconv.Itoa(opt.ID) + "/ping"
+	c.Trace("==> POST", targetURL)
 
-	utils.Request.Post(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Post(targetURL))
 }
 
 // DeleteTargetsByID is for to delete specific replication's target.
@@ -275,20 +245,11 @@ func PostTargetsPingByID(baseURL string) {
 //  id - (REQUIRED) The replication's target ID.
 //
 // e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/targets/2'
-func DeleteTargetsByID(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(tdByID.ID)
-	fmt.Println("==> DELETE", targetURL)
+func DeleteTargetsByID(c *harbor.Client, opt *TargetsDeleteByID) (*harbor.Result, error) {
+	targetURL := targetsURL(c) + "/" + strconv.Itoa(opt.ID)
+	c.Trace("==> DELETE", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
-
-	utils.Request.Delete(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Delete(targetURL))
 }
 
 // GetTargetsByID is for get specific replication's target.
@@ -297,20 +258,11 @@ func DeleteTargetsByID(baseURL string) {
 //  id - (REQUIRED) The replication's target ID.
 //
 // e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/targets/1'
-func GetTargetsByID(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(tgByID.ID)
-	fmt.Println("==> GET", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
+func GetTargetsByID(c *harbor.Client, opt *TargetsGetByID) (*harbor.Result, error) {
+	targetURL := targetsURL(c) + "/" + strconv.Itoa(opt.ID)
+	c.Trace("==> GET", targetURL)
 
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Get(targetURL))
 }
 
 // UpdateTargetsByID is for update specific replication's target.
@@ -333,29 +285,19 @@ curl -X PUT --header 'Content-Type: text/plain' --header 'Accept: text/plain' -d
   "insecure": true
 }' 'https://localhost/api/targets/4'
 */
-func UpdateTargetsByID(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(tuByID.ID)
-	fmt.Println("==> PUT", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
+func UpdateTargetsByID(c *harbor.Client, opt *TargetsUpdateByID) (*harbor.Result, error) {
+	targetURL := c.URL("/api/targets") + "/" + strconv.Itoa(opt.ID)
+	c.Trace("==> PUT", targetURL)
 
-	t, err := json.Marshal(&tuByID)
+	t, err := json.Marshal(opt)
 	if err != nil {
-		fmt.Println("error:", err)
-		return
+		return nil, err
 	}
 
 	//fmt.Println("===>", string(t))
 
-	utils.Request.Put(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		Send(string(t)).
-		End(utils.PrintStatus)
+	return c.Do(c.Put(targetURL).
+		Send(string(t)))
 }
 
 // GetPoliciesByID lists policies filter with specific replication's target ID.
@@ -364,18 +306,18 @@ func UpdateTargetsByID(baseURL string) {
 //  id - (REQUIRED) The replication's target ID.
 //
 // e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/targets/1/policies/'
-func GetPoliciesByID(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(tpoliciesByID.ID) + "/policies/"
-	fmt.Println("==> GET", targetURL)
+func GetPoliciesByID(c *harbor.Client, opt *TargetsPoliciesByID) (*harbor.Result, error) {
+	targetURL := c.URL("/api/targets") + "/" + strconv.Itoa(opt.ID) + "/policies/"
+	c.Trace("==> GET", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
+	return c.Do(c.Get(targetURL))
+}
 
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+// targetsURL returns the URL of replication targets, known as registries in
+// v2.0 API.
+func targetsURL(c *harbor.Client) string {
+	if c.IsV2() {
+		return c.URL("/api/v2.0/registries")
+	}
+	return c.URL("/api/targets")
 }
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"sync"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+func init() {
+	utils.Parser.AddCommand("project_usage",
+		"Show quota usage of a project broken down by repository.",
+		"Combine the quota usage of a project with the number and the total size of the artifacts of each of its repositories, largest first, to show what is eating the quota. Repositories are inspected concurrently. Layers shared by artifacts are counted in each repository, the quota counts them once. (Harbor v2.1+, uses v2.0 API)",
+		&ProjectUsageGet{})
+}
+
+// ProjectUsageGet holds the parameters of GetProjectUsage.
+type ProjectUsageGet struct {
+	Project string `short:"p" long:"project" description:"(REQUIRED) The name of the project." required:"yes"`
+	Workers int    `short:"w" long:"workers" description:"The number of repositories inspected concurrently." default:"4" validate:"min=1"`
+}
+
+func (x *ProjectUsageGet) Execute(args []string) error {
+	u, err := GetProjectUsage(utils.NewClient(), x)
+	return utils.PrintValue(u, err, func() { utils.PrintProjectUsage(u) })
+}
+
+type projectSummaryQuota struct {
+	Quota struct {
+		Hard struct {
+			Storage int64 `json:"storage"`
+		} `json:"hard"`
+		Used struct {
+			Storage int64 `json:"storage"`
+		} `json:"used"`
+	} `json:"quota"`
+}
+
+// GetProjectUsage returns the quota usage of a project, from its summary,
+// and the artifacts of every repository, listed by opt.Workers requests at
+// a time.
+//
+// format:
+//   GET /api/v2.0/projects/{project_name}/summary
+//   GET /api/v2.0/projects/{project_name}/repositories
+//   GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts
+func GetProjectUsage(c *harbor.Client, opt *ProjectUsageGet) (*utils.ProjectUsage, error) {
+	prjURL := c.URL("/api/v2.0/projects") + "/" + url.PathEscape(opt.Project)
+
+	var summary projectSummaryQuota
+	c.Trace("==> GET", prjURL+"/summary")
+	if err := c.GetJSON(prjURL+"/summary", &summary); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	c.Trace("==> GET", prjURL+"/repositories")
+	err := c.EachPage(prjURL+"/repositories", func(item json.RawMessage) error {
+		var repo struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(item, &repo); err != nil {
+			return err
+		}
+		names = append(names, repo.Name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	repos, err := repositoriesUsage(c, prjURL, names, opt.Workers)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(repos, func(i, j int) bool {
+		if repos[i].Size != repos[j].Size {
+			return repos[i].Size > repos[j].Size
+		}
+		return repos[i].Name < repos[j].Name
+	})
+
+	return &utils.ProjectUsage{
+		Project:      opt.Project,
+		QuotaHard:    summary.Quota.Hard.Storage,
+		QuotaUsed:    summary.Quota.Used.Storage,
+		Repositories: repos,
+	}, nil
+}
+
+// repositoriesUsage sums up the artifacts of the repositories, full names
+// as returned by v2.0 API, by workers concurrent listings. The first error
+// stops the listings not started yet.
+func repositoriesUsage(c *harbor.Client, prjURL string, names []string, workers int) ([]*utils.RepositoryUsage, error) {
+	repos := make([]*utils.RepositoryUsage, len(names))
+	jobs := make(chan int)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				u, err := repositoryUsage(c, prjURL, names[i])
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				repos[i] = u
+			}
+		}()
+	}
+
+	for i := range names {
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return repos, nil
+}
+
+func repositoryUsage(c *harbor.Client, prjURL, name string) (*utils.RepositoryUsage, error) {
+	_, repo := splitRepoName(name)
+	targetURL := prjURL + "/repositories/" + utils.RepoPathV2(repo) + "/artifacts?with_tag=false"
+	c.Trace("==> GET", targetURL)
+
+	u := &utils.RepositoryUsage{Name: name}
+	err := c.EachPage(targetURL, func(item json.RawMessage) error {
+		var art struct {
+			Size int64 `json:"size"`
+		}
+		if err := json.Unmarshal(item, &art); err != nil {
+			return err
+		}
+		u.Artifacts++
+		u.Size += art.Size
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
@@ -2,78 +2,155 @@ package api
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
+	"net/url"
 	"strconv"
 
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
 	"github.com/moooofly/harbor-go-client/utils"
 )
 
 func init() {
 	utils.Parser.AddCommand("usergroups_list",
 		"Get all user groups information",
-		"Get all user groups information",
-		&ugList)
+		"Get all user groups information, of an LDAP group DN with --ldap_group_dn; v2.0 API pages them.",
+		&UsergroupsList{})
+	utils.Parser.AddCommand("usergroups_search",
+		"Search user groups by name",
+		"Search the user groups whose name contains the given one, e.g. to find the ID of a group to add to a project with prj_member_create --group_id. (Harbor v2.3+, uses v2.0 API)",
+		&UsergroupsSearch{})
 	utils.Parser.AddCommand("usergroup_create",
 		"Create user group",
-		"Create user group information",
-		&ugCreate)
+		"Create user group information: an LDAP group (type 1) by its DN, found by ldap_groups_search, an HTTP (type 2) or OIDC (type 3) group by its name; it can then be granted project roles by prj_member_create --group_id.",
+		&UsergroupCreate{})
 	utils.Parser.AddCommand("usergroup_del",
 		"Delete user group",
 		"Delete user group",
-		&ugDel)
+		&UsergroupDel{})
 	utils.Parser.AddCommand("usergroup_get",
 		"Get user group information",
 		"Get user group information",
-		&ugGet)
+		&UsergroupGet{})
 	utils.Parser.AddCommand("usergroup_update",
 		"Update group information",
-		"Update group information",
-		&ugUpdate)
+		"Update group information, only the name of a group can be changed.",
+		&UsergroupUpdate{})
+	utils.RequireAdmin(
+		"usergroups_list",
+		"usergroups_search",
+		"usergroup_create",
+		"usergroup_del",
+		"usergroup_get",
+		"usergroup_update",
+	)
+
+	utils.ResponseModel("usergroups_list", model.UserGroup{})
+	utils.ResponseModel("usergroup_get", model.UserGroup{})
+	utils.ResponseModel("usergroups_search", model.UserGroup{})
 }
 
-type usergroupsList struct {
+// UsergroupsList holds the parameters of GetUsergroupsList.
+type UsergroupsList struct {
+	LDAPGroupDN string `short:"l" long:"ldap_group_dn" description:"Only the group of this LDAP group DN."`
+	Page        int    `short:"p" long:"page" description:"The page nubmer, default is 1. (v2.0 API)" default:"1"`
+	PageSize    int    `short:"s" long:"page_size" description:"The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs. (v2.0 API)" default:"10" validate:"pagesize"`
+	Count       bool   `long:"count" description:"Print the total number of matched items only."`
+	All         bool   `long:"all" description:"Fetch all pages, --page and --page_size are ignored."`
 }
 
-var ugList usergroupsList
-
-func (x *usergroupsList) Execute(args []string) error {
-	GetUsergroupsList(utils.URLGen("/api/usergroups"))
-	return nil
+func (x *UsergroupsList) Execute(args []string) error {
+	return utils.ListPrinter(x.Count)(GetUsergroupsList(utils.NewClient(), x))
 }
 
-// GetUsergroupsList get all user groups information
+// GetUsergroupsList get all user groups information. The v1 API lists them
+// all, whatever the page.
 //
 // params:
+//  ldap_group_dn - Only the group of this LDAP group DN.
+//  page          - The page nubmer, default is 1.
+//  page_size     - The size of per page, default is 10.
 //
-// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/usergroups'
-func GetUsergroupsList(baseURL string) {
-	targetURL := baseURL
-	fmt.Println("==> GET", targetURL)
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/usergroups?page=1&page_size=10'
+func GetUsergroupsList(c *harbor.Client, opt *UsergroupsList) (*harbor.Result, error) {
+	if opt.Count {
+		opt.Page, opt.PageSize = 1, 1
+	}
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
+	targetURL := c.APIURL("/usergroups") + "?page=" + strconv.Itoa(opt.Page) +
+		"&page_size=" + strconv.Itoa(opt.PageSize)
+	if opt.LDAPGroupDN != "" {
+		targetURL += "&ldap_group_dn=" + url.QueryEscape(opt.LDAPGroupDN)
 	}
 
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	if !opt.Count {
+		c.Trace("==> GET", targetURL)
+	}
+
+	if opt.All && !opt.Count {
+		return c.StreamAllPages(targetURL), nil
+	}
+
+	return c.DoStream(c.Get(targetURL))
+}
+
+// UsergroupsSearch holds the parameters of GetUsergroupsSearch.
+type UsergroupsSearch struct {
+	GroupName string `short:"n" long:"groupname" description:"(REQUIRED) A part of the name of the groups." required:"yes"`
+	Page      int    `short:"p" long:"page" description:"The page nubmer, default is 1." default:"1"`
+	PageSize  int    `short:"s" long:"page_size" description:"The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs." default:"10" validate:"pagesize"`
+	Count     bool   `long:"count" description:"Print the total number of matched items only."`
+	All       bool   `long:"all" description:"Fetch all pages, --page and --page_size are ignored."`
 }
 
-type usergroupCreate struct {
-	ID          int    `short:"i" long:"id" description:"The ID of the user group" default:"0" json:"id"`
-	GroupName   string `short:"n" long:"group_name" description:"The name of the user group" default:"tmp-group" json:"group_name"`
-	GroupType   int    `short:"t" long:"group_type" description:"The group type, 1 for LDAP group." default:"1" json:"group_type"`
-	LDAPGroupDN string `short:"l" long:"ldap_group_dn" description:"The DN of the LDAP group if group type is 1 (LDAP group)." default:"" json:"ldap_group_dn"`
+func (x *UsergroupsSearch) Execute(args []string) error {
+	return utils.ListPrinter(x.Count)(GetUsergroupsSearch(utils.NewClient(), x))
+}
+
+// GetUsergroupsSearch searches the user groups by name.
+//
+// params:
+//  groupname - (REQUIRED) A part of the name of the groups.
+//  page      - The page nubmer, default is 1.
+//  page_size - The size of per page, default is 10.
+//
+// format:
+//   GET /usergroups/search?groupname={groupname}
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/usergroups/search?groupname=dev&page=1&page_size=10'
+func GetUsergroupsSearch(c *harbor.Client, opt *UsergroupsSearch) (*harbor.Result, error) {
+	if !c.IsV2() {
+		return nil, errors.New("usergroups_search requires the v2.0 API (Harbor v2.3+)")
+	}
+	if opt.Count {
+		opt.Page, opt.PageSize = 1, 1
+	}
+
+	targetURL := c.APIURL("/usergroups/search") + "?groupname=" + url.QueryEscape(opt.GroupName) +
+		"&page=" + strconv.Itoa(opt.Page) +
+		"&page_size=" + strconv.Itoa(opt.PageSize)
+
+	if !opt.Count {
+		c.Trace("==> GET", targetURL)
+	}
+
+	if opt.All && !opt.Count {
+		return c.StreamAllPages(targetURL), nil
+	}
+
+	return c.DoStream(c.Get(targetURL))
 }
 
-var ugCreate usergroupCreate
+// UsergroupCreate holds the parameters of PostUsergroupCreate.
+type UsergroupCreate struct {
+	ID          int    `short:"i" long:"id" description:"The ID of the user group" default:"0" json:"id,omitempty"`
+	GroupName   string `short:"n" long:"group_name" description:"The name of the user group, required unless an LDAP group." json:"group_name,omitempty"`
+	GroupType   int    `short:"t" long:"group_type" description:"The group type, 1 for LDAP, 2 for HTTP, 3 for OIDC group." default:"1" validate:"min=1,max=3" json:"group_type"`
+	LDAPGroupDN string `short:"l" long:"ldap_group_dn" description:"The DN of the LDAP group, required if group type is 1 (LDAP group)." default:"" json:"ldap_group_dn,omitempty"`
+}
 
-func (x *usergroupCreate) Execute(args []string) error {
-	PostUsergroupCreate(utils.URLGen("/api/usergroups"))
-	return nil
+func (x *UsergroupCreate) Execute(args []string) error {
+	return utils.PrintResult(PostUsergroupCreate(utils.NewClient(), x))
 }
 
 // PostUsergroupCreate create user group information
@@ -93,40 +170,35 @@ curl -X POST --header 'Content-Type: application/json' --header 'Accept: text/pl
    "ldap_group_dn": "" \
  }' 'https://localhost/api/usergroups'
 */
-func PostUsergroupCreate(baseURL string) {
-	targetURL := baseURL
-	fmt.Println("==> POST", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
+func PostUsergroupCreate(c *harbor.Client, opt *UsergroupCreate) (*harbor.Result, error) {
+	if opt.GroupType == 1 && opt.LDAPGroupDN == "" {
+		return nil, errors.New("--ldap_group_dn is required for an LDAP group")
+	}
+	if opt.GroupType != 1 && opt.GroupName == "" {
+		return nil, errors.New("--group_name is required for an HTTP or OIDC group")
 	}
 
-	t, err := json.Marshal(&ugCreate)
+	targetURL := c.APIURL("/usergroups")
+	c.Trace("==> POST", targetURL)
+
+	t, err := json.Marshal(opt)
 	if err != nil {
-		fmt.Println("error:", err)
-		return
+		return nil, err
 	}
 
-	fmt.Println("===> usergroup create:", string(t))
+	c.Trace("==> usergroup create:", string(t))
 
-	utils.Request.Post(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		Send(string(t)).
-		End(utils.PrintStatus)
+	return c.Do(c.Post(targetURL).
+		Send(string(t)))
 }
 
-type usergroupDel struct {
+// UsergroupDel holds the parameters of DeleteUsergroup.
+type UsergroupDel struct {
 	ID int `short:"i" long:"id" description:"(REQUIRED) The ID of the user group" required:"yes"`
 }
 
-var ugDel usergroupDel
-
-func (x *usergroupDel) Execute(args []string) error {
-	DeleteUsergroup(utils.URLGen("/api/usergroups"))
-	return nil
+func (x *UsergroupDel) Execute(args []string) error {
+	return utils.PrintResult(DeleteUsergroup(utils.NewClient(), x))
 }
 
 // DeleteUsergroup delete user group
@@ -135,31 +207,20 @@ func (x *usergroupDel) Execute(args []string) error {
 //  id - (REQUIRED) The ID of the user group
 //
 // e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/usergroups/1'
-func DeleteUsergroup(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(ugDel.ID)
-	fmt.Println("==> DELETE", targetURL)
+func DeleteUsergroup(c *harbor.Client, opt *UsergroupDel) (*harbor.Result, error) {
+	targetURL := c.APIURL("/usergroups") + "/" + strconv.Itoa(opt.ID)
+	c.Trace("==> DELETE", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
-
-	utils.Request.Delete(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Delete(targetURL))
 }
 
-type usergroupGet struct {
+// UsergroupGet holds the parameters of GetUsergroup.
+type UsergroupGet struct {
 	ID int `short:"i" long:"id" description:"(REQUIRED) The ID of the user group" required:"yes"`
 }
 
-var ugGet usergroupGet
-
-func (x *usergroupGet) Execute(args []string) error {
-	GetUsergroup(utils.URLGen("/api/usergroups"))
-	return nil
+func (x *UsergroupGet) Execute(args []string) error {
+	return utils.PrintResult(GetUsergroup(utils.NewClient(), x))
 }
 
 // GetUsergroup get user group information
@@ -168,34 +229,23 @@ func (x *usergroupGet) Execute(args []string) error {
 //  id - (REQUIRED) The ID of the user group
 //
 // e.g. curl -X GET --header 'Accept: text/plain' 'https://localhost/api/usergroups/1'
-func GetUsergroup(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(ugGet.ID)
-	fmt.Println("==> GET", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
+func GetUsergroup(c *harbor.Client, opt *UsergroupGet) (*harbor.Result, error) {
+	targetURL := c.APIURL("/usergroups") + "/" + strconv.Itoa(opt.ID)
+	c.Trace("==> GET", targetURL)
 
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Get(targetURL))
 }
 
-type usergroupUpdate struct {
-	ID          int    `short:"i" long:"id" description:"The ID of the user group" default:"0" json:"id"`
-	GroupName   string `short:"n" long:"group_name" description:"The name of the user group" default:"tmp-group" json:"group_name"`
-	GroupType   int    `short:"t" long:"group_type" description:"The group type, 1 for LDAP group." default:"1" json:"group_type"`
-	LDAPGroupDN string `short:"l" long:"ldap_group_dn" description:"The DN of the LDAP group if group type is 1 (LDAP group)." default:"" json:"ldap_group_dn"`
+// UsergroupUpdate holds the parameters of PutUsergroup.
+type UsergroupUpdate struct {
+	ID          int    `short:"i" long:"id" description:"(REQUIRED) The ID of the user group" required:"yes" json:"id"`
+	GroupName   string `short:"n" long:"group_name" description:"(REQUIRED) The new name of the user group" required:"yes" json:"group_name"`
+	GroupType   int    `short:"t" long:"group_type" description:"The group type, 1 for LDAP, 2 for HTTP, 3 for OIDC group, cannot be changed." validate:"min=1,max=3" json:"group_type,omitempty"`
+	LDAPGroupDN string `short:"l" long:"ldap_group_dn" description:"The DN of the LDAP group, cannot be changed." default:"" json:"ldap_group_dn,omitempty"`
 }
 
-var ugUpdate usergroupUpdate
-
-func (x *usergroupUpdate) Execute(args []string) error {
-	PutUsergroup(utils.URLGen("/api/usergroups"))
-	return nil
+func (x *UsergroupUpdate) Execute(args []string) error {
+	return utils.PrintResult(PutUsergroup(utils.NewClient(), x))
 }
 
 // PutUsergroup update user group information
@@ -215,27 +265,17 @@ curl -X PUT --header 'Content-Type: application/json' --header 'Accept: text/pla
    "ldap_group_dn": "" \
  }' 'https://localhost/api/usergroups/1'
 */
-func PutUsergroup(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(ugUpdate.ID)
-	fmt.Println("==> PUT", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
+func PutUsergroup(c *harbor.Client, opt *UsergroupUpdate) (*harbor.Result, error) {
+	targetURL := c.APIURL("/usergroups") + "/" + strconv.Itoa(opt.ID)
+	c.Trace("==> PUT", targetURL)
 
-	t, err := json.Marshal(&ugUpdate)
+	t, err := json.Marshal(opt)
 	if err != nil {
-		fmt.Println("error:", err)
-		return
+		return nil, err
 	}
 
-	fmt.Println("===> usergroup update:", string(t))
+	c.Trace("==> usergroup update:", string(t))
 
-	utils.Request.Put(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		Send(string(t)).
-		End(utils.PrintStatus)
+	return c.Do(c.Put(targetURL).
+		Send(string(t)))
 }
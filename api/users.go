@@ -2,10 +2,14 @@ package api
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
 	"github.com/moooofly/harbor-go-client/utils"
 )
 
@@ -13,50 +17,65 @@ func init() {
 	utils.Parser.AddCommand("user_update_role",
 		"Update a registered user to change to be an administrator of Harbor.",
 		"This endpoint let a registered user change to be an administrator of Harbor.",
-		&usrUpdateRole)
+		&UserUpdateRole{})
 	utils.Parser.AddCommand("user_update_password",
 		"Change the password on a user that already exists.",
 		"This endpoint is for user to update password. Users with the admin role can change any user's password. Guest users can change only their own password.",
-		&usrUpdatePassword)
+		&UserUpdatePassword{})
 	utils.Parser.AddCommand("user_update",
 		"Update a registered user to change his profile.",
 		"This endpoint let a registered user change his profile.",
-		&usrUpdate)
+		&UserUpdate{})
 	utils.Parser.AddCommand("user_get",
 		"Get a user's profile.",
 		"Get user's profile with user id.",
-		&usrGet)
-	utils.Parser.AddCommand("user_delete",
+		&UserGet{})
+	cmd, _ := utils.Parser.AddCommand("user_delete",
 		"Mark a registered user as be removed.",
 		"This endpoint let administrator of Harbor mark a registered user as be removed. It actually won't be deleted from DB.",
-		&usrDelete)
+		&UserDelete{})
+	if cmd != nil {
+		cmd.Aliases = []string{"user_del"}
+	}
+	utils.Parser.AddCommand("user_set_sysadmin",
+		"Grant or revoke the system admin role of a user.",
+		"This endpoint grants a registered user the system admin role of Harbor, or revokes it with --unset.",
+		&UserSetSysadmin{})
+	utils.Parser.AddCommand("user_list",
+		"List the users of Harbor.",
+		"This endpoint lists the registered users with their profile, filtered by username and email (partial match), page by page. Notice, by now this operation is only for administrator.",
+		&UserList{})
 	utils.Parser.AddCommand("user_create",
 		"Creates a new user account.",
 		"This endpoint is to create a user if the user does not already exist.",
-		&usrCreate)
+		&UserCreate{})
 	utils.Parser.AddCommand("users_search",
 		"Get registered users of Harbor.",
 		"This endpoint is for user to search registered users, support for filtering results with username. Notice, by now this operation is only for administrator.",
-		&usrSearch)
+		&UsersSearch{})
 	// NOTE:
 	// 由于 user_current 命令是是用于列出当前 login 用户相关信息
 	// 故将其改名为 whoami
 	utils.Parser.AddCommand("whoami",
 		"Show info about current login user only.",
 		"Maybe 'whoami' is a better name.",
-		&usrCurrent)
+		&UserCurrent{})
+	utils.RequireAdmin("user_update_role", "user_set_sysadmin", "user_delete", "users_search", "user_list")
+
+	utils.ResponseModel("user_get", model.User{})
+	utils.ResponseModel("user_list", model.User{})
+	utils.ResponseModel("users_search", model.UserSearchResult{})
+	utils.ResponseModel("whoami", model.User{})
 }
 
-type userUpdateRole struct {
+// UserUpdateRole holds the parameters of PutUserUpdateRole.
+type UserUpdateRole struct {
 	UserID       int `short:"i" long:"user_id" description:"(REQUIRED) Registered user ID." required:"yes" json:"-"`
 	HasAdminRole int `short:"r" long:"has_admin_role" description:"(REQUIRED) Toggle a user to admin or not." required:"yes" json:"has_admin_role"`
 }
 
-var usrUpdateRole userUpdateRole
-
-func (x *userUpdateRole) Execute(args []string) error {
-	PutUserUpdateRole(utils.URLGen("/api/users"))
-	return nil
+func (x *UserUpdateRole) Execute(args []string) error {
+	return utils.PrintResult(PutUserUpdateRole(utils.NewClient(), x))
 }
 
 // PutUserUpdateRole let a registered user change to be an administrator of Harbor.
@@ -73,50 +92,96 @@ func (x *userUpdateRole) Execute(args []string) error {
 //    "has_admin_role": 1 \
 //  }' 'https://localhost/api/users/1/sysadmin'
 //
-func PutUserUpdateRole(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(usrUpdateRole.UserID) + "/sysadmin"
+// With v2.0 API, the body is '{"sysadmin_flag": true}'.
+//
+func PutUserUpdateRole(c *harbor.Client, opt *UserUpdateRole) (*harbor.Result, error) {
+	targetURL := c.APIURL("/users") + "/" + strconv.Itoa(opt.UserID) + "/sysadmin"
 
-	fmt.Println("==> PUT", targetURL)
+	c.Trace("==> PUT", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
+	var body interface{} = opt
+	if c.IsV2() {
+		body = map[string]bool{"sysadmin_flag": opt.HasAdminRole != 0}
 	}
-
-	t, err := json.Marshal(&usrUpdateRole)
+	t, err := json.Marshal(body)
 	if err != nil {
-		fmt.Println("error:", err)
-		return
+		return nil, err
 	}
 
-	fmt.Println("==> user_update_role:", string(t))
+	c.Trace("==> user_update_role:", string(t))
+
+	return c.Do(c.Put(targetURL).
+		Send(string(t)))
+}
+
+// UserSetSysadmin holds the parameters of the user_set_sysadmin command.
+type UserSetSysadmin struct {
+	UserID int  `short:"i" long:"user_id" description:"(REQUIRED) Registered user ID." required:"yes"`
+	Unset  bool `long:"unset" description:"Revoke the system admin role instead of granting it."`
+}
 
-	utils.Request.Put(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		Send(string(t)).
-		End(utils.PrintStatus)
+func (x *UserSetSysadmin) Execute(args []string) error {
+	opt := &UserUpdateRole{UserID: x.UserID, HasAdminRole: 1}
+	if x.Unset {
+		opt.HasAdminRole = 0
+	}
+	return utils.PrintResult(PutUserUpdateRole(utils.NewClient(), opt))
 }
 
-type userUpdatePassword struct {
-	UserID      int    `short:"i" long:"user_id" description:"(REQUIRED) Registered user ID." required:"yes" json:"-"`
-	OldPassword string `short:"o" long:"old_password" description:"(REQUIRED) Old password." required:"yes" json:"old_password"`
-	NewPassword string `short:"n" long:"new_password" description:"(REQUIRED) New password." required:"yes" json:"new_password"`
+// UserUpdatePassword holds the parameters of PutUserUpdatePassword.
+type UserUpdatePassword struct {
+	UserID      int    `short:"i" long:"user_id" description:"Registered user ID, the current user if not set." json:"-"`
+	OldPassword string `short:"o" long:"old_password" description:"Old password, prompted for if not set." json:"old_password,omitempty"`
+	NewPassword string `short:"n" long:"new_password" description:"New password, prompted for (twice) if not set." json:"new_password"`
+	Reset       bool   `long:"reset" description:"Reset the password of another user as an administrator, the old password is not needed." json:"-"`
 }
 
-var usrUpdatePassword userUpdatePassword
+func (x *UserUpdatePassword) Execute(args []string) error {
+	c := utils.NewClient()
+	if x.UserID == 0 {
+		if x.Reset {
+			return errors.New("--reset needs the --user_id of the user")
+		}
+		var me model.User
+		if err := c.GetJSON(c.APIURL("/users")+"/current", &me); err != nil {
+			return err
+		}
+		x.UserID = int(me.UserID)
+	}
 
-func (x *userUpdatePassword) Execute(args []string) error {
-	PutUserUpdatePassword(utils.URLGen("/api/users"))
-	return nil
+	if x.OldPassword == "" && !x.Reset {
+		passwd, err := utils.ReadSecretFromTerm("Old password: ")
+		if err != nil {
+			return err
+		}
+		x.OldPassword = passwd
+	}
+	if x.NewPassword == "" {
+		passwd, err := utils.ReadSecretFromTerm("New password: ")
+		if err != nil {
+			return err
+		}
+		again, err := utils.ReadSecretFromTerm("Retype new password: ")
+		if err != nil {
+			return err
+		}
+		if passwd != again {
+			return errors.New("passwords do not match")
+		}
+		x.NewPassword = passwd
+	}
+	if x.NewPassword == "" {
+		return errors.New("new password required")
+	}
+
+	return utils.PrintResult(PutUserUpdatePassword(c, x))
 }
 
 // PutUserUpdatePassword is for user to update password. Users with the admin role can change any user's password. Guest users can change only their own password.
 //
 // params:
 //  id - (REQUIRED) Registered user ID.
-//  old_password - (REQUIRED) Old password.
+//  old_password - Old password, not needed by administrators resetting the password of another user.
 //  new_password - (REQUIRED) New password.
 //
 // format:
@@ -128,33 +193,30 @@ func (x *userUpdatePassword) Execute(args []string) error {
 //    "new_password": "new password" \
 //  }' 'https://localhost/api/users/1/password'
 //
-func PutUserUpdatePassword(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(usrUpdatePassword.UserID) + "/password"
+func PutUserUpdatePassword(c *harbor.Client, opt *UserUpdatePassword) (*harbor.Result, error) {
+	targetURL := c.APIURL("/users") + "/" + strconv.Itoa(opt.UserID) + "/password"
 
-	fmt.Println("==> PUT", targetURL)
+	c.Trace("==> PUT", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
+	t, err := json.Marshal(opt)
 	if err != nil {
-		fmt.Println("Error:", err)
-		return
+		return nil, err
 	}
 
-	t, err := json.Marshal(&usrUpdatePassword)
-	if err != nil {
-		fmt.Println("error:", err)
-		return
+	masked := *opt
+	if masked.OldPassword != "" {
+		masked.OldPassword = "***"
 	}
+	masked.NewPassword = "***"
+	m, _ := json.Marshal(&masked)
+	c.Trace("==> user_update_password:", string(m))
 
-	fmt.Println("==> user_update_password:", string(t))
-
-	utils.Request.Put(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		Send(string(t)).
-		End(utils.PrintStatus)
+	return c.Do(c.Put(targetURL).
+		Send(string(t)))
 }
 
-type userUpdate struct {
+// UserUpdate holds the parameters of PutUserUpdate.
+type UserUpdate struct {
 	UserID int `short:"i" long:"user_id" description:"(REQUIRED) Registered user ID." required:"yes" json:"-"`
 	// Only email, realname and comment can be modified.
 	Email    string `short:"e" long:"email" description:"(REQUIRED) User email." required:"yes" json:"email"`
@@ -162,11 +224,8 @@ type userUpdate struct {
 	Comment  string `short:"m" long:"comment" description:"(REQUIRED) Custom comment." required:"yes" json:"comment"`
 }
 
-var usrUpdate userUpdate
-
-func (x *userUpdate) Execute(args []string) error {
-	PutUserUpdate(utils.URLGen("/api/users"))
-	return nil
+func (x *UserUpdate) Execute(args []string) error {
+	return utils.PrintResult(PutUserUpdate(utils.NewClient(), x))
 }
 
 // PutUserUpdate let a registered user change his profile.
@@ -187,41 +246,29 @@ func (x *userUpdate) Execute(args []string) error {
 //    "comment": "I'm Li Si" \
 //  }' 'https://localhost/api/users/1'
 //
-func PutUserUpdate(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(usrUpdate.UserID)
+func PutUserUpdate(c *harbor.Client, opt *UserUpdate) (*harbor.Result, error) {
+	targetURL := c.APIURL("/users") + "/" + strconv.Itoa(opt.UserID)
 
-	fmt.Println("==> PUT", targetURL)
+	c.Trace("==> PUT", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
+	t, err := json.Marshal(opt)
 	if err != nil {
-		fmt.Println("Error:", err)
-		return
+		return nil, err
 	}
 
-	t, err := json.Marshal(&usrUpdate)
-	if err != nil {
-		fmt.Println("error:", err)
-		return
-	}
+	c.Trace("==> user_update:", string(t))
 
-	fmt.Println("==> user_update:", string(t))
-
-	utils.Request.Put(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		Send(string(t)).
-		End(utils.PrintStatus)
+	return c.Do(c.Put(targetURL).
+		Send(string(t)))
 }
 
-type userGet struct {
+// UserGet holds the parameters of GetUserProfile.
+type UserGet struct {
 	UserID int `short:"i" long:"user_id" description:"(REQUIRED) Registered user ID." required:"yes"`
 }
 
-var usrGet userGet
-
-func (x *userGet) Execute(args []string) error {
-	GetUserProfile(utils.URLGen("/api/users"))
-	return nil
+func (x *UserGet) Execute(args []string) error {
+	return utils.PrintResult(GetUserProfile(utils.NewClient(), x))
 }
 
 // GetUserProfile gets user's profile with user id.
@@ -234,32 +281,21 @@ func (x *userGet) Execute(args []string) error {
 //
 // e.g. curl -X GET --header 'Accept: text/plain' 'https://localhost/api/users/1'
 //
-func GetUserProfile(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(usrGet.UserID)
+func GetUserProfile(c *harbor.Client, opt *UserGet) (*harbor.Result, error) {
+	targetURL := c.APIURL("/users") + "/" + strconv.Itoa(opt.UserID)
 
-	fmt.Println("==> GET", targetURL)
+	c.Trace("==> GET", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
-
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Get(targetURL))
 }
 
-type userDelete struct {
+// UserDelete holds the parameters of DeleteUser.
+type UserDelete struct {
 	UserID int `short:"i" long:"user_id" description:"(REQUIRED) User ID for marking as to be removed." required:"yes"`
 }
 
-var usrDelete userDelete
-
-func (x *userDelete) Execute(args []string) error {
-	DeleteUser(utils.URLGen("/api/users"))
-	return nil
+func (x *UserDelete) Execute(args []string) error {
+	return utils.PrintResult(DeleteUser(utils.NewClient(), x))
 }
 
 // DeleteUser let administrator of Harbor mark a registered user as be removed.It actually won't be deleted from DB.
@@ -272,29 +308,21 @@ func (x *userDelete) Execute(args []string) error {
 //
 // e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/users/1'
 //
-func DeleteUser(baseURL string) {
-	targetURL := baseURL + "/" + strconv.Itoa(usrDelete.UserID)
+func DeleteUser(c *harbor.Client, opt *UserDelete) (*harbor.Result, error) {
+	targetURL := c.APIURL("/users") + "/" + strconv.Itoa(opt.UserID)
 
-	fmt.Println("==> DELETE", targetURL)
+	c.Trace("==> DELETE", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
-
-	utils.Request.Delete(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return c.Do(c.Delete(targetURL))
 }
 
-type userCreate struct {
-	UserID       int    `long:"user_id" description:"(REQUIRED) Registered user ID. Must be unique." required:"yes" json:"user_id"`
-	Username     string `long:"username" description:"(REQUIRED) User name." required:"yes" json:"username"`
-	Password     string `long:"password" description:"(REQUIRED) User password. (not support consealing here)" required:"yes" json:"password"`
-	Email        string `long:"email" description:"(REQUIRED) User's email." required:"yes" json:"email"`
-	HasAdminRole int    `long:"has_admin_role" description:"(REQUIRED) Mark a user whether is admin or not." required:"yes" json:"has_admin_role"`
+// UserCreate holds the parameters of PostUserCreate.
+type UserCreate struct {
+	UserID       int    `long:"user_id" description:"(REQUIRED) Registered user ID. Must be unique." validate:"required" json:"user_id"`
+	Username     string `long:"username" description:"(REQUIRED) User name." validate:"required" json:"username"`
+	Password     string `long:"password" description:"(REQUIRED) User password. (not support consealing here)" validate:"required" json:"password"`
+	Email        string `long:"email" description:"(REQUIRED) User's email." validate:"required" json:"email"`
+	HasAdminRole int    `long:"has_admin_role" description:"Mark a user whether is admin or not." default:"0" json:"has_admin_role"`
 	// realname can not be "", at least one character needed.
 	RealName     string `long:"realname" description:"User's realname." default:" " json:"realname"`
 	Comment      string `long:"comment" description:"Custom comment." default:"" json:"comment"`
@@ -305,13 +333,11 @@ type userCreate struct {
 	Salt         string `long:"salt" description:"Salt for password encryption." default:"" json:"salt"`
 	CreationTime string `short:"c" long:"creation_time" description:"User's creation time. Default time.Now()." default:"" json:"creation_time"`
 	UpdateTime   string `short:"u" long:"update_time" description:"User's update time. Default time.Now()." default:"" json:"update_time"`
+	File         string `short:"f" long:"file" description:"Read the request from a JSON or YAML spec file (or @file, - for stdin) instead of flags." spec:"file" json:"-"`
 }
 
-var usrCreate userCreate
-
-func (x *userCreate) Execute(args []string) error {
-	PostUserCreate(utils.URLGen("/api/users"))
-	return nil
+func (x *UserCreate) Execute(args []string) error {
+	return utils.PrintResult(PostUserCreate(utils.NewClient(), x))
 }
 
 // PostUserCreate Creates a new user account.
@@ -353,49 +379,48 @@ func (x *userCreate) Execute(args []string) error {
 //    "update_time": "2018-07-23T05:59:26Z" \
 //  }' 'https://localhost/api/users'
 //
-func PostUserCreate(baseURL string) {
-	if usrCreate.CreationTime == "" || usrCreate.UpdateTime == "" {
-		now := time.Now().Format("2006-01-02T15:04:05Z")
-		usrCreate.CreationTime = now
-		usrCreate.UpdateTime = now
+func PostUserCreate(c *harbor.Client, opt *UserCreate) (*harbor.Result, error) {
+	if opt.File != "" {
+		if err := utils.SpecLoad(opt.File, opt); err != nil {
+			return nil, err
+		}
+	}
+	if err := utils.Validate(opt); err != nil {
+		return nil, err
 	}
 
-	targetURL := baseURL
-	fmt.Println("==> POST", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
+	if opt.CreationTime == "" || opt.UpdateTime == "" {
+		now := time.Now().Format("2006-01-02T15:04:05Z")
+		opt.CreationTime = now
+		opt.UpdateTime = now
 	}
 
-	t, err := json.Marshal(&usrCreate)
+	targetURL := c.APIURL("/users")
+	c.Trace("==> POST", targetURL)
+
+	t, err := json.Marshal(opt)
 	if err != nil {
-		fmt.Println("error:", err)
-		return
+		return nil, err
 	}
 
-	fmt.Println("==> user_create:", string(t))
+	c.Trace("==> user_create:", string(t))
 
-	utils.Request.Post(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		Send(string(t)).
-		End(utils.PrintStatus)
+	return c.Do(c.Post(targetURL).
+		Send(string(t)))
 }
 
-type usersSearch struct {
+// UsersSearch holds the parameters of GetUsersSearch.
+type UsersSearch struct {
 	Username string `short:"u" long:"username" description:"Username for filtering results." default:""`
 	Email    string `short:"e" long:"email" description:"Email for filtering results." default:""`
 	Page     int    `short:"p" long:"page" description:"The page nubmer, default is 1." default:"1"`
-	PageSize int    `short:"s" long:"page_size" description:"The size of per page, default is 10." default:"10"`
+	PageSize int    `short:"s" long:"page_size" description:"The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs." default:"10" validate:"pagesize"`
+	Count    bool   `long:"count" description:"Print the total number of matched items only."`
+	All      bool   `long:"all" description:"Fetch all pages, --page and --page_size are ignored."`
 }
 
-var usrSearch usersSearch
-
-func (x *usersSearch) Execute(args []string) error {
-	GetUsersSearch(utils.URLGen("/api/users"))
-	return nil
+func (x *UsersSearch) Execute(args []string) error {
+	return utils.ListPrinter(x.Count)(GetUsersSearch(utils.NewClient(), x))
 }
 
 // GetUsersSearch Get registered users of Harbor.
@@ -411,34 +436,95 @@ func (x *usersSearch) Execute(args []string) error {
 //
 // e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/users?username=san.zhang&email=san.zhang@163.com&page=1&page_size=10'
 //
-func GetUsersSearch(baseURL string) {
-	targetURL := baseURL + "?username=" + usrSearch.Username +
-		"&email=" + usrSearch.Email +
-		"&page=" + strconv.Itoa(usrSearch.Page) +
-		"&page_size=" + strconv.Itoa(usrSearch.PageSize)
+func GetUsersSearch(c *harbor.Client, opt *UsersSearch) (*harbor.Result, error) {
+	if opt.Count {
+		opt.Page, opt.PageSize = 1, 1
+	}
 
-	fmt.Println("==> GET", targetURL)
+	targetURL := c.APIURL("/users") + "?" + url.Values{
+		"username":  {opt.Username},
+		"email":     {opt.Email},
+		"page":      {strconv.Itoa(opt.Page)},
+		"page_size": {strconv.Itoa(opt.PageSize)},
+	}.Encode()
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("error:", err)
-		return
+	if !opt.Count {
+		c.Trace("==> GET", targetURL)
 	}
 
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	if opt.All && !opt.Count {
+		return c.StreamAllPages(targetURL), nil
+	}
+
+	return c.DoStream(c.Get(targetURL))
 }
 
-type userCurrent struct {
+// UserList holds the parameters of GetUsers.
+type UserList struct {
+	Username string `short:"u" long:"username" description:"Username for filtering results, partial match."`
+	Email    string `short:"e" long:"email" description:"Email for filtering results, partial match."`
+	Page     int    `short:"p" long:"page" description:"The page nubmer, default is 1." default:"1"`
+	PageSize int    `short:"s" long:"page_size" description:"The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs." default:"10" validate:"pagesize"`
+	Count    bool   `long:"count" description:"Print the total number of matched items only."`
+	All      bool   `long:"all" description:"Fetch all pages, --page and --page_size are ignored."`
+}
+
+func (x *UserList) Execute(args []string) error {
+	return utils.ListPrinter(x.Count)(GetUsers(utils.NewClient(), x))
+}
+
+// GetUsers lists the registered users with their profile.
+//
+// params:
+//  username - Username for filtering results, partial match.
+//  email - Email for filtering results, partial match.
+//  page - The page nubmer, default is 1.
+//  page_size - The size of per page, default is 10.
+//
+// format:
+//  GET /users
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/users?q=username%3D~san%2Cemail%3D~163.com&page=1&page_size=10'
+//
+func GetUsers(c *harbor.Client, opt *UserList) (*harbor.Result, error) {
+	if opt.Count {
+		opt.Page, opt.PageSize = 1, 1
+	}
+
+	var targetURL string
+	if c.IsV2() {
+		var q []string
+		if opt.Username != "" {
+			q = append(q, "username=~"+opt.Username)
+		}
+		if opt.Email != "" {
+			q = append(q, "email=~"+opt.Email)
+		}
+		targetURL = c.URL("/api/v2.0/users") + "?q=" + url.QueryEscape(strings.Join(q, ","))
+	} else {
+		targetURL = c.URL("/api/users") + "?username=" + url.QueryEscape(opt.Username) +
+			"&email=" + url.QueryEscape(opt.Email)
+	}
+	targetURL += "&page=" + strconv.Itoa(opt.Page) +
+		"&page_size=" + strconv.Itoa(opt.PageSize)
+
+	if !opt.Count {
+		c.Trace("==> GET", targetURL)
+	}
+
+	if opt.All && !opt.Count {
+		return c.StreamAllPages(targetURL), nil
+	}
+
+	return c.DoStream(c.Get(targetURL))
 }
 
-var usrCurrent userCurrent
+// UserCurrent is the whoami command.
+type UserCurrent struct {
+}
 
-func (x *userCurrent) Execute(args []string) error {
-	GetUserCurrent(utils.URLGen("/api/users"))
-	return nil
+func (x *UserCurrent) Execute(args []string) error {
+	return utils.PrintResult(GetUserCurrent(utils.NewClient()))
 }
 
 // GetUserCurrent gets the current user information.
@@ -448,21 +534,12 @@ func (x *userCurrent) Execute(args []string) error {
 //
 // e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/users/current?api_key=top'
 //
-func GetUserCurrent(baseURL string) {
-	targetURL := baseURL + "/current"
-	fmt.Println("==> GET", targetURL)
+func GetUserCurrent(c *harbor.Client) (*harbor.Result, error) {
+	targetURL := c.APIURL("/users") + "/current"
+	c.Trace("==> GET", targetURL)
 
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("error:", err)
-		return
-	}
-
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		// NOTE:
-		// 若后续需要根据用户权限做文章，则需要将用户信息进行维护
-		// 可以定制一个新的回调函数
-		End(utils.PrintStatus)
+	// NOTE:
+	// 若后续需要根据用户权限做文章，则需要将用户信息进行维护
+	// 可以定制一个新的回调函数
+	return c.Do(c.Get(targetURL))
 }
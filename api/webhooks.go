@@ -0,0 +1,429 @@
+package api
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+func init() {
+	utils.Parser.AddCommand("webhook_policy_create",
+		"Create a webhook policy of a project.",
+		"This endpoint creates a webhook policy of a project, notifying the events of --event_type to an HTTP endpoint or a Slack channel. (Harbor v1.9+)",
+		&WebhookPolicyCreate{})
+	utils.Parser.AddCommand("webhook_policies_list",
+		"List the webhook policies of a project.",
+		"This endpoint lists the webhook policies of a project. (Harbor v1.9+)",
+		&WebhookPoliciesList{})
+	utils.Parser.AddCommand("webhook_policy_get",
+		"Get a webhook policy of a project.",
+		"This endpoint gets a webhook policy of a project by specific ID. (Harbor v1.9+)",
+		&WebhookPolicyGet{})
+	utils.Parser.AddCommand("webhook_policy_update",
+		"Update a webhook policy of a project.",
+		"This endpoint updates a webhook policy of a project by specific ID: the settings given are changed, the other ones kept; --address replaces the target of the policy. (Harbor v1.9+)",
+		&WebhookPolicyUpdate{})
+	utils.Parser.AddCommand("webhook_policy_del",
+		"Delete a webhook policy of a project.",
+		"This endpoint deletes a webhook policy of a project by specific ID. (Harbor v1.9+)",
+		&WebhookPolicyDel{})
+	utils.Parser.AddCommand("webhook_policy_test",
+		"Send a test event to webhook targets.",
+		"This endpoint sends a test event to the targets of a webhook policy, or to the target given by --address, to check that they are reachable before wiring events to them. (Harbor v1.9+)",
+		&WebhookPolicyTest{})
+	utils.Parser.AddCommand("webhook_lasttrigger",
+		"Get the last triggers of the webhook policies of a project.",
+		"This endpoint gets the last time every webhook policy of a project was triggered, by event type. (Harbor v1.9+)",
+		&WebhookLastTrigger{})
+	utils.Parser.AddCommand("webhook_jobs_list",
+		"List the jobs of a webhook policy.",
+		"This endpoint lists the jobs of a webhook policy, the notifications sent with their status, see webhook_replay to send one again. (Harbor v1.9+)",
+		&WebhookJobsList{})
+	utils.ResponseModel("webhook_policies_list", model.WebhookPolicy{})
+	utils.ResponseModel("webhook_policy_get", model.WebhookPolicy{})
+	utils.ResponseModel("webhook_lasttrigger", model.WebhookLastTrigger{})
+	utils.ResponseModel("webhook_jobs_list", model.WebhookJob{})
+}
+
+// webhookURL returns the URL of the webhook endpoints of the project.
+func webhookURL(c *harbor.Client, projectID int) string {
+	return c.APIURL("/projects") + "/" + strconv.Itoa(projectID) + "/webhook"
+}
+
+// webhookTarget returns the target of the flags, nil without address.
+func webhookTarget(notifyType, address, authHeader string, skipCertVerify bool) *model.WebhookTarget {
+	if address == "" {
+		return nil
+	}
+	return &model.WebhookTarget{
+		Type:           notifyType,
+		Address:        address,
+		AuthHeader:     authHeader,
+		SkipCertVerify: skipCertVerify,
+	}
+}
+
+// WebhookPolicyCreate holds the parameters of PostWebhookPolicy.
+type WebhookPolicyCreate struct {
+	ProjectID      int      `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
+	Name           string   `short:"n" long:"name" description:"(REQUIRED) The name of the webhook policy." required:"yes"`
+	Description    string   `short:"d" long:"description" description:"The description of the webhook policy."`
+	EventTypes     []string `short:"e" long:"event_type" description:"(REQUIRED) An event type to notify, may be repeated, e.g. PUSH_ARTIFACT, PULL_ARTIFACT, DELETE_ARTIFACT, SCANNING_COMPLETED, SCANNING_FAILED, QUOTA_EXCEED, REPLICATION (pushImage, pullImage, deleteImage, scanningCompleted... before Harbor v2.0)." required:"yes"`
+	Address        string   `short:"a" long:"address" description:"(REQUIRED) The URL of the endpoint notified, an HTTP endpoint or a Slack incoming webhook." required:"yes"`
+	NotifyType     string   `short:"t" long:"notify_type" description:"The type of the endpoint notified." choice:"http" choice:"slack" default:"http"`
+	AuthHeader     string   `long:"auth_header" description:"The Authorization header sent to the HTTP endpoint, e.g. 'Bearer TOKEN'."`
+	SkipCertVerify bool     `long:"skip_cert_verify" description:"Do not verify the certificate of the endpoint."`
+	Disabled       bool     `long:"disabled" description:"Leave the policy disabled."`
+}
+
+func (x *WebhookPolicyCreate) Execute(args []string) error {
+	return utils.PrintResult(PostWebhookPolicy(utils.NewClient(), x))
+}
+
+// PostWebhookPolicy creates a webhook policy of a project.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   name - (REQUIRED) The name of the webhook policy.
+//   description - The description of the webhook policy.
+//   event_type - (REQUIRED) The event types to notify.
+//   address - (REQUIRED) The URL of the endpoint notified.
+//   notify_type - The type of the endpoint, http or slack.
+//   auth_header - The Authorization header sent to the endpoint.
+//   skip_cert_verify - Do not verify the certificate of the endpoint.
+//
+// format:
+//   POST /projects/{project_id}/webhook/policies
+//
+// e.g.
+/*
+  curl -X POST --header 'Content-Type: application/json' --header 'Accept: application/json' -d '{ \
+     "name": "ci", \
+     "description": "", \
+     "project_id": 1, \
+     "targets": [{"type": "http", "address": "https://ci.example.com/hook", "auth_header": "", "skip_cert_verify": false}], \
+     "event_types": ["PUSH_ARTIFACT"], \
+     "enabled": true \
+   }' 'https://localhost/api/v2.0/projects/1/webhook/policies'
+*/
+func PostWebhookPolicy(c *harbor.Client, opt *WebhookPolicyCreate) (*harbor.Result, error) {
+	body := struct {
+		Name        string                 `json:"name"`
+		Description string                 `json:"description"`
+		ProjectID   int                    `json:"project_id"`
+		Targets     []*model.WebhookTarget `json:"targets"`
+		EventTypes  []string               `json:"event_types"`
+		Enabled     bool                   `json:"enabled"`
+	}{
+		Name:        opt.Name,
+		Description: opt.Description,
+		ProjectID:   opt.ProjectID,
+		Targets:     []*model.WebhookTarget{webhookTarget(opt.NotifyType, opt.Address, opt.AuthHeader, opt.SkipCertVerify)},
+		EventTypes:  opt.EventTypes,
+		Enabled:     !opt.Disabled,
+	}
+
+	targetURL := webhookURL(c, opt.ProjectID) + "/policies"
+	c.Trace("==> POST", targetURL)
+
+	return c.Do(c.Post(targetURL).
+		Send(body))
+}
+
+// WebhookPoliciesList holds the parameters of GetWebhookPolicies.
+type WebhookPoliciesList struct {
+	ProjectID int  `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
+	Page      int  `short:"p" long:"page" description:"The page nubmer, default is 1." default:"1"`
+	PageSize  int  `short:"s" long:"page_size" description:"The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs." default:"10" validate:"pagesize"`
+	Count     bool `long:"count" description:"Print the total number of matched items only."`
+	All       bool `long:"all" description:"Fetch all pages, --page and --page_size are ignored."`
+}
+
+func (x *WebhookPoliciesList) Execute(args []string) error {
+	return utils.ListPrinter(x.Count)(GetWebhookPolicies(utils.NewClient(), x))
+}
+
+// GetWebhookPolicies lists the webhook policies of a project.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   page - The page nubmer, default is 1.
+//   page_size - The size of per page, default is 10.
+//
+// format:
+//   GET /projects/{project_id}/webhook/policies
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/projects/1/webhook/policies?page=1&page_size=10'
+//
+func GetWebhookPolicies(c *harbor.Client, opt *WebhookPoliciesList) (*harbor.Result, error) {
+	if opt.Count {
+		opt.Page, opt.PageSize = 1, 1
+	}
+
+	targetURL := webhookURL(c, opt.ProjectID) + "/policies?page=" + strconv.Itoa(opt.Page) +
+		"&page_size=" + strconv.Itoa(opt.PageSize)
+
+	if !opt.Count {
+		c.Trace("==> GET", targetURL)
+	}
+
+	if opt.All && !opt.Count {
+		return c.StreamAllPages(targetURL), nil
+	}
+
+	return c.DoStream(c.Get(targetURL))
+}
+
+// WebhookPolicyGet holds the parameters of GetWebhookPolicy.
+type WebhookPolicyGet struct {
+	ProjectID int `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
+	ID        int `short:"i" long:"policy_id" description:"(REQUIRED) The ID of the webhook policy." required:"yes"`
+}
+
+func (x *WebhookPolicyGet) Execute(args []string) error {
+	return utils.PrintResult(GetWebhookPolicy(utils.NewClient(), x))
+}
+
+// GetWebhookPolicy gets a webhook policy of a project.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   policy_id - (REQUIRED) The ID of the webhook policy.
+//
+// format:
+//   GET /projects/{project_id}/webhook/policies/{policy_id}
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/projects/1/webhook/policies/2'
+//
+func GetWebhookPolicy(c *harbor.Client, opt *WebhookPolicyGet) (*harbor.Result, error) {
+	targetURL := webhookURL(c, opt.ProjectID) + "/policies/" + strconv.Itoa(opt.ID)
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
+}
+
+// WebhookPolicyUpdate holds the parameters of PutWebhookPolicy.
+type WebhookPolicyUpdate struct {
+	ProjectID      int      `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
+	ID             int      `short:"i" long:"policy_id" description:"(REQUIRED) The ID of the webhook policy." required:"yes"`
+	Name           string   `short:"n" long:"name" description:"The new name of the webhook policy, unchanged if not set."`
+	Description    string   `short:"d" long:"description" description:"The new description of the webhook policy, unchanged if not set."`
+	EventTypes     []string `short:"e" long:"event_type" description:"An event type to notify, may be repeated; the event types given replace those of the policy, unchanged if none."`
+	Address        string   `short:"a" long:"address" description:"The URL of the endpoint notified, replacing the targets of the policy, unchanged if not set."`
+	NotifyType     string   `short:"t" long:"notify_type" description:"The type of the endpoint given by --address." choice:"http" choice:"slack" default:"http"`
+	AuthHeader     string   `long:"auth_header" description:"The Authorization header sent to the endpoint given by --address."`
+	SkipCertVerify bool     `long:"skip_cert_verify" description:"Do not verify the certificate of the endpoint given by --address."`
+	Enable         bool     `long:"enable" description:"Enable the policy."`
+	Disable        bool     `long:"disable" description:"Disable the policy."`
+}
+
+func (x *WebhookPolicyUpdate) Execute(args []string) error {
+	return utils.PrintResult(PutWebhookPolicy(utils.NewClient(), x))
+}
+
+// PutWebhookPolicy updates a webhook policy of a project, the policy is got
+// and updated as is but the settings given.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   policy_id - (REQUIRED) The ID of the webhook policy.
+//
+// format:
+//   GET /projects/{project_id}/webhook/policies/{policy_id}
+//   PUT /projects/{project_id}/webhook/policies/{policy_id}
+//
+// e.g. curl -X PUT --header 'Content-Type: application/json' -d '{"id": 2, "name": "ci", "targets": [...], "event_types": ["PUSH_ARTIFACT"], "enabled": false}' 'https://localhost/api/v2.0/projects/1/webhook/policies/2'
+func PutWebhookPolicy(c *harbor.Client, opt *WebhookPolicyUpdate) (*harbor.Result, error) {
+	if opt.Enable && opt.Disable {
+		return nil, errors.New("--enable and --disable are exclusive")
+	}
+
+	targetURL := webhookURL(c, opt.ProjectID) + "/policies/" + strconv.Itoa(opt.ID)
+	c.Trace("==> GET", targetURL)
+
+	var policy map[string]interface{}
+	if err := c.GetJSON(targetURL, &policy); err != nil {
+		return nil, err
+	}
+	if opt.Name != "" {
+		policy["name"] = opt.Name
+	}
+	if opt.Description != "" {
+		policy["description"] = opt.Description
+	}
+	if len(opt.EventTypes) > 0 {
+		policy["event_types"] = opt.EventTypes
+	}
+	if t := webhookTarget(opt.NotifyType, opt.Address, opt.AuthHeader, opt.SkipCertVerify); t != nil {
+		policy["targets"] = []*model.WebhookTarget{t}
+	}
+	if opt.Enable || opt.Disable {
+		policy["enabled"] = opt.Enable
+	}
+
+	c.Trace("==> PUT", targetURL)
+	return c.Do(c.Put(targetURL).Send(policy))
+}
+
+// WebhookPolicyDel holds the parameters of DeleteWebhookPolicy.
+type WebhookPolicyDel struct {
+	ProjectID int `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
+	ID        int `short:"i" long:"policy_id" description:"(REQUIRED) The ID of the webhook policy." required:"yes"`
+}
+
+func (x *WebhookPolicyDel) Execute(args []string) error {
+	return utils.PrintResult(DeleteWebhookPolicy(utils.NewClient(), x))
+}
+
+// DeleteWebhookPolicy deletes a webhook policy of a project.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   policy_id - (REQUIRED) The ID of the webhook policy.
+//
+// format:
+//   DELETE /projects/{project_id}/webhook/policies/{policy_id}
+//
+// e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/v2.0/projects/1/webhook/policies/2'
+func DeleteWebhookPolicy(c *harbor.Client, opt *WebhookPolicyDel) (*harbor.Result, error) {
+	targetURL := webhookURL(c, opt.ProjectID) + "/policies/" + strconv.Itoa(opt.ID)
+	c.Trace("==> DELETE", targetURL)
+
+	return c.Do(c.Delete(targetURL))
+}
+
+// WebhookPolicyTest holds the parameters of PostWebhookPolicyTest.
+type WebhookPolicyTest struct {
+	ProjectID      int    `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
+	ID             int    `short:"i" long:"policy_id" description:"The ID of the webhook policy whose targets are tested, required unless --address is set."`
+	Address        string `short:"a" long:"address" description:"The URL of the endpoint tested, instead of the targets of a policy."`
+	NotifyType     string `short:"t" long:"notify_type" description:"The type of the endpoint given by --address." choice:"http" choice:"slack" default:"http"`
+	AuthHeader     string `long:"auth_header" description:"The Authorization header sent to the endpoint given by --address."`
+	SkipCertVerify bool   `long:"skip_cert_verify" description:"Do not verify the certificate of the endpoint given by --address."`
+}
+
+func (x *WebhookPolicyTest) Execute(args []string) error {
+	return utils.PrintResult(PostWebhookPolicyTest(utils.NewClient(), x))
+}
+
+// PostWebhookPolicyTest sends a test event to the targets of a webhook
+// policy, or to the target given.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   policy_id - The ID of the webhook policy whose targets are tested.
+//   address - The URL of the endpoint tested.
+//
+// format:
+//   POST /projects/{project_id}/webhook/policies/test
+//
+// e.g. curl -X POST --header 'Content-Type: application/json' -d '{"targets": [{"type": "http", "address": "https://ci.example.com/hook", "skip_cert_verify": false}]}' 'https://localhost/api/v2.0/projects/1/webhook/policies/test'
+func PostWebhookPolicyTest(c *harbor.Client, opt *WebhookPolicyTest) (*harbor.Result, error) {
+	var targets []*model.WebhookTarget
+	switch {
+	case opt.Address != "":
+		targets = []*model.WebhookTarget{webhookTarget(opt.NotifyType, opt.Address, opt.AuthHeader, opt.SkipCertVerify)}
+	case opt.ID != 0:
+		var policy model.WebhookPolicy
+		policyURL := webhookURL(c, opt.ProjectID) + "/policies/" + strconv.Itoa(opt.ID)
+		c.Trace("==> GET", policyURL)
+		if err := c.GetJSON(policyURL, &policy); err != nil {
+			return nil, err
+		}
+		targets = policy.Targets
+	default:
+		return nil, errors.New("set --policy_id or --address")
+	}
+
+	targetURL := webhookURL(c, opt.ProjectID) + "/policies/test"
+	c.Trace("==> POST", targetURL)
+
+	return c.Do(c.Post(targetURL).
+		Send(map[string]interface{}{"targets": targets}))
+}
+
+// WebhookLastTrigger holds the parameters of GetWebhookLastTrigger.
+type WebhookLastTrigger struct {
+	ProjectID int `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
+}
+
+func (x *WebhookLastTrigger) Execute(args []string) error {
+	return utils.PrintResult(GetWebhookLastTrigger(utils.NewClient(), x))
+}
+
+// GetWebhookLastTrigger gets the last triggers of the webhook policies of
+// a project.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//
+// format:
+//   GET /projects/{project_id}/webhook/lasttrigger
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/projects/1/webhook/lasttrigger'
+//
+func GetWebhookLastTrigger(c *harbor.Client, opt *WebhookLastTrigger) (*harbor.Result, error) {
+	targetURL := webhookURL(c, opt.ProjectID) + "/lasttrigger"
+	c.Trace("==> GET", targetURL)
+
+	return c.Do(c.Get(targetURL))
+}
+
+// WebhookJobsList holds the parameters of GetWebhookJobs.
+type WebhookJobsList struct {
+	ProjectID int      `short:"j" long:"project_id" description:"(REQUIRED) The ID of project." required:"yes"`
+	PolicyID  int      `short:"i" long:"policy_id" description:"(REQUIRED) The ID of the webhook policy." required:"yes"`
+	Status    []string `long:"status" description:"List the jobs of this status only, may be repeated, e.g. Success, Error, Pending, Running."`
+	Page      int      `short:"p" long:"page" description:"The page nubmer, default is 1." default:"1"`
+	PageSize  int      `short:"s" long:"page_size" description:"The size of per page, default is 10 (or page_size in conf/config.yaml), maximum is 100 unless the target differs." default:"10" validate:"pagesize"`
+	Count     bool     `long:"count" description:"Print the total number of matched items only."`
+	All       bool     `long:"all" description:"Fetch all pages, --page and --page_size are ignored."`
+}
+
+func (x *WebhookJobsList) Execute(args []string) error {
+	return utils.ListPrinter(x.Count)(GetWebhookJobs(utils.NewClient(), x))
+}
+
+// GetWebhookJobs lists the jobs of a webhook policy.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   policy_id - (REQUIRED) The ID of the webhook policy.
+//   status - The status of the jobs.
+//   page - The page nubmer, default is 1.
+//   page_size - The size of per page, default is 10.
+//
+// format:
+//   GET /projects/{project_id}/webhook/jobs
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/v2.0/projects/1/webhook/jobs?policy_id=2&status=Error&page=1&page_size=10'
+//
+func GetWebhookJobs(c *harbor.Client, opt *WebhookJobsList) (*harbor.Result, error) {
+	if opt.Count {
+		opt.Page, opt.PageSize = 1, 1
+	}
+
+	q := url.Values{}
+	q.Set("policy_id", strconv.Itoa(opt.PolicyID))
+	for _, s := range opt.Status {
+		q.Add("status", strings.TrimSpace(s))
+	}
+	q.Set("page", strconv.Itoa(opt.Page))
+	q.Set("page_size", strconv.Itoa(opt.PageSize))
+	targetURL := webhookURL(c, opt.ProjectID) + "/jobs?" + q.Encode()
+
+	if !opt.Count {
+		c.Trace("==> GET", targetURL)
+	}
+
+	if opt.All && !opt.Count {
+		return c.StreamAllPages(targetURL), nil
+	}
+
+	return c.DoStream(c.Get(targetURL))
+}
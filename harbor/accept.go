@@ -0,0 +1,85 @@
+package harbor
+
+import (
+	"net/url"
+	"regexp"
+)
+
+// Media types of Harbor responses, for the Accept header.
+const (
+	MediaTypeJSON   = "application/json"
+	MediaTypeText   = "text/plain"
+	MediaTypeBinary = "application/octet-stream"
+	// MediaTypeVulnReport is the vulnerability report of the v2.0 API, in
+	// the format of Harbor's scanner adapters.
+	MediaTypeVulnReport = "application/vnd.security.vulnerability.report; version=1.1"
+	// MediaTypeScannerReport is the vulnerability report of the v2.0 API,
+	// in the original format of Harbor's scanner adapter spec.
+	MediaTypeScannerReport = "application/vnd.scanner.adapter.vuln.report.harbor+json; version=1.0"
+	// MediaTypeManifests are the manifests of the registry API accepted:
+	// manifest lists and images, of Docker and OCI.
+	MediaTypeManifests = "application/vnd.docker.distribution.manifest.list.v2+json, " +
+		"application/vnd.oci.image.index.v1+json, " +
+		"application/vnd.docker.distribution.manifest.v2+json, " +
+		"application/vnd.oci.image.manifest.v1+json"
+)
+
+// endpointAccept lists the endpoints which answer something else than
+// JSON, by a pattern of their path. The others are sent with
+// "Accept: application/json".
+var endpointAccept = []struct {
+	path   *regexp.Regexp
+	accept string
+}{
+	// Job logs: v1 jobs, v2.0 tasks of executions (replication, retention,
+	// preheat...), GC and job service.
+	{regexp.MustCompile(`/jobs/(replication|scan)/\d+/log$`), MediaTypeText},
+	{regexp.MustCompile(`/executions/\d+/tasks/\d+/log$`), MediaTypeText},
+	{regexp.MustCompile(`/retentions/\d+/executions/\d+/tasks/\d+$`), MediaTypeText},
+	{regexp.MustCompile(`/system/gc/\d+/log$`), MediaTypeText},
+	{regexp.MustCompile(`/jobservice/jobs/[^/]+/log$`), MediaTypeText},
+	// The root certificate is a file to download.
+	{regexp.MustCompile(`/systeminfo/getcert$`), MediaTypeBinary},
+	// v2.0 vulnerability reports, both formats are accepted.
+	{regexp.MustCompile(`/artifacts/[^/]+/additions/vulnerabilities$`), MediaTypeVulnReport + ", " + MediaTypeScannerReport},
+	// Manifests of the registry API, which would answer an old schema 1
+	// manifest to a JSON Accept.
+	{regexp.MustCompile(`^/v2/.+/manifests/[^/]+$`), MediaTypeManifests},
+}
+
+// scanOverviewAccept is sent as X-Accept-Vulnerabilities when the scan
+// overview of artifacts is asked for, without it Harbor v2.x leaves the
+// overview out.
+const scanOverviewAccept = MediaTypeVulnReport + ", " + MediaTypeScannerReport
+
+// WithAccept sends every request with "Accept: accept" instead of the media
+// type of its endpoint, e.g. to get a report in another format.
+func WithAccept(accept string) Option {
+	return func(c *Client) {
+		c.accept = accept
+	}
+}
+
+// acceptFor returns the Accept header of a request to targetURL.
+func (c *Client) acceptFor(targetURL string) string {
+	if c.accept != "" {
+		return c.accept
+	}
+	u, err := url.Parse(targetURL)
+	if err != nil {
+		return MediaTypeJSON
+	}
+	for _, e := range endpointAccept {
+		if e.path.MatchString(u.Path) {
+			return e.accept
+		}
+	}
+	return MediaTypeJSON
+}
+
+// wantsScanOverview reports whether targetURL asks for the scan overview of
+// artifacts.
+func wantsScanOverview(targetURL string) bool {
+	u, err := url.Parse(targetURL)
+	return err == nil && u.Query().Get("with_scan_overview") == "true"
+}
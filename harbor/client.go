@@ -0,0 +1,232 @@
+// Package harbor contains the client of a Harbor instance, which carries
+// the address, the authentication and the HTTP settings of every request.
+//
+// Clients share no state, several Harbor instances can be used concurrently
+// from a single program:
+//
+//	c := harbor.NewClient("https://harbor.example.com",
+//		harbor.WithBasicAuth("admin", "Harbor12345"),
+//		harbor.WithTimeout(30*time.Second))
+//	res, err := api.GetLabels(c, &api.LabelsList{Scope: "g", Page: 1, PageSize: 10})
+package harbor // import "github.com/moooofly/harbor-go-client/harbor"
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/transport"
+)
+
+// Client is a client of a Harbor instance.
+type Client struct {
+	baseURL    string
+	sessionID  string
+	username   string
+	password   string
+	httpClient *http.Client
+	timeout    time.Duration
+	insecure   bool
+	trace      io.Writer
+	accept     string
+	transport  transport.Config
+
+	followRedirects bool
+	maxPageSize     int
+
+	apiVersion string
+	// version is shared by the copies of WithContext, the API version is
+	// negotiated once.
+	version *versionState
+
+	deprecated func(method string, r *Route)
+	progress   func(Event)
+	retries    int
+	retryWait  time.Duration
+
+	ctx context.Context
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithSession authenticates requests by the beegosessionID of a UI login.
+func WithSession(sessionID string) Option {
+	return func(c *Client) {
+		c.sessionID = sessionID
+	}
+}
+
+// WithBasicAuth authenticates requests by username and password.
+func WithBasicAuth(username, password string) Option {
+	return func(c *Client) {
+		c.username = username
+		c.password = password
+	}
+}
+
+// WithHTTPClient sends requests by a copy of hc. A Transport which is not
+// an *http.Transport is used as is, WithTransport and
+// WithInsecureSkipVerify do not apply to it, see transport.New.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithTransport configures the connection pool, TLS and timeouts of the
+// HTTP client, see transport.Config. WithTimeout and
+// WithInsecureSkipVerify take precedence over the same settings of cfg.
+func WithTransport(cfg transport.Config) Option {
+	return func(c *Client) {
+		c.transport = cfg
+	}
+}
+
+// WithTimeout limits the time of every request, response body included.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = d
+	}
+}
+
+// WithInsecureSkipVerify disables the verification of the server's
+// certificate chain and host name.
+func WithInsecureSkipVerify(insecure bool) Option {
+	return func(c *Client) {
+		c.insecure = insecure
+	}
+}
+
+// WithTrace writes a "==> METHOD url" line to w for every request.
+func WithTrace(w io.Writer) Option {
+	return func(c *Client) {
+		c.trace = w
+	}
+}
+
+// WithContext sends every request with ctx, to cancel them all at once or
+// to set a deadline, see also the WithContext method.
+func WithContext(ctx context.Context) Option {
+	return func(c *Client) {
+		c.ctx = ctx
+	}
+}
+
+// NewClient returns a client of the Harbor at baseURL, e.g.
+// "https://localhost".
+func NewClient(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		maxPageSize: MaxPageSize,
+		version:     &versionState{},
+		ctx:         context.Background(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.maxPageSize <= 0 {
+		c.maxPageSize = MaxPageSize
+	}
+	if c.ctx == nil {
+		c.ctx = context.Background()
+	}
+
+	cfg := c.transport
+	if c.timeout != 0 {
+		cfg.Timeout = c.timeout
+	}
+	cfg.InsecureSkipVerify = cfg.InsecureSkipVerify || c.insecure
+	if c.httpClient == nil || c.httpClient.CheckRedirect == nil {
+		cfg.CheckRedirect = c.checkRedirect
+	}
+	c.httpClient = transport.New(c.httpClient, cfg)
+
+	return c
+}
+
+// WithContext returns a copy of the client sending its requests with ctx,
+// e.g. to give a single API call a deadline:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+//	defer cancel()
+//	res, err := api.GetLabels(c.WithContext(ctx), opt)
+//
+// The copy shares the HTTP client and the negotiated API version.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c2 := *c
+	c2.ctx = ctx
+	return &c2
+}
+
+// Context returns the context of the requests of the client,
+// context.Background() unless set by WithContext.
+func (c *Client) Context() context.Context {
+	return c.ctx
+}
+
+// BaseURL returns the address of the Harbor.
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// URL returns the URL of uri on the Harbor.
+func (c *Client) URL(uri string) string {
+	return c.baseURL + uri
+}
+
+// Trace writes its operands to the trace writer of the client, if any, in
+// the manner of fmt.Println.
+func (c *Client) Trace(a ...interface{}) {
+	if c.trace != nil {
+		fmt.Fprintln(c.trace, a...)
+	}
+}
+
+// Request returns a new authenticated request. targetURL may be a full URL
+// or a path on the Harbor.
+func (c *Client) Request(method, targetURL string) *transport.Request {
+	if strings.HasPrefix(targetURL, "/") {
+		targetURL = c.URL(targetURL)
+	}
+
+	c.checkDeprecated(method, targetURL, false)
+	req := transport.NewRequest(c.ctx, c.httpClient, method, targetURL).
+		Set("Accept", c.acceptFor(targetURL))
+	if wantsScanOverview(targetURL) {
+		req.Set("X-Accept-Vulnerabilities", scanOverviewAccept)
+	}
+	if c.sessionID != "" {
+		req.Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.sessionID)
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	return req
+}
+
+// Get returns a new authenticated GET request.
+func (c *Client) Get(targetURL string) *transport.Request {
+	return c.Request(transport.GET, targetURL)
+}
+
+// Post returns a new authenticated POST request.
+func (c *Client) Post(targetURL string) *transport.Request {
+	return c.Request(transport.POST, targetURL)
+}
+
+// Put returns a new authenticated PUT request.
+func (c *Client) Put(targetURL string) *transport.Request {
+	return c.Request(transport.PUT, targetURL)
+}
+
+// Delete returns a new authenticated DELETE request.
+func (c *Client) Delete(targetURL string) *transport.Request {
+	return c.Request(transport.DELETE, targetURL)
+}
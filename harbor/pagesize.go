@@ -0,0 +1,77 @@
+package harbor
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// probePageSize is the page_size asked for by ProbeMaxPageSize, larger
+// than any cap configured in practice.
+const probePageSize = 1000
+
+// WithMaxPageSize sets the maximum page_size of the Harbor, MaxPageSize by
+// default. It is the size of the pages requested by EachPage, which relies
+// on a short page to find the last one.
+func WithMaxPageSize(n int) Option {
+	return func(c *Client) {
+		c.maxPageSize = n
+	}
+}
+
+// MaxPageSize returns the maximum page_size of the Harbor, see
+// WithMaxPageSize.
+func (c *Client) MaxPageSize() int {
+	return c.maxPageSize
+}
+
+// ProbeMaxPageSize finds the maximum page_size of the Harbor by asking
+// targetURL, a list endpoint, for a page larger than any cap: Harbor caps
+// the page size silently, the page size used is told by the Link header,
+// or by the number of items when X-Total-Count is larger. It returns 0
+// when the list is too short to tell.
+func (c *Client) ProbeMaxPageSize(targetURL string) (int, error) {
+	if strings.HasPrefix(targetURL, "/") {
+		targetURL = c.URL(targetURL)
+	}
+	u, err := url.Parse(targetURL)
+	if err != nil {
+		return 0, err
+	}
+	q := u.Query()
+	q.Set("page", "1")
+	q.Set("page_size", strconv.Itoa(probePageSize))
+	u.RawQuery = q.Encode()
+
+	res, err := c.Do(c.Get(u.String()))
+	if err != nil {
+		return 0, err
+	}
+
+	if n := linkPageSize(res.Header.Get("Link")); n > 0 {
+		return n, nil
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(res.Body, &items); err != nil {
+		return 0, err
+	}
+	total, _ := strconv.Atoi(res.Header.Get("X-Total-Count"))
+	if total > len(items) {
+		return len(items), nil
+	}
+	return 0, nil
+}
+
+// linkPageSize returns the page_size of the first URL in a Link header,
+// e.g. `</api/projects?page=2&page_size=100>; rel="next"`, 0 if none.
+func linkPageSize(link string) int {
+	i := strings.Index(link, "<")
+	j := strings.Index(link, ">")
+	if i < 0 || j < i {
+		return 0
+	}
+	_, n := linkPage(link[i+1 : j])
+	return n
+}
@@ -0,0 +1,70 @@
+package harbor
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Pagination is the paging of a list response, as told by its
+// X-Total-Count and Link headers.
+type Pagination struct {
+	// Total is the number of items of all pages, -1 if unknown.
+	Total int
+	// Page is the number of the page, PageSize its size, 0 if unknown.
+	Page     int
+	PageSize int
+	// Next and Prev are the URLs of the next and the previous pages, empty
+	// if there is none.
+	Next string
+	Prev string
+}
+
+// Pagination returns the paging of the response, nil if it has neither an
+// X-Total-Count nor a Link header. The page is told by the links, it is the
+// first one when there is none.
+func (r *Result) Pagination() *Pagination {
+	total, link := r.Header.Get("X-Total-Count"), r.Header.Get("Link")
+	if total == "" && link == "" {
+		return nil
+	}
+
+	p := &Pagination{Total: -1, Page: 1}
+	if n, err := strconv.Atoi(total); err == nil {
+		p.Total = n
+	}
+	// e.g. </api/projects?page=1&page_size=10>; rel="prev" , </api/projects?page=3&page_size=10>; rel="next"
+	for _, l := range strings.Split(link, ",") {
+		i, j := strings.Index(l, "<"), strings.Index(l, ">")
+		if i < 0 || j < i {
+			continue
+		}
+		switch rel := l[j+1:]; {
+		case strings.Contains(rel, `rel="next"`):
+			p.Next = l[i+1 : j]
+		case strings.Contains(rel, `rel="prev"`):
+			p.Prev = l[i+1 : j]
+		}
+	}
+	if p.Next != "" {
+		page, size := linkPage(p.Next)
+		p.Page, p.PageSize = page-1, size
+	} else if p.Prev != "" {
+		page, size := linkPage(p.Prev)
+		p.Page, p.PageSize = page+1, size
+	}
+	return p
+}
+
+// linkPage returns the page and the page_size of a URL of a Link header, 0
+// if it has none.
+func linkPage(link string) (page, size int) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return 0, 0
+	}
+	q := u.Query()
+	page, _ = strconv.Atoi(q.Get("page"))
+	size, _ = strconv.Atoi(q.Get("page_size"))
+	return page, size
+}
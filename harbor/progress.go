@@ -0,0 +1,142 @@
+package harbor
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// EventKind is the kind of a progress Event.
+type EventKind int
+
+// Kinds of progress events.
+const (
+	// EventRequest is sent before a request is sent.
+	EventRequest EventKind = iota
+	// EventRetry is sent before a request is sent again, after the failure
+	// in Err.
+	EventRetry
+	// EventPage is sent when a page of a list endpoint has been read.
+	EventPage
+	// EventDone is sent when all pages of a list endpoint have been read,
+	// or the listing failed with Err.
+	EventDone
+	// EventStatus is sent when the status of an operation in Status
+	// changes, e.g. of a polled execution, see Client.Status.
+	EventStatus
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventRequest:
+		return "request"
+	case EventRetry:
+		return "retry"
+	case EventPage:
+		return "page"
+	case EventDone:
+		return "done"
+	case EventStatus:
+		return "status"
+	}
+	return "unknown"
+}
+
+// Event reports the progress of the requests of a client, for frontends to
+// render it, see WithProgress.
+type Event struct {
+	Kind   EventKind
+	Method string
+	// URL is the URL of the request, or of the page for EventPage and
+	// EventDone.
+	URL string
+	// Attempt counts the attempts of a request, from 1, see WithRetry.
+	Attempt int
+	// Page is the number of the page read, from 1.
+	Page int
+	// Items is the number of items read so far from all pages.
+	Items int
+	// Status is the status of the operation for EventStatus.
+	Status string
+	Err    error
+}
+
+// WithProgress calls fn with the progress events of the requests of the
+// client and of the listings by EachPage (thus StreamAllPages and
+// FetchAllPages). fn is called by the goroutine sending the requests, it
+// must return quickly; see ProgressChan to receive the events from a
+// channel instead.
+func WithProgress(fn func(Event)) Option {
+	return func(c *Client) {
+		c.progress = fn
+	}
+}
+
+// ProgressChan returns a progress callback for WithProgress which sends the
+// events to ch. Events are dropped while ch is full, so that a slow reader
+// never holds up the requests.
+func ProgressChan(ch chan<- Event) func(Event) {
+	return func(e Event) {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// WithRetry sends again the GET and HEAD requests failing with a network
+// error or with 502, 503 or 504, up to retries times, waiting wait, then
+// twice as long and so on between the attempts. Requests are not retried by
+// default.
+func WithRetry(retries int, wait time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.retryWait = wait
+	}
+}
+
+// notify sends a progress event, if a progress callback is set.
+func (c *Client) notify(e Event) {
+	if c.progress != nil {
+		c.progress(e)
+	}
+}
+
+// Status reports the status of an operation of the client, e.g. of an
+// execution it polls, to the progress callback as an EventStatus.
+func (c *Client) Status(format string, args ...interface{}) {
+	if c.progress != nil {
+		c.progress(Event{Kind: EventStatus, Status: fmt.Sprintf(format, args...)})
+	}
+}
+
+// retryable reports whether a request of method which got resp or err may
+// be sent again.
+func retryable(method string, resp *http.Response, err error) bool {
+	if method != http.MethodGet && method != http.MethodHead {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryWaitFor returns how long to wait before the attempt following the
+// failed attempt, from 1.
+func (c *Client) retryWaitFor(attempt int) time.Duration {
+	return c.retryWait << uint(attempt-1)
+}
+
+// discard reads the rest of the body of a response which is not used and
+// closes it, for its connection to be reused.
+func discard(resp *http.Response) {
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxErrorBody))
+	resp.Body.Close()
+}
@@ -0,0 +1,121 @@
+package harbor
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxRedirects is the number of redirects followed for a request.
+const maxRedirects = 10
+
+// WithFollowRedirects follows the redirects of Harbor to its own host, e.g.
+// from http to https or to another path. Redirects to other hosts, which
+// SSO proxies use to send to their identity provider, and from https to
+// http, are never followed.
+func WithFollowRedirects(follow bool) Option {
+	return func(c *Client) {
+		c.followRedirects = follow
+	}
+}
+
+// SSOError is returned when a request does not reach the API because an
+// SSO proxy in front of Harbor redirects it to an identity provider, or
+// answers with its login page. The API is usually let through with basic
+// auth: by a robot account, or by the CLI secret of an OIDC user.
+type SSOError struct {
+	Method string
+	URL    string
+	// Location is the address redirected to, empty for a login page.
+	Location string
+	Result   *Result
+}
+
+func (e *SSOError) Error() string {
+	what := "answered with an HTML page"
+	if e.Location != "" {
+		what = "redirected to " + e.Location
+	}
+	return fmt.Sprintf("%s %s: %s, Harbor seems to be behind an SSO proxy, "+
+		"authenticate by basic auth with a robot account or the CLI secret of an OIDC user instead",
+		e.Method, e.URL, what)
+}
+
+// RedirectError is returned for a redirect to the host of Harbor itself
+// which is not followed, see WithFollowRedirects.
+type RedirectError struct {
+	Method   string
+	URL      string
+	Location string
+	Result   *Result
+}
+
+func (e *RedirectError) Error() string {
+	return fmt.Sprintf("%s %s: redirected to %s, fix the address of Harbor or follow redirects",
+		e.Method, e.URL, e.Location)
+}
+
+// checkRedirect is the CheckRedirect of the HTTP client, it stops at
+// redirects which are not to be followed, so that they are reported by
+// redirectError.
+func (c *Client) checkRedirect(req *http.Request, via []*http.Request) error {
+	if !c.followRedirects || !safeRedirect(via[0].URL, req.URL) {
+		return http.ErrUseLastResponse
+	}
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	return nil
+}
+
+// safeRedirect reports whether a redirect stays on the same host and does
+// not downgrade https to http.
+func safeRedirect(from, to *url.URL) bool {
+	if from.Scheme == "https" && to.Scheme != "https" {
+		return false
+	}
+	return strings.EqualFold(from.Hostname(), to.Hostname())
+}
+
+// redirectError returns the error of a response which is a redirect not
+// followed, or the HTML page of an SSO proxy instead of the API, and nil
+// for any other response. An HTML page is taken for the one of a proxy when
+// it is a success, or reached by following a redirect: an API error page
+// in HTML, e.g. a 404 of a reverse proxy, is left to be an APIError.
+func redirectError(req *http.Request, res *Result) error {
+	if res.StatusCode >= 300 && res.StatusCode <= 399 {
+		location := res.Header.Get("Location")
+		if to, err := req.URL.Parse(location); err == nil && to.Host != "" {
+			location = to.String()
+			if !strings.EqualFold(to.Hostname(), req.URL.Hostname()) {
+				return &SSOError{Method: req.Method, URL: req.URL.String(), Location: location, Result: res}
+			}
+		}
+		return &RedirectError{Method: req.Method, URL: req.URL.String(), Location: location, Result: res}
+	}
+
+	// req is the last request of the redirects followed, if any.
+	orig := req
+	for orig.Response != nil && orig.Response.Request != nil {
+		orig = orig.Response.Request
+	}
+	success := res.StatusCode >= 200 && res.StatusCode <= 299
+	if strings.Contains(orig.URL.Path, "/api/") && isHTML(res.Header) && (success || orig != req) {
+		return &SSOError{Method: orig.Method, URL: orig.URL.String(), Result: res}
+	}
+	return nil
+}
+
+func isHTML(h http.Header) bool {
+	mt, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	return err == nil && mt == "text/html"
+}
+
+// IsSSO reports whether err is, or wraps, an *SSOError.
+func IsSSO(err error) bool {
+	var ssoErr *SSOError
+	return errors.As(err, &ssoErr)
+}
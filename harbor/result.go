@@ -0,0 +1,140 @@
+package harbor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/transport"
+)
+
+// MaxPageSize is the maximum page_size accepted by Harbor, unless configured
+// otherwise, see WithMaxPageSize.
+const MaxPageSize = 100
+
+// Result is the response of a Harbor API call.
+//
+// The body is either in Body, or, for responses which may be very large
+// (lists), left unread in Stream, see DoStream. WriteTo and Decode work for
+// both.
+type Result struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte
+	Stream     io.ReadCloser
+}
+
+// APIError is returned when Harbor answers with a non-2xx status, the
+// response is available as Result.
+type APIError struct {
+	Method string
+	URL    string
+	Result *Result
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s %s: unexpected status %s", e.Method, e.URL, e.Result.Status)
+}
+
+// Do ends the request and returns its response. A non-2xx status is
+// reported as an *APIError, along with the Result. A redirect which is not
+// followed, or the login page of an SSO proxy, is reported as a
+// *RedirectError or an *SSOError, without Result.
+func (c *Client) Do(sa *transport.Request) (*Result, error) {
+	resp, err := c.send(sa)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Header:     resp.Header,
+		Body:       body,
+	}
+	if err := redirectError(resp.Request, res); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return res, &APIError{Method: sa.Method, URL: sa.URL, Result: res}
+	}
+	return res, nil
+}
+
+// send sends the request of sa, a failure of the context of the client
+// is reported before any request is issued. A 404 response to a removed
+// v1 endpoint is reported to the deprecation handler. Requests are retried
+// as set by WithRetry, and reported to the progress callback.
+func (c *Client) send(sa *transport.Request) (*http.Response, error) {
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	e := Event{Kind: EventRequest, Method: sa.Method, URL: sa.URL, Attempt: 1}
+	for {
+		c.notify(e)
+		resp, err := sa.Do()
+		if e.Attempt > c.retries || !retryable(sa.Method, resp, err) {
+			if err == nil && resp.StatusCode == http.StatusNotFound {
+				c.checkDeprecated(sa.Method, sa.URL, true)
+			}
+			return resp, err
+		}
+
+		if err == nil {
+			discard(resp)
+			err = fmt.Errorf("%s %s: unexpected status %s", sa.Method, sa.URL, resp.Status)
+		}
+		select {
+		case <-c.ctx.Done():
+			return nil, c.ctx.Err()
+		case <-time.After(c.retryWaitFor(e.Attempt)):
+		}
+		e.Kind, e.Attempt, e.Err = EventRetry, e.Attempt+1, err
+	}
+}
+
+// GetJSON requests targetURL and decodes the JSON response into v.
+func (c *Client) GetJSON(targetURL string, v interface{}) error {
+	res, err := c.Do(c.Get(targetURL))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(res.Body, v)
+}
+
+// FetchAllPages requests a list endpoint page by page and returns the items
+// of all pages. The page and page_size parameters of targetURL are replaced,
+// pages of the maximum page size are used to keep the number of requests
+// low. The listing is a snapshot to delete items from safely, see EachPage.
+func (c *Client) FetchAllPages(targetURL string) ([]json.RawMessage, error) {
+	var all []json.RawMessage
+	err := c.EachPage(targetURL, func(item json.RawMessage) error {
+		all = append(all, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// SessionID returns the beegosessionID set by a login response.
+func SessionID(res *Result) (string, error) {
+	for _, cookie := range (&http.Response{Header: res.Header}).Cookies() {
+		if cookie.Name == "beegosessionID" && cookie.Value != "" {
+			return cookie.Value, nil
+		}
+	}
+	return "", errors.New("target cookies are not available")
+}
@@ -0,0 +1,118 @@
+package harbor
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Route is an endpoint of the v1 API which Harbor v2.x removed, with its
+// replacement in the v2.0 API, if any.
+type Route struct {
+	// Method is the HTTP method of the endpoint, "" for all.
+	Method string
+	// V1 is the v1 endpoint, e.g. "/repositories/{repo}/tags".
+	V1 string
+	// V2 is the v2.0 endpoint replacing it, "" if there is none.
+	V2 string
+
+	path *regexp.Regexp
+}
+
+// v1Routes is the routing table of the v1 endpoints removed from the v2.0
+// API, matched against the path after "/api" in order.
+var v1Routes = []*Route{
+	{Method: http.MethodGet, V1: "/repositories/{repo}/tags", V2: "/projects/{project}/repositories/{repo}/artifacts"},
+	{Method: http.MethodGet, V1: "/repositories/{repo}/tags/{tag}/manifest", V2: "/projects/{project}/repositories/{repo}/artifacts/{reference}"},
+	{Method: http.MethodPost, V1: "/repositories/{repo}/tags/{tag}/scan", V2: "/projects/{project}/repositories/{repo}/artifacts/{reference}/scan"},
+	{Method: http.MethodGet, V1: "/repositories/{repo}/tags/{tag}/vulnerability/details", V2: "/projects/{project}/repositories/{repo}/artifacts/{reference}/additions/vulnerabilities"},
+	{Method: http.MethodGet, V1: "/repositories/{repo}/tags/{tag}/labels", V2: "/projects/{project}/repositories/{repo}/artifacts/{reference}"},
+	{Method: http.MethodPost, V1: "/repositories/{repo}/tags/{tag}/labels", V2: "/projects/{project}/repositories/{repo}/artifacts/{reference}/labels"},
+	{Method: http.MethodDelete, V1: "/repositories/{repo}/tags/{tag}/labels/{id}", V2: "/projects/{project}/repositories/{repo}/artifacts/{reference}/labels/{id}"},
+	{Method: http.MethodGet, V1: "/repositories/{repo}/tags/{tag}", V2: "/projects/{project}/repositories/{repo}/artifacts/{reference}"},
+	{Method: http.MethodDelete, V1: "/repositories/{repo}/tags/{tag}", V2: "/projects/{project}/repositories/{repo}/artifacts/{reference}"},
+	{Method: http.MethodPost, V1: "/repositories/{repo}/tags", V2: "/projects/{project}/repositories/{repo}/artifacts?from={source}"},
+	{V1: "/repositories/{repo}/labels", V2: "/projects/{project}/repositories/{repo}/artifacts/{reference}/labels"},
+	{Method: http.MethodGet, V1: "/repositories/{repo}/signatures", V2: "/projects/{project}/repositories/{repo}/artifacts/{reference}/accessories"},
+	{Method: http.MethodGet, V1: "/repositories/top"},
+	{Method: http.MethodGet, V1: "/repositories", V2: "/projects/{project}/repositories"},
+	{Method: http.MethodPut, V1: "/repositories/{repo}", V2: "/projects/{project}/repositories/{repo}"},
+	{Method: http.MethodDelete, V1: "/repositories/{repo}", V2: "/projects/{project}/repositories/{repo}"},
+	{V1: "/targets/{id}/policies", V2: "/replication/policies"},
+	{V1: "/targets/ping", V2: "/registries/ping"},
+	{V1: "/targets/{id}/ping", V2: "/registries/ping"},
+	{V1: "/targets/{id}", V2: "/registries/{id}"},
+	{V1: "/targets", V2: "/registries"},
+	{V1: "/policies/replication/{id}", V2: "/replication/policies/{id}"},
+	{V1: "/policies/replication", V2: "/replication/policies"},
+	{Method: http.MethodGet, V1: "/jobs/replication/{id}/log", V2: "/replication/executions/{id}/tasks/{task_id}/log"},
+	{V1: "/jobs/replication/{id}", V2: "/replication/executions/{id}"},
+	{V1: "/jobs/replication", V2: "/replication/executions"},
+	{Method: http.MethodGet, V1: "/jobs/scan/{id}/log", V2: "/projects/{project}/repositories/{repo}/artifacts/{reference}/scan/{report_id}/log"},
+	{Method: http.MethodPost, V1: "/internal/syncregistry"},
+	{Method: http.MethodGet, V1: "/logs", V2: "/audit-logs"},
+}
+
+// routeParam matches the parameters of the routes, "{repo}" may hold
+// slashes.
+var routeParam = regexp.MustCompile(`\{[a-z_]+\}`)
+
+func init() {
+	for _, r := range v1Routes {
+		pattern, last := "^", 0
+		for _, loc := range routeParam.FindAllStringIndex(r.V1, -1) {
+			pattern += regexp.QuoteMeta(r.V1[last:loc[0]])
+			if r.V1[loc[0]:loc[1]] == "{repo}" {
+				pattern += `.+`
+			} else {
+				pattern += `[^/]+`
+			}
+			last = loc[1]
+		}
+		r.path = regexp.MustCompile(pattern + regexp.QuoteMeta(r.V1[last:]) + "$")
+	}
+}
+
+// V1Route returns the route of a request to targetURL if it is a v1
+// endpoint removed from the v2.0 API, nil otherwise.
+func V1Route(method, targetURL string) *Route {
+	u, err := url.Parse(targetURL)
+	if err != nil || !strings.HasPrefix(u.Path, "/api/") || strings.HasPrefix(u.Path, "/api/v2.0/") {
+		return nil
+	}
+	path := strings.TrimPrefix(u.Path, "/api")
+	for _, r := range v1Routes {
+		if (r.Method == "" || r.Method == method) && r.path.MatchString(path) {
+			return r
+		}
+	}
+	return nil
+}
+
+// WithDeprecationHandler calls fn for the requests to v1 endpoints removed
+// from the v2.0 API sent to a Harbor serving the v2.0 API, with the route of
+// the endpoint: before they are sent if the API version is already known,
+// when they fail with 404 Not Found otherwise. fn may be called several
+// times for a route.
+func WithDeprecationHandler(fn func(method string, r *Route)) Option {
+	return func(c *Client) {
+		c.deprecated = fn
+	}
+}
+
+// checkDeprecated calls the deprecation handler if the request is to a
+// removed v1 endpoint and the Harbor serves the v2.0 API, negotiated for a
+// request which got 404 Not Found only.
+func (c *Client) checkDeprecated(method, targetURL string, notFound bool) {
+	if c.deprecated == nil {
+		return
+	}
+	r := V1Route(method, targetURL)
+	if r == nil {
+		return
+	}
+	if notFound && c.IsV2() || !notFound && c.knownAPIVersion() == APIVersion2 {
+		c.deprecated(method, r)
+	}
+}
@@ -0,0 +1,199 @@
+package harbor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/moooofly/harbor-go-client/transport"
+)
+
+// maxErrorBody limits how much of the body of a failed streamed response
+// is kept for the APIError.
+const maxErrorBody = 64 << 10
+
+// DoStream sends the request like Do, but leaves the body of a successful
+// response unread: the Result carries it as Stream, to be consumed by
+// WriteTo or Decode and released by Close.
+func (c *Client) DoStream(sa *transport.Request) (*Result, error) {
+	resp, err := c.send(sa)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Header:     resp.Header,
+	}
+	if err := redirectError(resp.Request, res); err != nil {
+		res.Body, _ = ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		resp.Body.Close()
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		res.Body, _ = ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		resp.Body.Close()
+		return res, &APIError{Method: sa.Method, URL: sa.URL, Result: res}
+	}
+
+	res.Stream = resp.Body
+	return res, nil
+}
+
+// WriteTo writes the body of the response to w, streaming it if the
+// response is streamed. It implements io.WriterTo.
+func (r *Result) WriteTo(w io.Writer) (int64, error) {
+	if r.Stream == nil {
+		n, err := w.Write(r.Body)
+		return int64(n), err
+	}
+	defer r.Close()
+	return io.Copy(w, r.Stream)
+}
+
+// Decode decodes the JSON body of the response into v.
+func (r *Result) Decode(v interface{}) error {
+	if r.Stream == nil {
+		return json.Unmarshal(r.Body, v)
+	}
+	defer r.Close()
+	return json.NewDecoder(r.Stream).Decode(v)
+}
+
+// Close releases the body of a streamed response, it is a no-op otherwise.
+func (r *Result) Close() error {
+	if r.Stream == nil {
+		return nil
+	}
+	return r.Stream.Close()
+}
+
+// EachItem decodes the JSON array read from r item by item, so that only a
+// single item is held in memory at any time.
+func EachItem(r io.Reader, fn func(json.RawMessage) error) error {
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		// JSON null, Harbor answers so for some empty lists.
+		return nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected a JSON array, got %v", tok)
+	}
+
+	for dec.More() {
+		var item json.RawMessage
+		if err := dec.Decode(&item); err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// EachPage requests a list endpoint page by page, pages of the maximum
+// page size of the client, and calls fn for every item as soon as it is
+// decoded. Every page read, and the end of the listing, are reported to the
+// progress callback.
+//
+// fn must not delete the items listed: the following pages would shift and
+// items be skipped. Bulk deletions list all items first, by FetchAllPages,
+// then delete them.
+func (c *Client) EachPage(targetURL string, fn func(json.RawMessage) error) error {
+	if strings.HasPrefix(targetURL, "/") {
+		targetURL = c.URL(targetURL)
+	}
+	u, err := url.Parse(targetURL)
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	q.Set("page_size", strconv.Itoa(c.maxPageSize))
+
+	e := Event{Method: transport.GET}
+	defer func() {
+		e.Kind = EventDone
+		e.Err = err
+		c.notify(e)
+	}()
+
+	for page := 1; ; page++ {
+		q.Set("page", strconv.Itoa(page))
+		u.RawQuery = q.Encode()
+		e.URL = u.String()
+
+		var res *Result
+		res, err = c.DoStream(c.Get(e.URL))
+		if err != nil {
+			return err
+		}
+		n := 0
+		err = EachItem(res.Stream, func(item json.RawMessage) error {
+			n++
+			e.Items++
+			return fn(item)
+		})
+		res.Close()
+		if err != nil {
+			return err
+		}
+
+		e.Kind, e.Page = EventPage, page
+		c.notify(e)
+		if n < c.maxPageSize {
+			return nil
+		}
+	}
+}
+
+// StreamAllPages requests all pages of a list endpoint, see EachPage. The
+// Result carries the items of all pages as a single JSON array in Stream,
+// pages are requested while it is read.
+func (c *Client) StreamAllPages(targetURL string) *Result {
+	pr, pw := io.Pipe()
+	go func() {
+		first := true
+		err := c.EachPage(targetURL, func(item json.RawMessage) error {
+			sep := ",\n  "
+			if first {
+				sep, first = "[\n  ", false
+			}
+			if _, err := io.WriteString(pw, sep); err != nil {
+				return err
+			}
+			var b bytes.Buffer
+			if err := json.Indent(&b, item, "  ", "  "); err != nil {
+				return err
+			}
+			_, err := b.WriteTo(pw)
+			return err
+		})
+		if err == nil {
+			if first {
+				_, err = io.WriteString(pw, "[]")
+			} else {
+				_, err = io.WriteString(pw, "\n]")
+			}
+		}
+		pw.CloseWithError(err)
+	}()
+
+	return &Result{
+		StatusCode: 200,
+		Status:     "200 OK",
+		Header:     map[string][]string{},
+		Stream:     pr,
+	}
+}
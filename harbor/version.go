@@ -0,0 +1,144 @@
+package harbor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// API versions of Harbor. Harbor v1.x serves its API under /api, Harbor
+// v2.x under /api/v2.0.
+const (
+	APIVersion1 = "v1"
+	APIVersion2 = "v2.0"
+)
+
+// WithAPIVersion uses the given API version instead of negotiating it with
+// the Harbor, see ParseAPIVersion for the accepted values.
+func WithAPIVersion(version string) Option {
+	return func(c *Client) {
+		c.apiVersion = version
+	}
+}
+
+// ParseAPIVersion returns APIVersion1 or APIVersion2 for the usual ways of
+// writing them ("1", "v1", "2", "v2", "2.0", "v2.0").
+func ParseAPIVersion(s string) (string, error) {
+	switch strings.TrimPrefix(strings.ToLower(s), "v") {
+	case "1":
+		return APIVersion1, nil
+	case "2", "2.0":
+		return APIVersion2, nil
+	}
+	return "", fmt.Errorf("unknown API version %q, expected 'v1' or 'v2.0'", s)
+}
+
+// APIVersion returns the API version of the Harbor. Unless set by
+// WithAPIVersion, it is negotiated until a call succeeds: /api/version
+// answers on Harbor v2.x, /api/v2.0/systeminfo and /api/systeminfo are
+// tried for the versions without it. A failed negotiation, e.g. of a
+// canceled context, is not kept.
+func (c *Client) APIVersion() (string, error) {
+	if c.apiVersion != "" {
+		return ParseAPIVersion(c.apiVersion)
+	}
+
+	v := c.version
+	if atomic.LoadInt32(&v.done) == 1 {
+		return v.version, nil
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if atomic.LoadInt32(&v.done) == 1 {
+		return v.version, nil
+	}
+	version, err := c.negotiateAPIVersion()
+	if err != nil {
+		return "", err
+	}
+	v.version = version
+	atomic.StoreInt32(&v.done, 1)
+	return version, nil
+}
+
+// knownAPIVersion returns the API version if it is known without a
+// request, "" otherwise: it is set by WithAPIVersion, or was negotiated.
+func (c *Client) knownAPIVersion() string {
+	if c.apiVersion != "" {
+		v, _ := ParseAPIVersion(c.apiVersion)
+		return v
+	}
+	if atomic.LoadInt32(&c.version.done) == 1 {
+		return c.version.version
+	}
+	return ""
+}
+
+// versionState is the API version of a Harbor, once negotiated. mu
+// serializes the negotiations, done is set once one succeeded.
+type versionState struct {
+	mu      sync.Mutex
+	done    int32
+	version string
+}
+
+// IsV2 reports whether the Harbor serves the v2.0 API. It is false if the
+// API version cannot be negotiated, the request issued then fails anyway.
+func (c *Client) IsV2() bool {
+	v, _ := c.APIVersion()
+	return v == APIVersion2
+}
+
+// APIURL returns the URL of the API endpoint at path, e.g. "/projects", for
+// the API version of the Harbor. It suits the endpoints which are the same
+// in both versions, only their prefix differs.
+func (c *Client) APIURL(path string) string {
+	if c.IsV2() {
+		return c.URL("/api/v2.0" + path)
+	}
+	return c.URL("/api" + path)
+}
+
+func (c *Client) negotiateAPIVersion() (string, error) {
+	var ver struct {
+		Version string `json:"version"`
+	}
+	res, err := c.Do(c.Get("/api/version"))
+	if err == nil && json.Unmarshal(res.Body, &ver) == nil && ver.Version != "" {
+		return ParseAPIVersion(ver.Version)
+	}
+	if err != nil && !IsNotFound(err) {
+		return "", err
+	}
+
+	for _, v := range []string{APIVersion2, APIVersion1} {
+		path := "/api/systeminfo"
+		if v == APIVersion2 {
+			path = "/api/v2.0/systeminfo"
+		}
+		_, err := c.Do(c.Get(path))
+		if err == nil {
+			return v, nil
+		}
+		if !IsNotFound(err) {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("cannot determine the API version of %s, pass it explicitly", c.baseURL)
+}
+
+// IsNotFound reports whether err is an *APIError of a 404 response.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Result.StatusCode == http.StatusNotFound
+}
+
+// IsBadRequest reports whether err is an *APIError of a 400 response.
+func IsBadRequest(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Result.StatusCode == http.StatusBadRequest
+}
@@ -0,0 +1,38 @@
+package harbor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIVersionRetriedAfterFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/version" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"version": "v2.0"}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.WithContext(ctx).APIVersion(); err == nil {
+		t.Fatal("APIVersion with a canceled context: no error")
+	}
+
+	v, err := c.APIVersion()
+	if err != nil {
+		t.Fatalf("APIVersion after a failure: %v", err)
+	}
+	if v != APIVersion2 || !c.IsV2() {
+		t.Errorf("APIVersion = %q, want %q", v, APIVersion2)
+	}
+	if got := c.knownAPIVersion(); got != APIVersion2 {
+		t.Errorf("knownAPIVersion = %q, want %q", got, APIVersion2)
+	}
+}
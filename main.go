@@ -11,6 +11,10 @@ import (
 )
 
 func main() {
+	utils.Localize()
+	utils.MarkUnsupportedCommands()
+	utils.SetDefaultPageSize()
+
 	if _, err := utils.Parser.Parse(); err != nil {
 		if flagsErr, ok := err.(*flags.Error); ok && flagsErr.Type == flags.ErrHelp {
 			os.Exit(0)
@@ -0,0 +1,33 @@
+package model
+
+// ChartInfo is a Helm chart of a chart repository, as listed.
+type ChartInfo struct {
+	Name          string `json:"name"`
+	TotalVersions int64  `json:"total_versions"`
+	LatestVersion string `json:"latest_version"`
+	Created       Time   `json:"created"`
+	Updated       Time   `json:"updated"`
+	Icon          string `json:"icon,omitempty"`
+	Home          string `json:"home,omitempty"`
+	Deprecated    bool   `json:"deprecated,omitempty"`
+}
+
+// ChartVersion is a version of a Helm chart: its Chart.yaml metadata, where
+// to download it, and its labels.
+type ChartVersion struct {
+	Name        string   `json:"name"`
+	Version     string   `json:"version"`
+	AppVersion  string   `json:"appVersion,omitempty"`
+	Description string   `json:"description,omitempty"`
+	APIVersion  string   `json:"apiVersion,omitempty"`
+	Home        string   `json:"home,omitempty"`
+	Icon        string   `json:"icon,omitempty"`
+	Keywords    []string `json:"keywords,omitempty"`
+	Sources     []string `json:"sources,omitempty"`
+	Deprecated  bool     `json:"deprecated,omitempty"`
+	Engine      string   `json:"engine,omitempty"`
+	URLs        []string `json:"urls"`
+	Created     Time     `json:"created"`
+	Digest      string   `json:"digest"`
+	Labels      []Label  `json:"labels"`
+}
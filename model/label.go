@@ -0,0 +1,14 @@
+package model
+
+// Label is a label, of the system (scope "g") or of a project (scope "p").
+type Label struct {
+	ID           int64  `json:"id"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	Color        string `json:"color"`
+	Scope        string `json:"scope"`
+	ProjectID    int64  `json:"project_id"`
+	CreationTime Time   `json:"creation_time"`
+	UpdateTime   Time   `json:"update_time"`
+	Deleted      bool   `json:"deleted,omitempty"`
+}
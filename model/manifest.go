@@ -0,0 +1,73 @@
+package model
+
+// Manifest is an image manifest, Docker v2 schema 2 or OCI, or a manifest
+// list (an OCI index), whose Manifests are then the images of the
+// platforms it lists.
+type Manifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType,omitempty"`
+	Config        *Descriptor       `json:"config,omitempty"`
+	Layers        []Descriptor      `json:"layers,omitempty"`
+	Manifests     []Descriptor      `json:"manifests,omitempty"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// Descriptor is a content of a manifest: its config, a layer, or an image
+// of a manifest list.
+type Descriptor struct {
+	MediaType string    `json:"mediaType"`
+	Digest    string    `json:"digest"`
+	Size      int64     `json:"size"`
+	Platform  *Platform `json:"platform,omitempty"`
+}
+
+// Platform is the platform an image runs on.
+type Platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// String returns the platform as os/arch[/variant].
+func (p *Platform) String() string {
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
+
+// ImageConfig is the config of an image, as referenced by its manifest:
+// its platform, the config of its containers and how it was built. With
+// the v2.0 API it is the extra attributes of the artifact, without history.
+type ImageConfig struct {
+	Architecture string           `json:"architecture"`
+	OS           string           `json:"os"`
+	Variant      string           `json:"variant,omitempty"`
+	Created      Time             `json:"created"`
+	Author       string           `json:"author,omitempty"`
+	Config       *ContainerConfig `json:"config,omitempty"`
+	History      []ImageHistory   `json:"history,omitempty"`
+}
+
+// ContainerConfig is the config the containers of an image run with.
+type ContainerConfig struct {
+	User         string              `json:"User,omitempty"`
+	Env          []string            `json:"Env,omitempty"`
+	Entrypoint   []string            `json:"Entrypoint,omitempty"`
+	Cmd          []string            `json:"Cmd,omitempty"`
+	WorkingDir   string              `json:"WorkingDir,omitempty"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts,omitempty"`
+	Volumes      map[string]struct{} `json:"Volumes,omitempty"`
+	Labels       map[string]string   `json:"Labels,omitempty"`
+}
+
+// ImageHistory is a step of the build of an image, EmptyLayer if it made
+// no layer, e.g. ENV.
+type ImageHistory struct {
+	Created    Time   `json:"created"`
+	CreatedBy  string `json:"created_by,omitempty"`
+	Author     string `json:"author,omitempty"`
+	Comment    string `json:"comment,omitempty"`
+	EmptyLayer bool   `json:"empty_layer,omitempty"`
+}
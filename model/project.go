@@ -0,0 +1,114 @@
+package model
+
+// Project is a project.
+type Project struct {
+	ProjectID          int64            `json:"project_id"`
+	OwnerID            int64            `json:"owner_id"`
+	Name               string           `json:"name"`
+	RegistryID         int64            `json:"registry_id,omitempty"`
+	CreationTime       Time             `json:"creation_time"`
+	UpdateTime         Time             `json:"update_time"`
+	Deleted            bool             `json:"deleted,omitempty"`
+	OwnerName          string           `json:"owner_name"`
+	Togglable          bool             `json:"togglable,omitempty"`
+	CurrentUserRoleID  int64            `json:"current_user_role_id"`
+	CurrentUserRoleIDs []int64          `json:"current_user_role_ids,omitempty"`
+	RepoCount          int64            `json:"repo_count"`
+	ChartCount         int64            `json:"chart_count,omitempty"`
+	Metadata           *ProjectMetadata `json:"metadata,omitempty"`
+	CVEAllowlist       *CVEAllowlist    `json:"cve_allowlist,omitempty"`
+}
+
+// ProjectMetadata are the settings of a project, all values are strings,
+// e.g. "true".
+type ProjectMetadata struct {
+	Public                   string `json:"public,omitempty"`
+	EnableContentTrust       string `json:"enable_content_trust,omitempty"`
+	EnableContentTrustCosign string `json:"enable_content_trust_cosign,omitempty"`
+	PreventVul               string `json:"prevent_vul,omitempty"`
+	Severity                 string `json:"severity,omitempty"`
+	AutoScan                 string `json:"auto_scan,omitempty"`
+	AutoSBOMGeneration       string `json:"auto_sbom_generation,omitempty"`
+	ReuseSysCVEAllowlist     string `json:"reuse_sys_cve_allowlist,omitempty"`
+	RetentionID              string `json:"retention_id,omitempty"`
+	ProxySpeedKB             string `json:"proxy_speed_kb,omitempty"`
+}
+
+// CVEAllowlist is the list of CVEs ignored by the vulnerability policy of
+// the system or of a project.
+type CVEAllowlist struct {
+	ID           int64              `json:"id"`
+	ProjectID    int64              `json:"project_id"`
+	ExpiresAt    *int64             `json:"expires_at,omitempty"`
+	Items        []CVEAllowlistItem `json:"items"`
+	CreationTime Time               `json:"creation_time"`
+	UpdateTime   Time               `json:"update_time"`
+}
+
+// ProjectCVEAllowlist is the CVE allowlist of a project, used by its
+// vulnerability policy unless ReuseSysCVEAllowlist.
+type ProjectCVEAllowlist struct {
+	CVEAllowlist         *CVEAllowlist `json:"cve_allowlist"`
+	ReuseSysCVEAllowlist bool          `json:"reuse_sys_cve_allowlist"`
+}
+
+// CVEAllowlistItem is a CVE of a CVEAllowlist.
+type CVEAllowlistItem struct {
+	CVEID string `json:"cve_id"`
+}
+
+// ProjectMember is a user or a user group member of a project.
+type ProjectMember struct {
+	ID         int64  `json:"id"`
+	ProjectID  int64  `json:"project_id"`
+	EntityName string `json:"entity_name"`
+	RoleName   string `json:"role_name"`
+	RoleID     int64  `json:"role_id"`
+	EntityID   int64  `json:"entity_id"`
+	// EntityType is "u" for users, "g" for user groups.
+	EntityType string `json:"entity_type"`
+}
+
+// ProjectSummary sums up a project, the counts of its members by role and
+// its quota.
+type ProjectSummary struct {
+	RepoCount         int64     `json:"repo_count"`
+	ChartCount        int64     `json:"chart_count,omitempty"`
+	ProjectAdminCount int64     `json:"project_admin_count"`
+	MaintainerCount   int64     `json:"maintainer_count"`
+	DeveloperCount    int64     `json:"developer_count"`
+	GuestCount        int64     `json:"guest_count"`
+	LimitedGuestCount int64     `json:"limited_guest_count"`
+	Quota             *Quota    `json:"quota,omitempty"`
+	Registry          *Registry `json:"registry,omitempty"`
+}
+
+// Quota is the hard limit and the usage of resources, storage in bytes,
+// -1 for unlimited.
+type Quota struct {
+	Hard ResourceList `json:"hard"`
+	Used ResourceList `json:"used"`
+}
+
+// ResourceList are amounts of resources by name, e.g. "storage".
+type ResourceList map[string]int64
+
+// AccessLog is an operation on artifacts of the v1 API logs.
+type AccessLog struct {
+	LogID     int64  `json:"log_id"`
+	Username  string `json:"username"`
+	RepoName  string `json:"repo_name"`
+	RepoTag   string `json:"repo_tag"`
+	Operation string `json:"operation"`
+	OpTime    Time   `json:"op_time"`
+}
+
+// AuditLog is an operation of the v2.0 API audit logs.
+type AuditLog struct {
+	ID           int64  `json:"id"`
+	Username     string `json:"username"`
+	Resource     string `json:"resource"`
+	ResourceType string `json:"resource_type"`
+	Operation    string `json:"operation"`
+	OpTime       Time   `json:"op_time"`
+}
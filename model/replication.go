@@ -0,0 +1,125 @@
+package model
+
+// Target is a replication target of the v1 API.
+type Target struct {
+	ID           int64  `json:"id"`
+	Endpoint     string `json:"endpoint"`
+	Name         string `json:"name"`
+	Username     string `json:"username"`
+	Password     string `json:"password,omitempty"`
+	Type         int    `json:"type"`
+	Insecure     bool   `json:"insecure"`
+	CreationTime Time   `json:"creation_time"`
+	UpdateTime   Time   `json:"update_time"`
+}
+
+// Registry is a registry endpoint of the v2.0 API, the replication
+// targets and the upstream of proxy cache projects.
+type Registry struct {
+	ID           int64               `json:"id"`
+	URL          string              `json:"url"`
+	Name         string              `json:"name"`
+	Credential   *RegistryCredential `json:"credential,omitempty"`
+	Type         string              `json:"type"`
+	Insecure     bool                `json:"insecure"`
+	Description  string              `json:"description"`
+	Status       string              `json:"status"`
+	CreationTime Time                `json:"creation_time"`
+	UpdateTime   Time                `json:"update_time"`
+}
+
+// RegistryCredential is the credential of a Registry, the secret is never
+// returned.
+type RegistryCredential struct {
+	Type         string `json:"type"`
+	AccessKey    string `json:"access_key"`
+	AccessSecret string `json:"access_secret,omitempty"`
+}
+
+// ReplicationPolicy is a replication policy. Projects and Targets belong to
+// the v1 API, the registries to the v2.0 API.
+type ReplicationPolicy struct {
+	ID                        int64               `json:"id"`
+	Name                      string              `json:"name"`
+	Description               string              `json:"description"`
+	Projects                  []Project           `json:"projects,omitempty"`
+	Targets                   []Target            `json:"targets,omitempty"`
+	SrcRegistry               *Registry           `json:"src_registry,omitempty"`
+	DestRegistry              *Registry           `json:"dest_registry,omitempty"`
+	DestNamespace             string              `json:"dest_namespace,omitempty"`
+	DestNamespaceReplaceCount int                 `json:"dest_namespace_replace_count,omitempty"`
+	Trigger                   *ReplicationTrigger `json:"trigger,omitempty"`
+	Filters                   []ReplicationFilter `json:"filters,omitempty"`
+	ReplicateExistingImageNow bool                `json:"replicate_existing_image_now,omitempty"`
+	ReplicateDeletion         bool                `json:"replicate_deletion"`
+	Override                  bool                `json:"override,omitempty"`
+	Enabled                   bool                `json:"enabled,omitempty"`
+	Speed                     int64               `json:"speed,omitempty"`
+	CopyByChunk               bool                `json:"copy_by_chunk,omitempty"`
+	ErrorJobCount             int64               `json:"error_job_count,omitempty"`
+	Deleted                   bool                `json:"deleted,omitempty"`
+	CreationTime              Time                `json:"creation_time"`
+	UpdateTime                Time                `json:"update_time"`
+}
+
+// ReplicationTrigger is the trigger of a ReplicationPolicy: "manual",
+// "scheduled" or "event_based" ("immediate" in the v1 API).
+type ReplicationTrigger struct {
+	Kind            string                 `json:"kind,omitempty"`
+	Type            string                 `json:"type,omitempty"`
+	ScheduleParam   map[string]interface{} `json:"schedule_param,omitempty"`
+	TriggerSettings map[string]interface{} `json:"trigger_settings,omitempty"`
+}
+
+// ReplicationFilter selects the resources replicated by a policy.
+type ReplicationFilter struct {
+	Kind       string      `json:"kind,omitempty"`
+	Type       string      `json:"type,omitempty"`
+	Pattern    string      `json:"pattern,omitempty"`
+	Value      interface{} `json:"value,omitempty"`
+	Decoration string      `json:"decoration,omitempty"`
+}
+
+// ReplicationJob is a job of the v1 API, the replication of a repository.
+type ReplicationJob struct {
+	ID           int64    `json:"id"`
+	Status       string   `json:"status"`
+	Repository   string   `json:"repository"`
+	PolicyID     int64    `json:"policy_id"`
+	Operation    string   `json:"operation"`
+	Tags         []string `json:"tags"`
+	CreationTime Time     `json:"creation_time"`
+	UpdateTime   Time     `json:"update_time"`
+}
+
+// ReplicationExecution is an execution of a replication policy, Harbor
+// v1.8+.
+type ReplicationExecution struct {
+	ID         int64  `json:"id"`
+	PolicyID   int64  `json:"policy_id"`
+	Status     string `json:"status"`
+	StatusText string `json:"status_text"`
+	Trigger    string `json:"trigger"`
+	Total      int64  `json:"total"`
+	Failed     int64  `json:"failed"`
+	Succeed    int64  `json:"succeed"`
+	InProgress int64  `json:"in_progress"`
+	Stopped    int64  `json:"stopped"`
+	StartTime  Time   `json:"start_time"`
+	EndTime    Time   `json:"end_time"`
+}
+
+// ReplicationTask is the replication of a resource by a
+// ReplicationExecution.
+type ReplicationTask struct {
+	ID           int64  `json:"id"`
+	ExecutionID  int64  `json:"execution_id"`
+	ResourceType string `json:"resource_type"`
+	SrcResource  string `json:"src_resource"`
+	DstResource  string `json:"dst_resource"`
+	Operation    string `json:"operation"`
+	JobID        string `json:"job_id"`
+	Status       string `json:"status"`
+	StartTime    Time   `json:"start_time"`
+	EndTime      Time   `json:"end_time"`
+}
@@ -0,0 +1,118 @@
+package model
+
+// Repository is a repository, with the number of its tags (v1 API) or
+// artifacts (v2.0 API).
+type Repository struct {
+	ID            int64   `json:"id"`
+	Name          string  `json:"name"`
+	ProjectID     int64   `json:"project_id"`
+	Description   string  `json:"description"`
+	PullCount     int64   `json:"pull_count"`
+	StarCount     int64   `json:"star_count,omitempty"`
+	TagsCount     int64   `json:"tags_count,omitempty"`
+	ArtifactCount int64   `json:"artifact_count,omitempty"`
+	Labels        []Label `json:"labels,omitempty"`
+	CreationTime  Time    `json:"creation_time"`
+	UpdateTime    Time    `json:"update_time"`
+}
+
+// Tag is a tag of the v1 API, an image with its details.
+type Tag struct {
+	Digest        string                 `json:"digest"`
+	Name          string                 `json:"name"`
+	Size          int64                  `json:"size"`
+	Architecture  string                 `json:"architecture"`
+	OS            string                 `json:"os"`
+	OSVersion     string                 `json:"os.version,omitempty"`
+	DockerVersion string                 `json:"docker_version"`
+	Author        string                 `json:"author"`
+	Created       Time                   `json:"created"`
+	Config        *TagConfig             `json:"config,omitempty"`
+	Signature     interface{}            `json:"signature"`
+	ScanOverview  map[string]interface{} `json:"scan_overview,omitempty"`
+	Labels        []Label                `json:"labels"`
+	PushTime      Time                   `json:"push_time"`
+	PullTime      Time                   `json:"pull_time"`
+}
+
+// TagConfig is the part of the image config of a Tag.
+type TagConfig struct {
+	Labels map[string]string `json:"labels"`
+}
+
+// Artifact is an artifact of the v2.0 API: an image, an index, a chart,
+// or any other OCI artifact.
+type Artifact struct {
+	ID                int64                  `json:"id"`
+	Type              string                 `json:"type"`
+	MediaType         string                 `json:"media_type"`
+	ManifestMediaType string                 `json:"manifest_media_type"`
+	ProjectID         int64                  `json:"project_id"`
+	RepositoryID      int64                  `json:"repository_id"`
+	RepositoryName    string                 `json:"repository_name,omitempty"`
+	Digest            string                 `json:"digest"`
+	Size              int64                  `json:"size"`
+	Icon              string                 `json:"icon,omitempty"`
+	PushTime          Time                   `json:"push_time"`
+	PullTime          Time                   `json:"pull_time"`
+	ExtraAttrs        map[string]interface{} `json:"extra_attrs,omitempty"`
+	Annotations       map[string]string      `json:"annotations,omitempty"`
+	References        []ArtifactReference    `json:"references,omitempty"`
+	Tags              []ArtifactTag          `json:"tags,omitempty"`
+	Labels            []Label                `json:"labels,omitempty"`
+	ScanOverview      map[string]interface{} `json:"scan_overview,omitempty"`
+	Accessories       []Accessory            `json:"accessories,omitempty"`
+}
+
+// ArtifactReference is a child of an index artifact.
+type ArtifactReference struct {
+	ParentID    int64                  `json:"parent_id"`
+	ChildID     int64                  `json:"child_id"`
+	ChildDigest string                 `json:"child_digest"`
+	Platform    map[string]interface{} `json:"platform,omitempty"`
+	Annotations map[string]string      `json:"annotations,omitempty"`
+	URLs        []string               `json:"urls,omitempty"`
+}
+
+// ArtifactTag is a tag of an Artifact.
+type ArtifactTag struct {
+	ID           int64  `json:"id"`
+	RepositoryID int64  `json:"repository_id"`
+	ArtifactID   int64  `json:"artifact_id"`
+	Name         string `json:"name"`
+	PushTime     Time   `json:"push_time"`
+	PullTime     Time   `json:"pull_time"`
+	Immutable    bool   `json:"immutable"`
+	Signed       bool   `json:"signed,omitempty"`
+}
+
+// Accessory is an artifact attached to another one, e.g. a signature or an
+// SBOM (Harbor v2.8+).
+type Accessory struct {
+	ID                int64  `json:"id"`
+	ArtifactID        int64  `json:"artifact_id"`
+	SubjectArtifactID int64  `json:"subject_artifact_id"`
+	Size              int64  `json:"size"`
+	Digest            string `json:"digest"`
+	Type              string `json:"type"`
+	Icon              string `json:"icon,omitempty"`
+	CreationTime      Time   `json:"creation_time"`
+}
+
+// RepoSignature is a signature of a tag in notary.
+type RepoSignature struct {
+	Tag    string            `json:"tag"`
+	Hashes map[string]string `json:"hashes"`
+}
+
+// VulnerabilityItem is a vulnerability of the details of a tag of the v1
+// API, found by Clair. Severity is 1 for none to 5 for high.
+type VulnerabilityItem struct {
+	ID           string `json:"id"`
+	Severity     int    `json:"severity"`
+	Package      string `json:"package"`
+	Version      string `json:"version"`
+	Description  string `json:"description"`
+	Link         string `json:"link"`
+	FixedVersion string `json:"fixedVersion,omitempty"`
+}
@@ -0,0 +1,89 @@
+package model
+
+// RetentionPolicy is the tag retention policy of a project: the artifacts
+// kept by any of its rules are retained, the others deleted when it runs.
+// (Harbor v1.9+)
+type RetentionPolicy struct {
+	ID        int64             `json:"id,omitempty"`
+	Algorithm string            `json:"algorithm"`
+	Rules     []*RetentionRule  `json:"rules"`
+	Trigger   *RetentionTrigger `json:"trigger"`
+	Scope     *RetentionScope   `json:"scope"`
+}
+
+// RetentionRule is a rule of a retention policy, Template is e.g.
+// latestPushedK or nDaysSinceLastPull, with its value in Params.
+type RetentionRule struct {
+	ID             int                             `json:"id,omitempty"`
+	Priority       int                             `json:"priority,omitempty"`
+	Disabled       bool                            `json:"disabled"`
+	Action         string                          `json:"action"`
+	Template       string                          `json:"template"`
+	Params         map[string]interface{}          `json:"params"`
+	TagSelectors   []*RetentionSelector            `json:"tag_selectors"`
+	ScopeSelectors map[string][]*RetentionSelector `json:"scope_selectors"`
+}
+
+// RetentionSelector selects the repositories or tags of a retention or an
+// immutable rule by a doublestar Pattern, Decoration is e.g. repoMatches or
+// excludes.
+type RetentionSelector struct {
+	Kind       string `json:"kind"`
+	Decoration string `json:"decoration"`
+	Pattern    string `json:"pattern"`
+	Extras     string `json:"extras,omitempty"`
+}
+
+// RetentionTrigger is the schedule of a retention policy, a cron in
+// Settings, empty to run it manually only.
+type RetentionTrigger struct {
+	Kind       string                 `json:"kind"`
+	Settings   map[string]interface{} `json:"settings"`
+	References map[string]interface{} `json:"references,omitempty"`
+}
+
+// RetentionScope is the project of a retention policy.
+type RetentionScope struct {
+	Level string `json:"level"`
+	Ref   int64  `json:"ref"`
+}
+
+// RetentionExecution is a run of a retention policy.
+type RetentionExecution struct {
+	ID        int64  `json:"id"`
+	PolicyID  int64  `json:"policy_id"`
+	Status    string `json:"status"`
+	Trigger   string `json:"trigger"`
+	DryRun    bool   `json:"dry_run"`
+	StartTime Time   `json:"start_time"`
+	EndTime   Time   `json:"end_time"`
+}
+
+// RetentionTask is the task of a run of a retention policy for a
+// repository.
+type RetentionTask struct {
+	ID          int64  `json:"id"`
+	ExecutionID int64  `json:"execution_id"`
+	Repository  string `json:"repository"`
+	JobID       string `json:"job_id"`
+	Status      string `json:"status"`
+	StatusCode  int    `json:"status_code"`
+	Total       int    `json:"total"`
+	Retained    int    `json:"retained"`
+	StartTime   Time   `json:"start_time"`
+	EndTime     Time   `json:"end_time"`
+}
+
+// ImmutableRule is an immutable tag rule of a project: the tags it selects
+// cannot be deleted nor overwritten. Its selectors are those of retention
+// rules. (Harbor v1.10+)
+type ImmutableRule struct {
+	ID             int64                           `json:"id,omitempty"`
+	ProjectID      int64                           `json:"project_id"`
+	Disabled       bool                            `json:"disabled"`
+	Priority       int                             `json:"priority"`
+	Action         string                          `json:"action"`
+	Template       string                          `json:"template"`
+	TagSelectors   []*RetentionSelector            `json:"tag_selectors"`
+	ScopeSelectors map[string][]*RetentionSelector `json:"scope_selectors"`
+}
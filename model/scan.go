@@ -0,0 +1,63 @@
+package model
+
+// NativeReportSummary is the scan overview of an artifact of the v2.0 API,
+// by report media type. ScanStatus is e.g. Pending, Running, Success or
+// Error, Severity the highest of the vulnerabilities found. Scanner is
+// missing before Harbor v2.1.
+type NativeReportSummary struct {
+	ReportID   string `json:"report_id"`
+	ScanStatus string `json:"scan_status"`
+	Severity   string `json:"severity"`
+	Duration   int64  `json:"duration"`
+	StartTime  Time   `json:"start_time"`
+	EndTime    Time   `json:"end_time"`
+	Scanner    *struct {
+		Name    string `json:"name"`
+		Vendor  string `json:"vendor"`
+		Version string `json:"version"`
+	} `json:"scanner,omitempty"`
+	Summary *struct {
+		Total   int            `json:"total"`
+		Fixable int            `json:"fixable"`
+		Summary map[string]int `json:"summary"`
+	} `json:"summary,omitempty"`
+}
+
+// VulnerabilityReport is the vulnerability report of an artifact of the
+// v2.0 API, the value of its report media type. Severity is the highest of
+// the vulnerabilities found.
+type VulnerabilityReport struct {
+	GeneratedAt Time `json:"generated_at"`
+	Scanner     *struct {
+		Name    string `json:"name"`
+		Vendor  string `json:"vendor"`
+		Version string `json:"version"`
+	} `json:"scanner"`
+	Severity        string                 `json:"severity"`
+	Vulnerabilities []*ReportVulnerability `json:"vulnerabilities"`
+}
+
+// ReportVulnerability is a vulnerability of a report of the v2.0 API,
+// Severity is one of None, Unknown, Negligible, Low, Medium, High and
+// Critical.
+type ReportVulnerability struct {
+	ID          string   `json:"id"`
+	Package     string   `json:"package"`
+	Version     string   `json:"version"`
+	FixVersion  string   `json:"fix_version"`
+	Severity    string   `json:"severity"`
+	Description string   `json:"description"`
+	Links       []string `json:"links"`
+}
+
+// ScanAllMetrics is the progress of the scan of all the artifacts: Metrics
+// counts the scans by status, e.g. Success, Error or Running. (Harbor
+// v1.10+)
+type ScanAllMetrics struct {
+	Total     int            `json:"total"`
+	Completed int            `json:"completed"`
+	Metrics   map[string]int `json:"metrics"`
+	Requester string         `json:"requester"`
+	Ongoing   bool           `json:"ongoing"`
+	Trigger   string         `json:"trigger"`
+}
@@ -0,0 +1,22 @@
+package model
+
+// ScannerRegistration is a scanner adapter registered in Harbor, the
+// default one scans the projects which have no scanner of their own.
+// (Harbor v1.10+)
+type ScannerRegistration struct {
+	UUID            string `json:"uuid"`
+	Name            string `json:"name"`
+	Description     string `json:"description"`
+	URL             string `json:"url"`
+	Disabled        bool   `json:"disabled"`
+	IsDefault       bool   `json:"is_default"`
+	Auth            string `json:"auth"`
+	SkipCertVerify  bool   `json:"skip_certVerify"`
+	UseInternalAddr bool   `json:"use_internal_addr"`
+	Adapter         string `json:"adapter,omitempty"`
+	Vendor          string `json:"vendor,omitempty"`
+	Version         string `json:"version,omitempty"`
+	Health          string `json:"health,omitempty"`
+	CreateTime      Time   `json:"create_time"`
+	UpdateTime      Time   `json:"update_time"`
+}
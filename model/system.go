@@ -0,0 +1,87 @@
+package model
+
+// SystemInfo is the general information of the system.
+type SystemInfo struct {
+	HarborVersion               string `json:"harbor_version"`
+	AuthMode                    string `json:"auth_mode"`
+	ExternalURL                 string `json:"external_url,omitempty"`
+	RegistryURL                 string `json:"registry_url"`
+	ProjectCreationRestriction  string `json:"project_creation_restriction"`
+	SelfRegistration            bool   `json:"self_registration"`
+	HasCARoot                   bool   `json:"has_ca_root"`
+	ReadOnly                    bool   `json:"read_only"`
+	WithNotary                  bool   `json:"with_notary"`
+	WithClair                   bool   `json:"with_clair,omitempty"`
+	WithChartmuseum             bool   `json:"with_chartmuseum"`
+	WithAdmiral                 bool   `json:"with_admiral,omitempty"`
+	AdmiralEndpoint             string `json:"admiral_endpoint,omitempty"`
+	NotificationEnable          bool   `json:"notification_enable,omitempty"`
+	RegistryStorageProviderName string `json:"registry_storage_provider_name,omitempty"`
+	PrimaryAuthMode             bool   `json:"primary_auth_mode,omitempty"`
+	BannerMessage               string `json:"banner_message,omitempty"`
+}
+
+// OverallHealth is the health of Harbor: Status is healthy if all its
+// components are, unhealthy otherwise. (Harbor v1.9+)
+type OverallHealth struct {
+	Status     string             `json:"status"`
+	Components []*ComponentHealth `json:"components"`
+}
+
+// ComponentHealth is the health of a component of Harbor, e.g. core or
+// redis, with the Error making it unhealthy.
+type ComponentHealth struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Statistic counts the projects and repositories visible to the user.
+type Statistic struct {
+	PrivateProjectCount     int64 `json:"private_project_count"`
+	PrivateRepoCount        int64 `json:"private_repo_count"`
+	PublicProjectCount      int64 `json:"public_project_count"`
+	PublicRepoCount         int64 `json:"public_repo_count"`
+	TotalProjectCount       int64 `json:"total_project_count"`
+	TotalRepoCount          int64 `json:"total_repo_count"`
+	TotalStorageConsumption int64 `json:"total_storage_consumption,omitempty"`
+}
+
+// Search is the result of a search, projects and repositories whose name
+// match.
+type Search struct {
+	Project    []Project          `json:"project"`
+	Repository []SearchRepository `json:"repository"`
+}
+
+// SearchRepository is a repository found by a search.
+type SearchRepository struct {
+	ProjectID      int64  `json:"project_id"`
+	ProjectName    string `json:"project_name"`
+	ProjectPublic  bool   `json:"project_public"`
+	RepositoryName string `json:"repository_name"`
+	PullCount      int64  `json:"pull_count,omitempty"`
+	TagsCount      int64  `json:"tags_count,omitempty"`
+	ArtifactCount  int64  `json:"artifact_count,omitempty"`
+}
+
+// GCSchedule is the schedule of the garbage collection: Type is Hourly,
+// Daily, Weekly, Custom (with Cron), Manual or None.
+type GCSchedule struct {
+	Type string `json:"type"`
+	Cron string `json:"cron,omitempty"`
+}
+
+// GCHistory is a run of the garbage collection. JobParameters holds the
+// parameters of the run as JSON, e.g. delete_untagged with Harbor v2.x.
+type GCHistory struct {
+	ID            int64       `json:"id"`
+	JobName       string      `json:"job_name"`
+	JobKind       string      `json:"job_kind"`
+	JobParameters string      `json:"job_parameters,omitempty"`
+	Schedule      *GCSchedule `json:"schedule"`
+	JobStatus     string      `json:"job_status"`
+	Deleted       bool        `json:"deleted"`
+	CreationTime  Time        `json:"creation_time"`
+	UpdateTime    Time        `json:"update_time"`
+}
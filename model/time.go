@@ -0,0 +1,40 @@
+// Package model contains the models of the Harbor API, as defined by its
+// swagger documents, for decoding responses:
+//
+//	var labels []*model.Label
+//	res, err := api.GetLabels(c, &api.LabelsList{Scope: "g", Page: 1, PageSize: 10})
+//	if err == nil {
+//		err = res.Decode(&labels)
+//	}
+//
+// Models of the v1 and v2.0 API are merged where they describe the same
+// thing, fields known to one version only are omitted when empty.
+package model // import "github.com/moooofly/harbor-go-client/model"
+
+import (
+	"bytes"
+	"time"
+)
+
+// Time is a timestamp of the API. Besides RFC 3339 it accepts null and the
+// empty string, which Harbor sends for unset times, as the zero time.
+type Time struct {
+	time.Time
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *Time) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) || bytes.Equal(b, []byte(`""`)) {
+		t.Time = time.Time{}
+		return nil
+	}
+	return t.Time.UnmarshalJSON(b)
+}
+
+// MarshalJSON implements json.Marshaler, the zero time is null.
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return t.Time.MarshalJSON()
+}
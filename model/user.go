@@ -0,0 +1,72 @@
+package model
+
+// User is a user.
+type User struct {
+	UserID          int64  `json:"user_id"`
+	Username        string `json:"username"`
+	Email           string `json:"email"`
+	Realname        string `json:"realname"`
+	Comment         string `json:"comment"`
+	Deleted         bool   `json:"deleted,omitempty"`
+	RoleName        string `json:"role_name,omitempty"`
+	RoleID          int64  `json:"role_id,omitempty"`
+	SysadminFlag    bool   `json:"sysadmin_flag"`
+	AdminRoleInAuth bool   `json:"admin_role_in_auth"`
+	CreationTime    Time   `json:"creation_time"`
+	UpdateTime      Time   `json:"update_time"`
+}
+
+// UserSearchResult is a user found by users_search.
+type UserSearchResult struct {
+	UserID   int64  `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// UserGroup is a group of users of LDAP, HTTP or OIDC authentication.
+type UserGroup struct {
+	ID        int64  `json:"id"`
+	GroupName string `json:"group_name"`
+	// GroupType is 1 for LDAP, 2 for HTTP, 3 for OIDC groups.
+	GroupType   int    `json:"group_type"`
+	LDAPGroupDN string `json:"ldap_group_dn,omitempty"`
+}
+
+// LDAPUser is a user found in LDAP, imported by ldap_users_import.
+type LDAPUser struct {
+	Username string `json:"username"`
+	Realname string `json:"realname"`
+	Email    string `json:"email"`
+}
+
+// Permission is the actions allowed on a resource.
+type Permission struct {
+	Resource string `json:"resource"`
+	Action   string `json:"action"`
+}
+
+// RobotPermission is the actions allowed to a robot account of Harbor
+// v2.2+ on the resources of a namespace: a project name, or "*" for all
+// projects, with kind "project".
+type RobotPermission struct {
+	Kind      string       `json:"kind"`
+	Namespace string       `json:"namespace"`
+	Access    []Permission `json:"access"`
+}
+
+// Robot is a robot account of a project, or of the system with Harbor
+// v2.2+. Disabled belongs to the v1 API, Disable to Harbor v2.2+.
+type Robot struct {
+	ID           int64             `json:"id"`
+	Name         string            `json:"name"`
+	Description  string            `json:"description"`
+	ProjectID    int64             `json:"project_id,omitempty"`
+	Level        string            `json:"level,omitempty"`
+	Duration     int64             `json:"duration,omitempty"`
+	ExpiresAt    int64             `json:"expires_at"`
+	Disabled     bool              `json:"disabled,omitempty"`
+	Disable      bool              `json:"disable,omitempty"`
+	Access       []Permission      `json:"access,omitempty"`
+	Permissions  []RobotPermission `json:"permissions,omitempty"`
+	CreationTime Time              `json:"creation_time"`
+	UpdateTime   Time              `json:"update_time"`
+}
@@ -0,0 +1,47 @@
+package model
+
+// WebhookPolicy is a webhook policy of a project: the events notified to
+// its targets. (Harbor v1.9+)
+type WebhookPolicy struct {
+	ID           int64            `json:"id"`
+	Name         string           `json:"name"`
+	Description  string           `json:"description"`
+	ProjectID    int64            `json:"project_id"`
+	Targets      []*WebhookTarget `json:"targets"`
+	EventTypes   []string         `json:"event_types"`
+	Creator      string           `json:"creator"`
+	Enabled      bool             `json:"enabled"`
+	CreationTime Time             `json:"creation_time"`
+	UpdateTime   Time             `json:"update_time"`
+}
+
+// WebhookTarget is an endpoint notified by a webhook policy, Type is
+// "http" or "slack".
+type WebhookTarget struct {
+	Type           string `json:"type"`
+	Address        string `json:"address"`
+	AuthHeader     string `json:"auth_header,omitempty"`
+	SkipCertVerify bool   `json:"skip_cert_verify"`
+}
+
+// WebhookJob is a notification sent by a webhook policy.
+type WebhookJob struct {
+	ID           int64  `json:"id"`
+	PolicyID     int64  `json:"policy_id"`
+	EventType    string `json:"event_type"`
+	NotifyType   string `json:"notify_type"`
+	Status       string `json:"status"`
+	JobDetail    string `json:"job_detail"`
+	CreationTime Time   `json:"creation_time"`
+	UpdateTime   Time   `json:"update_time"`
+}
+
+// WebhookLastTrigger is the last time a webhook policy was triggered by
+// an event type.
+type WebhookLastTrigger struct {
+	PolicyName      string `json:"policy_name"`
+	EventType       string `json:"event_type"`
+	Enabled         bool   `json:"enabled"`
+	CreationTime    Time   `json:"creation_time"`
+	LastTriggerTime Time   `json:"last_trigger_time"`
+}
@@ -0,0 +1,182 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// HTTP methods.
+const (
+	GET    = http.MethodGet
+	POST   = http.MethodPost
+	PUT    = http.MethodPut
+	PATCH  = http.MethodPatch
+	DELETE = http.MethodDelete
+	HEAD   = http.MethodHead
+)
+
+// Request is an HTTP request being built. Errors met while building it,
+// e.g. a body which cannot be encoded, are kept in Errors and returned
+// when it is sent.
+type Request struct {
+	Method string
+	URL    string
+	Header http.Header
+	Errors []error
+
+	body    []byte
+	hasBody bool
+	ctx     context.Context
+	client  *http.Client
+}
+
+// NewRequest returns a request to be sent by client with ctx, which
+// aborts it when done.
+func NewRequest(ctx context.Context, client *http.Client, method, targetURL string) *Request {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &Request{
+		Method: method,
+		URL:    targetURL,
+		Header: http.Header{},
+		ctx:    ctx,
+		client: client,
+	}
+}
+
+// Set sets a header of the request.
+func (r *Request) Set(key, value string) *Request {
+	r.Header.Set(key, value)
+	return r
+}
+
+// SetBasicAuth authenticates the request by username and password.
+func (r *Request) SetBasicAuth(username, password string) *Request {
+	req := http.Request{Header: r.Header}
+	req.SetBasicAuth(username, password)
+	return r
+}
+
+// Send sets the body of the request. A string or a []byte is sent as is,
+// as JSON if it is JSON, as a form otherwise; other values are encoded as
+// JSON. The Content-Type is set accordingly unless already set.
+func (r *Request) Send(v interface{}) *Request {
+	var contentType string
+	switch v := v.(type) {
+	case string:
+		r.body = []byte(v)
+		contentType = bodyType(r.body)
+	case []byte:
+		r.body = v
+		contentType = bodyType(r.body)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			r.Errors = append(r.Errors, fmt.Errorf("encoding the body of %s %s: %v", r.Method, r.URL, err))
+			return r
+		}
+		r.body = b
+		contentType = "application/json"
+	}
+	r.hasBody = true
+	if r.Header.Get("Content-Type") == "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	return r
+}
+
+func bodyType(b []byte) string {
+	if json.Valid(b) {
+		return "application/json"
+	}
+	return "application/x-www-form-urlencoded"
+}
+
+// MakeRequest returns the *http.Request to send.
+func (r *Request) MakeRequest() (*http.Request, error) {
+	if len(r.Errors) > 0 {
+		return nil, r.Errors[0]
+	}
+	var body io.Reader
+	if r.hasBody {
+		body = bytes.NewReader(r.body)
+	}
+	req, err := http.NewRequestWithContext(r.ctx, r.Method, r.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range r.Header {
+		req.Header[k] = v
+	}
+	return req, nil
+}
+
+// Do sends the request and returns the response, whose body is left to the
+// caller to read and close.
+func (r *Request) Do() (*http.Response, error) {
+	req, err := r.MakeRequest()
+	if err != nil {
+		return nil, err
+	}
+	client := r.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
+
+// EndBytes sends the request and reads the whole body of the response.
+// The callbacks, if any, are called with the outcome before it is
+// returned.
+func (r *Request) EndBytes(callback ...func(resp *http.Response, body []byte, errs []error)) (*http.Response, []byte, []error) {
+	resp, body, err := r.end()
+	errs := errList(err)
+	for _, cb := range callback {
+		cb(resp, body, errs)
+	}
+	return resp, body, errs
+}
+
+// End is EndBytes with the body as a string.
+func (r *Request) End(callback ...func(resp *http.Response, body string, errs []error)) (*http.Response, string, []error) {
+	resp, body, err := r.end()
+	errs := errList(err)
+	for _, cb := range callback {
+		cb(resp, string(body), errs)
+	}
+	return resp, string(body), errs
+}
+
+// EndStruct is EndBytes decoding the JSON body of the response into v.
+func (r *Request) EndStruct(v interface{}) (*http.Response, []byte, []error) {
+	resp, body, err := r.end()
+	if err == nil {
+		if uerr := json.Unmarshal(body, v); uerr != nil {
+			err = fmt.Errorf("decoding the response of %s %s: %v", r.Method, r.URL, uerr)
+		}
+	}
+	return resp, body, errList(err)
+}
+
+func (r *Request) end() (*http.Response, []byte, error) {
+	resp, err := r.Do()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	return resp, body, err
+}
+
+func errList(err error) []error {
+	if err == nil {
+		return nil
+	}
+	return []error{err}
+}
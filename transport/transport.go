@@ -0,0 +1,147 @@
+// Package transport sends the HTTP requests of the client, on the standard
+// library's http.Client: New builds the client from a Config (connection
+// pool, TLS, timeouts, redirect policy), NewRequest builds a request in the
+// chained manner of the rest of the code:
+//
+//	hc := transport.New(nil, transport.Config{InsecureSkipVerify: true})
+//	resp, body, errs := transport.NewRequest(ctx, hc, "GET", "https://harbor.example.com/api/v2.0/ping").
+//		Set("Accept", "text/plain").
+//		End()
+//
+// Requests share no state but the http.Client, they may be sent from
+// several goroutines.
+package transport // import "github.com/moooofly/harbor-go-client/transport"
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Defaults of the connection pool, for a client talking to a single host
+// from a few goroutines at most.
+const (
+	DefaultMaxIdleConns        = 100
+	DefaultMaxIdleConnsPerHost = 16
+	DefaultIdleConnTimeout     = 90 * time.Second
+	DefaultTLSHandshakeTimeout = 10 * time.Second
+	DefaultDialTimeout         = 30 * time.Second
+)
+
+// Config holds the settings of the HTTP client built by New, the zero
+// value of a field stands for its default.
+type Config struct {
+	// Timeout limits the time of every request, response body included,
+	// there is no limit if 0.
+	Timeout time.Duration
+
+	// TLS is the TLS configuration, e.g. with RootCAs or client
+	// certificates, cloned before use.
+	TLS *tls.Config
+	// InsecureSkipVerify disables the verification of the server's
+	// certificate chain and host name, whatever TLS says.
+	InsecureSkipVerify bool
+
+	// Connection pool and dialing, see http.Transport.
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+	TLSHandshakeTimeout time.Duration
+	DialTimeout         time.Duration
+	DisableKeepAlives   bool
+
+	// CheckRedirect is the redirect policy, see http.Client. Redirects are
+	// followed up to 10 times if nil.
+	CheckRedirect func(req *http.Request, via []*http.Request) error
+}
+
+// New returns a copy of base, an empty client if nil, whose transport is
+// configured by cfg. A transport of base which is an *http.Transport is
+// cloned, settings of cfg left to their zero value keep its ones, or get
+// the defaults for a fresh transport. Any other transport of base, e.g. one
+// instrumenting or proxying the requests, is kept as is: its connections
+// and TLS are its own, only Timeout and CheckRedirect of cfg apply.
+func New(base *http.Client, cfg Config) *http.Client {
+	var hc http.Client
+	if base != nil {
+		hc = *base
+	}
+
+	switch t := hc.Transport.(type) {
+	case nil:
+		hc.Transport = configure(newTransport(cfg), cfg, false)
+	case *http.Transport:
+		hc.Transport = configure(t.Clone(), cfg, true)
+	}
+
+	if cfg.Timeout != 0 {
+		hc.Timeout = cfg.Timeout
+	}
+	if cfg.CheckRedirect != nil {
+		hc.CheckRedirect = cfg.CheckRedirect
+	}
+	return &hc
+}
+
+// newTransport returns a fresh transport with the defaults.
+func newTransport(cfg Config) *http.Transport {
+	dialTimeout := cfg.DialTimeout
+	if dialTimeout == 0 {
+		dialTimeout = DefaultDialTimeout
+	}
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   dialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          DefaultMaxIdleConns,
+		MaxIdleConnsPerHost:   DefaultMaxIdleConnsPerHost,
+		IdleConnTimeout:       DefaultIdleConnTimeout,
+		TLSHandshakeTimeout:   DefaultTLSHandshakeTimeout,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// configure applies the transport settings of cfg to t, a clone of the
+// transport of the caller if cloned, whose dialer is then replaced for
+// DialTimeout only.
+func configure(t *http.Transport, cfg Config, cloned bool) *http.Transport {
+	if cfg.TLS != nil {
+		t.TLSClientConfig = cfg.TLS.Clone()
+	}
+	if cfg.InsecureSkipVerify {
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{}
+		}
+		t.TLSClientConfig.InsecureSkipVerify = true
+	}
+	if cfg.MaxIdleConns != 0 {
+		t.MaxIdleConns = cfg.MaxIdleConns
+	}
+	if cfg.MaxIdleConnsPerHost != 0 {
+		t.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	}
+	if cfg.MaxConnsPerHost != 0 {
+		t.MaxConnsPerHost = cfg.MaxConnsPerHost
+	}
+	if cfg.IdleConnTimeout != 0 {
+		t.IdleConnTimeout = cfg.IdleConnTimeout
+	}
+	if cfg.TLSHandshakeTimeout != 0 {
+		t.TLSHandshakeTimeout = cfg.TLSHandshakeTimeout
+	}
+	if cfg.DialTimeout != 0 && cloned {
+		t.DialContext = (&net.Dialer{
+			Timeout:   cfg.DialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+	}
+	if cfg.DisableKeepAlives {
+		t.DisableKeepAlives = true
+	}
+	return t
+}
@@ -0,0 +1,51 @@
+package transport
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestNewTransport(t *testing.T) {
+	custom := roundTripperFunc(func(*http.Request) (*http.Response, error) { return nil, nil })
+	base := &http.Transport{MaxIdleConns: 7, IdleConnTimeout: time.Minute}
+	cfg := Config{Timeout: time.Second, InsecureSkipVerify: true, MaxIdleConnsPerHost: 3}
+
+	// Any other RoundTripper than an *http.Transport is kept.
+	hc := New(&http.Client{Transport: custom}, cfg)
+	if _, ok := hc.Transport.(roundTripperFunc); !ok {
+		t.Errorf("custom RoundTripper replaced by %T", hc.Transport)
+	}
+	if hc.Timeout != cfg.Timeout {
+		t.Errorf("Timeout = %s, want %s", hc.Timeout, cfg.Timeout)
+	}
+
+	// An *http.Transport is cloned, and configured.
+	hc = New(&http.Client{Transport: base}, cfg)
+	tr, ok := hc.Transport.(*http.Transport)
+	switch {
+	case !ok:
+		t.Fatalf("transport is %T, want *http.Transport", hc.Transport)
+	case tr == base:
+		t.Error("transport of base not cloned")
+	case tr.MaxIdleConns != 7 || tr.IdleConnTimeout != time.Minute:
+		t.Errorf("settings of base lost: MaxIdleConns %d, IdleConnTimeout %s", tr.MaxIdleConns, tr.IdleConnTimeout)
+	case tr.MaxIdleConnsPerHost != 3 || tr.TLSClientConfig == nil || !tr.TLSClientConfig.InsecureSkipVerify:
+		t.Error("settings of cfg not applied")
+	}
+	if base.MaxIdleConnsPerHost != 0 || (base.TLSClientConfig != nil && base.TLSClientConfig.InsecureSkipVerify) {
+		t.Error("transport of base changed")
+	}
+
+	// A fresh transport gets the defaults.
+	hc = New(nil, Config{})
+	if tr, ok := hc.Transport.(*http.Transport); !ok || tr.MaxIdleConns != DefaultMaxIdleConns {
+		t.Errorf("fresh transport %T without the defaults", hc.Transport)
+	}
+}
@@ -0,0 +1,309 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
+)
+
+func init() {
+	Parser.AddCommand("artifact_keep",
+		"Label the artifacts to keep, for label-based retention rules.",
+		"Add a 'keep' label, created if missing, to the artifacts of a project (or of one of its repositories) which are the latest release of their minor version, which are signed, or which are listed as deployed in a file, so that a retention rule excluding the label never deletes them. With --exclusive, the label is removed from the artifacts which do not match anymore. (Harbor v2.0+, uses v2.0 API)",
+		&artifactKeep)
+}
+
+type artifactKeepRun struct {
+	Project        string `short:"p" long:"project" description:"(REQUIRED) The name of the project." required:"yes"`
+	Repo           string `short:"r" long:"repo" description:"The name of the repository, without project. All repositories of the project if not set."`
+	Label          string `short:"l" long:"label" description:"The name of the label." default:"keep"`
+	Scope          string `long:"scope" description:"The scope of the label, if created: 'p' for a project label, 'g' for a system label." default:"p" validate:"oneof=p|g"`
+	LatestPerMinor bool   `long:"latest-per-minor" description:"Keep the latest release (semver tag, no prerelease) of every major.minor version."`
+	Signed         bool   `long:"signed" description:"Keep the signed artifacts."`
+	Deployed       string `long:"deployed" description:"Keep the artifacts listed in this file, one reference per line, e.g. 'library/app:1.2.3' or 'harbor.example.com/library/app@sha256:...'."`
+	Exclusive      bool   `long:"exclusive" description:"Remove the label from the artifacts which do not match."`
+	DryRun         bool   `long:"dry-run" description:"Print the changes only."`
+}
+
+var artifactKeep artifactKeepRun
+
+// keepColor is the color of a created keep label.
+const keepColor = "#48960C"
+
+func (x *artifactKeepRun) Execute(args []string) error {
+	if !x.LatestPerMinor && !x.Signed && x.Deployed == "" {
+		return fmt.Errorf("at least one of --latest-per-minor, --signed or --deployed is required")
+	}
+
+	var deployed map[string]bool
+	if x.Deployed != "" {
+		var err error
+		if deployed, err = loadDeployedRefs(x.Deployed); err != nil {
+			return err
+		}
+	}
+
+	c := NewClient()
+	prjURL := c.URL("/api/v2.0/projects") + "/" + url.PathEscape(x.Project)
+
+	repos := []string{x.Repo}
+	if x.Repo == "" {
+		repos = nil
+		err := c.EachPage(prjURL+"/repositories", func(item json.RawMessage) error {
+			var r model.Repository
+			if err := json.Unmarshal(item, &r); err != nil {
+				return err
+			}
+			repos = append(repos, strings.TrimPrefix(r.Name, x.Project+"/"))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	label, err := x.keepLabel(c, prjURL)
+	if err != nil {
+		return err
+	}
+
+	added, removed := 0, 0
+	for _, repo := range repos {
+		repoURL := prjURL + "/repositories/" + RepoPathV2(repo)
+		var arts []*model.Artifact
+		err := c.EachPage(repoURL+"/artifacts?with_tag=true&with_label=true&with_signature=true&with_accessory=true", func(item json.RawMessage) error {
+			var a model.Artifact
+			if err := json.Unmarshal(item, &a); err != nil {
+				return err
+			}
+			arts = append(arts, &a)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		reasons := x.keepReasons(x.Project+"/"+repo, arts, deployed)
+		for _, a := range arts {
+			has := false
+			if label != nil {
+				for _, l := range a.Labels {
+					has = has || l.ID == label.ID
+				}
+			}
+			name := x.Project + "/" + repo + "@" + a.Digest
+			if len(a.Tags) > 0 {
+				name = x.Project + "/" + repo + ":" + a.Tags[0].Name
+			}
+
+			switch why := reasons[a.Digest]; {
+			case len(why) > 0 && !has:
+				fmt.Printf("+ %s (%s)\n", name, strings.Join(why, ", "))
+				added++
+				if x.DryRun {
+					continue
+				}
+				if label == nil {
+					if label, err = x.createKeepLabel(c, prjURL); err != nil {
+						return err
+					}
+				}
+				body := map[string]int64{"id": label.ID}
+				if _, err := c.Do(c.Post(repoURL + "/artifacts/" + TagPath(a.Digest) + "/labels").Send(body)); err != nil {
+					return err
+				}
+			case len(why) == 0 && has && x.Exclusive:
+				fmt.Printf("- %s\n", name)
+				removed++
+				if x.DryRun {
+					continue
+				}
+				labelURL := repoURL + "/artifacts/" + TagPath(a.Digest) + "/labels/" + strconv.FormatInt(label.ID, 10)
+				if _, err := c.Do(c.Delete(labelURL)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	fmt.Printf("%d artifacts labeled %q, %d unlabeled\n", added, x.Label, removed)
+	return nil
+}
+
+// keepReasons returns why the artifacts of repo, a full name, are kept, by
+// digest.
+func (x *artifactKeepRun) keepReasons(repo string, arts []*model.Artifact, deployed map[string]bool) map[string][]string {
+	reasons := map[string][]string{}
+
+	if x.LatestPerMinor {
+		latest := map[string]*semver{}
+		digests := map[string]string{}
+		for _, a := range arts {
+			for _, t := range a.Tags {
+				v, ok := parseSemver(t.Name)
+				if !ok || v.Pre != "" {
+					continue
+				}
+				minor := strconv.Itoa(v.Major) + "." + strconv.Itoa(v.Minor)
+				if cur, ok := latest[minor]; !ok || v.compare(cur) > 0 {
+					latest[minor] = v
+					digests[minor] = a.Digest
+				}
+			}
+		}
+		minors := make([]string, 0, len(latest))
+		for minor := range latest {
+			minors = append(minors, minor)
+		}
+		sort.Strings(minors)
+		for _, minor := range minors {
+			d := digests[minor]
+			reasons[d] = append(reasons[d], "latest of "+minor)
+		}
+	}
+
+	for _, a := range arts {
+		if x.Signed && artifactSigned(a) {
+			reasons[a.Digest] = append(reasons[a.Digest], "signed")
+		}
+		if deployed == nil {
+			continue
+		}
+		in := deployed[repo+"@"+a.Digest]
+		for _, t := range a.Tags {
+			in = in || deployed[repo+":"+t.Name]
+		}
+		if in {
+			reasons[a.Digest] = append(reasons[a.Digest], "deployed")
+		}
+	}
+	return reasons
+}
+
+// artifactSigned reports whether a tag of the artifact is signed by
+// notary, or a signature is attached to it (cosign, notation).
+func artifactSigned(a *model.Artifact) bool {
+	for _, t := range a.Tags {
+		if t.Signed {
+			return true
+		}
+	}
+	for _, acc := range a.Accessories {
+		if strings.HasPrefix(acc.Type, "signature.") {
+			return true
+		}
+	}
+	return false
+}
+
+// loadDeployedRefs reads image references, one per line, '#' starts a
+// comment. The registry host is dropped, and a tag and a digest both given
+// make two references.
+func loadDeployedRefs(file string) (map[string]bool, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	refs := map[string]bool{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		ref := strings.TrimSpace(line)
+		if ref == "" {
+			continue
+		}
+		if i := strings.IndexByte(ref, '/'); i >= 0 && strings.ContainsAny(ref[:i], ".:") {
+			ref = ref[i+1:]
+		}
+
+		name, digest := ref, ""
+		if i := strings.IndexByte(ref, '@'); i >= 0 {
+			name, digest = ref[:i], ref[i+1:]
+			refs[name+"@"+digest] = true
+		}
+		if i := strings.LastIndexByte(name, ':'); i > strings.LastIndexByte(name, '/') {
+			refs[name] = true
+		} else if digest == "" {
+			refs[name+":latest"] = true
+		}
+	}
+	return refs, sc.Err()
+}
+
+// keepLabel returns the keep label, nil if it does not exist yet.
+func (x *artifactKeepRun) keepLabel(c *harbor.Client, prjURL string) (*model.Label, error) {
+	q := url.Values{"scope": {x.Scope}, "name": {x.Label}}
+	if x.Scope == "p" {
+		var prj model.Project
+		if err := c.GetJSON(prjURL, &prj); err != nil {
+			return nil, err
+		}
+		q.Set("project_id", strconv.FormatInt(prj.ProjectID, 10))
+	}
+
+	var labels []*model.Label
+	if err := c.GetJSON(c.URL("/api/v2.0/labels")+"?"+q.Encode(), &labels); err != nil {
+		return nil, err
+	}
+	for _, l := range labels {
+		if l.Name == x.Label {
+			return l, nil
+		}
+	}
+	return nil, nil
+}
+
+// createKeepLabel creates the keep label and returns it.
+func (x *artifactKeepRun) createKeepLabel(c *harbor.Client, prjURL string) (*model.Label, error) {
+	l := &model.Label{
+		Name:        x.Label,
+		Description: "Artifacts kept by retention rules, applied by artifact_keep.",
+		Color:       keepColor,
+		Scope:       x.Scope,
+	}
+	if x.Scope == "p" {
+		var prj model.Project
+		if err := c.GetJSON(prjURL, &prj); err != nil {
+			return nil, err
+		}
+		l.ProjectID = prj.ProjectID
+	}
+
+	targetURL := c.URL("/api/v2.0/labels")
+	c.Trace("==> POST", targetURL)
+	body := map[string]interface{}{
+		"name":        l.Name,
+		"description": l.Description,
+		"color":       l.Color,
+		"scope":       l.Scope,
+		"project_id":  l.ProjectID,
+	}
+	res, err := c.Do(c.Post(targetURL).Send(body))
+	if err != nil {
+		return nil, err
+	}
+
+	loc := res.Header.Get("Location")
+	id, err := strconv.ParseInt(loc[strings.LastIndexByte(loc, '/')+1:], 10, 64)
+	if err != nil {
+		if found, ferr := x.keepLabel(c, prjURL); ferr == nil && found != nil {
+			return found, nil
+		}
+		return nil, fmt.Errorf("label %q created, but its ID is unknown: Location %q", x.Label, loc)
+	}
+	l.ID = id
+	return l, nil
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+func init() {
+	Parser.AddCommand("artifact_pullcmd",
+		"Print pull commands of an artifact.",
+		"Print ready-to-copy 'docker pull', 'helm pull' and 'oras pull' commands for an artifact reference (project/repo:tag or project/repo@digest), with the registry hostname of the configured target.",
+		&pullCmd)
+}
+
+type artifactPullCmd struct {
+	Reference string `short:"r" long:"reference" description:"(REQUIRED) The artifact reference, e.g. 'library/nginx:1.19' or 'library/nginx@sha256:...'." required:"yes"`
+	Type      string `short:"t" long:"type" description:"The kind of pull command to print, all kinds if not set." validate:"oneof=docker|helm|oras"`
+}
+
+var pullCmd artifactPullCmd
+
+func (x *artifactPullCmd) Execute(args []string) error {
+	config, err := generalConfigLoad()
+	if err != nil {
+		return err
+	}
+
+	repo, tag, digest := splitReference(x.Reference)
+	if repo == "" || (tag == "" && digest == "") {
+		return fmt.Errorf("bad reference %q, want project/repo:tag or project/repo@digest", x.Reference)
+	}
+
+	for _, cmd := range pullCommands(config.Dstip, repo, tag, digest) {
+		if x.Type == "" || strings.HasPrefix(cmd, x.Type+" ") {
+			fmt.Println(cmd)
+		}
+	}
+	return nil
+}
+
+// splitReference splits "repo:tag" or "repo@digest" into its parts.
+func splitReference(ref string) (repo, tag, digest string) {
+	ref = strings.TrimPrefix(ref, "/")
+	if i := strings.Index(ref, "@"); i >= 0 {
+		return ref[:i], "", ref[i+1:]
+	}
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		return ref[:i], ref[i+1:], ""
+	}
+	return ref, "", ""
+}
+
+// pullCommands generates the pull commands of an artifact on registry host.
+// Helm only pulls OCI charts by tag (version), so it is omitted for digests.
+func pullCommands(host, repo, tag, digest string) []string {
+	ref := host + "/" + repo
+	if digest != "" {
+		ref += "@" + digest
+	} else {
+		ref += ":" + tag
+	}
+
+	cmds := []string{"docker pull " + ref}
+	if tag != "" {
+		cmds = append(cmds, "helm pull oci://"+host+"/"+repo+" --version "+tag)
+	}
+	cmds = append(cmds, "oras pull "+ref)
+	return cmds
+}
@@ -0,0 +1,207 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ArtifactInfo is the readable summary of an artifact (a tag in v1 API):
+// the extra attributes of its config and its annotations. In v1 API the
+// annotations are the labels of the image config.
+type ArtifactInfo struct {
+	Tag         string
+	Digest      string
+	Size        int64
+	OS          string
+	Arch        string
+	Author      string
+	Created     string
+	Annotations map[string]string
+}
+
+// PrintArtifactTable prints artifacts as a table, with their annotations
+// under every row if showAnnotations is set.
+func PrintArtifactTable(arts []*ArtifactInfo, showAnnotations bool) {
+	line := "+----------------------+--------------------------+-----------------+------------+----------------------+----------------------+"
+	fmt.Println(line)
+	fmt.Printf("| % -20s | % -24s | % -15s | % -10s | % -20s | % -20s |\n", "Tag", "Digest", "OS/Arch", "Size", "Author", "Created")
+	fmt.Println(line)
+
+	for _, a := range arts {
+		digest := a.Digest
+		if len(digest) > 24 {
+			digest = digest[:24]
+		}
+		created := a.Created
+		if len(created) > 20 {
+			created = created[:20]
+		}
+		fmt.Printf("| % -20s | % -24s | % -15s | % -10s | % -20s | % -20s |\n",
+			a.Tag, digest, a.OS+"/"+a.Arch, humanSize(a.Size), a.Author, created)
+
+		if showAnnotations && len(a.Annotations) > 0 {
+			var keys []string
+			for k := range a.Annotations {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				fmt.Printf("|   %s = %s\n", k, a.Annotations[k])
+			}
+		}
+	}
+	fmt.Println(line)
+}
+
+// RepoInfo is the readable summary of a repository.
+type RepoInfo struct {
+	Name        string `json:"name"`
+	TagsCount   int    `json:"tags_count"`
+	Artifacts   int    `json:"artifact_count"`
+	PullCount   int    `json:"pull_count"`
+	UpdateTime  string `json:"update_time"`
+	Description string `json:"description"`
+}
+
+// PrintRepoTable prints repositories as a table, only the first line of
+// descriptions is shown. Repositories of v2.0 API count artifacts instead
+// of tags.
+func PrintRepoTable(repos []*RepoInfo) {
+	line := "+--------------------------------+------+--------+----------------------+------------------------------------------+"
+	fmt.Println(line)
+	fmt.Printf("| % -30s | % -4s | % -6s | % -20s | % -40s |\n", "Name", "Tags", "Pulls", "Updated", "Description")
+	fmt.Println(line)
+
+	for _, r := range repos {
+		updated := r.UpdateTime
+		if len(updated) > 20 {
+			updated = updated[:20]
+		}
+		desc := strings.TrimSpace(r.Description)
+		if i := strings.IndexByte(desc, '\n'); i >= 0 {
+			desc = strings.TrimSpace(desc[:i]) + " ..."
+		}
+		if len([]rune(desc)) > 40 {
+			desc = string([]rune(desc)[:36]) + " ..."
+		}
+		tags := r.TagsCount
+		if tags == 0 {
+			tags = r.Artifacts
+		}
+		fmt.Printf("| % -30s | % 4d | % 6d | % -20s | % -40s |\n", r.Name, tags, r.PullCount, updated, desc)
+	}
+	fmt.Println(line)
+}
+
+// humanSize formats a size in bytes with binary units.
+func humanSize(n int64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	f := float64(n)
+	i := 0
+	for f >= 1024 && i < len(units)-1 {
+		f /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%d%s", n, units[i])
+	}
+	return fmt.Sprintf("%.1f%s", f, units[i])
+}
+
+// ProjectUsage is the quota usage of a project, broken down by repository.
+type ProjectUsage struct {
+	Project string `json:"project"`
+	// QuotaHard is the storage quota in bytes, -1 for unlimited.
+	QuotaHard    int64              `json:"quota_hard"`
+	QuotaUsed    int64              `json:"quota_used"`
+	Repositories []*RepositoryUsage `json:"repositories"`
+}
+
+// RepositoryUsage is the number and the total size of the artifacts of a
+// repository. Layers shared by artifacts are counted in each of them, while
+// the quota counts them once.
+type RepositoryUsage struct {
+	Name      string `json:"name"`
+	Artifacts int    `json:"artifacts"`
+	Size      int64  `json:"size"`
+}
+
+// PrintProjectUsage prints the quota usage of a project and its
+// repositories, largest first, as a table.
+func PrintProjectUsage(u *ProjectUsage) {
+	hard := "unlimited"
+	if u.QuotaHard >= 0 {
+		hard = humanSize(u.QuotaHard)
+	}
+	fmt.Printf("Project %s: %s used of %s\n", u.Project, humanSize(u.QuotaUsed), hard)
+
+	line := "+------------------------------------------+-----------+------------+--------+"
+	fmt.Println(line)
+	fmt.Printf("| % -40s | % -9s | % -10s | % -6s |\n", "Repository", "Artifacts", "Size", "Share")
+	fmt.Println(line)
+	for _, r := range u.Repositories {
+		share := "-"
+		if u.QuotaUsed > 0 {
+			share = fmt.Sprintf("%.1f%%", float64(r.Size)*100/float64(u.QuotaUsed))
+		}
+		fmt.Printf("| % -40s | % 9d | % 10s | % 6s |\n", r.Name, r.Artifacts, humanSize(r.Size), share)
+	}
+	fmt.Println(line)
+}
+
+// RetentionSimulation is what a dry run of a tag retention policy would
+// keep and delete, by repository.
+type RetentionSimulation struct {
+	PolicyID     int64                            `json:"policy_id"`
+	ExecutionID  int64                            `json:"execution_id"`
+	Repositories []*RetentionSimulationRepository `json:"repositories"`
+}
+
+// RetentionSimulationRepository is what a dry run would do in a
+// repository, Status is the status of its task.
+type RetentionSimulationRepository struct {
+	Name      string                    `json:"name"`
+	Status    string                    `json:"status"`
+	Artifacts []*RetentionSimulationTag `json:"artifacts"`
+}
+
+// RetentionSimulationTag is a tag, "<none>" for an untagged artifact, and
+// what a dry run would do with its artifact: keep, delete, immutable (to
+// delete, but protected by an immutable tag rule) or error.
+type RetentionSimulationTag struct {
+	Tag    string `json:"tag"`
+	Digest string `json:"digest"`
+	Action string `json:"action"`
+}
+
+// PrintRetentionSimulation prints the dry run of a tag retention policy, a
+// table of the tags kept and deleted by repository.
+func PrintRetentionSimulation(s *RetentionSimulation) {
+	kept, deleted := 0, 0
+	line := "+------------------------------------------+--------------------------------+-------------------------+-----------+"
+	fmt.Println(line)
+	fmt.Printf("| % -40s | % -30s | % -23s | % -9s |\n", "Repository", "Tag", "Digest", "Action")
+	fmt.Println(line)
+	for _, r := range s.Repositories {
+		if len(r.Artifacts) == 0 {
+			fmt.Printf("| % -40s | % -30s | % -23s | % -9s |\n", r.Name, "-", "-", strings.ToLower(r.Status))
+		}
+		for _, a := range r.Artifacts {
+			digest := a.Digest
+			if len(digest) > 23 {
+				digest = digest[:23]
+			}
+			fmt.Printf("| % -40s | % -30s | % -23s | % -9s |\n", r.Name, a.Tag, digest, a.Action)
+			switch a.Action {
+			case "keep":
+				kept++
+			case "delete":
+				deleted++
+			}
+		}
+	}
+	fmt.Println(line)
+	fmt.Printf("Dry run %d of retention policy %d: %d tags kept, %d deleted, in %d repositories\n",
+		s.ExecutionID, s.PolicyID, kept, deleted, len(s.Repositories))
+}
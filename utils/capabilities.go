@@ -0,0 +1,271 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func init() {
+	Parser.AddCommand("capabilities",
+		"Probe which components the target Harbor has enabled.",
+		"Probe the target Harbor (version, enabled components: chartmuseum, notary, clair, trivy, metrics) and print which commands are usable against it. The result is cached, and commands unavailable on the target are marked in help.",
+		&capa)
+}
+
+// Components that commands may depend on.
+const (
+	ComponentNotary      = "notary"
+	ComponentClair       = "clair"
+	ComponentTrivy       = "trivy"
+	ComponentChartmuseum = "chartmuseum"
+	ComponentMetrics     = "metrics"
+)
+
+// ComponentScanner is satisfied by any of the vulnerability scanners.
+const ComponentScanner = "scanner"
+
+var capabilitiesfile = "conf/.capabilities.yaml"
+
+// Capabilities records what the target Harbor supports.
+type Capabilities struct {
+	Target        string          `yaml:"target"`
+	HarborVersion string          `yaml:"harbor_version"`
+	APIVersion    string          `yaml:"api_version"`
+	MaxPageSize   int             `yaml:"max_page_size,omitempty"`
+	Components    map[string]bool `yaml:"components"`
+}
+
+// Has reports whether the component is enabled on the target.
+func (c *Capabilities) Has(component string) bool {
+	if component == ComponentScanner {
+		return c.Components[ComponentClair] || c.Components[ComponentTrivy]
+	}
+	return c.Components[component]
+}
+
+// commandRequires maps command name to the component it relies on.
+var commandRequires = map[string]string{}
+
+// RequireComponent declares that command only works when component is
+// enabled on the target Harbor.
+func RequireComponent(command, component string) {
+	commandRequires[command] = component
+}
+
+type capabilitiesProbe struct {
+}
+
+var capa capabilitiesProbe
+
+func (x *capabilitiesProbe) Execute(args []string) error {
+	caps, err := probeCapabilities()
+	if err != nil {
+		return err
+	}
+
+	if err := capabilitiesSave(caps); err != nil {
+		fmt.Println("error:", err)
+	}
+
+	printCapabilities(caps)
+	return nil
+}
+
+type sysInfoBrief struct {
+	HarborVersion   string `json:"harbor_version"`
+	WithNotary      bool   `json:"with_notary"`
+	WithClair       bool   `json:"with_clair"`
+	WithChartmuseum bool   `json:"with_chartmuseum"`
+}
+
+type scannerBrief struct {
+	Name     string `json:"name"`
+	Disabled bool   `json:"disabled"`
+}
+
+// probeCapabilities inspects the target by systeminfo (v1 first, v2.0 as
+// fallback), the registered scanners and the metrics endpoint.
+func probeCapabilities() (*Capabilities, error) {
+	c := NewClient()
+	caps := &Capabilities{
+		Target:     c.BaseURL(),
+		Components: make(map[string]bool),
+	}
+
+	var info sysInfoBrief
+	v2 := false
+	resp, _, errs := c.Get(c.URL("/api/systeminfo")).EndStruct(&info)
+	for _, e := range errs {
+		if e != nil {
+			return nil, e
+		}
+	}
+	if resp.StatusCode == 404 {
+		v2 = true
+		resp, _, errs = c.Get(c.URL("/api/v2.0/systeminfo")).EndStruct(&info)
+		for _, e := range errs {
+			if e != nil {
+				return nil, e
+			}
+		}
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("GET systeminfo: unexpected status %s", resp.Status)
+	}
+
+	caps.HarborVersion = info.HarborVersion
+	caps.APIVersion = harbor.APIVersion1
+	if v2 {
+		caps.APIVersion = harbor.APIVersion2
+	}
+	caps.Components[ComponentNotary] = info.WithNotary
+	caps.Components[ComponentClair] = info.WithClair
+	caps.Components[ComponentChartmuseum] = info.WithChartmuseum
+
+	// Since v2.0, scanners are pluggable and listed by /scanners.
+	if v2 {
+		var scanners []*scannerBrief
+		resp, _, errs := c.Get(c.URL("/api/v2.0/scanners")).
+			EndStruct(&scanners)
+		if len(errs) == 0 && resp.StatusCode == 200 {
+			for _, s := range scanners {
+				if s.Disabled {
+					continue
+				}
+				name := strings.ToLower(s.Name)
+				if strings.Contains(name, "trivy") {
+					caps.Components[ComponentTrivy] = true
+				}
+				if strings.Contains(name, "clair") {
+					caps.Components[ComponentClair] = true
+				}
+			}
+		}
+	}
+
+	// Harbor caps page_size silently, 100 unless the deployment differs.
+	projects := "/api/projects"
+	if v2 {
+		projects = "/api/v2.0/projects"
+	}
+	if n, err := c.ProbeMaxPageSize(c.URL(projects)); err == nil {
+		caps.MaxPageSize = n
+	}
+
+	// Metrics are served on their own port, 9090 by default.
+	if metricsURL, err := MetricsURL(9090, "/metrics", ""); err == nil {
+		resp, _, errs = c.Get(metricsURL).End()
+		caps.Components[ComponentMetrics] = len(errs) == 0 && resp.StatusCode == 200
+	}
+
+	return caps, nil
+}
+
+// capabilitiesSave caches probing result into .capabilities.yaml
+func capabilitiesSave(caps *Capabilities) error {
+	c, err := yaml.Marshal(caps)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(capabilitiesfile, c, 0644)
+}
+
+// CapabilitiesLoad loads the cached probing result, it returns nil if the
+// target has not been probed yet or the target has changed since.
+func CapabilitiesLoad() *Capabilities {
+	var caps Capabilities
+
+	dataBytes, err := ioutil.ReadFile(capabilitiesfile)
+	if err != nil {
+		return nil
+	}
+	if err := yaml.Unmarshal(dataBytes, &caps); err != nil {
+		return nil
+	}
+
+	config, err := generalConfigLoad()
+	if err != nil || caps.Target != config.Scheme+"://"+config.Dstip {
+		return nil
+	}
+
+	return &caps
+}
+
+// apiVersion returns the API version given by --api-version, or else the
+// one cached by 'capabilities'. The client negotiates it with the target
+// when both are empty.
+func apiVersion() string {
+	if GlobalOpts.APIVersion != "" {
+		return GlobalOpts.APIVersion
+	}
+	if caps := CapabilitiesLoad(); caps != nil {
+		return caps.APIVersion
+	}
+	return ""
+}
+
+// MarkUnsupportedCommands marks commands unavailable on the target in help,
+// based on the cached result of 'capabilities'.
+func MarkUnsupportedCommands() {
+	caps := CapabilitiesLoad()
+	if caps == nil {
+		return
+	}
+
+	for _, cmd := range Parser.Commands() {
+		component, ok := commandRequires[cmd.Name]
+		if !ok || caps.Has(component) {
+			continue
+		}
+		cmd.ShortDescription = Tf("(unavailable: requires %s) ", component) + cmd.ShortDescription
+	}
+}
+
+func printCapabilities(caps *Capabilities) {
+	fmt.Println("+----------------------+------------------------------------------+")
+	fmt.Printf("| % -20s | % -40s |\n", "Target", caps.Target)
+	fmt.Printf("| % -20s | % -40s |\n", "Harbor Version", caps.HarborVersion)
+	fmt.Printf("| % -20s | % -40s |\n", "API Version", caps.APIVersion)
+	maxPage := "unknown (too few projects to tell)"
+	if caps.MaxPageSize > 0 {
+		maxPage = strconv.Itoa(caps.MaxPageSize)
+	}
+	fmt.Printf("| % -20s | % -40s |\n", "Max Page Size", maxPage)
+	fmt.Println("+----------------------+------------------------------------------+")
+
+	var components []string
+	for c := range caps.Components {
+		components = append(components, c)
+	}
+	sort.Strings(components)
+	for _, c := range components {
+		status := "disabled"
+		if caps.Components[c] {
+			status = "enabled"
+		}
+		fmt.Printf("| % -20s | % -40s |\n", c, status)
+	}
+	fmt.Println("+----------------------+------------------------------------------+")
+
+	var commands []string
+	for cmd := range commandRequires {
+		commands = append(commands, cmd)
+	}
+	sort.Strings(commands)
+	for _, cmd := range commands {
+		status := "usable"
+		if !caps.Has(commandRequires[cmd]) {
+			status = "unavailable (requires " + commandRequires[cmd] + ")"
+		}
+		fmt.Printf("| % -20s | % -40s |\n", cmd, status)
+	}
+	fmt.Println("+----------------------+------------------------------------------+")
+	fmt.Println("All other commands are usable.")
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/moooofly/harbor-go-client/model"
+)
+
+// CleanupReport is what cleanup_tags deleted, or would delete with DryRun.
+type CleanupReport struct {
+	Project string         `json:"project"`
+	Before  model.Time     `json:"before"`
+	DryRun  bool           `json:"dry_run"`
+	Deleted []*CleanupItem `json:"deleted"`
+	Skipped []*CleanupItem `json:"skipped,omitempty"`
+	Failed  []*CleanupItem `json:"failed,omitempty"`
+
+	// Tags and Artifacts count the deleted tags and artifacts, Size is the
+	// size of the deleted artifacts.
+	Tags      int   `json:"tags"`
+	Artifacts int   `json:"artifacts"`
+	Size      int64 `json:"size"`
+}
+
+// CleanupItem is an artifact, an image with v1 API, and its tags to delete.
+// With Artifact, the artifact is deleted with them. Reason tells why it is
+// skipped or failed, the digest is empty for a repository failing to be
+// listed.
+type CleanupItem struct {
+	Repository string   `json:"repository"`
+	Digest     string   `json:"digest,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	Artifact   bool     `json:"artifact"`
+	Size       int64    `json:"size"`
+	Reason     string   `json:"reason,omitempty"`
+}
+
+// String returns the artifact and its tags.
+func (i *CleanupItem) String() string {
+	if i.Digest == "" {
+		return i.Repository
+	}
+	return fmt.Sprintf("%s@%s (%s)", i.Repository, i.Digest, strings.Join(i.Tags, ", "))
+}
+
+// Add records the item as deleted.
+func (r *CleanupReport) Add(i *CleanupItem) {
+	r.Deleted = append(r.Deleted, i)
+	r.Tags += len(i.Tags)
+	if i.Artifact {
+		r.Artifacts++
+		r.Size += i.Size
+	}
+}
+
+// PrintCleanupReport prints the deleted artifacts and tags, the skipped
+// and failed ones, and sums them up.
+func PrintCleanupReport(r *CleanupReport) {
+	for _, i := range r.Deleted {
+		if i.Artifact {
+			fmt.Printf("delete %s, %s\n", i, humanSize(i.Size))
+		} else {
+			fmt.Printf("untag %s\n", i)
+		}
+	}
+	for _, i := range r.Skipped {
+		fmt.Printf("skip %s: %s\n", i, i.Reason)
+	}
+	for _, i := range r.Failed {
+		fmt.Printf("failed: %s: %s\n", i, i.Reason)
+	}
+
+	if r.DryRun {
+		fmt.Printf("%d tags to delete, %d artifacts to reclaim (%s)\n", r.Tags, r.Artifacts, humanSize(r.Size))
+		return
+	}
+	fmt.Printf("%d tags deleted, %d artifacts reclaimed (%s)\n", r.Tags, r.Artifacts, humanSize(r.Size))
+}
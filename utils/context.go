@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// cmdContext is the context of the requests of the running command, see
+// commandContext.
+var cmdContext = context.Background()
+
+// commandContext sets cmdContext for the command about to run: it is
+// canceled by SIGINT or SIGTERM, aborting the requests in flight and the
+// loops over pages, and times out after --deadline if given. A second
+// signal kills the process as usual. cancel releases it.
+func commandContext() (cancel func()) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	cancelDeadline := func() {}
+	if GlobalOpts.Deadline > 0 {
+		ctx, cancelDeadline = context.WithTimeout(ctx, GlobalOpts.Deadline)
+	}
+
+	cmdContext = ctx
+	return func() {
+		cancelDeadline()
+		stop()
+	}
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// 2024-01-01 is a Monday.
+	at := func(s string) time.Time {
+		v, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		expr string
+		from string
+		want string // empty for none
+	}{
+		{"0 6 * * *", "2024-01-01 05:59", "2024-01-01 06:00"},
+		{"0 6 * * *", "2024-01-01 06:00", "2024-01-02 06:00"},
+		{"0 6 * * mon-fri", "2024-01-06 10:00", "2024-01-08 06:00"},
+		{"*/15 * * * *", "2024-01-01 00:07", "2024-01-01 00:15"},
+		{"10-20/5 * * * *", "2024-01-01 00:16", "2024-01-01 00:20"},
+		{"10-20/5 * * * *", "2024-01-01 00:21", "2024-01-01 01:10"},
+		{"5/20 * * * *", "2024-01-01 00:30", "2024-01-01 00:45"},
+		{"0 0 1,15 * *", "2024-01-02 00:00", "2024-01-15 00:00"},
+		{"0 12 * jan,mar *", "2024-02-01 00:00", "2024-03-01 12:00"},
+		{"30 8 * * 1,3-4", "2024-01-02 00:00", "2024-01-03 08:30"},
+		// Both day fields restricted: either one matches.
+		{"0 0 13 * fri", "2024-01-01 00:00", "2024-01-05 00:00"},
+		{"0 0 13 * fri", "2024-01-06 00:00", "2024-01-12 00:00"},
+		{"0 0 13 * fri", "2024-01-12 00:00", "2024-01-13 00:00"},
+		// One day field restricted: it alone matters.
+		{"0 0 13 * *", "2024-01-01 00:00", "2024-01-13 00:00"},
+		{"0 0 * * 7", "2024-01-01 00:00", "2024-01-07 00:00"},
+		{"0 0 * * 0", "2024-01-01 00:00", "2024-01-07 00:00"},
+		{"@weekly", "2024-01-01 00:00", "2024-01-07 00:00"},
+		{"@monthly", "2024-01-01 00:00", "2024-02-01 00:00"},
+		{"0 0 29 2 *", "2024-03-01 00:00", "2028-02-29 00:00"},
+		{"0 0 30 2 *", "2024-01-01 00:00", ""},
+	}
+	for _, tt := range tests {
+		s, err := parseCron(tt.expr)
+		if err != nil {
+			t.Errorf("parseCron(%q): %v", tt.expr, err)
+			continue
+		}
+		got := s.Next(at(tt.from))
+		var want time.Time
+		if tt.want != "" {
+			want = at(tt.want)
+		}
+		if !got.Equal(want) {
+			t.Errorf("parseCron(%q).Next(%s) = %s, want %s", tt.expr, tt.from, got, want)
+		}
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"@never",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * 13 *",
+		"* * * * 8",
+		"* * * foo *",
+		"a * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"1-2-3 * * * *",
+		"1,,2 * * * *",
+	}
+	for _, expr := range tests {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("parseCron(%q): no error", expr)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+// replacementCommands are the commands which replace, with the v2.0 API,
+// the commands using a removed v1 endpoint, by method and v1 endpoint of
+// the harbor routing table.
+var replacementCommands = map[string]string{
+	"GET /repositories/{repo}/tags":                      "artifacts_list",
+	"GET /repositories/{repo}/tags/{tag}":                "artifact_get",
+	"GET /repositories/{repo}/tags/{tag}/manifest":       "artifact_get",
+	"GET /repositories/{repo}/tags/{tag}/labels":         "artifact_get",
+	"POST /repositories/{repo}/tags/{tag}/labels":        "artifact_label_add",
+	"DELETE /repositories/{repo}/tags/{tag}/labels/{id}": "artifact_label_del",
+	"DELETE /repositories/{repo}/tags/{tag}":             "artifact_del",
+	" /repositories/{repo}/labels":                       "artifact_label_add",
+	"GET /repositories/{repo}/signatures":                "artifact_accessories_list",
+}
+
+var (
+	deprecationMu     sync.Mutex
+	deprecationWarned = map[*harbor.Route]bool{}
+)
+
+// warnDeprecated is the deprecation handler of the clients, it warns once
+// per endpoint that a command used a v1 endpoint removed from the v2.0 API
+// of the target, and tells what replaces it.
+func warnDeprecated(method string, r *harbor.Route) {
+	deprecationMu.Lock()
+	defer deprecationMu.Unlock()
+	if deprecationWarned[r] {
+		return
+	}
+	deprecationWarned[r] = true
+
+	endpoint := method + " /api" + r.V1
+	switch cmd := replacementCommands[r.Method+" "+r.V1]; {
+	case cmd != "":
+		fmt.Fprintln(os.Stderr, Tf("warning: %s is removed from the v2.0 API of the target, use '%s' instead (%s).", endpoint, cmd, "/api/v2.0"+r.V2))
+	case r.V2 != "":
+		fmt.Fprintln(os.Stderr, Tf("warning: %s is removed from the v2.0 API of the target, replaced by %s.", endpoint, "/api/v2.0"+r.V2))
+	default:
+		fmt.Fprintln(os.Stderr, Tf("warning: %s is removed from the v2.0 API of the target, without replacement.", endpoint))
+	}
+}
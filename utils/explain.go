@@ -0,0 +1,399 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+func init() {
+	Parser.AddCommand("explain",
+		"Explain what a command does against Harbor.",
+		"Print, for the command given as argument, the HTTP endpoints it calls, the role it requires, the Harbor components and version it relies on, whether it is read-only, and example invocations built from its flags.",
+		&explain)
+}
+
+type explainRun struct {
+}
+
+var explain explainRun
+
+// commandEndpoints maps command name to the endpoints it calls, as "METHOD
+// path". "{api}" stands for the API of the negotiated version, "/api" or
+// "/api/v2.0"; other paths are used as is whatever the version. Commands
+// which only work locally have none.
+var commandEndpoints = map[string][]string{
+	"artifact_accessories_list":   {"GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/accessories"},
+	"artifact_copy":               {"POST {api}/projects/{project_name}/repositories/{repository_name}/artifacts?from={from}"},
+	"artifact_del":                {"DELETE /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}"},
+	"artifact_get":                {"GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}"},
+	"artifact_keep":               {"GET /api/v2.0/projects/{project_name}/repositories", "GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts", "GET /api/v2.0/labels", "POST /api/v2.0/labels", "POST /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/labels", "DELETE /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/labels/{label_id}"},
+	"artifact_label_add":          {"POST /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/labels"},
+	"artifact_label_del":          {"DELETE /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/labels/{label_id}"},
+	"artifact_sbom_get":           {"GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/accessories", "GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/additions/sbom"},
+	"artifact_tag_create":         {"POST /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/tags"},
+	"artifact_tag_del":            {"DELETE /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/tags/{tag_name}"},
+	"artifact_tags_list":          {"GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/tags"},
+	"artifacts_list":              {"GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts"},
+	"capabilities":                {"GET {api}/systeminfo", "GET /api/v2.0/scanners", "GET {api}/projects"},
+	"chartrepo_chart_del":         {"DELETE /api/chartrepo/{repo}/charts/{name}/{version}", "DELETE /api/chartrepo/{repo}/charts/{name}"},
+	"chartrepo_chart_get":         {"GET /api/chartrepo/{repo}/charts/{name}/{version}"},
+	"chartrepo_chart_label_add":   {"POST /api/chartrepo/{repo}/charts/{name}/{version}/labels"},
+	"chartrepo_chart_label_del":   {"DELETE /api/chartrepo/{repo}/charts/{name}/{version}/labels/{id}"},
+	"chartrepo_chart_labels_get":  {"GET /api/chartrepo/{repo}/charts/{name}/{version}/labels"},
+	"chartrepo_chart_upload":      {"POST /api/chartrepo/{repo}/charts"},
+	"chartrepo_chart_versions":    {"GET /api/chartrepo/{repo}/charts/{name}"},
+	"chartrepo_charts_list":       {"GET /api/chartrepo/{repo}/charts"},
+	"cleanup_tags":                {"GET {api}/projects", "GET /api/v2.0/projects/{project_name}/repositories", "GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts", "DELETE /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{digest}", "DELETE /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{digest}/tags/{tag}", "GET /api/repositories", "GET /api/repositories/{repo_name}/tags", "DELETE /api/repositories/{repo_name}/tags/{tag}"},
+	"configurations_create":       {"PUT {api}/configurations"},
+	"configurations_get":          {"GET {api}/configurations"},
+	"configurations_pull_get":     {"GET /api/v2.0/configurations"},
+	"configurations_pull_set":     {"PUT /api/v2.0/configurations"},
+	"configurations_reset":        {"POST {api}/configurations/reset"},
+	"configurations_update":       {"GET {api}/configurations", "PUT {api}/configurations"},
+	"cve_allowlist_export":        {"GET {api}/system/CVEAllowlist"},
+	"cve_allowlist_get":           {"GET {api}/system/CVEAllowlist"},
+	"cve_allowlist_import":        {"GET {api}/system/CVEAllowlist", "PUT {api}/system/CVEAllowlist"},
+	"cve_allowlist_update":        {"GET {api}/system/CVEAllowlist", "PUT {api}/system/CVEAllowlist"},
+	"email_ping":                  {"POST {api}/email/ping"},
+	"health":                      {"GET {api}/health"},
+	"idmap_sync":                  {"GET {api}/projects", "GET {api}/labels", "GET /api/v2.0/registries", "GET /api/targets"},
+	"immutable_rule_create":       {"POST {api}/projects/{project_id}/immutabletagrules"},
+	"immutable_rule_del":          {"DELETE {api}/projects/{project_id}/immutabletagrules/{id}"},
+	"immutable_rule_update":       {"GET {api}/projects/{project_id}/immutabletagrules", "PUT {api}/projects/{project_id}/immutabletagrules/{id}"},
+	"immutable_rules_list":        {"GET {api}/projects/{project_id}/immutabletagrules"},
+	"jobs_repl_job_del_by_jid":    {"DELETE {api}/jobs/replication/{id}"},
+	"jobs_repl_list_by_filters":   {"GET {api}/jobs/replication"},
+	"jobs_repl_log_get_by_jid":    {"GET {api}/jobs/replication/{id}/log"},
+	"jobs_repl_stop_by_policy":    {"PUT {api}/jobs/replication"},
+	"jobs_scan_log_get_by_jid":    {"GET {api}/jobs/scan/{id}/log"},
+	"gc_get":                      {"GET {api}/system/gc/{id}"},
+	"gc_history_list":             {"GET {api}/system/gc"},
+	"gc_log":                      {"GET {api}/system/gc/{id}/log"},
+	"gc_run":                      {"POST {api}/system/gc/schedule"},
+	"gc_schedule_get":             {"GET {api}/system/gc/schedule"},
+	"gc_schedule_set":             {"PUT {api}/system/gc/schedule"},
+	"label_autoapply":             {"GET {api}/projects/{project_id}", "GET /api/v2.0/projects/{project_name}/repositories", "GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts", "POST /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/labels", "GET /api/repositories", "POST /api/repositories/{repo_name}/labels"},
+	"label_create":                {"POST {api}/labels"},
+	"label_del_by_id":             {"DELETE {api}/labels/{id}"},
+	"label_get_by_id":             {"GET {api}/labels/{id}"},
+	"label_merge":                 {"GET {api}/labels", "GET {api}/projects", "PUT {api}/labels/{id}", "DELETE {api}/labels/{id}", "POST /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/labels", "DELETE /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/labels/{label_id}", "POST /api/chartrepo/{repo}/charts/{name}/{version}/labels", "DELETE /api/chartrepo/{repo}/charts/{name}/{version}/labels/{id}"},
+	"label_restore":               {"GET {api}/labels/{id}", "PUT {api}/labels/{id}"},
+	"label_update":                {"PUT {api}/labels/{id}"},
+	"label_usage":                 {"GET {api}/labels/{id}", "GET {api}/projects", "GET /api/v2.0/projects/{project_name}/repositories", "GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts", "GET /api/repositories", "GET /api/repositories/{repo_name}/tags", "GET /api/chartrepo/{repo}/charts/{name}"},
+	"labels_list":                 {"GET {api}/labels"},
+	"ldap_groups_search":          {"GET {api}/ldap/groups/search"},
+	"ldap_ping":                   {"POST {api}/ldap/ping"},
+	"ldap_users_import":           {"POST {api}/ldap/users/import"},
+	"ldap_users_search":           {"GET {api}/ldap/users/search"},
+	"login":                       {"POST /login"},
+	"logout":                      {"GET /log_out"},
+	"logs":                        {"GET {api}/logs"},
+	"manifest_inspect":            {"GET /v2/{project_name}/{repository_name}/manifests/{reference}", "GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}", "GET /api/repositories/{repo_name}/tags/{tag}/manifest", "GET /api/repositories/{repo_name}/tags/{tag}"},
+	"metrics_get":                 {"GET /metrics"},
+	"permissions":                 {"GET /api/v2.0/users/current/permissions"},
+	"policies_list":               {"GET {api}/policies/replication"},
+	"policy_create":               {"POST {api}/policies/replication"},
+	"policy_del":                  {"DELETE {api}/policies/replication/{id}"},
+	"policy_disable":              {"GET {api}/policies/replication/{id}", "PUT {api}/policies/replication/{id}"},
+	"policy_enable":               {"GET {api}/policies/replication/{id}", "PUT {api}/policies/replication/{id}"},
+	"policy_get_by_id":            {"GET {api}/policies/replication/{id}"},
+	"policy_update_by_id":         {"PUT {api}/policies/replication/{id}"},
+	"preflight_scan":              {"POST /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/scan", "GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}", "DELETE /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}", "POST /api/repositories/{repo_name}/tags/{tag}/scan", "GET /api/repositories/{repo_name}/tags/{tag}", "DELETE /api/repositories/{repo_name}/tags/{tag}"},
+	"prj_create":                  {"POST {api}/projects"},
+	"prj_cve_allowlist_get":       {"GET {api}/projects/{project_id}"},
+	"prj_cve_allowlist_update":    {"GET {api}/projects/{project_id}", "PUT {api}/projects/{project_id}"},
+	"prj_del":                     {"DELETE {api}/projects/{project_id}"},
+	"prj_get":                     {"GET {api}/projects/{project_id}"},
+	"prj_logs_get":                {"GET {api}/projects/{project_id}/logs"},
+	"prj_member_create":           {"POST {api}/projects/{project_id}/members"},
+	"prj_member_del":              {"DELETE {api}/projects/{project_id}/members/{mid}"},
+	"prj_member_get":              {"GET {api}/projects/{project_id}/members/{mid}"},
+	"prj_member_update":           {"PUT {api}/projects/{project_id}/members/{mid}"},
+	"prj_members_get":             {"GET {api}/projects/{project_id}/members"},
+	"prj_metadata_add":            {"POST {api}/projects/{project_id}/metadatas"},
+	"prj_metadata_del_by_name":    {"DELETE {api}/projects/{project_id}/metadatas/{meta_name}"},
+	"prj_metadata_get":            {"GET {api}/projects/{project_id}/metadatas"},
+	"prj_metadata_get_by_name":    {"GET {api}/projects/{project_id}/metadatas/{meta_name}"},
+	"prj_metadata_update_by_name": {"PUT {api}/projects/{project_id}/metadatas/{meta_name}"},
+	"prj_proxy_speed_get":         {"GET /api/v2.0/projects/{project_name_or_id}/metadatas/proxy_speed_kb"},
+	"prj_proxy_speed_set":         {"PUT /api/v2.0/projects/{project_name_or_id}/metadatas/proxy_speed_kb"},
+	"prj_summary_get":             {"GET {api}/projects/{project_name_or_id}/summary"},
+	"prj_unsigned_tags":           {"GET {api}/projects", "GET /api/v2.0/projects/{project_name}/repositories", "GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts", "GET /api/repositories", "GET /api/repositories/{repo_name}/tags"},
+	"prj_update":                  {"PUT {api}/projects/{project_id}"},
+	"prjs_list":                   {"GET {api}/projects"},
+	"project_clone_settings":      {"GET /api/v2.0/projects/{project_name}", "PUT /api/v2.0/projects/{project_id}", "POST /api/v2.0/projects/{project_name}/webhook/policies", "GET /api/v2.0/retentions/{id}", "POST /api/v2.0/retentions", "PUT /api/v2.0/retentions/{id}", "POST /api/v2.0/projects/{project_name}/immutabletagrules", "POST /api/v2.0/labels"},
+	"project_inventory":           {"GET {api}/projects", "GET /api/v2.0/projects/{project_name}/repositories", "GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts", "GET /api/repositories", "GET /api/repositories/{repo_name}/tags"},
+	"project_retire":              {"GET {api}/projects", "PUT {api}/projects/{project_id}/metadatas/public", "GET {api}/labels", "POST {api}/labels", "POST /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/labels", "POST /api/repositories/{repo_name}/labels", "GET {api}/users/current", "DELETE {api}/projects/{project_id}/members/{mid}", "DELETE {api}/projects/{project_id}/robots/{robot_id}", "DELETE /api/v2.0/projects/{project_name}/repositories/{repository_name}", "DELETE /api/repositories/{repo_name}", "DELETE {api}/projects/{project_id}"},
+	"project_scanner_get":         {"GET {api}/projects/{project_id}/scanner"},
+	"project_scanner_set":         {"PUT {api}/projects/{project_id}/scanner"},
+	"project_usage":               {"GET /api/v2.0/projects/{project_name}/summary", "GET /api/v2.0/projects/{project_name}/repositories", "GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts"},
+	"project_verify":              {"GET {api}/projects", "GET /api/v2.0/projects/{project_name}/repositories", "GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts", "GET /api/repositories", "GET /api/repositories/{repo_name}/tags"},
+	"replication_adapters":        {"GET {api}/replication/adapters", "GET /api/v2.0/replication/adapterinfos"},
+	"replication_execution_get":   {"GET {api}/replication/executions/{id}"},
+	"replication_executions_list": {"GET {api}/replication/executions"},
+	"replication_start":           {"POST {api}/replication/executions"},
+	"replication_tasks_list":      {"GET {api}/replication/executions/{id}/tasks"},
+	"replication_topology":        {"GET {api}/targets", "GET {api}/policies/replication"},
+	"replication_trigger_by_id":   {"POST {api}/replications"},
+	"repo_del":                    {"DELETE {api}/repositories/{repo_name}", "GET {api}/repositories"},
+	"repo_describe":               {"PUT {api}/repositories/{project}/{repo_name}"},
+	"repo_desp_update":            {"PUT {api}/repositories/{repo_name}"},
+	"repo_image_label_add":        {"POST {api}/repositories/{repo_name}/tags/{tag}/labels"},
+	"repo_image_label_del":        {"DELETE {api}/repositories/{repo_name}/tags/{tag}/labels/{label_id}"},
+	"repo_image_labels_get":       {"GET {api}/repositories/{repo_name}/tags/{tag}/labels"},
+	"repo_image_manifests_get":    {"GET {api}/repositories/{repo_name}/tags/{tag}/manifest"},
+	"repo_image_scan":             {"POST {api}/repositories/{repo_name}/tags/{tag}/scan"},
+	"repo_image_vul_details_get":  {"GET {api}/repositories/{repo_name}/tags/{tag}/vulnerability/details"},
+	"repo_label_add":              {"POST {api}/repositories/{repo_name}/labels"},
+	"repo_label_del":              {"DELETE {api}/repositories/{repo_name}/labels/{label_id}"},
+	"repo_labels_get":             {"GET {api}/repositories/{repo_name}/labels"},
+	"repo_signature_get":          {"GET {api}/repositories/{repo_name}/signatures"},
+	"repos_list":                  {"GET {api}/repositories"},
+	"repos_top":                   {"GET {api}/repositories/top"},
+	"retention_create":            {"POST {api}/retentions"},
+	"retention_executions_list":   {"GET {api}/retentions/{id}/executions"},
+	"retention_get":               {"GET {api}/retentions/{id}", "GET {api}/projects/{project_id}"},
+	"retention_metadatas":         {"GET {api}/retentions/metadatas"},
+	"retention_run":               {"POST {api}/retentions/{id}/executions"},
+	"retention_simulate":          {"POST {api}/retentions/{id}/executions", "GET {api}/retentions/{id}/executions", "GET {api}/retentions/{id}/executions/{execution_id}/tasks", "GET {api}/retentions/{id}/executions/{execution_id}/tasks/{task_id}"},
+	"retention_task_log":          {"GET {api}/retentions/{id}/executions/{execution_id}/tasks/{task_id}"},
+	"retention_tasks_list":        {"GET {api}/retentions/{id}/executions/{execution_id}/tasks"},
+	"retention_update":            {"GET {api}/retentions/{id}", "PUT {api}/retentions/{id}"},
+	"robot_create":                {"POST {api}/projects/{project_id}/robots"},
+	"robot_del":                   {"DELETE {api}/projects/{project_id}/robots/{robot_id}"},
+	"robot_disable":               {"GET {api}/projects/{project_id}/robots/{robot_id}", "PUT {api}/projects/{project_id}/robots/{robot_id}"},
+	"robot_enable":                {"GET {api}/projects/{project_id}/robots/{robot_id}", "PUT {api}/projects/{project_id}/robots/{robot_id}"},
+	"robot_get":                   {"GET {api}/projects/{project_id}/robots/{robot_id}"},
+	"robot_refresh_secret":        {"PATCH /api/v2.0/robots/{robot_id}"},
+	"robots_list":                 {"GET {api}/projects/{project_id}/robots"},
+	"retention_propagate":         {"GET /api/v2.0/projects", "GET /api/v2.0/retentions/{id}", "POST /api/v2.0/retentions", "PUT /api/v2.0/retentions/{id}"},
+	"rp_repos":                    {"GET /api/statistics", "GET /api/repositories/top", "DELETE /api/repositories/{repo_name}"},
+	"rp_tags":                     {"GET /api/search", "GET /api/repositories/{repo_name}/tags", "DELETE /api/repositories/{repo_name}/tags/{tag}"},
+	"scan_all_metrics":            {"GET {api}/scans/all/metrics"},
+	"scan_all_run":                {"POST {api}/system/scanAll/schedule"},
+	"scan_all_schedule_get":       {"GET {api}/system/scanAll/schedule"},
+	"scan_all_schedule_set":       {"PUT {api}/system/scanAll/schedule"},
+	"scan_report":                 {"GET {api}/projects/{project}/repositories/{repo}/artifacts/{reference}/additions/vulnerabilities"},
+	"scan_start":                  {"POST {api}/projects/{project}/repositories/{repo}/artifacts/{reference}/scan"},
+	"scanner_create":              {"POST {api}/scanners"},
+	"scanner_get":                 {"GET {api}/scanners/{uuid}"},
+	"scanner_ping":                {"GET {api}/scanners/{uuid}/metadata", "POST {api}/scanners/ping"},
+	"scanner_set_default":         {"PATCH {api}/scanners/{uuid}"},
+	"scanners_list":               {"GET {api}/scanners"},
+	"search":                      {"GET {api}/search"},
+	"statistics":                  {"GET {api}/statistics"},
+	"syncregistry":                {"POST {api}/internal/syncregistry"},
+	"sysinfo_general":             {"GET {api}/systeminfo"},
+	"sysinfo_rootcert":            {"GET {api}/systeminfo/getcert"},
+	"sysinfo_volumes":             {"GET {api}/systeminfo/volumes"},
+	"system_robot_create":         {"POST /api/v2.0/robots"},
+	"system_robot_del":            {"DELETE /api/v2.0/robots/{robot_id}"},
+	"system_robot_get":            {"GET /api/v2.0/robots/{robot_id}"},
+	"system_robot_update":         {"GET /api/v2.0/robots/{robot_id}", "PUT /api/v2.0/robots/{robot_id}"},
+	"system_robots_list":          {"GET /api/v2.0/robots"},
+	"tag_del":                     {"DELETE {api}/repositories/{repo_name}/tags/{tag}"},
+	"tag_get":                     {"GET {api}/repositories/{repo_name}/tags/{tag}"},
+	"tag_history":                 {"GET /v2/{project_name}/{repository_name}/manifests/{reference}", "GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/additions/build_history", "GET /api/repositories/{repo_name}/tags/{tag}/manifest"},
+	"tag_retag":                   {"POST {api}/repositories/{repo_name}/tags"},
+	"tag_signature_get":           {"GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts/{reference}", "GET /api/repositories/{repo_name}/tags/{tag}"},
+	"tags_list":                   {"GET {api}/repositories/{repo_name}/tags"},
+	"tags_semver":                 {"GET /api/v2.0/projects/{project_name}/repositories/{repository_name}/artifacts", "GET /api/repositories/{repo_name}/tags"},
+	"targets_create":              {"POST {api}/targets"},
+	"targets_delete_by_tid":       {"DELETE {api}/targets/{id}"},
+	"targets_get_by_tid":          {"GET {api}/targets/{id}"},
+	"targets_list":                {"GET {api}/targets"},
+	"targets_ping":                {"POST {api}/targets/ping"},
+	"targets_ping_by_tid":         {"POST {api}/targets/{id}/ping"},
+	"targets_policies_by_tid":     {"GET {api}/targets/{id}/policies"},
+	"targets_update_by_tid":       {"PUT {api}/targets/{id}"},
+	"user_create":                 {"POST {api}/users"},
+	"user_delete":                 {"DELETE {api}/users/{user_id}"},
+	"user_get":                    {"GET {api}/users/{user_id}"},
+	"user_list":                   {"GET {api}/users"},
+	"user_set_sysadmin":           {"PUT {api}/users/{user_id}/sysadmin"},
+	"user_update":                 {"PUT {api}/users/{user_id}"},
+	"user_update_password":        {"PUT {api}/users/{user_id}/password"},
+	"user_update_role":            {"PUT {api}/users/{user_id}/sysadmin"},
+	"usergroup_create":            {"POST {api}/usergroups"},
+	"usergroup_del":               {"DELETE {api}/usergroups/{id}"},
+	"usergroup_get":               {"GET {api}/usergroups/{id}"},
+	"usergroup_update":            {"PUT {api}/usergroups/{id}"},
+	"usergroups_list":             {"GET {api}/usergroups"},
+	"usergroups_search":           {"GET {api}/usergroups/search"},
+	"users_search":                {"GET {api}/users"},
+	"wait_healthy":                {"GET {api}/health"},
+	"webhook_jobs_list":           {"GET {api}/projects/{project_id}/webhook/jobs"},
+	"webhook_lasttrigger":         {"GET {api}/projects/{project_id}/webhook/lasttrigger"},
+	"webhook_policies_list":       {"GET {api}/projects/{project_id}/webhook/policies"},
+	"webhook_policy_create":       {"POST {api}/projects/{project_id}/webhook/policies"},
+	"webhook_policy_del":          {"DELETE {api}/projects/{project_id}/webhook/policies/{policy_id}"},
+	"webhook_policy_get":          {"GET {api}/projects/{project_id}/webhook/policies/{policy_id}"},
+	"webhook_policy_test":         {"GET {api}/projects/{project_id}/webhook/policies/{policy_id}", "POST {api}/projects/{project_id}/webhook/policies/test"},
+	"webhook_policy_update":       {"GET {api}/projects/{project_id}/webhook/policies/{policy_id}", "PUT {api}/projects/{project_id}/webhook/policies/{policy_id}"},
+	"webhook_replay":              {"GET /api/v2.0/projects/{project_name}/webhook/jobs", "GET /api/v2.0/projects/{project_name}/webhook/policies/{policy_id}"},
+	"whoami":                      {"GET {api}/users/current"},
+}
+
+// minHarbor matches the minimum Harbor version in the long description of
+// a command, e.g. "(Harbor v1.8+)".
+var minHarbor = regexp.MustCompile(`\(Harbor (v[0-9.]+\+?)`)
+
+// commandExplanation is what explain tells about a command.
+type commandExplanation struct {
+	Command     string   `json:"command"`
+	Aliases     []string `json:"aliases,omitempty"`
+	Description string   `json:"description"`
+	Endpoints   []string `json:"endpoints"`
+	Role        string   `json:"role"`
+	Component   string   `json:"component,omitempty"`
+	MinHarbor   string   `json:"min_harbor"`
+	ReadOnly    bool     `json:"read_only"`
+	Examples    []string `json:"examples"`
+}
+
+func (x *explainRun) Execute(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: explain <command>")
+	}
+	cmd := Parser.Find(args[0])
+	if cmd == nil {
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+
+	e := explainCommand(cmd)
+	return PrintValue(e, nil, func() {
+		fmt.Printf("%s: %s\n", e.Command, e.Description)
+		if len(e.Aliases) > 0 {
+			fmt.Printf("\nAliases: %s\n", strings.Join(e.Aliases, ", "))
+		}
+		fmt.Println("\nEndpoints:")
+		if len(e.Endpoints) == 0 {
+			fmt.Println("  none, the command works locally")
+		}
+		for _, ep := range e.Endpoints {
+			fmt.Println(" ", ep)
+		}
+		fmt.Println("\nRole:", e.Role)
+		if e.Component != "" {
+			fmt.Println("Component:", e.Component)
+		}
+		fmt.Println("Harbor:", e.MinHarbor)
+		fmt.Println("Read-only:", e.ReadOnly)
+		fmt.Println("\nExamples:")
+		for _, ex := range e.Examples {
+			fmt.Println(" ", ex)
+		}
+	})
+}
+
+// explainCommand gathers what the registries of the commands tell about
+// cmd.
+func explainCommand(cmd *flags.Command) *commandExplanation {
+	e := &commandExplanation{
+		Command:     cmd.Name,
+		Aliases:     cmd.Aliases,
+		Description: cmd.ShortDescription,
+		Endpoints:   []string{},
+		Component:   commandRequires[cmd.Name],
+		ReadOnly:    readOnlyCommands[cmd.Name],
+		MinHarbor:   "any",
+	}
+	for _, ep := range commandEndpoints[cmd.Name] {
+		e.Endpoints = append(e.Endpoints, explainEndpoint(ep))
+	}
+
+	switch {
+	case adminCommands[cmd.Name]:
+		e.Role = "system administrator"
+	case len(e.Endpoints) == 0:
+		e.Role = "none"
+	case e.ReadOnly:
+		e.Role = "any user allowed to read the resources, e.g. a guest of their project"
+	default:
+		e.Role = "a user allowed to change the resources, e.g. a maintainer or administrator of their project"
+	}
+
+	switch m := minHarbor.FindStringSubmatch(cmd.LongDescription); {
+	case m != nil:
+		e.MinHarbor = m[1]
+	case strings.Contains(cmd.LongDescription, "uses v2.0 API"):
+		e.MinHarbor = "v2.0+"
+	}
+
+	e.Examples = explainExamples(cmd)
+	return e
+}
+
+// explainEndpoint expands the "{api}" of an endpoint of commandEndpoints,
+// noting the v1 endpoints removed from the v2.0 API.
+func explainEndpoint(ep string) string {
+	method, path := ep, ""
+	if i := strings.IndexByte(ep, ' '); i >= 0 {
+		method, path = ep[:i], ep[i+1:]
+	}
+	if !strings.HasPrefix(path, "{api}") {
+		return ep
+	}
+
+	rest := strings.TrimPrefix(path, "{api}")
+	ep = method + " /api[/v2.0]" + rest
+	if r := harbor.V1Route(method, "http://harbor/api"+rest); r != nil {
+		if r.V2 == "" {
+			return method + " /api" + rest + " (v1 API only)"
+		}
+		return method + " /api" + rest + " (v1 API), " + method + " /api/v2.0" + r.V2 + " (v2.0 API)"
+	}
+	return ep
+}
+
+// explainExamples returns invocations of cmd: with its required flags, and
+// more.
+func explainExamples(cmd *flags.Command) []string {
+	base := "harbor-go-client " + cmd.Name
+	var required []string
+	var optional []*flags.Option
+	for _, opt := range cmd.Options() {
+		if opt.Hidden {
+			continue
+		}
+		if !opt.Required {
+			optional = append(optional, opt)
+			continue
+		}
+		required = append(required, explainFlag(opt))
+	}
+	sort.Slice(optional, func(i, j int) bool { return optional[i].LongName < optional[j].LongName })
+
+	first := strings.Join(append([]string{base}, required...), " ")
+	examples := []string{first}
+	if readOnlyCommands[cmd.Name] && cmd.FindOptionByLongName("all") != nil {
+		examples = append(examples, "harbor-go-client --output json "+strings.TrimPrefix(first, "harbor-go-client ")+" --all")
+	} else if len(optional) > 0 {
+		examples = append(examples, first+" "+explainFlag(optional[0]))
+	}
+	return append(examples, base+" --help")
+}
+
+// explainFlag returns opt with an example value.
+func explainFlag(opt *flags.Option) string {
+	name := "--" + opt.LongName
+	if opt.LongName == "" {
+		name = "-" + string(opt.ShortName)
+	}
+	switch {
+	case opt.Field().Type.Kind() == reflect.Bool:
+		return name
+	case len(opt.Choices) > 0:
+		return name + " " + opt.Choices[0]
+	case opt.LongName == "":
+		return name + " <value>"
+	}
+	return name + " <" + opt.LongName + ">"
+}
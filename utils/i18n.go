@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Supported languages of CLI messages.
+const (
+	LangEN   = "en"
+	LangZhCN = "zh-cn"
+)
+
+// catalogs maps a language to the translations of English messages.
+// Messages without translation are shown in English.
+var catalogs = map[string]map[string]string{
+	LangZhCN: zhCN,
+}
+
+// lang is the language of CLI messages, set up by Localize.
+var lang = LangEN
+
+// T translates an English message into the selected language.
+func T(msg string) string {
+	if tr, ok := catalogs[lang][msg]; ok {
+		return tr
+	}
+	return msg
+}
+
+// Tf translates an English format string and formats it.
+func Tf(format string, a ...interface{}) string {
+	return fmt.Sprintf(T(format), a...)
+}
+
+// langFromArgs finds --lang in the command line before it is parsed, the
+// command descriptions have to be translated before help is printed.
+func langFromArgs(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if strings.HasPrefix(arg, "--lang=") {
+			return strings.TrimPrefix(arg, "--lang=")
+		}
+		if arg == "--lang" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// Localize selects the language of CLI messages, by --lang, HARBOR_LANG or
+// 'lang' in conf/config.yaml, in that order, and translates the command
+// descriptions.
+func Localize() {
+	l := langFromArgs(os.Args[1:])
+	if l == "" {
+		l = os.Getenv("HARBOR_LANG")
+	}
+	if l == "" {
+		if config, err := generalConfigLoad(); err == nil {
+			l = config.Lang
+		}
+	}
+
+	l = strings.ToLower(l)
+	if _, ok := catalogs[l]; !ok {
+		return
+	}
+	lang = l
+
+	for _, cmd := range Parser.Commands() {
+		cmd.ShortDescription = T(cmd.ShortDescription)
+		cmd.LongDescription = T(cmd.LongDescription)
+	}
+}
@@ -0,0 +1,248 @@
+package utils
+
+// zhCN holds the Simplified Chinese translations, keyed by English message.
+var zhCN = map[string]string{
+	"Get system configurations.": "获取系统配置。",
+	"Modify system configurations. (set configuration in conf/config.yaml)":              "修改系统配置。（配置项位于 conf/config.yaml）",
+	"Reset system configurations.":                                                       "重置系统配置。",
+	"Trigger the replication according to the specified policy.":                         "按指定策略触发复制。",
+	"Render replication targets and policies as a graph.":                                "将复制目标和策略渲染为图。",
+	"Get general system info.":                                                           "获取系统基本信息。",
+	"Get system volume info (total/free size).":                                          "获取系统存储卷信息（总量/剩余）。",
+	"Get default root certificate under OVA deployment.":                                 "获取 OVA 部署下的默认根证书。",
+	"Get projects number and repositories number relevant to the user.":                  "获取与当前用户相关的项目数和仓库数。",
+	"List labels according to the query strings.":                                        "按查询条件列出标签。",
+	"Post creates a label":                                                               "创建标签",
+	"Delete the label specified by ID.":                                                  "删除指定 ID 的标签。",
+	"Get the label specified by ID.":                                                     "获取指定 ID 的标签。",
+	"Update the label properties.":                                                       "更新标签属性。",
+	"Get signature information of a repository from notary instance.":                    "从 notary 获取仓库的签名信息。",
+	"Get vulnerability details of the image. (not support yet)":                          "获取镜像的漏洞详情。（暂不支持）",
+	"Scan the image. (not support yet)":                                                  "扫描镜像。（暂不支持）",
+	"Get manifests of a relevant repository.":                                            "获取仓库的 manifest。",
+	"Delete label from the image under specific repository.":                             "从指定仓库的镜像上删除标签。",
+	"Add a label to the image under specific repository.":                                "为指定仓库的镜像添加标签。",
+	"Get labels of an image under specific repository.":                                  "获取指定仓库中镜像的标签。",
+	"Delete a label from the repository.":                                                "从仓库上删除标签。",
+	"Add a label to the repository.":                                                     "为仓库添加标签。",
+	"Get labels of a repository.":                                                        "获取仓库的标签。",
+	"Update description of the repository.":                                              "更新仓库描述。",
+	"Set the description of a repository in a project.":                                  "设置项目中仓库的描述。",
+	"Delete a repository by repo_name.":                                                  "按 repo_name 删除仓库。",
+	"Get repositories accompany with relevant project and repo name.":                    "按项目和仓库名获取仓库。",
+	"Get public repositories which are accessed most.":                                   "获取访问最多的公开仓库。",
+	"Update a registered user to change to be an administrator of Harbor.":               "将注册用户设置为 Harbor 管理员。",
+	"Change the password on a user that already exists.":                                 "修改已有用户的密码。",
+	"Update a registered user to change his profile.":                                    "更新注册用户的个人信息。",
+	"Get a user's profile.":                                                              "获取用户的个人信息。",
+	"Mark a registered user as be removed.":                                              "将注册用户标记为已删除。",
+	"Creates a new user account.":                                                        "创建新用户。",
+	"Get registered users of Harbor.":                                                    "获取 Harbor 的注册用户。",
+	"Show info about current login user only.":                                           "仅显示当前登录用户的信息。",
+	"Search for projects and repositories.":                                              "搜索项目和仓库。",
+	"Log in to Harbor.":                                                                  "登录 Harbor。",
+	"Log in to Harbor with username and password.":                                       "使用用户名和密码登录 Harbor。",
+	"Log out from Harbor.":                                                               "退出 Harbor。",
+	"Log out current user from Harbor.":                                                  "当前用户退出 Harbor。",
+	"Get recent logs of the projects which the user is a member of.":                     "获取用户所属项目的最近日志。",
+	"Update a member of a project.":                                                      "更新项目成员。",
+	"Get a member of a project.":                                                         "获取项目成员。",
+	"Delete a member of a project.":                                                      "删除项目成员。",
+	"Create a member of a project.":                                                      "创建项目成员。",
+	"Get all members information of a project.":                                          "获取项目的全部成员信息。",
+	"Update metadata of a project by meta_name.":                                         "按 meta_name 更新项目元数据。",
+	"Get metadata of a project by meta_name.":                                            "按 meta_name 获取项目元数据。",
+	"Delete metadata of a project by meta_name.":                                         "按 meta_name 删除项目元数据。",
+	"Add metadata for a project.":                                                        "为项目添加元数据。",
+	"Get metadata of a project.":                                                         "获取项目元数据。",
+	"Get access logs accompany with a relevant project.":                                 "获取项目的访问日志。",
+	"Update properties for a selected project.":                                          "更新所选项目的属性。",
+	"Create a new project.":                                                              "创建新项目。",
+	"Return specific project detail information.":                                        "返回指定项目的详细信息。",
+	"Delete a project by project_id.":                                                    "按 project_id 删除项目。",
+	"List projects.":                                                                     "列出项目。",
+	"List targets filtered by name.":                                                     "按名称列出复制目标。",
+	"Create a new replication target.":                                                   "创建新的复制目标。",
+	"Ping validates target.":                                                             "校验复制目标的连通性。",
+	"Ping target.":                                                                       "测试复制目标的连通性。",
+	"Delete specific replication's target.":                                              "删除指定的复制目标。",
+	"Get replication's target.":                                                          "获取复制目标。",
+	"Update replication's target.":                                                       "更新复制目标。",
+	"List the target relevant policies.":                                                 "列出复制目标相关的策略。",
+	"Get all user groups information":                                                    "获取全部用户组信息",
+	"Create user group":                                                                  "创建用户组",
+	"Delete user group":                                                                  "删除用户组",
+	"Get user group information":                                                         "获取用户组信息",
+	"Update group information":                                                           "更新用户组信息",
+	"Modify name, description, target and enablement of a policy.":                       "修改策略的名称、描述、目标和启用状态。",
+	"Get a policy.":                                                                      "获取策略。",
+	"Create a policy.":                                                                   "创建策略。",
+	"Filter policies by name and project_id.":                                            "按名称和 project_id 筛选策略。",
+	"List jobs filtered by specific policy and repository.":                              "按策略和仓库列出任务。",
+	"Update status of jobs. Only \"stop\" is supported for now.":                         "更新任务状态。目前仅支持 \"stop\"。",
+	"Delete replication job with specific ID.":                                           "删除指定 ID 的复制任务。",
+	"Get replication job logs by specific job ID.":                                       "按任务 ID 获取复制任务日志。",
+	"Get scan job logs by specific job ID.":                                              "按任务 ID 获取扫描任务日志。",
+	"Sync repositories from registry to DB.":                                             "将仓库从 registry 同步到数据库。",
+	"Test connection and authentication with email server.":                              "测试与邮件服务器的连接和认证。",
+	"Get the tag of the repository.":                                                     "获取仓库的 tag。",
+	"Delete a tag in a repository.":                                                      "删除仓库中的 tag。",
+	"Get tags of a relevant repository.":                                                 "获取仓库的 tag 列表。",
+	"Export artifact inventory of a project.":                                            "导出项目的制品清单。",
+	"Delete repos by retention policy.":                                                  "按保留策略删除仓库。",
+	"Delete tags of repo by retention policy.":                                           "按保留策略删除仓库的 tag。",
+	"Scan a local image in a quarantine project before promoting it.":                    "在隔离项目中扫描本地镜像后再发布。",
+	"Probe which components the target Harbor has enabled.":                              "探测目标 Harbor 启用了哪些组件。",
+	"Show version info.":                                                                 "显示版本信息。",
+	"Apply labels to repositories by name pattern rules.":                                "按名称匹配规则为仓库添加标签。",
+	"Verify a project against an exported inventory.":                                    "按导出的清单校验项目。",
+	"Get pull audit log and pull time update settings.":                                  "获取拉取审计日志和拉取时间更新设置。",
+	"Set pull audit log and pull time update settings.":                                  "设置拉取审计日志和拉取时间更新设置。",
+	"Get summary of a project, with the upstream registry of a proxy cache project.":     "获取项目概要，包括代理缓存项目的上游仓库。",
+	"Get the bandwidth limit of a proxy cache project.":                                  "获取代理缓存项目的带宽限制。",
+	"Set the bandwidth limit of a proxy cache project.":                                  "设置代理缓存项目的带宽限制。",
+	"Get vulnerability details of the image.":                                            "获取镜像的漏洞详情。",
+	"Print pull commands of an artifact.":                                                "打印制品的拉取命令。",
+	"Record name to ID mapping of the current target.":                                   "记录当前目标的名称到 ID 映射。",
+	"Translate an ID from another Harbor to the current target.":                         "将其他 Harbor 的 ID 转换为当前目标的 ID。",
+	"Get Prometheus metrics of Harbor components.":                                       "获取 Harbor 组件的 Prometheus 指标。",
+	"Show the permission matrix of current user.":                                        "显示当前用户的权限矩阵。",
+	"Retire a project and delete it after N days.":                                       "停用项目并在 N 天后删除。",
+	"List tags of a repository grouped by semantic version.":                             "按语义化版本分组列出仓库的 tag。",
+	"Re-send the payload of a past webhook job.":                                         "重新发送历史 webhook 任务的内容。",
+	"List artifacts of a repository. (v2.0 API)":                                         "列出仓库中的制品。（v2.0 API）",
+	"Get an artifact by tag or digest. (v2.0 API)":                                       "按 tag 或 digest 获取制品。（v2.0 API）",
+	"Delete an artifact by tag or digest. (v2.0 API)":                                    "按 tag 或 digest 删除制品。（v2.0 API）",
+	"List tags of an artifact. (v2.0 API)":                                               "列出制品的 tag。（v2.0 API）",
+	"Create a tag for an artifact. (v2.0 API)":                                           "为制品创建 tag。（v2.0 API）",
+	"Delete a tag of an artifact. (v2.0 API)":                                            "删除制品的 tag。（v2.0 API）",
+	"Add a label to an artifact. (v2.0 API)":                                             "为制品添加标签。（v2.0 API）",
+	"Delete a label from an artifact. (v2.0 API)":                                        "删除制品的标签。（v2.0 API）",
+	"List accessories (signatures, SBOMs, ...) of an artifact. (v2.0 API, Harbor v2.8+)": "列出制品的附件（签名、SBOM 等）。（v2.0 API，Harbor v2.8+）",
+	"hint: logging in through the SSO proxy is not possible, set HARBOR_USERNAME and HARBOR_PASSWORD to a robot account, or to an OIDC user and its CLI secret (from the user profile in the Harbor UI), to use basic auth instead.": "提示：无法通过 SSO 代理登录，请将 HARBOR_USERNAME 和 HARBOR_PASSWORD 设置为机器人账户，或 OIDC 用户及其 CLI 密钥（见 Harbor 界面的用户设置），改用 basic auth。",
+	"must be at most %d, the maximum page size of the target, got %d":                              "不能超过目标的最大分页大小 %d，实际为 %d",
+	"unknown output format %q, expected one of json, yaml or table":                                "未知的输出格式 %q，应为 json、yaml 或 table",
+	"Show quota usage of a project broken down by repository.":                                     "按仓库分项显示项目的配额使用情况。",
+	"Run commands on cron schedules.":                                                              "按 cron 计划运行命令。",
+	"Export the system CVE allowlist as YAML.":                                                     "将系统 CVE 白名单导出为 YAML。",
+	"Replace the system CVE allowlist by a YAML file.":                                             "用 YAML 文件替换系统 CVE 白名单。",
+	"Label the artifacts to keep, for label-based retention rules.":                                "为需保留的 artifact 添加标签，配合基于标签的保留规则。",
+	"Merge a label into another one, or rename it.":                                                "将一个标签合并到另一个标签，或重命名标签。",
+	"warning: %s is removed from the v2.0 API of the target, use '%s' instead (%s).":               "警告：目标的 v2.0 API 已移除 %s，请改用 '%s'（%s）。",
+	"warning: %s is removed from the v2.0 API of the target, replaced by %s.":                      "警告：目标的 v2.0 API 已移除 %s，由 %s 取代。",
+	"warning: %s is removed from the v2.0 API of the target, without replacement.":                 "警告：目标的 v2.0 API 已移除 %s，且没有替代接口。",
+	"Tag an image into a repository.":                                                              "为镜像打标签到仓库。",
+	"Copy the settings of a project to another one.":                                               "将项目的设置复制到另一个项目。",
+	"Grant or revoke the system admin role of a user.":                                             "授予或撤销用户的系统管理员角色。",
+	"List the users of Harbor.":                                                                    "列出 Harbor 的用户。",
+	"Delete a policy.":                                                                             "删除策略。",
+	"Enable a policy.":                                                                             "启用策略。",
+	"Disable a policy.":                                                                            "停用策略。",
+	"Start a replication execution of a policy.":                                                   "启动策略的一次复制执行。",
+	"List replication executions.":                                                                 "列出复制执行。",
+	"Get a replication execution.":                                                                 "获取复制执行。",
+	"List the tasks of a replication execution.":                                                   "列出复制执行的任务。",
+	"List the registry types supported for replication.":                                           "列出复制支持的仓库类型。",
+	"Create a robot account of a project.":                                                         "创建项目的机器人账户。",
+	"List the robot accounts of a project.":                                                        "列出项目的机器人账户。",
+	"Get a robot account of a project.":                                                            "获取项目的机器人账户。",
+	"Enable a robot account of a project.":                                                         "启用项目的机器人账户。",
+	"Disable a robot account of a project.":                                                        "停用项目的机器人账户。",
+	"Delete a robot account of a project.":                                                         "删除项目的机器人账户。",
+	"Regenerate the secret of a robot account.":                                                    "重新生成机器人账户的密钥。",
+	"Explain what a command does against Harbor.":                                                  "说明命令对 Harbor 做了什么。",
+	"Apply a tag retention policy template to many projects.":                                      "将标签保留策略模板应用到多个项目。",
+	"Create a system robot account.":                                                               "创建系统机器人账户。",
+	"List the system robot accounts.":                                                              "列出系统机器人账户。",
+	"Get a system robot account.":                                                                  "获取系统机器人账户。",
+	"Update a system robot account.":                                                               "更新系统机器人账户。",
+	"Delete a system robot account.":                                                               "删除系统机器人账户。",
+	"Restore a deleted label.":                                                                     "恢复已删除的标签。",
+	"Create a webhook policy of a project.":                                                        "创建项目的 Webhook 策略。",
+	"List the webhook policies of a project.":                                                      "列出项目的 Webhook 策略。",
+	"Get a webhook policy of a project.":                                                           "获取项目的 Webhook 策略。",
+	"Update a webhook policy of a project.":                                                        "更新项目的 Webhook 策略。",
+	"Delete a webhook policy of a project.":                                                        "删除项目的 Webhook 策略。",
+	"Send a test event to webhook targets.":                                                        "向 Webhook 目标发送测试事件。",
+	"Get the last triggers of the webhook policies of a project.":                                  "获取项目 Webhook 策略的最近触发时间。",
+	"List the jobs of a webhook policy.":                                                           "列出 Webhook 策略的任务。",
+	"Run the garbage collection now.":                                                              "立即运行垃圾回收。",
+	"Get the schedule of the garbage collection.":                                                  "获取垃圾回收的计划。",
+	"Set the schedule of the garbage collection.":                                                  "设置垃圾回收的计划。",
+	"List the runs of the garbage collection.":                                                     "列出垃圾回收的运行记录。",
+	"Get a run of the garbage collection.":                                                         "获取垃圾回收的一次运行。",
+	"Get the log of a run of the garbage collection.":                                              "获取垃圾回收运行的日志。",
+	"Serve the commands as JSON requests and responses.":                                           "以 JSON 请求和响应的方式提供命令服务。",
+	"Get the metadatas of tag retention.":                                                          "获取 tag 保留策略的元数据。",
+	"Get a tag retention policy.":                                                                  "获取 tag 保留策略。",
+	"Create the tag retention policy of a project.":                                                "创建项目的 tag 保留策略。",
+	"Update a tag retention policy.":                                                               "更新 tag 保留策略。",
+	"Run a tag retention policy.":                                                                  "执行 tag 保留策略。",
+	"List the executions of a tag retention policy.":                                               "列出 tag 保留策略的执行记录。",
+	"List the tasks of an execution of a tag retention policy.":                                    "列出 tag 保留策略某次执行的任务。",
+	"Get the log of a task of a tag retention policy.":                                             "获取 tag 保留策略任务的日志。",
+	"Simulate a tag retention policy.":                                                             "模拟执行 tag 保留策略。",
+	"List the immutable tag rules of a project.":                                                   "列出项目的不可变 tag 规则。",
+	"Create an immutable tag rule of a project.":                                                   "创建项目的不可变 tag 规则。",
+	"Update an immutable tag rule of a project.":                                                   "更新项目的不可变 tag 规则。",
+	"Delete an immutable tag rule of a project.":                                                   "删除项目的不可变 tag 规则。",
+	"List the scanners.":                                                                           "列出扫描器。",
+	"Register a scanner.":                                                                          "注册扫描器。",
+	"Get a scanner.":                                                                               "获取扫描器。",
+	"Ping a scanner.":                                                                              "检测扫描器的连通性。",
+	"Set the default scanner.":                                                                     "设置默认扫描器。",
+	"Get the scanner of a project.":                                                                "获取项目的扫描器。",
+	"Set the scanner of a project.":                                                                "设置项目的扫描器。",
+	"Scan an artifact for vulnerabilities.":                                                        "扫描制品的漏洞。",
+	"Get the vulnerability report of an artifact.":                                                 "获取制品的漏洞报告。",
+	"Scan all the artifacts now.":                                                                  "立即扫描所有制品。",
+	"Get the schedule of the scan of all the artifacts.":                                           "获取全部制品扫描的计划。",
+	"Set the schedule of the scan of all the artifacts.":                                           "设置全部制品扫描的计划。",
+	"Get the progress of the scan of all the artifacts.":                                           "获取全部制品扫描的进度。",
+	"Get the system CVE allowlist.":                                                                "获取系统 CVE 白名单。",
+	"Add CVEs to or remove CVEs from the system CVE allowlist.":                                    "向系统 CVE 白名单添加或移除 CVE。",
+	"Get the CVE allowlist of a project.":                                                          "获取项目的 CVE 白名单。",
+	"Add CVEs to or remove CVEs from the CVE allowlist of a project.":                              "向项目的 CVE 白名单添加或移除 CVE。",
+	"Check the health of Harbor and its components.":                                               "检查 Harbor 及其各组件的健康状态。",
+	"Wait for Harbor and all its components to be healthy.":                                        "等待 Harbor 及其所有组件变为健康状态。",
+	"Change some system configurations.":                                                           "修改部分系统配置。",
+	"Ping the LDAP server.":                                                                        "检测 LDAP 服务器的连通性。",
+	"Search the users of LDAP.":                                                                    "搜索 LDAP 用户。",
+	"Search the groups of LDAP.":                                                                   "搜索 LDAP 用户组。",
+	"Import users of LDAP.":                                                                        "导入 LDAP 用户。",
+	"Search user groups by name":                                                                   "按名称搜索用户组",
+	"List the Helm charts of a project.":                                                           "列出项目的 Helm chart。",
+	"List the versions of a Helm chart.":                                                           "列出 Helm chart 的版本。",
+	"Get a version of a Helm chart.":                                                               "获取 Helm chart 的某个版本。",
+	"Delete a Helm chart or a version of it.":                                                      "删除 Helm chart 或其某个版本。",
+	"Upload a Helm chart package to a project.":                                                    "上传 Helm chart 包到项目。",
+	"Get the labels of a version of a Helm chart.":                                                 "获取 Helm chart 某个版本的标签。",
+	"Add a label to a version of a Helm chart.":                                                    "为 Helm chart 的某个版本添加标签。",
+	"Delete a label from a version of a Helm chart.":                                               "删除 Helm chart 某个版本的标签。",
+	"List the resources carrying a label.":                                                         "列出带有某个标签的资源。",
+	"Inspect the manifest of an image.":                                                            "查看镜像的 manifest。",
+	"Get the build history of an image.":                                                           "获取镜像的构建历史。",
+	"Tell whether a tag is signed.":                                                                "判断标签是否已签名。",
+	"List the unsigned tags of a project.":                                                         "列出项目中未签名的标签。",
+	"Download the SBOM of an artifact. (v2.0 API, Harbor v2.11+)":                                  "下载制品的 SBOM。（v2.0 API，Harbor v2.11+）",
+	"Copy an artifact into another repository. (v2.0 API)":                                         "将制品复制到另一个仓库。（v2.0 API）",
+	"Delete the tags older than a given age, or all but the last ones.":                            "删除早于指定时长的标签，或仅保留最新的若干个。",
+	"showing %d (page %d)":                                                                         "显示 %d 条（第 %d 页）",
+	"showing %d of %d (page %d)":                                                                   "显示 %d 条，共 %d 条（第 %d 页）",
+	"next page: --page %d":                                                                         "下一页：--page %d",
+	"all pages: --all":                                                                             "全部页：--all",
+	"(unavailable: requires %s) ":                                                                  "（不可用：需要 %s）",
+	"non-interactive mode: refusing to prompt for %q, provide it via flags or environment instead": "非交互模式：不会提示输入 %q，请通过参数或环境变量提供",
+	"missing required field(s): ":                                                                  "缺少必填项：",
+	"Public repos suggested to delete, by score from low to high":                                  "根据分数排名（由低到高）建议删除 public repo 信息如下",
+	"Number of repos to delete: ":                                                                  "请输入希望删除的 repo 数量: ",
+	"Invalid number, please enter again: ":                                                         "输入数字不合法，请重新输入: ",
+	"The number you entered:":                                                                      "您输入的数字为:",
+	"Confirm? [y/n]: ":                                                                             "确认吗 [y/n]: ",
+	"Please enter the number of repos to delete again: ":                                           "请重新输入希望删除的 repo 数量: ",
+	"Soft deletion is done, to really free the disk space you still need to:":                      "您已成功完成 soft deletion ，若想真正释放磁盘空间，还需要:",
+	"1. Change to the installation directory of harbor (e.g. /opt/apps/harbor/)":                   "1. 切换到 harbor 的安装主目录（例如 /opt/apps/harbor/）",
+	"2. Run the commands below to preview which files/images would be deleted:":                    "2. 运行如下命令以 preview 哪些 files/images 会被删除：",
+	"3. Run the commands below to really trigger GC:":                                              "3. 运行如下命令以真正触发 GC 动作：",
+	"WARNING:\nMake sure that no one is pushing images or Harbor is not running at all before you perform a GC. If someone were pushing an image while GC is running, there is a risk that the image's layers will be mistakenly deleted which results in a corrupted image. So before running GC, a preferred approach is to stop Harbor first.": "警告：\n执行 GC 前请确保没有人在推送镜像，或者 Harbor 已完全停止。如果 GC 运行期间有人推送镜像，镜像的层可能被误删而导致镜像损坏。因此运行 GC 前，推荐先停止 Harbor。",
+}
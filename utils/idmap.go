@@ -0,0 +1,233 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func init() {
+	Parser.AddCommand("idmap_sync",
+		"Record name to ID mapping of the current target.",
+		"Record the IDs of projects, labels and replication targets of the current Harbor by their names in a local mapping table, so that references by ID can be translated between Harbor instances (e.g. 'policy_create --map_from').",
+		&idmapSync)
+	Parser.AddCommand("idmap_translate",
+		"Translate an ID from another Harbor to the current target.",
+		"Translate the ID of a project, label or replication target recorded for another Harbor to the ID of the same named object on the current target, using the mapping table recorded by idmap_sync on both.",
+		&idmapTranslate)
+	RequireAdmin("idmap_sync")
+}
+
+// Kinds of objects in the ID mapping table.
+const (
+	IDKindProject = "project"
+	IDKindLabel   = "label"
+	IDKindTarget  = "target"
+)
+
+var idmapfile = "conf/.idmap.yaml"
+
+// idMap maps the names of objects to their IDs on one Harbor. Global labels
+// are named by their name, project labels by "project/name".
+type idMap struct {
+	Projects map[string]int `yaml:"projects"`
+	Labels   map[string]int `yaml:"labels"`
+	Targets  map[string]int `yaml:"targets"`
+}
+
+// idMapTable holds the mapping of every Harbor, by base URL.
+type idMapTable map[string]*idMap
+
+type targetBrief struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type idmapSyncRun struct {
+}
+
+var idmapSync idmapSyncRun
+
+type idmapTranslateRun struct {
+	Kind string `short:"k" long:"kind" description:"(REQUIRED) The kind of object." required:"yes" validate:"oneof=project|label|target"`
+	ID   int    `short:"i" long:"id" description:"(REQUIRED) The ID on the source Harbor." required:"yes"`
+	From string `long:"from" description:"(REQUIRED) Base URL of the source Harbor, as listed by idmap_sync." required:"yes"`
+}
+
+var idmapTranslate idmapTranslateRun
+
+func (x *idmapSyncRun) Execute(args []string) error {
+	return idMapSync()
+}
+
+func (x *idmapTranslateRun) Execute(args []string) error {
+	id, err := IDMapTranslate(x.Kind, x.ID, x.From)
+	if err != nil {
+		return err
+	}
+	fmt.Println(id)
+	return nil
+}
+
+func idMapTableLoad() (idMapTable, error) {
+	table := make(idMapTable)
+
+	dataBytes, err := ioutil.ReadFile(idmapfile)
+	if os.IsNotExist(err) {
+		return table, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(dataBytes, &table); err != nil {
+		return nil, fmt.Errorf("%s: %v", idmapfile, err)
+	}
+	return table, nil
+}
+
+func idMapTableSave(table idMapTable) error {
+	dataBytes, err := yaml.Marshal(table)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(idmapfile, dataBytes, 0644)
+}
+
+// idMapSync records the mapping of the current target.
+func idMapSync() error {
+	c := NewClient()
+	m := &idMap{
+		Projects: make(map[string]int),
+		Labels:   make(map[string]int),
+		Targets:  make(map[string]int),
+	}
+
+	items, err := c.FetchAllPages(c.APIURL("/projects"))
+	if err != nil {
+		return err
+	}
+	var prjs []*projectBrief
+	for _, it := range items {
+		var p projectBrief
+		if err := json.Unmarshal(it, &p); err != nil {
+			return err
+		}
+		prjs = append(prjs, &p)
+		m.Projects[p.Name] = p.ProjectID
+	}
+
+	labels, err := c.FetchAllPages(c.APIURL("/labels") + "?scope=g")
+	if err != nil {
+		return err
+	}
+	if err := idMapAddLabels(m, "", labels); err != nil {
+		return err
+	}
+	for _, p := range prjs {
+		labels, err := c.FetchAllPages(c.APIURL("/labels") + "?scope=p&project_id=" + strconv.Itoa(p.ProjectID))
+		if err != nil {
+			return err
+		}
+		if err := idMapAddLabels(m, p.Name+"/", labels); err != nil {
+			return err
+		}
+	}
+
+	// Replication targets are registries with v2.0 API.
+	targetsURL := c.APIURL("/targets")
+	if c.IsV2() {
+		targetsURL = c.APIURL("/registries")
+	}
+	var targets []*targetBrief
+	if err := FetchAllPagesInto(c, targetsURL, &targets); err != nil {
+		return err
+	}
+	for _, t := range targets {
+		m.Targets[t.Name] = t.ID
+	}
+
+	table, err := idMapTableLoad()
+	if err != nil {
+		return err
+	}
+	table[c.BaseURL()] = m
+	if err := idMapTableSave(table); err != nil {
+		return err
+	}
+
+	fmt.Printf("==> %s: %d projects, %d labels, %d targets recorded in %s\n",
+		c.BaseURL(), len(m.Projects), len(m.Labels), len(m.Targets), idmapfile)
+
+	var known []string
+	for k := range table {
+		known = append(known, k)
+	}
+	sort.Strings(known)
+	fmt.Println("==> known Harbors:", known)
+	return nil
+}
+
+func idMapAddLabels(m *idMap, prefix string, items []json.RawMessage) error {
+	for _, it := range items {
+		var l labelBrief
+		if err := json.Unmarshal(it, &l); err != nil {
+			return err
+		}
+		m.Labels[prefix+l.Name] = l.ID
+	}
+	return nil
+}
+
+func (m *idMap) byKind(kind string) map[string]int {
+	switch kind {
+	case IDKindProject:
+		return m.Projects
+	case IDKindLabel:
+		return m.Labels
+	case IDKindTarget:
+		return m.Targets
+	}
+	return nil
+}
+
+// IDMapTranslate translates the ID of an object of kind on the Harbor of
+// base URL from to the ID of the same named object on the current target.
+func IDMapTranslate(kind string, id int, from string) (int, error) {
+	table, err := idMapTableLoad()
+	if err != nil {
+		return 0, err
+	}
+
+	src, ok := table[from]
+	if !ok {
+		return 0, fmt.Errorf("no ID mapping of %s, run idmap_sync against it first", from)
+	}
+	to := URLGen("")
+	dst, ok := table[to]
+	if !ok {
+		return 0, fmt.Errorf("no ID mapping of %s, run idmap_sync against it first", to)
+	}
+
+	name := ""
+	for n, i := range src.byKind(kind) {
+		if i == id {
+			name = n
+			break
+		}
+	}
+	if name == "" {
+		return 0, fmt.Errorf("%s %d not found in ID mapping of %s", kind, id, from)
+	}
+
+	dstID, ok := dst.byKind(kind)[name]
+	if !ok {
+		return 0, fmt.Errorf("%s %q (%d on %s) does not exist on %s", kind, name, id, from, to)
+	}
+	return dstID, nil
+}
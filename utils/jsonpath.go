@@ -0,0 +1,235 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a compiled JSONPath template in the manner of kubectl:
+// literal text with {expressions}, where an expression is
+//
+//	.field, ['field']   - a field of an object
+//	[n], [*]            - an element, or all elements, of an array
+//	"text"              - a quoted literal, e.g. {"\n"}
+//	range PATH ... end  - the enclosed template for every result of PATH
+//
+// e.g. '{.items[*].name}' or '{range .items[*]}{.id}{"\t"}{.name}{"\n"}{end}'.
+// Several results of an expression are separated by spaces.
+type jsonPath struct {
+	nodes []*jpNode
+}
+
+type jpNode struct {
+	text  string   // literal text, if path is nil
+	path  []string // "field", "[n]" or "[*]" steps
+	body  []*jpNode
+	isRng bool
+}
+
+func parseJSONPath(tmpl string) (*jsonPath, error) {
+	root := &jpNode{isRng: true}
+	stack := []*jpNode{root}
+
+	for len(tmpl) > 0 {
+		top := stack[len(stack)-1]
+		i := strings.IndexByte(tmpl, '{')
+		if i < 0 {
+			top.body = append(top.body, &jpNode{text: tmpl})
+			break
+		}
+		if i > 0 {
+			top.body = append(top.body, &jpNode{text: tmpl[:i]})
+		}
+		j := strings.IndexByte(tmpl[i:], '}')
+		if j < 0 {
+			return nil, fmt.Errorf("unclosed '{' in jsonpath %q", tmpl)
+		}
+		expr := strings.TrimSpace(tmpl[i+1 : i+j])
+		tmpl = tmpl[i+j+1:]
+
+		switch {
+		case expr == "end":
+			if len(stack) == 1 {
+				return nil, fmt.Errorf("{end} without {range} in jsonpath")
+			}
+			stack = stack[:len(stack)-1]
+		case strings.HasPrefix(expr, "range "):
+			path, err := parsePathSteps(strings.TrimSpace(expr[len("range "):]))
+			if err != nil {
+				return nil, err
+			}
+			n := &jpNode{path: path, isRng: true}
+			top.body = append(top.body, n)
+			stack = append(stack, n)
+		case strings.HasPrefix(expr, `"`):
+			text, err := strconv.Unquote(expr)
+			if err != nil {
+				return nil, fmt.Errorf("bad literal %s in jsonpath", expr)
+			}
+			top.body = append(top.body, &jpNode{text: text})
+		default:
+			path, err := parsePathSteps(expr)
+			if err != nil {
+				return nil, err
+			}
+			top.body = append(top.body, &jpNode{path: path})
+		}
+	}
+	if len(stack) != 1 {
+		return nil, fmt.Errorf("{range} without {end} in jsonpath")
+	}
+	return &jsonPath{nodes: root.body}, nil
+}
+
+// parsePathSteps splits ".items[*].name" into "items", "[*]", "name".
+func parsePathSteps(expr string) ([]string, error) {
+	steps := []string{}
+	s := strings.TrimPrefix(expr, "$")
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			n := strings.IndexAny(s, ".[")
+			if n < 0 {
+				n = len(s)
+			}
+			if n > 0 {
+				steps = append(steps, s[:n])
+			}
+			s = s[n:]
+		case '[':
+			n := strings.IndexByte(s, ']')
+			if n < 0 {
+				return nil, fmt.Errorf("unclosed '[' in jsonpath expression %q", expr)
+			}
+			idx := strings.TrimSpace(s[1:n])
+			if q, err := strconv.Unquote(strings.Replace(idx, "'", `"`, -1)); err == nil {
+				steps = append(steps, q)
+			} else if _, err := strconv.Atoi(idx); err == nil || idx == "*" {
+				steps = append(steps, "["+idx+"]")
+			} else {
+				return nil, fmt.Errorf("bad index [%s] in jsonpath expression %q", idx, expr)
+			}
+			s = s[n+1:]
+		default:
+			return nil, fmt.Errorf("bad jsonpath expression %q, expected e.g. .name or [0]", expr)
+		}
+	}
+	return steps, nil
+}
+
+// Execute writes the template for data, a value decoded by json with
+// UseNumber.
+func (p *jsonPath) Execute(w io.Writer, data interface{}) error {
+	return executeJPNodes(w, p.nodes, data)
+}
+
+func executeJPNodes(w io.Writer, nodes []*jpNode, data interface{}) error {
+	for _, n := range nodes {
+		if n.path == nil {
+			if _, err := io.WriteString(w, n.text); err != nil {
+				return err
+			}
+			continue
+		}
+
+		results, err := evalPath(n.path, data)
+		if err != nil {
+			return err
+		}
+		if n.isRng {
+			for _, r := range results {
+				if err := executeJPNodes(w, n.body, r); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		for i, r := range results {
+			if i > 0 {
+				io.WriteString(w, " ")
+			}
+			if _, err := io.WriteString(w, jpString(r)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func evalPath(path []string, data interface{}) ([]interface{}, error) {
+	cur := []interface{}{data}
+	for _, step := range path {
+		var next []interface{}
+		for _, v := range cur {
+			switch {
+			case step == "[*]":
+				switch v := v.(type) {
+				case []interface{}:
+					next = append(next, v...)
+				case map[string]interface{}:
+					for _, k := range sortedMapKeys(v) {
+						next = append(next, v[k])
+					}
+				}
+			case strings.HasPrefix(step, "["):
+				arr, ok := v.([]interface{})
+				if !ok {
+					return nil, fmt.Errorf("%s: not an array", step)
+				}
+				i, _ := strconv.Atoi(step[1 : len(step)-1])
+				if i < 0 {
+					i += len(arr)
+				}
+				if i < 0 || i >= len(arr) {
+					return nil, fmt.Errorf("%s: index out of range", step)
+				}
+				next = append(next, arr[i])
+			default:
+				obj, ok := v.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("%s: not an object", step)
+				}
+				if f, ok := obj[step]; ok {
+					next = append(next, f)
+				}
+			}
+		}
+		cur = next
+	}
+	return cur, nil
+}
+
+// jpString formats a result, strings and numbers as is, others as JSON.
+func jpString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		var b bytes.Buffer
+		enc := json.NewEncoder(&b)
+		enc.SetEscapeHTML(false)
+		enc.Encode(v)
+		return strings.TrimSuffix(b.String(), "\n")
+	}
+}
+
+func sortedMapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJSONPath(t *testing.T) {
+	const data = `{
+		"name": "library",
+		"public": true,
+		"count": 12,
+		"meta": {"a": "1", "b": "2"},
+		"items": [
+			{"id": 1, "name": "nginx", "tags": ["1.25", "latest"], "labels": {"env": "prod"}},
+			{"id": 2, "name": "redis", "tags": [], "size": 1.5e3}
+		]
+	}`
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{"{.name}", "library"},
+		{"{$.name}", "library"},
+		{"name: {.name}!", "name: library!"},
+		{"{.public} {.count}", "true 12"},
+		{"{.items[0].name}", "nginx"},
+		{"{.items[-1].name}", "redis"},
+		{"{.items[1].size}", "1.5e3"},
+		{"{.items[*].name}", "nginx redis"},
+		{"{.items[*].tags[*]}", "1.25 latest"},
+		{"{.items[0]['labels'].env}", "prod"},
+		{`{.items[0]["labels"]}`, `{"env":"prod"}`},
+		{"{.meta[*]}", "1 2"},
+		{"{.missing}", ""},
+		{"{.items[*].size}", "1.5e3"},
+		{`{range .items[*]}{.id}{"\t"}{.name}{"\n"}{end}`, "1\tnginx\n2\tredis\n"},
+		{`{range .items[*]}{.name}:{range .tags[*]} {$}{end};{end}`, "nginx: 1.25 latest;redis:;"},
+	}
+	for _, tt := range tests {
+		p, err := parseJSONPath(tt.tmpl)
+		if err != nil {
+			t.Errorf("parseJSONPath(%q): %v", tt.tmpl, err)
+			continue
+		}
+		var b bytes.Buffer
+		if err := p.Execute(&b, v); err != nil {
+			t.Errorf("%q: %v", tt.tmpl, err)
+			continue
+		}
+		if got := b.String(); got != tt.want {
+			t.Errorf("%q = %q, want %q", tt.tmpl, got, tt.want)
+		}
+	}
+}
+
+func TestJSONPathInvalid(t *testing.T) {
+	tests := []struct {
+		tmpl string
+		data string // empty if the template fails to parse
+	}{
+		{"{.name", ""},
+		{"{end}", ""},
+		{"{range .items[*]}{.name}", ""},
+		{`{"unclosed}`, ""},
+		{"{name}", ""},
+		{"{.items[0}", ""},
+		{"{.items[x]}", ""},
+		{"{.items[2].name}", `{"items": [{}]}`},
+		{"{.name[0]}", `{"name": "library"}`},
+		{"{.items.name}", `{"items": []}`},
+	}
+	for _, tt := range tests {
+		p, err := parseJSONPath(tt.tmpl)
+		if tt.data == "" {
+			if err == nil {
+				t.Errorf("parseJSONPath(%q): no error", tt.tmpl)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseJSONPath(%q): %v", tt.tmpl, err)
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal([]byte(tt.data), &v); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Execute(&bytes.Buffer{}, v); err == nil {
+			t.Errorf("%q on %s: no error", tt.tmpl, tt.data)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
+)
+
+func init() {
+	Parser.AddCommand("label_merge",
+		"Merge a label into another one, or rename it.",
+		"Relabel every resource carrying the label --from with the label --to, in all projects for a system label or in the project of a project label, then delete --from: artifacts with v2.0 API, repositories and tags with v1 API, and the versions of the Helm charts of the projects having some. When --to does not exist, --from is renamed instead. Resources failing to be relabeled are reported at the end, and --from is kept if any failed.",
+		&labelMerge)
+}
+
+type labelMergeRun struct {
+	From    string `long:"from" description:"(REQUIRED) The name of the label to merge." required:"yes"`
+	To      string `long:"to" description:"(REQUIRED) The name of the label to merge into, or the new name of --from if it does not exist." required:"yes"`
+	Scope   string `short:"s" long:"scope" description:"The scope of the labels, 'g' for system labels and 'p' for project labels." default:"g" validate:"oneof=g|p"`
+	Project string `short:"p" long:"project" description:"The name of the project of the labels. (required with --scope p)" validate:"required_if=Scope:p"`
+	DryRun  bool   `long:"dry-run" description:"Print the changes only."`
+}
+
+var labelMerge labelMergeRun
+
+func (x *labelMergeRun) Execute(args []string) error {
+	if x.From == x.To {
+		return fmt.Errorf("--from and --to are the same label")
+	}
+
+	c := NewClient()
+	var projects []*model.Project
+	if x.Scope == "p" {
+		prj, err := findProject(c, x.Project)
+		if err != nil {
+			return err
+		}
+		projects = append(projects, prj)
+	}
+
+	from, err := labelByName(c, x.Scope, projects, x.From)
+	if err != nil {
+		return err
+	}
+	if from == nil {
+		return fmt.Errorf("label %q not found", x.From)
+	}
+	to, err := labelByName(c, x.Scope, projects, x.To)
+	if err != nil {
+		return err
+	}
+
+	if to == nil {
+		fmt.Printf("label %q renamed to %q\n", x.From, x.To)
+		if x.DryRun {
+			return nil
+		}
+		from.Name = x.To
+		targetURL := c.APIURL("/labels") + "/" + strconv.FormatInt(from.ID, 10)
+		c.Trace("==> PUT", targetURL)
+		_, err := c.Do(c.Put(targetURL).Send(from))
+		return err
+	}
+
+	if x.Scope == "g" {
+		if projects, err = allProjects(c); err != nil {
+			return err
+		}
+	}
+
+	m := &labelMover{from: from, to: to, dryRun: x.DryRun}
+	m.labelWalker = labelWalker{c: c, visit: m.relabel}
+	for _, prj := range projects {
+		m.project(prj)
+	}
+
+	fmt.Printf("%d resources relabeled from %q to %q\n", m.moved, x.From, x.To)
+	if len(m.failed) > 0 {
+		for _, f := range m.failed {
+			fmt.Println("failed:", f)
+		}
+		return fmt.Errorf("%d failures, label %q is kept, run label_merge again to retry", len(m.failed), x.From)
+	}
+	if x.DryRun {
+		return nil
+	}
+
+	targetURL := c.APIURL("/labels") + "/" + strconv.FormatInt(from.ID, 10)
+	c.Trace("==> DELETE", targetURL)
+	if _, err := c.Do(c.Delete(targetURL)); err != nil {
+		return err
+	}
+	fmt.Printf("label %q deleted\n", x.From)
+	return nil
+}
+
+// labelMover moves the label from to the label to on resources, and keeps
+// count of the moves.
+type labelMover struct {
+	labelWalker
+	from, to *model.Label
+	dryRun   bool
+
+	moved int
+}
+
+// labelWalker visits the resources of projects which can carry labels,
+// with their labels, and keeps the failures to list them.
+type labelWalker struct {
+	c *harbor.Client
+	// visit is called with the name of a resource, the URL of its labels
+	// and its labels.
+	visit func(name, labelsURL string, labels []model.Label)
+
+	failed []string
+}
+
+// project visits the artifacts of a project with v2.0 API, its
+// repositories and tags with v1 API, and the versions of its Helm charts
+// if it has some.
+func (w *labelWalker) project(prj *model.Project) {
+	if w.c.IsV2() {
+		w.projectV2(prj)
+	} else {
+		w.projectV1(prj)
+	}
+	if prj.ChartCount > 0 {
+		w.projectCharts(prj)
+	}
+}
+
+// projectV2 visits the artifacts of a project with v2.0 API.
+func (w *labelWalker) projectV2(prj *model.Project) {
+	prjURL := w.c.URL("/api/v2.0/projects") + "/" + url.PathEscape(prj.Name)
+
+	var repos []string
+	err := w.c.EachPage(prjURL+"/repositories", func(item json.RawMessage) error {
+		var r model.Repository
+		if err := json.Unmarshal(item, &r); err != nil {
+			return err
+		}
+		repos = append(repos, r.Name[len(prj.Name)+1:])
+		return nil
+	})
+	if err != nil {
+		w.failed = append(w.failed, fmt.Sprintf("%s: listing repositories: %v", prj.Name, err))
+		return
+	}
+
+	for _, repo := range repos {
+		repoURL := prjURL + "/repositories/" + RepoPathV2(repo)
+		// All artifacts are listed before relabeling any, not to relabel
+		// while paging.
+		var arts []*model.Artifact
+		err := w.c.EachPage(repoURL+"/artifacts?with_label=true&with_tag=false", func(item json.RawMessage) error {
+			var a model.Artifact
+			if err := json.Unmarshal(item, &a); err != nil {
+				return err
+			}
+			arts = append(arts, &a)
+			return nil
+		})
+		if err != nil {
+			w.failed = append(w.failed, fmt.Sprintf("%s/%s: listing artifacts: %v", prj.Name, repo, err))
+			continue
+		}
+		for _, a := range arts {
+			w.visit(prj.Name+"/"+repo+"@"+a.Digest, repoURL+"/artifacts/"+TagPath(a.Digest)+"/labels", a.Labels)
+		}
+	}
+}
+
+// projectV1 visits the repositories and tags of a project with v1 API.
+func (w *labelWalker) projectV1(prj *model.Project) {
+	var repos []*model.Repository
+	err := w.c.EachPage(w.c.APIURL("/repositories")+"?project_id="+strconv.FormatInt(prj.ProjectID, 10), func(item json.RawMessage) error {
+		var r model.Repository
+		if err := json.Unmarshal(item, &r); err != nil {
+			return err
+		}
+		repos = append(repos, &r)
+		return nil
+	})
+	if err != nil {
+		w.failed = append(w.failed, fmt.Sprintf("%s: listing repositories: %v", prj.Name, err))
+		return
+	}
+
+	for _, r := range repos {
+		repoURL := w.c.APIURL("/repositories") + "/" + RepoPath(r.Name)
+		w.visit(r.Name, repoURL+"/labels", r.Labels)
+
+		var tags []*model.Tag
+		if err := w.c.GetJSON(repoURL+"/tags", &tags); err != nil {
+			w.failed = append(w.failed, fmt.Sprintf("%s: listing tags: %v", r.Name, err))
+			continue
+		}
+		for _, t := range tags {
+			w.visit(r.Name+":"+t.Name, repoURL+"/tags/"+TagPath(t.Name)+"/labels", t.Labels)
+		}
+	}
+}
+
+// projectCharts visits the versions of the Helm charts of a project, whose
+// chart repository is the same with both API versions.
+func (w *labelWalker) projectCharts(prj *model.Project) {
+	chartsURL := w.c.URL("/api/chartrepo") + "/" + url.PathEscape(prj.Name) + "/charts"
+
+	var charts []*model.ChartInfo
+	if err := w.c.GetJSON(chartsURL, &charts); err != nil {
+		w.failed = append(w.failed, fmt.Sprintf("%s: listing charts: %v", prj.Name, err))
+		return
+	}
+	for _, chart := range charts {
+		chartURL := chartsURL + "/" + url.PathEscape(chart.Name)
+		var versions []*model.ChartVersion
+		if err := w.c.GetJSON(chartURL, &versions); err != nil {
+			w.failed = append(w.failed, fmt.Sprintf("%s/%s: listing chart versions: %v", prj.Name, chart.Name, err))
+			continue
+		}
+		for _, v := range versions {
+			w.visit("chart "+prj.Name+"/"+chart.Name+":"+v.Version, chartURL+"/"+url.PathEscape(v.Version)+"/labels", v.Labels)
+		}
+	}
+}
+
+// relabel moves the label of the resource name, whose labels are at
+// labelsURL, if it carries it.
+func (m *labelMover) relabel(name, labelsURL string, labels []model.Label) {
+	hasFrom, hasTo := false, false
+	for _, l := range labels {
+		hasFrom = hasFrom || l.ID == m.from.ID
+		hasTo = hasTo || l.ID == m.to.ID
+	}
+	if !hasFrom {
+		return
+	}
+
+	fmt.Printf("%s: %s -> %s\n", name, m.from.Name, m.to.Name)
+	if m.dryRun {
+		m.moved++
+		return
+	}
+
+	if !hasTo {
+		m.c.Trace("==> POST", labelsURL)
+		if _, err := m.c.Do(m.c.Post(labelsURL).Send(map[string]int64{"id": m.to.ID})); err != nil {
+			m.failed = append(m.failed, fmt.Sprintf("%s: adding %q: %v", name, m.to.Name, err))
+			return
+		}
+	}
+	targetURL := labelsURL + "/" + strconv.FormatInt(m.from.ID, 10)
+	m.c.Trace("==> DELETE", targetURL)
+	if _, err := m.c.Do(m.c.Delete(targetURL)); err != nil {
+		m.failed = append(m.failed, fmt.Sprintf("%s: removing %q: %v", name, m.from.Name, err))
+		return
+	}
+	m.moved++
+}
+
+// allProjects returns all the projects, page after page.
+func allProjects(c *harbor.Client) ([]*model.Project, error) {
+	var projects []*model.Project
+	err := c.EachPage(c.APIURL("/projects"), func(item json.RawMessage) error {
+		var prj model.Project
+		if err := json.Unmarshal(item, &prj); err != nil {
+			return err
+		}
+		projects = append(projects, &prj)
+		return nil
+	})
+	return projects, err
+}
+
+// findProject returns the project named name.
+func findProject(c *harbor.Client, name string) (*model.Project, error) {
+	var prjs []*model.Project
+	if err := c.GetJSON(c.APIURL("/projects")+"?name="+url.QueryEscape(name), &prjs); err != nil {
+		return nil, err
+	}
+	for _, prj := range prjs {
+		if prj.Name == name {
+			return prj, nil
+		}
+	}
+	return nil, fmt.Errorf("project %q not found", name)
+}
+
+// labelByName returns the label named name of scope, of the only project of
+// projects for a project label, nil if there is none.
+func labelByName(c *harbor.Client, scope string, projects []*model.Project, name string) (*model.Label, error) {
+	q := url.Values{"scope": {scope}, "name": {name}}
+	if scope == "p" {
+		q.Set("project_id", strconv.FormatInt(projects[0].ProjectID, 10))
+	}
+
+	var labels []*model.Label
+	if err := c.GetJSON(c.APIURL("/labels")+"?"+q.Encode(), &labels); err != nil {
+		return nil, err
+	}
+	for _, l := range labels {
+		if l.Name == name {
+			return l, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,220 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func init() {
+	Parser.AddCommand("label_autoapply",
+		"Apply labels to repositories by name pattern rules.",
+		"Poll repositories of the projects configured in the rules file, and add the configured labels to every newly pushed repository whose name matches the pattern, so labeling conventions are enforced automatically. With v2.0 API, where repositories have no labels, the labels are added to every newly pushed artifact of the repository.",
+		&labelAutoApply)
+}
+
+type labelAutoApplyRun struct {
+	File     string `short:"f" long:"file" description:"The rules file." default:"conf/label_rules.yaml"`
+	Interval int    `short:"i" long:"interval" description:"Polling interval in seconds." default:"60" validate:"min=1"`
+	Once     bool   `long:"once" description:"Do a single pass over all repositories and exit."`
+}
+
+var labelAutoApply labelAutoApplyRun
+
+func (x *labelAutoApplyRun) Execute(args []string) error {
+	if !x.Once && x.Interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	rules, err := labelRulesLoad(x.File)
+	if err != nil {
+		return err
+	}
+
+	c := NewClient()
+
+	// Repositories which have been handled already, they are only checked
+	// once per run. Those failing to be labeled are tried again.
+	seen := make(map[string]bool)
+
+	for {
+		if err := labelRulesApply(c, rules, seen); err != nil {
+			if x.Once {
+				return err
+			}
+			fmt.Println("error:", err)
+		}
+		if x.Once {
+			return nil
+		}
+
+		select {
+		case <-c.Context().Done():
+			return c.Context().Err()
+		case <-time.After(time.Duration(x.Interval) * time.Second):
+		}
+	}
+}
+
+// labelRule describes which labels should be applied to the repositories
+// matching the pattern under the project.
+type labelRule struct {
+	ProjectID int    `yaml:"project_id"`
+	Pattern   string `yaml:"pattern"`
+	LabelIDs  []int  `yaml:"label_ids"`
+}
+
+type labelRules struct {
+	Rules []labelRule `yaml:"rules"`
+}
+
+type labelBrief struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type repoBrief struct {
+	ID        int           `json:"id"`
+	Name      string        `json:"name"`
+	ProjectID int           `json:"project_id"`
+	Labels    []*labelBrief `json:"labels"`
+}
+
+// labelRulesLoad loads label rules from the rules file, patterns are
+// validated before any request is issued.
+func labelRulesLoad(file string) (*labelRules, error) {
+	var rules labelRules
+
+	dataBytes, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	err = yaml.Unmarshal([]byte(dataBytes), &rules)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, r := range rules.Rules {
+		if r.ProjectID <= 0 {
+			return nil, fmt.Errorf("rule #%d: project_id is required", i+1)
+		}
+		if _, err := path.Match(r.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("rule #%d: bad pattern %q: %v", i+1, r.Pattern, err)
+		}
+		if len(r.LabelIDs) == 0 {
+			return nil, fmt.Errorf("rule #%d: label_ids is required", i+1)
+		}
+	}
+
+	return &rules, nil
+}
+
+// reposOfProject lists all repositories of a project page by page.
+func reposOfProject(c *harbor.Client, prj *projectBrief) ([]*repoBrief, error) {
+	targetURL := c.APIURL("/repositories") + "?project_id=" + strconv.Itoa(prj.ProjectID)
+	if c.IsV2() {
+		targetURL = c.APIURL("/projects") + "/" + url.PathEscape(prj.Name) + "/repositories"
+	}
+
+	items, err := c.FetchAllPages(targetURL)
+	if err != nil {
+		return nil, err
+	}
+
+	all := make([]*repoBrief, 0, len(items))
+	for _, it := range items {
+		var r repoBrief
+		if err := json.Unmarshal(it, &r); err != nil {
+			return nil, err
+		}
+		all = append(all, &r)
+	}
+
+	return all, nil
+}
+
+// labelRulesApply does one pass over the projects referenced by rules.
+func labelRulesApply(c *harbor.Client, rules *labelRules, seen map[string]bool) error {
+	for _, rule := range rules.Rules {
+		prj, err := projectByID(c, rule.ProjectID)
+		if err != nil {
+			return err
+		}
+		repos, err := reposOfProject(c, prj)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range repos {
+			key := strconv.Itoa(rule.ProjectID) + "|" + rule.Pattern + "|" + r.Name
+			if seen[key] {
+				continue
+			}
+
+			// The pattern is checked against both the full name (prj/repo)
+			// and the name without project prefix.
+			full, _ := path.Match(rule.Pattern, r.Name)
+			short, _ := path.Match(rule.Pattern, path.Base(r.Name))
+			if !full && !short {
+				seen[key] = true
+				continue
+			}
+
+			if !c.IsV2() {
+				if err := labelAdd(c, repoURL(c, r.Name)+"/labels", rule.LabelIDs, r.Labels); err != nil {
+					return err
+				}
+				seen[key] = true
+				continue
+			}
+
+			// Repositories have no labels with v2.0 API, their artifacts
+			// are labeled instead, the newly pushed ones on every pass.
+			arts, err := artifactsOfRepo(c, r.Name)
+			if err != nil {
+				return err
+			}
+			for _, a := range arts {
+				if seen[key+"@"+a.Digest] {
+					continue
+				}
+				targetURL := repoURL(c, r.Name) + "/artifacts/" + TagPath(a.Digest) + "/labels"
+				if err := labelAdd(c, targetURL, rule.LabelIDs, a.Labels); err != nil {
+					return err
+				}
+				seen[key+"@"+a.Digest] = true
+			}
+		}
+	}
+
+	return nil
+}
+
+// labelAdd adds the labels of ids not in has, by posting them to
+// targetURL.
+func labelAdd(c *harbor.Client, targetURL string, ids []int, has []*labelBrief) error {
+	for _, id := range ids {
+		if hasLabel(has, id) {
+			continue
+		}
+
+		c.Trace("==> POST", targetURL, "(label_id: "+strconv.Itoa(id)+")")
+
+		t, err := json.Marshal(&labelBrief{ID: id})
+		if err != nil {
+			return err
+		}
+
+		if _, err := c.Do(c.Post(targetURL).Send(string(t))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
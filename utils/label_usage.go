@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/moooofly/harbor-go-client/model"
+)
+
+func init() {
+	Parser.AddCommand("label_usage",
+		"List the resources carrying a label.",
+		"List every resource carrying the label --id, in all projects for a system label or in the project of a project label: artifacts with v2.0 API, repositories and tags with v1 API, and the versions of the Helm charts of the projects having some; e.g. to see what deleting the label would affect. Resources failing to be listed are reported at the end.",
+		&labelUsage)
+}
+
+type labelUsageRun struct {
+	ID int `short:"i" long:"id" description:"(REQUIRED) The ID of the label." required:"yes"`
+}
+
+var labelUsage labelUsageRun
+
+func (x *labelUsageRun) Execute(args []string) error {
+	c := NewClient()
+
+	var label model.Label
+	if err := c.GetJSON(c.APIURL("/labels")+"/"+strconv.Itoa(x.ID), &label); err != nil {
+		return err
+	}
+
+	var projects []*model.Project
+	if label.Scope == "p" {
+		var prj model.Project
+		if err := c.GetJSON(c.APIURL("/projects")+"/"+strconv.FormatInt(label.ProjectID, 10), &prj); err != nil {
+			return err
+		}
+		projects = append(projects, &prj)
+	} else {
+		var err error
+		if projects, err = allProjects(c); err != nil {
+			return err
+		}
+	}
+
+	used := 0
+	w := &labelWalker{c: c, visit: func(name, labelsURL string, labels []model.Label) {
+		for _, l := range labels {
+			if l.ID == label.ID {
+				fmt.Println(name)
+				used++
+				return
+			}
+		}
+	}}
+	for _, prj := range projects {
+		w.project(prj)
+	}
+
+	fmt.Printf("%d resources carry label %q\n", used, label.Name)
+	if len(w.failed) > 0 {
+		for _, f := range w.failed {
+			fmt.Println("failed:", f)
+		}
+		return fmt.Errorf("%d failures, more resources may carry label %q", len(w.failed), label.Name)
+	}
+	return nil
+}
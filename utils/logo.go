@@ -14,5 +14,5 @@ const logo = `
 
 // PrintLogo print logo.
 func PrintLogo() {
-	fmt.Println(logo)
+	fmt.Print(logo + "\n")
 }
@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/moooofly/harbor-go-client/model"
+)
+
+// ManifestInfo is the readable summary of the manifest of an image, or of
+// a manifest list whose images are then in Manifests: its layers and their
+// sizes, and its config.
+type ManifestInfo struct {
+	Artifact    string             `json:"artifact"`
+	Digest      string             `json:"digest"`
+	MediaType   string             `json:"media_type"`
+	Platform    string             `json:"platform,omitempty"`
+	Created     model.Time         `json:"created"`
+	Size        int64              `json:"size"`
+	Config      *model.Descriptor  `json:"config,omitempty"`
+	Layers      []model.Descriptor `json:"layers,omitempty"`
+	Manifests   []model.Descriptor `json:"manifests,omitempty"`
+	ImageConfig *model.ImageConfig `json:"image_config,omitempty"`
+
+	// Raw is the manifest as sent by Harbor.
+	Raw []byte `json:"-"`
+}
+
+// NewManifestInfo returns the summary of the manifest m of artifact, with
+// the config of the image, nil for a manifest list. Size is the size of the
+// config and the compressed layers, of the images for a manifest list.
+func NewManifestInfo(artifact, digest string, m *model.Manifest, cfg *model.ImageConfig, raw []byte) *ManifestInfo {
+	info := &ManifestInfo{
+		Artifact:    artifact,
+		Digest:      digest,
+		MediaType:   m.MediaType,
+		Config:      m.Config,
+		Layers:      m.Layers,
+		Manifests:   m.Manifests,
+		ImageConfig: cfg,
+		Raw:         raw,
+	}
+	if info.MediaType == "" {
+		// OCI manifests may leave their media type out.
+		info.MediaType = "application/vnd.oci.image.manifest.v1+json"
+		if len(m.Manifests) > 0 {
+			info.MediaType = "application/vnd.oci.image.index.v1+json"
+		}
+	}
+	if m.Config != nil {
+		info.Size += m.Config.Size
+	}
+	for _, l := range m.Layers {
+		info.Size += l.Size
+	}
+	for _, d := range m.Manifests {
+		info.Size += d.Size
+	}
+	if cfg != nil {
+		info.Created = cfg.Created
+		info.Platform = (&model.Platform{OS: cfg.OS, Architecture: cfg.Architecture, Variant: cfg.Variant}).String()
+	}
+	return info
+}
+
+// PrintManifest prints the summary of a manifest: the images of a manifest
+// list, or the layers and the config of an image.
+func PrintManifest(info *ManifestInfo) {
+	fmt.Printf("Artifact %s (%s)\n", info.Artifact, info.Digest)
+	fmt.Println("Media type:", info.MediaType)
+
+	if len(info.Manifests) > 0 {
+		fmt.Printf("Manifest list of %d images, inspect one with --platform:\n", len(info.Manifests))
+		line := "+----------------------+-------------------------------------------------------------------------+------------+"
+		fmt.Println(line)
+		fmt.Printf("| % -20s | % -71s | % -10s |\n", "Platform", "Digest", "Size")
+		fmt.Println(line)
+		for _, d := range info.Manifests {
+			platform := ""
+			if d.Platform != nil {
+				platform = d.Platform.String()
+			}
+			fmt.Printf("| % -20s | % -71s | % -10s |\n", platform, d.Digest, humanSize(d.Size))
+		}
+		fmt.Println(line)
+		return
+	}
+
+	if info.ImageConfig != nil {
+		fmt.Println("Platform:", info.Platform)
+		if !info.Created.IsZero() {
+			fmt.Println("Created:", info.Created.Format("2006-01-02 15:04:05 MST"))
+		}
+		if info.ImageConfig.Author != "" {
+			fmt.Println("Author:", info.ImageConfig.Author)
+		}
+	}
+	fmt.Printf("Size: %s in %d layers\n", humanSize(info.Size), len(info.Layers))
+	if info.Config != nil {
+		fmt.Printf("Config: %s (%s)\n", info.Config.Digest, humanSize(info.Config.Size))
+	}
+
+	line := "+-----+-------------------------------------------------------------------------+------------+"
+	fmt.Println(line)
+	fmt.Printf("| % -3s | % -71s | % -10s |\n", "#", "Layer", "Size")
+	fmt.Println(line)
+	for i, l := range info.Layers {
+		fmt.Printf("| % 3d | % -71s | % -10s |\n", i+1, l.Digest, humanSize(l.Size))
+	}
+	fmt.Println(line)
+
+	if info.ImageConfig == nil || info.ImageConfig.Config == nil {
+		return
+	}
+	cc := info.ImageConfig.Config
+	printList := func(name string, values []string) {
+		if len(values) > 0 {
+			fmt.Printf("%s: %s\n", name, strings.Join(values, " "))
+		}
+	}
+	printList("Entrypoint", cc.Entrypoint)
+	printList("Cmd", cc.Cmd)
+	if cc.WorkingDir != "" {
+		fmt.Println("WorkingDir:", cc.WorkingDir)
+	}
+	if cc.User != "" {
+		fmt.Println("User:", cc.User)
+	}
+	printList("ExposedPorts", sortedKeys(cc.ExposedPorts))
+	printList("Volumes", sortedKeys(cc.Volumes))
+	for _, env := range cc.Env {
+		fmt.Println("Env:", env)
+	}
+	labels := make([]string, 0, len(cc.Labels))
+	for k := range cc.Labels {
+		labels = append(labels, k)
+	}
+	sort.Strings(labels)
+	for _, k := range labels {
+		fmt.Printf("Label: %s=%s\n", k, cc.Labels[k])
+	}
+}
+
+// sortedKeys returns the keys of a set, sorted.
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// HistoryStep is a step of the build of an image, with the layer it made
+// and its size, if any.
+type HistoryStep struct {
+	Created    model.Time `json:"created"`
+	CreatedBy  string     `json:"created_by"`
+	Comment    string     `json:"comment,omitempty"`
+	EmptyLayer bool       `json:"empty_layer"`
+	Layer      string     `json:"layer,omitempty"`
+	Size       int64      `json:"size"`
+}
+
+// NewImageHistory returns the steps of the build history of an image,
+// the steps which made a layer matched with the layers of its manifest in
+// order. Layers are left out if they do not match, e.g. for a squashed
+// image.
+func NewImageHistory(history []model.ImageHistory, layers []model.Descriptor) []*HistoryStep {
+	nonEmpty := 0
+	for _, h := range history {
+		if !h.EmptyLayer {
+			nonEmpty++
+		}
+	}
+	match := nonEmpty == len(layers)
+
+	steps := make([]*HistoryStep, 0, len(history))
+	i := 0
+	for _, h := range history {
+		step := &HistoryStep{Created: h.Created, CreatedBy: h.CreatedBy, Comment: h.Comment, EmptyLayer: h.EmptyLayer}
+		if !h.EmptyLayer && match {
+			step.Layer, step.Size = layers[i].Digest, layers[i].Size
+			i++
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// PrintImageHistory prints the build history of an image, first step
+// first, the commands cut to 60 characters unless noTrunc is set.
+func PrintImageHistory(steps []*HistoryStep, noTrunc bool) {
+	line := "+-----+----------------------+------------+--------------------------------------------------------------+"
+	fmt.Println(line)
+	fmt.Printf("| % -3s | % -20s | % -10s | % -60s |\n", "#", "Created", "Size", "Created By")
+	fmt.Println(line)
+	for i, s := range steps {
+		created := ""
+		if !s.Created.IsZero() {
+			created = s.Created.Format("2006-01-02 15:04:05")
+		}
+		size := "-"
+		if !s.EmptyLayer {
+			size = humanSize(s.Size)
+		}
+		by := strings.TrimSpace(strings.TrimPrefix(s.CreatedBy, "/bin/sh -c #(nop) "))
+		if r := []rune(by); !noTrunc && len(r) > 60 {
+			by = string(r[:57]) + "..."
+		}
+		fmt.Printf("| % 3d | % -20s | % -10s | % -60s |\n", i+1, created, size, by)
+	}
+	fmt.Println(line)
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	Parser.AddCommand("metrics_get",
+		"Get Prometheus metrics of Harbor components.",
+		"Fetch Prometheus metrics exposed by Harbor 2.2+ (core, exporter, registry, jobservice) and optionally filter them by metric name, for quick health numbers without a Prometheus server.",
+		&metricsGet)
+	RequireComponent("metrics_get", ComponentMetrics)
+}
+
+type metricsGetRun struct {
+	Component string `short:"c" long:"component" description:"The component whose metrics to get, all components if not set." validate:"oneof=core|exporter|registry|jobservice"`
+	Port      int    `short:"p" long:"port" description:"The metrics port configured in harbor.yml." default:"9090"`
+	Path      string `long:"path" description:"The metrics path configured in harbor.yml." default:"/metrics"`
+	Filter    string `short:"f" long:"filter" description:"Only print metrics whose name matches this regular expression."`
+	NoHelp    bool   `long:"no_help" description:"Drop the '# HELP' and '# TYPE' comment lines."`
+	Username  string `short:"u" long:"username" description:"Username for basic auth, if the metrics endpoint is protected."`
+	Password  string `long:"password" env:"HARBOR_METRICS_PASSWORD" description:"Password for basic auth."`
+}
+
+var metricsGet metricsGetRun
+
+func (x *metricsGetRun) Execute(args []string) error {
+	var filter *regexp.Regexp
+	if x.Filter != "" {
+		var err error
+		if filter, err = regexp.Compile(x.Filter); err != nil {
+			return fmt.Errorf("bad filter: %v", err)
+		}
+	}
+
+	targetURL, err := MetricsURL(x.Port, x.Path, x.Component)
+	if err != nil {
+		return err
+	}
+	fmt.Println("==> GET", targetURL)
+
+	req := NewClient().Get(targetURL)
+	if x.Username != "" {
+		req = req.SetBasicAuth(x.Username, x.Password)
+	}
+	resp, body, errs := req.End()
+	for _, e := range errs {
+		if e != nil {
+			return e
+		}
+	}
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	metricsPrint(body, filter, x.NoHelp)
+	return nil
+}
+
+// MetricsURL generates the URL of the metrics endpoint, which is served on
+// its own port of the Harbor host.
+func MetricsURL(port int, path, component string) (string, error) {
+	config, err := generalConfigLoad()
+	if err != nil {
+		return "", err
+	}
+
+	host := config.Dstip
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	targetURL := config.Scheme + "://" + net.JoinHostPort(host, strconv.Itoa(port)) + path
+	if component != "" {
+		targetURL += "?comp=" + component
+	}
+	return targetURL, nil
+}
+
+// metricsPrint prints metrics in Prometheus text format, keeping the
+// samples (and their comment lines) whose name matches filter.
+func metricsPrint(body string, filter *regexp.Regexp, noHelp bool) {
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		name := line
+		isComment := strings.HasPrefix(line, "#")
+		if isComment {
+			// # HELP name ... / # TYPE name ...
+			fields := strings.Fields(line)
+			if len(fields) < 3 {
+				continue
+			}
+			name = fields[2]
+		} else if i := strings.IndexAny(line, "{ "); i >= 0 {
+			name = line[:i]
+		}
+
+		if isComment && noHelp {
+			continue
+		}
+		if filter != nil && !filter.MatchString(name) {
+			continue
+		}
+		fmt.Println(line)
+	}
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"reflect"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+// commandModels maps command name to the model of its response, or of the
+// items of the response for list commands.
+var commandModels = map[string]reflect.Type{}
+
+// ResponseModel declares the model of the response of command, of its
+// items for list commands, e.g. ResponseModel("labels_list", model.Label{}).
+// With --output, the response is decoded into the model before it is
+// rendered, so the fields printed are those of the model, whichever version
+// of Harbor answered. The raw response is printed as is.
+func ResponseModel(command string, v interface{}) {
+	commandModels[command] = reflect.TypeOf(v)
+}
+
+// activeModel returns the model of the running command, nil if it has none.
+func activeModel() reflect.Type {
+	if Parser.Active == nil {
+		return nil
+	}
+	return commandModels[Parser.Active.Name]
+}
+
+// throughModel decodes the JSON read from r into values of t, an object
+// into one, an array item by item, and returns them encoded again.
+func throughModel(t reflect.Type, r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	first, err := peekNonSpace(br)
+	if err != nil || (first != '[' && first != '{') {
+		return br
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		if first == '{' {
+			raw, err := ioutil.ReadAll(br)
+			if err == nil {
+				raw, err = modelJSON(t, raw)
+			}
+			if err == nil {
+				_, err = pw.Write(raw)
+			}
+			pw.CloseWithError(err)
+			return
+		}
+
+		sep := "["
+		err := harbor.EachItem(br, func(item json.RawMessage) error {
+			b, err := modelJSON(t, item)
+			if err != nil {
+				return err
+			}
+			io.WriteString(pw, sep)
+			sep = ","
+			_, err = pw.Write(b)
+			return err
+		})
+		if err == nil {
+			if sep == "[" {
+				io.WriteString(pw, "[")
+			}
+			_, err = io.WriteString(pw, "]")
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
+
+func modelJSON(t reflect.Type, raw []byte) ([]byte, error) {
+	v := reflect.New(t).Interface()
+	if err := json.Unmarshal(raw, v); err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
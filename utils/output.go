@@ -0,0 +1,445 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Output formats of --output, the raw response is printed when none is
+// given. go-template and jsonpath take their template after a '=', e.g.
+// "jsonpath={.items[*].id}".
+const (
+	OutputJSON       = "json"
+	OutputYAML       = "yaml"
+	OutputTable      = "table"
+	OutputGoTemplate = "go-template"
+	OutputJSONPath   = "jsonpath"
+)
+
+// splitOutput splits an --output value into the format and its template.
+func splitOutput(output string) (format, tmpl string) {
+	if i := strings.IndexByte(output, '='); i >= 0 {
+		return output[:i], output[i+1:]
+	}
+	return output, ""
+}
+
+// checkOutput rejects an unknown --output format, or a bad template, before
+// any request is issued.
+func checkOutput() error {
+	format, tmpl := splitOutput(GlobalOpts.Output)
+	switch format {
+	case "", OutputJSON, OutputYAML, OutputTable:
+		if tmpl == "" {
+			return nil
+		}
+	case OutputGoTemplate:
+		_, err := template.New("output").Parse(tmpl)
+		return err
+	case OutputJSONPath:
+		_, err := parseJSONPath(tmpl)
+		return err
+	}
+	return fmt.Errorf(T("unknown output format %q, expected one of json, yaml, table, go-template=TEMPLATE or jsonpath=TEMPLATE"), GlobalOpts.Output)
+}
+
+// printOutput prints the body of the response in the --output format, the
+// body of a failed response goes to stderr.
+func printOutput(res *harbor.Result, err error) error {
+	if err != nil {
+		if res != nil {
+			res.WriteTo(os.Stderr)
+			fmt.Fprintln(os.Stderr)
+		}
+		return err
+	}
+	defer res.Close()
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+
+	counter := &itemCounter{r: bytes.NewReader(res.Body)}
+	if res.Stream != nil {
+		counter.r = res.Stream
+	}
+	var body io.Reader = counter
+	if t := activeModel(); t != nil {
+		body = throughModel(t, body)
+	}
+	if err := Render(w, GlobalOpts.Output, body); err != nil {
+		return err
+	}
+	// The data is on stdout, the hint goes to stderr.
+	if hint := PageHint(res.Pagination(), counter.count()); hint != "" {
+		w.Flush()
+		fmt.Fprintln(os.Stderr, hint)
+	}
+	return nil
+}
+
+// Render writes the JSON read from r in the format of output, an --output
+// value. Arrays are rendered item by item, except for tables whose columns
+// have to be aligned and for jsonpath, where they are the items of the
+// root: '{.items[*].name}'. Go templates are executed for every item of
+// arrays. Bodies which are not JSON are copied as is.
+func Render(w io.Writer, output string, r io.Reader) error {
+	format, tmpl := splitOutput(output)
+	br := bufio.NewReader(r)
+	first, err := peekNonSpace(br)
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if first != '[' && first != '{' {
+		_, err := io.Copy(w, br)
+		return err
+	}
+
+	switch {
+	case format == OutputGoTemplate:
+		return renderGoTemplate(w, tmpl, first == '[', br)
+	case format == OutputJSONPath:
+		return renderJSONPath(w, tmpl, first == '[', br)
+	case first == '[' && format == OutputJSON:
+		return renderJSONArray(w, br)
+	case first == '[' && format == OutputYAML:
+		return renderYAMLArray(w, br)
+	case format == OutputJSON:
+		return renderJSON(w, br)
+	}
+
+	v, err := decodeOrdered(json.NewDecoder(br))
+	if err != nil {
+		return err
+	}
+	switch format {
+	case OutputYAML:
+		return renderYAML(w, v)
+	default:
+		return renderTable(w, v)
+	}
+}
+
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			br.ReadByte()
+		default:
+			return b[0], nil
+		}
+	}
+}
+
+func renderJSONArray(w io.Writer, r io.Reader) error {
+	first := true
+	err := harbor.EachItem(r, func(item json.RawMessage) error {
+		sep := ",\n  "
+		if first {
+			sep, first = "[\n  ", false
+		}
+		io.WriteString(w, sep)
+		var b bytes.Buffer
+		if err := json.Indent(&b, item, "  ", "  "); err != nil {
+			return err
+		}
+		_, err := b.WriteTo(w)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if first {
+		_, err = io.WriteString(w, "[]\n")
+	} else {
+		_, err = io.WriteString(w, "\n]\n")
+	}
+	return err
+}
+
+func renderYAMLArray(w io.Writer, r io.Reader) error {
+	n := 0
+	err := harbor.EachItem(r, func(item json.RawMessage) error {
+		n++
+		v, err := decodeOrdered(json.NewDecoder(bytes.NewReader(item)))
+		if err != nil {
+			return err
+		}
+		return renderYAML(w, []interface{}{v})
+	})
+	if err == nil && n == 0 {
+		_, err = io.WriteString(w, "[]\n")
+	}
+	return err
+}
+
+func renderJSON(w io.Writer, r io.Reader) error {
+	raw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	if err := json.Indent(&b, raw, "", "  "); err != nil {
+		return err
+	}
+	b.WriteByte('\n')
+	_, err = b.WriteTo(w)
+	return err
+}
+
+func renderGoTemplate(w io.Writer, tmpl string, array bool, r io.Reader) error {
+	t, err := template.New("output").Parse(tmpl)
+	if err != nil {
+		return err
+	}
+	execute := func(raw []byte) error {
+		var data interface{}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		if err := dec.Decode(&data); err != nil {
+			return err
+		}
+		var b bytes.Buffer
+		if err := t.Execute(&b, data); err != nil {
+			return err
+		}
+		return writeLine(w, &b)
+	}
+
+	if array {
+		return harbor.EachItem(r, func(item json.RawMessage) error {
+			return execute(item)
+		})
+	}
+	raw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return execute(raw)
+}
+
+func renderJSONPath(w io.Writer, tmpl string, array bool, r io.Reader) error {
+	p, err := parseJSONPath(tmpl)
+	if err != nil {
+		return err
+	}
+	var data interface{}
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if err := dec.Decode(&data); err != nil {
+		return err
+	}
+	if array {
+		data = map[string]interface{}{"items": data}
+	}
+	var b bytes.Buffer
+	if err := p.Execute(&b, data); err != nil {
+		return err
+	}
+	return writeLine(w, &b)
+}
+
+// writeLine writes the output of a template, ending it with a newline
+// unless it does already.
+func writeLine(w io.Writer, b *bytes.Buffer) error {
+	if b.Len() > 0 && !bytes.HasSuffix(b.Bytes(), []byte("\n")) {
+		b.WriteByte('\n')
+	}
+	_, err := b.WriteTo(w)
+	return err
+}
+
+func renderYAML(w io.Writer, v interface{}) error {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// renderTable renders an array of objects with a column per scalar field,
+// and an object with a row per field.
+func renderTable(w io.Writer, v interface{}) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	switch v := v.(type) {
+	case []interface{}:
+		var columns []string
+		seen := map[string]bool{}
+		for _, item := range v {
+			obj, ok := item.(yaml.MapSlice)
+			if !ok {
+				fmt.Fprintln(tw, cell(item))
+				continue
+			}
+			for _, kv := range obj {
+				key := fmt.Sprint(kv.Key)
+				if isScalar(kv.Value) && !seen[key] {
+					seen[key] = true
+					columns = append(columns, key)
+				}
+			}
+		}
+		if len(columns) > 0 {
+			fmt.Fprintln(tw, strings.ToUpper(strings.Join(columns, "\t")))
+		}
+		for _, item := range v {
+			obj, ok := item.(yaml.MapSlice)
+			if !ok {
+				continue
+			}
+			fields := map[string]interface{}{}
+			for _, kv := range obj {
+				fields[fmt.Sprint(kv.Key)] = kv.Value
+			}
+			row := make([]string, len(columns))
+			for i, c := range columns {
+				row[i] = cell(fields[c])
+			}
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+	case yaml.MapSlice:
+		fmt.Fprintln(tw, "FIELD\tVALUE")
+		for _, kv := range v {
+			fmt.Fprintf(tw, "%v\t%s\n", kv.Key, cell(kv.Value))
+		}
+	default:
+		fmt.Fprintln(tw, cell(v))
+	}
+	return tw.Flush()
+}
+
+func isScalar(v interface{}) bool {
+	switch v.(type) {
+	case yaml.MapSlice, []interface{}:
+		return false
+	}
+	return true
+}
+
+// cell formats a value for a table cell, nested values as compact JSON.
+func cell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case yaml.MapSlice, []interface{}:
+		b, _ := json.Marshal(jsonValue(v))
+		s := string(b)
+		if len([]rune(s)) > 60 {
+			s = string([]rune(s)[:56]) + " ..."
+		}
+		return s
+	default:
+		return strings.Replace(fmt.Sprint(v), "\n", " ", -1)
+	}
+}
+
+// decodeOrdered decodes the next JSON value of dec, objects as
+// yaml.MapSlice to keep the order of their fields.
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		switch tok {
+		case '{':
+			obj := yaml.MapSlice{}
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				val, err := decodeOrdered(dec)
+				if err != nil {
+					return nil, err
+				}
+				obj = append(obj, yaml.MapItem{Key: key, Value: val})
+			}
+			_, err := dec.Token()
+			return obj, err
+		case '[':
+			arr := []interface{}{}
+			for dec.More() {
+				val, err := decodeOrdered(dec)
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, val)
+			}
+			_, err := dec.Token()
+			return arr, err
+		}
+		return nil, fmt.Errorf("unexpected %v", tok)
+	case json.Number:
+		if n, err := tok.Int64(); err == nil {
+			return n, nil
+		}
+		return tok.Float64()
+	default:
+		return tok, nil
+	}
+}
+
+// orderedObject marshals a yaml.MapSlice as a JSON object, in order.
+type orderedObject yaml.MapSlice
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, kv := range o {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, err := json.Marshal(fmt.Sprint(kv.Key))
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(jsonValue(kv.Value))
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(val)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// jsonValue prepares a value of decodeOrdered for json.Marshal.
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		return orderedObject(v)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = jsonValue(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/moooofly/harbor-go-client/utils/term"
+)
+
+// noPagerCommands are read-only commands which talk to the user, or wait
+// and print as they go, they are never paged.
+var noPagerCommands = map[string]bool{
+	"login":        true,
+	"logout":       true,
+	"schedule_run": true,
+	"serve":        true,
+	"wait_healthy": true,
+}
+
+// defaultPager is the pager when neither HARBOR_PAGER nor PAGER is set.
+const defaultPager = "less"
+
+// startPager pipes the output of the read-only command name through the
+// pager, like git does, when stdin and stdout are terminals and --no-pager
+// is not given. It returns the function waiting for the pager to quit,
+// which passes the error of the command through, but the broken pipe of a
+// pager quit before the end of the output. With the default LESS=FRX, less
+// quits right away when the output fits on the screen, so only long output
+// is paged.
+func startPager(name string) (stop func(error) error) {
+	stop = func(err error) error { return err }
+	if GlobalOpts.NoPager || !readOnlyCommands[name] || noPagerCommands[name] ||
+		!term.IsTerminal(os.Stdin.Fd()) || !term.IsTerminal(os.Stdout.Fd()) {
+		return stop
+	}
+
+	pager, ok := os.LookupEnv("HARBOR_PAGER")
+	if !ok {
+		if pager, ok = os.LookupEnv("PAGER"); !ok {
+			pager = defaultPager
+		}
+	}
+	args := strings.Fields(pager)
+	if len(args) == 0 || args[0] == "cat" {
+		return stop
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return stop
+	}
+	cmd.Stdin = r
+	err = cmd.Start()
+	r.Close()
+	if err != nil {
+		w.Close()
+		return stop
+	}
+
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout = w
+	if term.IsTerminal(stderr.Fd()) {
+		os.Stderr = w
+	}
+	return func(err error) error {
+		os.Stdout, os.Stderr = stdout, stderr
+		w.Close()
+		cmd.Wait()
+		if errors.Is(err, syscall.EPIPE) {
+			return nil
+		}
+		return err
+	}
+}
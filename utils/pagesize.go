@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"strconv"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+// maxPageSize returns the maximum page_size of the target, as probed by
+// 'capabilities', or harbor.MaxPageSize if it has not been probed.
+func maxPageSize() int {
+	if caps := CapabilitiesLoad(); caps != nil && caps.MaxPageSize > 0 {
+		return caps.MaxPageSize
+	}
+	return harbor.MaxPageSize
+}
+
+// SetDefaultPageSize makes 'page_size' in conf/config.yaml the default of
+// the --page_size flag of all list commands.
+func SetDefaultPageSize() {
+	config, err := generalConfigLoad()
+	if err != nil || config.PageSize <= 0 {
+		return
+	}
+
+	for _, cmd := range Parser.Commands() {
+		if opt := cmd.FindOptionByLongName("page_size"); opt != nil {
+			opt.Default = []string{strconv.Itoa(config.PageSize)}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+// FetchAllPagesInto requests all pages of a list endpoint like
+// harbor.Client.FetchAllPages and decodes the items into v, a pointer to a
+// slice.
+func FetchAllPagesInto(c *harbor.Client, targetURL string, v interface{}) error {
+	items, err := c.FetchAllPages(targetURL)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// itemCounter counts the items of the JSON array read through it, the
+// reads are passed on as is.
+type itemCounter struct {
+	r io.Reader
+
+	items    int
+	array    bool
+	depth    int
+	inString bool
+	escaped  bool
+	awaiting bool
+}
+
+func (c *itemCounter) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	for _, b := range p[:n] {
+		if c.inString {
+			switch {
+			case c.escaped:
+				c.escaped = false
+			case b == '\\':
+				c.escaped = true
+			case b == '"':
+				c.inString = false
+			}
+			continue
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n', ':':
+		case ',':
+			c.awaiting = c.depth == 1
+		case ']', '}':
+			c.depth--
+		case '[', '{':
+			if c.depth == 0 && b == '[' {
+				c.array, c.awaiting = true, true
+			} else {
+				c.value()
+			}
+			c.depth++
+		case '"':
+			c.value()
+			c.inString = true
+		default:
+			c.value()
+		}
+	}
+	return n, err
+}
+
+// value records a value starting, an item of the array at depth 1.
+func (c *itemCounter) value() {
+	if c.depth == 1 && c.awaiting {
+		c.items++
+		c.awaiting = false
+	}
+}
+
+// count returns the number of items read, -1 if the body is not an array.
+func (c *itemCounter) count() int {
+	if !c.array {
+		return -1
+	}
+	return c.items
+}
+
+// PageHint tells which part of a list was shown, shown items of the page
+// of p, and how to list the others with the flags of the running command.
+// It is empty when the list was shown in full, or its paging is unknown.
+func PageHint(p *harbor.Pagination, shown int) string {
+	if p == nil || shown < 0 || (p.Page <= 1 && p.Next == "" && (p.Total < 0 || shown >= p.Total)) {
+		return ""
+	}
+
+	hint := Tf("showing %d (page %d)", shown, p.Page)
+	if p.Total >= 0 {
+		hint = Tf("showing %d of %d (page %d)", shown, p.Total, p.Page)
+	}
+	if Parser.Active == nil {
+		return hint
+	}
+	if p.Next != "" && Parser.Active.FindOptionByLongName("page") != nil {
+		hint += ", " + Tf("next page: --page %d", p.Page+1)
+	}
+	if Parser.Active.FindOptionByLongName("all") != nil {
+		hint += ", " + T("all pages: --all")
+	}
+	return hint
+}
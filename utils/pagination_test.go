@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestItemCounter(t *testing.T) {
+	tests := []struct {
+		body string
+		want int
+	}{
+		{`[]`, 0},
+		{` [ ] `, 0},
+		{`[1,2,3]`, 3},
+		{"[\n  1,\n  2\n]\n", 2},
+		{`[null, true, false, -1.5e3]`, 4},
+		{`[""]`, 1},
+		{`[{}]`, 1},
+		{`[[1, 2], [3]]`, 2},
+		{`[{"name": "a", "tags": [1, 2]}, {"name": "b"}]`, 2},
+		{`[{"name": "a,b]"}, "c,d[", "e{"]`, 3},
+		{`["a\"],", "b\\", "c"]`, 3},
+		{`{"items": [1, 2]}`, -1},
+		{`"[1, 2]"`, -1},
+		{``, -1},
+	}
+	for _, tt := range tests {
+		// Read a byte at a time too, values are split across reads.
+		for _, r := range []io.Reader{strings.NewReader(tt.body), iotest.OneByteReader(strings.NewReader(tt.body))} {
+			c := &itemCounter{r: r}
+			b, err := ioutil.ReadAll(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.body {
+				t.Errorf("%s: read %q", tt.body, b)
+			}
+			if got := c.count(); got != tt.want {
+				t.Errorf("%s: count() = %d, want %d", tt.body, got, tt.want)
+			}
+		}
+	}
+}
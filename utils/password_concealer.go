@@ -2,13 +2,20 @@ package utils
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"strings"
 
 	"github.com/moooofly/harbor-go-client/utils/term"
 )
 
+// ErrNotATerminal is returned when a hidden prompt is requested but stdin is
+// not attached to a terminal.
+var ErrNotATerminal = errors.New("stdin is not a terminal, can not prompt for password")
+
 func readInput(in io.Reader, out io.Writer) string {
 	reader := bufio.NewReader(in)
 	line, _, err := reader.ReadLine()
@@ -21,13 +28,28 @@ func readInput(in io.Reader, out io.Writer) string {
 
 // ReadPasswordFromTerm gets user password from stdin without showing on screen
 func ReadPasswordFromTerm() (string, error) {
+	return ReadSecretFromTerm("Password: ")
+}
+
+// ReadSecretFromTerm prints prompt and reads a secret from stdin without
+// showing on screen. It works on linux, darwin/bsd and windows consoles.
+//
+// In non-interactive mode, or when stdin is not a terminal, it fails fast
+// instead of blocking on a prompt nobody will answer.
+func ReadSecretFromTerm(prompt string) (string, error) {
+	if err := PromptAllowed(prompt); err != nil {
+		return "", err
+	}
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return "", ErrNotATerminal
+	}
 
 	oldState, err := term.SaveState(os.Stdin.Fd())
 	if err != nil {
 		return "", err
 	}
 
-	fmt.Fprintf(os.Stdout, "Password: ")
+	fmt.Fprint(os.Stdout, prompt)
 
 	err = term.DisableEcho(os.Stdin.Fd(), oldState)
 	if err != nil {
@@ -44,3 +66,23 @@ func ReadPasswordFromTerm() (string, error) {
 
 	return passwd, nil
 }
+
+// ReadPasswordFromStdin reads a password piped through stdin, e.g.
+// `echo $HARBOR_PASSWORD | harbor-go-client login -u admin --password-stdin`.
+func ReadPasswordFromStdin() (string, error) {
+	dataBytes, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(dataBytes), "\r\n"), nil
+}
+
+// PromptAllowed returns an actionable error if the CLI runs in non-interactive
+// mode, what describes the input which would have been prompted for.
+func PromptAllowed(what string) error {
+	if GlobalOpts.NonInteractive {
+		return fmt.Errorf(T("non-interactive mode: refusing to prompt for %q, provide it via flags or environment instead"),
+			strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(what), ":")))
+	}
+	return nil
+}
@@ -0,0 +1,299 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
+)
+
+func init() {
+	Parser.AddCommand("preflight_scan",
+		"Scan a local image in a quarantine project before promoting it.",
+		"Push a local image (tarball from 'docker save', or image ID/digest/reference known by the local docker daemon) to a quarantine project, wait for the vulnerability scan, report the result and delete the image again. Requires docker CLI, logged in to the Harbor registry.",
+		&preflight)
+}
+
+type preflightScan struct {
+	Image      string `short:"i" long:"image" description:"(REQUIRED) Image tarball file, or image ID/digest/reference of local docker daemon." required:"yes"`
+	Project    string `short:"j" long:"project" description:"The quarantine project to push to." default:"quarantine"`
+	Timeout    int    `short:"t" long:"timeout" description:"Seconds to wait for the scan to finish." default:"600"`
+	Keep       bool   `short:"k" long:"keep" description:"Do not delete the pushed image after scanning."`
+	FailOnHigh bool   `long:"fail_on_high" description:"Exit with non-zero code if high severity vulnerabilities are found."`
+}
+
+var preflight preflightScan
+
+func (x *preflightScan) Execute(args []string) error {
+	return preflightRun()
+}
+
+// scanOverview is the scan_overview of a tag (v1 API).
+type scanOverview struct {
+	ScanStatus string `json:"scan_status"`
+	JobID      int    `json:"job_id"`
+	UpdateTime string `json:"update_time"`
+	Severity   int    `json:"severity"`
+	Components struct {
+		Total   int `json:"total"`
+		Summary []struct {
+			Severity int `json:"severity"`
+			Count    int `json:"count"`
+		} `json:"summary"`
+	} `json:"components"`
+}
+
+type tagScanInfo struct {
+	Name         string        `json:"name"`
+	Digest       string        `json:"digest"`
+	ScanOverview *scanOverview `json:"scan_overview"`
+}
+
+// severityNames maps the v1 severity levels to readable names.
+var severityNames = map[int]string{
+	1: "None",
+	2: "Unknown",
+	3: "Low",
+	4: "Medium",
+	5: "High",
+}
+
+// docker runs docker CLI and returns its combined output.
+func docker(args ...string) (string, error) {
+	fmt.Println("==> docker", strings.Join(args, " "))
+	out, err := exec.Command("docker", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("docker %s: %v: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// preflightSource resolves the image to be pushed, loading it into the
+// local docker daemon first when a tarball is given.
+func preflightSource(image string) (string, error) {
+	if _, err := os.Stat(image); err != nil {
+		return image, nil
+	}
+
+	out, err := docker("load", "-i", image)
+	if err != nil {
+		return "", err
+	}
+
+	// "Loaded image: name:tag" or "Loaded image ID: sha256:..."
+	lines := strings.Split(out, "\n")
+	last := lines[len(lines)-1]
+	i := strings.LastIndex(last, ": ")
+	if i < 0 {
+		return "", fmt.Errorf("unexpected output of docker load: %s", out)
+	}
+	return strings.TrimSpace(last[i+2:]), nil
+}
+
+// preflightRepoName derives repository name from the source image.
+func preflightRepoName(src string) string {
+	name := src
+	if i := strings.Index(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name = name[:i]
+	}
+	name = path.Base(name)
+	if name == "" || strings.HasPrefix(name, "sha256") || !strings.ContainsAny(name, "abcdefghijklmnopqrstuvwxyz") {
+		name = "preflight"
+	}
+	return name
+}
+
+func preflightRun() (err error) {
+	config, err := generalConfigLoad()
+	if err != nil {
+		return err
+	}
+
+	c := NewClient()
+	src, err := preflightSource(preflight.Image)
+	if err != nil {
+		return err
+	}
+
+	repoName := preflight.Project + "/" + preflightRepoName(src)
+	tag := "preflight-" + time.Now().UTC().Format("20060102150405")
+	ref := config.Dstip + "/" + repoName + ":" + tag
+
+	if _, err := docker("tag", src, ref); err != nil {
+		return err
+	}
+	defer docker("rmi", ref)
+
+	if _, err := docker("push", ref); err != nil {
+		return err
+	}
+
+	// The pushed image is deleted: its tag with v1 API, which deletes the
+	// image along, its artifact by digest with v2.0 API.
+	delURL := repoURL(c, repoName) + "/tags/" + TagPath(tag)
+	scanURL := delURL + "/scan"
+	infoURL := delURL
+	if c.IsV2() {
+		artURL := repoURL(c, repoName) + "/artifacts/" + TagPath(tag)
+		var art struct {
+			Digest string `json:"digest"`
+		}
+		if err := c.GetJSON(artURL, &art); err != nil {
+			return fmt.Errorf("%v, %s is left in project %s", err, ref, preflight.Project)
+		}
+		delURL = repoURL(c, repoName) + "/artifacts/" + TagPath(art.Digest)
+		scanURL = artURL + "/scan"
+		infoURL = artURL + "?with_scan_overview=true"
+	}
+	if !preflight.Keep {
+		defer func() {
+			// The image is deleted after an interrupt or a timeout too.
+			dc := c.WithContext(context.Background())
+			dc.Trace("==> DELETE", delURL)
+			if _, derr := dc.Do(dc.Delete(delURL)); derr != nil {
+				derr = fmt.Errorf("%s not deleted: %v", ref, derr)
+				if err == nil {
+					err = derr
+				} else {
+					fmt.Fprintln(os.Stderr, "warning:", derr)
+				}
+			}
+		}()
+	}
+
+	// The project may scan on push already, the explicit trigger is harmless.
+	c.Trace("==> POST", scanURL)
+	if _, err := c.Do(c.Post(scanURL)); err != nil {
+		return err
+	}
+
+	deadline := time.After(time.Duration(preflight.Timeout) * time.Second)
+	for {
+		s, err := preflightSummaryGet(c, infoURL)
+		if err != nil {
+			return err
+		}
+
+		if s != nil {
+			switch s.Status {
+			case "finished":
+				return preflightReport(ref, s)
+			case "error", "stopped":
+				return fmt.Errorf("scan of %s ended with status %q", ref, s.Status)
+			}
+		}
+
+		select {
+		case <-c.Context().Done():
+			return c.Context().Err()
+		case <-deadline:
+			return fmt.Errorf("scan of %s not finished in %d seconds", ref, preflight.Timeout)
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// preflightSummary is the scan overview of the pushed image, the same for
+// both API versions. Status is finished, error or stopped once the scan
+// ended, Counts are by severity name.
+type preflightSummary struct {
+	Status   string
+	Digest   string
+	Severity string
+	// TotalOf tells what Total counts: components with v1 API,
+	// vulnerabilities with v2.0 API.
+	TotalOf string
+	Total   int
+	Counts  map[string]int
+}
+
+// preflightSummaryGet gets the scan overview of infoURL, nil if the image
+// is not scanned yet.
+func preflightSummaryGet(c *harbor.Client, infoURL string) (*preflightSummary, error) {
+	if !c.IsV2() {
+		var info tagScanInfo
+		if err := c.GetJSON(infoURL, &info); err != nil {
+			return nil, err
+		}
+		so := info.ScanOverview
+		if so == nil {
+			return nil, nil
+		}
+		s := &preflightSummary{
+			Status:   so.ScanStatus,
+			Digest:   info.Digest,
+			Severity: severityNames[so.Severity],
+			TotalOf:  "Components",
+			Total:    so.Components.Total,
+			Counts:   map[string]int{},
+		}
+		for _, sum := range so.Components.Summary {
+			s.Counts[severityNames[sum.Severity]] += sum.Count
+		}
+		return s, nil
+	}
+
+	var art struct {
+		Digest       string                                `json:"digest"`
+		ScanOverview map[string]*model.NativeReportSummary `json:"scan_overview"`
+	}
+	if err := c.GetJSON(infoURL, &art); err != nil {
+		return nil, err
+	}
+	for _, so := range art.ScanOverview {
+		if so == nil {
+			continue
+		}
+		s := &preflightSummary{
+			Status:   strings.ToLower(so.ScanStatus),
+			Digest:   art.Digest,
+			Severity: so.Severity,
+			TotalOf:  "Vulnerabilities",
+			Counts:   map[string]int{},
+		}
+		if s.Status == "success" {
+			s.Status = "finished"
+		}
+		if so.Summary != nil {
+			s.Total = so.Summary.Total
+			s.Counts = so.Summary.Summary
+		}
+		return s, nil
+	}
+	return nil, nil
+}
+
+func preflightReport(ref string, s *preflightSummary) error {
+	fmt.Println("+----------------------+------------------------------------------+")
+	fmt.Printf("| % -20s | % -40s |\n", "Image", ref)
+	fmt.Printf("| % -20s | % -40s |\n", "Digest", s.Digest)
+	fmt.Printf("| % -20s | % -40s |\n", "Overall Severity", s.Severity)
+	fmt.Printf("| % -20s | % -40d |\n", s.TotalOf, s.Total)
+	fmt.Println("+----------------------+------------------------------------------+")
+	var severities []string
+	for severity := range s.Counts {
+		severities = append(severities, severity)
+	}
+	sort.Slice(severities, func(i, j int) bool {
+		return SeverityRank(severities[i]) > SeverityRank(severities[j])
+	})
+	for _, severity := range severities {
+		fmt.Printf("| % -20s | % -40d |\n", severity, s.Counts[severity])
+	}
+	fmt.Println("+----------------------+------------------------------------------+")
+
+	if preflight.FailOnHigh && SeverityRank(s.Severity) >= severityRanks["High"] {
+		return fmt.Errorf("high severity vulnerabilities found in %s", ref)
+	}
+	return nil
+}
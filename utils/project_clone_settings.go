@@ -0,0 +1,367 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
+)
+
+func init() {
+	Parser.AddCommand("project_clone_settings",
+		"Copy the settings of a project to another one.",
+		"Copy the settings of the project --from to the project --to, so that a new project inherits a golden configuration: metadata (public, vulnerability policy, auto scan...), CVE allowlist, webhook policies, tag retention policy, immutable tag rules and project labels. Webhook policies and labels already in --to with the same name, and identical immutable rules, are kept as they are; the retention policy of --to is replaced. (Harbor v2.0+, uses v2.0 API)",
+		&projectCloneSettings)
+}
+
+type projectCloneSettingsRun struct {
+	From   string   `long:"from" description:"(REQUIRED) The name of the project to copy the settings of." required:"yes"`
+	To     string   `long:"to" description:"(REQUIRED) The name of the project to copy the settings to." required:"yes"`
+	Only   []string `long:"only" description:"Copy only these settings, may be repeated. All settings if not set." choice:"metadata" choice:"cve_allowlist" choice:"webhooks" choice:"retention" choice:"immutable" choice:"labels"`
+	DryRun bool     `long:"dry-run" description:"Print the changes only."`
+}
+
+var projectCloneSettings projectCloneSettingsRun
+
+// cloneProject is a project with its metadata as they are, unknown keys
+// included, to copy them all.
+type cloneProject struct {
+	ProjectID    int64               `json:"project_id"`
+	Name         string              `json:"name"`
+	Metadata     map[string]string   `json:"metadata"`
+	CVEAllowlist *model.CVEAllowlist `json:"cve_allowlist"`
+
+	url string
+}
+
+// settingsCloner copies the settings of a project to another one, and
+// keeps count of the copies.
+type settingsCloner struct {
+	c        *harbor.Client
+	src, dst *cloneProject
+	dryRun   bool
+
+	copied int
+}
+
+func (x *projectCloneSettingsRun) Execute(args []string) error {
+	if x.From == x.To {
+		return fmt.Errorf("--from and --to are the same project")
+	}
+
+	c := NewClient()
+	src, err := cloneProjectGet(c, x.From)
+	if err != nil {
+		return err
+	}
+	dst, err := cloneProjectGet(c, x.To)
+	if err != nil {
+		return err
+	}
+
+	s := &settingsCloner{c: c, src: src, dst: dst, dryRun: x.DryRun}
+	steps := []struct {
+		name string
+		copy func() error
+	}{
+		{"metadata", s.metadata},
+		{"cve_allowlist", s.cveAllowlist},
+		{"webhooks", s.webhooks},
+		{"retention", s.retention},
+		{"immutable", s.immutableRules},
+		{"labels", s.labels},
+	}
+
+	var failed []string
+	for _, step := range steps {
+		if len(x.Only) > 0 && !containsString(x.Only, step.name) {
+			continue
+		}
+		if err := step.copy(); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", step.name, err))
+		}
+	}
+
+	fmt.Printf("%d settings copied from %q to %q\n", s.copied, x.From, x.To)
+	if len(failed) > 0 {
+		for _, f := range failed {
+			fmt.Println("failed:", f)
+		}
+		return fmt.Errorf("%d settings failed to be copied", len(failed))
+	}
+	return nil
+}
+
+// metadata copies the metadata, but the retention policy ID which is
+// copied with the policy.
+func (s *settingsCloner) metadata() error {
+	keys := make([]string, 0, len(s.src.Metadata))
+	for k := range s.src.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	md := map[string]string{}
+	for _, k := range keys {
+		v := s.src.Metadata[k]
+		if k == "retention_id" || s.dst.Metadata[k] == v {
+			continue
+		}
+		fmt.Printf("metadata %s: %q -> %q\n", k, s.dst.Metadata[k], v)
+		md[k] = v
+	}
+	if len(md) == 0 || s.dryRun {
+		s.copied += len(md)
+		return nil
+	}
+
+	if err := s.putProject(map[string]interface{}{"metadata": md}); err != nil {
+		return err
+	}
+	s.copied += len(md)
+	return nil
+}
+
+// cveAllowlist replaces the CVE allowlist.
+func (s *settingsCloner) cveAllowlist() error {
+	list := &model.CVEAllowlist{Items: []model.CVEAllowlistItem{}}
+	if s.src.CVEAllowlist != nil {
+		list.Items = append(list.Items, s.src.CVEAllowlist.Items...)
+		list.ExpiresAt = s.src.CVEAllowlist.ExpiresAt
+	}
+	if cur := s.dst.CVEAllowlist; cur != nil && reflect.DeepEqual(cur.ExpiresAt, list.ExpiresAt) &&
+		(len(cur.Items) == 0 && len(list.Items) == 0 || reflect.DeepEqual(cur.Items, list.Items)) {
+		return nil
+	}
+
+	fmt.Printf("cve allowlist: %d CVEs\n", len(list.Items))
+	s.copied++
+	if s.dryRun {
+		return nil
+	}
+	return s.putProject(map[string]interface{}{
+		"cve_allowlist": map[string]interface{}{
+			"items":      list.Items,
+			"expires_at": list.ExpiresAt,
+		},
+	})
+}
+
+// webhooks copies the webhook policies missing by name.
+func (s *settingsCloner) webhooks() error {
+	return s.copyNamed("webhook policy", "/webhook/policies", "id", "project_id", "creator", "creation_time", "update_time")
+}
+
+// immutableRules copies the immutable tag rules missing.
+func (s *settingsCloner) immutableRules() error {
+	strip := []string{"id", "project_id"}
+	have, err := s.list(s.dst.url+"/immutabletagrules", strip)
+	if err != nil {
+		return err
+	}
+	rules, err := s.list(s.src.url+"/immutabletagrules", strip)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range rules {
+		b, _ := json.Marshal(r)
+		dup := false
+		for _, h := range have {
+			hb, _ := json.Marshal(h)
+			dup = dup || string(hb) == string(b)
+		}
+		if dup {
+			continue
+		}
+
+		fmt.Printf("+ immutable rule %s\n", b)
+		s.copied++
+		if s.dryRun {
+			continue
+		}
+		r["project_id"] = s.dst.ProjectID
+		targetURL := s.dst.url + "/immutabletagrules"
+		s.c.Trace("==> POST", targetURL)
+		if _, err := s.c.Do(s.c.Post(targetURL).Send(r)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retention replaces the tag retention policy.
+func (s *settingsCloner) retention() error {
+	id := s.src.Metadata["retention_id"]
+	if id == "" {
+		return nil
+	}
+
+	var policy map[string]interface{}
+	if err := s.c.GetJSON(s.c.URL("/api/v2.0/retentions")+"/"+url.PathEscape(id), &policy); err != nil {
+		return err
+	}
+	delete(policy, "id")
+	policy["scope"] = map[string]interface{}{"level": "project", "ref": s.dst.ProjectID}
+	if trigger, ok := policy["trigger"].(map[string]interface{}); ok {
+		delete(trigger, "references")
+	}
+	if rules, ok := policy["rules"].([]interface{}); ok {
+		for _, r := range rules {
+			if rule, ok := r.(map[string]interface{}); ok {
+				delete(rule, "id")
+			}
+		}
+	}
+
+	fmt.Printf("retention policy %s\n", id)
+	s.copied++
+	if s.dryRun {
+		return nil
+	}
+
+	if dstID := s.dst.Metadata["retention_id"]; dstID != "" {
+		n, err := strconv.ParseInt(dstID, 10, 64)
+		if err != nil {
+			return fmt.Errorf("bad retention_id %q of project %q", dstID, s.dst.Name)
+		}
+		policy["id"] = n
+		targetURL := s.c.URL("/api/v2.0/retentions") + "/" + url.PathEscape(dstID)
+		s.c.Trace("==> PUT", targetURL)
+		_, err = s.c.Do(s.c.Put(targetURL).Send(policy))
+		return err
+	}
+	targetURL := s.c.URL("/api/v2.0/retentions")
+	s.c.Trace("==> POST", targetURL)
+	_, err := s.c.Do(s.c.Post(targetURL).Send(policy))
+	return err
+}
+
+// labels copies the project labels missing by name.
+func (s *settingsCloner) labels() error {
+	have, err := s.projectLabels(s.dst)
+	if err != nil {
+		return err
+	}
+	names := map[string]bool{}
+	for _, l := range have {
+		names[l.Name] = true
+	}
+
+	labels, err := s.projectLabels(s.src)
+	if err != nil {
+		return err
+	}
+	for _, l := range labels {
+		if names[l.Name] {
+			continue
+		}
+
+		fmt.Printf("+ label %q\n", l.Name)
+		s.copied++
+		if s.dryRun {
+			continue
+		}
+		targetURL := s.c.URL("/api/v2.0/labels")
+		s.c.Trace("==> POST", targetURL)
+		body := map[string]interface{}{
+			"name":        l.Name,
+			"description": l.Description,
+			"color":       l.Color,
+			"scope":       "p",
+			"project_id":  s.dst.ProjectID,
+		}
+		if _, err := s.c.Do(s.c.Post(targetURL).Send(body)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// projectLabels returns the labels of the project prj.
+func (s *settingsCloner) projectLabels(prj *cloneProject) ([]*model.Label, error) {
+	var labels []*model.Label
+	err := s.c.EachPage(s.c.URL("/api/v2.0/labels")+"?scope=p&project_id="+strconv.FormatInt(prj.ProjectID, 10), func(item json.RawMessage) error {
+		var l model.Label
+		if err := json.Unmarshal(item, &l); err != nil {
+			return err
+		}
+		labels = append(labels, &l)
+		return nil
+	})
+	return labels, err
+}
+
+// copyNamed copies the items of the project list endpoint path missing by
+// name, without the keys strip.
+func (s *settingsCloner) copyNamed(kind, path string, strip ...string) error {
+	have, err := s.list(s.dst.url+path, strip)
+	if err != nil {
+		return err
+	}
+	names := map[interface{}]bool{}
+	for _, h := range have {
+		names[h["name"]] = true
+	}
+
+	items, err := s.list(s.src.url+path, strip)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if names[item["name"]] {
+			continue
+		}
+
+		fmt.Printf("+ %s %q\n", kind, item["name"])
+		s.copied++
+		if s.dryRun {
+			continue
+		}
+		targetURL := s.dst.url + path
+		s.c.Trace("==> POST", targetURL)
+		if _, err := s.c.Do(s.c.Post(targetURL).Send(item)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// list returns the items of a list endpoint, without the keys strip.
+func (s *settingsCloner) list(targetURL string, strip []string) ([]map[string]interface{}, error) {
+	var items []map[string]interface{}
+	err := s.c.EachPage(targetURL, func(raw json.RawMessage) error {
+		var item map[string]interface{}
+		if err := json.Unmarshal(raw, &item); err != nil {
+			return err
+		}
+		for _, k := range strip {
+			delete(item, k)
+		}
+		items = append(items, item)
+		return nil
+	})
+	return items, err
+}
+
+// putProject updates the destination project.
+func (s *settingsCloner) putProject(body interface{}) error {
+	targetURL := s.c.URL("/api/v2.0/projects") + "/" + strconv.FormatInt(s.dst.ProjectID, 10)
+	s.c.Trace("==> PUT", targetURL)
+	_, err := s.c.Do(s.c.Put(targetURL).Send(body))
+	return err
+}
+
+// cloneProjectGet returns the project named name.
+func cloneProjectGet(c *harbor.Client, name string) (*cloneProject, error) {
+	prj := &cloneProject{url: c.URL("/api/v2.0/projects") + "/" + url.PathEscape(name)}
+	if err := c.GetJSON(prj.url, prj); err != nil {
+		return nil, err
+	}
+	return prj, nil
+}
@@ -0,0 +1,297 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/model"
+)
+
+func init() {
+	Parser.AddCommand("project_inventory",
+		"Export artifact inventory of a project.",
+		"Capture every repository, artifact (digest), tag, size and label of a project into a JSON file of stable schema, for DR documentation and cross-checking after migrations.",
+		&prjInventory)
+}
+
+type projectInventory struct {
+	Project string `short:"p" long:"project" description:"(REQUIRED) The name of the project." required:"yes"`
+	Output  string `short:"o" long:"output" description:"The inventory file, '-' for stdout." default:"inventory.json"`
+}
+
+var prjInventory projectInventory
+
+func (x *projectInventory) Execute(args []string) error {
+	inv, err := InventoryCollect(NewDataClient(), x.Project)
+	if err != nil {
+		return err
+	}
+
+	return inventorySave(inv, x.Output)
+}
+
+// InventorySchemaVersion is bumped on every incompatible change of Inventory.
+const InventorySchemaVersion = 1
+
+// Inventory is the artifact inventory of a project. Repositories, artifacts,
+// tags and labels are all sorted, so two inventories of the same content
+// are byte-identical.
+type Inventory struct {
+	SchemaVersion int                    `json:"schema_version"`
+	GeneratedAt   string                 `json:"generated_at"`
+	Harbor        string                 `json:"harbor"`
+	Project       InventoryProject       `json:"project"`
+	Repositories  []*InventoryRepository `json:"repositories"`
+}
+
+// InventoryProject identifies the project an inventory is taken from.
+type InventoryProject struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// InventoryRepository is a repository and its artifacts.
+type InventoryRepository struct {
+	Name      string               `json:"name"`
+	Labels    []string             `json:"labels"`
+	Artifacts []*InventoryArtifact `json:"artifacts"`
+}
+
+// InventoryArtifact is an artifact identified by digest, with all the tags
+// pointing to it.
+type InventoryArtifact struct {
+	Digest string   `json:"digest"`
+	Size   int64    `json:"size"`
+	Tags   []string `json:"tags"`
+	Labels []string `json:"labels"`
+}
+
+type projectBrief struct {
+	ProjectID int    `json:"project_id"`
+	Name      string `json:"name"`
+}
+
+type tagBrief struct {
+	Digest string        `json:"digest"`
+	Name   string        `json:"name"`
+	Size   int64         `json:"size"`
+	Labels []*labelBrief `json:"labels"`
+}
+
+// artifactBrief is an artifact of a repository, an image with v1 API, with
+// all the tags pointing to it.
+type artifactBrief struct {
+	Digest string
+	Size   int64
+	Tags   []string
+	Labels []*labelBrief
+}
+
+// projectByName looks up a project by its exact name.
+func projectByName(c *harbor.Client, name string) (*projectBrief, error) {
+	var prjs []*projectBrief
+
+	targetURL := c.APIURL("/projects") + "?name=" + url.QueryEscape(name)
+	if err := c.GetJSON(targetURL, &prjs); err != nil {
+		return nil, err
+	}
+
+	for _, p := range prjs {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("project %q not found", name)
+}
+
+// projectByID looks up a project by its ID.
+func projectByID(c *harbor.Client, id int) (*projectBrief, error) {
+	var prj projectBrief
+	if err := c.GetJSON(c.APIURL("/projects")+"/"+strconv.Itoa(id), &prj); err != nil {
+		return nil, err
+	}
+	return &prj, nil
+}
+
+// repoURL returns the URL of a repository, by its full name, with the API
+// version of c.
+func repoURL(c *harbor.Client, repoName string) string {
+	if !c.IsV2() {
+		return c.APIURL("/repositories") + "/" + RepoPath(repoName)
+	}
+	project, repo := repoName, ""
+	if i := strings.Index(repoName, "/"); i >= 0 {
+		project, repo = repoName[:i], repoName[i+1:]
+	}
+	return c.APIURL("/projects") + "/" + url.PathEscape(project) + "/repositories/" + RepoPathV2(repo)
+}
+
+// artifactsOfRepo lists the artifacts of a repository. With v1 API, they
+// are the images of its tags, grouped by digest.
+func artifactsOfRepo(c *harbor.Client, repoName string) ([]*artifactBrief, error) {
+	var arts []*artifactBrief
+	if c.IsV2() {
+		items, err := c.FetchAllPages(repoURL(c, repoName) + "/artifacts?with_tag=true&with_label=true")
+		if err != nil {
+			return nil, err
+		}
+		for _, it := range items {
+			var a model.Artifact
+			if err := json.Unmarshal(it, &a); err != nil {
+				return nil, err
+			}
+			ab := &artifactBrief{Digest: a.Digest, Size: a.Size}
+			for _, t := range a.Tags {
+				ab.Tags = append(ab.Tags, t.Name)
+			}
+			for _, l := range a.Labels {
+				ab.Labels = append(ab.Labels, &labelBrief{ID: int(l.ID), Name: l.Name})
+			}
+			arts = append(arts, ab)
+		}
+		return arts, nil
+	}
+
+	var tags []*tagBrief
+	if err := c.GetJSON(repoURL(c, repoName)+"/tags", &tags); err != nil {
+		return nil, err
+	}
+	byDigest := make(map[string]*artifactBrief)
+	for _, t := range tags {
+		a, ok := byDigest[t.Digest]
+		if !ok {
+			a = &artifactBrief{Digest: t.Digest, Size: t.Size}
+			byDigest[t.Digest] = a
+			arts = append(arts, a)
+		}
+		a.Tags = append(a.Tags, t.Name)
+		for _, l := range t.Labels {
+			if !hasLabel(a.Labels, l.ID) {
+				a.Labels = append(a.Labels, l)
+			}
+		}
+	}
+	return arts, nil
+}
+
+// tagsOfRepo lists all tags of a repository.
+func tagsOfRepo(c *harbor.Client, repoName string) ([]string, error) {
+	arts, err := artifactsOfRepo(c, repoName)
+	if err != nil {
+		return nil, err
+	}
+	var tags []string
+	for _, a := range arts {
+		tags = append(tags, a.Tags...)
+	}
+	return tags, nil
+}
+
+func hasLabel(labels []*labelBrief, id int) bool {
+	for _, l := range labels {
+		if l.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func labelNames(labels []*labelBrief) []string {
+	names := []string{}
+	for _, l := range labels {
+		names = append(names, l.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// InventoryCollect takes the artifact inventory of the project.
+func InventoryCollect(c *harbor.Client, project string) (*Inventory, error) {
+	prj, err := projectByName(c, project)
+	if err != nil {
+		return nil, err
+	}
+
+	repos, err := reposOfProject(c, prj)
+	if err != nil {
+		return nil, err
+	}
+
+	inv := &Inventory{
+		SchemaVersion: InventorySchemaVersion,
+		GeneratedAt:   time.Now().UTC().Format(time.RFC3339),
+		Harbor:        c.BaseURL(),
+		Project:       InventoryProject{ID: prj.ProjectID, Name: prj.Name},
+		Repositories:  []*InventoryRepository{},
+	}
+
+	for _, r := range repos {
+		arts, err := artifactsOfRepo(c, r.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		ir := &InventoryRepository{
+			Name:      r.Name,
+			Labels:    labelNames(r.Labels),
+			Artifacts: []*InventoryArtifact{},
+		}
+		for _, a := range arts {
+			tags := append([]string{}, a.Tags...)
+			sort.Strings(tags)
+			ir.Artifacts = append(ir.Artifacts, &InventoryArtifact{
+				Digest: a.Digest,
+				Size:   a.Size,
+				Tags:   tags,
+				Labels: labelNames(a.Labels),
+			})
+		}
+		sort.Slice(ir.Artifacts, func(i, j int) bool {
+			return ir.Artifacts[i].Digest < ir.Artifacts[j].Digest
+		})
+
+		inv.Repositories = append(inv.Repositories, ir)
+	}
+
+	sort.Slice(inv.Repositories, func(i, j int) bool {
+		return inv.Repositories[i].Name < inv.Repositories[j].Name
+	})
+
+	return inv, nil
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func inventorySave(inv *Inventory, file string) error {
+	data, err := json.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if file == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	if err := ioutil.WriteFile(file, data, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("inventory of %d repositories saved to %s\n", len(inv.Repositories), file)
+	return nil
+}
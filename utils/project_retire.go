@@ -0,0 +1,417 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func init() {
+	Parser.AddCommand("project_retire",
+		"Retire a project and delete it after N days.",
+		"Retire a project instead of deleting it right away: make it private, label its repositories as retired, revoke its members and remove its robot accounts (no more pushes), then schedule the deletion after N days in a local state file. Run with --purge (e.g. from cron) to delete the projects which are due.",
+		&prjRetire)
+}
+
+type projectRetire struct {
+	Project string `short:"p" long:"project" description:"The name of the project to retire."`
+	Days    int    `short:"d" long:"days" description:"Delete the project after N days." default:"30" validate:"min=0"`
+	Cancel  bool   `long:"cancel" description:"Cancel the scheduled deletion of the project. (the project is not restored)"`
+	List    bool   `short:"l" long:"list" description:"List retired projects and when they will be deleted."`
+	Purge   bool   `long:"purge" description:"Delete the retired projects which are due, along with their repositories."`
+	Verbose bool   `short:"v" long:"verbose" description:"Trace the requests to stderr."`
+}
+
+var prjRetire projectRetire
+
+// retiredLabel is the project label put on every repository of a retired
+// project.
+const retiredLabel = "retired"
+
+var retirefile = "conf/.retire.yaml"
+
+type retiredProject struct {
+	ProjectID   int    `yaml:"project_id"`
+	Name        string `yaml:"name"`
+	RetiredAt   string `yaml:"retired_at"`
+	DeleteAfter string `yaml:"delete_after"`
+}
+
+type retireState struct {
+	Projects []*retiredProject `yaml:"projects"`
+}
+
+type memberBrief struct {
+	ID         int    `json:"id"`
+	EntityName string `json:"entity_name"`
+	RoleID     int    `json:"role_id"`
+}
+
+type robotBrief struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type userBrief struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+}
+
+func (x *projectRetire) Execute(args []string) error {
+	var trace io.Writer
+	if x.Verbose {
+		trace = os.Stderr
+	}
+
+	var err error
+	switch {
+	case x.List:
+		err = retireList()
+	case x.Purge:
+		err = retirePurge(newClient(trace))
+	case x.Project == "":
+		err = fmt.Errorf("--project is required")
+	case x.Cancel:
+		err = retireCancel(x.Project)
+	default:
+		err = retireProject(newClient(trace), x.Project, x.Days)
+	}
+
+	return err
+}
+
+func retireStateLoad() (*retireState, error) {
+	var state retireState
+
+	dataBytes, err := ioutil.ReadFile(retirefile)
+	if os.IsNotExist(err) {
+		return &state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(dataBytes, &state); err != nil {
+		return nil, fmt.Errorf("%s: %v", retirefile, err)
+	}
+	return &state, nil
+}
+
+func retireStateSave(state *retireState) error {
+	dataBytes, err := yaml.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(retirefile, dataBytes, 0600)
+}
+
+// sendJSON issues a request with a JSON body (if any) and fails on any
+// non-2xx status.
+func sendJSON(c *harbor.Client, method, targetURL string, body interface{}) error {
+	c.Trace("==>", method, targetURL)
+
+	req := c.Request(method, targetURL)
+	if body != nil {
+		t, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		req = req.Send(string(t))
+	}
+
+	resp, respBody, errs := req.End()
+	for _, e := range errs {
+		if e != nil {
+			return e
+		}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: unexpected status %s: %s", method, targetURL, resp.Status, respBody)
+	}
+	return nil
+}
+
+func retireProject(c *harbor.Client, name string, days int) error {
+	state, err := retireStateLoad()
+	if err != nil {
+		return err
+	}
+	for _, p := range state.Projects {
+		if p.Name == name {
+			return fmt.Errorf("project %s is retired already, deletion after %s", name, p.DeleteAfter)
+		}
+	}
+
+	// Everything is looked up before the first change, so that a failing
+	// lookup leaves the project as it is.
+	prj, err := projectByName(c, name)
+	if err != nil {
+		return err
+	}
+	prjURL := c.APIURL("/projects") + "/" + strconv.Itoa(prj.ProjectID)
+
+	label, err := retireLabel(c, prj.ProjectID)
+	if err != nil {
+		return err
+	}
+	targets, err := retireLabelTargets(c, prj)
+	if err != nil {
+		return err
+	}
+
+	// The current user keeps access to clean up.
+	var cur userBrief
+	if err := c.GetJSON(c.APIURL("/users/current"), &cur); err != nil {
+		return err
+	}
+	// Members and robot accounts are all listed before any is removed,
+	// removing them while paging would shift the pages and skip some.
+	var members []*memberBrief
+	if err := FetchAllPagesInto(c, prjURL+"/members", &members); err != nil {
+		return err
+	}
+	// Harbor before v1.8 has no robot accounts.
+	var robots []*robotBrief
+	if err := FetchAllPagesInto(c, prjURL+"/robots", &robots); err != nil {
+		fmt.Fprintln(os.Stderr, "warning: robot accounts not removed:", err)
+	}
+
+	// The steps done, told on failure since the project is then left
+	// half-retired.
+	var done []string
+	fail := func(err error) error {
+		if len(done) == 0 {
+			return err
+		}
+		return fmt.Errorf("%v, project %s is half-retired, done already: %s", err, name, strings.Join(done, ", "))
+	}
+
+	// 1. private
+	if err := sendJSON(c, http.MethodPut, prjURL+"/metadatas/public",
+		map[string]string{"public": "false"}); err != nil {
+		return fail(err)
+	}
+	done = append(done, "made private")
+
+	// 2. label repositories
+	if label == nil {
+		if label, err = retireLabelCreate(c, prj.ProjectID); err != nil {
+			return fail(err)
+		}
+		done = append(done, "label created")
+	}
+	for _, t := range targets {
+		if hasLabel(t.labels, label.ID) {
+			continue
+		}
+		if err := sendJSON(c, http.MethodPost, t.url, &labelBrief{ID: label.ID}); err != nil {
+			return fail(err)
+		}
+	}
+	done = append(done, "repositories labeled")
+
+	// 3. revoke members
+	for _, m := range members {
+		if m.EntityName == cur.Username {
+			continue
+		}
+		if err := sendJSON(c, http.MethodDelete, prjURL+"/members/"+strconv.Itoa(m.ID), nil); err != nil {
+			return fail(err)
+		}
+	}
+	done = append(done, "members revoked")
+
+	// 4. remove robot accounts, there is no other way to deny pushes.
+	for _, r := range robots {
+		if err := sendJSON(c, http.MethodDelete, prjURL+"/robots/"+strconv.Itoa(r.ID), nil); err != nil {
+			return fail(err)
+		}
+	}
+	done = append(done, "robot accounts removed")
+
+	// 5. schedule deletion
+	now := time.Now().UTC()
+	rp := &retiredProject{
+		ProjectID:   prj.ProjectID,
+		Name:        prj.Name,
+		RetiredAt:   now.Format(time.RFC3339),
+		DeleteAfter: now.AddDate(0, 0, days).Format(time.RFC3339),
+	}
+	state.Projects = append(state.Projects, rp)
+	if err := retireStateSave(state); err != nil {
+		return fail(err)
+	}
+
+	fmt.Printf("==> project %s retired, it will be deleted by 'project_retire --purge' after %s\n", rp.Name, rp.DeleteAfter)
+	return nil
+}
+
+// retireLabelsURL is the URL of the retired label of the project.
+func retireLabelsURL(c *harbor.Client, projectID int) string {
+	return c.APIURL("/labels") + "?" + url.Values{
+		"scope":      {"p"},
+		"project_id": {strconv.Itoa(projectID)},
+		"name":       {retiredLabel},
+	}.Encode()
+}
+
+// retireLabel returns the retired label of the project, nil if it does not
+// exist yet.
+func retireLabel(c *harbor.Client, projectID int) (*labelBrief, error) {
+	var labels []*labelBrief
+	if err := c.GetJSON(retireLabelsURL(c, projectID), &labels); err != nil {
+		return nil, err
+	}
+	for _, l := range labels {
+		if l.Name == retiredLabel {
+			return l, nil
+		}
+	}
+	return nil, nil
+}
+
+// retireLabelCreate creates the retired label of the project.
+func retireLabelCreate(c *harbor.Client, projectID int) (*labelBrief, error) {
+	if err := sendJSON(c, http.MethodPost, c.APIURL("/labels"), map[string]interface{}{
+		"name":        retiredLabel,
+		"description": "The project is retired and will be deleted.",
+		"color":       "#A9B6BE",
+		"scope":       "p",
+		"project_id":  projectID,
+	}); err != nil {
+		return nil, err
+	}
+	label, err := retireLabel(c, projectID)
+	if err == nil && label == nil {
+		err = fmt.Errorf("label %q not found after creation", retiredLabel)
+	}
+	return label, err
+}
+
+// retireLabelTarget is where the retired label is posted to, and the
+// labels there already.
+type retireLabelTarget struct {
+	url    string
+	labels []*labelBrief
+}
+
+// retireLabelTargets lists where the retired label goes: every repository
+// of the project, every artifact with v2.0 API where repositories have no
+// labels.
+func retireLabelTargets(c *harbor.Client, prj *projectBrief) ([]*retireLabelTarget, error) {
+	repos, err := reposOfProject(c, prj)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []*retireLabelTarget
+	for _, r := range repos {
+		if !c.IsV2() {
+			targets = append(targets, &retireLabelTarget{url: repoURL(c, r.Name) + "/labels", labels: r.Labels})
+			continue
+		}
+
+		arts, err := artifactsOfRepo(c, r.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range arts {
+			targets = append(targets, &retireLabelTarget{
+				url:    repoURL(c, r.Name) + "/artifacts/" + TagPath(a.Digest) + "/labels",
+				labels: a.Labels,
+			})
+		}
+	}
+	return targets, nil
+}
+
+func retireCancel(name string) error {
+	state, err := retireStateLoad()
+	if err != nil {
+		return err
+	}
+
+	for i, p := range state.Projects {
+		if p.Name == name {
+			state.Projects = append(state.Projects[:i], state.Projects[i+1:]...)
+			if err := retireStateSave(state); err != nil {
+				return err
+			}
+			fmt.Printf("==> deletion of project %s cancelled, members and robot accounts have to be restored by hand\n", name)
+			return nil
+		}
+	}
+	return fmt.Errorf("project %s is not retired", name)
+}
+
+func retireList() error {
+	state, err := retireStateLoad()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("+------------+--------------------------------+----------------------+----------------------+")
+	fmt.Printf("| % -10s | % -30s | % -20s | % -20s |\n", "ProjectID", "Name", "RetiredAt", "DeleteAfter")
+	fmt.Println("+------------+--------------------------------+----------------------+----------------------+")
+	for _, p := range state.Projects {
+		fmt.Printf("| % -10d | % -30s | % -20s | % -20s |\n", p.ProjectID, p.Name, p.RetiredAt, p.DeleteAfter)
+	}
+	fmt.Println("+------------+--------------------------------+----------------------+----------------------+")
+	return nil
+}
+
+// retirePurge deletes the retired projects which are due. Harbor refuses
+// to delete a project with repositories, they are deleted first.
+func retirePurge(c *harbor.Client) error {
+	state, err := retireStateLoad()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	pending := state.Projects
+	var kept []*retiredProject
+	for i, p := range pending {
+		due, err := time.Parse(time.RFC3339, p.DeleteAfter)
+		if err != nil {
+			return fmt.Errorf("%s: project %s: %v", retirefile, p.Name, err)
+		}
+		if now.Before(due) {
+			kept = append(kept, p)
+			continue
+		}
+
+		repos, err := reposOfProject(c, &projectBrief{ProjectID: p.ProjectID, Name: p.Name})
+		if err != nil {
+			return err
+		}
+		for _, r := range repos {
+			if err := sendJSON(c, http.MethodDelete, repoURL(c, r.Name), nil); err != nil {
+				return err
+			}
+		}
+		if err := sendJSON(c, http.MethodDelete, c.APIURL("/projects")+"/"+strconv.Itoa(p.ProjectID), nil); err != nil {
+			return err
+		}
+		fmt.Printf("==> project %s deleted\n", p.Name)
+
+		// Save after every deletion, so a failure later does not bring
+		// the deleted project back into the state file.
+		state.Projects = append(append([]*retiredProject{}, kept...), pending[i+1:]...)
+		if err := retireStateSave(state); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+func init() {
+	Parser.AddCommand("project_verify",
+		"Verify a project against an exported inventory.",
+		"Re-list the project recorded in an inventory file written by project_inventory, and report repositories, artifacts and tags which are missing or changed since the export. Exit with non-zero code if any difference is found.",
+		&prjVerify)
+}
+
+type projectVerify struct {
+	File    string `short:"f" long:"file" description:"The inventory file written by project_inventory." default:"inventory.json"`
+	Project string `short:"p" long:"project" description:"Verify against another project (e.g. the replication destination) instead of the one recorded in the inventory."`
+}
+
+var prjVerify projectVerify
+
+func (x *projectVerify) Execute(args []string) error {
+	want, err := InventoryLoad(x.File)
+	if err != nil {
+		return err
+	}
+
+	project := want.Project.Name
+	if x.Project != "" {
+		project = x.Project
+	}
+
+	got, err := InventoryCollect(NewClient(), project)
+	if err != nil {
+		return err
+	}
+
+	diffs := inventoryDiff(want, got)
+	for _, d := range diffs {
+		fmt.Println(d)
+	}
+
+	if len(diffs) > 0 {
+		return fmt.Errorf("%d difference(s) found in project %s against %s", len(diffs), project, x.File)
+	}
+	fmt.Printf("==> project %s matches %s\n", project, x.File)
+	return nil
+}
+
+// InventoryLoad reads an inventory file written by project_inventory.
+func InventoryLoad(file string) (*Inventory, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var inv Inventory
+	if err := json.Unmarshal(data, &inv); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	if inv.SchemaVersion != InventorySchemaVersion {
+		return nil, fmt.Errorf("%s: unsupported schema_version %d", file, inv.SchemaVersion)
+	}
+	return &inv, nil
+}
+
+// repoBaseName strips the project part of a repository name, so the
+// inventories of different projects can be compared.
+func repoBaseName(name string) string {
+	if i := strings.Index(name, "/"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// inventoryDiff reports what in want is missing or changed in got. Extra
+// content in got is not a difference, the project may have grown since.
+func inventoryDiff(want, got *Inventory) []string {
+	var diffs []string
+
+	gotRepos := make(map[string]*InventoryRepository)
+	for _, r := range got.Repositories {
+		gotRepos[repoBaseName(r.Name)] = r
+	}
+
+	for _, wr := range want.Repositories {
+		gr, ok := gotRepos[repoBaseName(wr.Name)]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("MISSING repository %s", wr.Name))
+			continue
+		}
+
+		gotTags := make(map[string]*InventoryArtifact)
+		gotDigests := make(map[string]*InventoryArtifact)
+		for _, a := range gr.Artifacts {
+			gotDigests[a.Digest] = a
+			for _, t := range a.Tags {
+				gotTags[t] = a
+			}
+		}
+
+		for _, wa := range wr.Artifacts {
+			ga, ok := gotDigests[wa.Digest]
+			if !ok {
+				diffs = append(diffs, fmt.Sprintf("MISSING artifact %s@%s", wr.Name, wa.Digest))
+			} else if ga.Size != wa.Size {
+				diffs = append(diffs, fmt.Sprintf("CHANGED artifact %s@%s: size %d -> %d", wr.Name, wa.Digest, wa.Size, ga.Size))
+			}
+
+			for _, t := range wa.Tags {
+				ta, ok := gotTags[t]
+				switch {
+				case !ok:
+					diffs = append(diffs, fmt.Sprintf("MISSING tag %s:%s", wr.Name, t))
+				case ta.Digest != wa.Digest:
+					diffs = append(diffs, fmt.Sprintf("CHANGED tag %s:%s: digest %s -> %s", wr.Name, t, wa.Digest, ta.Digest))
+				}
+			}
+		}
+	}
+
+	return diffs
+}
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"fmt"
+)
+
+// readOnlyCommands are the commands which do not change anything on the
+// target, they are the only ones allowed against a target configured with
+// 'read_only: true'. Commands not listed are taken as mutating.
+var readOnlyCommands = map[string]bool{
+	"artifact_accessories_list":   true,
+	"artifact_get":                true,
+	"artifact_pullcmd":            true,
+	"artifact_sbom_get":           true,
+	"artifact_tags_list":          true,
+	"artifacts_list":              true,
+	"capabilities":                true,
+	"chartrepo_chart_get":         true,
+	"chartrepo_chart_labels_get":  true,
+	"chartrepo_chart_versions":    true,
+	"chartrepo_charts_list":       true,
+	"configurations_get":          true,
+	"configurations_pull_get":     true,
+	"cve_allowlist_export":        true,
+	"cve_allowlist_get":           true,
+	"email_ping":                  true,
+	"explain":                     true,
+	"gc_get":                      true,
+	"gc_history_list":             true,
+	"gc_log":                      true,
+	"gc_schedule_get":             true,
+	"health":                      true,
+	"idmap_sync":                  true,
+	"idmap_translate":             true,
+	"immutable_rules_list":        true,
+	"jobs_repl_list_by_filters":   true,
+	"jobs_repl_log_get_by_jid":    true,
+	"jobs_scan_log_get_by_jid":    true,
+	"label_get_by_id":             true,
+	"label_usage":                 true,
+	"labels_list":                 true,
+	"ldap_groups_search":          true,
+	"ldap_ping":                   true,
+	"ldap_users_search":           true,
+	"login":                       true,
+	"logout":                      true,
+	"logs":                        true,
+	"manifest_inspect":            true,
+	"metrics_get":                 true,
+	"permissions":                 true,
+	"policies_list":               true,
+	"policy_get_by_id":            true,
+	"prj_cve_allowlist_get":       true,
+	"prj_get":                     true,
+	"prj_logs_get":                true,
+	"prj_member_get":              true,
+	"prj_members_get":             true,
+	"prj_metadata_get":            true,
+	"prj_metadata_get_by_name":    true,
+	"prj_proxy_speed_get":         true,
+	"prj_summary_get":             true,
+	"prj_unsigned_tags":           true,
+	"prjs_list":                   true,
+	"project_inventory":           true,
+	"project_scanner_get":         true,
+	"project_usage":               true,
+	"project_verify":              true,
+	"replication_adapters":        true,
+	"replication_execution_get":   true,
+	"replication_executions_list": true,
+	"replication_tasks_list":      true,
+	"replication_topology":        true,
+	"repo_image_labels_get":       true,
+	"repo_image_manifests_get":    true,
+	"repo_image_vul_details_get":  true,
+	"repo_labels_get":             true,
+	"repo_signature_get":          true,
+	"repos_list":                  true,
+	"repos_top":                   true,
+	"retention_executions_list":   true,
+	"retention_get":               true,
+	"retention_metadatas":         true,
+	"retention_simulate":          true,
+	"retention_task_log":          true,
+	"retention_tasks_list":        true,
+	"scan_all_metrics":            true,
+	"scan_all_schedule_get":       true,
+	"scan_report":                 true,
+	"scanner_get":                 true,
+	"scanner_ping":                true,
+	"scanners_list":               true,
+	"schedule_run":                true,
+	"robot_get":                   true,
+	"robots_list":                 true,
+	"search":                      true,
+	"serve":                       true,
+	"statistics":                  true,
+	"sysinfo_general":             true,
+	"sysinfo_rootcert":            true,
+	"sysinfo_volumes":             true,
+	"system_robot_get":            true,
+	"system_robots_list":          true,
+	"tag_get":                     true,
+	"tag_history":                 true,
+	"tag_signature_get":           true,
+	"tags_list":                   true,
+	"tags_semver":                 true,
+	"targets_get_by_tid":          true,
+	"targets_list":                true,
+	"targets_ping":                true,
+	"targets_ping_by_tid":         true,
+	"targets_policies_by_tid":     true,
+	"user_get":                    true,
+	"user_list":                   true,
+	"usergroup_get":               true,
+	"usergroups_list":             true,
+	"usergroups_search":           true,
+	"users_search":                true,
+	"version":                     true,
+	"wait_healthy":                true,
+	"webhook_jobs_list":           true,
+	"webhook_lasttrigger":         true,
+	"webhook_policies_list":       true,
+	"webhook_policy_get":          true,
+	"whoami":                      true,
+}
+
+// checkWritable refuses a mutating command against a read-only target,
+// unless --force-write is given.
+func checkWritable(command string) error {
+	if readOnlyCommands[command] || GlobalOpts.ForceWrite {
+		return nil
+	}
+
+	config, err := generalConfigLoad()
+	if err != nil || !config.ReadOnly {
+		return nil
+	}
+
+	return fmt.Errorf(T("target %s is read-only ('read_only' in %s), refusing to run '%s' which may change it, pass --force-write to run it anyway"),
+		config.Dstip, configfile, command)
+}
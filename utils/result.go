@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+// PrintResult prints the response of an api call, in the --output format
+// if given, the error is returned as is for the CLI to report.
+func PrintResult(res *harbor.Result, err error) error {
+	if GlobalOpts.Output != "" {
+		return printOutput(res, err)
+	}
+
+	fmt.Println("<== ")
+	if res != nil {
+		fmt.Println("<== Rsp Status:", res.Status)
+		fmt.Print("<== Rsp Body: ")
+		body := &itemCounter{r: bytes.NewReader(res.Body)}
+		if res.Stream != nil {
+			body.r = res.Stream
+		}
+		if _, werr := io.Copy(os.Stdout, body); werr != nil && err == nil {
+			err = werr
+		}
+		res.Close()
+		fmt.Println()
+		if hint := PageHint(res.Pagination(), body.count()); hint != "" && err == nil {
+			fmt.Println("<==", hint)
+		}
+	}
+	return err
+}
+
+// PrintTotalCount prints nothing but the total from X-Total-Count, for
+// count-only list queries.
+func PrintTotalCount(res *harbor.Result, err error) error {
+	if err != nil {
+		if res != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", res.Body)
+		}
+		return err
+	}
+
+	res.Close()
+	total := res.Header.Get("X-Total-Count")
+	if total == "" {
+		return fmt.Errorf("the server does not expose X-Total-Count for this endpoint")
+	}
+	fmt.Println(total)
+	return nil
+}
+
+// FilterResult returns the items of the JSON array listed in res which
+// keep accepts, as the body of a new result with the status and headers of
+// res.
+func FilterResult(res *harbor.Result, keep func(json.RawMessage) (bool, error)) (*harbor.Result, error) {
+	defer res.Close()
+
+	var r io.Reader = bytes.NewReader(res.Body)
+	if res.Stream != nil {
+		r = res.Stream
+	}
+	items := []json.RawMessage{}
+	err := harbor.EachItem(r, func(item json.RawMessage) error {
+		ok, err := keep(item)
+		if ok {
+			items = append(items, item)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	return &harbor.Result{
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		Header:     res.Header,
+		Body:       body,
+	}, nil
+}
+
+// ListPrinter returns the printer for list commands, PrintTotalCount when
+// only the count is wanted, otherwise PrintResult.
+func ListPrinter(count bool) func(*harbor.Result, error) error {
+	if count {
+		return PrintTotalCount
+	}
+	return PrintResult
+}
+
+// ssoHint tells how to get through an SSO proxy when err is a *harbor.SSOError,
+// err is returned as is.
+func ssoHint(err error) error {
+	if harbor.IsSSO(err) {
+		fmt.Fprintln(os.Stderr, T("hint: logging in through the SSO proxy is not possible, set HARBOR_USERNAME and HARBOR_PASSWORD to a robot account, or to an OIDC user and its CLI secret (from the user profile in the Harbor UI), to use basic auth instead."))
+	}
+	return err
+}
+
+// PrintValue prints v, a response decoded by an api function, in the
+// --output format if given, otherwise by table.
+func PrintValue(v interface{}, err error, table func()) error {
+	if err != nil {
+		return err
+	}
+	if GlobalOpts.Output == "" {
+		table()
+		return nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	return Render(w, GlobalOpts.Output, bytes.NewReader(b))
+}
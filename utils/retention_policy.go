@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -87,16 +88,17 @@ func init() {
 }
 
 type reposRetentionPolicy struct {
+	Num int `short:"n" long:"num" description:"The number of repos to delete, in range (0, 50]. If not set, prompt for it interactively." default:"0"`
 }
 
 var reposRP reposRetentionPolicy
 
 func (x *reposRetentionPolicy) Execute(args []string) error {
 	if err := repoAnalyse(); err != nil {
-		os.Exit(1)
+		return err
 	}
 	if err := repoErase(); err != nil {
-		os.Exit(1)
+		return err
 	}
 	rpGCHint()
 	return nil
@@ -111,10 +113,7 @@ type tagsRetentionPolicy struct {
 var tagsRP tagsRetentionPolicy
 
 func (x *tagsRetentionPolicy) Execute(args []string) error {
-	if err := tagAnalyseAndErase(); err != nil {
-		os.Exit(1)
-	}
-	return nil
+	return tagAnalyseAndErase()
 }
 
 func tagAnalyseAndErase() error {
@@ -123,21 +122,16 @@ func tagAnalyseAndErase() error {
 	fmt.Println("===============================")
 	fmt.Println()
 
-	c, err := CookieLoad()
-	if err != nil {
-		fmt.Println("error:", err)
-		return err
-	}
+	c := NewClient()
 
 	// 基于 search 接口获取全部 projects 和 repositories 信息
 	// 设置 "q=" 可以获取全部信息
 	// 设置 "q=xxx" 可以过滤指定信息，但是目前发现该功能有 bug ，故暂时无法基于该接口针对指定 repo 进行处理
-	searchURL := URLGen("/api/search") + "?q=" + tagsRP.RepoName
+	searchURL := URLGen("/api/search") + "?q=" + url.QueryEscape(tagsRP.RepoName)
 	fmt.Println("--------------------")
 	fmt.Println("==> GET", searchURL)
 
-	_, _, errs := Request.Get(searchURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
+	_, _, errs := c.Get(searchURL).
 		EndStruct(&scRsp)
 	for _, e := range errs {
 		if e != nil {
@@ -168,11 +162,10 @@ func tagAnalyseAndErase() error {
 		fmt.Println("+------------+----------------------------------------------------+----------------------------------+-----------------+")
 
 		// 获取每个 repo 下的 tags 信息
-		tagsListURL := URLGen("/api/repositories") + "/" + r.RepositoryName + "/tags"
+		tagsListURL := URLGen("/api/repositories") + "/" + RepoPath(r.RepositoryName) + "/tags"
 		//fmt.Println("==> GET", tagsListURL)
 
-		_, _, errs := Request.Get(tagsListURL).
-			Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
+		_, _, errs := c.Get(tagsListURL).
 			EndStruct(&tlRsp)
 		for _, e := range errs {
 			if e != nil {
@@ -221,11 +214,10 @@ func tagAnalyseAndErase() error {
 				fmt.Printf("[POP] %s <==> %d\n", it.tagName, it.timestamp)
 
 				// 如果 len(minheap) > max 则删除 len(minheap) - max 个 tag
-				targetURL := URLGen("/api/repositories") + "/" + r.RepositoryName + "/tags/" + it.tagName
+				targetURL := URLGen("/api/repositories") + "/" + RepoPath(r.RepositoryName) + "/tags/" + TagPath(it.tagName)
 				fmt.Println("==> DELETE", targetURL)
 
-				Request.Delete(targetURL).
-					Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
+				c.Delete(targetURL).
 					End(PrintStatus)
 
 				gtNdays--
@@ -375,14 +367,9 @@ func repoAnalyse() error {
 
 	statsURL := URLGen("/api/statistics")
 
-	c, err := CookieLoad()
-	if err != nil {
-		fmt.Println("error:", err)
-		return err
-	}
+	c := NewClient()
 
-	resp, _, statsErrs := Request.Get(statsURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
+	resp, _, statsErrs := c.Get(statsURL).
 		EndStruct(&stats)
 	if resp.StatusCode != 200 {
 		fmt.Printf("error: Expected StatusCode=200, actual StatusCode=%v\n", resp.StatusCode)
@@ -398,7 +385,7 @@ func repoAnalyse() error {
 
 	topURL := URLGen("/api/repositories/top") + "?count=" + strconv.Itoa(stats.PublicRepoCount)
 	fmt.Println("==> GET", topURL)
-	_, _, topErrs := Request.Get(topURL).EndStruct(&repos)
+	_, _, topErrs := c.Get(topURL).EndStruct(&repos)
 	for _, e := range topErrs {
 		if e != nil {
 			fmt.Println("error:", e)
@@ -432,7 +419,7 @@ func repoAnalyse() error {
 		heap.Push(&mhBk, it)
 	}
 
-	fmt.Printf("\n========== 根据分数排名（由低到高）建议删除 public repo 信息如下 ========\n\n")
+	fmt.Printf("\n========== %s ========\n\n", T("Public repos suggested to delete, by score from low to high"))
 
 	for mhBk.Len() > 0 {
 		it := heap.Pop(&mhBk).(*repoItem)
@@ -442,21 +429,21 @@ func repoAnalyse() error {
 	return nil
 }
 
-// repoErase implements soft deletion
-func repoErase() error {
+// repoErasePrompt asks user for the number of repos to delete.
+func repoErasePrompt() int {
 
 	var num int
 	scanner := bufio.NewScanner(os.Stdin)
-	fmt.Print("\n请输入希望删除的 repo 数量: ")
+	fmt.Print("\n" + T("Number of repos to delete: "))
 	for scanner.Scan() {
 		in, err := strconv.Atoi(scanner.Text())
 		if err != nil {
-			fmt.Print("输入数字不合法，请重新输入: ")
+			fmt.Print(T("Invalid number, please enter again: "))
 			continue
 		}
-		fmt.Println("您输入的数字为:", in)
+		fmt.Println(T("The number you entered:"), in)
 
-		fmt.Print("确认吗 [y/n]: ")
+		fmt.Print(T("Confirm? [y/n]: "))
 
 		if !scanner.Scan() {
 			break
@@ -467,13 +454,28 @@ func repoErase() error {
 			num = in
 			break
 		} else {
-			fmt.Print("请重新输入希望删除的 repo 数量: ")
+			fmt.Print(T("Please enter the number of repos to delete again: "))
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
 		fmt.Println("error:", err)
-		return err
+	}
+
+	return num
+}
+
+// repoErase implements soft deletion
+func repoErase() error {
+
+	num := reposRP.Num
+	if num == 0 {
+		if err := PromptAllowed("the number of repos to delete"); err != nil {
+			fmt.Println("error:", err)
+			fmt.Println("hint: pass --num to rp_repos.")
+			return err
+		}
+		num = repoErasePrompt()
 	}
 
 	if num <= 0 || num > 50 {
@@ -492,17 +494,10 @@ func repoErase() error {
 			// 进行删除动作前，必须成功登陆，这里没有进行判定，而是直接发出 delete 动作
 
 			// 对应 repo_del 的调用
-			targetURL := URLGen("/api/repositories") + "/" + it.data.Name
+			targetURL := URLGen("/api/repositories") + "/" + RepoPath(it.data.Name)
 			fmt.Println("==> DELETE", targetURL)
 
-			c, err := CookieLoad()
-			if err != nil {
-				fmt.Println("Error:", err)
-				return err
-			}
-
-			Request.Delete(targetURL).
-				Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
+			NewClient().Delete(targetURL).
 				End(PrintStatus)
 		}
 		num--
@@ -516,15 +511,15 @@ func repoErase() error {
 func rpGCHint() {
 
 	fmt.Println("-----------------------------")
-	fmt.Println("您已成功完成 soft deletion ，若想真正释放磁盘空间，还需要:")
-	fmt.Println("1. 切换到 harbor 的安装主目录（例如 /opt/apps/harbor/）")
-	fmt.Println("2. 运行如下命令以 preview 哪些 files/images 会被删除：")
+	fmt.Println(T("Soft deletion is done, to really free the disk space you still need to:"))
+	fmt.Println(T("1. Change to the installation directory of harbor (e.g. /opt/apps/harbor/)"))
+	fmt.Println(T("2. Run the commands below to preview which files/images would be deleted:"))
 	fmt.Println("    a. docker-compose stop")
 	fmt.Println("    b. docker run -it --name gc --rm --volumes-from registry vmware/registry:2.6.2-photon garbage-collect --dry-run /etc/registry/config.yml")
-	fmt.Println("3. 运行如下命令以真正触发 GC 动作：")
+	fmt.Println(T("3. Run the commands below to really trigger GC:"))
 	fmt.Println("    a. docker run -it --name gc --rm --volumes-from registry vmware/registry:2.6.2-photon garbage-collect  /etc/registry/config.yml")
 	fmt.Println("    b. docker-compose start")
 	fmt.Println("")
-	fmt.Println("WARNING:\nMake sure that no one is pushing images or Harbor is not running at all before you perform a GC. If someone were pushing an image while GC is running, there is a risk that the image's layers will be mistakenly deleted which results in a corrupted image. So before running GC, a preferred approach is to stop Harbor first.")
+	fmt.Println(T("WARNING:\nMake sure that no one is pushing images or Harbor is not running at all before you perform a GC. If someone were pushing an image while GC is running, there is a risk that the image's layers will be mistakenly deleted which results in a corrupted image. So before running GC, a preferred approach is to stop Harbor first."))
 	fmt.Println("-----------------------------")
 }
@@ -0,0 +1,276 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func init() {
+	Parser.AddCommand("retention_propagate",
+		"Apply a tag retention policy template to many projects.",
+		"Apply the tag retention policy of a template file (JSON or YAML: algorithm, rules and trigger of a retention policy, e.g. as got from Harbor) to every project lacking one, of those whose name matches --match; --override replaces the policy of the matching projects which have one too. The projects touched are recorded with their previous policy, for --rollback to restore them. (Harbor v2.0+, uses v2.0 API)",
+		&retentionPropagate)
+}
+
+type retentionPropagateRun struct {
+	File     string `short:"f" long:"file" description:"The retention policy template, a JSON or YAML file (or @file, - for stdin). (required unless --rollback)"`
+	Match    string `short:"m" long:"match" description:"Apply to the projects whose name matches this pattern only, e.g. 'team-*'." default:"*"`
+	Override bool   `long:"override" description:"Replace the policy of the matching projects which have one too."`
+	DryRun   bool   `long:"dry-run" description:"Print the changes only."`
+	Rollback bool   `long:"rollback" description:"Restore the policies of the projects touched by the previous runs, as recorded."`
+}
+
+var retentionPropagate retentionPropagateRun
+
+var propagationfile = "conf/.retention_propagation.yaml"
+
+// retentionTouched is a project whose retention policy was set from a
+// template.
+type retentionTouched struct {
+	ProjectID   int64  `yaml:"project_id"`
+	Name        string `yaml:"name"`
+	RetentionID int64  `yaml:"retention_id"`
+	AppliedAt   string `yaml:"applied_at"`
+	// Previous is the policy replaced, as JSON, empty if the policy was
+	// created.
+	Previous string `yaml:"previous,omitempty"`
+}
+
+type propagationState struct {
+	Projects []*retentionTouched `yaml:"projects"`
+}
+
+func (x *retentionPropagateRun) Execute(args []string) error {
+	state, err := propagationStateLoad()
+	if err != nil {
+		return err
+	}
+	c := NewClient()
+	if x.Rollback {
+		return x.rollback(c, state)
+	}
+	if x.File == "" {
+		return fmt.Errorf("--file is required")
+	}
+	if _, err := path.Match(x.Match, ""); err != nil {
+		return fmt.Errorf("--match: %v", err)
+	}
+
+	template := map[string]interface{}{}
+	if err := SpecLoad(x.File, &template); err != nil {
+		return err
+	}
+	delete(template, "id")
+	delete(template, "scope")
+	if _, ok := template["rules"].([]interface{}); !ok {
+		return fmt.Errorf("%s: no rules in the retention policy template", x.File)
+	}
+	if _, ok := template["algorithm"]; !ok {
+		template["algorithm"] = "or"
+	}
+	if _, ok := template["trigger"]; !ok {
+		template["trigger"] = noRetentionSchedule()
+	}
+
+	var projects []*cloneProject
+	err = c.EachPage(c.URL("/api/v2.0/projects"), func(item json.RawMessage) error {
+		var prj cloneProject
+		if err := json.Unmarshal(item, &prj); err != nil {
+			return err
+		}
+		projects = append(projects, &prj)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	applied := 0
+	for _, prj := range projects {
+		if ok, _ := path.Match(x.Match, prj.Name); !ok {
+			continue
+		}
+		id := prj.Metadata["retention_id"]
+		if id != "" && !x.Override {
+			continue
+		}
+
+		if id == "" {
+			fmt.Printf("+ %s\n", prj.Name)
+		} else {
+			fmt.Printf("~ %s (retention policy %s replaced)\n", prj.Name, id)
+		}
+		applied++
+		if x.DryRun {
+			continue
+		}
+
+		touched, err := applyRetention(c, prj, id, template)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", prj.Name, err))
+			continue
+		}
+		// Saved after every project, so that a failure later does not lose
+		// what to roll back.
+		state.Projects = append(state.Projects, touched)
+		if err := propagationStateSave(state); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("%d projects given the retention policy of %s\n", applied-len(failed), x.File)
+	if len(failed) > 0 {
+		for _, f := range failed {
+			fmt.Println("failed:", f)
+		}
+		return fmt.Errorf("%d projects failed", len(failed))
+	}
+	return nil
+}
+
+// applyRetention sets the retention policy of the project prj, whose
+// policy is id if any, to template.
+func applyRetention(c *harbor.Client, prj *cloneProject, id string, template map[string]interface{}) (*retentionTouched, error) {
+	touched := &retentionTouched{
+		ProjectID: prj.ProjectID,
+		Name:      prj.Name,
+		AppliedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	policy := map[string]interface{}{}
+	for k, v := range template {
+		policy[k] = v
+	}
+	policy["scope"] = map[string]interface{}{"level": "project", "ref": prj.ProjectID}
+
+	if id == "" {
+		targetURL := c.URL("/api/v2.0/retentions")
+		c.Trace("==> POST", targetURL)
+		res, err := c.Do(c.Post(targetURL).Send(policy))
+		if err != nil {
+			return nil, err
+		}
+		loc := res.Header.Get("Location")
+		if touched.RetentionID, err = strconv.ParseInt(path.Base(loc), 10, 64); err != nil {
+			return nil, fmt.Errorf("no retention policy ID in the Location header %q of the response", loc)
+		}
+		return touched, nil
+	}
+
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("bad retention_id %q", id)
+	}
+	touched.RetentionID = n
+	targetURL := c.URL("/api/v2.0/retentions") + "/" + id
+	var previous json.RawMessage
+	if err := c.GetJSON(targetURL, &previous); err != nil {
+		return nil, err
+	}
+	touched.Previous = string(previous)
+
+	policy["id"] = n
+	c.Trace("==> PUT", targetURL)
+	if _, err := c.Do(c.Put(targetURL).Send(policy)); err != nil {
+		return nil, err
+	}
+	return touched, nil
+}
+
+// rollback restores the policies recorded in state, latest first. Harbor
+// cannot delete a retention policy: the ones created are emptied of their
+// rules and schedule instead, as the policy of a project never given one.
+func (x *retentionPropagateRun) rollback(c *harbor.Client, state *propagationState) error {
+	if len(state.Projects) == 0 {
+		fmt.Println("nothing to roll back")
+		return nil
+	}
+
+	var failed []string
+	restored := 0
+	for i := len(state.Projects) - 1; i >= 0; i-- {
+		t := state.Projects[i]
+		targetURL := c.URL("/api/v2.0/retentions") + "/" + strconv.FormatInt(t.RetentionID, 10)
+
+		var policy interface{}
+		if t.Previous != "" {
+			fmt.Printf("~ %s (retention policy %d restored)\n", t.Name, t.RetentionID)
+			policy = json.RawMessage(t.Previous)
+		} else {
+			fmt.Printf("- %s (retention policy %d emptied)\n", t.Name, t.RetentionID)
+			policy = map[string]interface{}{
+				"id":        t.RetentionID,
+				"algorithm": "or",
+				"rules":     []interface{}{},
+				"trigger":   noRetentionSchedule(),
+				"scope":     map[string]interface{}{"level": "project", "ref": t.ProjectID},
+			}
+		}
+		if x.DryRun {
+			restored++
+			continue
+		}
+
+		c.Trace("==> PUT", targetURL)
+		if _, err := c.Do(c.Put(targetURL).Send(policy)); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", t.Name, err))
+			continue
+		}
+		restored++
+		state.Projects = state.Projects[:i]
+		if err := propagationStateSave(state); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("%d projects rolled back\n", restored)
+	if len(failed) > 0 {
+		for _, f := range failed {
+			fmt.Println("failed:", f)
+		}
+		return fmt.Errorf("%d projects failed, run --rollback again to retry", len(failed))
+	}
+	return nil
+}
+
+// noRetentionSchedule is the trigger of a retention policy run manually
+// only.
+func noRetentionSchedule() map[string]interface{} {
+	return map[string]interface{}{
+		"kind":     "Schedule",
+		"settings": map[string]interface{}{"cron": ""},
+	}
+}
+
+func propagationStateLoad() (*propagationState, error) {
+	var state propagationState
+
+	dataBytes, err := ioutil.ReadFile(propagationfile)
+	if os.IsNotExist(err) {
+		return &state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(dataBytes, &state); err != nil {
+		return nil, fmt.Errorf("%s: %v", propagationfile, err)
+	}
+	return &state, nil
+}
+
+func propagationStateSave(state *propagationState) error {
+	dataBytes, err := yaml.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(propagationfile, dataBytes, 0600)
+}
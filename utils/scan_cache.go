@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+// Vulnerability reports are large and never change once the scan is done,
+// so they are cached locally, keyed by artifact digest, scanner and report
+// version. A new scan gives a new report version and so a cache miss.
+
+var scanCacheDir = "conf/.scan_cache"
+
+// ScannerClair is the scanner of v1 API.
+const ScannerClair = "clair"
+
+func scanCachePath(digest, scanner, version string) string {
+	sum := sha256.Sum256([]byte(digest + "\n" + scanner + "\n" + version))
+	return filepath.Join(scanCacheDir, hex.EncodeToString(sum[:])+".json")
+}
+
+// ScanReportCacheGet returns the cached report, if any.
+func ScanReportCacheGet(digest, scanner, version string) ([]byte, bool) {
+	if digest == "" || version == "" {
+		return nil, false
+	}
+	report, err := ioutil.ReadFile(scanCachePath(digest, scanner, version))
+	if err != nil {
+		return nil, false
+	}
+	return report, true
+}
+
+// ScanReportCachePut stores a report in cache.
+func ScanReportCachePut(digest, scanner, version string, report []byte) error {
+	if digest == "" || version == "" {
+		return nil
+	}
+	if err := os.MkdirAll(scanCacheDir, 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(scanCachePath(digest, scanner, version), report, 0600)
+}
+
+// VulDetailsFetch returns the vulnerability details of a tag (v1 API). The
+// small tag info is fetched first to learn the digest and the version of
+// the latest report, the details are only downloaded on a cache miss.
+func VulDetailsFetch(c *harbor.Client, repoName, tag string, noCache bool) ([]byte, error) {
+	if c.IsV2() {
+		return nil, errors.New("the vulnerability details of a tag require the v1 API, use scan_report with v2.0 API")
+	}
+	tagURL := c.APIURL("/repositories") + "/" + RepoPath(repoName) + "/tags/" + TagPath(tag)
+
+	var info tagScanInfo
+	if err := c.GetJSON(tagURL, &info); err != nil {
+		return nil, err
+	}
+
+	version := ""
+	if so := info.ScanOverview; so != nil && so.ScanStatus == "finished" {
+		version = strconv.Itoa(so.JobID) + "@" + so.UpdateTime
+	}
+
+	if !noCache {
+		if report, ok := ScanReportCacheGet(info.Digest, ScannerClair, version); ok {
+			return report, nil
+		}
+	}
+
+	targetURL := tagURL + "/vulnerability/details"
+	c.Trace("==> GET", targetURL)
+	res, err := c.Do(c.Get(targetURL))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ScanReportCachePut(info.Digest, ScannerClair, version, res.Body); err != nil {
+		fmt.Println("warning: report not cached:", err)
+	}
+	return res.Body, nil
+}
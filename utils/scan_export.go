@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/model"
+)
+
+// The formats a vulnerability report is exported to.
+const (
+	ScanFormatSARIF     = "sarif"
+	ScanFormatCycloneDX = "cyclonedx"
+	ScanFormatCSV       = "csv"
+)
+
+// WriteScanReport writes a vulnerability report in format: SARIF 2.1.0 for
+// code scanning (e.g. GitHub), a CycloneDX 1.4 BOM with the vulnerabilities
+// for SBOM tooling, or CSV.
+func WriteScanReport(w io.Writer, r *ScanReport, format string) error {
+	switch format {
+	case ScanFormatSARIF:
+		return writeJSON(w, scanSARIF(r))
+	case ScanFormatCycloneDX:
+		return writeJSON(w, scanCycloneDX(r))
+	case ScanFormatCSV:
+		return writeScanCSV(w, r)
+	}
+	return fmt.Errorf("unknown vulnerability report format %q", format)
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// scanScore returns the CVSS-like score GitHub code scanning ranks a
+// severity by.
+func scanScore(severity string) string {
+	switch SeverityRank(severity) {
+	case severityRanks["Critical"]:
+		return "9.5"
+	case severityRanks["High"]:
+		return "8.0"
+	case severityRanks["Medium"]:
+		return "5.5"
+	case severityRanks["Low"]:
+		return "2.0"
+	}
+	return "0.0"
+}
+
+// scanLevel returns the SARIF level of a severity.
+func scanLevel(severity string) string {
+	switch rank := SeverityRank(severity); {
+	case rank >= severityRanks["High"]:
+		return "error"
+	case rank == severityRanks["Medium"]:
+		return "warning"
+	}
+	return "note"
+}
+
+// scanTitle returns the short description of a vulnerability.
+func scanTitle(v *model.ReportVulnerability) string {
+	return fmt.Sprintf("%s %s in %s %s", v.Severity, v.ID, v.Package, v.Version)
+}
+
+// scanSARIF returns the SARIF log of a report, a rule by vulnerability and
+// a result by vulnerable package, located at the artifact.
+func scanSARIF(r *ScanReport) map[string]interface{} {
+	var rules, results []interface{}
+	seen := map[string]bool{}
+	for _, v := range r.Vulnerabilities {
+		if !seen[v.ID] {
+			seen[v.ID] = true
+			rule := map[string]interface{}{
+				"id":               v.ID,
+				"shortDescription": map[string]string{"text": v.ID},
+				"fullDescription":  map[string]string{"text": v.Description},
+				"properties": map[string]interface{}{
+					"security-severity": scanScore(v.Severity),
+					"tags":              []string{"security", "vulnerability", strings.ToLower(v.Severity)},
+				},
+			}
+			if len(v.Links) > 0 {
+				rule["helpUri"] = v.Links[0]
+			}
+			rules = append(rules, rule)
+		}
+
+		text := scanTitle(v)
+		if v.FixVersion != "" {
+			text += ", fixed in " + v.FixVersion
+		}
+		results = append(results, map[string]interface{}{
+			"ruleId":  v.ID,
+			"level":   scanLevel(v.Severity),
+			"message": map[string]string{"text": text},
+			"locations": []interface{}{map[string]interface{}{
+				"physicalLocation": map[string]interface{}{
+					"artifactLocation": map[string]string{"uri": r.Artifact},
+					"region":           map[string]int{"startLine": 1},
+				},
+			}},
+		})
+	}
+	if rules == nil {
+		rules, results = []interface{}{}, []interface{}{}
+	}
+
+	return map[string]interface{}{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []interface{}{map[string]interface{}{
+			"tool": map[string]interface{}{
+				"driver": map[string]interface{}{
+					"name":  r.Scanner,
+					"rules": rules,
+				},
+			},
+			"results": results,
+		}},
+	}
+}
+
+// scanCycloneDX returns the CycloneDX BOM of a report: the artifact, its
+// vulnerable packages as components, and the vulnerabilities affecting
+// them.
+func scanCycloneDX(r *ScanReport) map[string]interface{} {
+	components := []interface{}{}
+	vulns := []interface{}{}
+	seen := map[string]bool{}
+	for _, v := range r.Vulnerabilities {
+		ref := v.Package + "@" + v.Version
+		if !seen[ref] {
+			seen[ref] = true
+			components = append(components, map[string]string{
+				"bom-ref": ref,
+				"type":    "library",
+				"name":    v.Package,
+				"version": v.Version,
+			})
+		}
+
+		severity := strings.ToLower(v.Severity)
+		if v.Severity == "Negligible" {
+			severity = "info"
+		}
+		vuln := map[string]interface{}{
+			"id":          v.ID,
+			"ratings":     []interface{}{map[string]string{"severity": severity}},
+			"description": v.Description,
+			"affects":     []interface{}{map[string]string{"ref": ref}},
+		}
+		if v.FixVersion != "" {
+			vuln["recommendation"] = "Upgrade " + v.Package + " to " + v.FixVersion
+		}
+		if len(v.Links) > 0 {
+			var advisories []interface{}
+			for _, link := range v.Links {
+				advisories = append(advisories, map[string]string{"url": link})
+			}
+			vuln["advisories"] = advisories
+		}
+		vulns = append(vulns, vuln)
+	}
+
+	return map[string]interface{}{
+		"bomFormat":   "CycloneDX",
+		"specVersion": "1.4",
+		"version":     1,
+		"metadata": map[string]interface{}{
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
+			"tools":     []interface{}{map[string]string{"name": r.Scanner}},
+			"component": map[string]string{
+				"bom-ref": r.Artifact,
+				"type":    "container",
+				"name":    r.Artifact,
+				"version": r.Digest,
+			},
+		},
+		"components":      components,
+		"vulnerabilities": vulns,
+	}
+}
+
+// writeScanCSV writes a report as CSV, a line by vulnerable package.
+func writeScanCSV(w io.Writer, r *ScanReport) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"artifact", "digest", "vulnerability", "severity", "package", "version", "fix_version", "links", "description"})
+	for _, v := range r.Vulnerabilities {
+		cw.Write([]string{r.Artifact, r.Digest, v.ID, v.Severity, v.Package, v.Version, v.FixVersion,
+			strings.Join(v.Links, " "), v.Description})
+	}
+	cw.Flush()
+	return cw.Error()
+}
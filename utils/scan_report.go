@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/moooofly/harbor-go-client/model"
+)
+
+// severityRanks orders the severities of vulnerabilities, of both API
+// versions.
+var severityRanks = map[string]int{
+	"None":       0,
+	"Unknown":    1,
+	"Negligible": 2,
+	"Low":        3,
+	"Medium":     4,
+	"High":       5,
+	"Critical":   6,
+}
+
+// SeverityRank returns the rank of a severity, higher is more severe, -1
+// for a severity unknown to the client.
+func SeverityRank(severity string) int {
+	for name, rank := range severityRanks {
+		if strings.EqualFold(name, severity) {
+			return rank
+		}
+	}
+	return -1
+}
+
+// ScanReport is the vulnerability report of an artifact (a tag in v1 API),
+// the same for both API versions, most severe vulnerabilities first.
+type ScanReport struct {
+	Artifact        string                       `json:"artifact"`
+	Digest          string                       `json:"digest"`
+	Scanner         string                       `json:"scanner"`
+	Severity        string                       `json:"severity"`
+	Summary         map[string]int               `json:"summary"`
+	Vulnerabilities []*model.ReportVulnerability `json:"vulnerabilities"`
+}
+
+// NewScanReport returns the report of the artifact of the vulnerabilities,
+// sorting them and counting them by severity.
+func NewScanReport(artifact, digest, scanner string, vulns []*model.ReportVulnerability) *ScanReport {
+	r := &ScanReport{
+		Artifact:        artifact,
+		Digest:          digest,
+		Scanner:         scanner,
+		Severity:        "None",
+		Summary:         map[string]int{},
+		Vulnerabilities: vulns,
+	}
+	sort.SliceStable(vulns, func(i, j int) bool {
+		if a, b := SeverityRank(vulns[i].Severity), SeverityRank(vulns[j].Severity); a != b {
+			return a > b
+		}
+		return vulns[i].ID < vulns[j].ID
+	})
+	for _, v := range vulns {
+		r.Summary[v.Severity]++
+		if SeverityRank(v.Severity) > SeverityRank(r.Severity) {
+			r.Severity = v.Severity
+		}
+	}
+	return r
+}
+
+// ScanReportFromV1 returns the report of the vulnerability details of a
+// tag of the v1 API.
+func ScanReportFromV1(artifact, digest string, items []model.VulnerabilityItem) *ScanReport {
+	vulns := make([]*model.ReportVulnerability, 0, len(items))
+	for _, it := range items {
+		severity, ok := severityNames[it.Severity]
+		if !ok {
+			severity = "Unknown"
+		}
+		v := &model.ReportVulnerability{
+			ID:          it.ID,
+			Package:     it.Package,
+			Version:     it.Version,
+			FixVersion:  it.FixedVersion,
+			Severity:    severity,
+			Description: it.Description,
+		}
+		if it.Link != "" {
+			v.Links = []string{it.Link}
+		}
+		vulns = append(vulns, v)
+	}
+	return NewScanReport(artifact, digest, ScannerClair, vulns)
+}
+
+// AtLeast returns the number of vulnerabilities of the severity threshold
+// or more severe.
+func (r *ScanReport) AtLeast(threshold string) int {
+	n := 0
+	for _, v := range r.Vulnerabilities {
+		if SeverityRank(v.Severity) >= SeverityRank(threshold) {
+			n++
+		}
+	}
+	return n
+}
+
+// PrintScanReport prints a vulnerability report as a table, most severe
+// first, and the number of vulnerabilities by severity.
+func PrintScanReport(r *ScanReport) {
+	fmt.Printf("Artifact %s (%s), scanned by %s\n", r.Artifact, r.Digest, r.Scanner)
+	if len(r.Vulnerabilities) > 0 {
+		line := "+----------------------+------------+--------------------------------+----------------------+----------------------+"
+		fmt.Println(line)
+		fmt.Printf("| % -20s | % -10s | % -30s | % -20s | % -20s |\n", "Vulnerability", "Severity", "Package", "Version", "Fixed In")
+		fmt.Println(line)
+		for _, v := range r.Vulnerabilities {
+			fmt.Printf("| % -20s | % -10s | % -30s | % -20s | % -20s |\n", v.ID, v.Severity, v.Package, v.Version, v.FixVersion)
+		}
+		fmt.Println(line)
+	}
+
+	var counts []string
+	for _, name := range []string{"Critical", "High", "Medium", "Low", "Negligible", "Unknown", "None"} {
+		if n := r.Summary[name]; n > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", n, name))
+		}
+	}
+	if len(counts) == 0 {
+		fmt.Println("No vulnerabilities found")
+		return
+	}
+	fmt.Printf("%d vulnerabilities: %s\n", len(r.Vulnerabilities), strings.Join(counts, ", "))
+}
+
+// ScanAllProgress returns the progress of the scan of all the artifacts as
+// a line, e.g. "42/100 completed (42%), ongoing: 40 Success, 2 Error".
+func ScanAllProgress(m *model.ScanAllMetrics) string {
+	pct := 0
+	if m.Total > 0 {
+		pct = m.Completed * 100 / m.Total
+	}
+	state := "ended"
+	if m.Ongoing {
+		state = "ongoing"
+	}
+	line := fmt.Sprintf("%d/%d completed (%d%%), %s", m.Completed, m.Total, pct, state)
+
+	names := make([]string, 0, len(m.Metrics))
+	for name := range m.Metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var counts []string
+	for _, name := range names {
+		if n := m.Metrics[name]; n > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", n, name))
+		}
+	}
+	if len(counts) > 0 {
+		line += ": " + strings.Join(counts, ", ")
+	}
+	return line
+}
+
+// PrintScanAllMetrics prints the progress of the scan of all the artifacts
+// and what triggered it.
+func PrintScanAllMetrics(m *model.ScanAllMetrics) {
+	fmt.Println(ScanAllProgress(m))
+	if m.Trigger != "" {
+		fmt.Printf("Triggered %s", m.Trigger)
+		if m.Requester != "" {
+			fmt.Printf(" by %s", m.Requester)
+		}
+		fmt.Println()
+	}
+}
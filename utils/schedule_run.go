@@ -0,0 +1,220 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func init() {
+	cmd, _ := Parser.AddCommand("schedule_run",
+		"Run commands on cron schedules.",
+		"Keep running and execute the commands of a jobs file on their cron schedules, for reports and housekeeping without a crontab. Every run is a separate process of this client, with the config and login session of the current directory; a run still going when its job is due again is skipped. SIGINT or SIGTERM stops scheduling and waits for the running jobs.",
+		&scheduleRun)
+	if cmd != nil {
+		cmd.Aliases = []string{"schedule-run"}
+	}
+}
+
+type scheduleRunOpts struct {
+	File string `short:"f" long:"file" description:"(REQUIRED) The jobs file (YAML), see README for the format." required:"yes"`
+	List bool   `short:"l" long:"list" description:"Print the next run of every job and exit."`
+	Now  bool   `long:"now" description:"Run every job once right away, one after another, and exit."`
+}
+
+var scheduleRun scheduleRunOpts
+
+// scheduledJob is a job of the jobs file:
+//
+//	jobs:
+//	  - name: weekly-usage
+//	    schedule: "0 6 * * mon"
+//	    command: [project_usage, -p, library, -o, json]
+//	    output: reports/usage.json
+type scheduledJob struct {
+	Name     string   `yaml:"name"`
+	Schedule string   `yaml:"schedule"`
+	Command  []string `yaml:"command"`
+	// Output is the file the output of the runs is appended to, stdout if
+	// empty.
+	Output string `yaml:"output"`
+
+	cron    *cronSchedule
+	running bool
+}
+
+type scheduleFile struct {
+	Jobs []*scheduledJob `yaml:"jobs"`
+}
+
+func (x *scheduleRunOpts) Execute(args []string) error {
+	jobs, err := loadScheduledJobs(x.File)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case x.List:
+		now := time.Now()
+		for _, j := range jobs {
+			fmt.Printf("%-24s %-20s %s\n", j.Name, j.Schedule, j.cron.Next(now).Format("2006-01-02 15:04 MST"))
+		}
+		return nil
+	case x.Now:
+		failed := 0
+		for _, j := range jobs {
+			if runScheduledJob(j) != nil {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d jobs failed", failed, len(jobs))
+		}
+		return nil
+	}
+	return runSchedules(jobs)
+}
+
+func loadScheduledJobs(file string) ([]*scheduledJob, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var f scheduleFile
+	if err := yaml.UnmarshalStrict(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	if len(f.Jobs) == 0 {
+		return nil, fmt.Errorf("%s: no jobs", file)
+	}
+
+	names := map[string]bool{}
+	for i, j := range f.Jobs {
+		if j.Name == "" {
+			j.Name = fmt.Sprintf("job%d", i+1)
+		}
+		if names[j.Name] {
+			return nil, fmt.Errorf("%s: duplicate job name %q", file, j.Name)
+		}
+		names[j.Name] = true
+		if len(j.Command) == 0 {
+			return nil, fmt.Errorf("%s: job %q has no command", file, j.Name)
+		}
+		if j.Command[0] == "schedule_run" || j.Command[0] == "schedule-run" {
+			return nil, fmt.Errorf("%s: job %q runs schedule_run", file, j.Name)
+		}
+		if j.cron, err = parseCron(j.Schedule); err != nil {
+			return nil, fmt.Errorf("%s: job %q: %v", file, j.Name, err)
+		}
+	}
+	return f.Jobs, nil
+}
+
+// runSchedules runs the jobs when they are due, until SIGINT or SIGTERM.
+func runSchedules(jobs []*scheduledJob) error {
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for {
+		now := time.Now()
+		var next time.Time
+		var due []*scheduledJob
+		for _, j := range jobs {
+			t := j.cron.Next(now)
+			switch {
+			case t.IsZero():
+			case next.IsZero() || t.Before(next):
+				next, due = t, []*scheduledJob{j}
+			case t.Equal(next):
+				due = append(due, j)
+			}
+		}
+		if next.IsZero() {
+			wg.Wait()
+			return fmt.Errorf("no job is ever due")
+		}
+		logSchedule("next run at %s: %d job(s)", next.Format("2006-01-02 15:04"), len(due))
+
+		select {
+		case <-stop:
+			logSchedule("stopping, waiting for running jobs")
+			wg.Wait()
+			return nil
+		case <-time.After(time.Until(next)):
+		}
+
+		for _, j := range due {
+			mu.Lock()
+			if j.running {
+				mu.Unlock()
+				logSchedule("%s: still running, skipped", j.Name)
+				continue
+			}
+			j.running = true
+			mu.Unlock()
+
+			wg.Add(1)
+			go func(j *scheduledJob) {
+				defer wg.Done()
+				runScheduledJob(j)
+				mu.Lock()
+				j.running = false
+				mu.Unlock()
+			}(j)
+		}
+	}
+}
+
+// runScheduledJob runs the command of j as a child process of this client,
+// appending its output to j.Output.
+func runScheduledJob(j *scheduledJob) error {
+	exe, err := os.Executable()
+	if err != nil {
+		logSchedule("%s: %v", j.Name, err)
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if j.Output != "" {
+		f, err := os.OpenFile(j.Output, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			logSchedule("%s: %v", j.Name, err)
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	cmd := exec.Command(exe, j.Command...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	// A run must not wait for answers of a terminal nobody watches.
+	cmd.Env = append(os.Environ(), "HARBOR_NON_INTERACTIVE=true")
+
+	start := time.Now()
+	logSchedule("%s: started", j.Name)
+	err = cmd.Run()
+	if err != nil {
+		logSchedule("%s: failed after %s: %v", j.Name, time.Since(start).Round(time.Second), err)
+		return err
+	}
+	logSchedule("%s: done in %s", j.Name, time.Since(start).Round(time.Second))
+	return nil
+}
+
+func logSchedule(format string, a ...interface{}) {
+	fmt.Fprintf(os.Stderr, "%s schedule_run: %s\n", time.Now().Format("2006-01-02 15:04:05"), fmt.Sprintf(format, a...))
+}
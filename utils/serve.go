@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+func init() {
+	Parser.AddCommand("serve",
+		"Serve the commands as JSON requests and responses.",
+		"Keep running and serve the commands of this client to other programs (editors, bots, ...), with the config and login session of the current directory, instead of a process per command. With --stdio, every line read from stdin is a request, {\"id\": 1, \"command\": \"prjs_list\", \"args\": [\"--page\", \"2\"]}, answered by a line on stdout, {\"id\": 1, \"ok\": true, \"result\": ...}: the JSON response of the command in result (its text in output when it is not JSON), or its error in error, with the HTTP status in status if any. The global options given to serve apply to every request; requests never prompt and cannot read stdin. It ends at the end of stdin, or on SIGINT or SIGTERM.",
+		&serve)
+}
+
+type serveOpts struct {
+	Stdio bool `long:"stdio" description:"(REQUIRED) Read requests from stdin and write responses to stdout, a JSON document per line." required:"yes"`
+}
+
+var serve serveOpts
+
+// serveRequest is a line read by serve.
+type serveRequest struct {
+	ID      json.RawMessage `json:"id,omitempty"`
+	Command string          `json:"command"`
+	Args    []string        `json:"args"`
+}
+
+// serveResponse is the line written by serve for a request.
+type serveResponse struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	OK     bool            `json:"ok"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Output string          `json:"output,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	Status int             `json:"status,omitempty"`
+}
+
+func (x *serveOpts) Execute(args []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Requests get their own copy of the global options, JSON output and
+	// never prompting, as stdin is the stream of requests.
+	global := GlobalOpts
+	if global.Output == "" {
+		global.Output = "json"
+	}
+	global.NonInteractive = true
+	global.NoPager = true
+
+	in := os.Stdin
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		sc := bufio.NewScanner(in)
+		sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for sc.Scan() {
+			lines <- append([]byte(nil), sc.Bytes()...)
+		}
+		readErr <- sc.Err()
+	}()
+
+	enc := json.NewEncoder(os.Stdout)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-readErr:
+			return err
+		case line := <-lines:
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			var req serveRequest
+			var rsp *serveResponse
+			if err := json.Unmarshal(line, &req); err != nil {
+				rsp = &serveResponse{Error: fmt.Sprintf("bad request: %v", err)}
+			} else {
+				GlobalOpts = global
+				rsp = serveOne(&req)
+			}
+			if err := enc.Encode(rsp); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// serveOne runs the command of req, on a parser of its own with fresh
+// command data: flags are never left over from a previous request.
+func serveOne(req *serveRequest) *serveResponse {
+	rsp := &serveResponse{ID: req.ID}
+
+	cmd := Parser.Find(req.Command)
+	if cmd == nil || cmd.Name == "serve" {
+		rsp.Error = fmt.Sprintf("unknown command %q", req.Command)
+		return rsp
+	}
+	if err := configCheck(); err != nil {
+		rsp.Error = err.Error()
+		return rsp
+	}
+	data := reflect.New(reflect.TypeOf(Parser.data[cmd.Name]).Elem()).Interface()
+
+	p := flags.NewParser(nil, flags.HelpFlag|flags.PassDoubleDash)
+	sub, err := p.AddCommand(cmd.Name, cmd.ShortDescription, cmd.LongDescription, data)
+	if err != nil {
+		rsp.Error = err.Error()
+		return rsp
+	}
+	// Defaults changed at startup, e.g. by SetDefaultPageSize.
+	for _, o := range sub.Options() {
+		if o.LongName == "" {
+			continue
+		}
+		if orig := cmd.FindOptionByLongName(o.LongName); orig != nil {
+			o.Default = orig.Default
+		}
+	}
+	p.CommandHandler = func(command flags.Commander, args []string) error {
+		active := Parser.Active
+		Parser.Active = sub
+		defer func() { Parser.Active = active }()
+		return validateAndExecute(command, args)
+	}
+
+	out, err := captureOutput(func() error {
+		_, err := p.ParseArgs(append([]string{cmd.Name}, req.Args...))
+		return err
+	})
+
+	var flagsErr *flags.Error
+	if errors.As(err, &flagsErr) && flagsErr.Type == flags.ErrHelp {
+		out, err = []byte(flagsErr.Message), nil
+	}
+	if err != nil {
+		rsp.Error = err.Error()
+		var apiErr *harbor.APIError
+		if errors.As(err, &apiErr) {
+			rsp.Status = apiErr.Result.StatusCode
+			out = apiErr.Result.Body
+		}
+	} else {
+		rsp.OK = true
+	}
+
+	if out = bytes.TrimSpace(out); json.Valid(out) {
+		rsp.Result = out
+	} else {
+		rsp.Output = string(out)
+	}
+	return rsp
+}
+
+// captureOutput returns what fn writes to stdout, while stdin reads
+// nothing.
+func captureOutput(fn func() error) ([]byte, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	null, err := os.Open(os.DevNull)
+	if err != nil {
+		r.Close()
+		w.Close()
+		return nil, err
+	}
+	defer null.Close()
+
+	var buf bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		io.Copy(&buf, r)
+		r.Close()
+		close(done)
+	}()
+
+	stdout, stdin := os.Stdout, os.Stdin
+	os.Stdout, os.Stdin = w, null
+	err = fn()
+	os.Stdout, os.Stdin = stdout, stdin
+	w.Close()
+	<-done
+	return buf.Bytes(), err
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/moooofly/harbor-go-client/model"
+)
+
+// TagSignature tells whether a tag is signed, and by what: "notary" for a
+// signature of the tag in Notary, the kind of the signatures attached to
+// its artifact otherwise, e.g. "cosign".
+type TagSignature struct {
+	Tag        string   `json:"tag"`
+	Digest     string   `json:"digest"`
+	Signed     bool     `json:"signed"`
+	Signatures []string `json:"signatures,omitempty"`
+}
+
+// NewTagSignature returns the signatures of the tag of the artifact a, of
+// the v2.0 API, named name.
+func NewTagSignature(name string, a *model.Artifact, tag string) *TagSignature {
+	sig := &TagSignature{Tag: name, Digest: a.Digest}
+	for _, t := range a.Tags {
+		if t.Name == tag && t.Signed {
+			sig.Signatures = append(sig.Signatures, "notary")
+		}
+	}
+	for _, acc := range a.Accessories {
+		if strings.HasPrefix(acc.Type, "signature.") {
+			sig.Signatures = append(sig.Signatures, strings.TrimPrefix(acc.Type, "signature."))
+		}
+	}
+	sig.Signed = len(sig.Signatures) > 0
+	return sig
+}
+
+// NewTagSignatureV1 returns the signature of the tag t of the v1 API, named
+// name, which Notary signed if it has a signature.
+func NewTagSignatureV1(name string, t *model.Tag) *TagSignature {
+	sig := &TagSignature{Tag: name, Digest: t.Digest, Signed: t.Signature != nil}
+	if sig.Signed {
+		sig.Signatures = []string{"notary"}
+	}
+	return sig
+}
+
+// PrintTagSignatures prints whether tags are signed, a tag by line.
+func PrintTagSignatures(sigs []*TagSignature) {
+	for _, sig := range sigs {
+		if sig.Signed {
+			fmt.Printf("%s: signed (%s)\n", sig.Tag, strings.Join(sig.Signatures, ", "))
+		} else {
+			fmt.Printf("%s: not signed\n", sig.Tag)
+		}
+	}
+}
+
+// UnsignedTags are the unsigned tags of a project, out of Tags, and the
+// content trust policies the project enforces ("notary", "cosign").
+type UnsignedTags struct {
+	Project  string          `json:"project"`
+	Enforced []string        `json:"enforced"`
+	Tags     int             `json:"tags"`
+	Unsigned []*TagSignature `json:"unsigned"`
+}
+
+// PrintUnsignedTags prints the unsigned tags of a project, and whether they
+// can be pulled.
+func PrintUnsignedTags(r *UnsignedTags) {
+	if len(r.Enforced) > 0 {
+		fmt.Printf("Project %s enforces content trust (%s): unsigned tags cannot be pulled\n", r.Project, strings.Join(r.Enforced, ", "))
+	} else {
+		fmt.Printf("Project %s does not enforce content trust\n", r.Project)
+	}
+	PrintTagSignatures(r.Unsigned)
+	fmt.Printf("%d of %d tags not signed\n", len(r.Unsigned), r.Tags)
+}
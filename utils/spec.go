@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// SpecLoad loads a JSON or YAML spec file onto v, as an alternative to
+// passing dozens of flags to complex create/update commands.
+//
+// The file may be given as "path", "@path" (curl style) or "-" for stdin.
+// Fields absent from the spec keep their current (flag) values, unknown
+// fields are rejected, and errors point at the offending line and column.
+func SpecLoad(file string, v interface{}) error {
+	file = strings.TrimPrefix(file, "@")
+
+	var dataBytes []byte
+	var err error
+	if file == "-" {
+		dataBytes, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		dataBytes, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return err
+	}
+
+	isJSON := false
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+		isJSON = true
+	case ".yaml", ".yml":
+	default:
+		trimmed := bytes.TrimSpace(dataBytes)
+		isJSON = len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+	}
+
+	if !isJSON {
+		var raw interface{}
+		if err := yaml.Unmarshal(dataBytes, &raw); err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		// Round-trip through JSON, so json tags are the only schema.
+		if dataBytes, err = json.Marshal(yamlToJSONCompatible(raw)); err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(dataBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%s: %s", file, specErrorf(dataBytes, err, isJSON))
+	}
+
+	return nil
+}
+
+// specErrorf adds position information to the decoding error.
+func specErrorf(data []byte, err error, withPos bool) string {
+	var offset int64
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		offset = e.Offset
+	case *json.UnmarshalTypeError:
+		if e.Field != "" {
+			return fmt.Sprintf("field %q: expected %s, got %s", e.Field, e.Type, e.Value)
+		}
+		offset = e.Offset
+	default:
+		return err.Error()
+	}
+
+	if !withPos {
+		return err.Error()
+	}
+
+	line, col := 1, 1
+	for i := int64(0); i < offset && i < int64(len(data)); i++ {
+		if data[i] == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return fmt.Sprintf("line %d, column %d: %v", line, col, err)
+}
+
+// yamlToJSONCompatible converts map[interface{}]interface{} produced by
+// yaml.v2 into map[string]interface{}, recursively.
+func yamlToJSONCompatible(in interface{}) interface{} {
+	switch t := in.(type) {
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			out[fmt.Sprint(k)] = yamlToJSONCompatible(v)
+		}
+		return out
+	case []interface{}:
+		for i, v := range t {
+			t[i] = yamlToJSONCompatible(v)
+		}
+		return t
+	default:
+		return in
+	}
+}
@@ -0,0 +1,229 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	Parser.AddCommand("tags_semver",
+		"List tags of a repository grouped by semantic version.",
+		"Parse the tags of a repository as semantic versions (with optional 'v' prefix), and list them grouped by release with their prereleases and builds. With --latest-of, print only the latest release matching a version pattern like '1.x' or '1.2.x', for release tooling deciding what to promote or prune.",
+		&tagsSemver)
+}
+
+type tagsSemverList struct {
+	Project    string `short:"p" long:"project" description:"(REQUIRED) The name of the project." required:"yes"`
+	Repo       string `short:"r" long:"repo" description:"(REQUIRED) The name of the repository, without project." required:"yes"`
+	LatestOf   string `short:"l" long:"latest-of" description:"Print the latest version matching the pattern only, e.g. '1.x', '1.2.x' or 'x'."`
+	Prerelease bool   `long:"prerelease" description:"Take prereleases into account for --latest-of."`
+}
+
+var tagsSemver tagsSemverList
+
+func (x *tagsSemverList) Execute(args []string) error {
+	tags, err := tagsOfRepo(NewClient(), x.Project+"/"+x.Repo)
+	if err != nil {
+		return err
+	}
+
+	var versions []*semver
+	var others []string
+	for _, t := range tags {
+		if v, ok := parseSemver(t); ok {
+			versions = append(versions, v)
+		} else {
+			others = append(others, t)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].compare(versions[j]) > 0
+	})
+
+	if x.LatestOf != "" {
+		for _, v := range versions {
+			if (v.Pre == "" || x.Prerelease) && v.matches(x.LatestOf) {
+				fmt.Println(v.Tag)
+				return nil
+			}
+		}
+		return fmt.Errorf("no version matching %q", x.LatestOf)
+	}
+
+	semverPrint(versions, others)
+	return nil
+}
+
+// semver is a tag parsed as semantic version, see https://semver.org/.
+// Missing minor and patch numbers are taken as 0, so '1.2' is 1.2.0.
+type semver struct {
+	Tag                 string
+	Major, Minor, Patch int
+	Pre                 string
+	Build               string
+}
+
+func parseSemver(tag string) (*semver, bool) {
+	v := &semver{Tag: tag}
+
+	s := strings.TrimPrefix(strings.TrimPrefix(tag, "v"), "V")
+	if i := strings.Index(s, "+"); i >= 0 {
+		s, v.Build = s[:i], s[i+1:]
+		if v.Build == "" {
+			return nil, false
+		}
+	}
+	if i := strings.Index(s, "-"); i >= 0 {
+		s, v.Pre = s[:i], s[i+1:]
+		if v.Pre == "" {
+			return nil, false
+		}
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return nil, false
+	}
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || (len(p) > 1 && p[0] == '0') {
+			return nil, false
+		}
+		*nums[i] = n
+	}
+	return v, true
+}
+
+// release is the version without prerelease and build.
+func (v *semver) release() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// compare returns -1, 0 or 1 by semver precedence. Build metadata does not
+// take part in precedence, tags are compared last to keep a stable order.
+func (v *semver) compare(o *semver) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+
+	if c := comparePre(v.Pre, o.Pre); c != 0 {
+		return c
+	}
+	return strings.Compare(v.Tag, o.Tag)
+}
+
+// comparePre compares prereleases, a release is greater than its
+// prereleases, numeric identifiers are less than alphanumeric ones.
+func comparePre(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return sign(an - bn)
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return sign(len(as) - len(bs))
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	}
+	return 0
+}
+
+// matches tells whether v matches a pattern like '1.x', '1.2.x', '1.2' or
+// 'x', where 'x' or '*' is a wildcard and missing parts match anything.
+func (v *semver) matches(pattern string) bool {
+	parts := strings.Split(strings.TrimPrefix(pattern, "v"), ".")
+	if len(parts) > 3 {
+		return false
+	}
+
+	nums := []int{v.Major, v.Minor, v.Patch}
+	for i, p := range parts {
+		if p == "x" || p == "X" || p == "*" {
+			continue
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil || n != nums[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// semverPrint prints versions (sorted in descending order) grouped by
+// release, tags which are no semver are listed last.
+func semverPrint(versions []*semver, others []string) {
+	fmt.Println("+----------------+--------------------------------------------------------------+")
+	fmt.Printf("| % -14s | % -60s |\n", "Release", "Tags (release / prereleases / builds)")
+	fmt.Println("+----------------+--------------------------------------------------------------+")
+
+	for i := 0; i < len(versions); {
+		rel := versions[i].release()
+
+		var group []string
+		for ; i < len(versions) && versions[i].release() == rel; i++ {
+			v := versions[i]
+			kind := "release"
+			switch {
+			case v.Pre != "":
+				kind = "prerelease"
+			case v.Build != "":
+				kind = "build"
+			}
+			group = append(group, v.Tag+" ("+kind+")")
+		}
+
+		for j, t := range group {
+			if j == 0 {
+				fmt.Printf("| % -14s | % -60s |\n", rel, t)
+			} else {
+				fmt.Printf("| % -14s | % -60s |\n", "", t)
+			}
+		}
+	}
+
+	if len(others) > 0 {
+		sort.Strings(others)
+		fmt.Println("+----------------+--------------------------------------------------------------+")
+		for j, t := range others {
+			if j == 0 {
+				fmt.Printf("| % -14s | % -60s |\n", "(not semver)", t)
+			} else {
+				fmt.Printf("| % -14s | % -60s |\n", "", t)
+			}
+		}
+	}
+	fmt.Println("+----------------+--------------------------------------------------------------+")
+}
@@ -0,0 +1,123 @@
+package utils
+
+import "testing"
+
+func TestParseSemver(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want *semver // nil if not a semver
+	}{
+		{"1.2.3", &semver{Major: 1, Minor: 2, Patch: 3}},
+		{"v1.2.3", &semver{Major: 1, Minor: 2, Patch: 3}},
+		{"V10.0.1", &semver{Major: 10, Minor: 0, Patch: 1}},
+		{"1.2", &semver{Major: 1, Minor: 2}},
+		{"1", &semver{Major: 1}},
+		{"0.0.0", &semver{}},
+		{"1.2.3-rc.1", &semver{Major: 1, Minor: 2, Patch: 3, Pre: "rc.1"}},
+		{"1.2.3-rc-1", &semver{Major: 1, Minor: 2, Patch: 3, Pre: "rc-1"}},
+		{"1.2.3+build.5", &semver{Major: 1, Minor: 2, Patch: 3, Build: "build.5"}},
+		{"1.2.3-beta+exp-sha.5114f85", &semver{Major: 1, Minor: 2, Patch: 3, Pre: "beta", Build: "exp-sha.5114f85"}},
+		{"latest", nil},
+		{"", nil},
+		{"1.2.3.4", nil},
+		{"01.2.3", nil},
+		{"1.02", nil},
+		{"1.-2.3", nil},
+		{"1..3", nil},
+		{"1.2.3-", nil},
+		{"1.2.3+", nil},
+		{"1.2.x", nil},
+		{"sha256-abc", nil},
+	}
+	for _, tt := range tests {
+		got, ok := parseSemver(tt.tag)
+		if tt.want == nil {
+			if ok {
+				t.Errorf("parseSemver(%q) = %+v, want no semver", tt.tag, got)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("parseSemver(%q): not a semver", tt.tag)
+			continue
+		}
+		tt.want.Tag = tt.tag
+		if *got != *tt.want {
+			t.Errorf("parseSemver(%q) = %+v, want %+v", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestSemverCompare(t *testing.T) {
+	// Each version is less than the next one, as in the example of
+	// precedence of https://semver.org/.
+	ordered := []string{
+		"0.9.9",
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.0+build.1",
+		"1.0.0+build.2",
+		"1.0.1",
+		"1.1",
+		"1.2.0",
+		"1.10.0",
+		"2.0.0",
+		"v2.0.1",
+		"10.0.0",
+	}
+	var versions []*semver
+	for _, tag := range ordered {
+		v, ok := parseSemver(tag)
+		if !ok {
+			t.Fatalf("parseSemver(%q): not a semver", tag)
+		}
+		versions = append(versions, v)
+	}
+	for i, a := range versions {
+		for j, b := range versions {
+			want := sign(i - j)
+			if got := a.compare(b); got != want {
+				t.Errorf("compare(%q, %q) = %d, want %d", a.Tag, b.Tag, got, want)
+			}
+		}
+	}
+}
+
+func TestSemverMatches(t *testing.T) {
+	tests := []struct {
+		tag     string
+		pattern string
+		want    bool
+	}{
+		{"1.2.3", "x", true},
+		{"1.2.3", "*", true},
+		{"1.2.3", "1", true},
+		{"1.2.3", "1.x", true},
+		{"1.2.3", "v1.x", true},
+		{"1.2.3", "1.2", true},
+		{"1.2.3", "1.2.x", true},
+		{"1.2.3", "1.X.3", true},
+		{"1.2.3", "1.2.3", true},
+		{"1.2.3-rc.1", "1.2.3", true},
+		{"1.2.3", "2.x", false},
+		{"1.2.3", "1.3", false},
+		{"1.2.3", "1.2.4", false},
+		{"1.2.3", "1.2.3.x", false},
+		{"1.2.3", "1.y", false},
+	}
+	for _, tt := range tests {
+		v, ok := parseSemver(tt.tag)
+		if !ok {
+			t.Fatalf("parseSemver(%q): not a semver", tt.tag)
+		}
+		if got := v.matches(tt.pattern); got != tt.want {
+			t.Errorf("%q matches %q = %v, want %v", tt.tag, tt.pattern, got, tt.want)
+		}
+	}
+}
@@ -1,23 +1,12 @@
+//go:build !windows
 // +build !windows
 
-// Package term provides structures and helper functions to work with
-// terminal (state, sizes).
 package term
 
 import (
-	"errors"
-	"fmt"
-	"os"
-	"os/signal"
-
 	"golang.org/x/sys/unix"
 )
 
-var (
-	// ErrInvalidState is returned if the state of the terminal is invalid.
-	ErrInvalidState = errors.New("Invalid terminal state")
-)
-
 // State represents the state of the terminal.
 type State struct {
 	termios Termios
@@ -58,17 +47,8 @@ func DisableEcho(fd uintptr, state *State) error {
 	return nil
 }
 
-func handleInterrupt(fd uintptr, state *State) {
-	sigchan := make(chan os.Signal, 1)
-	signal.Notify(sigchan, os.Interrupt)
-	go func() {
-		for range sigchan {
-			// quit cleanly and the new terminal item is on a new line
-			fmt.Println()
-			signal.Stop(sigchan)
-			close(sigchan)
-			RestoreTerminal(fd, state)
-			os.Exit(1)
-		}
-	}()
+// IsTerminal returns true if the given file descriptor is a terminal.
+func IsTerminal(fd uintptr) bool {
+	var termios Termios
+	return tcget(fd, &termios) == 0
 }
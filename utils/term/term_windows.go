@@ -0,0 +1,55 @@
+//go:build windows
+// +build windows
+
+package term
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// State represents the state of the terminal.
+type State struct {
+	mode uint32
+}
+
+// RestoreTerminal restores the terminal connected to the given file descriptor
+// to a previous state.
+func RestoreTerminal(fd uintptr, state *State) error {
+	if state == nil {
+		return ErrInvalidState
+	}
+	return windows.SetConsoleMode(windows.Handle(fd), state.mode)
+}
+
+// SaveState saves the state of the terminal connected to the given file descriptor.
+func SaveState(fd uintptr) (*State, error) {
+	var oldState State
+	if err := windows.GetConsoleMode(windows.Handle(fd), &oldState.mode); err != nil {
+		return nil, err
+	}
+
+	return &oldState, nil
+}
+
+// DisableEcho applies the specified state to the terminal connected to the file
+// descriptor, with echo disabled.
+//
+// Line input and processed input are kept, so the password is still committed
+// with Enter and Ctrl+C still works.
+func DisableEcho(fd uintptr, state *State) error {
+	newMode := state.mode
+	newMode &^= windows.ENABLE_ECHO_INPUT
+	newMode |= windows.ENABLE_LINE_INPUT | windows.ENABLE_PROCESSED_INPUT
+
+	if err := windows.SetConsoleMode(windows.Handle(fd), newMode); err != nil {
+		return err
+	}
+	handleInterrupt(fd, state)
+	return nil
+}
+
+// IsTerminal returns true if the given file descriptor is a terminal.
+func IsTerminal(fd uintptr) bool {
+	var mode uint32
+	return windows.GetConsoleMode(windows.Handle(fd), &mode) == nil
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/transport"
+)
+
+// transportConfig is the 'transport' section of conf/config.yaml, the
+// tuning of the HTTP connections, e.g. for long batch jobs against a slow
+// Harbor. Settings left out keep the defaults of package transport.
+type transportConfig struct {
+	Timeout             time.Duration `yaml:"timeout"`
+	MaxIdleConns        int           `yaml:"max_idle_conns"`
+	MaxIdleConnsPerHost int           `yaml:"max_idle_conns_per_host"`
+	MaxConnsPerHost     int           `yaml:"max_conns_per_host"`
+	IdleConnTimeout     time.Duration `yaml:"idle_conn_timeout"`
+	TLSHandshakeTimeout time.Duration `yaml:"tls_handshake_timeout"`
+	DialTimeout         time.Duration `yaml:"dial_timeout"`
+	DisableKeepAlives   bool          `yaml:"disable_keep_alives"`
+}
+
+// transportSettings returns the transport settings of conf/config.yaml,
+// shared by every HTTP client of the commands. It exits on a negative
+// setting, like URLGen on a bad config file.
+func transportSettings() transport.Config {
+	config, err := generalConfigLoad()
+	if err != nil {
+		return transport.Config{}
+	}
+	t := config.Transport
+	if err := t.check(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	return transport.Config{
+		Timeout:             t.Timeout,
+		MaxIdleConns:        t.MaxIdleConns,
+		MaxIdleConnsPerHost: t.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     t.MaxConnsPerHost,
+		IdleConnTimeout:     t.IdleConnTimeout,
+		TLSHandshakeTimeout: t.TLSHandshakeTimeout,
+		DialTimeout:         t.DialTimeout,
+		DisableKeepAlives:   t.DisableKeepAlives,
+	}
+}
+
+// check returns an error on a negative setting.
+func (t *transportConfig) check() error {
+	for name, v := range map[string]int64{
+		"timeout":                 int64(t.Timeout),
+		"max_idle_conns":          int64(t.MaxIdleConns),
+		"max_idle_conns_per_host": int64(t.MaxIdleConnsPerHost),
+		"max_conns_per_host":      int64(t.MaxConnsPerHost),
+		"idle_conn_timeout":       int64(t.IdleConnTimeout),
+		"tls_handshake_timeout":   int64(t.TLSHandshakeTimeout),
+		"dial_timeout":            int64(t.DialTimeout),
+	} {
+		if v < 0 {
+			return fmt.Errorf("%s: transport.%s must not be negative", configfile, name)
+		}
+	}
+	return nil
+}
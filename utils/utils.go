@@ -1,16 +1,18 @@
 package utils
 
 import (
-	"crypto/tls"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/jessevdk/go-flags"
-	"github.com/parnurzeal/gorequest"
+	"github.com/moooofly/harbor-go-client/harbor"
 	yaml "gopkg.in/yaml.v2"
 )
 
@@ -28,11 +30,43 @@ var errMalCookies = errors.New("get malformed cookies")
 var errCookiesNotAvailable = errors.New("target cookies are not available")
 
 // Parser is a command registry
-var Parser = flags.NewParser(nil, flags.Default)
+var Parser = &commandParser{
+	Parser: flags.NewParser(nil, flags.Default),
+	data:   map[string]interface{}{},
+}
+
+// commandParser is the parser of the command line, recording the data of
+// its commands, so that serve can run them on a parser of their own.
+type commandParser struct {
+	*flags.Parser
+	data map[string]interface{}
+}
+
+// AddCommand adds a command to the parser, see flags.Parser.AddCommand.
+func (p *commandParser) AddCommand(command string, shortDescription string, longDescription string, data interface{}) (*flags.Command, error) {
+	p.data[command] = data
+	return p.Parser.AddCommand(command, shortDescription, longDescription, data)
+}
+
+// globalOptions are options shared by all commands.
+type globalOptions struct {
+	NonInteractive bool          `long:"non-interactive" env:"HARBOR_NON_INTERACTIVE" description:"Never prompt for input, fail with an error instead. (for CI and service accounts)"`
+	Lang           string        `long:"lang" env:"HARBOR_LANG" description:"Language of messages, 'en' or 'zh-cn'. (default: 'lang' in conf/config.yaml, or 'en')"`
+	ForceWrite     bool          `long:"force-write" description:"Run mutating commands even if the target is configured with 'read_only: true'."`
+	FollowRedirect bool          `long:"follow-redirects" env:"HARBOR_FOLLOW_REDIRECTS" description:"Follow redirects of the target to itself, e.g. from http to https. Redirects to other hosts (SSO) are never followed."`
+	Output         string        `short:"o" long:"output" description:"Print responses as json, yaml, table, go-template=TEMPLATE or jsonpath=TEMPLATE instead of raw, requests are traced to stderr. (give it before the command for commands with an -o of their own)"`
+	APIVersion     string        `long:"api-version" env:"HARBOR_API_VERSION" choice:"v1" choice:"v2.0" description:"API version of the target. (default: the one cached by 'capabilities', or negotiated with the target)"`
+	Accept         string        `long:"accept" env:"HARBOR_ACCEPT" description:"Send this Accept header instead of the media type of the endpoint, e.g. 'application/vnd.scanner.adapter.vuln.report.harbor+json; version=1.0'."`
+	Deadline       time.Duration `long:"deadline" env:"HARBOR_DEADLINE" description:"Abort the command, and its requests in flight, when it runs longer than this, e.g. 90s or 10m. (default: no deadline)"`
+	NoPager        bool          `long:"no-pager" env:"HARBOR_NO_PAGER" description:"Do not pipe the output of read-only commands through $HARBOR_PAGER, $PAGER or less when it is a terminal."`
+}
 
-// Request is a new SuperAgent object with a setting of not verifying
-// server's certificate chain and host name.
-var Request = gorequest.New().TLSClientConfig(&tls.Config{InsecureSkipVerify: true})
+// GlobalOpts holds the parsed global options.
+var GlobalOpts globalOptions
+
+func init() {
+	Parser.AddGroup("Global Options", "", &GlobalOpts)
+}
 
 var configfile = "conf/config.yaml"
 var secretfile = "conf/.cookie.yaml"
@@ -43,8 +77,13 @@ type Beegocookie struct {
 }
 
 type generalConfig struct {
-	Scheme string `yaml:"scheme"`
-	Dstip  string `yaml:"dstip"`
+	Scheme   string `yaml:"scheme"`
+	Dstip    string `yaml:"dstip"`
+	Lang     string `yaml:"lang"`
+	ReadOnly bool   `yaml:"read_only"`
+	PageSize int    `yaml:"page_size"`
+
+	Transport transportConfig `yaml:"transport"`
 }
 
 // SysConfig defines system configurations
@@ -188,53 +227,129 @@ func URLGen(uri string) string {
 	return url
 }
 
-// LoginProc is the callback function for login.
-func LoginProc(resp gorequest.Response, body string, errs []error) {
-	for _, e := range errs {
-		if e != nil {
-			fmt.Println(e)
-			return
-		}
+// configCheck returns the error URLGen and transportSettings exit on, for
+// serve to answer it instead of exiting.
+func configCheck() error {
+	config, err := generalConfigLoad()
+	if err != nil {
+		return err
 	}
+	return config.Transport.check()
+}
 
-	cookies := (*http.Response)(resp).Cookies()
-	fmt.Println("<== Cookies:", cookies)
+// RepoPath escapes a repository name for the v1 API, where the name is a
+// path of its own (e.g. /api/repositories/team/app/service/tags): every
+// segment is escaped, the slashes are kept.
+func RepoPath(name string) string {
+	segs := strings.Split(name, "/")
+	for i, seg := range segs {
+		segs[i] = url.PathEscape(seg)
+	}
+	return strings.Join(segs, "/")
+}
 
-	sid, err := cookieFilter(cookies, "beegosessionID")
-	if err != nil {
-		fmt.Println(err)
-		return
+// RepoPathV2 escapes a repository name (without the project part) for the
+// v2.0 API, where it is a single path segment. Harbor decodes the segment
+// once before routing, so it has to be URL-encoded twice, "team/app"
+// becomes "team%252Fapp".
+func RepoPathV2(name string) string {
+	return url.PathEscape(url.PathEscape(name))
+}
+
+// TagPath escapes a tag or digest for use in a path.
+func TagPath(tag string) string {
+	return url.PathEscape(tag)
+}
+
+// FlagsGiven returns the long names of the flags given to the running
+// command, on the command line or by environment, defaults aside. It is nil
+// when no command is running, e.g. when the api package is used as a
+// library.
+func FlagsGiven() map[string]bool {
+	if Parser.Active == nil {
+		return nil
 	}
+	given := map[string]bool{}
+	for _, o := range Parser.Active.Options() {
+		if o.IsSet() && !o.IsSetDefault() {
+			given[o.LongName] = true
+		}
+	}
+	return given
+}
 
-	// TODO: 根据状态码进行 .cookie.yaml 文件处理，以及用户友好提示
-	fmt.Println("<== Rsp Status:", resp.Status)
-	fmt.Println("<== Rsp Body:", body)
+// NewClient returns the client of the target in conf/config.yaml, with the
+// session saved by login if any, or basic auth by HARBOR_USERNAME and
+// HARBOR_PASSWORD when set. Requests are traced to stdout, or to stderr
+// with --output.
+func NewClient() *harbor.Client {
+	if GlobalOpts.Output != "" {
+		return newClient(os.Stderr)
+	}
+	return newClient(os.Stdout)
+}
 
-	err = cookieSave(sid)
-	if err != nil {
-		fmt.Printf("Error: %v\n", err)
-		return
+// NewDataClient returns the client of NewClient for commands whose stdout
+// is data, e.g. an export: requests are traced to stderr.
+func NewDataClient() *harbor.Client {
+	return newClient(os.Stderr)
+}
+
+func newClient(trace io.Writer) *harbor.Client {
+	opts := []harbor.Option{
+		harbor.WithTrace(trace),
+		harbor.WithFollowRedirects(GlobalOpts.FollowRedirect),
+		harbor.WithMaxPageSize(maxPageSize()),
+		harbor.WithContext(cmdContext),
+		harbor.WithAccept(GlobalOpts.Accept),
+		harbor.WithDeprecationHandler(warnDeprecated),
+		harbor.WithProgress(printStatus),
+		harbor.WithTransport(transportSettings()),
+	}
+	if username := os.Getenv("HARBOR_USERNAME"); username != "" {
+		// Basic auth gets through SSO proxies which the UI login does not.
+		opts = append(opts, harbor.WithBasicAuth(username, os.Getenv("HARBOR_PASSWORD")))
+	} else if c, err := CookieLoad(); err == nil {
+		opts = append(opts, harbor.WithSession(c.BeegosessionID))
 	}
+	if v := apiVersion(); v != "" {
+		opts = append(opts, harbor.WithAPIVersion(v))
+	}
+	return harbor.NewClient(URLGen(""), append(opts, harbor.WithInsecureSkipVerify(true))...)
 }
 
-// LogoutProc is the callback function for logout.
-func LogoutProc(resp gorequest.Response, body string, errs []error) {
-	for _, e := range errs {
-		if e != nil {
-			fmt.Println(e)
-			return
-		}
+// printStatus is the progress callback of the clients, it prints the status
+// events to stderr and leaves out the progress of the requests.
+func printStatus(e harbor.Event) {
+	if e.Kind == harbor.EventStatus {
+		fmt.Fprintln(os.Stderr, e.Status)
 	}
+}
 
-	fmt.Println("<== Rsp Cookies:", (*http.Response)(resp).Cookies())
-	fmt.Println("<== Rsp Status:", resp.Status)
-	fmt.Println("<== Rsp Body:", body)
+// SaveSession saves the beegosessionID set by a successful login response
+// into .cookie.yaml.
+func SaveSession(res *harbor.Result) error {
+	cookies := (&http.Response{Header: res.Header}).Cookies()
 
-	os.Remove(secretfile)
+	sid, err := cookieFilter(cookies, "beegosessionID")
+	if err != nil {
+		return err
+	}
+
+	return cookieSave(sid)
+}
+
+// DropSession removes .cookie.yaml, after logout.
+func DropSession() error {
+	err := os.Remove(secretfile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
 }
 
 // PrintStatus is a regular callback function.
-func PrintStatus(resp gorequest.Response, body string, errs []error) {
+func PrintStatus(resp *http.Response, body string, errs []error) {
 	fmt.Println("<== ")
 	for _, e := range errs {
 		if e != nil {
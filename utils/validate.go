@@ -0,0 +1,207 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+)
+
+func init() {
+	Parser.CommandHandler = validateAndExecute
+}
+
+// Validation rules are given by the `validate` tag of command fields, as a
+// comma separated list of:
+//
+//  required            - the field must not be zero
+//  required_if=F:value - the field must not be zero when field F is value
+//  oneof=a|b|c         - the field must be one of the values
+//  color               - the field must be a color code like #A9B6BE
+//  min=N, max=N        - the (int) field must be in range
+//  pagesize            - the (int) field must not exceed the maximum page
+//                        size of the target
+//
+// All rules except required* are skipped for zero values, so an unset
+// optional flag never fails.
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// validateAndExecute is the CommandHandler of Parser, it rejects mutating
+// commands against a read-only target and invalid flags before any HTTP
+// request is issued.
+//
+// The required rule is left to the commands, their values may come from a
+// spec file or be derived from other flags. Commands reading their request
+// from a spec file (field tagged `spec:"file"`) are left to validate
+// themselves entirely once the spec is loaded.
+func validateAndExecute(command flags.Commander, args []string) error {
+	if command == nil {
+		return nil
+	}
+
+	if err := checkOutput(); err != nil {
+		return err
+	}
+
+	if Parser.Active != nil {
+		if err := checkWritable(Parser.Active.Name); err != nil {
+			return err
+		}
+		if err := checkAdmin(Parser.Active.Name); err != nil {
+			return err
+		}
+	}
+
+	if !specFileGiven(command) {
+		if err := validate(command, false); err != nil {
+			return err
+		}
+	}
+	defer commandContext()()
+	stop := func(err error) error { return err }
+	if Parser.Active != nil {
+		stop = startPager(Parser.Active.Name)
+	}
+	return stop(ssoHint(command.Execute(args)))
+}
+
+func specFileGiven(v interface{}) bool {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return false
+	}
+
+	given := false
+	walkFields(rv, func(f reflect.StructField, fv reflect.Value) {
+		if f.Tag.Get("spec") == "file" && fv.String() != "" {
+			given = true
+		}
+	})
+	return given
+}
+
+// walkFields calls fn on every field of struct rv, fields of embedded
+// structs included.
+func walkFields(rv reflect.Value, fn func(reflect.StructField, reflect.Value)) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			walkFields(rv.Field(i), fn)
+			continue
+		}
+		fn(f, rv.Field(i))
+	}
+}
+
+// fieldName is the name of the field users know, the flag if any,
+// otherwise the spec (JSON) field.
+func fieldName(f reflect.StructField) string {
+	if long := f.Tag.Get("long"); long != "" {
+		return "--" + long
+	}
+	if name := strings.Split(f.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+		return name
+	}
+	return f.Name
+}
+
+func isZero(v reflect.Value) bool {
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
+
+// Validate checks the fields of struct v against their `validate` rules,
+// and reports all violations at once.
+func Validate(v interface{}) error {
+	return validate(v, true)
+}
+
+func validate(v interface{}, required bool) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var missing, invalid []string
+	walkFields(rv, func(f reflect.StructField, fv reflect.Value) {
+		tag := f.Tag.Get("validate")
+		if tag == "" {
+			return
+		}
+
+		for _, rule := range strings.Split(tag, ",") {
+			name, arg := rule, ""
+			if i := strings.Index(rule, "="); i >= 0 {
+				name, arg = rule[:i], rule[i+1:]
+			}
+
+			switch name {
+			case "required":
+				if required && isZero(fv) {
+					missing = append(missing, fieldName(f))
+				}
+			case "required_if":
+				parts := strings.SplitN(arg, ":", 2)
+				other := rv.FieldByName(parts[0])
+				if len(parts) == 2 && other.IsValid() && fmt.Sprint(other.Interface()) == parts[1] && isZero(fv) {
+					of, _ := rv.Type().FieldByName(parts[0])
+					invalid = append(invalid, fmt.Sprintf("%s is required when %s is '%s'", fieldName(f), fieldName(of), parts[1]))
+				}
+			default:
+				if isZero(fv) {
+					continue
+				}
+				if err := validateRule(name, arg, fv); err != nil {
+					invalid = append(invalid, fieldName(f)+" "+err.Error())
+				}
+			}
+		}
+	})
+
+	if len(missing) > 0 {
+		invalid = append([]string{T("missing required field(s): ") + strings.Join(missing, ", ")}, invalid...)
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("%s", strings.Join(invalid, "; "))
+	}
+	return nil
+}
+
+func validateRule(name, arg string, fv reflect.Value) error {
+	switch name {
+	case "oneof":
+		s := fmt.Sprint(fv.Interface())
+		for _, o := range strings.Split(arg, "|") {
+			if s == o {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of [%s], got '%s'", arg, s)
+	case "color":
+		if !colorPattern.MatchString(fv.String()) {
+			return fmt.Errorf("must be a color code like #A9B6BE, got '%s'", fv.String())
+		}
+	case "min", "max":
+		n, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return fmt.Errorf("has bad rule %s=%s", name, arg)
+		}
+		if name == "min" && fv.Int() < n {
+			return fmt.Errorf("must be at least %d, got %d", n, fv.Int())
+		}
+		if name == "max" && fv.Int() > n {
+			return fmt.Errorf("must be at most %d, got %d", n, fv.Int())
+		}
+	case "pagesize":
+		if max := maxPageSize(); fv.Int() > int64(max) {
+			return fmt.Errorf(T("must be at most %d, the maximum page size of the target, got %d"), max, fv.Int())
+		}
+	default:
+		return fmt.Errorf("has unknown rule '%s'", name)
+	}
+	return nil
+}
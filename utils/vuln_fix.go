@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/moooofly/harbor-go-client/model"
+)
+
+// VulFixable returns the vulnerabilities which have a fixed version.
+func VulFixable(vulns []model.VulnerabilityItem) []model.VulnerabilityItem {
+	fixable := []model.VulnerabilityItem{}
+	for _, v := range vulns {
+		if v.FixedVersion != "" {
+			fixable = append(fixable, v)
+		}
+	}
+	return fixable
+}
+
+// vulnFix is an upgrade of a package fixing vulnerabilities.
+type vulnFix struct {
+	pkg, version string
+	fixes        []string
+	severity     int
+	cves         []string
+}
+
+// PrintVulFixSummary prints the packages of vulns which have fixed versions
+// as a table, the most severe first: the version to upgrade to, fixing all
+// their vulnerabilities which can be, and the CVEs it fixes.
+func PrintVulFixSummary(vulns []model.VulnerabilityItem) {
+	byPkg := map[string]*vulnFix{}
+	var fixes []*vulnFix
+	for _, v := range VulFixable(vulns) {
+		key := v.Package + " " + v.Version
+		f, ok := byPkg[key]
+		if !ok {
+			f = &vulnFix{pkg: v.Package, version: v.Version}
+			byPkg[key] = f
+			fixes = append(fixes, f)
+		}
+		f.fixes = append(f.fixes, v.FixedVersion)
+		f.cves = append(f.cves, v.ID)
+		if v.Severity > f.severity {
+			f.severity = v.Severity
+		}
+	}
+	sort.SliceStable(fixes, func(i, j int) bool {
+		if fixes[i].severity != fixes[j].severity {
+			return fixes[i].severity > fixes[j].severity
+		}
+		return fixes[i].pkg < fixes[j].pkg
+	})
+
+	line := "+--------------------------+------------------+------------------+----------+"
+	fmt.Println(line)
+	fmt.Printf("| % -24s | % -16s | % -16s | % -8s | %s\n", "Package", "Version", "Upgrade to", "Severity", "CVEs")
+	fmt.Println(line)
+	fixed := 0
+	for _, f := range fixes {
+		sort.Slice(f.fixes, func(i, j int) bool { return versionLess(f.fixes[i], f.fixes[j]) })
+		sort.Strings(f.cves)
+		fixed += len(f.cves)
+		fmt.Printf("| % -24s | % -16s | % -16s | % -8s | %s\n",
+			f.pkg, f.version, f.fixes[len(f.fixes)-1], severityNames[f.severity], strings.Join(f.cves, ", "))
+	}
+	fmt.Println(line)
+	fmt.Printf("%d of %d vulnerabilities fixable by upgrading %d packages\n", fixed, len(vulns), len(fixes))
+}
+
+// versionLess compares package versions by their runs of digits, compared
+// as numbers, and of other characters, e.g. "1.2.10-r1" > "1.2.9-r3".
+func versionLess(a, b string) bool {
+	as, bs := versionParts(a), versionParts(b)
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		an, aerr := strconv.Atoi(as[i])
+		bn, berr := strconv.Atoi(bs[i])
+		if aerr == nil && berr == nil {
+			return an < bn
+		}
+		return as[i] < bs[i]
+	}
+	return len(as) < len(bs)
+}
+
+// versionParts splits a version into runs of digits and of other
+// characters.
+func versionParts(v string) []string {
+	var parts []string
+	start := 0
+	for i := 1; i <= len(v); i++ {
+		if i == len(v) || unicode.IsDigit(rune(v[i])) != unicode.IsDigit(rune(v[i-1])) {
+			parts = append(parts, v[start:i])
+			start = i
+		}
+	}
+	return parts
+}
@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/moooofly/harbor-go-client/transport"
+)
+
+func init() {
+	Parser.AddCommand("webhook_replay",
+		"Re-send the payload of a past webhook job.",
+		"Re-send the payload of a past webhook job to the endpoints of its policy (or to the given URL), so downstream consumers can recover from missed events without pushing artifacts again. (Harbor v2.0+, uses v2.0 API)",
+		&webhookReplay)
+}
+
+type webhookReplayRun struct {
+	Project  string `short:"p" long:"project" description:"(REQUIRED) Name or ID of the project." required:"yes"`
+	PolicyID int    `short:"i" long:"policy_id" description:"(REQUIRED) The ID of the webhook policy." required:"yes"`
+	JobID    int    `short:"j" long:"job_id" description:"The ID of the webhook job to replay, list the jobs if not set."`
+	URL      string `short:"u" long:"url" description:"Send the payload to this URL instead of the endpoints of the policy."`
+	DryRun   bool   `long:"dry_run" description:"Print the payload and where it would be sent only."`
+}
+
+var webhookReplay webhookReplayRun
+
+type webhookJob struct {
+	ID           int    `json:"id"`
+	PolicyID     int    `json:"policy_id"`
+	EventType    string `json:"event_type"`
+	NotifyType   string `json:"notify_type"`
+	Status       string `json:"status"`
+	JobDetail    string `json:"job_detail"`
+	CreationTime string `json:"creation_time"`
+}
+
+type webhookTarget struct {
+	Type           string `json:"type"`
+	Address        string `json:"address"`
+	AuthHeader     string `json:"auth_header"`
+	SkipCertVerify bool   `json:"skip_cert_verify"`
+}
+
+type webhookPolicy struct {
+	ID      int              `json:"id"`
+	Name    string           `json:"name"`
+	Targets []*webhookTarget `json:"targets"`
+}
+
+func (x *webhookReplayRun) Execute(args []string) error {
+	return x.run()
+}
+
+func (x *webhookReplayRun) run() error {
+	c := NewClient()
+	webhookURL := c.URL("/api/v2.0/projects") + "/" + url.PathEscape(x.Project) + "/webhook"
+
+	items, err := c.FetchAllPages(webhookURL + "/jobs?policy_id=" + strconv.Itoa(x.PolicyID))
+	if err != nil {
+		return err
+	}
+	var job *webhookJob
+	var jobs []*webhookJob
+	for _, it := range items {
+		var j webhookJob
+		if err := json.Unmarshal(it, &j); err != nil {
+			return err
+		}
+		jobs = append(jobs, &j)
+		if j.ID == x.JobID {
+			job = &j
+		}
+	}
+
+	if x.JobID == 0 {
+		webhookJobsPrint(jobs)
+		return nil
+	}
+	if job == nil {
+		return fmt.Errorf("webhook job %d of policy %d not found", x.JobID, x.PolicyID)
+	}
+	if job.JobDetail == "" {
+		return fmt.Errorf("webhook job %d has no payload recorded", x.JobID)
+	}
+
+	targets := []*webhookTarget{{Type: "http", Address: x.URL}}
+	if x.URL == "" {
+		var policy webhookPolicy
+		if err := c.GetJSON(webhookURL+"/policies/"+strconv.Itoa(x.PolicyID), &policy); err != nil {
+			return err
+		}
+		targets = policy.Targets
+	}
+
+	for _, t := range targets {
+		if t.Type != "" && t.Type != job.NotifyType && x.URL == "" {
+			continue
+		}
+		fmt.Printf("==> POST %s (%s event of job %d)\n", t.Address, job.EventType, job.ID)
+		if x.DryRun {
+			fmt.Println(job.JobDetail)
+			continue
+		}
+		if err := webhookSend(t, job.JobDetail); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// webhookSend posts the payload the way Harbor does, with the auth header
+// of the target. The endpoint is not Harbor, so Request is not used.
+func webhookSend(t *webhookTarget, payload string) error {
+	cfg := transportSettings()
+	cfg.InsecureSkipVerify = t.SkipCertVerify
+	hc := transport.New(nil, cfg)
+	req := transport.NewRequest(cmdContext, hc, transport.POST, t.Address).
+		Set("Content-Type", "application/json").
+		Send(payload)
+	if t.AuthHeader != "" {
+		req = req.Set("Authorization", t.AuthHeader)
+	}
+
+	resp, body, errs := req.End()
+	for _, e := range errs {
+		if e != nil {
+			return e
+		}
+	}
+	fmt.Println("<== Rsp Status:", resp.Status)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("POST %s: unexpected status %s: %s", t.Address, resp.Status, body)
+	}
+	return nil
+}
+
+func webhookJobsPrint(jobs []*webhookJob) {
+	fmt.Println("+------------+----------------------+------------+------------+----------------------------------+")
+	fmt.Printf("| % -10s | % -20s | % -10s | % -10s | % -32s |\n", "JobID", "EventType", "NotifyType", "Status", "CreationTime")
+	fmt.Println("+------------+----------------------+------------+------------+----------------------------------+")
+	for _, j := range jobs {
+		fmt.Printf("| % -10d | % -20s | % -10s | % -10s | % -32s |\n", j.ID, j.EventType, j.NotifyType, j.Status, j.CreationTime)
+	}
+	fmt.Println("+------------+----------------------+------------+------------+----------------------------------+")
+}